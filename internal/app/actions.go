@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// Actions lists the message actions accepted by ApplyAction.
+var Actions = []string{"archive", "trash", "untrash", "star", "unstar", "read", "unread", "spam", "notspam"}
+
+// ErrUnknownAction is returned by ApplyAction for an action not in Actions.
+var ErrUnknownAction = errors.New("unknown action")
+
+// ApplyAction applies a single-message action on the provider. Read state is
+// also updated in the local store first so the change shows immediately, as
+// is the star when the message is stored under accountID. Trash and spam
+// moves also update the local labels of a stored message, so trashed mail
+// stays in the store under TRASH until it is deleted for good. "delete" is
+// accepted as an alias for "trash".
+func ApplyAction(ctx context.Context, p provider.EmailProvider, s store.Store, accountID, id, action string) error {
+	switch action {
+	case "archive":
+		return p.ModifyLabels(ctx, id, nil, []string{domain.LabelInbox})
+	case "trash", "delete":
+		if err := p.TrashMessage(ctx, id); err != nil {
+			return err
+		}
+		add, remove := TrashLabels(true)
+		return updateLocalLabels(ctx, s, accountID, id, add, remove)
+	case "untrash":
+		add, remove := TrashLabels(false)
+		if err := p.ModifyLabels(ctx, id, add, remove); err != nil {
+			return err
+		}
+		return updateLocalLabels(ctx, s, accountID, id, add, remove)
+	case "star", "unstar":
+		starred := action == "star"
+		// Mail that was never synced has no local row to update.
+		if _, err := s.GetEmail(ctx, id, accountID); err == nil {
+			if err := s.SetEmailStarred(ctx, id, accountID, starred); err != nil {
+				return fmt.Errorf("failed to update local star: %w", err)
+			}
+		}
+		if starred {
+			return p.ModifyLabels(ctx, id, []string{domain.LabelStarred}, nil)
+		}
+		return p.ModifyLabels(ctx, id, nil, []string{domain.LabelStarred})
+	case "read", "unread":
+		read := action == "read"
+		if err := s.SetEmailRead(ctx, id, accountID, read); err != nil {
+			return fmt.Errorf("failed to update local read state: %w", err)
+		}
+		return p.MarkRead(ctx, id, read)
+	case "spam", "notspam":
+		add, remove := SpamLabels(action == "spam")
+		if err := p.ModifyLabels(ctx, id, add, remove); err != nil {
+			return err
+		}
+		return updateLocalLabels(ctx, s, accountID, id, add, remove)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownAction, action)
+	}
+}
+
+// SpamLabels returns the label changes that report a message as spam, or
+// with spam false, that move it from Spam back to the inbox.
+func SpamLabels(spam bool) (add, remove []string) {
+	if spam {
+		return []string{domain.LabelSpam}, []string{domain.LabelInbox}
+	}
+	return []string{domain.LabelInbox}, []string{domain.LabelSpam}
+}
+
+// TrashLabels returns the label changes that mirror moving a message to
+// Trash, or with trash false, that restore it from Trash to the inbox.
+func TrashLabels(trash bool) (add, remove []string) {
+	if trash {
+		return []string{domain.LabelTrash}, []string{domain.LabelInbox}
+	}
+	return []string{domain.LabelInbox}, []string{domain.LabelTrash}
+}
+
+// folderLabels are the mutually exclusive system labels that act as folders.
+var folderLabels = []string{domain.LabelInbox, domain.LabelSpam, domain.LabelTrash}
+
+// MoveLabelChanges computes the label changes that move a message with the
+// current labels into target: every folder label other than target is removed
+// and target is added if missing. result is the message's labels afterwards.
+func MoveLabelChanges(current []string, target string) (add, remove, result []string) {
+	hasTarget := false
+	for _, l := range current {
+		if l == target {
+			hasTarget = true
+			result = append(result, l)
+			continue
+		}
+		if slices.Contains(folderLabels, l) {
+			remove = append(remove, l)
+			continue
+		}
+		result = append(result, l)
+	}
+	if !hasTarget {
+		add = []string{target}
+		result = append(result, target)
+	}
+	return add, remove, result
+}
+
+// MoveToLabel moves a message stored under accountID into the target label
+// in a single provider change and mirrors the result in the local store.
+func MoveToLabel(ctx context.Context, p provider.EmailProvider, s store.Store, accountID, id, target string) error {
+	email, err := s.GetEmail(ctx, id, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to get email %s: %w", id, err)
+	}
+	add, remove, result := MoveLabelChanges(email.Labels, target)
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+	if err := p.ModifyLabels(ctx, id, add, remove); err != nil {
+		return err
+	}
+	if err := s.SetEmailLabels(ctx, id, accountID, result); err != nil {
+		return fmt.Errorf("failed to update local labels: %w", err)
+	}
+	return nil
+}
+
+// updateLocalLabels mirrors a label change in the local store. Messages
+// that were never synced are skipped.
+func updateLocalLabels(ctx context.Context, s store.Store, accountID, id string, add, remove []string) error {
+	email, err := s.GetEmail(ctx, id, accountID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get local email: %w", err)
+	}
+	var labels []string
+	for _, l := range email.Labels {
+		if !slices.Contains(remove, l) && !slices.Contains(add, l) {
+			labels = append(labels, l)
+		}
+	}
+	labels = append(labels, add...)
+	if err := s.SetEmailLabels(ctx, id, accountID, labels); err != nil {
+		return fmt.Errorf("failed to update local labels: %w", err)
+	}
+	return nil
+}
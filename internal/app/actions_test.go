@@ -0,0 +1,260 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// actionProvider records the provider calls made by ApplyAction.
+type actionProvider struct {
+	provider.EmailProvider
+	calls []string
+}
+
+func (a *actionProvider) ModifyLabels(_ context.Context, id string, add, remove []string) error {
+	a.calls = append(a.calls, "modify "+id+" +"+strings.Join(add, ",")+" -"+strings.Join(remove, ","))
+	return nil
+}
+
+func (a *actionProvider) TrashMessage(_ context.Context, id string) error {
+	a.calls = append(a.calls, "trash "+id)
+	return nil
+}
+
+func (a *actionProvider) MarkRead(_ context.Context, id string, read bool) error {
+	if read {
+		a.calls = append(a.calls, "read "+id)
+	} else {
+		a.calls = append(a.calls, "unread "+id)
+	}
+	return nil
+}
+
+func TestApplyAction(t *testing.T) {
+	local := []domain.Email{{ID: "m1", ThreadID: "t1", IsRead: true, Labels: []string{domain.LabelInbox}}}
+	_, db := newTestService(t, nil, local)
+	ctx := context.Background()
+
+	tests := []struct {
+		action string
+		want   string
+	}{
+		{"archive", "modify m1 + -INBOX"},
+		{"trash", "trash m1"},
+		{"delete", "trash m1"},
+		{"untrash", "modify m1 +INBOX -TRASH"},
+		{"star", "modify m1 +STARRED -"},
+		{"unstar", "modify m1 + -STARRED"},
+		{"unread", "unread m1"},
+	}
+	for _, tt := range tests {
+		p := &actionProvider{}
+		if err := ApplyAction(ctx, p, db, "acc-1", "m1", tt.action); err != nil {
+			t.Fatalf("ApplyAction(%q) error: %v", tt.action, err)
+		}
+		if !slices.Equal(p.calls, []string{tt.want}) {
+			t.Errorf("ApplyAction(%q) calls = %v, want [%s]", tt.action, p.calls, tt.want)
+		}
+	}
+
+	got, err := db.GetEmail(ctx, "m1", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if got.IsRead {
+		t.Error("unread should update the local read state")
+	}
+
+	if err := ApplyAction(ctx, &actionProvider{}, db, "acc-1", "m1", "explode"); !errors.Is(err, ErrUnknownAction) {
+		t.Errorf("ApplyAction(explode) error = %v, want ErrUnknownAction", err)
+	}
+}
+
+func TestApplyAction_StarUpdatesLocal(t *testing.T) {
+	local := []domain.Email{{ID: "m1", ThreadID: "t1", Labels: []string{domain.LabelInbox}}}
+	_, db := newTestService(t, nil, local)
+	ctx := context.Background()
+
+	for _, action := range []string{"star", "unstar"} {
+		if err := ApplyAction(ctx, &actionProvider{}, db, "acc-1", "m1", action); err != nil {
+			t.Fatalf("ApplyAction(%q) error: %v", action, err)
+		}
+		got, err := db.GetEmail(ctx, "m1", "acc-1")
+		if err != nil {
+			t.Fatalf("GetEmail() error: %v", err)
+		}
+		want := action == "star"
+		if got.IsStarred != want || got.HasLabel(domain.LabelStarred) != want {
+			t.Errorf("after %s: starred %v, labels %v; want starred %v", action, got.IsStarred, got.Labels, want)
+		}
+	}
+
+	// Mail that was never synced is still starred remotely.
+	p := &actionProvider{}
+	if err := ApplyAction(ctx, p, db, "acc-1", "remote-only", "star"); err != nil {
+		t.Fatalf("ApplyAction(remote-only) error: %v", err)
+	}
+	if len(p.calls) != 1 {
+		t.Errorf("calls = %v, want the remote star", p.calls)
+	}
+}
+
+func TestApplyAction_Spam(t *testing.T) {
+	local := []domain.Email{{ID: "m1", ThreadID: "t1", Labels: []string{domain.LabelInbox, "Label_work"}}}
+	_, db := newTestService(t, nil, local)
+	ctx := context.Background()
+
+	tests := []struct {
+		action     string
+		wantCall   string
+		wantLabels []string
+	}{
+		{"spam", "modify m1 +SPAM -INBOX", []string{"Label_work", domain.LabelSpam}},
+		{"notspam", "modify m1 +INBOX -SPAM", []string{"Label_work", domain.LabelInbox}},
+	}
+	for _, tt := range tests {
+		p := &actionProvider{}
+		if err := ApplyAction(ctx, p, db, "acc-1", "m1", tt.action); err != nil {
+			t.Fatalf("ApplyAction(%q) error: %v", tt.action, err)
+		}
+		if !slices.Equal(p.calls, []string{tt.wantCall}) {
+			t.Errorf("ApplyAction(%q) calls = %v, want [%s]", tt.action, p.calls, tt.wantCall)
+		}
+
+		got, err := db.GetEmail(ctx, "m1", "acc-1")
+		if err != nil {
+			t.Fatalf("GetEmail() error: %v", err)
+		}
+		slices.Sort(got.Labels)
+		want := slices.Sorted(slices.Values(tt.wantLabels))
+		if !slices.Equal(got.Labels, want) {
+			t.Errorf("after %s labels = %v, want %v", tt.action, got.Labels, want)
+		}
+	}
+}
+
+// failingGetStore fails every GetEmail with err.
+type failingGetStore struct {
+	store.Store
+	err error
+}
+
+func (f failingGetStore) GetEmail(context.Context, string, string) (*domain.Email, error) {
+	return nil, f.err
+}
+
+func TestApplyAction_LocalLabelErrors(t *testing.T) {
+	_, db := newTestService(t, nil, nil)
+	ctx := context.Background()
+
+	// Mail that was never synced is skipped.
+	if err := ApplyAction(ctx, &actionProvider{}, db, "acc-1", "unsynced", "trash"); err != nil {
+		t.Errorf("ApplyAction(trash) of unsynced mail error: %v", err)
+	}
+
+	boom := errors.New("disk I/O error")
+	s := failingGetStore{Store: db, err: boom}
+	if err := ApplyAction(ctx, &actionProvider{}, s, "acc-1", "m1", "trash"); !errors.Is(err, boom) {
+		t.Errorf("ApplyAction(trash) error = %v, want the store error", err)
+	}
+}
+
+func TestApplyAction_TrashKeepsMailUnderTrash(t *testing.T) {
+	local := []domain.Email{{ID: "m1", ThreadID: "t1", Labels: []string{domain.LabelInbox, "Label_work"}}}
+	_, db := newTestService(t, nil, local)
+	ctx := context.Background()
+
+	tests := []struct {
+		action     string
+		wantLabels []string
+	}{
+		{"trash", []string{"Label_work", domain.LabelTrash}},
+		{"untrash", []string{"Label_work", domain.LabelInbox}},
+	}
+	for _, tt := range tests {
+		if err := ApplyAction(ctx, &actionProvider{}, db, "acc-1", "m1", tt.action); err != nil {
+			t.Fatalf("ApplyAction(%q) error: %v", tt.action, err)
+		}
+
+		got, err := db.GetEmail(ctx, "m1", "acc-1")
+		if err != nil {
+			t.Fatalf("after %s: GetEmail() error: %v", tt.action, err)
+		}
+		slices.Sort(got.Labels)
+		want := slices.Sorted(slices.Values(tt.wantLabels))
+		if !slices.Equal(got.Labels, want) {
+			t.Errorf("after %s labels = %v, want %v", tt.action, got.Labels, want)
+		}
+
+		trashed, err := db.ListEmails(ctx, store.ListEmailOptions{AccountID: "acc-1", LabelID: domain.LabelTrash})
+		if err != nil {
+			t.Fatalf("ListEmails(TRASH) error: %v", err)
+		}
+		if inTrash := len(trashed) == 1; inTrash != (tt.action == "trash") {
+			t.Errorf("after %s: TRASH lists %d messages", tt.action, len(trashed))
+		}
+	}
+}
+
+func TestMoveLabelChanges(t *testing.T) {
+	tests := []struct {
+		name                string
+		current             []string
+		target              string
+		add, remove, result []string
+	}{
+		{
+			name:    "inbox to user label",
+			current: []string{"INBOX", "UNREAD"},
+			target:  "Label_1",
+			add:     []string{"Label_1"},
+			remove:  []string{"INBOX"},
+			result:  []string{"UNREAD", "Label_1"},
+		},
+		{
+			name:    "keeps other user labels",
+			current: []string{"INBOX", "Label_2", "IMPORTANT"},
+			target:  "Label_1",
+			add:     []string{"Label_1"},
+			remove:  []string{"INBOX"},
+			result:  []string{"Label_2", "IMPORTANT", "Label_1"},
+		},
+		{
+			name:    "spam back to inbox",
+			current: []string{"SPAM", "STARRED"},
+			target:  "INBOX",
+			add:     []string{"INBOX"},
+			remove:  []string{"SPAM"},
+			result:  []string{"STARRED", "INBOX"},
+		},
+		{
+			name:    "already in target",
+			current: []string{"INBOX", "TRASH"},
+			target:  "TRASH",
+			remove:  []string{"INBOX"},
+			result:  []string{"TRASH"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			add, remove, result := MoveLabelChanges(tt.current, tt.target)
+			if !reflect.DeepEqual(add, tt.add) {
+				t.Errorf("add = %v, want %v", add, tt.add)
+			}
+			if !reflect.DeepEqual(remove, tt.remove) {
+				t.Errorf("remove = %v, want %v", remove, tt.remove)
+			}
+			if !reflect.DeepEqual(result, tt.result) {
+				t.Errorf("result = %v, want %v", result, tt.result)
+			}
+		})
+	}
+}
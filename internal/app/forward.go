@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider"
+)
+
+// ForwardAttachments downloads the files attached to message msgID so a
+// forward of it can send them on. The local store keeps no attachments, so
+// the message is fetched from the provider to list them.
+func ForwardAttachments(ctx context.Context, p provider.EmailProvider, msgID string) ([]domain.Attachment, error) {
+	msg, err := p.GetMessage(ctx, msgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get message %s: %w", msgID, err)
+	}
+
+	var attachments []domain.Attachment
+	for _, a := range msg.Attachments {
+		if a.ID == "" {
+			continue
+		}
+		data, err := p.GetAttachment(ctx, msgID, a.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get attachment %s: %w", a.Filename, err)
+		}
+		if data == nil {
+			data = []byte{}
+		}
+		a.Data = data
+		a.Size = int64(len(data))
+		if a.MIMEType == "" {
+			a.MIMEType = "application/octet-stream"
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, nil
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/rfc822"
+)
+
+// attachmentProvider serves a message with attachments and their content.
+type attachmentProvider struct {
+	provider.EmailProvider
+	msg     domain.Email
+	content map[string]string
+}
+
+func (a *attachmentProvider) GetMessage(context.Context, string) (*domain.Email, error) {
+	msg := a.msg
+	return &msg, nil
+}
+
+func (a *attachmentProvider) GetAttachment(_ context.Context, _, attachmentID string) ([]byte, error) {
+	return []byte(a.content[attachmentID]), nil
+}
+
+func TestForwardAttachments_CarriedOntoBuiltEmail(t *testing.T) {
+	p := &attachmentProvider{
+		msg: domain.Email{ID: "m1", Attachments: []domain.Attachment{
+			{ID: "a1", Filename: "report.pdf", MIMEType: "application/pdf", Size: 999},
+			{ID: "", Filename: "inline.txt", MIMEType: "text/plain"},
+			{ID: "a2", Filename: "notes.bin"},
+		}},
+		content: map[string]string{"a1": "%PDF-1.7", "a2": "\x00\x01"},
+	}
+
+	attachments, err := ForwardAttachments(context.Background(), p, "m1")
+	if err != nil {
+		t.Fatalf("ForwardAttachments() error: %v", err)
+	}
+	if len(attachments) != 2 {
+		t.Fatalf("ForwardAttachments() = %d attachments, want 2", len(attachments))
+	}
+	if a := attachments[0]; a.Filename != "report.pdf" || a.MIMEType != "application/pdf" || a.Size != 8 || string(a.Data) != "%PDF-1.7" {
+		t.Errorf("attachment 0 = %+v", a)
+	}
+	if a := attachments[1]; a.MIMEType != "application/octet-stream" || a.Size != 2 {
+		t.Errorf("attachment 1 = %+v, want an octet-stream of 2 bytes", a)
+	}
+
+	fwd := &domain.Email{
+		From:        domain.Address{Email: "me@example.com"},
+		To:          []domain.Address{{Email: "you@example.com"}},
+		Subject:     "Fwd: Report",
+		Body:        "See below.",
+		Attachments: attachments,
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(rfc822.Build(fwd)))
+	if err != nil {
+		t.Fatalf("ReadMessage() error: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q (%v), want multipart/mixed", msg.Header.Get("Content-Type"), err)
+	}
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	var names, types []string
+	for {
+		part, err := r.NextPart()
+		if err != nil {
+			break
+		}
+		if part.FileName() == "" {
+			continue
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		names = append(names, part.FileName())
+		types = append(types, partType)
+	}
+	if strings.Join(names, ",") != "report.pdf,notes.bin" {
+		t.Errorf("attached files = %v, want report.pdf and notes.bin", names)
+	}
+	if strings.Join(types, ",") != "application/pdf,application/octet-stream" {
+		t.Errorf("attachment types = %v", types)
+	}
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// ErrAlreadySent is returned by OutboxService.Cancel when the message has
+// already left the outbox.
+var ErrAlreadySent = errors.New("message already sent or cancelled")
+
+// ErrQueued is wrapped into a send error when the message could not be sent
+// but was kept in the outbox, so SendDue can retry it later.
+var ErrQueued = errors.New("saved to the outbox to retry later")
+
+// OutboxService holds outgoing mail for an undo-send window before handing
+// it to the provider, and keeps mail that failed to send for a retry.
+type OutboxService struct {
+	store     store.Store
+	provider  provider.EmailProvider
+	accountID string
+}
+
+// NewOutboxService creates an OutboxService for the given account.
+func NewOutboxService(s store.Store, p provider.EmailProvider, accountID string) *OutboxService {
+	return &OutboxService{store: s, provider: p, accountID: accountID}
+}
+
+// Queue holds email in the outbox until delay has elapsed and returns its
+// outbox ID.
+func (o *OutboxService) Queue(ctx context.Context, email *domain.Email, delay time.Duration) (int64, error) {
+	id, err := o.store.EnqueueOutbox(ctx, o.accountID, email, time.Now().Add(delay))
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue message: %w", err)
+	}
+	return id, nil
+}
+
+// SendNow sends email right away. If the provider fails, for instance while
+// offline, the message is saved to the outbox as due immediately and the
+// returned error wraps both the send failure and ErrQueued.
+func (o *OutboxService) SendNow(ctx context.Context, email *domain.Email) error {
+	err := o.provider.SendMessage(ctx, email)
+	if err == nil {
+		o.recordSent(ctx, email)
+		return nil
+	}
+	if _, qerr := o.store.EnqueueOutbox(ctx, o.accountID, email, time.Now()); qerr != nil {
+		return fmt.Errorf("failed to send message and save it to the outbox: %w", errors.Join(err, qerr))
+	}
+	return fmt.Errorf("failed to send message: %w (%w)", err, ErrQueued)
+}
+
+// Cancel removes a queued message before it is sent.
+func (o *OutboxService) Cancel(ctx context.Context, id int64) error {
+	if err := o.store.DeleteOutbox(ctx, id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrAlreadySent
+		}
+		return fmt.Errorf("failed to cancel message: %w", err)
+	}
+	return nil
+}
+
+// Send sends one queued message immediately, regardless of its send time.
+// It returns ErrAlreadySent if the message was cancelled or already sent.
+func (o *OutboxService) Send(ctx context.Context, id int64) error {
+	items, err := o.store.ListOutbox(ctx, o.accountID)
+	if err != nil {
+		return fmt.Errorf("failed to list outbox: %w", err)
+	}
+	for _, item := range items {
+		if item.ID == id {
+			return o.send(ctx, item)
+		}
+	}
+	return ErrAlreadySent
+}
+
+// SendDue sends every queued message whose send time is at or before now
+// and returns how many were sent. Each message is removed from the outbox
+// before sending, so a concurrent Cancel either wins outright or finds the
+// message gone. A message whose send fails is queued again for retry and
+// the remaining messages are still tried.
+func (o *OutboxService) SendDue(ctx context.Context, now time.Time) (int, error) {
+	items, err := o.store.ListOutbox(ctx, o.accountID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list outbox: %w", err)
+	}
+
+	sent := 0
+	var errs []error
+	for _, item := range items {
+		if item.SendAt.After(now) {
+			continue
+		}
+		if err := o.send(ctx, item); err != nil {
+			if errors.Is(err, ErrAlreadySent) {
+				continue // cancelled meanwhile
+			}
+			errs = append(errs, err)
+			continue
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+// send claims item by removing it from the outbox and hands it to the
+// provider, queueing it again if the send fails.
+func (o *OutboxService) send(ctx context.Context, item store.OutboxItem) error {
+	if err := o.store.DeleteOutbox(ctx, item.ID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrAlreadySent
+		}
+		return fmt.Errorf("failed to claim outbox message %d: %w", item.ID, err)
+	}
+	if err := o.provider.SendMessage(ctx, &item.Email); err != nil {
+		if _, qerr := o.store.EnqueueOutbox(ctx, o.accountID, &item.Email, item.SendAt); qerr != nil {
+			return fmt.Errorf("failed to send message and requeue it: %w", errors.Join(err, qerr))
+		}
+		return fmt.Errorf("failed to send message: %w (%w)", err, ErrQueued)
+	}
+	o.recordSent(ctx, &item.Email)
+	return nil
+}
+
+// recordSent stores a sent message locally, read and in SENT, so it is
+// listed before the next sync fetches it. A message the provider gave no
+// ID is left to the sync, as is any failure here: the send itself worked.
+func (o *OutboxService) recordSent(ctx context.Context, email *domain.Email) {
+	if email.ID == "" {
+		return
+	}
+	sent := *email
+	sent.IsRead = true
+	if !slices.Contains(sent.Labels, domain.LabelSent) {
+		sent.Labels = append(slices.Clone(sent.Labels), domain.LabelSent)
+	}
+	if sent.From.Email == "" {
+		if acct, err := o.store.GetAccount(ctx, o.accountID); err == nil {
+			sent.From = domain.Address{Name: acct.DisplayName, Email: acct.Email}
+		}
+	}
+	_ = o.store.UpsertEmail(ctx, &sent, o.accountID)
+}
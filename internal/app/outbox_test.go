@@ -0,0 +1,186 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// sendingProvider records sent messages and can be made to fail.
+type sendingProvider struct {
+	fakeProvider
+	sent []string
+	err  error
+	// failSubject makes only the message with this subject fail.
+	failSubject string
+}
+
+func (p *sendingProvider) SendMessage(_ context.Context, email *domain.Email) error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.failSubject != "" && email.Subject == p.failSubject {
+		return errors.New("rejected")
+	}
+	p.sent = append(p.sent, email.Subject)
+	email.ID = fmt.Sprintf("sent-%d", len(p.sent))
+	email.ThreadID = email.ID
+	return nil
+}
+
+func newTestOutbox(t *testing.T) (*OutboxService, *sendingProvider) {
+	t.Helper()
+	_, db := newTestService(t, nil, nil)
+	p := &sendingProvider{}
+	return NewOutboxService(db, p, "acc-1"), p
+}
+
+func TestOutbox_CancelWithinWindow(t *testing.T) {
+	o, p := newTestOutbox(t)
+	ctx := context.Background()
+
+	id, err := o.Queue(ctx, &domain.Email{Subject: "oops"}, 10*time.Second)
+	if err != nil {
+		t.Fatalf("Queue() error: %v", err)
+	}
+	if n, err := o.SendDue(ctx, time.Now()); err != nil || n != 0 {
+		t.Fatalf("SendDue() before window = %d, %v; want 0, nil", n, err)
+	}
+	if err := o.Cancel(ctx, id); err != nil {
+		t.Fatalf("Cancel() error: %v", err)
+	}
+	if n, err := o.SendDue(ctx, time.Now().Add(time.Minute)); err != nil || n != 0 {
+		t.Errorf("SendDue() after cancel = %d, %v; want 0, nil", n, err)
+	}
+	if len(p.sent) != 0 {
+		t.Errorf("sent = %v, want nothing", p.sent)
+	}
+}
+
+func TestOutbox_SendAfterWindow(t *testing.T) {
+	o, p := newTestOutbox(t)
+	ctx := context.Background()
+
+	id, err := o.Queue(ctx, &domain.Email{Subject: "hello"}, 10*time.Second)
+	if err != nil {
+		t.Fatalf("Queue() error: %v", err)
+	}
+	if n, err := o.SendDue(ctx, time.Now().Add(11*time.Second)); err != nil || n != 1 {
+		t.Fatalf("SendDue() = %d, %v; want 1, nil", n, err)
+	}
+	if len(p.sent) != 1 || p.sent[0] != "hello" {
+		t.Errorf("sent = %v, want [hello]", p.sent)
+	}
+	if err := o.Cancel(ctx, id); !errors.Is(err, ErrAlreadySent) {
+		t.Errorf("Cancel() after send error = %v, want ErrAlreadySent", err)
+	}
+}
+
+func TestOutbox_FailedSendIsRequeued(t *testing.T) {
+	o, p := newTestOutbox(t)
+	ctx := context.Background()
+	p.err = errors.New("network down")
+
+	if _, err := o.Queue(ctx, &domain.Email{Subject: "retry"}, 0); err != nil {
+		t.Fatalf("Queue() error: %v", err)
+	}
+	if _, err := o.SendDue(ctx, time.Now()); err == nil {
+		t.Fatal("SendDue() should report the send failure")
+	}
+
+	p.err = nil
+	if n, err := o.SendDue(ctx, time.Now()); err != nil || n != 1 {
+		t.Errorf("SendDue() retry = %d, %v; want 1, nil", n, err)
+	}
+}
+
+func TestOutbox_SendNowFailureIsQueuedUntilFlushed(t *testing.T) {
+	o, p := newTestOutbox(t)
+	ctx := context.Background()
+	p.err = errors.New("network down")
+
+	err := o.SendNow(ctx, &domain.Email{Subject: "offline"})
+	if !errors.Is(err, ErrQueued) {
+		t.Fatalf("SendNow() error = %v, want ErrQueued", err)
+	}
+	items, err := o.store.ListOutbox(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("ListOutbox() error: %v", err)
+	}
+	if len(items) != 1 || items[0].Email.Subject != "offline" {
+		t.Fatalf("outbox = %+v, want the failed message", items)
+	}
+
+	p.err = nil
+	if n, err := o.SendDue(ctx, time.Now()); err != nil || n != 1 {
+		t.Fatalf("SendDue() = %d, %v; want 1, nil", n, err)
+	}
+	if items, _ := o.store.ListOutbox(ctx, "acc-1"); len(items) != 0 {
+		t.Errorf("outbox after flush = %+v, want empty", items)
+	}
+	if len(p.sent) != 1 || p.sent[0] != "offline" {
+		t.Errorf("sent = %v, want [offline]", p.sent)
+	}
+}
+
+func TestOutbox_SendDueContinuesPastFailure(t *testing.T) {
+	o, p := newTestOutbox(t)
+	ctx := context.Background()
+
+	for _, subject := range []string{"bad", "good"} {
+		if _, err := o.Queue(ctx, &domain.Email{Subject: subject}, 0); err != nil {
+			t.Fatalf("Queue() error: %v", err)
+		}
+	}
+	p.failSubject = "bad"
+	n, err := o.SendDue(ctx, time.Now())
+	if n != 1 || !errors.Is(err, ErrQueued) {
+		t.Fatalf("SendDue() = %d, %v; want 1 sent and ErrQueued", n, err)
+	}
+	items, _ := o.store.ListOutbox(ctx, "acc-1")
+	if len(items) != 1 || items[0].Email.Subject != "bad" {
+		t.Errorf("outbox = %+v, want only the failing message", items)
+	}
+}
+
+func TestOutbox_SentMailStoredLocally(t *testing.T) {
+	_, db := newTestService(t, nil, nil)
+	o := NewOutboxService(db, &sendingProvider{}, "acc-1")
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Second)
+	if err := o.SendNow(ctx, &domain.Email{Subject: "now", Body: "hi", To: []domain.Address{{Email: "you@example.com"}}, Date: now}); err != nil {
+		t.Fatalf("SendNow() error: %v", err)
+	}
+	if _, err := o.Queue(ctx, &domain.Email{Subject: "later", Date: now}, 0); err != nil {
+		t.Fatalf("Queue() error: %v", err)
+	}
+	if n, err := o.SendDue(ctx, time.Now().Add(time.Second)); err != nil || n != 1 {
+		t.Fatalf("SendDue() = %d, %v; want 1, nil", n, err)
+	}
+
+	sent, err := db.ListEmails(ctx, store.ListEmailOptions{AccountID: "acc-1", LabelID: domain.LabelSent})
+	if err != nil {
+		t.Fatalf("ListEmails(SENT) error: %v", err)
+	}
+	if len(sent) != 2 {
+		t.Fatalf("ListEmails(SENT) = %+v, want both sent messages", sent)
+	}
+	for _, e := range sent {
+		if !e.IsRead || e.From.Email != "me@example.com" {
+			t.Errorf("sent %q read/from = %v/%q, want read and from the account", e.Subject, e.IsRead, e.From.Email)
+		}
+	}
+	got, err := db.GetEmail(ctx, "sent-1", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail(sent-1) error: %v", err)
+	}
+	if got.Subject != "now" || got.ThreadID != "sent-1" || got.Body != "hi" {
+		t.Errorf("GetEmail(sent-1) = %+v, want the message sent now", got)
+	}
+}
@@ -2,94 +2,330 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"time"
 
+	"github.com/lu-zhengda/termail/internal/domain"
 	"github.com/lu-zhengda/termail/internal/provider"
 	"github.com/lu-zhengda/termail/internal/store"
 )
 
+// messageBatchSize is how many messages IncrementalSync fetches from the
+// provider and stores per round trip.
+const messageBatchSize = 50
+
+// defaultFallbackCount is how many messages IncrementalSync fetches when it
+// has to fall back to a full sync, unless SetFallbackCount says otherwise.
+const defaultFallbackCount = 500
+
 // SyncService orchestrates synchronization between an email provider and the
 // local store for a single account.
 type SyncService struct {
 	store     store.Store
 	provider  provider.EmailProvider
 	accountID string
+
+	progress      func(fetched, total int)
+	fallbackCount int
+	maxBodyBytes  int
+	threadByRefs  bool
 }
 
 // NewSyncService creates a SyncService that syncs the given account between
 // the provider and the local store.
 func NewSyncService(s store.Store, p provider.EmailProvider, accountID string) *SyncService {
-	return &SyncService{store: s, provider: p, accountID: accountID}
+	return &SyncService{store: s, provider: p, accountID: accountID, fallbackCount: defaultFallbackCount}
+}
+
+// SetFallbackCount sets how many messages IncrementalSync fetches when it
+// falls back to a full sync. Values below one keep the default of 500.
+func (s *SyncService) SetFallbackCount(n int) {
+	if n < 1 {
+		n = defaultFallbackCount
+	}
+	s.fallbackCount = n
+}
+
+// OnProgress registers fn to be called after each page of a full sync with
+// the number of messages stored so far and the most the sync will fetch.
+func (s *SyncService) OnProgress(fn func(fetched, total int)) {
+	s.progress = fn
+}
+
+// SetMaxBodyBytes caps how many bytes of each message's text and HTML body
+// are stored; see domain.Email.TruncateBody. Zero, the default, stores
+// bodies whole.
+func (s *SyncService) SetMaxBodyBytes(n int) {
+	s.maxBodyBytes = n
+}
+
+// SetThreadByReferences makes each stored batch of messages without a
+// provider thread ID be threaded by their reply headers; see
+// store.Store.ReconstructThreads.
+func (s *SyncService) SetThreadByReferences(on bool) {
+	s.threadByRefs = on
+}
+
+// storeMessages truncates msgs' bodies to the configured limit, upserts
+// them, and threads them by reference when that is enabled.
+func (s *SyncService) storeMessages(ctx context.Context, msgs []domain.Email) error {
+	for i := range msgs {
+		msgs[i].TruncateBody(s.maxBodyBytes)
+	}
+	if err := s.store.UpsertEmails(ctx, msgs, s.accountID); err != nil {
+		return err
+	}
+	if s.threadByRefs {
+		if _, err := s.store.ReconstructThreads(ctx, s.accountID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // InitialSync performs a full initial sync, fetching up to count messages from
 // the provider and persisting them locally along with all labels.
 func (s *SyncService) InitialSync(ctx context.Context, count int) error {
+	_, err := s.FullSync(ctx, count, nil)
+	return err
+}
+
+// ErrIncompleteSync is returned by PruneCandidates when the full sync stopped
+// at its message limit, so absent messages may simply not have been fetched.
+var ErrIncompleteSync = errors.New("full sync did not list every message in scope")
+
+// FullSyncResult summarizes a FullSync and records what it saw remotely so
+// local messages missing from the server can be pruned.
+type FullSyncResult struct {
+	// Fetched is the number of messages stored.
+	Fetched int
+	// Complete reports whether every remote message in scope was listed,
+	// rather than the sync stopping at its count limit.
+	Complete bool
+	// LabelIDs is the label scope that was synced; empty means all mail.
+	LabelIDs []string
+
+	seen  map[string]bool
+	total int
+}
+
+// FullSync re-fetches up to count messages for each label in labelIDs, or
+// from all mail when labelIDs is empty, along with all labels. The account's
+// sync state is cleared first, so a sync that fails or is cancelled part way
+// leaves the next IncrementalSync to start over with a full sync.
+func (s *SyncService) FullSync(ctx context.Context, count int, labelIDs []string) (*FullSyncResult, error) {
+	started := time.Now()
+	if err := s.store.SetSyncState(ctx, &store.SyncState{AccountID: s.accountID}); err != nil {
+		return nil, fmt.Errorf("failed to clear sync state: %w", err)
+	}
+
 	// Sync labels first.
 	labels, err := s.provider.ListLabels(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to list labels: %w", err)
+		return nil, fmt.Errorf("failed to list labels: %w", err)
 	}
 	for i := range labels {
 		labels[i].AccountID = s.accountID
 		if err := s.store.UpsertLabel(ctx, &labels[i]); err != nil {
-			return fmt.Errorf("failed to upsert label %s: %w", labels[i].ID, err)
+			return nil, fmt.Errorf("failed to upsert label %s: %w", labels[i].ID, err)
 		}
 	}
 	log.Printf("[sync] synced %d labels for account %s", len(labels), s.accountID)
 
-	// Fetch messages in pages.
+	res := &FullSyncResult{Complete: true, LabelIDs: labelIDs, seen: make(map[string]bool)}
+
+	// Gmail treats several label IDs as an AND filter, so each label in
+	// scope is listed separately.
+	scopes := [][]string{nil}
+	if len(labelIDs) > 0 {
+		scopes = scopes[:0]
+		for _, id := range labelIDs {
+			scopes = append(scopes, []string{id})
+		}
+	}
+	res.total = count * len(scopes)
+	for _, scope := range scopes {
+		if err := s.fetchMessages(ctx, count, scope, res); err != nil {
+			return nil, err
+		}
+	}
+
+	// Save sync state.
+	if err := s.store.SetSyncState(ctx, &store.SyncState{
+		AccountID: s.accountID,
+		HistoryID: 0,
+		LastSync:  started.Unix(),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save sync state: %w", err)
+	}
+
+	s.refreshProfile(ctx)
+
+	log.Printf("[sync] initial sync complete: %d messages for account %s", res.Fetched, s.accountID)
+	return res, nil
+}
+
+// refreshProfile records the provider's mailbox size on the account so it
+// can be shown offline. The size is informational, so failures only log.
+func (s *SyncService) refreshProfile(ctx context.Context) {
+	profile, err := s.provider.GetProfile(ctx)
+	if err != nil {
+		log.Printf("[sync] failed to get profile for account %s: %v", s.accountID, err)
+		return
+	}
+	if err := s.store.SetAccountMessagesTotal(ctx, s.accountID, profile.MessagesTotal); err != nil {
+		log.Printf("[sync] failed to save mailbox size for account %s: %v", s.accountID, err)
+	}
+}
+
+// fetchMessages pages through up to count messages matching labelIDs and
+// stores them, recording each ID in res.
+func (s *SyncService) fetchMessages(ctx context.Context, count int, labelIDs []string, res *FullSyncResult) error {
 	const batchSize = 100
 	var (
 		pageToken string
 		fetched   int
 	)
-	for fetched < count {
-		remaining := count - fetched
-		limit := min(batchSize, remaining)
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("sync stopped after %d messages: %w", fetched, err)
+		}
+		if fetched >= count {
+			// Stopped at the limit; there may be more remote messages.
+			res.Complete = false
+			return nil
+		}
+		limit := min(batchSize, count-fetched)
 
 		msgs, nextToken, err := s.provider.ListMessages(ctx, provider.ListOptions{
 			PageToken:  pageToken,
 			MaxResults: limit,
+			LabelIDs:   labelIDs,
 		})
 		if err != nil {
 			return fmt.Errorf("failed to list messages (fetched %d so far): %w", fetched, err)
 		}
 
+		if err := s.storeMessages(ctx, msgs); err != nil {
+			return fmt.Errorf("failed to store messages: %w", err)
+		}
 		for i := range msgs {
-			if err := s.store.UpsertEmail(ctx, &msgs[i], s.accountID); err != nil {
-				return fmt.Errorf("failed to upsert email %s: %w", msgs[i].ID, err)
-			}
+			res.seen[msgs[i].ID] = true
 		}
 
 		fetched += len(msgs)
+		res.Fetched += len(msgs)
 		log.Printf("[sync] fetched %d/%d messages for account %s", fetched, count, s.accountID)
+		if s.progress != nil {
+			s.progress(res.Fetched, res.total)
+		}
 
 		if nextToken == "" || len(msgs) == 0 {
-			break
+			return nil
 		}
 		pageToken = nextToken
 	}
+}
 
-	// Save sync state.
-	if err := s.store.SetSyncState(ctx, &store.SyncState{
-		AccountID: s.accountID,
-		HistoryID: 0,
-		LastSync:  time.Now().Unix(),
-	}); err != nil {
-		return fmt.Errorf("failed to save sync state: %w", err)
+// PruneCandidates returns the IDs of local messages within res's label scope
+// that the full sync did not see on the server. Messages outside the scope
+// are never candidates; for an all-mail sync that includes SPAM and TRASH,
+// which Gmail omits from message listings.
+func (s *SyncService) PruneCandidates(ctx context.Context, res *FullSyncResult) ([]string, error) {
+	if !res.Complete {
+		return nil, ErrIncompleteSync
 	}
 
-	log.Printf("[sync] initial sync complete: %d messages for account %s", fetched, s.accountID)
-	return nil
+	inScope, err := s.localScope(ctx, res.LabelIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, id := range inScope {
+		if !res.seen[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// localScope lists the IDs of local messages in the given label scope.
+func (s *SyncService) localScope(ctx context.Context, labelIDs []string) ([]string, error) {
+	idsFor := func(labelID string) ([]string, error) {
+		emails, err := s.store.ListEmails(ctx, store.ListEmailOptions{AccountID: s.accountID, LabelID: labelID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list local emails: %w", err)
+		}
+		ids := make([]string, len(emails))
+		for i := range emails {
+			ids[i] = emails[i].ID
+		}
+		return ids, nil
+	}
+
+	included := labelIDs
+	excluded := map[string]bool{}
+	if len(labelIDs) == 0 {
+		included = []string{""}
+		for _, labelID := range []string{domain.LabelSpam, domain.LabelTrash} {
+			ids, err := idsFor(labelID)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				excluded[id] = true
+			}
+		}
+	}
+
+	var scope []string
+	for _, labelID := range included {
+		ids, err := idsFor(labelID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if !excluded[id] {
+				excluded[id] = true // dedupe across labels
+				scope = append(scope, id)
+			}
+		}
+	}
+	return scope, nil
+}
+
+// Wipe deletes every local message of the account ahead of a FullSync that
+// rebuilds them, returning how many were removed.
+func (s *SyncService) Wipe(ctx context.Context) (int, error) {
+	n, err := s.store.DeleteAccountEmails(ctx, s.accountID)
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("[sync] wiped %d local messages for account %s", n, s.accountID)
+	return n, nil
+}
+
+// Prune deletes the given local messages, returning how many were removed.
+func (s *SyncService) Prune(ctx context.Context, ids []string) (int, error) {
+	for i, id := range ids {
+		if err := s.store.DeleteEmail(ctx, id, s.accountID); err != nil {
+			return i, fmt.Errorf("failed to prune message %s: %w", id, err)
+		}
+	}
+	log.Printf("[sync] pruned %d messages for account %s", len(ids), s.accountID)
+	return len(ids), nil
 }
 
 // IncrementalSync performs a delta sync using the provider's history API.
-// If no prior sync state exists (historyID == 0), it falls back to an
-// InitialSync of 500 messages.
+// If no prior sync state exists (historyID == 0), or the provider no longer
+// has history that far back, it falls back to an InitialSync of the
+// fallback count (500 messages unless SetFallbackCount changed it).
 func (s *SyncService) IncrementalSync(ctx context.Context) error {
+	started := time.Now()
 	state, err := s.store.GetSyncState(ctx, s.accountID)
 	if err != nil {
 		return fmt.Errorf("failed to get sync state: %w", err)
@@ -97,56 +333,135 @@ func (s *SyncService) IncrementalSync(ctx context.Context) error {
 
 	if state == nil || state.HistoryID == 0 {
 		log.Printf("[sync] no history ID found, falling back to initial sync for account %s", s.accountID)
-		return s.InitialSync(ctx, 500)
+		return s.InitialSync(ctx, s.fallbackCount)
 	}
 
 	events, newHistoryID, err := s.provider.History(ctx, state.HistoryID)
+	if errors.Is(err, provider.ErrNotFound) {
+		// Gmail only keeps history for a limited time; an expired start
+		// ID is reported as not found and can only be recovered from by
+		// syncing afresh.
+		log.Printf("[sync] history ID %d expired, falling back to initial sync for account %s: %v",
+			state.HistoryID, s.accountID, err)
+		return s.InitialSync(ctx, s.fallbackCount)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to fetch history: %w", err)
 	}
 
-	var added, deleted, modified int
-
+	// Collapse the events to one outcome per message, in first-seen order:
+	// a later deletion wins, and an added message needs no separate label
+	// update since it is fetched in full.
+	var order []string
+	kind := make(map[string]provider.HistoryEventType)
 	for _, event := range events {
+		_, ok := kind[event.MessageID]
+		if !ok {
+			order = append(order, event.MessageID)
+		}
 		switch event.Type {
-		case provider.HistoryMessageAdded:
-			msg, err := s.provider.GetMessage(ctx, event.MessageID)
-			if err != nil {
-				return fmt.Errorf("failed to get added message %s: %w", event.MessageID, err)
-			}
-			if err := s.store.UpsertEmail(ctx, msg, s.accountID); err != nil {
-				return fmt.Errorf("failed to upsert added message %s: %w", event.MessageID, err)
+		case provider.HistoryMessageAdded, provider.HistoryMessageDeleted:
+			kind[event.MessageID] = event.Type
+		case provider.HistoryLabelsAdded, provider.HistoryLabelsRemoved:
+			if !ok {
+				kind[event.MessageID] = provider.HistoryLabelsAdded
 			}
-			added++
+		}
+	}
 
+	var addedIDs, modifiedIDs, deletedIDs []string
+	for _, id := range order {
+		switch kind[id] {
+		case provider.HistoryMessageAdded:
+			addedIDs = append(addedIDs, id)
 		case provider.HistoryMessageDeleted:
-			if err := s.store.DeleteEmail(ctx, event.MessageID); err != nil {
-				return fmt.Errorf("failed to delete message %s: %w", event.MessageID, err)
-			}
-			deleted++
+			deletedIDs = append(deletedIDs, id)
+		default:
+			modifiedIDs = append(modifiedIDs, id)
+		}
+	}
 
-		case provider.HistoryLabelsAdded, provider.HistoryLabelsRemoved:
-			msg, err := s.provider.GetMessage(ctx, event.MessageID)
-			if err != nil {
-				return fmt.Errorf("failed to get message %s for label update: %w", event.MessageID, err)
-			}
-			if err := s.store.SetEmailLabels(ctx, msg.ID, msg.Labels); err != nil {
-				return fmt.Errorf("failed to set labels for message %s: %w", event.MessageID, err)
+	for batch := range slices.Chunk(addedIDs, messageBatchSize) {
+		msgs, err := s.provider.GetMessages(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("failed to get added messages: %w", err)
+		}
+		if err := s.storeMessages(ctx, msgs); err != nil {
+			return fmt.Errorf("failed to store added messages: %w", err)
+		}
+	}
+
+	for batch := range slices.Chunk(modifiedIDs, messageBatchSize) {
+		msgs, err := s.provider.GetMessages(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("failed to get messages for label update: %w", err)
+		}
+		for i := range msgs {
+			if err := s.store.SetEmailLabels(ctx, msgs[i].ID, s.accountID, msgs[i].Labels); err != nil {
+				return fmt.Errorf("failed to set labels for message %s: %w", msgs[i].ID, err)
 			}
-			modified++
 		}
 	}
 
+	for _, id := range deletedIDs {
+		if err := s.store.DeleteEmail(ctx, id, s.accountID); err != nil {
+			return fmt.Errorf("failed to delete message %s: %w", id, err)
+		}
+	}
+	added, deleted, modified := len(addedIDs), len(deletedIDs), len(modifiedIDs)
+
 	// Update sync state with new history ID.
 	if err := s.store.SetSyncState(ctx, &store.SyncState{
 		AccountID: s.accountID,
 		HistoryID: newHistoryID,
-		LastSync:  time.Now().Unix(),
+		LastSync:  started.Unix(),
 	}); err != nil {
 		return fmt.Errorf("failed to update sync state: %w", err)
 	}
 
+	s.refreshProfile(ctx)
+
 	log.Printf("[sync] incremental sync complete for account %s: %d added, %d deleted, %d modified",
 		s.accountID, added, deleted, modified)
 	return nil
 }
+
+// SyncThread refreshes one thread from the provider: every remote message is
+// stored, updating its read state and labels, and local messages of the
+// thread that are no longer on the server are deleted. It returns how many
+// messages were fetched and how many were removed locally.
+func (s *SyncService) SyncThread(ctx context.Context, threadID string) (fetched, removed int, err error) {
+	remote, err := s.provider.GetThread(ctx, threadID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get thread %s: %w", threadID, err)
+	}
+	if err := s.storeMessages(ctx, remote.Messages); err != nil {
+		return 0, 0, fmt.Errorf("failed to store thread %s: %w", threadID, err)
+	}
+
+	local, err := s.store.GetThread(ctx, threadID, s.accountID)
+	if errors.Is(err, store.ErrNotFound) {
+		return len(remote.Messages), 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to load local thread %s: %w", threadID, err)
+	}
+
+	seen := make(map[string]bool, len(remote.Messages))
+	for _, m := range remote.Messages {
+		seen[m.ID] = true
+	}
+	for _, m := range local.Messages {
+		if seen[m.ID] {
+			continue
+		}
+		if err := s.store.DeleteEmail(ctx, m.ID, s.accountID); err != nil {
+			return len(remote.Messages), removed, fmt.Errorf("failed to delete message %s: %w", m.ID, err)
+		}
+		removed++
+	}
+
+	log.Printf("[sync] synced thread %s for account %s: %d messages, %d removed",
+		threadID, s.accountID, len(remote.Messages), removed)
+	return len(remote.Messages), removed, nil
+}
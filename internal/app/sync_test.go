@@ -0,0 +1,531 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/store"
+	"github.com/lu-zhengda/termail/internal/store/sqlite"
+)
+
+// fakeProvider serves a fixed set of remote messages, filtered by label.
+type fakeProvider struct {
+	provider.EmailProvider
+	remote []domain.Email
+}
+
+func (f *fakeProvider) ListLabels(context.Context) ([]domain.Label, error) {
+	return nil, nil
+}
+
+func (f *fakeProvider) ListMessages(_ context.Context, opts provider.ListOptions) ([]domain.Email, string, error) {
+	var out []domain.Email
+	for _, e := range f.remote {
+		if len(opts.LabelIDs) == 0 || e.HasLabel(opts.LabelIDs[0]) {
+			out = append(out, e)
+		}
+	}
+	if opts.MaxResults > 0 && len(out) > opts.MaxResults {
+		return out[:opts.MaxResults], "more", nil
+	}
+	return out, "", nil
+}
+
+func (f *fakeProvider) GetThread(_ context.Context, id string) (*domain.Thread, error) {
+	t := &domain.Thread{ID: id}
+	for _, e := range f.remote {
+		if e.ThreadID == id {
+			t.Messages = append(t.Messages, e)
+		}
+	}
+	return t, nil
+}
+
+// GetProfile reports the remote messages as the mailbox size.
+func (f *fakeProvider) GetProfile(context.Context) (*domain.Profile, error) {
+	return &domain.Profile{Email: "me@example.com", MessagesTotal: int64(len(f.remote))}, nil
+}
+
+func newTestService(t *testing.T, remote []domain.Email, local []domain.Email) (*SyncService, *sqlite.DB) {
+	t.Helper()
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New() error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	if err := db.CreateAccount(ctx, &domain.Account{ID: "acc-1", Email: "me@example.com", Provider: "gmail"}); err != nil {
+		t.Fatalf("CreateAccount() error: %v", err)
+	}
+	for i := range local {
+		if err := db.UpsertEmail(ctx, &local[i], "acc-1"); err != nil {
+			t.Fatalf("UpsertEmail() error: %v", err)
+		}
+	}
+	return NewSyncService(db, &fakeProvider{remote: remote}, "acc-1"), db
+}
+
+func TestPruneCandidates_ScopedToSyncedLabels(t *testing.T) {
+	remote := []domain.Email{
+		{ID: "kept", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+	}
+	local := []domain.Email{
+		{ID: "kept", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+		{ID: "gone-inbox", ThreadID: "t2", Labels: []string{domain.LabelInbox}},
+		{ID: "other-label", ThreadID: "t3", Labels: []string{"Label_1"}},
+		{ID: "sent", ThreadID: "t4", Labels: []string{domain.LabelSent}},
+	}
+	svc, _ := newTestService(t, remote, local)
+	ctx := context.Background()
+
+	res, err := svc.FullSync(ctx, 100, []string{domain.LabelInbox})
+	if err != nil {
+		t.Fatalf("FullSync() error: %v", err)
+	}
+	ids, err := svc.PruneCandidates(ctx, res)
+	if err != nil {
+		t.Fatalf("PruneCandidates() error: %v", err)
+	}
+	if !slices.Equal(ids, []string{"gone-inbox"}) {
+		t.Errorf("PruneCandidates() = %v, want [gone-inbox]; out-of-scope messages must be kept", ids)
+	}
+}
+
+func TestPruneCandidates_AllMailSkipsSpamAndTrash(t *testing.T) {
+	remote := []domain.Email{
+		{ID: "kept", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+	}
+	local := []domain.Email{
+		{ID: "kept", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+		{ID: "gone", ThreadID: "t2", Labels: []string{domain.LabelSent}},
+		{ID: "spam", ThreadID: "t3", Labels: []string{domain.LabelSpam}},
+		{ID: "trash", ThreadID: "t4", Labels: []string{domain.LabelTrash}},
+	}
+	svc, _ := newTestService(t, remote, local)
+	ctx := context.Background()
+
+	res, err := svc.FullSync(ctx, 100, nil)
+	if err != nil {
+		t.Fatalf("FullSync() error: %v", err)
+	}
+	ids, err := svc.PruneCandidates(ctx, res)
+	if err != nil {
+		t.Fatalf("PruneCandidates() error: %v", err)
+	}
+	if !slices.Equal(ids, []string{"gone"}) {
+		t.Errorf("PruneCandidates() = %v, want [gone]", ids)
+	}
+}
+
+func TestPruneCandidates_IncompleteSync(t *testing.T) {
+	remote := []domain.Email{
+		{ID: "a", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+		{ID: "b", ThreadID: "t2", Labels: []string{domain.LabelInbox}},
+	}
+	svc, _ := newTestService(t, remote, nil)
+	ctx := context.Background()
+
+	res, err := svc.FullSync(ctx, 1, nil)
+	if err != nil {
+		t.Fatalf("FullSync() error: %v", err)
+	}
+	if res.Complete {
+		t.Error("FullSync() Complete = true, want false when stopped at count")
+	}
+	if _, err := svc.PruneCandidates(ctx, res); !errors.Is(err, ErrIncompleteSync) {
+		t.Errorf("PruneCandidates() error = %v, want ErrIncompleteSync", err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	local := []domain.Email{{ID: "gone", ThreadID: "t1", Labels: []string{domain.LabelInbox}}}
+	svc, db := newTestService(t, nil, local)
+	ctx := context.Background()
+
+	n, err := svc.Prune(ctx, []string{"gone"})
+	if err != nil || n != 1 {
+		t.Fatalf("Prune() = %d, %v; want 1, nil", n, err)
+	}
+	if _, err := db.GetEmail(ctx, "gone", "acc-1"); err == nil {
+		t.Error("pruned email should no longer exist")
+	}
+}
+
+// historyProvider replays a fixed history and counts message fetches.
+type historyProvider struct {
+	fakeProvider
+	events         []provider.HistoryEvent
+	getCalls       int
+	getManyCalls   int
+	requestedTotal int
+}
+
+func (h *historyProvider) History(context.Context, uint64) ([]provider.HistoryEvent, uint64, error) {
+	return h.events, 2, nil
+}
+
+func (h *historyProvider) GetMessage(_ context.Context, id string) (*domain.Email, error) {
+	h.getCalls++
+	return &domain.Email{ID: id, ThreadID: "t-" + id, Labels: []string{domain.LabelInbox}}, nil
+}
+
+func (h *historyProvider) GetMessages(_ context.Context, ids []string) ([]domain.Email, error) {
+	h.getManyCalls++
+	h.requestedTotal += len(ids)
+	out := make([]domain.Email, len(ids))
+	for i, id := range ids {
+		out[i] = domain.Email{ID: id, ThreadID: "t-" + id, Labels: []string{domain.LabelInbox, "Label_1"}}
+	}
+	return out, nil
+}
+
+func TestIncrementalSync_BatchesFetches(t *testing.T) {
+	local := []domain.Email{
+		{ID: "old", ThreadID: "t-old", Labels: []string{domain.LabelInbox}},
+		{ID: "gone", ThreadID: "t-gone", Labels: []string{domain.LabelInbox}},
+	}
+	svc, db := newTestService(t, nil, local)
+	ctx := context.Background()
+
+	hp := &historyProvider{}
+	for i := range 120 {
+		hp.events = append(hp.events, provider.HistoryEvent{
+			Type: provider.HistoryMessageAdded, MessageID: fmt.Sprintf("new-%d", i),
+		})
+	}
+	hp.events = append(hp.events,
+		provider.HistoryEvent{Type: provider.HistoryLabelsAdded, MessageID: "old"},
+		provider.HistoryEvent{Type: provider.HistoryLabelsAdded, MessageID: "new-0"},
+		provider.HistoryEvent{Type: provider.HistoryMessageDeleted, MessageID: "gone"},
+		provider.HistoryEvent{Type: provider.HistoryMessageDeleted, MessageID: "new-1"},
+	)
+	svc.provider = hp
+	if err := db.SetSyncState(ctx, &store.SyncState{AccountID: "acc-1", HistoryID: 1}); err != nil {
+		t.Fatalf("SetSyncState() error: %v", err)
+	}
+
+	if err := svc.IncrementalSync(ctx); err != nil {
+		t.Fatalf("IncrementalSync() error: %v", err)
+	}
+
+	if hp.getCalls != 0 {
+		t.Errorf("GetMessage called %d times, want 0", hp.getCalls)
+	}
+	// 119 added messages in batches of 50, plus one batch for "old".
+	if hp.getManyCalls != 4 {
+		t.Errorf("GetMessages called %d times, want 4", hp.getManyCalls)
+	}
+	if hp.requestedTotal != 120 {
+		t.Errorf("requested %d messages, want 120 (new-1 is deleted, new-0 fetched once)", hp.requestedTotal)
+	}
+
+	if _, err := db.GetEmail(ctx, "new-119", "acc-1"); err != nil {
+		t.Errorf("added message not stored: %v", err)
+	}
+	if _, err := db.GetEmail(ctx, "new-1", "acc-1"); err == nil {
+		t.Error("message deleted later in the history should not be stored")
+	}
+	if _, err := db.GetEmail(ctx, "gone", "acc-1"); err == nil {
+		t.Error("deleted message should be removed")
+	}
+	old, err := db.GetEmail(ctx, "old", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail(old) error: %v", err)
+	}
+	if !old.HasLabel("Label_1") {
+		t.Errorf("old labels = %v, want Label_1 added", old.Labels)
+	}
+}
+
+// expiredHistoryProvider fails History the way Gmail does once the start
+// history ID is too old.
+type expiredHistoryProvider struct {
+	fakeProvider
+	err error
+}
+
+func (e *expiredHistoryProvider) History(context.Context, uint64) ([]provider.HistoryEvent, uint64, error) {
+	return nil, 0, e.err
+}
+
+func TestIncrementalSync_ExpiredHistoryFallsBackToInitialSync(t *testing.T) {
+	remote := []domain.Email{
+		{ID: "m1", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+		{ID: "m2", ThreadID: "t2", Labels: []string{domain.LabelInbox}},
+		{ID: "m3", ThreadID: "t3", Labels: []string{domain.LabelInbox}},
+	}
+	svc, db := newTestService(t, nil, nil)
+	svc.provider = &expiredHistoryProvider{
+		fakeProvider: fakeProvider{remote: remote},
+		err:          fmt.Errorf("%w: googleapi: Error 404: Requested entity was not found.", provider.ErrNotFound),
+	}
+	svc.SetFallbackCount(2)
+	ctx := context.Background()
+	if err := db.SetSyncState(ctx, &store.SyncState{AccountID: "acc-1", HistoryID: 42, LastSync: 1}); err != nil {
+		t.Fatalf("SetSyncState() error: %v", err)
+	}
+
+	if err := svc.IncrementalSync(ctx); err != nil {
+		t.Fatalf("IncrementalSync() error: %v", err)
+	}
+
+	for _, id := range []string{"m1", "m2"} {
+		if _, err := db.GetEmail(ctx, id, "acc-1"); err != nil {
+			t.Errorf("GetEmail(%s) error: %v", id, err)
+		}
+	}
+	if _, err := db.GetEmail(ctx, "m3", "acc-1"); err == nil {
+		t.Error("m3 stored, want the fallback sync limited to 2 messages")
+	}
+	state, err := db.GetSyncState(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("GetSyncState() error: %v", err)
+	}
+	if state.HistoryID != 0 || state.LastSync <= 1 {
+		t.Errorf("sync state = %+v, want the expired history ID reset and LastSync updated", state)
+	}
+}
+
+func TestIncrementalSync_OtherHistoryErrorsFail(t *testing.T) {
+	svc, db := newTestService(t, nil, nil)
+	svc.provider = &expiredHistoryProvider{err: fmt.Errorf("%w: connection reset", provider.ErrNetwork)}
+	ctx := context.Background()
+	if err := db.SetSyncState(ctx, &store.SyncState{AccountID: "acc-1", HistoryID: 42}); err != nil {
+		t.Fatalf("SetSyncState() error: %v", err)
+	}
+
+	if err := svc.IncrementalSync(ctx); !errors.Is(err, provider.ErrNetwork) {
+		t.Fatalf("IncrementalSync() error = %v, want ErrNetwork", err)
+	}
+	state, err := db.GetSyncState(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("GetSyncState() error: %v", err)
+	}
+	if state.HistoryID != 42 {
+		t.Errorf("HistoryID = %d, want 42 kept", state.HistoryID)
+	}
+}
+
+func TestFullSync_TruncatesLargeBodies(t *testing.T) {
+	remote := []domain.Email{
+		{ID: "big", ThreadID: "t1", Body: strings.Repeat("a", 300), BodyHTML: "<p>" + strings.Repeat("b", 300) + "</p>", Labels: []string{domain.LabelInbox}},
+		{ID: "small", ThreadID: "t2", Body: "hi", Labels: []string{domain.LabelInbox}},
+	}
+	svc, db := newTestService(t, remote, nil)
+	svc.SetMaxBodyBytes(100)
+	ctx := context.Background()
+
+	if _, err := svc.FullSync(ctx, 10, nil); err != nil {
+		t.Fatalf("FullSync() error: %v", err)
+	}
+	big, err := db.GetEmail(ctx, "big", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail(big) error: %v", err)
+	}
+	if !big.BodyTruncated() || !strings.HasPrefix(big.Body, strings.Repeat("a", 100)+"\n") || len(big.BodyHTML) > 100+len(domain.BodyTruncatedMarker)+2 {
+		t.Errorf("big bodies = %q / %q, want both cut at 100 bytes with the marker", big.Body, big.BodyHTML)
+	}
+	small, err := db.GetEmail(ctx, "small", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail(small) error: %v", err)
+	}
+	if small.Body != "hi" || small.BodyTruncated() {
+		t.Errorf("small body = %q, want it whole", small.Body)
+	}
+}
+
+func TestFullSync_ThreadsByReferences(t *testing.T) {
+	remote := []domain.Email{
+		{ID: "root", MessageID: "<a@x>", Labels: []string{domain.LabelInbox}},
+		{ID: "reply", MessageID: "<b@x>", InReplyTo: "<a@x>", Labels: []string{domain.LabelInbox}},
+	}
+	svc, db := newTestService(t, remote, nil)
+	svc.SetThreadByReferences(true)
+	ctx := context.Background()
+
+	if _, err := svc.FullSync(ctx, 10, nil); err != nil {
+		t.Fatalf("FullSync() error: %v", err)
+	}
+	root, err := db.GetEmail(ctx, "root", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail(root) error: %v", err)
+	}
+	reply, err := db.GetEmail(ctx, "reply", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail(reply) error: %v", err)
+	}
+	if root.ThreadID == "" || reply.ThreadID != root.ThreadID {
+		t.Errorf("thread IDs = %q, %q; want one shared thread", root.ThreadID, reply.ThreadID)
+	}
+}
+
+func TestFullSync_RecordsMailboxSize(t *testing.T) {
+	remote := []domain.Email{
+		{ID: "m1", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+		{ID: "m2", ThreadID: "t2", Labels: []string{domain.LabelSent}},
+	}
+	svc, db := newTestService(t, remote, nil)
+	ctx := context.Background()
+
+	if _, err := svc.FullSync(ctx, 100, nil); err != nil {
+		t.Fatalf("FullSync() error: %v", err)
+	}
+	acct, err := db.GetAccount(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("GetAccount() error: %v", err)
+	}
+	if acct.MessagesTotal != 2 {
+		t.Errorf("MessagesTotal = %d, want 2", acct.MessagesTotal)
+	}
+}
+
+func TestFullSync_ReportsProgress(t *testing.T) {
+	remote := []domain.Email{
+		{ID: "m1", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+		{ID: "m2", ThreadID: "t2", Labels: []string{domain.LabelInbox}},
+		{ID: "m3", ThreadID: "t3", Labels: []string{domain.LabelSent}},
+	}
+	svc, _ := newTestService(t, remote, nil)
+
+	var got [][2]int
+	svc.OnProgress(func(fetched, total int) {
+		got = append(got, [2]int{fetched, total})
+	})
+	if _, err := svc.FullSync(context.Background(), 2, []string{domain.LabelInbox, domain.LabelSent}); err != nil {
+		t.Fatalf("FullSync() error: %v", err)
+	}
+	want := [][2]int{{2, 4}, {3, 4}}
+	if !slices.Equal(got, want) {
+		t.Errorf("progress = %v, want %v", got, want)
+	}
+}
+
+func TestSyncThread_Reconciles(t *testing.T) {
+	remote := []domain.Email{
+		{ID: "m1", ThreadID: "t1", Labels: []string{domain.LabelInbox}, IsRead: true},
+		{ID: "m3", ThreadID: "t1", Labels: []string{domain.LabelInbox, domain.LabelUnread}},
+	}
+	local := []domain.Email{
+		{ID: "m1", ThreadID: "t1", Labels: []string{domain.LabelInbox, domain.LabelUnread}},
+		{ID: "m2", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+		{ID: "other", ThreadID: "t2", Labels: []string{domain.LabelInbox}},
+	}
+	svc, db := newTestService(t, remote, local)
+	ctx := context.Background()
+
+	fetched, removed, err := svc.SyncThread(ctx, "t1")
+	if err != nil {
+		t.Fatalf("SyncThread() error: %v", err)
+	}
+	if fetched != 2 || removed != 1 {
+		t.Errorf("SyncThread() = %d fetched, %d removed; want 2, 1", fetched, removed)
+	}
+
+	thread, err := db.GetThread(ctx, "t1", "acc-1")
+	if err != nil {
+		t.Fatalf("GetThread() error: %v", err)
+	}
+	var ids []string
+	for _, m := range thread.Messages {
+		ids = append(ids, m.ID)
+		if m.ID == "m1" && !m.IsRead {
+			t.Error("m1 should be read after the remote marked it read")
+		}
+		if m.ID == "m3" && m.IsRead {
+			t.Error("m3 should be unread")
+		}
+	}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []string{"m1", "m3"}) {
+		t.Errorf("thread messages = %v, want [m1 m3]", ids)
+	}
+	if _, err := db.GetEmail(ctx, "other", "acc-1"); err != nil {
+		t.Errorf("message in another thread should be kept: %v", err)
+	}
+}
+
+func TestSyncThread_GoneRemotely(t *testing.T) {
+	local := []domain.Email{{ID: "m1", ThreadID: "t1", Labels: []string{domain.LabelInbox}}}
+	svc, db := newTestService(t, nil, local)
+	ctx := context.Background()
+
+	if _, removed, err := svc.SyncThread(ctx, "t1"); err != nil || removed != 1 {
+		t.Fatalf("SyncThread() removed %d, error %v; want 1, nil", removed, err)
+	}
+	if _, err := db.GetThread(ctx, "t1", "acc-1"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetThread() error = %v, want ErrNotFound", err)
+	}
+}
+
+// cancellingProvider returns one page with more to come, cancelling the
+// sync's context as it does, like a user pressing Ctrl+C mid-sync.
+type cancellingProvider struct {
+	fakeProvider
+	cancel context.CancelFunc
+	calls  int
+}
+
+func (c *cancellingProvider) ListMessages(ctx context.Context, opts provider.ListOptions) ([]domain.Email, string, error) {
+	c.calls++
+	c.cancel()
+	msgs, _, err := c.fakeProvider.ListMessages(ctx, opts)
+	return msgs, "more", err
+}
+
+func TestFullSync_ClearsStateAndStopsOnCancel(t *testing.T) {
+	_, db := newTestService(t, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := db.SetSyncState(ctx, &store.SyncState{AccountID: "acc-1", HistoryID: 42}); err != nil {
+		t.Fatalf("SetSyncState() error: %v", err)
+	}
+
+	p := &cancellingProvider{
+		fakeProvider: fakeProvider{remote: []domain.Email{{ID: "m1", ThreadID: "t1", Labels: []string{domain.LabelInbox}}}},
+		cancel:       cancel,
+	}
+	svc := NewSyncService(db, p, "acc-1")
+	if _, err := svc.FullSync(ctx, 500, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("FullSync() error = %v, want context.Canceled", err)
+	}
+	if p.calls != 1 {
+		t.Errorf("ListMessages called %d times, want 1 before stopping", p.calls)
+	}
+
+	state, err := db.GetSyncState(context.Background(), "acc-1")
+	if err != nil {
+		t.Fatalf("GetSyncState() error: %v", err)
+	}
+	if state.HistoryID != 0 {
+		t.Errorf("HistoryID after an interrupted full sync = %d, want 0", state.HistoryID)
+	}
+}
+
+func TestWipe_RemovesAccountMail(t *testing.T) {
+	local := []domain.Email{
+		{ID: "a", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+		{ID: "b", ThreadID: "t2", Labels: []string{domain.LabelSent}},
+	}
+	svc, db := newTestService(t, nil, local)
+	ctx := context.Background()
+
+	n, err := svc.Wipe(ctx)
+	if err != nil || n != 2 {
+		t.Fatalf("Wipe() = %d, %v; want 2, nil", n, err)
+	}
+	left, err := db.ListEmails(ctx, store.ListEmailOptions{AccountID: "acc-1"})
+	if err != nil {
+		t.Fatalf("ListEmails() error: %v", err)
+	}
+	if len(left) != 0 {
+		t.Errorf("%d messages left after Wipe(), want 0", len(left))
+	}
+}
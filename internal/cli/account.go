@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -10,7 +13,6 @@ import (
 	"github.com/lu-zhengda/termail/internal/app"
 	"github.com/lu-zhengda/termail/internal/domain"
 	"github.com/lu-zhengda/termail/internal/provider/gmail"
-	"github.com/lu-zhengda/termail/internal/store"
 )
 
 func newAccountCmd() *cobra.Command {
@@ -21,11 +23,13 @@ func newAccountCmd() *cobra.Command {
 	cmd.AddCommand(newAccountAddCmd())
 	cmd.AddCommand(newAccountListCmd())
 	cmd.AddCommand(newAccountRemoveCmd())
+	cmd.AddCommand(newAccountWhoamiCmd())
 	return cmd
 }
 
 func newAccountAddCmd() *cobra.Command {
 	var email string
+	var yesFlag bool
 
 	cmd := &cobra.Command{
 		Use:   "add",
@@ -45,7 +49,10 @@ func newAccountAddCmd() *cobra.Command {
 			}
 			defer db.Close()
 
-			tokenStore := store.NewKeyringTokenStore()
+			tokenStore, err := newTokenStore(cfg)
+			if err != nil {
+				return err
+			}
 
 			// Use email as account ID if provided, otherwise use a temporary ID
 			// that will be replaced after OAuth when we learn the real email.
@@ -54,7 +61,17 @@ func newAccountAddCmd() *cobra.Command {
 				accountID = fmt.Sprintf("gmail-%d", time.Now().UnixNano())
 			}
 
-			provider := gmail.New(accountID, tokenStore)
+			if cfg.Auth.ConfirmScopes && !yesFlag {
+				if jsonFlag {
+					return fmt.Errorf("--yes is required with --json")
+				}
+				if !confirmScopes(os.Stdin, os.Stdout, gmail.ScopeSummary()) {
+					fmt.Println("Aborted.")
+					return nil
+				}
+			}
+
+			provider := newGmailProvider(cfg, accountID, tokenStore)
 
 			ctx := cmd.Context()
 			fmt.Println("Starting Gmail OAuth flow...")
@@ -64,11 +81,11 @@ func newAccountAddCmd() *cobra.Command {
 
 			// If no email was provided, fetch it from the Gmail profile.
 			if email == "" {
-				profileEmail, err := provider.GetProfile(ctx)
+				profile, err := provider.GetProfile(ctx)
 				if err != nil {
 					return fmt.Errorf("failed to get profile email: %w", err)
 				}
-				email = profileEmail
+				email = profile.Email
 
 				// Re-save the token under the real email as account ID,
 				// and clean up the temporary one.
@@ -107,9 +124,20 @@ func newAccountAddCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&email, "email", "", "email address (auto-detected if omitted)")
+	cmd.Flags().BoolVar(&yesFlag, "yes", false, "skip the OAuth scope confirmation")
 	return cmd
 }
 
+// confirmScopes prints the OAuth scopes about to be requested, one per
+// line with why they are needed, and asks whether to continue.
+func confirmScopes(in io.Reader, out io.Writer, scopes []string) bool {
+	fmt.Fprintln(out, "termail will ask Google for these permissions:")
+	for _, s := range scopes {
+		fmt.Fprintf(out, "  - %s\n", s)
+	}
+	return confirm(in, out, "Continue to Google sign-in?")
+}
+
 func newAccountListCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "list",
@@ -125,9 +153,15 @@ func newAccountListCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to list accounts: %w", err)
 			}
+			unread := make(map[string]int, len(accounts))
+			for _, a := range accounts {
+				if unread[a.ID], err = db.UnreadCount(cmd.Context(), a.ID); err != nil {
+					return err
+				}
+			}
 
 			if jsonFlag {
-				return printJSON(toJSONAccounts(accounts))
+				return printJSON(toJSONAccounts(accounts, unread))
 			}
 
 			if len(accounts) == 0 {
@@ -136,12 +170,18 @@ func newAccountListCmd() *cobra.Command {
 			}
 
 			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
-			fmt.Fprintln(w, "ID\tEMAIL\tPROVIDER\tCREATED")
+			fmt.Fprintln(w, "ID\tEMAIL\tPROVIDER\tMESSAGES\tUNREAD\tCREATED")
 			for _, a := range accounts {
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				size := "-"
+				if a.MessagesTotal > 0 {
+					size = fmt.Sprint(a.MessagesTotal)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
 					a.ID,
 					a.Email,
 					a.Provider,
+					size,
+					unread[a.ID],
 					a.CreatedAt.Format(time.DateOnly),
 				)
 			}
@@ -150,6 +190,52 @@ func newAccountListCmd() *cobra.Command {
 	}
 }
 
+func newAccountWhoamiCmd() *cobra.Command {
+	var accountFlag string
+
+	cmd := &cobra.Command{
+		Use:   "whoami",
+		Short: "Show the signed-in address and mailbox size",
+		Long: "Fetch the account's profile from the provider and print its address,\n" +
+			"message count, and thread count. The message count is also cached\n" +
+			"for 'account list' and the TUI sidebar.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			provider, accountID, err := setupProvider(cmd, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			profile, err := provider.GetProfile(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get profile: %w", err)
+			}
+
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			if err := db.SetAccountMessagesTotal(ctx, accountID, profile.MessagesTotal); err != nil {
+				return err
+			}
+
+			if jsonFlag {
+				return printJSON(toJSONProfile(profile))
+			}
+
+			fmt.Printf("Email:    %s\n", profile.Email)
+			fmt.Printf("Messages: %d\n", profile.MessagesTotal)
+			fmt.Printf("Threads:  %d\n", profile.ThreadsTotal)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID")
+	return cmd
+}
+
 func newAccountRemoveCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "remove [email]",
@@ -185,10 +271,17 @@ func newAccountRemoveCmd() *cobra.Command {
 				return fmt.Errorf("failed to delete account: %w", err)
 			}
 
-			tokenStore := store.NewKeyringTokenStore()
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			tokenStore, err := newTokenStore(cfg)
+			if err != nil {
+				return err
+			}
 			if err := tokenStore.DeleteToken(target.ID); err != nil {
 				// Non-fatal: token may already be gone.
-				fmt.Fprintf(os.Stderr, "Warning: could not remove token from keyring: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Warning: could not remove stored token: %v\n", err)
 			}
 
 			if jsonFlag {
@@ -202,12 +295,34 @@ func newAccountRemoveCmd() *cobra.Command {
 }
 
 func newSyncCmd() *cobra.Command {
-	var accountFlag string
+	var accountFlag, threadFlag string
+	var fullFlag, pruneFlag, wipeFlag, yesFlag bool
+	var labelFlags []string
+	var countFlag int
 
 	cmd := &cobra.Command{
 		Use:   "sync",
 		Short: "Manually sync emails",
+		Long: "Sync emails incrementally. --full re-fetches messages (optionally only\n" +
+			"those with the given --label IDs); adding --prune then deletes local\n" +
+			"messages in that scope that are no longer on the server, while --wipe\n" +
+			"deletes all local mail of the account first to rebuild it from scratch.\n" +
+			"--thread refreshes a single thread and drops its messages deleted\n" +
+			"remotely. Ctrl+C stops a full sync between pages.",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if threadFlag != "" && fullFlag {
+				return fmt.Errorf("--thread cannot be combined with --full")
+			}
+			if pruneFlag && !fullFlag {
+				return fmt.Errorf("--prune requires --full")
+			}
+			if len(labelFlags) > 0 && !fullFlag {
+				return fmt.Errorf("--label requires --full")
+			}
+			if wipeFlag && (!fullFlag || len(labelFlags) > 0 || pruneFlag) {
+				return fmt.Errorf("--wipe requires --full and cannot be combined with --label or --prune")
+			}
+
 			db, err := openDB()
 			if err != nil {
 				return err
@@ -231,28 +346,128 @@ func newSyncCmd() *cobra.Command {
 				return err
 			}
 
-			tokenStore := store.NewKeyringTokenStore()
-			provider := gmail.New(accountID, tokenStore)
+			tokenStore, err := newTokenStore(cfg)
+			if err != nil {
+				return err
+			}
+			provider := newGmailProvider(cfg, accountID, tokenStore)
 
 			ctx := cmd.Context()
 			svc := app.NewSyncService(db, provider, accountID)
+			svc.SetFallbackCount(cfg.Sync.InitialCount)
+			svc.SetMaxBodyBytes(cfg.Sync.MaxBodyBytes)
+			svc.SetThreadByReferences(cfg.Sync.ThreadByReferences)
+
+			if threadFlag != "" {
+				fetched, removed, err := svc.SyncThread(ctx, threadFlag)
+				if err != nil {
+					return fmt.Errorf("failed to sync thread: %w", err)
+				}
+				if jsonFlag {
+					return printJSON(jsonSync{OK: true, AccountID: accountID, Fetched: fetched, Pruned: removed})
+				}
+				fmt.Printf("Thread %s synced: %d messages, %d removed.\n", threadFlag, fetched, removed)
+				return nil
+			}
 
 			if !jsonFlag {
 				fmt.Printf("Syncing account %s...\n", accountID)
 			}
-			if err := svc.IncrementalSync(ctx); err != nil {
+			if !fullFlag {
+				if err := svc.IncrementalSync(ctx); err != nil {
+					return fmt.Errorf("failed to sync: %w", err)
+				}
+				if jsonFlag {
+					return printJSON(jsonAction{OK: true, Action: "sync", AccountID: accountID})
+				}
+				fmt.Println("Sync complete.")
+				return nil
+			}
+
+			count := countFlag
+			if count <= 0 {
+				count = cfg.Sync.InitialCount
+			}
+
+			wiped := 0
+			if wipeFlag {
+				if !yesFlag {
+					if jsonFlag {
+						return fmt.Errorf("--yes is required with --json")
+					}
+					prompt := fmt.Sprintf("Delete all local mail of %s and re-fetch up to %d messages?", accountID, count)
+					if !confirm(os.Stdin, os.Stdout, prompt) {
+						fmt.Println("Sync cancelled.")
+						return nil
+					}
+				}
+				if wiped, err = svc.Wipe(ctx); err != nil {
+					return err
+				}
+			}
+
+			res, err := svc.FullSync(ctx, count, labelFlags)
+			if err != nil {
 				return fmt.Errorf("failed to sync: %w", err)
 			}
 
+			pruned := 0
+			if pruneFlag {
+				ids, err := svc.PruneCandidates(ctx, res)
+				if errors.Is(err, app.ErrIncompleteSync) {
+					return fmt.Errorf("not pruning: %w (raise --count above %d)", err, count)
+				}
+				if err != nil {
+					return fmt.Errorf("failed to find messages to prune: %w", err)
+				}
+
+				if len(ids) > 0 && cfg.Sync.ConfirmPrune && !yesFlag {
+					if jsonFlag {
+						return fmt.Errorf("--yes is required with --json")
+					}
+					prompt := fmt.Sprintf("%d local messages in %s are no longer on the server. Delete them locally?",
+						len(ids), pruneScope(labelFlags))
+					if !confirm(os.Stdin, os.Stdout, prompt) {
+						fmt.Println("Prune skipped.")
+						return nil
+					}
+				}
+				if pruned, err = svc.Prune(ctx, ids); err != nil {
+					return err
+				}
+			}
+
 			if jsonFlag {
-				return printJSON(jsonAction{OK: true, Action: "sync", AccountID: accountID})
+				return printJSON(jsonSync{OK: true, AccountID: accountID, Fetched: res.Fetched, Pruned: pruned, Wiped: wiped})
 			}
 
-			fmt.Println("Sync complete.")
+			fmt.Printf("Sync complete: %d messages fetched", res.Fetched)
+			if pruneFlag {
+				fmt.Printf(", %d pruned", pruned)
+			}
+			if wipeFlag {
+				fmt.Printf(", %d local messages replaced", wiped)
+			}
+			fmt.Println(".")
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID to sync (defaults to config default or first account)")
+	cmd.Flags().BoolVar(&fullFlag, "full", false, "re-fetch messages instead of syncing changes")
+	cmd.Flags().BoolVar(&pruneFlag, "prune", false, "with --full, delete local messages in scope that are gone from the server")
+	cmd.Flags().BoolVar(&wipeFlag, "wipe", false, "with --full, delete all local mail of the account before re-fetching")
+	cmd.Flags().StringSliceVar(&labelFlags, "label", nil, "with --full, only sync (and prune) messages with these label IDs")
+	cmd.Flags().IntVar(&countFlag, "count", 0, "max messages to fetch per label (defaults to sync.initial_count)")
+	cmd.Flags().BoolVar(&yesFlag, "yes", false, "skip the prune and wipe confirmation prompts")
+	cmd.Flags().StringVar(&threadFlag, "thread", "", "only refresh the thread with this ID")
 	return cmd
 }
+
+// pruneScope describes a --label scope for the prune confirmation.
+func pruneScope(labelIDs []string) string {
+	if len(labelIDs) == 0 {
+		return "all mail (excluding spam and trash)"
+	}
+	return strings.Join(labelIDs, ", ")
+}
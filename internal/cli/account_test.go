@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lu-zhengda/termail/internal/provider/gmail"
+)
+
+func TestConfirmScopes(t *testing.T) {
+	scopes := gmail.ScopeSummary()
+
+	var out bytes.Buffer
+	if confirmScopes(strings.NewReader("n\n"), &out, scopes) {
+		t.Error("answering no should decline")
+	}
+	for _, s := range scopes {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("output does not list %q:\n%s", s, out.String())
+		}
+	}
+
+	if !confirmScopes(strings.NewReader("y\n"), &bytes.Buffer{}, scopes) {
+		t.Error("answering yes should continue")
+	}
+}
@@ -1,57 +1,127 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
-	"io"
+	"net/mail"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/lu-zhengda/termail/internal/app"
 	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/mailto"
 	"github.com/lu-zhengda/termail/internal/provider/gmail"
-	"github.com/lu-zhengda/termail/internal/store"
 )
 
 func newComposeCmd() *cobra.Command {
-	var accountFlag, toFlag, ccFlag, subjectFlag, bodyFlag string
+	var accountFlag, toFlag, ccFlag, subjectFlag, bodyFlag, replyToFlag, mailtoFlag, attachNameFlag string
+	var attachFlags []string
+	var tuiFlag, editorFlag, yesFlag bool
 
 	cmd := &cobra.Command{
 		Use:   "compose",
 		Short: "Compose and send a new email",
+		Long: "Compose and send a new email. --mailto fills the message from an\n" +
+			"RFC 6068 mailto: URL; explicit flags override its fields. With --tui the\n" +
+			"message opens in the interactive composer instead of being sent.\n" +
+			"--attach - reads an attachment from stdin, named by --attach-name.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if toFlag == "" {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if err := checkStdinUse(bodyFlag, attachFlags); err != nil {
+				return err
+			}
+
+			draft := &domain.Email{}
+			if mailtoFlag != "" {
+				draft, err = mailto.Parse(mailtoFlag)
+				if err != nil {
+					return fmt.Errorf("invalid --mailto: %w", err)
+				}
+			}
+			if toFlag != "" {
+				draft.To = parseAddrList(toFlag, cfg.Groups)
+			}
+			if ccFlag != "" {
+				draft.CC = parseAddrList(ccFlag, cfg.Groups)
+			}
+			if subjectFlag != "" {
+				draft.Subject = subjectFlag
+			}
+			if bodyFlag != "" {
+				body, err := readBody(bodyFlag)
+				if err != nil {
+					return err
+				}
+				draft.Body = body
+			}
+
+			if tuiFlag {
+				if len(attachFlags) > 0 {
+					return fmt.Errorf("--attach cannot be used with --tui")
+				}
+				return runTUI(cmd, accountFlag, draft)
+			}
+
+			if len(draft.To) == 0 {
 				return fmt.Errorf("--to is required")
 			}
-			if subjectFlag == "" {
+			if draft.Subject == "" {
 				return fmt.Errorf("--subject is required")
 			}
+			attachments, err := readAttachments(attachFlags, attachNameFlag, os.Stdin)
+			if err != nil {
+				return err
+			}
 
-			body := bodyFlag
-			if body == "-" {
-				b, err := io.ReadAll(os.Stdin)
+			if shouldUseEditor(editorFlag, draft.Body) {
+				body, err := editBody(draft.Body)
+				if errors.Is(err, errEmptyBody) {
+					fmt.Println("Empty message; not sent.")
+					return nil
+				}
 				if err != nil {
-					return fmt.Errorf("failed to read body from stdin: %w", err)
+					return err
 				}
-				body = string(b)
+				draft.Body = body
 			}
 
-			provider, _, err := setupProvider(cmd, accountFlag)
+			if replyToFlag == "" {
+				replyToFlag = cfg.Compose.ReplyTo
+			}
+			replyTo := parseAddrList(replyToFlag, nil)
+			if err := validateAddresses(replyTo); err != nil {
+				return fmt.Errorf("invalid --reply-to: %w", err)
+			}
+
+			provider, accountID, err := setupProvider(cmd, accountFlag)
 			if err != nil {
 				return err
 			}
 
 			email := &domain.Email{
-				To:      parseAddrList(toFlag),
-				CC:      parseAddrList(ccFlag),
-				Subject: subjectFlag,
-				Body:    body,
+				To:      draft.To,
+				CC:      draft.CC,
+				BCC:     draft.BCC,
+				ReplyTo: replyTo,
+				Subject: draft.Subject,
+				Body:    draft.Body,
 				Date:    time.Now(),
+
+				Attachments: attachments,
 			}
 
-			if err := provider.SendMessage(cmd.Context(), email); err != nil {
+			result, err := sendMail(cmd, provider, accountID, email, yesFlag)
+			if err != nil {
 				return fmt.Errorf("failed to send email: %w", err)
 			}
+			if result != sendSent {
+				return printUnsent(result, "compose", "")
+			}
 
 			if jsonFlag {
 				return printJSON(jsonAction{OK: true, Action: "compose"})
@@ -67,27 +137,37 @@ func newComposeCmd() *cobra.Command {
 	cmd.Flags().StringVar(&ccFlag, "cc", "", "CC email addresses (comma-separated)")
 	cmd.Flags().StringVar(&subjectFlag, "subject", "", "email subject")
 	cmd.Flags().StringVar(&bodyFlag, "body", "", "email body (use '-' to read from stdin)")
+	cmd.Flags().StringVar(&replyToFlag, "reply-to", "", "Reply-To address (defaults to compose.reply_to in config)")
+	cmd.Flags().StringVar(&mailtoFlag, "mailto", "", "pre-fill recipients, subject, and body from a mailto: URL")
+	cmd.Flags().BoolVar(&tuiFlag, "tui", false, "open the message in the interactive composer instead of sending")
+	cmd.Flags().BoolVar(&editorFlag, "editor", false, "write the body in $EDITOR (default when --body is absent and stdin is a terminal)")
+	cmd.Flags().BoolVar(&yesFlag, "yes", false, "send without confirming a long recipient list (compose.confirm_recipients)")
+	cmd.Flags().StringArrayVar(&attachFlags, "attach", nil, "attach a file (repeatable; use '-' to read one from stdin)")
+	cmd.Flags().StringVar(&attachNameFlag, "attach-name", "", "file name for the attachment read from stdin (sets its MIME type)")
 	return cmd
 }
 
 func newReplyCmd() *cobra.Command {
-	var accountFlag, bodyFlag string
-	var allFlag bool
+	var accountFlag, bodyFlag, quoteFlag string
+	var allFlag, editorFlag, senderFlag, yesFlag bool
 
 	cmd := &cobra.Command{
 		Use:   "reply <message-id>",
 		Short: "Reply to an email",
+		Long: "Reply to an email. Replies to mailing-list mail go to the list's\n" +
+			"List-Post address; use --sender to reply privately to the author.",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			messageID := args[0]
 
-			body := bodyFlag
-			if body == "-" {
-				b, err := io.ReadAll(os.Stdin)
-				if err != nil {
-					return fmt.Errorf("failed to read body from stdin: %w", err)
-				}
-				body = string(b)
+			quoteMode, err := domain.ParseQuoteMode(quoteFlag)
+			if err != nil {
+				return err
+			}
+
+			body, err := readBody(bodyFlag)
+			if err != nil {
+				return err
 			}
 
 			provider, accountID, err := setupProvider(cmd, accountFlag)
@@ -102,33 +182,60 @@ func newReplyCmd() *cobra.Command {
 			defer db.Close()
 
 			// Fetch the original email to build the reply.
-			original, err := db.GetEmail(cmd.Context(), messageID)
+			original, err := db.GetEmail(cmd.Context(), messageID, accountID)
 			if err != nil {
 				return fmt.Errorf("failed to get email %s: %w", messageID, err)
 			}
 			_ = accountID
 
+			// The editor opens on the quoted original so the user can
+			// write above it or trim it in place.
+			replyBody := body
+			if quote := domain.QuoteReply(original, quoteMode); quote != "" {
+				replyBody += "\n\n" + quote
+			}
+			if shouldUseEditor(editorFlag, body) {
+				replyBody, err = editBody(replyBody)
+				if errors.Is(err, errEmptyBody) {
+					fmt.Println("Empty reply; not sent.")
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			to := original.ReplyRecipient()
+			if senderFlag {
+				to = original.From
+			}
+
 			reply := &domain.Email{
-				To:        []domain.Address{original.From},
+				To:        []domain.Address{to},
 				Subject:   prefixSubject("Re: ", original.Subject),
-				Body:      body + "\n\n" + formatQuote(original),
+				Body:      replyBody,
 				Date:      time.Now(),
-				InReplyTo: original.ID,
-				ThreadID:  original.ThreadID,
+				InReplyTo:  original.ReplyInReplyTo(),
+				References: original.ReplyReferences(),
+				ThreadID:   original.ThreadID,
 			}
 
 			if allFlag {
-				for _, addr := range original.To {
-					reply.CC = append(reply.CC, addr)
-				}
-				for _, addr := range original.CC {
-					reply.CC = append(reply.CC, addr)
+				for _, addr := range append(append([]domain.Address{original.From}, original.To...), original.CC...) {
+					// The sender is already in To unless replying to a list.
+					if !strings.EqualFold(addr.Email, to.Email) {
+						reply.CC = append(reply.CC, addr)
+					}
 				}
 			}
 
-			if err := provider.SendMessage(cmd.Context(), reply); err != nil {
+			result, err := sendMail(cmd, provider, accountID, reply, yesFlag)
+			if err != nil {
 				return fmt.Errorf("failed to send reply: %w", err)
 			}
+			if result != sendSent {
+				return printUnsent(result, "reply", messageID)
+			}
 
 			if jsonFlag {
 				return printJSON(jsonAction{OK: true, Action: "reply", MessageID: messageID})
@@ -142,16 +249,23 @@ func newReplyCmd() *cobra.Command {
 	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID")
 	cmd.Flags().StringVar(&bodyFlag, "body", "", "reply body (use '-' to read from stdin)")
 	cmd.Flags().BoolVar(&allFlag, "all", false, "reply to all recipients")
+	cmd.Flags().BoolVar(&senderFlag, "sender", false, "reply to the sender even for mailing-list mail")
+	cmd.Flags().StringVar(&quoteFlag, "quote", "full", "how much of the original to quote: full, last (newest message only) or none")
+	cmd.Flags().BoolVar(&editorFlag, "editor", false, "write the reply in $EDITOR (default when --body is absent and stdin is a terminal)")
+	cmd.Flags().BoolVar(&yesFlag, "yes", false, "send without confirming a long recipient list (compose.confirm_recipients)")
 	return cmd
 }
 
 func newForwardCmd() *cobra.Command {
 	var accountFlag, toFlag, bodyFlag string
+	var editorFlag, yesFlag, noAttachmentsFlag bool
 
 	cmd := &cobra.Command{
 		Use:   "forward <message-id>",
 		Short: "Forward an email",
-		Args:  cobra.ExactArgs(1),
+		Long: "Forward an email, sending on the files attached to it unless\n" +
+			"--no-attachments is given.",
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			messageID := args[0]
 
@@ -159,16 +273,17 @@ func newForwardCmd() *cobra.Command {
 				return fmt.Errorf("--to is required")
 			}
 
-			body := bodyFlag
-			if body == "-" {
-				b, err := io.ReadAll(os.Stdin)
-				if err != nil {
-					return fmt.Errorf("failed to read body from stdin: %w", err)
-				}
-				body = string(b)
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
 			}
 
-			provider, _, err := setupProvider(cmd, accountFlag)
+			body, err := readBody(bodyFlag)
+			if err != nil {
+				return err
+			}
+
+			provider, accountID, err := setupProvider(cmd, accountFlag)
 			if err != nil {
 				return err
 			}
@@ -179,21 +294,46 @@ func newForwardCmd() *cobra.Command {
 			}
 			defer db.Close()
 
-			original, err := db.GetEmail(cmd.Context(), messageID)
+			original, err := db.GetEmail(cmd.Context(), messageID, accountID)
 			if err != nil {
 				return fmt.Errorf("failed to get email %s: %w", messageID, err)
 			}
 
+			fwdBody := body + "\n\n---------- Forwarded message ----------\n" + formatForward(original)
+			if shouldUseEditor(editorFlag, body) {
+				fwdBody, err = editBody(fwdBody)
+				if errors.Is(err, errEmptyBody) {
+					fmt.Println("Empty message; not forwarded.")
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			var attachments []domain.Attachment
+			if !noAttachmentsFlag {
+				attachments, err = app.ForwardAttachments(cmd.Context(), provider, messageID)
+				if err != nil {
+					return fmt.Errorf("failed to get attachments to forward: %w", err)
+				}
+			}
+
 			fwd := &domain.Email{
-				To:      parseAddrList(toFlag),
-				Subject: prefixSubject("Fwd: ", original.Subject),
-				Body:    body + "\n\n---------- Forwarded message ----------\n" + formatForward(original),
-				Date:    time.Now(),
+				To:          parseAddrList(toFlag, cfg.Groups),
+				Subject:     prefixSubject("Fwd: ", original.Subject),
+				Body:        fwdBody,
+				Date:        time.Now(),
+				Attachments: attachments,
 			}
 
-			if err := provider.SendMessage(cmd.Context(), fwd); err != nil {
+			result, err := sendMail(cmd, provider, accountID, fwd, yesFlag)
+			if err != nil {
 				return fmt.Errorf("failed to forward: %w", err)
 			}
+			if result != sendSent {
+				return printUnsent(result, "forward", messageID)
+			}
 
 			if jsonFlag {
 				return printJSON(jsonAction{OK: true, Action: "forward", MessageID: messageID})
@@ -207,6 +347,9 @@ func newForwardCmd() *cobra.Command {
 	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID")
 	cmd.Flags().StringVar(&toFlag, "to", "", "recipient email addresses (comma-separated)")
 	cmd.Flags().StringVar(&bodyFlag, "body", "", "optional message to prepend (use '-' for stdin)")
+	cmd.Flags().BoolVar(&editorFlag, "editor", false, "edit the forwarded message in $EDITOR (default when --body is absent and stdin is a terminal)")
+	cmd.Flags().BoolVar(&yesFlag, "yes", false, "send without confirming a long recipient list (compose.confirm_recipients)")
+	cmd.Flags().BoolVar(&noAttachmentsFlag, "no-attachments", false, "forward the text only, without the original's attachments")
 	return cmd
 }
 
@@ -218,14 +361,24 @@ func newArchiveCmd() *cobra.Command {
 		Short: "Archive an email (remove from Inbox)",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			provider, _, err := setupProvider(cmd, accountFlag)
+			provider, accountID, err := setupProvider(cmd, accountFlag)
 			if err != nil {
 				return err
 			}
 
-			if err := provider.ModifyLabels(cmd.Context(), args[0], nil, []string{domain.LabelInbox}); err != nil {
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			applied, err := messageAction(cmd.Context(), os.Stdout, provider, db, accountID, args[0], "archive", dryRunFlag)
+			if err != nil {
 				return fmt.Errorf("failed to archive: %w", err)
 			}
+			if !applied {
+				return nil
+			}
 
 			if jsonFlag {
 				return printJSON(jsonAction{OK: true, Action: "archive", MessageID: args[0]})
@@ -241,27 +394,47 @@ func newArchiveCmd() *cobra.Command {
 }
 
 func newTrashCmd() *cobra.Command {
+	return newTrashMoveCmd("trash", "Move an email to trash", "Email moved to trash.")
+}
+
+func newUntrashCmd() *cobra.Command {
+	return newTrashMoveCmd("untrash", "Restore an email from trash to the inbox", "Email restored from trash.")
+}
+
+// newTrashMoveCmd builds the trash and untrash commands, which differ only
+// in the app action they apply. Both update the local copy of the email.
+func newTrashMoveCmd(action, short, done string) *cobra.Command {
 	var accountFlag string
 
 	cmd := &cobra.Command{
-		Use:   "trash <message-id>",
-		Short: "Move an email to trash",
+		Use:   action + " <message-id>",
+		Short: short,
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			provider, _, err := setupProvider(cmd, accountFlag)
+			provider, accountID, err := setupProvider(cmd, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			db, err := openDB()
 			if err != nil {
 				return err
 			}
+			defer db.Close()
 
-			if err := provider.TrashMessage(cmd.Context(), args[0]); err != nil {
-				return fmt.Errorf("failed to trash: %w", err)
+			applied, err := messageAction(cmd.Context(), os.Stdout, provider, db, accountID, args[0], action, dryRunFlag)
+			if err != nil {
+				return fmt.Errorf("failed to %s: %w", action, err)
+			}
+			if !applied {
+				return nil
 			}
 
 			if jsonFlag {
-				return printJSON(jsonAction{OK: true, Action: "trash", MessageID: args[0]})
+				return printJSON(jsonAction{OK: true, Action: action, MessageID: args[0]})
 			}
 
-			fmt.Println("Email moved to trash.")
+			fmt.Println(done)
 			return nil
 		},
 	}
@@ -270,6 +443,68 @@ func newTrashCmd() *cobra.Command {
 	return cmd
 }
 
+func newSpamCmd() *cobra.Command {
+	return newSpamReportCmd("spam", "spam", "Report an email as spam (move it to Spam)", "Reported as spam.")
+}
+
+func newNotSpamCmd() *cobra.Command {
+	return newSpamReportCmd("not-spam", "notspam", "Mark an email as not spam (move it back to Inbox)", notSpamDone)
+}
+
+// notSpamDone is printed once an email is moved out of Spam.
+const notSpamDone = "Moved back to inbox."
+
+// newSpamReportCmd builds the spam and not-spam commands, which differ only
+// in the app action they apply. spam also takes --not to do what not-spam
+// does.
+func newSpamReportCmd(use, action, short, done string) *cobra.Command {
+	var accountFlag string
+	var notFlag bool
+
+	cmd := &cobra.Command{
+		Use:   use + " <message-id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			provider, accountID, err := setupProvider(cmd, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			action, done := action, done
+			if notFlag {
+				action, done = "notspam", notSpamDone
+			}
+			applied, err := messageAction(cmd.Context(), os.Stdout, provider, db, accountID, args[0], action, dryRunFlag)
+			if err != nil {
+				return fmt.Errorf("failed to %s: %w", use, err)
+			}
+			if !applied {
+				return nil
+			}
+
+			if jsonFlag {
+				return printJSON(jsonAction{OK: true, Action: action, MessageID: args[0]})
+			}
+
+			fmt.Println(done)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID")
+	if action == "spam" {
+		cmd.Flags().BoolVar(&notFlag, "not", false, "move the email out of Spam instead, like not-spam")
+	}
+	return cmd
+}
+
 func newStarCmd() *cobra.Command {
 	var accountFlag string
 	var removeFlag bool
@@ -279,27 +514,26 @@ func newStarCmd() *cobra.Command {
 		Short: "Star or unstar an email",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			provider, _, err := setupProvider(cmd, accountFlag)
+			provider, accountID, err := setupProvider(cmd, accountFlag)
 			if err != nil {
 				return err
 			}
 
-			var add, remove []string
-			if removeFlag {
-				remove = []string{domain.LabelStarred}
-			} else {
-				add = []string{domain.LabelStarred}
+			db, err := openDB()
+			if err != nil {
+				return err
 			}
+			defer db.Close()
 
-			if err := provider.ModifyLabels(cmd.Context(), args[0], add, remove); err != nil {
+			action := "star"
+			if removeFlag {
+				action = "unstar"
+			}
+			if err := app.ApplyAction(cmd.Context(), provider, db, accountID, args[0], action); err != nil {
 				return fmt.Errorf("failed to update star: %w", err)
 			}
 
 			if jsonFlag {
-				action := "star"
-				if removeFlag {
-					action = "unstar"
-				}
 				return printJSON(jsonAction{OK: true, Action: action, MessageID: args[0]})
 			}
 
@@ -326,7 +560,7 @@ func newMarkReadCmd() *cobra.Command {
 		Short: "Mark an email as read or unread",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			provider, _, err := setupProvider(cmd, accountFlag)
+			provider, accountID, err := setupProvider(cmd, accountFlag)
 			if err != nil {
 				return err
 			}
@@ -340,7 +574,7 @@ func newMarkReadCmd() *cobra.Command {
 			read := !unreadFlag
 
 			// Update local DB first.
-			if err := db.SetEmailRead(cmd.Context(), args[0], read); err != nil {
+			if err := db.SetEmailRead(cmd.Context(), args[0], accountID, read); err != nil {
 				return fmt.Errorf("failed to update local state: %w", err)
 			}
 
@@ -371,6 +605,113 @@ func newMarkReadCmd() *cobra.Command {
 	return cmd
 }
 
+func newFlagCmd() *cobra.Command {
+	var accountFlag string
+	var clearFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "flag <message-id> <flag>",
+		Short: "Set or clear a local flag (follow-up, todo, waiting)",
+		Long: "Mark an email with a local flag. Flags are stored only in termail's\n" +
+			"database and are not synced to Gmail. Filter with `termail list --flag`.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			messageID, flag := args[0], args[1]
+			if !domain.IsValidFlag(flag) {
+				return fmt.Errorf("unknown flag %q (use %s)", flag, strings.Join(domain.Flags, ", "))
+			}
+
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			accountID, err := resolveAccountFlag(db, accountFlag)
+			if err != nil {
+				return err
+			}
+			if _, err := db.GetEmail(cmd.Context(), messageID, accountID); err != nil {
+				return fmt.Errorf("failed to get email %s: %w", messageID, err)
+			}
+			if err := db.SetEmailFlag(cmd.Context(), messageID, flag, !clearFlag); err != nil {
+				return fmt.Errorf("failed to update flag: %w", err)
+			}
+
+			if jsonFlag {
+				return printJSON(jsonAction{OK: true, Action: "flag", MessageID: messageID})
+			}
+
+			if clearFlag {
+				fmt.Printf("Cleared %s.\n", flag)
+			} else {
+				fmt.Printf("Flagged %s.\n", flag)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID")
+	cmd.Flags().BoolVar(&clearFlag, "clear", false, "remove the flag instead of setting it")
+	return cmd
+}
+
+func newSnoozeCmd() *cobra.Command {
+	var clearFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "snooze <message-id> [duration|RFC3339]",
+		Short: "Hide an email from the inbox until a later time",
+		Long: "Snooze an email so it is hidden from the inbox until the given time.\n" +
+			"The time is a duration (90m, 2h), a number of days (3d), or an RFC 3339\n" +
+			"timestamp. Snoozes are local and are not synced to Gmail.",
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			messageID := args[0]
+
+			var until time.Time
+			if !clearFlag {
+				if len(args) < 2 {
+					return fmt.Errorf("snooze time is required (or use --clear)")
+				}
+				var err error
+				until, err = domain.ParseSnoozeUntil(args[1], time.Now())
+				if err != nil {
+					return err
+				}
+			}
+
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			if err := db.SnoozeEmail(cmd.Context(), messageID, until); err != nil {
+				return fmt.Errorf("failed to snooze: %w", err)
+			}
+
+			if jsonFlag {
+				action := jsonAction{OK: true, Action: "snooze", MessageID: messageID}
+				if !until.IsZero() {
+					action.Until = until.Format(time.RFC3339)
+				}
+				return printJSON(action)
+			}
+
+			if clearFlag {
+				fmt.Println("Snooze cleared.")
+			} else {
+				fmt.Printf("Snoozed until %s.\n", until.Local().Format("Mon Jan 2 15:04"))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&clearFlag, "clear", false, "remove the snooze and return the email to the inbox")
+	return cmd
+}
+
 func newLabelModifyCmd() *cobra.Command {
 	var accountFlag string
 
@@ -399,9 +740,13 @@ func newLabelModifyCmd() *cobra.Command {
 				remove = splitTrim(removeLabels)
 			}
 
-			if err := provider.ModifyLabels(cmd.Context(), args[0], add, remove); err != nil {
+			applied, err := modifyLabels(cmd.Context(), os.Stdout, provider, args[0], add, remove, dryRunFlag)
+			if err != nil {
 				return fmt.Errorf("failed to modify labels: %w", err)
 			}
+			if !applied {
+				return nil
+			}
 
 			if jsonFlag {
 				return printJSON(jsonAction{OK: true, Action: "label-modify", MessageID: args[0]})
@@ -418,6 +763,149 @@ func newLabelModifyCmd() *cobra.Command {
 	return cmd
 }
 
+func newMoveCmd() *cobra.Command {
+	var accountFlag string
+
+	cmd := &cobra.Command{
+		Use:   "move <message-id> <label>",
+		Short: "Move an email to a folder/label",
+		Long: "Move an email to the given label (ID or name), removing it from the\n" +
+			"INBOX, SPAM, and TRASH system folders in a single change.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			messageID, labelArg := args[0], args[1]
+
+			provider, accountID, err := setupProvider(cmd, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			ctx := cmd.Context()
+			labels, err := provider.ListLabels(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list labels: %w", err)
+			}
+			target, ok := findLabel(labels, labelArg)
+			if !ok {
+				return fmt.Errorf("label not found: %s", labelArg)
+			}
+
+			email, err := db.GetEmail(ctx, messageID, accountID)
+			if err != nil {
+				return fmt.Errorf("failed to get email %s: %w", messageID, err)
+			}
+
+			add, remove, result := app.MoveLabelChanges(email.Labels, target.ID)
+			if len(add) > 0 || len(remove) > 0 {
+				if err := provider.ModifyLabels(ctx, messageID, add, remove); err != nil {
+					return fmt.Errorf("failed to move: %w", err)
+				}
+				if err := db.SetEmailLabels(ctx, messageID, accountID, result); err != nil {
+					return fmt.Errorf("failed to update local labels: %w", err)
+				}
+			}
+
+			if jsonFlag {
+				return printJSON(jsonAction{OK: true, Action: "move", MessageID: messageID})
+			}
+
+			fmt.Printf("Moved to %s.\n", target.Name)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID")
+	return cmd
+}
+
+// findLabel looks up a label by ID, or by name ignoring case.
+func findLabel(labels []domain.Label, arg string) (domain.Label, bool) {
+	for _, l := range labels {
+		if l.ID == arg {
+			return l, true
+		}
+	}
+	for _, l := range labels {
+		if strings.EqualFold(l.Name, arg) {
+			return l, true
+		}
+	}
+	return domain.Label{}, false
+}
+
+func newUnsubscribeCmd() *cobra.Command {
+	var accountFlag string
+
+	cmd := &cobra.Command{
+		Use:   "unsubscribe <message-id>",
+		Short: "Unsubscribe from a mailing list using its List-Unsubscribe header",
+		Long: "Unsubscribe from the list that sent a message. If the message advertises a\n" +
+			"mailto: target, an unsubscribe email is sent; otherwise the unsubscribe URL is printed.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			messageID := args[0]
+
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			accountID, err := resolveAccountFlag(db, accountFlag)
+			if err != nil {
+				return err
+			}
+			email, err := db.GetEmail(cmd.Context(), messageID, accountID)
+			if err != nil {
+				return fmt.Errorf("failed to get email %s: %w", messageID, err)
+			}
+
+			unsub := domain.ParseListUnsubscribe(email.ListUnsubscribe)
+			if unsub.IsEmpty() {
+				return fmt.Errorf("message %s has no List-Unsubscribe header", messageID)
+			}
+
+			if unsub.Mailto == "" {
+				if jsonFlag {
+					return printJSON(jsonAction{OK: true, Action: "unsubscribe", MessageID: messageID, URL: unsub.URL})
+				}
+				fmt.Printf("Open this URL to unsubscribe:\n\n  %s\n", unsub.URL)
+				return nil
+			}
+
+			request, err := unsub.MailtoEmail()
+			if err != nil {
+				return err
+			}
+			request.Date = time.Now()
+
+			provider, _, err := setupProvider(cmd, accountFlag)
+			if err != nil {
+				return err
+			}
+			if err := provider.SendMessage(cmd.Context(), request); err != nil {
+				return fmt.Errorf("failed to send unsubscribe request: %w", err)
+			}
+
+			if jsonFlag {
+				return printJSON(jsonAction{OK: true, Action: "unsubscribe", MessageID: messageID, Email: request.To[0].Email})
+			}
+
+			fmt.Printf("Unsubscribe request sent to %s.\n", request.To[0].Email)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID")
+	return cmd
+}
+
 // setupProvider creates an authenticated Gmail provider for the resolved account.
 func setupProvider(cmd *cobra.Command, accountFlag string) (*gmail.Provider, string, error) {
 	db, err := openDB()
@@ -439,18 +927,22 @@ func setupProvider(cmd *cobra.Command, accountFlag string) (*gmail.Provider, str
 		return nil, "", err
 	}
 
-	tokenStore := store.NewKeyringTokenStore()
-	p := gmail.New(accountID, tokenStore)
+	tokenStore, err := newTokenStore(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	p := newGmailProvider(cfg, accountID, tokenStore)
 
 	return p, accountID, nil
 }
 
-// parseAddrList splits a comma-separated string of email addresses.
-func parseAddrList(s string) []domain.Address {
+// parseAddrList splits a comma-separated string of email addresses,
+// expanding names of address groups.
+func parseAddrList(s string, groups map[string]string) []domain.Address {
 	if s == "" {
 		return nil
 	}
-	parts := splitTrim(s)
+	parts := domain.ExpandGroups(splitTrim(s), groups)
 	addrs := make([]domain.Address, len(parts))
 	for i, p := range parts {
 		addrs[i] = domain.Address{Email: p}
@@ -458,6 +950,16 @@ func parseAddrList(s string) []domain.Address {
 	return addrs
 }
 
+// validateAddresses checks that every address is a well-formed RFC 5322 address.
+func validateAddresses(addrs []domain.Address) error {
+	for _, a := range addrs {
+		if _, err := mail.ParseAddress(a.Email); err != nil {
+			return fmt.Errorf("%q is not a valid email address", a.Email)
+		}
+	}
+	return nil
+}
+
 // splitTrim splits by comma and trims whitespace.
 func splitTrim(s string) []string {
 	parts := strings.Split(s, ",")
@@ -479,26 +981,16 @@ func prefixSubject(prefix, subject string) string {
 	return prefix + subject
 }
 
-// formatQuote formats an email for quoting in a reply.
-func formatQuote(e *domain.Email) string {
-	var b strings.Builder
-	fmt.Fprintf(&b, "On %s, %s wrote:\n", e.Date.Format("Mon, Jan 2, 2006 at 3:04 PM"), e.From)
-	for _, line := range strings.Split(e.Body, "\n") {
-		fmt.Fprintf(&b, "> %s\n", line)
-	}
-	return b.String()
-}
-
 // formatForward formats an email for forwarding.
 func formatForward(e *domain.Email) string {
 	var b strings.Builder
-	fmt.Fprintf(&b, "From: %s\n", e.From)
-	fmt.Fprintf(&b, "Date: %s\n", e.Date.Format("Mon, Jan 2, 2006 at 3:04 PM"))
+	fmt.Fprintf(&b, "From: %s\n", e.From.Display())
+	fmt.Fprintf(&b, "Date: %s\n", domain.FormatDate(e.Date, "Mon, Jan 2, 2006 at 3:04 PM"))
 	fmt.Fprintf(&b, "Subject: %s\n", e.Subject)
 	if len(e.To) > 0 {
 		to := make([]string, len(e.To))
 		for i, a := range e.To {
-			to[i] = a.String()
+			to[i] = a.Display()
 		}
 		fmt.Fprintf(&b, "To: %s\n", strings.Join(to, ", "))
 	}
@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+func TestFindLabel(t *testing.T) {
+	labels := []domain.Label{
+		{ID: "INBOX", Name: "INBOX"},
+		{ID: "Label_1", Name: "Receipts"},
+	}
+
+	if l, ok := findLabel(labels, "Label_1"); !ok || l.Name != "Receipts" {
+		t.Errorf("findLabel(ID) = %+v, %v", l, ok)
+	}
+	if l, ok := findLabel(labels, "receipts"); !ok || l.ID != "Label_1" {
+		t.Errorf("findLabel(name) = %+v, %v", l, ok)
+	}
+	if _, ok := findLabel(labels, "Missing"); ok {
+		t.Error("findLabel() found a label that does not exist")
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+// stdinAttachmentName names an attachment read from stdin when
+// --attach-name is not given.
+const stdinAttachmentName = "attachment"
+
+// readAttachments reads the files named by --attach, reading the one given
+// as "-" from stdin under the name stdinName.
+func readAttachments(paths []string, stdinName string, stdin io.Reader) ([]domain.Attachment, error) {
+	var attachments []domain.Attachment
+	fromStdin := false
+	for _, path := range paths {
+		var (
+			name string
+			data []byte
+			err  error
+		)
+		if path == "-" {
+			if fromStdin {
+				return nil, fmt.Errorf("only one attachment can be read from stdin")
+			}
+			fromStdin = true
+			name = stdinName
+			if name == "" {
+				name = stdinAttachmentName
+			}
+			data, err = io.ReadAll(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read attachment from stdin: %w", err)
+			}
+		} else {
+			name = filepath.Base(path)
+			data, err = os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read attachment: %w", err)
+			}
+		}
+		if data == nil {
+			data = []byte{}
+		}
+		attachments = append(attachments, domain.Attachment{
+			Filename: name,
+			MIMEType: attachmentMIMEType(name),
+			Size:     int64(len(data)),
+			Data:     data,
+		})
+	}
+	return attachments, nil
+}
+
+// attachmentMIMEType guesses a file's media type from its extension,
+// falling back to application/octet-stream.
+func attachmentMIMEType(name string) string {
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		if mediaType, _, err := mime.ParseMediaType(t); err == nil {
+			return mediaType
+		}
+	}
+	return "application/octet-stream"
+}
+
+// checkStdinUse rejects reading both the body and an attachment from
+// stdin, which can only be read once.
+func checkStdinUse(bodyFlag string, attachFlags []string) error {
+	if bodyFlag != "-" {
+		return nil
+	}
+	for _, path := range attachFlags {
+		if path == "-" {
+			return fmt.Errorf("--body - and --attach - both read stdin; pass one of them from a file")
+		}
+	}
+	return nil
+}
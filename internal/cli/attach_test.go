@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/rfc822"
+)
+
+func TestAttachmentMIMEType(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"report.pdf", "application/pdf"},
+		{"chart.PNG", "image/png"},
+		{"data.json", "application/json"},
+		{"page.html", "text/html"},
+		{"notes", "application/octet-stream"},
+		{"archive.unknownext", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		if got := attachmentMIMEType(tt.name); got != tt.want {
+			t.Errorf("attachmentMIMEType(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadAttachments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chart.png")
+	if err := os.WriteFile(path, []byte("\x89PNG"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readAttachments([]string{path, "-"}, "report.pdf", strings.NewReader("%PDF-1.7"))
+	if err != nil {
+		t.Fatalf("readAttachments() error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("readAttachments() = %d attachments, want 2", len(got))
+	}
+	if a := got[0]; a.Filename != "chart.png" || a.MIMEType != "image/png" || string(a.Data) != "\x89PNG" || a.Size != 4 {
+		t.Errorf("file attachment = %+v", a)
+	}
+	if a := got[1]; a.Filename != "report.pdf" || a.MIMEType != "application/pdf" || string(a.Data) != "%PDF-1.7" || a.Size != 8 {
+		t.Errorf("stdin attachment = %+v", a)
+	}
+}
+
+func TestReadAttachments_StdinDefaults(t *testing.T) {
+	got, err := readAttachments([]string{"-"}, "", strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("readAttachments() error: %v", err)
+	}
+	if len(got) != 1 || got[0].Filename != stdinAttachmentName || got[0].MIMEType != "application/octet-stream" || got[0].Data == nil {
+		t.Errorf("readAttachments(-) = %+v, want an empty %q attachment", got, stdinAttachmentName)
+	}
+
+	if _, err := readAttachments([]string{"-", "-"}, "", strings.NewReader("x")); err == nil {
+		t.Error("readAttachments(-, -) error = nil, want an error")
+	}
+	if _, err := readAttachments([]string{filepath.Join(t.TempDir(), "missing")}, "", nil); err == nil {
+		t.Error("readAttachments(missing file) error = nil, want an error")
+	}
+}
+
+func TestCheckStdinUse(t *testing.T) {
+	if err := checkStdinUse("-", []string{"a.txt", "-"}); err == nil {
+		t.Error("checkStdinUse(--body -, --attach -) error = nil, want a conflict")
+	}
+	if err := checkStdinUse("-", []string{"a.txt"}); err != nil {
+		t.Errorf("checkStdinUse(--body -, file) error = %v", err)
+	}
+	if err := checkStdinUse("hello", []string{"-"}); err != nil {
+		t.Errorf("checkStdinUse(--body text, --attach -) error = %v", err)
+	}
+}
+
+func TestStdinAttachment_IsSentAsMultipart(t *testing.T) {
+	attachments, err := readAttachments([]string{"-"}, "report.csv", strings.NewReader("name,total\nAnn,3\n"))
+	if err != nil {
+		t.Fatalf("readAttachments() error: %v", err)
+	}
+	email := &domain.Email{
+		From:        domain.Address{Email: "me@example.com"},
+		To:          []domain.Address{{Email: "you@example.com"}},
+		Subject:     "Report",
+		Body:        "Attached.",
+		Attachments: attachments,
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(rfc822.Build(email)))
+	if err != nil {
+		t.Fatalf("ReadMessage() error: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q (%v), want multipart/mixed", msg.Header.Get("Content-Type"), err)
+	}
+	r := multipart.NewReader(msg.Body, params["boundary"])
+
+	body, err := r.NextPart()
+	if err != nil {
+		t.Fatalf("NextPart(body) error: %v", err)
+	}
+	if b, _ := io.ReadAll(body); string(b) != "Attached." {
+		t.Errorf("body part = %q, want %q", b, "Attached.")
+	}
+
+	part, err := r.NextPart()
+	if err != nil {
+		t.Fatalf("NextPart(attachment) error: %v", err)
+	}
+	if part.FileName() != "report.csv" {
+		t.Errorf("attachment filename = %q, want report.csv", part.FileName())
+	}
+	// multipart.Reader decodes quoted-printable only, so read the base64.
+	raw, _ := io.ReadAll(part)
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(raw), "\r\n", ""))
+	if err != nil {
+		t.Fatalf("decode attachment error: %v", err)
+	}
+	if string(data) != "name,total\nAnn,3\n" {
+		t.Errorf("attachment content = %q", data)
+	}
+	if _, err := r.NextPart(); err != io.EOF {
+		t.Errorf("NextPart() after attachment error = %v, want EOF", err)
+	}
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/lu-zhengda/termail/internal/app"
+	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// batchResult records the outcome of an action on one message.
+type batchResult struct {
+	ID  string
+	Err error
+}
+
+func newBatchCmd() *cobra.Command {
+	var accountFlag string
+
+	cmd := &cobra.Command{
+		Use:   "batch <action> [message-id...]",
+		Short: "Apply an action to several messages by ID",
+		Long: "Apply an action to each message ID given as arguments, or read from\n" +
+			"stdin one per line when none are given. Every ID is attempted; the\n" +
+			"command exits non-zero if any failed.\n\n" +
+			"Supported actions: " + strings.Join(app.Actions, ", ") + ".",
+		Example: `  termail batch archive 18c1a 18c1b
+  termail search "from:news@example.com" --json | jq -r '.[].id' | termail batch trash`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			action := args[0]
+			if !isBatchAction(action) {
+				return fmt.Errorf("unsupported action: %s (use one of %s)", action, strings.Join(app.Actions, ", "))
+			}
+
+			ids := args[1:]
+			if len(ids) == 0 {
+				var err error
+				ids, err = readIDs(cmd.InOrStdin())
+				if err != nil {
+					return err
+				}
+			}
+			if len(ids) == 0 {
+				return fmt.Errorf("no message IDs given")
+			}
+
+			p, accountID, err := setupProvider(cmd, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			results := applyBatch(cmd.Context(), p, db, accountID, action, ids)
+			failed := 0
+			for _, r := range results {
+				if r.Err != nil {
+					failed++
+				}
+			}
+
+			if jsonFlag {
+				if err := printJSON(toJSONBatch(action, results)); err != nil {
+					return err
+				}
+			} else {
+				for _, r := range results {
+					if r.Err != nil {
+						fmt.Fprintf(os.Stderr, "FAIL %s: %v\n", r.ID, r.Err)
+					} else {
+						fmt.Printf("ok   %s\n", r.ID)
+					}
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d messages failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID")
+	return cmd
+}
+
+// applyBatch applies action to every ID of accountID in turn, continuing
+// past failures.
+func applyBatch(ctx context.Context, p provider.EmailProvider, s store.Store, accountID, action string, ids []string) []batchResult {
+	results := make([]batchResult, len(ids))
+	for i, id := range ids {
+		results[i] = batchResult{ID: id, Err: app.ApplyAction(ctx, p, s, accountID, id, action)}
+	}
+	return results
+}
+
+// readIDs reads newline-separated message IDs, skipping blank lines.
+func readIDs(r io.Reader) ([]string, error) {
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read message IDs: %w", err)
+	}
+	return ids, nil
+}
+
+// isBatchAction reports whether action is accepted by the batch command.
+func isBatchAction(action string) bool {
+	for _, a := range app.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/store/sqlite"
+)
+
+// fakeBatchProvider trashes messages, failing for one ID.
+type fakeBatchProvider struct {
+	provider.EmailProvider
+	trashed []string
+	failOn  string
+}
+
+func (f *fakeBatchProvider) TrashMessage(_ context.Context, id string) error {
+	if id == f.failOn {
+		return fmt.Errorf("boom")
+	}
+	f.trashed = append(f.trashed, id)
+	return nil
+}
+
+func TestReadIDs(t *testing.T) {
+	ids, err := readIDs(strings.NewReader("a\n\n  b  \nc"))
+	if err != nil {
+		t.Fatalf("readIDs() error: %v", err)
+	}
+	if !slices.Equal(ids, []string{"a", "b", "c"}) {
+		t.Errorf("readIDs() = %v, want [a b c]", ids)
+	}
+}
+
+func TestApplyBatch_ContinuesPastFailures(t *testing.T) {
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New() error: %v", err)
+	}
+	defer db.Close()
+
+	p := &fakeBatchProvider{failOn: "b"}
+	results := applyBatch(context.Background(), p, db, "acc-1", "trash", []string{"a", "b", "c"})
+
+	if !slices.Equal(p.trashed, []string{"a", "c"}) {
+		t.Errorf("trashed = %v, want [a c]", p.trashed)
+	}
+	if len(results) != 3 || results[0].Err != nil || results[1].Err == nil || results[2].Err != nil {
+		t.Errorf("results = %+v, want only b to fail", results)
+	}
+
+	out := toJSONBatch("trash", results)
+	if out.OK || out.Failed != 1 || out.Results[1].Error != "boom" {
+		t.Errorf("toJSONBatch() = %+v, want ok=false failed=1", out)
+	}
+}
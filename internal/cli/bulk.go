@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider"
+)
+
+// bulkPageSize is the number of search results requested per provider page.
+const bulkPageSize = 100
+
+// bulkActions lists the actions supported by the bulk command.
+var bulkActions = []string{"trash", "archive", "read", "unread"}
+
+// bulkProvider is the subset of provider.EmailProvider used by bulk actions.
+type bulkProvider interface {
+	Search(ctx context.Context, query string, opts provider.ListOptions) ([]domain.Email, string, error)
+	BatchModifyLabels(ctx context.Context, msgIDs []string, add, remove []string) error
+	TrashMessage(ctx context.Context, msgID string) error
+}
+
+func newBulkCmd() *cobra.Command {
+	var accountFlag, queryFlag, actionFlag string
+	var dryRunFlag, yesFlag bool
+	var limitFlag int
+
+	cmd := &cobra.Command{
+		Use:   "bulk",
+		Short: "Apply an action to every message matching a Gmail query",
+		Long: "Run a Gmail search on the server and apply an action to every match,\n" +
+			"without syncing first. Supported actions: " + strings.Join(bulkActions, ", ") + ".",
+		Example: `  termail bulk --query "from:news@example.com before:2023/01/01" --action trash --dry-run
+  termail bulk --query "label:promotions is:unread" --action read --yes`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if queryFlag == "" {
+				return fmt.Errorf("--query is required")
+			}
+			if !isBulkAction(actionFlag) {
+				return fmt.Errorf("unsupported action: %s (use one of %s)", actionFlag, strings.Join(bulkActions, ", "))
+			}
+
+			p, _, err := setupProvider(cmd, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			ids, err := collectQueryMatches(ctx, p, queryFlag, limitFlag)
+			if err != nil {
+				return err
+			}
+
+			if dryRunFlag || len(ids) == 0 {
+				if jsonFlag {
+					return printJSON(jsonBulk{OK: true, Action: actionFlag, Query: queryFlag, Count: len(ids), DryRun: dryRunFlag, MessageIDs: ids})
+				}
+				fmt.Printf("%d messages match %q.\n", len(ids), queryFlag)
+				if dryRunFlag && len(ids) > 0 {
+					fmt.Printf("Dry run: would %s %d messages.\n", actionFlag, len(ids))
+				}
+				return nil
+			}
+
+			if !yesFlag {
+				if jsonFlag {
+					return fmt.Errorf("--yes is required with --json")
+				}
+				prompt := fmt.Sprintf("%s %d messages matching %q?", capitalize(actionFlag), len(ids), queryFlag)
+				if !confirm(os.Stdin, os.Stdout, prompt) {
+					fmt.Println("Aborted.")
+					return nil
+				}
+			}
+
+			if err := applyBulkAction(ctx, p, actionFlag, ids); err != nil {
+				return err
+			}
+
+			if jsonFlag {
+				return printJSON(jsonBulk{OK: true, Action: actionFlag, Query: queryFlag, Count: len(ids), MessageIDs: ids})
+			}
+
+			fmt.Printf("Applied %s to %d messages.\n", actionFlag, len(ids))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID")
+	cmd.Flags().StringVar(&queryFlag, "query", "", "Gmail search query (e.g. \"from:x before:2023/01/01\")")
+	cmd.Flags().StringVar(&actionFlag, "action", "", "action to apply: "+strings.Join(bulkActions, ", "))
+	cmd.Flags().BoolVar(&dryRunFlag, "dry-run", false, "show how many messages match without changing anything")
+	cmd.Flags().BoolVar(&yesFlag, "yes", false, "skip the confirmation prompt")
+	cmd.Flags().IntVar(&limitFlag, "limit", 0, "maximum number of messages to act on (0 for no limit)")
+	return cmd
+}
+
+// collectQueryMatches pages through provider search results and returns the
+// matching message IDs, stopping after limit results when limit is positive.
+func collectQueryMatches(ctx context.Context, p bulkProvider, query string, limit int) ([]string, error) {
+	var ids []string
+	pageToken := ""
+	for {
+		emails, next, err := p.Search(ctx, query, provider.ListOptions{
+			PageToken:  pageToken,
+			MaxResults: bulkPageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search: %w", err)
+		}
+		for _, e := range emails {
+			ids = append(ids, e.ID)
+			if limit > 0 && len(ids) >= limit {
+				return ids, nil
+			}
+		}
+		if next == "" {
+			return ids, nil
+		}
+		pageToken = next
+	}
+}
+
+// applyBulkAction applies action to every message in ids. Label changes are
+// sent as batch requests; trash is applied per message.
+func applyBulkAction(ctx context.Context, p bulkProvider, action string, ids []string) error {
+	var add, remove []string
+	switch action {
+	case "trash":
+		for _, id := range ids {
+			if err := p.TrashMessage(ctx, id); err != nil {
+				return fmt.Errorf("failed to trash: %w", err)
+			}
+		}
+		return nil
+	case "archive":
+		remove = []string{domain.LabelInbox}
+	case "read":
+		remove = []string{domain.LabelUnread}
+	case "unread":
+		add = []string{domain.LabelUnread}
+	default:
+		return fmt.Errorf("unsupported action: %s", action)
+	}
+
+	if err := p.BatchModifyLabels(ctx, ids, add, remove); err != nil {
+		return fmt.Errorf("failed to %s: %w", action, err)
+	}
+	return nil
+}
+
+func isBulkAction(action string) bool {
+	for _, a := range bulkActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// confirm prints prompt and reports whether the user answered yes.
+func confirm(in io.Reader, out io.Writer, prompt string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", prompt)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider"
+)
+
+// fakeBulkProvider serves search results in pages and records actions.
+type fakeBulkProvider struct {
+	pages   [][]domain.Email
+	queries []string
+	trashed []string
+	batches []fakeBatch
+	failOn  string
+}
+
+type fakeBatch struct {
+	ids         []string
+	add, remove []string
+}
+
+func (f *fakeBulkProvider) Search(_ context.Context, query string, opts provider.ListOptions) ([]domain.Email, string, error) {
+	f.queries = append(f.queries, query)
+	page := 0
+	if opts.PageToken != "" {
+		fmt.Sscanf(opts.PageToken, "page-%d", &page)
+	}
+	if page >= len(f.pages) {
+		return nil, "", nil
+	}
+	next := ""
+	if page+1 < len(f.pages) {
+		next = fmt.Sprintf("page-%d", page+1)
+	}
+	return f.pages[page], next, nil
+}
+
+func (f *fakeBulkProvider) BatchModifyLabels(_ context.Context, ids []string, add, remove []string) error {
+	f.batches = append(f.batches, fakeBatch{ids: ids, add: add, remove: remove})
+	return nil
+}
+
+func (f *fakeBulkProvider) TrashMessage(_ context.Context, id string) error {
+	if id == f.failOn {
+		return fmt.Errorf("boom")
+	}
+	f.trashed = append(f.trashed, id)
+	return nil
+}
+
+func emailsWithIDs(ids ...string) []domain.Email {
+	emails := make([]domain.Email, len(ids))
+	for i, id := range ids {
+		emails[i] = domain.Email{ID: id}
+	}
+	return emails
+}
+
+func TestCollectQueryMatches(t *testing.T) {
+	p := &fakeBulkProvider{pages: [][]domain.Email{
+		emailsWithIDs("m1", "m2"),
+		emailsWithIDs("m3"),
+	}}
+
+	ids, err := collectQueryMatches(context.Background(), p, "from:x", 0)
+	if err != nil {
+		t.Fatalf("collectQueryMatches() error: %v", err)
+	}
+	if got := strings.Join(ids, ","); got != "m1,m2,m3" {
+		t.Errorf("ids = %q, want %q", got, "m1,m2,m3")
+	}
+	if len(p.queries) != 2 || p.queries[0] != "from:x" {
+		t.Errorf("queries = %v, want two calls with %q", p.queries, "from:x")
+	}
+}
+
+func TestCollectQueryMatches_Limit(t *testing.T) {
+	p := &fakeBulkProvider{pages: [][]domain.Email{
+		emailsWithIDs("m1", "m2"),
+		emailsWithIDs("m3"),
+	}}
+
+	ids, err := collectQueryMatches(context.Background(), p, "from:x", 2)
+	if err != nil {
+		t.Fatalf("collectQueryMatches() error: %v", err)
+	}
+	if len(ids) != 2 {
+		t.Errorf("got %d ids, want 2", len(ids))
+	}
+	if len(p.queries) != 1 {
+		t.Errorf("got %d search calls, want 1", len(p.queries))
+	}
+}
+
+func TestApplyBulkAction(t *testing.T) {
+	ids := []string{"m1", "m2"}
+
+	t.Run("trash", func(t *testing.T) {
+		p := &fakeBulkProvider{}
+		if err := applyBulkAction(context.Background(), p, "trash", ids); err != nil {
+			t.Fatalf("applyBulkAction() error: %v", err)
+		}
+		if strings.Join(p.trashed, ",") != "m1,m2" {
+			t.Errorf("trashed = %v, want [m1 m2]", p.trashed)
+		}
+		if len(p.batches) != 0 {
+			t.Errorf("trash should not batch modify, got %d batches", len(p.batches))
+		}
+	})
+
+	t.Run("archive", func(t *testing.T) {
+		p := &fakeBulkProvider{}
+		if err := applyBulkAction(context.Background(), p, "archive", ids); err != nil {
+			t.Fatalf("applyBulkAction() error: %v", err)
+		}
+		if len(p.batches) != 1 {
+			t.Fatalf("got %d batches, want 1", len(p.batches))
+		}
+		b := p.batches[0]
+		if len(b.ids) != 2 || len(b.remove) != 1 || b.remove[0] != domain.LabelInbox || len(b.add) != 0 {
+			t.Errorf("batch = %+v, want remove INBOX from 2 messages", b)
+		}
+	})
+
+	t.Run("unread", func(t *testing.T) {
+		p := &fakeBulkProvider{}
+		if err := applyBulkAction(context.Background(), p, "unread", ids); err != nil {
+			t.Fatalf("applyBulkAction() error: %v", err)
+		}
+		if len(p.batches) != 1 || len(p.batches[0].add) != 1 || p.batches[0].add[0] != domain.LabelUnread {
+			t.Errorf("batches = %+v, want add UNREAD", p.batches)
+		}
+	})
+
+	t.Run("trash error", func(t *testing.T) {
+		p := &fakeBulkProvider{failOn: "m2"}
+		if err := applyBulkAction(context.Background(), p, "trash", ids); err == nil {
+			t.Error("applyBulkAction() expected error, got nil")
+		}
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		p := &fakeBulkProvider{}
+		if err := applyBulkAction(context.Background(), p, "explode", ids); err == nil {
+			t.Error("applyBulkAction() expected error for unknown action")
+		}
+	})
+}
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if got := confirm(strings.NewReader(tt.input), &out, "Trash 3 messages?"); got != tt.want {
+			t.Errorf("confirm(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+		if !strings.Contains(out.String(), "Trash 3 messages? [y/N]") {
+			t.Errorf("prompt = %q", out.String())
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+	"github.com/lu-zhengda/termail/internal/config"
+	"github.com/lu-zhengda/termail/internal/tui"
+)
+
+// maskedSecret replaces the Gmail client secret in `config show`.
+const maskedSecret = "********"
+
+func newConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show, locate or validate the configuration",
+	}
+	cmd.AddCommand(newConfigShowCmd())
+	cmd.AddCommand(newConfigPathCmd())
+	cmd.AddCommand(newConfigValidateCmd())
+	return cmd
+}
+
+func newConfigShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration",
+		Long: "Print the configuration termail uses: the config file merged over the defaults.\n" +
+			"The Gmail client secret is masked. Use --json for JSON instead of TOML.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			return writeConfig(os.Stdout, cfg, jsonFlag)
+		},
+	}
+}
+
+// writeConfig writes cfg to w as TOML, or as JSON with the same keys, with
+// the Gmail client secret masked.
+func writeConfig(w io.Writer, cfg *config.Config, asJSON bool) error {
+	masked := *cfg
+	if masked.Gmail.ClientSecret != "" {
+		masked.Gmail.ClientSecret = maskedSecret
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(masked); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if !asJSON {
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+
+	// Round-trip through TOML so the JSON keys match the config file's.
+	var tree map[string]any
+	if _, err := toml.Decode(buf.String(), &tree); err != nil {
+		return fmt.Errorf("failed to decode config: %w", err)
+	}
+	return fprintJSON(w, tree)
+}
+
+func newConfigPathCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "path",
+		Short: "Print the config file and data directory paths",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := jsonConfigPaths{
+				ConfigFile: configPath(),
+				ConfigDir:  config.ConfigDir(),
+				DataDir:    config.DataDir(),
+				Database:   filepath.Join(config.DataDir(), "termail.db"),
+			}
+			if jsonFlag {
+				return printJSON(out)
+			}
+
+			fmt.Printf("Config file: %s\n", out.ConfigFile)
+			fmt.Printf("Config dir:  %s\n", out.ConfigDir)
+			fmt.Printf("Data dir:    %s\n", out.DataDir)
+			fmt.Printf("Database:    %s\n", out.Database)
+			return nil
+		},
+	}
+}
+
+func newConfigValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check the config file for unknown keys and invalid values",
+		Args:  cobra.NoArgs,
+		// The problems are the useful output; usage would only bury them.
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := configPath()
+			problems, err := validateConfig(path)
+			if err != nil {
+				return err
+			}
+
+			if jsonFlag {
+				if err := printJSON(jsonConfigValidation{Path: path, Valid: len(problems) == 0, Problems: append([]string{}, problems...)}); err != nil {
+					return err
+				}
+			} else {
+				for _, p := range problems {
+					fmt.Printf("%s: %s\n", path, p)
+				}
+			}
+			if len(problems) > 0 {
+				return &config.Error{Err: fmt.Errorf("config has %d problem(s)", len(problems))}
+			}
+			if !jsonFlag {
+				fmt.Printf("%s: OK\n", path)
+			}
+			return nil
+		},
+	}
+}
+
+// validateConfig reports the problems with the config file at path,
+// including a theme the TUI does not know.
+func validateConfig(path string) ([]string, error) {
+	problems, err := config.Validate(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tui.LookupTheme(cfg.UI.Theme); err != nil {
+		problems = append(problems, "ui.theme: "+err.Error())
+	}
+	return problems, nil
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lu-zhengda/termail/internal/config"
+)
+
+func TestWriteConfig_MasksClientSecret(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Gmail.ClientID = "client-id"
+	cfg.Gmail.ClientSecret = "GOCSPX-secret"
+
+	var out bytes.Buffer
+	if err := writeConfig(&out, cfg, false); err != nil {
+		t.Fatalf("writeConfig() error: %v", err)
+	}
+	if strings.Contains(out.String(), "GOCSPX-secret") || !strings.Contains(out.String(), maskedSecret) {
+		t.Errorf("TOML output should mask the client secret:\n%s", out.String())
+	}
+	if cfg.Gmail.ClientSecret != "GOCSPX-secret" {
+		t.Error("writeConfig should not modify the config")
+	}
+
+	out.Reset()
+	if err := writeConfig(&out, cfg, true); err != nil {
+		t.Fatalf("writeConfig(json) error: %v", err)
+	}
+	var got struct {
+		Gmail struct {
+			ClientID     string `json:"client_id"`
+			ClientSecret string `json:"client_secret"`
+		} `json:"gmail"`
+		Sync struct {
+			Interval string `json:"interval"`
+		} `json:"sync"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if got.Gmail.ClientID != "client-id" || got.Gmail.ClientSecret != maskedSecret || got.Sync.Interval != "5m" {
+		t.Errorf("JSON config = %+v, want config file keys with the secret masked", got)
+	}
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/lu-zhengda/termail/internal/app"
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// Provider calls a destructive command can make.
+const (
+	callModifyLabels = "modify_labels"
+	callTrash        = "trash"
+)
+
+// dryRunCommands are the commands that honour --dry-run.
+var dryRunCommands = map[string]bool{
+	"archive": true, "trash": true, "untrash": true,
+	"spam": true, "not-spam": true, "label-modify": true,
+}
+
+// checkDryRun rejects --dry-run for a command that does not support it.
+func checkDryRun(cmd *cobra.Command, dryRun bool) error {
+	if dryRun && !dryRunCommands[cmd.Name()] {
+		return fmt.Errorf("--dry-run is not supported by %s", cmd.CommandPath())
+	}
+	return nil
+}
+
+// providerChange is the provider call a destructive command makes for one
+// message.
+type providerChange struct {
+	Call   string
+	Add    []string
+	Remove []string
+}
+
+// actionChange returns the provider call app.ApplyAction makes for action.
+func actionChange(action string) (providerChange, error) {
+	switch action {
+	case "archive":
+		return providerChange{Call: callModifyLabels, Remove: []string{domain.LabelInbox}}, nil
+	case "trash", "delete":
+		return providerChange{Call: callTrash}, nil
+	case "untrash":
+		add, remove := app.TrashLabels(false)
+		return providerChange{Call: callModifyLabels, Add: add, Remove: remove}, nil
+	case "spam", "notspam":
+		add, remove := app.SpamLabels(action == "spam")
+		return providerChange{Call: callModifyLabels, Add: add, Remove: remove}, nil
+	}
+	return providerChange{}, fmt.Errorf("%w: %s", app.ErrUnknownAction, action)
+}
+
+// messageAction applies action to message id, or when dryRun is set writes
+// the provider call it would make to w instead, leaving the provider and the
+// local store untouched. It reports whether the action was applied.
+func messageAction(ctx context.Context, w io.Writer, p provider.EmailProvider, s store.Store, accountID, id, action string, dryRun bool) (bool, error) {
+	if dryRun {
+		change, err := actionChange(action)
+		if err != nil {
+			return false, err
+		}
+		return false, writeDryRun(w, action, id, change)
+	}
+	if err := app.ApplyAction(ctx, p, s, accountID, id, action); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// modifyLabels adds and removes labels of message id on the provider, or
+// when dryRun is set writes the change to w instead. It reports whether the
+// change was made.
+func modifyLabels(ctx context.Context, w io.Writer, p provider.EmailProvider, id string, add, remove []string, dryRun bool) (bool, error) {
+	if dryRun {
+		change := providerChange{Call: callModifyLabels, Add: add, Remove: remove}
+		return false, writeDryRun(w, "label-modify", id, change)
+	}
+	if err := p.ModifyLabels(ctx, id, add, remove); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// writeDryRun describes the provider call action would make for message id.
+func writeDryRun(w io.Writer, action, id string, change providerChange) error {
+	if jsonFlag {
+		return fprintJSON(w, jsonDryRun{
+			OK:        true,
+			DryRun:    true,
+			Action:    action,
+			MessageID: id,
+			Call:      change.Call,
+			Add:       change.Add,
+			Remove:    change.Remove,
+		})
+	}
+	if change.Call == callTrash {
+		_, err := fmt.Fprintf(w, "Dry run: would move %s to trash.\n", id)
+		return err
+	}
+	var parts []string
+	if len(change.Add) > 0 {
+		parts = append(parts, "add "+strings.Join(change.Add, ", "))
+	}
+	if len(change.Remove) > 0 {
+		parts = append(parts, "remove "+strings.Join(change.Remove, ", "))
+	}
+	_, err := fmt.Fprintf(w, "Dry run: would modify labels of %s: %s.\n", id, strings.Join(parts, "; "))
+	return err
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/store/sqlite"
+)
+
+// recordingProvider records the mutating provider calls made through it.
+// Any other call panics on the nil embedded interface.
+type recordingProvider struct {
+	provider.EmailProvider
+	calls []string
+}
+
+func (r *recordingProvider) ModifyLabels(_ context.Context, id string, add, remove []string) error {
+	r.calls = append(r.calls, "modify "+id+" +"+strings.Join(add, ",")+" -"+strings.Join(remove, ","))
+	return nil
+}
+
+func (r *recordingProvider) TrashMessage(_ context.Context, id string) error {
+	r.calls = append(r.calls, "trash "+id)
+	return nil
+}
+
+func newDryRunDB(t *testing.T) *sqlite.DB {
+	t.Helper()
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New() error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	ctx := context.Background()
+	if err := db.CreateAccount(ctx, &domain.Account{ID: "acc-1", Email: "me@example.com", Provider: "gmail"}); err != nil {
+		t.Fatalf("CreateAccount() error: %v", err)
+	}
+	if err := db.UpsertEmail(ctx, &domain.Email{ID: "m1", ThreadID: "t1", Labels: []string{domain.LabelInbox}}, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+	return db
+}
+
+func TestMessageAction_DryRunMakesNoCalls(t *testing.T) {
+	tests := []struct {
+		action string
+		want   string
+	}{
+		{"archive", "Dry run: would modify labels of m1: remove INBOX.\n"},
+		{"trash", "Dry run: would move m1 to trash.\n"},
+		{"untrash", "Dry run: would modify labels of m1: add INBOX; remove TRASH.\n"},
+		{"spam", "Dry run: would modify labels of m1: add SPAM; remove INBOX.\n"},
+		{"notspam", "Dry run: would modify labels of m1: add INBOX; remove SPAM.\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			db := newDryRunDB(t)
+			p := &recordingProvider{}
+			var out bytes.Buffer
+
+			applied, err := messageAction(context.Background(), &out, p, db, "acc-1", "m1", tt.action, true)
+			if err != nil || applied {
+				t.Fatalf("messageAction() = %v, %v; want false, nil", applied, err)
+			}
+			if len(p.calls) != 0 {
+				t.Errorf("provider calls = %v, want none", p.calls)
+			}
+			if out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+			email, err := db.GetEmail(context.Background(), "m1", "acc-1")
+			if err != nil {
+				t.Fatalf("GetEmail() error: %v", err)
+			}
+			if !slices.Equal(email.Labels, []string{domain.LabelInbox}) {
+				t.Errorf("local labels = %v, want them unchanged", email.Labels)
+			}
+		})
+	}
+}
+
+func TestMessageAction_Applies(t *testing.T) {
+	db := newDryRunDB(t)
+	p := &recordingProvider{}
+	var out bytes.Buffer
+
+	applied, err := messageAction(context.Background(), &out, p, db, "acc-1", "m1", "trash", false)
+	if err != nil || !applied {
+		t.Fatalf("messageAction() = %v, %v; want true, nil", applied, err)
+	}
+	if !slices.Equal(p.calls, []string{"trash m1"}) || out.Len() != 0 {
+		t.Errorf("calls = %v, output = %q; want one trash call and no output", p.calls, out.String())
+	}
+}
+
+func TestModifyLabels_DryRunJSON(t *testing.T) {
+	jsonFlag = true
+	t.Cleanup(func() { jsonFlag = false })
+	p := &recordingProvider{}
+	var out bytes.Buffer
+
+	applied, err := modifyLabels(context.Background(), &out, p, "m1", []string{"Label_1"}, []string{domain.LabelInbox}, true)
+	if err != nil || applied {
+		t.Fatalf("modifyLabels() = %v, %v; want false, nil", applied, err)
+	}
+	if len(p.calls) != 0 {
+		t.Errorf("provider calls = %v, want none", p.calls)
+	}
+	var got jsonDryRun
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output %q is not JSON: %v", out.String(), err)
+	}
+	want := jsonDryRun{OK: true, DryRun: true, Action: "label-modify", MessageID: "m1", Call: callModifyLabels,
+		Add: []string{"Label_1"}, Remove: []string{domain.LabelInbox}}
+	if got.Call != want.Call || !got.DryRun || got.Action != want.Action ||
+		!slices.Equal(got.Add, want.Add) || !slices.Equal(got.Remove, want.Remove) {
+		t.Errorf("output = %+v, want %+v", got, want)
+	}
+}
+
+func TestCheckDryRun(t *testing.T) {
+	for _, use := range []string{"archive <message-id>", "trash <message-id>", "spam <message-id>", "label-modify <message-id>"} {
+		if err := checkDryRun(&cobra.Command{Use: use}, true); err != nil {
+			t.Errorf("checkDryRun(%s) error: %v", use, err)
+		}
+	}
+	batch := &cobra.Command{Use: "batch <action>"}
+	if err := checkDryRun(batch, true); err == nil || !strings.Contains(err.Error(), "batch") {
+		t.Errorf("checkDryRun(batch) error = %v, want --dry-run refused", err)
+	}
+	if err := checkDryRun(batch, false); err != nil {
+		t.Errorf("checkDryRun(batch) without --dry-run error: %v", err)
+	}
+}
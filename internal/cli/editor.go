@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// errEmptyBody is returned when the user saves an empty message in the editor,
+// which cancels sending.
+var errEmptyBody = errors.New("message body is empty")
+
+// shouldUseEditor reports whether the body should be written in $EDITOR:
+// when --editor is set, or when there is no body yet and stdin is a terminal.
+func shouldUseEditor(editorFlag bool, body string) bool {
+	return editorFlag || (body == "" && stdinIsTerminal())
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal.
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// readBody resolves a message body from --body, reading stdin for "-".
+func readBody(bodyFlag string) (string, error) {
+	if bodyFlag != "-" {
+		return bodyFlag, nil
+	}
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read body from stdin: %w", err)
+	}
+	return string(b), nil
+}
+
+// editBody opens the user's editor ($EDITOR, then $VISUAL, then vi) on a
+// temporary file pre-filled with initial and returns the saved text.
+// It returns errEmptyBody if the result is blank.
+func editBody(initial string) (string, error) {
+	f, err := os.CreateTemp("", "termail-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	path := f.Name()
+	defer os.Remove(path)
+
+	if _, err := f.WriteString(initial); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = os.Getenv("VISUAL")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// Allow editors with arguments, e.g. EDITOR="code --wait".
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited message: %w", err)
+	}
+	body := string(b)
+	if strings.TrimSpace(body) == "" {
+		return "", errEmptyBody
+	}
+	return body, nil
+}
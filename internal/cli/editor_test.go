@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeEditor writes an executable script that runs body against the file
+// passed as $1, and points $EDITOR at it.
+func fakeEditor(t *testing.T, body string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EDITOR", path)
+}
+
+func TestEditBody_PrefillsAndReadsBack(t *testing.T) {
+	fakeEditor(t, `printf 'Thanks!\n' | cat - "$1" > "$1.new" && mv "$1.new" "$1"`)
+
+	got, err := editBody("> quoted original\n")
+	if err != nil {
+		t.Fatalf("editBody() error: %v", err)
+	}
+	if want := "Thanks!\n> quoted original\n"; got != want {
+		t.Errorf("editBody() = %q, want %q", got, want)
+	}
+}
+
+func TestEditBody_EmptyCancels(t *testing.T) {
+	fakeEditor(t, `: > "$1"`)
+
+	if _, err := editBody("draft"); !errors.Is(err, errEmptyBody) {
+		t.Errorf("editBody() error = %v, want errEmptyBody", err)
+	}
+}
+
+func TestEditBody_EditorFailure(t *testing.T) {
+	fakeEditor(t, `exit 3`)
+
+	_, err := editBody("draft")
+	if err == nil || !strings.Contains(err.Error(), "failed") {
+		t.Errorf("editBody() error = %v, want editor failure", err)
+	}
+}
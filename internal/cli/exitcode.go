@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/lu-zhengda/termail/internal/config"
+	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// Exit statuses for failures scripts may want to handle differently. The
+// README documents them; keep the two in step.
+const (
+	exitOK       = 0
+	exitError    = 1 // any other failure, including invalid usage
+	exitAuth     = 2 // credentials missing, expired or revoked
+	exitNetwork  = 3 // server unreachable or rate limited; retry later
+	exitNotFound = 4 // the message, thread or account does not exist
+	exitConfig   = 5 // config file unreadable, malformed or invalid
+)
+
+// exitCode maps a command's error to the process exit status.
+func exitCode(err error) int {
+	var cfgErr *config.Error
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &cfgErr):
+		return exitConfig
+	case errors.Is(err, provider.ErrAuth), errors.Is(err, store.ErrTokenNotFound):
+		return exitAuth
+	case errors.Is(err, provider.ErrNetwork), errors.Is(err, provider.ErrRateLimit):
+		return exitNetwork
+	case errors.Is(err, provider.ErrNotFound), errors.Is(err, store.ErrNotFound):
+		return exitNotFound
+	default:
+		return exitError
+	}
+}
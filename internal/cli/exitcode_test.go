@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lu-zhengda/termail/internal/config"
+	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"generic", errors.New("boom"), exitError},
+		{"expired token", fmt.Errorf("failed to sync: %w", provider.ErrAuth), exitAuth},
+		{"no token", fmt.Errorf("failed to load gmail token: %w", store.ErrTokenNotFound), exitAuth},
+		{"offline", fmt.Errorf("failed to list: %w", provider.ErrNetwork), exitNetwork},
+		{"rate limited", fmt.Errorf("failed to list: %w", provider.ErrRateLimit), exitNetwork},
+		{"missing thread", fmt.Errorf("failed to get thread: %w", store.ErrNotFound), exitNotFound},
+		{"missing message on server", fmt.Errorf("failed to trash: %w", provider.ErrNotFound), exitNotFound},
+		{"bad config", fmt.Errorf("failed to send email: %w", &config.Error{Err: errors.New("invalid gmail send_delay")}), exitConfig},
+		{"success", nil, exitOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	badConfig := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(badConfig, []byte("[ui\ntheme = "), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"success", []string{"config", "path"}, exitOK},
+		{"malformed config", []string{"--config", badConfig, "config", "show"}, exitConfig},
+		{"missing thread", []string{"read", "no-such-thread", "--account", "acc-1"}, exitNotFound},
+		{"unknown command", []string{"no-such-command"}, exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := run(context.Background(), tt.args); got != tt.want {
+				t.Errorf("run(%q) = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
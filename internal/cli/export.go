@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/export"
+	"github.com/lu-zhengda/termail/internal/rfc822"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+func newExportCmd() *cobra.Command {
+	var accountFlag, labelFlag, queryFlag, outFlag, formatFlag string
+	var encodingFlag, lineEndingFlag string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export emails to an mbox file, .eml files, or a maildir",
+		Long: "Export all locally synced emails in a label, or matching a search query,\n" +
+			"to a single mbox file (default), a directory of .eml files, or a maildir\n" +
+			"whose file names carry read (S) and starred (F) flags. Non-ASCII bodies\n" +
+			"are encoded as quoted-printable or base64 so the files are valid MIME.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (labelFlag == "") == (queryFlag == "") {
+				return fmt.Errorf("exactly one of --label or --query is required")
+			}
+			if outFlag == "" {
+				return fmt.Errorf("--out is required")
+			}
+			if formatFlag != "mbox" && formatFlag != "eml" && formatFlag != "maildir" {
+				return fmt.Errorf("unsupported format: %s (use mbox, eml, or maildir)", formatFlag)
+			}
+			encoding, err := rfc822.ParseEncoding(encodingFlag)
+			if err != nil {
+				return err
+			}
+			lineEnding, err := rfc822.ParseLineEnding(lineEndingFlag)
+			if err != nil {
+				return err
+			}
+			opts := rfc822.Options{Encoding: encoding, LineEnding: lineEnding}
+
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			accountID, err := resolveAccountFlag(db, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			emails, err := exportEmails(cmd.Context(), db, accountID, labelFlag, queryFlag)
+			if err != nil {
+				return err
+			}
+
+			count := len(emails)
+			switch formatFlag {
+			case "eml":
+				if count, err = export.WriteEML(outFlag, emails, opts); err != nil {
+					return err
+				}
+			case "maildir":
+				if count, err = export.WriteMaildir(outFlag, emails, opts); err != nil {
+					return err
+				}
+			default:
+				f, err := os.OpenFile(outFlag, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+				if err != nil {
+					return fmt.Errorf("failed to create %s: %w", outFlag, err)
+				}
+				if err := export.WriteMbox(f, emails, opts); err != nil {
+					f.Close()
+					return err
+				}
+				if err := f.Close(); err != nil {
+					return fmt.Errorf("failed to close %s: %w", outFlag, err)
+				}
+			}
+
+			if jsonFlag {
+				return printJSON(jsonExport{OK: true, Format: formatFlag, Path: outFlag, Count: count})
+			}
+
+			fmt.Printf("Exported %d messages to %s.\n", count, outFlag)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID (defaults to config default)")
+	cmd.Flags().StringVar(&labelFlag, "label", "", "export all emails with this label")
+	cmd.Flags().StringVar(&queryFlag, "query", "", "export emails matching this full-text search")
+	cmd.Flags().StringVar(&outFlag, "out", "", "output mbox file, or directory for --format eml or maildir")
+	cmd.Flags().StringVar(&formatFlag, "format", "mbox", "output format (mbox, eml, or maildir)")
+	cmd.Flags().StringVar(&encodingFlag, "encoding", "auto", "body encoding (auto, quoted-printable, or base64)")
+	cmd.Flags().StringVar(&lineEndingFlag, "line-ending", "crlf", "line endings for eml and maildir (crlf or lf); mbox always uses lf")
+	return cmd
+}
+
+// exportEmails loads the full messages in label, or matching query when it is
+// set. Listings and search hits carry summary fields only, so each message is
+// loaded in full to keep its threading headers and labels.
+func exportEmails(ctx context.Context, s store.Store, accountID, label, query string) ([]domain.Email, error) {
+	var ids []string
+	if query != "" {
+		hits, err := s.SearchEmails(ctx, query, accountID, store.SearchOptions{IncludeTrash: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search: %w", err)
+		}
+		for _, h := range hits {
+			ids = append(ids, h.ID)
+		}
+	} else {
+		summaries, err := s.ListEmails(ctx, store.ListEmailOptions{
+			AccountID: accountID,
+			LabelID:   label,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list emails: %w", err)
+		}
+		for _, e := range summaries {
+			ids = append(ids, e.ID)
+		}
+	}
+
+	emails := make([]domain.Email, 0, len(ids))
+	for _, id := range ids {
+		e, err := s.GetEmail(ctx, id, accountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get email %s: %w", id, err)
+		}
+		emails = append(emails, *e)
+	}
+	return emails, nil
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/export"
+	"github.com/lu-zhengda/termail/internal/rfc822"
+)
+
+func TestExportEmails_QueryKeepsThreadingHeaders(t *testing.T) {
+	db := newDryRunDB(t)
+	ctx := context.Background()
+	reply := &domain.Email{
+		ID:         "m2",
+		ThreadID:   "t1",
+		MessageID:  "<reply@example.com>",
+		InReplyTo:  "<root@example.com>",
+		References: []string{"<root@example.com>"},
+		From:       domain.Address{Email: "you@example.com"},
+		Subject:    "Re: Quarterly numbers",
+		Body:       "The quarterly numbers look fine.",
+		Labels:     []string{domain.LabelInbox},
+	}
+	if err := db.UpsertEmail(ctx, reply, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+
+	emails, err := exportEmails(ctx, db, "acc-1", "", "quarterly")
+	if err != nil {
+		t.Fatalf("exportEmails() error: %v", err)
+	}
+	if len(emails) != 1 {
+		t.Fatalf("exportEmails() = %d emails, want 1", len(emails))
+	}
+	var out bytes.Buffer
+	if err := export.WriteMbox(&out, emails, rfc822.Options{}); err != nil {
+		t.Fatalf("WriteMbox() error: %v", err)
+	}
+	for _, want := range []string{"Message-ID: <reply@example.com>", "References: <root@example.com>"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("mbox output missing %q:\n%s", want, out.String())
+		}
+	}
+}
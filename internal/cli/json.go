@@ -11,25 +11,41 @@ import (
 // ---------------------------------------------------------------------------
 
 type jsonAccount struct {
-	ID        string `json:"id"`
-	Email     string `json:"email"`
-	Provider  string `json:"provider"`
-	CreatedAt string `json:"created_at"`
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	Provider      string `json:"provider"`
+	CreatedAt     string `json:"created_at"`
+	MessagesTotal int64  `json:"messages_total,omitempty"`
+	Unread        int    `json:"unread"`
 }
 
-func toJSONAccounts(accounts []domain.Account) []jsonAccount {
+// toJSONAccounts converts accounts, with their unread INBOX counts keyed by
+// account ID.
+func toJSONAccounts(accounts []domain.Account, unread map[string]int) []jsonAccount {
 	out := make([]jsonAccount, 0, len(accounts))
 	for _, a := range accounts {
 		out = append(out, jsonAccount{
-			ID:        a.ID,
-			Email:     a.Email,
-			Provider:  a.Provider,
-			CreatedAt: a.CreatedAt.Format(time.DateOnly),
+			ID:            a.ID,
+			Email:         a.Email,
+			Provider:      a.Provider,
+			CreatedAt:     a.CreatedAt.Format(time.DateOnly),
+			MessagesTotal: a.MessagesTotal,
+			Unread:        unread[a.ID],
 		})
 	}
 	return out
 }
 
+type jsonProfile struct {
+	Email         string `json:"email"`
+	MessagesTotal int64  `json:"messages_total"`
+	ThreadsTotal  int64  `json:"threads_total"`
+}
+
+func toJSONProfile(p *domain.Profile) jsonProfile {
+	return jsonProfile{Email: p.Email, MessagesTotal: p.MessagesTotal, ThreadsTotal: p.ThreadsTotal}
+}
+
 // ---------------------------------------------------------------------------
 // Thread JSON types (list)
 // ---------------------------------------------------------------------------
@@ -43,6 +59,7 @@ type jsonThread struct {
 	HasUnread    bool        `json:"has_unread"`
 	Snippet      string      `json:"snippet,omitempty"`
 	Labels       []string    `json:"labels,omitempty"`
+	Flags        []string    `json:"flags,omitempty"`
 }
 
 func toJSONThreads(threads []domain.Thread) []jsonThread {
@@ -57,6 +74,7 @@ func toJSONThreads(threads []domain.Thread) []jsonThread {
 			HasUnread:    t.IsUnread(),
 			Snippet:      t.Snippet,
 			Labels:       t.Labels,
+			Flags:        t.Flags,
 		})
 	}
 	return out
@@ -78,12 +96,17 @@ type jsonMessage struct {
 	From      jsonAddress   `json:"from"`
 	To        []jsonAddress `json:"to,omitempty"`
 	CC        []jsonAddress `json:"cc,omitempty"`
+	BCC       []jsonAddress `json:"bcc,omitempty"`
 	Subject   string        `json:"subject"`
 	Body      string        `json:"body"`
 	Date      string        `json:"date"`
 	IsRead    bool          `json:"is_read"`
 	IsStarred bool          `json:"is_starred"`
 	Labels    []string      `json:"labels,omitempty"`
+	Flags     []string      `json:"flags,omitempty"`
+
+	ListUnsubscribe string `json:"list_unsubscribe,omitempty"`
+	ListID          string `json:"list_id,omitempty"`
 }
 
 func toJSONThreadDetail(t *domain.Thread) jsonThreadDetail {
@@ -105,15 +128,26 @@ func toJSONMessage(e *domain.Email) jsonMessage {
 		From:      toJSONAddress(e.From),
 		To:        toJSONAddresses(e.To),
 		CC:        toJSONAddresses(e.CC),
+		BCC:       toJSONAddresses(e.BCC),
 		Subject:   e.Subject,
 		Body:      e.Body,
 		Date:      e.Date.Format(time.RFC3339),
 		IsRead:    e.IsRead,
 		IsStarred: e.IsStarred,
 		Labels:    e.Labels,
+		Flags:     e.Flags,
+
+		ListUnsubscribe: e.ListUnsubscribe,
+		ListID:          listID(e),
 	}
 }
 
+// listID returns the bare List-Id of mailing-list mail, or "".
+func listID(e *domain.Email) string {
+	list, _ := e.MailingList()
+	return list.ID
+}
+
 // ---------------------------------------------------------------------------
 // Email JSON type (search results)
 // ---------------------------------------------------------------------------
@@ -123,6 +157,7 @@ type jsonEmail struct {
 	From    jsonAddress `json:"from"`
 	Subject string      `json:"subject"`
 	Date    string      `json:"date"`
+	Snippet string      `json:"snippet,omitempty"`
 }
 
 func toJSONEmails(emails []domain.Email) []jsonEmail {
@@ -133,11 +168,73 @@ func toJSONEmails(emails []domain.Email) []jsonEmail {
 			From:    toJSONAddress(e.From),
 			Subject: e.Subject,
 			Date:    e.Date.Format(time.RFC3339),
+			Snippet: e.PlainSnippet(),
 		})
 	}
 	return out
 }
 
+// ---------------------------------------------------------------------------
+// Message summary JSON type (messages)
+// ---------------------------------------------------------------------------
+
+type jsonMessageSummary struct {
+	ID        string      `json:"id"`
+	ThreadID  string      `json:"thread_id"`
+	From      jsonAddress `json:"from"`
+	Subject   string      `json:"subject"`
+	Date      string      `json:"date"`
+	IsRead    bool        `json:"is_read"`
+	IsStarred bool        `json:"is_starred"`
+	Flags     []string    `json:"flags,omitempty"`
+}
+
+func toJSONMessageSummaries(emails []domain.Email) []jsonMessageSummary {
+	out := make([]jsonMessageSummary, 0, len(emails))
+	for _, e := range emails {
+		out = append(out, jsonMessageSummary{
+			ID:        e.ID,
+			ThreadID:  e.ThreadID,
+			From:      toJSONAddress(e.From),
+			Subject:   e.Subject,
+			Date:      e.Date.Format(time.RFC3339),
+			IsRead:    e.IsRead,
+			IsStarred: e.IsStarred,
+			Flags:     e.Flags,
+		})
+	}
+	return out
+}
+
+// ---------------------------------------------------------------------------
+// Recently viewed JSON type (recent)
+// ---------------------------------------------------------------------------
+
+type jsonRecent struct {
+	ID       string      `json:"id"`
+	ThreadID string      `json:"thread_id"`
+	From     jsonAddress `json:"from"`
+	Subject  string      `json:"subject"`
+	ViewedAt string      `json:"viewed_at"`
+}
+
+// ---------------------------------------------------------------------------
+// Outbox JSON types (outbox list, outbox flush)
+// ---------------------------------------------------------------------------
+
+type jsonOutboxItem struct {
+	ID      int64         `json:"id"`
+	To      []jsonAddress `json:"to,omitempty"`
+	Subject string        `json:"subject"`
+	SendAt  string        `json:"send_at"`
+}
+
+type jsonOutboxFlush struct {
+	OK        bool   `json:"ok"`
+	AccountID string `json:"account_id"`
+	Sent      int    `json:"sent"`
+}
+
 // ---------------------------------------------------------------------------
 // Label JSON type (labels)
 // ---------------------------------------------------------------------------
@@ -160,6 +257,33 @@ func toJSONLabels(labels []domain.Label) []jsonLabel {
 	return out
 }
 
+type jsonLabelTree struct {
+	System []jsonLabel     `json:"system"`
+	User   []jsonLabelNode `json:"user"`
+}
+
+type jsonLabelNode struct {
+	Name     string          `json:"name"`
+	Path     string          `json:"path"`
+	ID       string          `json:"id,omitempty"`
+	Children []jsonLabelNode `json:"children,omitempty"`
+}
+
+func toJSONLabelNodes(nodes []*domain.LabelNode) []jsonLabelNode {
+	out := make([]jsonLabelNode, 0, len(nodes))
+	for _, n := range nodes {
+		node := jsonLabelNode{Name: n.Name, Path: n.Path}
+		if n.Label != nil {
+			node.ID = n.Label.ID
+		}
+		if len(n.Children) > 0 {
+			node.Children = toJSONLabelNodes(n.Children)
+		}
+		out = append(out, node)
+	}
+	return out
+}
+
 // ---------------------------------------------------------------------------
 // Address JSON type (shared)
 // ---------------------------------------------------------------------------
@@ -184,14 +308,142 @@ func toJSONAddresses(addrs []domain.Address) []jsonAddress {
 	return out
 }
 
+// ---------------------------------------------------------------------------
+// Export JSON type (export)
+// ---------------------------------------------------------------------------
+
+type jsonExport struct {
+	OK     bool   `json:"ok"`
+	Format string `json:"format"`
+	Path   string `json:"path"`
+	Count  int    `json:"count"`
+}
+
+// ---------------------------------------------------------------------------
+// Bulk JSON type (bulk)
+// ---------------------------------------------------------------------------
+
+type jsonBulk struct {
+	OK         bool     `json:"ok"`
+	Action     string   `json:"action"`
+	Query      string   `json:"query"`
+	Count      int      `json:"count"`
+	DryRun     bool     `json:"dry_run,omitempty"`
+	MessageIDs []string `json:"message_ids"`
+}
+
+type jsonBatch struct {
+	OK      bool              `json:"ok"`
+	Action  string            `json:"action"`
+	Failed  int               `json:"failed"`
+	Results []jsonBatchResult `json:"results"`
+}
+
+type jsonBatchResult struct {
+	ID    string `json:"id"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func toJSONBatch(action string, results []batchResult) jsonBatch {
+	out := jsonBatch{OK: true, Action: action, Results: make([]jsonBatchResult, 0, len(results))}
+	for _, r := range results {
+		res := jsonBatchResult{ID: r.ID, OK: r.Err == nil}
+		if r.Err != nil {
+			res.Error = r.Err.Error()
+			out.OK = false
+			out.Failed++
+		}
+		out.Results = append(out.Results, res)
+	}
+	return out
+}
+
 // ---------------------------------------------------------------------------
 // Action JSON type (compose, reply, forward, archive, trash, star, etc.)
 // ---------------------------------------------------------------------------
 
+type jsonSync struct {
+	OK        bool   `json:"ok"`
+	AccountID string `json:"account_id"`
+	Fetched   int    `json:"fetched"`
+	Pruned    int    `json:"pruned"`
+	Wiped     int    `json:"wiped,omitempty"`
+}
+
+type jsonRebuildSummaries struct {
+	OK        bool   `json:"ok"`
+	AccountID string `json:"account_id"`
+	Threads   int    `json:"threads"`
+}
+
+type jsonStats struct {
+	AccountID  string            `json:"account_id"`
+	Total      int               `json:"total"`
+	Unread     int               `json:"unread"`
+	Starred    int               `json:"starred"`
+	Labels     []jsonLabelCount  `json:"labels"`
+	TopSenders []jsonSenderCount `json:"top_senders"`
+	Months     []jsonMonthCount  `json:"months"`
+}
+
+type jsonLabelCount struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Total  int    `json:"total"`
+	Unread int    `json:"unread"`
+}
+
+type jsonSenderCount struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email"`
+	Count int    `json:"count"`
+}
+
+type jsonMonthCount struct {
+	Month string `json:"month"`
+	Count int    `json:"count"`
+}
+
+// jsonDryRun is the provider call a destructive command would have made
+// under --dry-run.
+type jsonDryRun struct {
+	OK        bool     `json:"ok"`
+	DryRun    bool     `json:"dry_run"`
+	Action    string   `json:"action"`
+	MessageID string   `json:"message_id"`
+	Call      string   `json:"call"`
+	Add       []string `json:"add,omitempty"`
+	Remove    []string `json:"remove,omitempty"`
+}
+
 type jsonAction struct {
 	OK        bool   `json:"ok"`
 	Action    string `json:"action"`
 	MessageID string `json:"message_id,omitempty"`
 	Email     string `json:"email,omitempty"`
 	AccountID string `json:"account_id,omitempty"`
+	LabelID   string `json:"label_id,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Until     string `json:"until,omitempty"`
+	// Queued is set when a send failed and the message was kept in the
+	// outbox.
+	Queued bool `json:"queued,omitempty"`
+}
+
+// ---------------------------------------------------------------------------
+// Config JSON types (config path, config validate)
+// ---------------------------------------------------------------------------
+
+type jsonConfigPaths struct {
+	ConfigFile string `json:"config_file"`
+	ConfigDir  string `json:"config_dir"`
+	DataDir    string `json:"data_dir"`
+	Database   string `json:"database"`
+}
+
+type jsonConfigValidation struct {
+	Path     string   `json:"path"`
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems"`
 }
@@ -3,10 +3,12 @@ package cli
 import (
 	"bytes"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
 )
 
 func TestToJSONAccounts(t *testing.T) {
@@ -25,7 +27,7 @@ func TestToJSONAccounts(t *testing.T) {
 		},
 	}
 
-	got := toJSONAccounts(accounts)
+	got := toJSONAccounts(accounts, map[string]int{"user@example.com": 3})
 
 	if len(got) != 2 {
 		t.Fatalf("got %d accounts, want 2", len(got))
@@ -36,6 +38,9 @@ func TestToJSONAccounts(t *testing.T) {
 	if got[0].Provider != "gmail" {
 		t.Errorf("got provider %q, want %q", got[0].Provider, "gmail")
 	}
+	if got[0].Unread != 3 || got[1].Unread != 0 {
+		t.Errorf("got unread %d/%d, want 3/0", got[0].Unread, got[1].Unread)
+	}
 	if got[0].CreatedAt != "2025-01-15" {
 		t.Errorf("got created_at %q, want %q", got[0].CreatedAt, "2025-01-15")
 	}
@@ -58,7 +63,7 @@ func TestToJSONAccounts(t *testing.T) {
 }
 
 func TestToJSONAccounts_Empty(t *testing.T) {
-	got := toJSONAccounts(nil)
+	got := toJSONAccounts(nil, nil)
 	if len(got) != 0 {
 		t.Errorf("got %d accounts for nil input, want 0", len(got))
 	}
@@ -243,6 +248,40 @@ func TestToJSONEmails(t *testing.T) {
 	}
 }
 
+func TestToJSONMessageSummaries(t *testing.T) {
+	emails := []domain.Email{
+		{
+			ID:        "email-1",
+			ThreadID:  "thread-1",
+			From:      domain.Address{Name: "Alice", Email: "alice@example.com"},
+			Subject:   "Hello",
+			Date:      time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC),
+			IsRead:    true,
+			IsStarred: true,
+		},
+	}
+
+	got := toJSONMessageSummaries(emails)
+
+	if len(got) != 1 {
+		t.Fatalf("got %d messages, want 1", len(got))
+	}
+	if got[0].ThreadID != "thread-1" {
+		t.Errorf("got thread ID %q, want %q", got[0].ThreadID, "thread-1")
+	}
+	if !got[0].IsRead || !got[0].IsStarred {
+		t.Errorf("got read=%v starred=%v, want both true", got[0].IsRead, got[0].IsStarred)
+	}
+
+	var buf bytes.Buffer
+	if err := fprintJSON(&buf, got); err != nil {
+		t.Fatalf("fprintJSON() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"is_starred": true`) {
+		t.Errorf("JSON missing is_starred: %s", buf.String())
+	}
+}
+
 func TestToJSONEmails_Empty(t *testing.T) {
 	got := toJSONEmails(nil)
 	if len(got) != 0 {
@@ -529,3 +568,38 @@ func TestToJSONThreads_OmitsEmpty(t *testing.T) {
 		}
 	}
 }
+
+func TestToJSONStats(t *testing.T) {
+	got := toJSONStats("acc-1", store.MailboxStats{Total: 3, Unread: 1},
+		[]store.LabelCount{{LabelID: "Label_1", Name: "Work", Total: 2, Unread: 1}},
+		[]store.SenderCount{{Address: domain.Address{Name: "Ann", Email: "ann@example.com"}, Count: 2}}, nil)
+
+	var buf bytes.Buffer
+	if err := fprintJSON(&buf, got); err != nil {
+		t.Fatalf("fprintJSON() error = %v", err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &raw); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+	// An empty breakdown is an empty array, not null.
+	if string(raw["months"]) != "[]" {
+		t.Errorf("months = %s, want []", raw["months"])
+	}
+	if !strings.Contains(string(raw["top_senders"]), `"email": "ann@example.com"`) {
+		t.Errorf("top_senders = %s, want Ann", raw["top_senders"])
+	}
+
+	var out bytes.Buffer
+	if err := writeStats(&out, got); err != nil {
+		t.Fatalf("writeStats() error = %v", err)
+	}
+	for _, want := range []string{"Messages:  3", "Work", "Ann <ann@example.com>  2"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("writeStats() output missing %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "MONTH") {
+		t.Errorf("writeStats() printed an empty month table:\n%s", out.String())
+	}
+}
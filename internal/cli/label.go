@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider/gmail"
+)
+
+func newLabelCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "label",
+		Short: "Create or delete labels",
+	}
+	cmd.AddCommand(newLabelCreateCmd())
+	cmd.AddCommand(newLabelDeleteCmd())
+	return cmd
+}
+
+func newLabelCreateCmd() *cobra.Command {
+	var accountFlag string
+
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a label",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			provider, accountID, err := setupProvider(cmd, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			label, err := provider.CreateLabel(cmd.Context(), args[0])
+			if err != nil {
+				return fmt.Errorf("failed to create label: %w", err)
+			}
+			label.AccountID = accountID
+
+			// Store it right away so `labels` and the TUI sidebar show it
+			// without waiting for the next sync.
+			if err := db.UpsertLabel(cmd.Context(), &label); err != nil {
+				return fmt.Errorf("failed to save label: %w", err)
+			}
+
+			if jsonFlag {
+				return printJSON(toJSONLabels([]domain.Label{label})[0])
+			}
+
+			fmt.Printf("Label created: %s (%s)\n", label.Name, label.ID)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID")
+	return cmd
+}
+
+func newLabelDeleteCmd() *cobra.Command {
+	var accountFlag string
+
+	cmd := &cobra.Command{
+		Use:   "delete <label-id>",
+		Short: "Delete a label",
+		Long:  "Delete a user label. Messages keep their other labels; system labels cannot be deleted.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			labelID := args[0]
+
+			provider, accountID, err := setupProvider(cmd, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			ctx := cmd.Context()
+			labels, err := db.ListLabels(ctx, accountID)
+			if err != nil {
+				return fmt.Errorf("failed to list labels: %w", err)
+			}
+			if l, ok := findLabel(labels, labelID); ok && l.Type == domain.LabelTypeSystem {
+				return fmt.Errorf("cannot delete %s: %w", l.Name, gmail.ErrSystemLabel)
+			}
+
+			if err := provider.DeleteLabel(ctx, labelID); err != nil {
+				if errors.Is(err, gmail.ErrSystemLabel) {
+					return fmt.Errorf("cannot delete %s: %w", labelID, gmail.ErrSystemLabel)
+				}
+				return fmt.Errorf("failed to delete label: %w", err)
+			}
+			if err := db.DeleteLabel(ctx, labelID); err != nil {
+				return fmt.Errorf("failed to remove local label: %w", err)
+			}
+
+			if jsonFlag {
+				return printJSON(jsonAction{OK: true, Action: "label-delete", LabelID: labelID})
+			}
+
+			fmt.Println("Label deleted.")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID")
+	return cmd
+}
@@ -5,16 +5,24 @@ import (
 	"os"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/lu-zhengda/termail/internal/config"
+	"github.com/lu-zhengda/termail/internal/domain"
 	"github.com/lu-zhengda/termail/internal/store"
 	"github.com/lu-zhengda/termail/internal/store/sqlite"
+	"github.com/lu-zhengda/termail/internal/tui"
 )
 
 func newListCmd() *cobra.Command {
 	var accountFlag string
 	var labelFlag string
 	var limitFlag int
+	var sortFlag string
+	var flagFilter string
+	var sinceFlag, beforeFlag string
+	var sinceSyncFlag bool
 
 	cmd := &cobra.Command{
 		Use:   "list",
@@ -32,10 +40,42 @@ func newListCmd() *cobra.Command {
 				return err
 			}
 
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if sortFlag == "" {
+				sortFlag = cfg.UI.Sort
+			}
+			if sortFlag != store.SortDate && sortFlag != store.SortPriority {
+				return fmt.Errorf("invalid sort %q (use %s or %s)", sortFlag, store.SortDate, store.SortPriority)
+			}
+			if flagFilter != "" && !domain.IsValidFlag(flagFilter) {
+				return fmt.Errorf("unknown flag %q (use %s)", flagFilter, strings.Join(domain.Flags, ", "))
+			}
+			if !cmd.Flags().Changed("limit") {
+				if n := labelListLimit(cmd, db, cfg, accountID, labelFlag); n > 0 {
+					limitFlag = n
+				}
+			}
+			after, before, err := parseDateRange(sinceFlag, beforeFlag)
+			if err != nil {
+				return err
+			}
+			syncedAfter, err := sinceSyncTime(cmd, db, accountID, sinceSyncFlag)
+			if err != nil {
+				return err
+			}
+
 			threads, err := db.ListThreads(cmd.Context(), store.ListEmailOptions{
-				AccountID: accountID,
-				LabelID:   labelFlag,
-				Limit:     limitFlag,
+				AccountID:   accountID,
+				LabelID:     labelFlag,
+				Limit:       limitFlag,
+				Sort:        sortFlag,
+				Flag:        flagFilter,
+				After:       after,
+				Before:      before,
+				SyncedAfter: syncedAfter,
 			})
 			if err != nil {
 				return fmt.Errorf("failed to list threads: %w", err)
@@ -65,12 +105,15 @@ func newListCmd() *cobra.Command {
 					from = from[:27] + "..."
 				}
 				subject := t.Subject
+				if len(t.Flags) > 0 {
+					subject = "[" + strings.Join(t.Flags, ",") + "] " + subject
+				}
 				if len(subject) > 50 {
 					subject = subject[:47] + "..."
 				}
 				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
 					unread, from, subject,
-					t.LastDate.Format("Jan 2, 2006"),
+					domain.FormatDate(t.LastDate, "Jan 2, 2006"),
 					t.MessageCount(), t.ID,
 				)
 			}
@@ -80,18 +123,123 @@ func newListCmd() *cobra.Command {
 
 	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID (defaults to config default)")
 	cmd.Flags().StringVar(&labelFlag, "label", "INBOX", "label to list (INBOX, SENT, STARRED, TRASH, SPAM, DRAFT, or custom)")
-	cmd.Flags().IntVar(&limitFlag, "limit", 25, "max threads to show")
+	cmd.Flags().IntVar(&limitFlag, "limit", 25, "max threads to show (defaults to config ui.list_limits, then ui.list_limit)")
+	cmd.Flags().StringVar(&sortFlag, "sort", "", "thread order: date or priority (defaults to config ui.sort)")
+	cmd.Flags().StringVar(&flagFilter, "flag", "", "only threads with this local flag (follow-up, todo, waiting)")
+	addDateRangeFlags(cmd, &sinceFlag, &beforeFlag)
+	cmd.Flags().BoolVar(&sinceSyncFlag, "since-sync", false, "only mail added by the last sync")
 	return cmd
 }
 
-func newReadCmd() *cobra.Command {
+func newMessagesCmd() *cobra.Command {
 	var accountFlag string
+	var labelFlag, listFlag string
+	var limitFlag int
+	var offsetFlag int
+	var sinceFlag, beforeFlag string
+	var sinceSyncFlag bool
+
+	cmd := &cobra.Command{
+		Use:   "messages",
+		Short: "List individual messages",
+		Long:  "List individual messages in a label (defaults to INBOX), newest first, without grouping by thread.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			accountID, err := resolveAccountFlag(db, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			// A list filter searches all mail unless a label is given.
+			if listFlag != "" && !cmd.Flags().Changed("label") {
+				labelFlag = ""
+			}
+			after, before, err := parseDateRange(sinceFlag, beforeFlag)
+			if err != nil {
+				return err
+			}
+			syncedAfter, err := sinceSyncTime(cmd, db, accountID, sinceSyncFlag)
+			if err != nil {
+				return err
+			}
+
+			emails, err := db.ListEmails(cmd.Context(), store.ListEmailOptions{
+				AccountID:   accountID,
+				LabelID:     labelFlag,
+				ListID:      listFlag,
+				Limit:       limitFlag,
+				Offset:      offsetFlag,
+				After:       after,
+				Before:      before,
+				SyncedAfter: syncedAfter,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to list messages: %w", err)
+			}
+
+			if jsonFlag {
+				return printJSON(toJSONMessageSummaries(emails))
+			}
+
+			if len(emails) == 0 {
+				fmt.Println("No messages found.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "FROM\tSUBJECT\tDATE\tREAD\tID")
+			for _, e := range emails {
+				from := e.From.Name
+				if from == "" {
+					from = e.From.Email
+				}
+				if len(from) > 30 {
+					from = from[:27] + "..."
+				}
+				subject := e.Subject
+				if len(subject) > 50 {
+					subject = subject[:47] + "..."
+				}
+				read := "no"
+				if e.IsRead {
+					read = "yes"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+					from, subject, domain.FormatDate(e.Date, "Jan 2, 2006"), read, e.ID)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID (defaults to config default)")
+	cmd.Flags().StringVar(&labelFlag, "label", "INBOX", "label to list (INBOX, SENT, STARRED, TRASH, SPAM, DRAFT, or custom)")
+	cmd.Flags().StringVar(&listFlag, "list", "", "only show mailing-list mail with this List-Id (e.g. golang-nuts.googlegroups.com)")
+	cmd.Flags().IntVar(&limitFlag, "limit", 25, "max messages to show")
+	cmd.Flags().IntVar(&offsetFlag, "offset", 0, "number of messages to skip")
+	addDateRangeFlags(cmd, &sinceFlag, &beforeFlag)
+	cmd.Flags().BoolVar(&sinceSyncFlag, "since-sync", false, "only mail added by the last sync")
+	return cmd
+}
+
+func newReadCmd() *cobra.Command {
+	var (
+		accountFlag string
+		wrapFlag    int
+		noWrapFlag  bool
+	)
 
 	cmd := &cobra.Command{
 		Use:   "read <thread-id>",
 		Short: "Read an email thread",
-		Long:  "Display all messages in a thread by thread ID.",
-		Args:  cobra.ExactArgs(1),
+		Long: "Display all messages in a thread by thread ID.\n" +
+			"Bodies are word-wrapped to the terminal width when printing to a terminal;\n" +
+			"use --wrap to pick the width or --no-wrap to print them as stored.",
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			threadID := args[0]
 
@@ -115,6 +263,7 @@ func newReadCmd() *cobra.Command {
 				return printJSON(toJSONThreadDetail(thread))
 			}
 
+			width := wrapWidth(wrapFlag, noWrapFlag)
 			fmt.Printf("Subject: %s\n", thread.Subject)
 			fmt.Printf("Thread ID: %s\n", thread.ID)
 			fmt.Printf("Messages: %d\n", len(thread.Messages))
@@ -125,47 +274,68 @@ func newReadCmd() *cobra.Command {
 					fmt.Println()
 					fmt.Println(strings.Repeat("─", 60))
 				}
-				fmt.Printf("From: %s\n", msg.From)
+				fmt.Printf("From: %s\n", msg.From.Display())
 				if len(msg.To) > 0 {
 					to := make([]string, len(msg.To))
 					for j, a := range msg.To {
-						to[j] = a.String()
+						to[j] = a.Display()
 					}
 					fmt.Printf("To: %s\n", strings.Join(to, ", "))
 				}
 				if len(msg.CC) > 0 {
 					cc := make([]string, len(msg.CC))
 					for j, a := range msg.CC {
-						cc[j] = a.String()
+						cc[j] = a.Display()
 					}
 					fmt.Printf("CC: %s\n", strings.Join(cc, ", "))
 				}
-				fmt.Printf("Date: %s\n", msg.Date.Format("Mon, Jan 2 2006 3:04 PM"))
+				fmt.Printf("Date: %s\n", domain.FormatDate(msg.Date, "Mon, Jan 2 2006 3:04 PM"))
 				readStatus := "read"
 				if !msg.IsRead {
 					readStatus = "unread"
 				}
 				fmt.Printf("Status: %s\n", readStatus)
 				fmt.Printf("Message ID: %s\n", msg.ID)
+				if ev := msg.Event; ev != nil {
+					fmt.Printf("Event: %s\n", ev.Summary)
+					if !ev.Start.IsZero() {
+						fmt.Printf("  Starts: %s\n", ev.Start.Local().Format("Mon, Jan 2 2006 3:04 PM"))
+					}
+					if !ev.End.IsZero() {
+						fmt.Printf("  Ends: %s\n", ev.End.Local().Format("Mon, Jan 2 2006 3:04 PM"))
+					}
+					if ev.Location != "" {
+						fmt.Printf("  Location: %s\n", ev.Location)
+					}
+					if ev.Organizer.Email != "" {
+						fmt.Printf("  Organizer: %s\n", ev.Organizer)
+					}
+				}
 				fmt.Println()
-				fmt.Println(msg.Body)
+				fmt.Println(tui.WrapText(msg.Body, width))
 			}
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID (defaults to config default)")
+	cmd.Flags().IntVar(&wrapFlag, "wrap", 0, "wrap bodies at this many columns (default: terminal width)")
+	cmd.Flags().BoolVar(&noWrapFlag, "no-wrap", false, "print bodies without wrapping")
+	cmd.MarkFlagsMutuallyExclusive("wrap", "no-wrap")
 	return cmd
 }
 
 func newSearchCmd() *cobra.Command {
 	var accountFlag string
 	var limitFlag int
+	var allFlag, inboxFlag, sinceSyncFlag bool
+	var sinceFlag, beforeFlag string
 
 	cmd := &cobra.Command{
 		Use:   "search <query>",
 		Short: "Search emails",
-		Long:  "Full-text search across email subject, body, and sender.",
+		Long: "Full-text search across email subject, body, and sender.\n" +
+			"Messages in Trash and Spam are excluded unless --all is given.",
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			query := strings.Join(args, " ")
@@ -181,7 +351,22 @@ func newSearchCmd() *cobra.Command {
 				return err
 			}
 
-			emails, err := db.SearchEmails(cmd.Context(), query, accountID)
+			after, before, err := parseDateRange(sinceFlag, beforeFlag)
+			if err != nil {
+				return err
+			}
+			syncedAfter, err := sinceSyncTime(cmd, db, accountID, sinceSyncFlag)
+			if err != nil {
+				return err
+			}
+
+			emails, err := db.SearchEmails(cmd.Context(), query, accountID, store.SearchOptions{
+				IncludeTrash: allFlag,
+				InboxOnly:    inboxFlag,
+				After:        after,
+				Before:       before,
+				SyncedAfter:  syncedAfter,
+			})
 			if err != nil {
 				return fmt.Errorf("failed to search: %w", err)
 			}
@@ -201,7 +386,7 @@ func newSearchCmd() *cobra.Command {
 			}
 
 			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
-			fmt.Fprintln(w, "FROM\tSUBJECT\tDATE\tID")
+			fmt.Fprintln(w, "FROM\tSUBJECT\tDATE\tID\tSNIPPET")
 			for _, e := range shown {
 				from := e.From.Name
 				if from == "" {
@@ -214,10 +399,14 @@ func newSearchCmd() *cobra.Command {
 				if len(subject) > 50 {
 					subject = subject[:47] + "..."
 				}
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				snippet := strings.Join(strings.Fields(e.PlainSnippet()), " ")
+				if len(snippet) > 60 {
+					snippet = snippet[:57] + "..."
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
 					from, subject,
-					e.Date.Format("Jan 2, 2006"),
-					e.ID,
+					domain.FormatDate(e.Date, "Jan 2, 2006"),
+					e.ID, snippet,
 				)
 			}
 			return w.Flush()
@@ -226,15 +415,22 @@ func newSearchCmd() *cobra.Command {
 
 	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID (defaults to config default)")
 	cmd.Flags().IntVar(&limitFlag, "limit", 25, "max results to show")
+	cmd.Flags().BoolVar(&allFlag, "all", false, "include messages in Trash and Spam")
+	cmd.Flags().BoolVar(&inboxFlag, "inbox", false, "only search messages in the inbox")
+	addDateRangeFlags(cmd, &sinceFlag, &beforeFlag)
+	cmd.Flags().BoolVar(&sinceSyncFlag, "since-sync", false, "only mail added by the last sync")
 	return cmd
 }
 
 func newLabelsCmd() *cobra.Command {
 	var accountFlag string
+	var treeFlag bool
 
 	cmd := &cobra.Command{
 		Use:   "labels",
 		Short: "List labels for an account",
+		Long: "List labels for an account. With --tree, user labels are shown as a\n" +
+			"hierarchy split on \"/\" (e.g. \"Work/ProjectA\" nests under \"Work\").",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			db, err := openDB()
 			if err != nil {
@@ -252,6 +448,10 @@ func newLabelsCmd() *cobra.Command {
 				return fmt.Errorf("failed to list labels: %w", err)
 			}
 
+			if treeFlag {
+				return printLabelTree(labels)
+			}
+
 			if jsonFlag {
 				return printJSON(toJSONLabels(labels))
 			}
@@ -271,10 +471,106 @@ func newLabelsCmd() *cobra.Command {
 	}
 
 	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID (defaults to config default)")
+	cmd.Flags().BoolVar(&treeFlag, "tree", false, "show nested user labels as a tree")
 	return cmd
 }
 
+// printLabelTree prints system labels followed by user labels arranged by
+// their "/" hierarchy. Parents that exist only implicitly have no ID.
+func printLabelTree(labels []domain.Label) error {
+	var system, user []domain.Label
+	for _, l := range labels {
+		if l.Type == domain.LabelTypeUser {
+			user = append(user, l)
+		} else {
+			system = append(system, l)
+		}
+	}
+	tree := domain.BuildLabelTree(user)
+
+	if jsonFlag {
+		return printJSON(jsonLabelTree{
+			System: toJSONLabels(system),
+			User:   toJSONLabelNodes(tree),
+		})
+	}
+
+	if len(labels) == 0 {
+		fmt.Println("No labels found. Run 'termail sync' first.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tID")
+	for _, l := range system {
+		fmt.Fprintf(w, "%s\t%s\n", l.Name, l.ID)
+	}
+	domain.WalkLabelTree(tree, func(n *domain.LabelNode, depth int) bool {
+		id := ""
+		if n.Label != nil {
+			id = n.Label.ID
+		}
+		fmt.Fprintf(w, "%s%s\t%s\n", strings.Repeat("  ", depth), n.Name, id)
+		return true
+	})
+	return w.Flush()
+}
+
 // resolveAccountFlag resolves the account ID from flag, config default, or first account.
+// addDateRangeFlags registers the --since and --before filters.
+func addDateRangeFlags(cmd *cobra.Command, since, before *string) {
+	cmd.Flags().StringVar(since, "since", "", "only mail received on or after this date (2006-01-02, RFC 3339, or relative like 7d or 12h)")
+	cmd.Flags().StringVar(before, "before", "", "only mail received before this date (same formats as --since)")
+}
+
+// parseDateRange resolves the --since and --before values; empty values
+// leave that end of the range open.
+func parseDateRange(since, before string) (after, until time.Time, err error) {
+	now := time.Now()
+	if since != "" {
+		if after, err = domain.ParseDateFilter(since, now); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("--since: %w", err)
+		}
+	}
+	if before != "" {
+		if until, err = domain.ParseDateFilter(before, now); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("--before: %w", err)
+		}
+	}
+	if !after.IsZero() && !until.IsZero() && !after.Before(until) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--since must be earlier than --before")
+	}
+	return after, until, nil
+}
+
+// sinceSyncTime returns when the account's last sync started, to filter on
+// with --since-sync, or the zero time when the flag is off.
+func sinceSyncTime(cmd *cobra.Command, db *sqlite.DB, accountID string, enabled bool) (time.Time, error) {
+	if !enabled {
+		return time.Time{}, nil
+	}
+	state, err := db.GetSyncState(cmd.Context(), accountID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if state.LastSync == 0 {
+		return time.Time{}, fmt.Errorf("account %s has not been synced yet", accountID)
+	}
+	return time.Unix(state.LastSync, 0), nil
+}
+
+// labelListLimit returns the configured default list limit for labelID,
+// matching per-label overrides by ID or by the label's name.
+func labelListLimit(cmd *cobra.Command, db *sqlite.DB, cfg *config.Config, accountID, labelID string) int {
+	var name string
+	if labels, err := db.ListLabels(cmd.Context(), accountID); err == nil {
+		if l, ok := findLabel(labels, labelID); ok {
+			labelID, name = l.ID, l.Name
+		}
+	}
+	return cfg.ListLimit(labelID, name)
+}
+
 func resolveAccountFlag(db *sqlite.DB, accountFlag string) (string, error) {
 	if accountFlag != "" {
 		return accountFlag, nil
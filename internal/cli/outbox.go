@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/lu-zhengda/termail/internal/app"
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider"
+)
+
+// sendResult is the outcome of sendMail.
+type sendResult int
+
+const (
+	sendSent sendResult = iota
+	// sendCancelled: cancelled during the send delay, or declined at the
+	// recipient-count prompt.
+	sendCancelled
+	// sendQueued: the send failed and the message waits in the outbox for
+	// `termail outbox flush` or the TUI to retry it.
+	sendQueued
+)
+
+// sendMail sends email, first holding it in the outbox for the configured
+// send delay so it can be cancelled with `termail outbox cancel`. A message
+// the provider fails to take, for instance while offline, stays in the
+// outbox rather than being lost. The configured default CC and BCC are
+// added first.
+func sendMail(cmd *cobra.Command, p provider.EmailProvider, accountID string, email *domain.Email, yes bool) (sendResult, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return sendCancelled, err
+	}
+	email.ApplyDefaultRecipients(parseAddrList(cfg.Compose.DefaultCC, cfg.Groups),
+		parseAddrList(cfg.Compose.DefaultBCC, cfg.Groups))
+	ok, err := confirmRecipients(os.Stdin, os.Stdout, !jsonFlag && stdinIsTerminal(), yes,
+		cfg.Compose.ConfirmRecipients, email)
+	if err != nil || !ok {
+		return sendCancelled, err
+	}
+
+	delay, err := cfg.SendDelay("gmail")
+	if err != nil {
+		return sendCancelled, err
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return sendCancelled, err
+	}
+	defer db.Close()
+	outbox := app.NewOutboxService(db, p, accountID)
+
+	if delay == 0 {
+		return queuedResult(outbox.SendNow(cmd.Context(), email))
+	}
+
+	id, err := outbox.Queue(cmd.Context(), email, delay)
+	if err != nil {
+		return sendCancelled, err
+	}
+	fmt.Fprintf(os.Stderr, "Sending in %s; cancel with: termail outbox cancel %d\n", delay, id)
+
+	select {
+	case <-time.After(delay):
+	case <-cmd.Context().Done():
+		return sendCancelled, fmt.Errorf("interrupted; message %d is still in the outbox", id)
+	}
+
+	err = outbox.Send(cmd.Context(), id)
+	if errors.Is(err, app.ErrAlreadySent) {
+		return sendCancelled, nil
+	}
+	return queuedResult(err)
+}
+
+// queuedResult maps an outbox send error to a sendResult, reporting a
+// failure that left the message in the outbox on stderr instead of failing.
+func queuedResult(err error) (sendResult, error) {
+	switch {
+	case err == nil:
+		return sendSent, nil
+	case errors.Is(err, app.ErrQueued):
+		fmt.Fprintln(os.Stderr, err)
+		return sendQueued, nil
+	default:
+		return sendCancelled, err
+	}
+}
+
+// confirmRecipients asks before sending email to more than limit distinct
+// recipients, guarding against an accidental reply-all. It reports false if
+// the user declines; when there is no terminal to ask on, it fails unless
+// yes is set.
+func confirmRecipients(in io.Reader, out io.Writer, interactive, yes bool, limit int, email *domain.Email) (bool, error) {
+	n := len(email.Recipients())
+	if limit <= 0 || n <= limit || yes {
+		return true, nil
+	}
+	if !interactive {
+		return false, fmt.Errorf("message has %d recipients, more than compose.confirm_recipients (%d); pass --yes to send", n, limit)
+	}
+	return confirm(in, out, fmt.Sprintf("This message goes to %d recipients. Send it?", n)), nil
+}
+
+// printUnsent reports a message that did not leave: cancelled, or queued in
+// the outbox after a failed send.
+func printUnsent(result sendResult, action, messageID string) error {
+	if jsonFlag {
+		return printJSON(jsonAction{OK: false, Action: action, MessageID: messageID, Queued: result == sendQueued})
+	}
+	if result == sendQueued {
+		fmt.Println("Not sent; saved to the outbox. Retry with: termail outbox flush")
+		return nil
+	}
+	fmt.Println("Send cancelled.")
+	return nil
+}
+
+func newOutboxCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "outbox",
+		Short: "Manage messages waiting to be sent",
+		Long: "Messages are held in the outbox for the configured send delay\n" +
+			"([gmail] send_delay) before they are sent, and can be cancelled meanwhile.\n" +
+			"Mail that fails to send, for instance while offline, also waits there\n" +
+			"until `outbox flush` or the TUI retries it.",
+	}
+	cmd.AddCommand(newOutboxListCmd())
+	cmd.AddCommand(newOutboxCancelCmd())
+	cmd.AddCommand(newOutboxFlushCmd())
+	return cmd
+}
+
+func newOutboxListCmd() *cobra.Command {
+	var accountFlag string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List messages waiting to be sent",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			accountID, err := resolveAccountFlag(db, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			items, err := db.ListOutbox(cmd.Context(), accountID)
+			if err != nil {
+				return err
+			}
+
+			if jsonFlag {
+				out := make([]jsonOutboxItem, 0, len(items))
+				for _, item := range items {
+					out = append(out, jsonOutboxItem{
+						ID:      item.ID,
+						To:      toJSONAddresses(item.Email.To),
+						Subject: item.Email.Subject,
+						SendAt:  item.SendAt.Format(time.RFC3339),
+					})
+				}
+				return printJSON(out)
+			}
+
+			if len(items) == 0 {
+				fmt.Println("Outbox is empty.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tTO\tSUBJECT\tSEND AT")
+			for _, item := range items {
+				to := ""
+				if len(item.Email.To) > 0 {
+					to = item.Email.To[0].Email
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n",
+					item.ID, to, item.Email.Subject, item.SendAt.Format("15:04:05"))
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID (defaults to config default)")
+	return cmd
+}
+
+func newOutboxCancelCmd() *cobra.Command {
+	var accountFlag string
+
+	cmd := &cobra.Command{
+		Use:   "cancel <id>",
+		Short: "Cancel a message before it is sent",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid outbox ID %q", args[0])
+			}
+
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			accountID, err := resolveAccountFlag(db, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			// Cancelling only touches the local outbox, so no provider is needed.
+			if err := app.NewOutboxService(db, nil, accountID).Cancel(cmd.Context(), id); err != nil {
+				return err
+			}
+
+			if jsonFlag {
+				return printJSON(jsonAction{OK: true, Action: "outbox-cancel"})
+			}
+			fmt.Println("Send cancelled.")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID (defaults to config default)")
+	return cmd
+}
+
+func newOutboxFlushCmd() *cobra.Command {
+	var accountFlag string
+
+	cmd := &cobra.Command{
+		Use:   "flush",
+		Short: "Retry sending every due message in the outbox",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			provider, accountID, err := setupProvider(cmd, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			sent, err := app.NewOutboxService(db, provider, accountID).SendDue(cmd.Context(), time.Now())
+			if err != nil {
+				return fmt.Errorf("failed to flush outbox (%d sent): %w", sent, err)
+			}
+
+			if jsonFlag {
+				return printJSON(jsonOutboxFlush{OK: true, AccountID: accountID, Sent: sent})
+			}
+			fmt.Printf("Sent %d message(s) from the outbox.\n", sent)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID (defaults to config default)")
+	return cmd
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+func TestConfirmRecipients(t *testing.T) {
+	email := &domain.Email{
+		To: []domain.Address{{Email: "a@example.com"}, {Email: "b@example.com"}},
+		CC: []domain.Address{{Email: "c@example.com"}, {Email: "A@example.com"}},
+	}
+
+	// Three distinct recipients: at the threshold nothing is asked.
+	var out bytes.Buffer
+	ok, err := confirmRecipients(strings.NewReader(""), &out, true, false, 3, email)
+	if err != nil || !ok || out.Len() != 0 {
+		t.Errorf("at threshold: ok=%v err=%v prompt=%q, want send without asking", ok, err, out.String())
+	}
+
+	ok, err = confirmRecipients(strings.NewReader("n\n"), &out, true, false, 2, email)
+	if err != nil || ok {
+		t.Errorf("declined: ok=%v err=%v, want cancelled", ok, err)
+	}
+	if !strings.Contains(out.String(), "3 recipients") {
+		t.Errorf("prompt = %q, want the recipient count", out.String())
+	}
+
+	if ok, err := confirmRecipients(strings.NewReader("y\n"), &out, true, false, 2, email); err != nil || !ok {
+		t.Errorf("confirmed: ok=%v err=%v, want send", ok, err)
+	}
+
+	if _, err := confirmRecipients(strings.NewReader(""), &out, false, false, 2, email); err == nil {
+		t.Error("without a terminal the send should fail instead of asking")
+	}
+	if ok, err := confirmRecipients(strings.NewReader(""), &out, false, true, 2, email); err != nil || !ok {
+		t.Errorf("--yes: ok=%v err=%v, want send", ok, err)
+	}
+	if ok, err := confirmRecipients(strings.NewReader(""), &out, false, false, 0, email); err != nil || !ok {
+		t.Errorf("limit 0: ok=%v err=%v, want send", ok, err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+
+	"github.com/charmbracelet/x/term"
 )
 
 // printJSON encodes v as indented JSON to stdout.
@@ -21,3 +23,24 @@ func fprintJSON(w io.Writer, v any) error {
 	}
 	return nil
 }
+
+// wrapWidth returns the column to wrap printed bodies at: wrap when set,
+// otherwise the terminal width when stdout is a terminal, and 0 (no
+// wrapping) with noWrap or when output is piped.
+func wrapWidth(wrap int, noWrap bool) int {
+	if noWrap {
+		return 0
+	}
+	if wrap > 0 {
+		return wrap
+	}
+	fd := os.Stdout.Fd()
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+	width, _, err := term.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+	return width
+}
@@ -58,3 +58,24 @@ func TestFprintJSON(t *testing.T) {
 		}
 	})
 }
+
+func TestWrapWidth(t *testing.T) {
+	// Tests run with stdout redirected, so there is no terminal to measure.
+	tests := []struct {
+		name   string
+		wrap   int
+		noWrap bool
+		want   int
+	}{
+		{"explicit width", 72, false, 72},
+		{"no-wrap", 0, true, 0},
+		{"piped output", 0, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapWidth(tt.wrap, tt.noWrap); got != tt.want {
+				t.Errorf("wrapWidth(%d, %v) = %d, want %d", tt.wrap, tt.noWrap, got, tt.want)
+			}
+		})
+	}
+}
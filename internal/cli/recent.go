@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+func newRecentCmd() *cobra.Command {
+	var accountFlag string
+	var limitFlag int
+
+	cmd := &cobra.Command{
+		Use:   "recent",
+		Short: "List recently viewed threads",
+		Long: "List the threads most recently opened in the TUI, newest first, so\n" +
+			"something just read is easy to get back to with `termail read`.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			accountID, err := resolveAccountFlag(db, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			views, err := db.ListRecent(cmd.Context(), accountID, limitFlag)
+			if err != nil {
+				return err
+			}
+
+			if jsonFlag {
+				out := make([]jsonRecent, 0, len(views))
+				for _, v := range views {
+					out = append(out, jsonRecent{
+						ID:       v.Email.ID,
+						ThreadID: v.Email.ThreadID,
+						From:     toJSONAddress(v.Email.From),
+						Subject:  v.Email.Subject,
+						ViewedAt: v.ViewedAt.Format(time.RFC3339),
+					})
+				}
+				return printJSON(out)
+			}
+
+			if len(views) == 0 {
+				fmt.Println("Nothing viewed recently.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "VIEWED\tFROM\tSUBJECT\tTHREAD ID")
+			for _, v := range views {
+				from := v.Email.From.Name
+				if from == "" {
+					from = v.Email.From.Email
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+					domain.FormatDate(v.ViewedAt, "Jan 2 15:04"), from, v.Email.Subject, v.Email.ThreadID)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID (defaults to config default)")
+	cmd.Flags().IntVar(&limitFlag, "limit", 10, "max threads to show")
+	return cmd
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/lu-zhengda/termail/internal/config"
+	"github.com/lu-zhengda/termail/internal/domain"
 	"github.com/lu-zhengda/termail/internal/provider"
 	"github.com/lu-zhengda/termail/internal/provider/gmail"
 	"github.com/lu-zhengda/termail/internal/store"
@@ -22,6 +24,10 @@ var (
 
 	// jsonFlag enables JSON output for all commands.
 	jsonFlag bool
+
+	// dryRunFlag makes destructive commands print the provider calls they
+	// would make instead of making them.
+	dryRunFlag bool
 )
 
 func NewRootCmd() *cobra.Command {
@@ -46,74 +52,71 @@ func NewRootCmd() *cobra.Command {
 				}
 			}
 
-			db, err := openDB()
-			if err != nil {
-				return err
-			}
-			defer db.Close()
-
-			cfg, err := loadConfig()
-			if err != nil {
-				return err
-			}
-
-			if err := resolveGmailCredentials(cfg); err != nil {
-				return err
-			}
-
-			// Determine the initial account.
-			accountID := accountFlag
-			if accountID == "" {
-				accountID, err = resolveAccountID(db, cfg)
-				if err != nil {
-					return err
-				}
-			}
-
-			// Load all accounts for account switching.
-			accounts, err := db.ListAccounts(cmd.Context())
-			if err != nil {
-				return fmt.Errorf("failed to list accounts: %w", err)
-			}
-
-			tokenStore := store.NewKeyringTokenStore()
-			p := gmail.New(accountID, tokenStore)
-
-			factory := tui.ProviderFactory(func(accID string) provider.EmailProvider {
-				return gmail.New(accID, tokenStore)
-			})
-
-			return tui.Run(db, p, accountID, accounts, factory)
+			return runTUI(cmd, accountFlag, nil)
 		},
 	}
+	// --dry-run is global, so refuse it where a command would ignore it
+	// and make its changes anyway.
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return checkDryRun(cmd, dryRunFlag)
+	}
 	root.SetVersionTemplate(fmt.Sprintf("termail %s\n", version))
 	root.CompletionOptions.DisableDefaultCmd = true
 	root.Flags().String("generate-completion", "", "Generate shell completion (bash, zsh, fish)")
 	root.Flags().MarkHidden("generate-completion")
 	root.PersistentFlags().StringVar(&cfgFile, "config", "", "config file path")
 	root.PersistentFlags().BoolVar(&jsonFlag, "json", false, "output in JSON format")
+	root.PersistentFlags().BoolVar(&dryRunFlag, "dry-run", false, "print what archive, trash, spam and label-modify would send to the provider without changing anything")
 	root.Flags().StringVar(&accountFlag, "account", "", "account ID to use (defaults to config default or first account)")
 	root.AddCommand(newAccountCmd())
 	root.AddCommand(newSyncCmd())
+	root.AddCommand(newRebuildSummariesCmd())
 	root.AddCommand(newListCmd())
+	root.AddCommand(newMessagesCmd())
 	root.AddCommand(newReadCmd())
 	root.AddCommand(newSearchCmd())
+	root.AddCommand(newRecentCmd())
+	root.AddCommand(newStatsCmd())
 	root.AddCommand(newLabelsCmd())
+	root.AddCommand(newLabelCmd())
 	root.AddCommand(newComposeCmd())
 	root.AddCommand(newReplyCmd())
 	root.AddCommand(newForwardCmd())
+	root.AddCommand(newOutboxCmd())
 	root.AddCommand(newArchiveCmd())
 	root.AddCommand(newTrashCmd())
+	root.AddCommand(newUntrashCmd())
+	root.AddCommand(newSpamCmd())
+	root.AddCommand(newNotSpamCmd())
 	root.AddCommand(newStarCmd())
 	root.AddCommand(newMarkReadCmd())
+	root.AddCommand(newFlagCmd())
+	root.AddCommand(newSnoozeCmd())
 	root.AddCommand(newLabelModifyCmd())
+	root.AddCommand(newMoveCmd())
+	root.AddCommand(newUnsubscribeCmd())
+	root.AddCommand(newExportCmd())
+	root.AddCommand(newBulkCmd())
+	root.AddCommand(newBatchCmd())
+	root.AddCommand(newConfigCmd())
 	return root
 }
 
 func Execute() {
-	if err := NewRootCmd().Execute(); err != nil {
-		os.Exit(1)
-	}
+	// Ctrl+C cancels the command's context so long runs such as
+	// `sync --full` stop cleanly between pages.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+// run executes the command line args and returns the process exit status
+// for its outcome.
+func run(ctx context.Context, args []string) int {
+	root := NewRootCmd()
+	root.SetArgs(args)
+	return exitCode(root.ExecuteContext(ctx))
 }
 
 // openDB creates the data directory and opens the SQLite database.
@@ -131,13 +134,67 @@ func openDB() (*sqlite.DB, error) {
 	return db, nil
 }
 
+// runTUI launches the interactive TUI. A non-nil draft opens the composer
+// pre-filled with it.
+func runTUI(cmd *cobra.Command, accountFlag string, draft *domain.Email) error {
+	db, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := resolveGmailCredentials(cfg); err != nil {
+		return err
+	}
+
+	// Determine the initial account.
+	accountID := accountFlag
+	if accountID == "" {
+		accountID, err = resolveAccountID(db, cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Load all accounts for account switching.
+	accounts, err := db.ListAccounts(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to list accounts: %w", err)
+	}
+
+	tokenStore, err := newTokenStore(cfg)
+	if err != nil {
+		return err
+	}
+	p := newGmailProvider(cfg, accountID, tokenStore)
+
+	factory := tui.ProviderFactory(func(accID string) provider.EmailProvider {
+		return newGmailProvider(cfg, accID, tokenStore)
+	})
+
+	if draft != nil {
+		return tui.RunCompose(cfg, db, p, accountID, accounts, factory, draft)
+	}
+	return tui.Run(cfg, db, p, accountID, accounts, factory)
+}
+
+// configPath returns the config file given by --config, or the default one
+// in the config directory.
+func configPath() string {
+	if cfgFile != "" {
+		return cfgFile
+	}
+	return filepath.Join(config.ConfigDir(), "config.toml")
+}
+
 // loadConfig loads the application configuration from the config file.
 func loadConfig() (*config.Config, error) {
-	path := cfgFile
-	if path == "" {
-		path = filepath.Join(config.ConfigDir(), "config.toml")
-	}
-	cfg, err := config.Load(path)
+	cfg, err := config.Load(configPath())
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
@@ -161,6 +218,31 @@ func resolveAccountID(db *sqlite.DB, cfg *config.Config) (string, error) {
 	return accounts[0].ID, nil
 }
 
+// newTokenStore returns the token store selected by the TERMAIL_TOKEN_STORE
+// environment variable or the [auth] token_store config setting.
+func newTokenStore(cfg *config.Config) (store.TokenStore, error) {
+	kind := cfg.Auth.TokenStore
+	if env := os.Getenv("TERMAIL_TOKEN_STORE"); env != "" {
+		kind = env
+	}
+	switch kind {
+	case "", "keyring":
+		return store.NewKeyringTokenStore(), nil
+	case "file":
+		return store.NewFileTokenStore(filepath.Join(config.DataDir(), "tokens")), nil
+	default:
+		return nil, fmt.Errorf("unknown token store %q (use \"keyring\" or \"file\")", kind)
+	}
+}
+
+// newGmailProvider creates a Gmail provider for accountID configured from
+// cfg.
+func newGmailProvider(cfg *config.Config, accountID string, tokenStore store.TokenStore) *gmail.Provider {
+	p := gmail.New(accountID, tokenStore)
+	p.SetMaxConcurrency(cfg.Sync.MaxConcurrency)
+	return p
+}
+
 // resolveGmailCredentials sets Gmail OAuth credentials using the first
 // available source: config file → environment variables.
 func resolveGmailCredentials(cfg *config.Config) error {
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+func newStatsCmd() *cobra.Command {
+	var accountFlag string
+	var topFlag int
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Summarize the locally synced mailbox",
+		Long: "Print counts over the locally synced mail of an account: messages, unread\n" +
+			"and starred, messages per label, the most frequent senders and messages\n" +
+			"per month (UTC). Works offline.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			accountID, err := resolveAccountFlag(db, accountFlag)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			totals, err := db.MailboxStats(ctx, accountID)
+			if err != nil {
+				return err
+			}
+			labels, err := db.LabelCounts(ctx, accountID)
+			if err != nil {
+				return err
+			}
+			senders, err := db.TopSenders(ctx, accountID, topFlag)
+			if err != nil {
+				return err
+			}
+			months, err := db.MessagesPerMonth(ctx, accountID)
+			if err != nil {
+				return err
+			}
+
+			stats := toJSONStats(accountID, totals, labels, senders, months)
+			if jsonFlag {
+				return printJSON(stats)
+			}
+			return writeStats(os.Stdout, stats)
+		},
+	}
+
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID (defaults to config default or first account)")
+	cmd.Flags().IntVar(&topFlag, "top", 10, "number of top senders to show (0 for all)")
+	return cmd
+}
+
+// writeStats prints stats as a summary followed by one table per breakdown.
+func writeStats(out io.Writer, stats jsonStats) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Account:\t%s\n", stats.AccountID)
+	fmt.Fprintf(w, "Messages:\t%d\n", stats.Total)
+	fmt.Fprintf(w, "Unread:\t%d\n", stats.Unread)
+	fmt.Fprintf(w, "Starred:\t%d\n", stats.Starred)
+
+	if len(stats.Labels) > 0 {
+		fmt.Fprintln(w, "\nLABEL\tMESSAGES\tUNREAD")
+		for _, l := range stats.Labels {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", l.Name, l.Total, l.Unread)
+		}
+	}
+	if len(stats.TopSenders) > 0 {
+		fmt.Fprintln(w, "\nSENDER\tMESSAGES")
+		for _, s := range stats.TopSenders {
+			from := s.Email
+			if s.Name != "" {
+				from = fmt.Sprintf("%s <%s>", s.Name, s.Email)
+			}
+			fmt.Fprintf(w, "%s\t%d\n", from, s.Count)
+		}
+	}
+	if len(stats.Months) > 0 {
+		fmt.Fprintln(w, "\nMONTH\tMESSAGES")
+		for _, m := range stats.Months {
+			fmt.Fprintf(w, "%s\t%d\n", m.Month, m.Count)
+		}
+	}
+	return w.Flush()
+}
+
+func toJSONStats(accountID string, totals store.MailboxStats, labels []store.LabelCount,
+	senders []store.SenderCount, months []store.MonthCount) jsonStats {
+	out := jsonStats{
+		AccountID:  accountID,
+		Total:      totals.Total,
+		Unread:     totals.Unread,
+		Starred:    totals.Starred,
+		Labels:     make([]jsonLabelCount, 0, len(labels)),
+		TopSenders: make([]jsonSenderCount, 0, len(senders)),
+		Months:     make([]jsonMonthCount, 0, len(months)),
+	}
+	for _, l := range labels {
+		out.Labels = append(out.Labels, jsonLabelCount{ID: l.LabelID, Name: l.Name, Total: l.Total, Unread: l.Unread})
+	}
+	for _, s := range senders {
+		out.TopSenders = append(out.TopSenders, jsonSenderCount{Name: s.Address.Name, Email: s.Address.Email, Count: s.Count})
+	}
+	for _, m := range months {
+		out.Months = append(out.Months, jsonMonthCount{Month: m.Month, Count: m.Count})
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newRebuildSummariesCmd() *cobra.Command {
+	var accountFlag string
+
+	cmd := &cobra.Command{
+		Use:   "rebuild-summaries",
+		Short: "Recompute the cached thread summaries from local mail",
+		Long: "Thread listings read precomputed summaries that termail keeps in step\n" +
+			"with the local mail. Rebuild them from scratch if a listing ever looks\n" +
+			"out of date.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := openDB()
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			accountID, err := resolveAccountFlag(db, accountFlag)
+			if err != nil {
+				return err
+			}
+			threads, err := db.RebuildThreadSummaries(cmd.Context(), accountID)
+			if err != nil {
+				return err
+			}
+
+			if jsonFlag {
+				return printJSON(jsonRebuildSummaries{OK: true, AccountID: accountID, Threads: threads})
+			}
+			fmt.Printf("Rebuilt summaries of %d threads for %s\n", threads, accountID)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&accountFlag, "account", "", "account ID (defaults to config default or first account)")
+	return cmd
+}
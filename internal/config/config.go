@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -14,6 +16,12 @@ type Config struct {
 	UI       UIConfig       `toml:"ui"`
 	Accounts AccountsConfig `toml:"accounts"`
 	Gmail    GmailConfig    `toml:"gmail"`
+	Compose  ComposeConfig  `toml:"compose"`
+	Auth     AuthConfig     `toml:"auth"`
+
+	// Groups maps a name to comma-separated addresses or other group names;
+	// a recipient given as the name expands to its members.
+	Groups map[string]string `toml:"groups"`
 }
 
 // GmailConfig holds Gmail OAuth credentials.
@@ -21,18 +29,125 @@ type Config struct {
 type GmailConfig struct {
 	ClientID     string `toml:"client_id"`
 	ClientSecret string `toml:"client_secret"`
+	// SendDelay holds outgoing mail in the outbox for this long, e.g.
+	// "10s", so it can still be cancelled. Empty sends immediately.
+	SendDelay string `toml:"send_delay"`
 }
 
 // SyncConfig holds email synchronization settings.
 type SyncConfig struct {
 	Interval     string `toml:"interval"`
 	InitialCount int    `toml:"initial_count"`
+	// ConfirmPrune asks before `termail sync --full --prune` deletes local
+	// messages that are no longer on the server.
+	ConfirmPrune bool `toml:"confirm_prune"`
+	// ThreadByReferences groups messages that lack a provider thread ID
+	// into threads using their In-Reply-To and References headers as they
+	// are synced.
+	ThreadByReferences bool `toml:"thread_by_references"`
+	// MaxConcurrency is how many messages are fetched from the provider in
+	// parallel during a sync.
+	MaxConcurrency int `toml:"max_concurrency"`
+	// MaxBodyBytes caps how much of each message body a sync stores; longer
+	// text and HTML bodies are cut short. 0 means no limit.
+	MaxBodyBytes int `toml:"max_body_bytes"`
 }
 
 // UIConfig holds TUI display settings.
 type UIConfig struct {
 	DefaultView string `toml:"default_view"`
 	Theme       string `toml:"theme"`
+	// SearchContextLines is how many lines are kept above a search match
+	// when the reader scrolls to it.
+	SearchContextLines int `toml:"search_context_lines"`
+	// AutoReload is how often the TUI checks whether another process (such
+	// as a cron-driven `termail sync`) changed the database, e.g. "10s".
+	// Empty disables the check.
+	AutoReload string `toml:"auto_reload"`
+	// Sort is the default thread order: "date" (newest first) or
+	// "priority" (unread, starred and important threads first).
+	Sort string `toml:"sort"`
+	// ThreadEnter selects what Enter does on a multi-message thread:
+	// "open" (default) shows the whole thread in the reader, "expand"
+	// first lists its messages inline so one can be picked.
+	ThreadEnter string `toml:"thread_enter"`
+	// IncludeChildLabels makes selecting a nested label in the sidebar
+	// also list mail under its child labels (e.g. "Work/ProjectA").
+	IncludeChildLabels bool `toml:"include_child_labels"`
+	// FocusOrder is the order Tab moves focus through the panes, from
+	// "sidebar", "list" and "reader"; hidden panes are skipped and panes
+	// left out are never tabbed to. Empty cycles through all three.
+	FocusOrder []string `toml:"focus_order"`
+	// DeleteAction selects what the delete key does: "trash" (default),
+	// "archive", or "label:<name>" to move the mail to that label. The
+	// trash key always trashes.
+	DeleteAction string `toml:"delete_action"`
+	// StartupLabel is the label, by ID or name, the TUI opens on instead
+	// of INBOX.
+	StartupLabel string `toml:"startup_label"`
+	// LoadRemoteContent shows remote images in HTML mail by default. When
+	// false they are replaced by a placeholder until toggled per message.
+	LoadRemoteContent bool `toml:"load_remote_content"`
+	// CompactHeaders starts the reader with a one-line header per message
+	// instead of the full header block.
+	CompactHeaders bool `toml:"compact_headers"`
+	// PreferHTML renders mail that has both a text and an HTML part from
+	// the HTML part by default; the reader can switch per message.
+	PreferHTML bool `toml:"prefer_html"`
+	// InlineReply makes the reply key open a one-field reply box under the
+	// message in the reader instead of the composer; reply-all and
+	// forward still use the composer.
+	InlineReply bool `toml:"inline_reply"`
+	// Density is the initial list layout: "compact" (default) shows one
+	// line per row, "comfortable" adds a snippet line under each row.
+	Density string `toml:"density"`
+	// ListLimit caps how many rows a label lists by default. Zero lists
+	// everything in the TUI and leaves the CLI at its --limit default.
+	ListLimit int `toml:"list_limit"`
+	// ListLimits overrides ListLimit per label, keyed by label ID or name,
+	// e.g. a large page for INBOX and a small one for a noisy list.
+	ListLimits map[string]int `toml:"list_limits"`
+	// List holds the message list's column settings.
+	List ListConfig `toml:"list"`
+}
+
+// ListConfig holds the message list's column settings.
+type ListConfig struct {
+	// FromWidth is the width of the sender column. It shrinks on narrow
+	// terminals to leave room for the subject.
+	FromWidth int `toml:"from_width"`
+	// DateFormat is "relative" (default) for ages such as "5m" or "3d",
+	// or "absolute" for the date and time of every row.
+	DateFormat string `toml:"date_format"`
+	// ShowLabels renders the user labels of each row as colored chips
+	// after the subject when there is room.
+	ShowLabels bool `toml:"show_labels"`
+	// GroupByDate splits the list into "Today", "Yesterday", "Last 7
+	// days" and "Older" sections.
+	GroupByDate bool `toml:"group_by_date"`
+}
+
+// ComposeConfig holds defaults applied to outgoing mail.
+type ComposeConfig struct {
+	// ReplyTo is an optional Reply-To address added to every composed email.
+	ReplyTo string `toml:"reply_to"`
+	// DefaultCC and DefaultBCC are comma-separated addresses added to
+	// outgoing mail whose CC or BCC is otherwise empty.
+	DefaultCC  string `toml:"default_cc"`
+	DefaultBCC string `toml:"default_bcc"`
+	// ConfirmRecipients asks for confirmation before sending to more than
+	// this many distinct To, CC and BCC addresses. Zero never asks.
+	ConfirmRecipients int `toml:"confirm_recipients"`
+}
+
+// AuthConfig holds OAuth token storage settings.
+type AuthConfig struct {
+	// TokenStore selects where OAuth tokens are kept: "keyring" (default)
+	// or "file" for systems without a usable OS keyring.
+	TokenStore string `toml:"token_store"`
+	// ConfirmScopes lists the OAuth scopes `termail account add` will
+	// request, and why, and asks before opening the browser.
+	ConfirmScopes bool `toml:"confirm_scopes"`
 }
 
 // AccountsConfig holds account selection settings.
@@ -45,14 +160,77 @@ func defaults() Config {
 		Sync: SyncConfig{
 			Interval:     "5m",
 			InitialCount: 500,
+			ConfirmPrune: true,
+
+			MaxConcurrency: 4,
+		},
+		Auth: AuthConfig{
+			TokenStore:    "keyring",
+			ConfirmScopes: true,
+		},
+		Compose: ComposeConfig{
+			ConfirmRecipients: 10,
 		},
 		UI: UIConfig{
-			DefaultView: "thread",
-			Theme:       "default",
+			DefaultView:  "thread",
+			Theme:        "default",
+			Sort:         "date",
+			ThreadEnter:  "open",
+			Density:      "compact",
+			DeleteAction: "trash",
+
+			SearchContextLines: 3,
+			List: ListConfig{
+				FromWidth:  18,
+				DateFormat: "relative",
+			},
 		},
 	}
 }
 
+// SendDelay returns the undo-send window configured for the given provider
+// (such as "gmail"). Providers without a setting send immediately.
+func (c *Config) SendDelay(provider string) (time.Duration, error) {
+	var raw string
+	switch provider {
+	case "gmail":
+		raw = c.Gmail.SendDelay
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		return 0, &Error{Err: fmt.Errorf("invalid %s send_delay %q", provider, raw)}
+	}
+	return d, nil
+}
+
+// ListLimit returns the default number of rows to list for a label,
+// preferring an override keyed by its ID, then by its name (ignoring case),
+// and falling back to ui.list_limit. Zero means no limit.
+func (c *Config) ListLimit(labelID, labelName string) int {
+	if n, ok := c.UI.ListLimits[labelID]; ok {
+		return n
+	}
+	for key, n := range c.UI.ListLimits {
+		if strings.EqualFold(key, labelID) || (labelName != "" && strings.EqualFold(key, labelName)) {
+			return n
+		}
+	}
+	return c.UI.ListLimit
+}
+
+// Error is a problem with the configuration itself: a config file that
+// cannot be read or parsed, or a setting with an invalid value.
+type Error struct {
+	Err error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
 // Load reads config from path. If path is empty, returns defaults.
 func Load(path string) (*Config, error) {
 	cfg := defaults()
@@ -64,10 +242,10 @@ func Load(path string) (*Config, error) {
 		if os.IsNotExist(err) {
 			return &cfg, nil
 		}
-		return nil, fmt.Errorf("failed to read config: %w", err)
+		return nil, &Error{Err: fmt.Errorf("failed to read config: %w", err)}
 	}
 	if err := toml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config: %w", err)
+		return nil, &Error{Err: fmt.Errorf("failed to parse config: %w", err)}
 	}
 	return &cfg, nil
 }
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad_Defaults(t *testing.T) {
@@ -21,6 +24,18 @@ func TestLoad_Defaults(t *testing.T) {
 	if cfg.UI.DefaultView != "thread" {
 		t.Errorf("default view = %q, want %q", cfg.UI.DefaultView, "thread")
 	}
+	if cfg.UI.SearchContextLines != 3 {
+		t.Errorf("default search_context_lines = %d, want 3", cfg.UI.SearchContextLines)
+	}
+	if cfg.Sync.MaxConcurrency != 4 {
+		t.Errorf("default max_concurrency = %d, want 4", cfg.Sync.MaxConcurrency)
+	}
+	if cfg.UI.Sort != "date" {
+		t.Errorf("default sort = %q, want %q", cfg.UI.Sort, "date")
+	}
+	if cfg.UI.Density != "compact" {
+		t.Errorf("default density = %q, want %q", cfg.UI.Density, "compact")
+	}
 }
 
 func TestLoad_FromFile(t *testing.T) {
@@ -33,6 +48,16 @@ initial_count = 100
 
 [ui]
 default_view = "flat"
+
+[ui.list_limits]
+INBOX = 100
+
+[ui.list]
+date_format = "absolute"
+show_labels = true
+
+[groups]
+team = "a@x.com, b@x.com"
 `
 	if err := os.WriteFile(cfgPath, []byte(content), 0644); err != nil {
 		t.Fatal(err)
@@ -47,6 +72,15 @@ default_view = "flat"
 	if cfg.UI.DefaultView != "flat" {
 		t.Errorf("view = %q, want %q", cfg.UI.DefaultView, "flat")
 	}
+	if n := cfg.ListLimit("INBOX", ""); n != 100 {
+		t.Errorf("ListLimit(INBOX) = %d, want 100", n)
+	}
+	if cfg.UI.List.FromWidth != 18 || cfg.UI.List.DateFormat != "absolute" || !cfg.UI.List.ShowLabels {
+		t.Errorf("ui.list = %+v, want default from_width with absolute dates and labels", cfg.UI.List)
+	}
+	if got := cfg.Groups["team"]; got != "a@x.com, b@x.com" {
+		t.Errorf("groups.team = %q, want the member list", got)
+	}
 }
 
 func TestLoad_NonExistentFile(t *testing.T) {
@@ -72,6 +106,10 @@ func TestLoad_InvalidTOML(t *testing.T) {
 	if !strings.Contains(err.Error(), "failed to parse config") {
 		t.Errorf("error = %q, want it to contain %q", err.Error(), "failed to parse config")
 	}
+	var cfgErr *Error
+	if !errors.As(err, &cfgErr) {
+		t.Errorf("Load() error = %T, want a *config.Error", err)
+	}
 }
 
 func TestConfigDir(t *testing.T) {
@@ -109,3 +147,97 @@ func TestDataDir(t *testing.T) {
 		}
 	})
 }
+
+func TestSendDelay(t *testing.T) {
+	cfg := defaults()
+	if d, err := cfg.SendDelay("gmail"); err != nil || d != 0 {
+		t.Errorf("default SendDelay = %v, %v; want 0, nil", d, err)
+	}
+
+	cfg.Gmail.SendDelay = "15s"
+	if d, err := cfg.SendDelay("gmail"); err != nil || d != 15*time.Second {
+		t.Errorf("SendDelay = %v, %v; want 15s, nil", d, err)
+	}
+	if d, _ := cfg.SendDelay("other"); d != 0 {
+		t.Errorf("SendDelay(other) = %v, want 0", d)
+	}
+
+	cfg.Gmail.SendDelay = "soon"
+	var cfgErr *Error
+	if _, err := cfg.SendDelay("gmail"); !errors.As(err, &cfgErr) {
+		t.Errorf("SendDelay with invalid value error = %v, want a *config.Error", err)
+	}
+}
+
+func TestListLimit(t *testing.T) {
+	cfg := defaults()
+	if n := cfg.ListLimit("INBOX", "INBOX"); n != 0 {
+		t.Errorf("default ListLimit = %d, want 0", n)
+	}
+
+	cfg.UI.ListLimit = 50
+	cfg.UI.ListLimits = map[string]int{
+		"INBOX":    200,
+		"label_7":  5,
+		"receipts": 10,
+	}
+	tests := []struct {
+		id, name string
+		want     int
+	}{
+		{"INBOX", "INBOX", 200},
+		{"inbox", "", 200},
+		{"Label_7", "Newsletters", 5},
+		{"Label_9", "Receipts", 10},
+		{"SENT", "SENT", 50},
+	}
+	for _, tt := range tests {
+		if got := cfg.ListLimit(tt.id, tt.name); got != tt.want {
+			t.Errorf("ListLimit(%q, %q) = %d, want %d", tt.id, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	cfgPath := filepath.Join(dir, "config.toml")
+	content := `
+[sync]
+interval = "5 minutes"
+
+[ui]
+default_view = "grid"
+delete_action = "label:Archive"
+colour = "blue"
+
+[ui.list_limits]
+INBOX = 100
+`
+	if err := os.WriteFile(cfgPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	problems, err := Validate(cfgPath)
+	if err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+	want := []string{
+		`unknown key "ui.colour"`,
+		`sync.interval: invalid duration "5 minutes" (e.g. "30s" or "5m")`,
+		`ui.default_view: invalid value "grid" (use thread or flat)`,
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("Validate() = %q, want %q", problems, want)
+	}
+
+	problems, err = Validate(filepath.Join(dir, "missing.toml"))
+	if err != nil || len(problems) != 0 {
+		t.Errorf("Validate(missing) = %q, %v; want no problems", problems, err)
+	}
+}
+
+func TestProblems_Defaults(t *testing.T) {
+	cfg := defaults()
+	if problems := cfg.Problems(); len(problems) != 0 {
+		t.Errorf("defaults have problems: %q", problems)
+	}
+}
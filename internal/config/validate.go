@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Validate reads the config file at path and reports its problems: keys
+// termail does not know and values it cannot use. A missing file has none.
+// The error is only set when the file cannot be read or parsed.
+func Validate(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, &Error{Err: fmt.Errorf("failed to read config: %w", err)}
+	}
+	cfg := defaults()
+	md, err := toml.Decode(string(data), &cfg)
+	if err != nil {
+		return nil, &Error{Err: fmt.Errorf("failed to parse config: %w", err)}
+	}
+
+	var problems []string
+	for _, key := range md.Undecoded() {
+		problems = append(problems, fmt.Sprintf("unknown key %q", key.String()))
+	}
+	return append(problems, cfg.Problems()...), nil
+}
+
+// Problems reports the settings in c that hold values termail cannot use.
+func (c *Config) Problems() []string {
+	var problems []string
+	oneOf := func(key, value string, allowed ...string) {
+		if !slices.Contains(allowed, value) {
+			problems = append(problems, fmt.Sprintf("%s: invalid value %q (use %s)", key, value, strings.Join(allowed, " or ")))
+		}
+	}
+	duration := func(key, value string, optional bool) {
+		if value == "" && optional {
+			return
+		}
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			problems = append(problems, fmt.Sprintf("%s: invalid duration %q (e.g. \"30s\" or \"5m\")", key, value))
+		}
+	}
+	nonNegative := func(key string, n int) {
+		if n < 0 {
+			problems = append(problems, fmt.Sprintf("%s: must not be negative, got %d", key, n))
+		}
+	}
+
+	duration("sync.interval", c.Sync.Interval, false)
+	nonNegative("sync.initial_count", c.Sync.InitialCount)
+	nonNegative("sync.max_body_bytes", c.Sync.MaxBodyBytes)
+	if c.Sync.MaxConcurrency < 1 {
+		problems = append(problems, fmt.Sprintf("sync.max_concurrency: must be at least 1, got %d", c.Sync.MaxConcurrency))
+	}
+
+	oneOf("ui.default_view", c.UI.DefaultView, "thread", "flat")
+	oneOf("ui.sort", c.UI.Sort, "date", "priority")
+	oneOf("ui.thread_enter", c.UI.ThreadEnter, "open", "expand")
+	oneOf("ui.density", c.UI.Density, "compact", "comfortable")
+	if a := c.UI.DeleteAction; !strings.HasPrefix(a, "label:") || a == "label:" {
+		oneOf("ui.delete_action", a, "trash", "archive", "label:<name>")
+	}
+	duration("ui.auto_reload", c.UI.AutoReload, true)
+	for _, p := range c.UI.FocusOrder {
+		oneOf("ui.focus_order", strings.ToLower(strings.TrimSpace(p)), "sidebar", "list", "reader")
+	}
+	nonNegative("ui.search_context_lines", c.UI.SearchContextLines)
+	nonNegative("ui.list_limit", c.UI.ListLimit)
+	for label, n := range c.UI.ListLimits {
+		nonNegative("ui.list_limits."+label, n)
+	}
+	nonNegative("ui.list.from_width", c.UI.List.FromWidth)
+	oneOf("ui.list.date_format", c.UI.List.DateFormat, "relative", "absolute")
+
+	duration("gmail.send_delay", c.Gmail.SendDelay, true)
+	nonNegative("compose.confirm_recipients", c.Compose.ConfirmRecipients)
+	oneOf("auth.token_store", c.Auth.TokenStore, "keyring", "file")
+
+	return problems
+}
@@ -8,4 +8,15 @@ type Account struct {
 	Provider    string
 	DisplayName string
 	CreatedAt   time.Time
+
+	// MessagesTotal is the mailbox size reported by the provider at the
+	// last sync, so it can be shown offline. Zero means unknown.
+	MessagesTotal int64
+}
+
+// Profile describes the authenticated mailbox as reported by the provider.
+type Profile struct {
+	Email         string
+	MessagesTotal int64
+	ThreadsTotal  int64
 }
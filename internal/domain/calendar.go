@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// CalendarEvent holds the basic fields of an iCalendar VEVENT attached to an
+// email (typically a meeting invitation).
+type CalendarEvent struct {
+	Method    string // iTIP method, e.g. REQUEST or CANCEL
+	Summary   string
+	Location  string
+	Organizer Address
+	Start     time.Time
+	End       time.Time
+	AllDay    bool
+}
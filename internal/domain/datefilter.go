@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDateFilter resolves a --since or --before value relative to now. It
+// accepts a calendar date such as "2006-01-02" (local midnight), an RFC 3339
+// timestamp, a number of days ago such as "7d", or a Go duration ago such
+// as "12h".
+func ParseDateFilter(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("date is empty")
+	}
+
+	if t, err := time.ParseInLocation(time.DateOnly, s, now.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	} else if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q: use YYYY-MM-DD, RFC 3339, days ago (7d), or a duration ago (12h)", s)
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateFilter(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-01-02", time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"2026-01-02T08:00:00Z", time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC)},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"0d", now},
+		{"12h", now.Add(-12 * time.Hour)},
+		{" 90m ", now.Add(-90 * time.Minute)},
+	}
+	for _, tt := range tests {
+		got, err := ParseDateFilter(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseDateFilter(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseDateFilter(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "yesterday", "-3d", "2026-13-01", "xd"} {
+		if _, err := ParseDateFilter(in, now); err == nil {
+			t.Errorf("ParseDateFilter(%q) should fail", in)
+		}
+	}
+}
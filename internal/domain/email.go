@@ -1,17 +1,66 @@
 package domain
 
-import "time"
+import (
+	"mime"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
 
 type Address struct {
 	Name  string
 	Email string
 }
 
+// String formats a for a message header as RFC 5322 requires: a display
+// name with specials such as "," or "." is quoted, and a non-ASCII one is
+// an RFC 2047 encoded-word. Use Display to show an address to a person.
 func (a Address) String() string {
 	if a.Name == "" {
 		return a.Email
 	}
-	return a.Name + " <" + a.Email + ">"
+	if !isASCII(a.Name) {
+		// Q encoding leaves specials such as "," as they are, which would
+		// end the phrase early; base64 has none.
+		enc := mime.QEncoding
+		if strings.ContainsAny(a.Name, nameSpecials) {
+			enc = mime.BEncoding
+		}
+		return enc.Encode("utf-8", a.Name) + " <" + a.Email + ">"
+	}
+	return quoteName(a.Name) + " <" + a.Email + ">"
+}
+
+// Display formats a as "Name <email>" for reading and editing: like String,
+// but with a non-ASCII name left as it is.
+func (a Address) Display() string {
+	if a.Name == "" {
+		return a.Email
+	}
+	return quoteName(a.Name) + " <" + a.Email + ">"
+}
+
+// nameSpecials are the RFC 5322 specials, which a display name may only
+// contain inside a quoted string.
+const nameSpecials = `()<>[]:;@\,."`
+
+// quoteName returns name as a quoted string if it contains specials, and
+// unchanged otherwise.
+func quoteName(name string) string {
+	if !strings.ContainsAny(name, nameSpecials) {
+		return name
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(name) + `"`
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
 }
 
 type Attachment struct {
@@ -19,6 +68,10 @@ type Attachment struct {
 	Filename string
 	MIMEType string
 	Size     int64
+
+	// Data is the content of an attachment being sent. It is empty for
+	// synced mail, whose attachments stay with the provider.
+	Data []byte
 }
 
 type Email struct {
@@ -28,6 +81,7 @@ type Email struct {
 	To          []Address
 	CC          []Address
 	BCC         []Address
+	ReplyTo     []Address
 	Subject     string
 	Body        string
 	BodyHTML    string
@@ -37,6 +91,152 @@ type Email struct {
 	IsStarred   bool
 	Attachments []Attachment
 	InReplyTo   string
+
+	// ReceivedAt is when the provider received the message (Gmail's
+	// internalDate). Unlike Date, which the sender sets, it is reliable
+	// for ordering. Zero when unknown.
+	ReceivedAt time.Time
+
+	// Snippet is a short preview of the body, populated by list queries
+	// that do not load Body. Search results instead hold an excerpt around
+	// the match, with matched terms between SnippetMatchStart and
+	// SnippetMatchEnd.
+	Snippet string
+
+	// MessageID is the RFC 5322 Message-ID header, distinct from the provider ID.
+	MessageID string
+	// References is the chain of Message-IDs this email replies to, oldest first.
+	References []string
+
+	// Flags are local markers such as FlagTodo; they are not synced.
+	Flags []string
+
+	// ListUnsubscribe is the raw List-Unsubscribe header, if present.
+	ListUnsubscribe string
+	// ListID and ListPost are the raw List-Id and List-Post headers of
+	// mailing-list mail; see MailingList and ReplyRecipient.
+	ListID   string
+	ListPost string
+
+	// Event is the calendar invitation carried in a text/calendar part, if any.
+	Event *CalendarEvent
+}
+
+// SnippetMatchStart and SnippetMatchEnd enclose the matched terms in a
+// search result's Snippet. Control characters are used since they do not
+// occur in readable message text.
+const (
+	SnippetMatchStart = "\x02"
+	SnippetMatchEnd   = "\x03"
+)
+
+// PlainSnippet returns the snippet with any search match markers removed.
+func (e *Email) PlainSnippet() string {
+	return strings.NewReplacer(SnippetMatchStart, "", SnippetMatchEnd, "").Replace(e.Snippet)
+}
+
+// BodyTruncatedMarker ends a body that TruncateBody cut short.
+const BodyTruncatedMarker = "[termail: message truncated]"
+
+// TruncateBody cuts the text and HTML bodies to at most max bytes each,
+// ending at a whole character and followed by BodyTruncatedMarker, and
+// reports whether either was cut. A max below one leaves them whole.
+func (e *Email) TruncateBody(max int) bool {
+	if max < 1 {
+		return false
+	}
+	cut := false
+	for _, body := range []*string{&e.Body, &e.BodyHTML} {
+		if len(*body) <= max {
+			continue
+		}
+		n := max
+		for n > 0 && !utf8.RuneStart((*body)[n]) {
+			n--
+		}
+		*body = (*body)[:n] + "\n\n" + BodyTruncatedMarker
+		cut = true
+	}
+	return cut
+}
+
+// BodyTruncated reports whether TruncateBody cut either body short.
+func (e *Email) BodyTruncated() bool {
+	return strings.HasSuffix(e.Body, BodyTruncatedMarker) || strings.HasSuffix(e.BodyHTML, BodyTruncatedMarker)
+}
+
+// ReplyReferences returns the References chain for a reply to e: its own
+// references followed by its Message-ID.
+func (e *Email) ReplyReferences() []string {
+	refs := make([]string, 0, len(e.References)+1)
+	refs = append(refs, e.References...)
+	if e.MessageID != "" {
+		refs = append(refs, e.MessageID)
+	}
+	return refs
+}
+
+// ReplyInReplyTo returns the In-Reply-To value for a reply to e, falling back
+// to the provider ID when the Message-ID header is unknown.
+func (e *Email) ReplyInReplyTo() string {
+	if e.MessageID != "" {
+		return e.MessageID
+	}
+	return e.ID
+}
+
+// Recipients returns the distinct To, CC and BCC addresses of e, compared
+// case-insensitively, in the order they first appear.
+func (e *Email) Recipients() []Address {
+	seen := make(map[string]bool)
+	var out []Address
+	for _, list := range [][]Address{e.To, e.CC, e.BCC} {
+		for _, a := range list {
+			addr := strings.ToLower(strings.TrimSpace(a.Email))
+			if addr == "" || seen[addr] {
+				continue
+			}
+			seen[addr] = true
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// ApplyDefaultRecipients fills an empty CC or BCC with the given defaults,
+// leaving out addresses e already goes to.
+func (e *Email) ApplyDefaultRecipients(cc, bcc []Address) {
+	present := make(map[string]bool)
+	for _, a := range e.Recipients() {
+		present[strings.ToLower(a.Email)] = true
+	}
+	fill := func(field *[]Address, defaults []Address) {
+		if len(*field) > 0 {
+			return
+		}
+		for _, a := range defaults {
+			key := strings.ToLower(strings.TrimSpace(a.Email))
+			if key == "" || present[key] {
+				continue
+			}
+			present[key] = true
+			*field = append(*field, a)
+		}
+	}
+	fill(&e.CC, cc)
+	fill(&e.BCC, bcc)
+}
+
+// UnknownDate is shown in place of a date that is not known, such as that
+// of a message with neither a Date header nor a provider receipt time.
+const UnknownDate = "unknown date"
+
+// FormatDate formats t with layout, or returns UnknownDate for the zero time.
+func FormatDate(t time.Time, layout string) string {
+	if t.IsZero() {
+		return UnknownDate
+	}
+	return t.Format(layout)
 }
 
 func (e *Email) HasLabel(label string) bool {
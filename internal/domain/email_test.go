@@ -1,6 +1,11 @@
 package domain
 
-import "testing"
+import (
+	"net/mail"
+	"slices"
+	"testing"
+	"time"
+)
 
 func TestAddress_String(t *testing.T) {
 	tests := []struct {
@@ -9,17 +14,48 @@ func TestAddress_String(t *testing.T) {
 		want string
 	}{
 		{"with name", Address{Name: "John", Email: "john@example.com"}, "John <john@example.com>"},
+		{"plain", Address{Name: "Alice", Email: "alice@example.com"}, "Alice <alice@example.com>"},
 		{"email only", Address{Email: "john@example.com"}, "john@example.com"},
+		{"comma", Address{Name: "Doe, John", Email: "john@example.com"}, `"Doe, John" <john@example.com>`},
+		{"dot", Address{Name: "J. Doe", Email: "j@example.com"}, `"J. Doe" <j@example.com>`},
+		{"quotes", Address{Name: `Bob "the builder"`, Email: "bob@example.com"}, `"Bob \"the builder\"" <bob@example.com>`},
+		{"non-ASCII", Address{Name: "José", Email: "jose@example.com"}, "=?utf-8?q?Jos=C3=A9?= <jose@example.com>"},
+		{"non-ASCII with comma", Address{Name: "Müller, Jörg", Email: "jm@example.com"}, ""},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.addr.String(); got != tt.want {
+			got := tt.addr.String()
+			if tt.want != "" && got != tt.want {
 				t.Errorf("Address.String() = %q, want %q", got, tt.want)
 			}
+			parsed, err := mail.ParseAddress(got)
+			if err != nil {
+				t.Fatalf("mail.ParseAddress(%q) error: %v", got, err)
+			}
+			if parsed.Name != tt.addr.Name || parsed.Address != tt.addr.Email {
+				t.Errorf("mail.ParseAddress(%q) = %q <%s>, want %q <%s>", got, parsed.Name, parsed.Address, tt.addr.Name, tt.addr.Email)
+			}
 		})
 	}
 }
 
+func TestAddress_Display(t *testing.T) {
+	tests := []struct {
+		addr Address
+		want string
+	}{
+		{Address{Name: "Alice", Email: "alice@example.com"}, "Alice <alice@example.com>"},
+		{Address{Name: "Doe, John", Email: "john@example.com"}, `"Doe, John" <john@example.com>`},
+		{Address{Name: "José", Email: "jose@example.com"}, "José <jose@example.com>"},
+		{Address{Email: "john@example.com"}, "john@example.com"},
+	}
+	for _, tt := range tests {
+		if got := tt.addr.Display(); got != tt.want {
+			t.Errorf("%+v.Display() = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
 func TestEmail_HasLabel(t *testing.T) {
 	e := &Email{Labels: []string{"INBOX", "STARRED"}}
 	if !e.HasLabel("INBOX") {
@@ -29,3 +65,90 @@ func TestEmail_HasLabel(t *testing.T) {
 		t.Error("expected HasLabel(TRASH) = false")
 	}
 }
+
+func TestEmail_Recipients(t *testing.T) {
+	e := &Email{
+		To:  []Address{{Email: "ann@example.com"}, {Name: "Bob", Email: "bob@example.com"}},
+		CC:  []Address{{Email: "BOB@example.com"}, {Email: "cat@example.com"}, {Email: ""}},
+		BCC: []Address{{Email: "ann@example.com"}, {Email: "dan@example.com"}},
+	}
+	var got []string
+	for _, a := range e.Recipients() {
+		got = append(got, a.Email)
+	}
+	want := []string{"ann@example.com", "bob@example.com", "cat@example.com", "dan@example.com"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Recipients() = %v, want %v", got, want)
+	}
+}
+
+func TestEmail_ApplyDefaultRecipients(t *testing.T) {
+	me := Address{Email: "me@example.com"}
+	shared := Address{Email: "shared@example.com"}
+	emails := func(addrs []Address) []string {
+		var out []string
+		for _, a := range addrs {
+			out = append(out, a.Email)
+		}
+		return out
+	}
+
+	e := &Email{To: []Address{{Email: "ann@example.com"}}}
+	e.ApplyDefaultRecipients([]Address{shared, shared}, []Address{me})
+	if got := emails(e.CC); !slices.Equal(got, []string{"shared@example.com"}) {
+		t.Errorf("CC = %v, want [shared@example.com]", got)
+	}
+	if got := emails(e.BCC); !slices.Equal(got, []string{"me@example.com"}) {
+		t.Errorf("BCC = %v, want [me@example.com]", got)
+	}
+
+	// A reply to the shared address does not copy it again, and a default
+	// BCC already in CC is not repeated.
+	reply := &Email{To: []Address{{Email: "SHARED@example.com"}}, CC: []Address{me}}
+	reply.ApplyDefaultRecipients([]Address{shared}, []Address{me})
+	if got := emails(reply.CC); !slices.Equal(got, []string{"me@example.com"}) {
+		t.Errorf("reply CC = %v, want the existing CC only", got)
+	}
+	if len(reply.BCC) != 0 {
+		t.Errorf("reply BCC = %v, want none", reply.BCC)
+	}
+
+	// Explicit recipients win over the defaults.
+	explicit := &Email{To: []Address{{Email: "ann@example.com"}}, CC: []Address{{Email: "bob@example.com"}}}
+	explicit.ApplyDefaultRecipients([]Address{shared}, nil)
+	if got := emails(explicit.CC); !slices.Equal(got, []string{"bob@example.com"}) {
+		t.Errorf("explicit CC = %v, want [bob@example.com]", got)
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	if got := FormatDate(time.Time{}, "Jan 2, 2006"); got != UnknownDate {
+		t.Errorf("FormatDate(zero) = %q, want %q", got, UnknownDate)
+	}
+	d := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	if got := FormatDate(d, "Jan 2, 2006"); got != "Jun 15, 2025" {
+		t.Errorf("FormatDate() = %q, want %q", got, "Jun 15, 2025")
+	}
+}
+
+func TestEmail_TruncateBody(t *testing.T) {
+	e := &Email{Body: "héllo world", BodyHTML: "<p>short</p>"}
+	if !e.TruncateBody(2) {
+		t.Fatal("TruncateBody(2) = false, want the text body cut")
+	}
+	// "é" spans bytes 1-2, so the cut backs up to a whole character.
+	if want := "h\n\n" + BodyTruncatedMarker; e.Body != want {
+		t.Errorf("Body = %q, want %q", e.Body, want)
+	}
+	if want := "<p\n\n" + BodyTruncatedMarker; e.BodyHTML != want {
+		t.Errorf("BodyHTML = %q, want %q", e.BodyHTML, want)
+	}
+	if !e.BodyTruncated() {
+		t.Error("BodyTruncated() = false after a cut")
+	}
+
+	whole := &Email{Body: "hello", BodyHTML: "<p>hello</p>"}
+	if whole.TruncateBody(0) || whole.TruncateBody(100) || whole.BodyTruncated() {
+		t.Errorf("bodies within the limit were cut: %+v", whole)
+	}
+}
@@ -0,0 +1,40 @@
+package domain
+
+// Local flags are user-defined markers kept only in termail's database; they
+// are never synced to the provider.
+const (
+	FlagFollowUp = "follow-up"
+	FlagTodo     = "todo"
+	FlagWaiting  = "waiting"
+)
+
+// Flags lists the supported local flags in display and cycling order.
+var Flags = []string{FlagFollowUp, FlagTodo, FlagWaiting}
+
+// IsValidFlag reports whether flag is one of Flags.
+func IsValidFlag(flag string) bool {
+	for _, f := range Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// NextFlag returns the flag that follows current in Flags, or "" after the
+// last one, so repeatedly applying it cycles none → follow-up → todo →
+// waiting → none. With several current flags the first one is used.
+func NextFlag(current []string) string {
+	if len(current) == 0 {
+		return Flags[0]
+	}
+	for i, f := range Flags {
+		if f == current[0] {
+			if i+1 < len(Flags) {
+				return Flags[i+1]
+			}
+			return ""
+		}
+	}
+	return Flags[0]
+}
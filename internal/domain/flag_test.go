@@ -0,0 +1,30 @@
+package domain
+
+import "testing"
+
+func TestNextFlag(t *testing.T) {
+	tests := []struct {
+		current []string
+		want    string
+	}{
+		{nil, FlagFollowUp},
+		{[]string{FlagFollowUp}, FlagTodo},
+		{[]string{FlagTodo}, FlagWaiting},
+		{[]string{FlagWaiting}, ""},
+		{[]string{"unknown"}, FlagFollowUp},
+	}
+	for _, tt := range tests {
+		if got := NextFlag(tt.current); got != tt.want {
+			t.Errorf("NextFlag(%v) = %q, want %q", tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidFlag(t *testing.T) {
+	if !IsValidFlag(FlagTodo) {
+		t.Error("IsValidFlag(todo) = false, want true")
+	}
+	if IsValidFlag("urgent") {
+		t.Error("IsValidFlag(urgent) = true, want false")
+	}
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "strings"
+
+// ExpandGroups replaces each entry that names an address group, matched
+// case-insensitively, with the group's comma-separated members, expanding
+// groups nested inside groups. A group reached again while it is being
+// expanded is skipped, so cycles terminate. Entries are trimmed, empty ones
+// dropped, and an address seen earlier in the list is not repeated.
+func ExpandGroups(entries []string, groups map[string]string) []string {
+	byName := make(map[string]string, len(groups))
+	for name, members := range groups {
+		byName[strings.ToLower(strings.TrimSpace(name))] = members
+	}
+
+	var out []string
+	seen := make(map[string]bool)
+	expanding := make(map[string]bool)
+	var expand func(entries []string)
+	expand = func(entries []string) {
+		for _, entry := range entries {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			name := strings.ToLower(entry)
+			if members, ok := byName[name]; ok && !strings.Contains(entry, "@") {
+				if !expanding[name] {
+					expanding[name] = true
+					expand(strings.Split(members, ","))
+					expanding[name] = false
+				}
+				continue
+			}
+			key := addressKey(entry)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, entry)
+		}
+	}
+	expand(entries)
+	return out
+}
+
+// addressKey returns the lower-cased address of a "Name <addr>" or bare
+// address entry, for spotting duplicates.
+func addressKey(entry string) string {
+	if open := strings.LastIndex(entry, "<"); open >= 0 {
+		if end := strings.Index(entry[open:], ">"); end > 0 {
+			entry = entry[open+1 : open+end]
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(entry))
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExpandGroups(t *testing.T) {
+	groups := map[string]string{
+		"team":    "ann@example.com, Bob <bob@example.com>",
+		"Leads":   "carol@example.com, team",
+		"all":     "leads, dan@example.com, ALL",
+		"ping":    "pong",
+		"pong":    "ping, eve@example.com",
+		"missing": "",
+	}
+	tests := []struct {
+		name    string
+		entries []string
+		want    []string
+	}{
+		{"plain addresses pass through", []string{" x@example.com ", ""}, []string{"x@example.com"}},
+		{"group", []string{"team"}, []string{"ann@example.com", "Bob <bob@example.com>"}},
+		{"case-insensitive name", []string{"TEAM"}, []string{"ann@example.com", "Bob <bob@example.com>"}},
+		{"nested", []string{"leads"}, []string{"carol@example.com", "ann@example.com", "Bob <bob@example.com>"}},
+		{"self reference", []string{"all"}, []string{"carol@example.com", "ann@example.com", "Bob <bob@example.com>", "dan@example.com"}},
+		{"cycle", []string{"ping"}, []string{"eve@example.com"}},
+		{"dedup across groups and addresses", []string{"BOB@example.com", "team", "ann@example.com"}, []string{"BOB@example.com", "ann@example.com"}},
+		{"empty group", []string{"missing", "z@example.com"}, []string{"z@example.com"}},
+		{"unknown name kept", []string{"nobody"}, []string{"nobody"}},
+	}
+	for _, tt := range tests {
+		if got := ExpandGroups(tt.entries, groups); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: ExpandGroups(%q) = %q, want %q", tt.name, tt.entries, got, tt.want)
+		}
+	}
+}
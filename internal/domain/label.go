@@ -16,10 +16,12 @@ type Label struct {
 }
 
 const (
-	LabelInbox   = "INBOX"
-	LabelStarred = "STARRED"
-	LabelSent    = "SENT"
-	LabelDraft   = "DRAFT"
-	LabelTrash   = "TRASH"
-	LabelSpam    = "SPAM"
+	LabelInbox     = "INBOX"
+	LabelStarred   = "STARRED"
+	LabelSent      = "SENT"
+	LabelDraft     = "DRAFT"
+	LabelTrash     = "TRASH"
+	LabelSpam      = "SPAM"
+	LabelUnread    = "UNREAD"
+	LabelImportant = "IMPORTANT"
 )
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+)
+
+// LabelSeparator separates the levels of a nested label name, as in
+// "Work/ProjectA".
+const LabelSeparator = "/"
+
+// LabelNode is one level of a label hierarchy.
+type LabelNode struct {
+	// Name is the last segment of the path, e.g. "ProjectA".
+	Name string
+	// Path is the full label name, e.g. "Work/ProjectA".
+	Path string
+	// Label is the label at this path, or nil for a parent that exists only
+	// implicitly because a nested label names it.
+	Label    *Label
+	Children []*LabelNode
+}
+
+// BuildLabelTree arranges labels into a hierarchy by splitting their names
+// on LabelSeparator. Missing parents are created without a Label. Siblings
+// are sorted by name, case-insensitively.
+func BuildLabelTree(labels []Label) []*LabelNode {
+	root := &LabelNode{}
+	index := make(map[string]*LabelNode)
+
+	for i := range labels {
+		parts := strings.Split(labels[i].Name, LabelSeparator)
+		parent := root
+		for depth := range parts {
+			path := strings.Join(parts[:depth+1], LabelSeparator)
+			node, ok := index[path]
+			if !ok {
+				node = &LabelNode{Name: parts[depth], Path: path}
+				index[path] = node
+				parent.Children = append(parent.Children, node)
+			}
+			parent = node
+		}
+		parent.Label = &labels[i]
+	}
+
+	sortLabelNodes(root.Children)
+	return root.Children
+}
+
+func sortLabelNodes(nodes []*LabelNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return strings.ToLower(nodes[i].Name) < strings.ToLower(nodes[j].Name)
+	})
+	for _, n := range nodes {
+		sortLabelNodes(n.Children)
+	}
+}
+
+// WalkLabelTree calls fn for each node in depth-first order with its depth,
+// starting at 0. Children are skipped when fn returns false.
+func WalkLabelTree(nodes []*LabelNode, fn func(n *LabelNode, depth int) bool) {
+	var walk func([]*LabelNode, int)
+	walk = func(nodes []*LabelNode, depth int) {
+		for _, n := range nodes {
+			if fn(n, depth) {
+				walk(n.Children, depth+1)
+			}
+		}
+	}
+	walk(nodes, 0)
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+// renderTree flattens a tree to "depth:name[*]" entries, where "*" marks a
+// parent with no label of its own.
+func renderTree(nodes []*LabelNode) string {
+	var out []string
+	WalkLabelTree(nodes, func(n *LabelNode, depth int) bool {
+		entry := strings.Repeat(" ", depth) + n.Name
+		if n.Label == nil {
+			entry += "*"
+		}
+		out = append(out, entry)
+		return true
+	})
+	return strings.Join(out, ",")
+}
+
+func TestBuildLabelTree_MultiLevel(t *testing.T) {
+	labels := []Label{
+		{ID: "L3", Name: "Work/ProjectA/Specs"},
+		{ID: "L1", Name: "Work"},
+		{ID: "L2", Name: "Work/ProjectA"},
+		{ID: "L4", Name: "Work/admin"},
+		{ID: "L5", Name: "Family"},
+	}
+
+	tree := BuildLabelTree(labels)
+
+	want := "Family,Work, admin, ProjectA,  Specs"
+	if got := renderTree(tree); got != want {
+		t.Errorf("tree = %q, want %q", got, want)
+	}
+	specs := tree[1].Children[1].Children[0]
+	if specs.Path != "Work/ProjectA/Specs" || specs.Label.ID != "L3" {
+		t.Errorf("leaf = %+v, want path Work/ProjectA/Specs with label L3", specs)
+	}
+}
+
+func TestBuildLabelTree_OrphanedParents(t *testing.T) {
+	labels := []Label{
+		{ID: "L1", Name: "Clients/Acme/Invoices"},
+		{ID: "L2", Name: "Clients/Globex"},
+	}
+
+	tree := BuildLabelTree(labels)
+
+	want := "Clients*, Acme*,  Invoices, Globex"
+	if got := renderTree(tree); got != want {
+		t.Errorf("tree = %q, want %q", got, want)
+	}
+}
+
+func TestWalkLabelTree_SkipChildren(t *testing.T) {
+	tree := BuildLabelTree([]Label{{Name: "A/B"}, {Name: "C"}})
+
+	var visited []string
+	WalkLabelTree(tree, func(n *LabelNode, depth int) bool {
+		visited = append(visited, n.Path)
+		return n.Path != "A"
+	})
+	if got := strings.Join(visited, ","); got != "A,C" {
+		t.Errorf("visited = %q, want %q", got, "A,C")
+	}
+}
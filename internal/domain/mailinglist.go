@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"net/url"
+	"strings"
+)
+
+// MailingList identifies the list a message was distributed through, as
+// advertised by its List-Id header (RFC 2919).
+type MailingList struct {
+	ID   string // e.g. "golang-nuts.googlegroups.com"
+	Name string // optional description, e.g. "Go Nuts"
+}
+
+// ParseListID parses a List-Id header value such as
+// `"Go Nuts" <golang-nuts.googlegroups.com>`. A bare value without angle
+// brackets is taken as the ID. It reports false when no ID is present.
+func ParseListID(header string) (MailingList, bool) {
+	header = strings.TrimSpace(header)
+	open := strings.LastIndex(header, "<")
+	end := strings.LastIndex(header, ">")
+	if open < 0 || end < open {
+		if header == "" || strings.ContainsAny(header, " <>") {
+			return MailingList{}, false
+		}
+		return MailingList{ID: header}, true
+	}
+
+	id := strings.TrimSpace(header[open+1 : end])
+	if id == "" {
+		return MailingList{}, false
+	}
+	name := strings.TrimSpace(header[:open])
+	name = strings.Trim(name, `"`)
+	return MailingList{ID: id, Name: strings.TrimSpace(name)}, true
+}
+
+// DisplayName returns the list's name, falling back to its ID.
+func (l MailingList) DisplayName() string {
+	if l.Name != "" {
+		return l.Name
+	}
+	return l.ID
+}
+
+// ParseListPost returns the posting address from a List-Post header value
+// (RFC 2369) such as "<mailto:list@example.com>". It reports false for
+// lists that do not accept posts ("NO") or headers without a mailto target.
+func ParseListPost(header string) (Address, bool) {
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		part = strings.TrimPrefix(part, "<")
+		part = strings.TrimSuffix(part, ">")
+		if !strings.HasPrefix(strings.ToLower(part), "mailto:") {
+			continue
+		}
+		parsed, err := url.Parse(part)
+		if err != nil {
+			continue
+		}
+		addr, err := url.PathUnescape(parsed.Opaque)
+		if err != nil || addr == "" {
+			continue
+		}
+		return Address{Email: addr}, true
+	}
+	return Address{}, false
+}
+
+// MailingList returns the list e was sent through, if any.
+func (e *Email) MailingList() (MailingList, bool) {
+	return ParseListID(e.ListID)
+}
+
+// ReplyRecipient returns the address a reply to e goes to by default: the
+// list's List-Post address for mailing-list mail, otherwise the sender.
+func (e *Email) ReplyRecipient() Address {
+	if e.ListID != "" {
+		if addr, ok := ParseListPost(e.ListPost); ok {
+			return addr
+		}
+	}
+	return e.From
+}
@@ -0,0 +1,131 @@
+package domain
+
+import "testing"
+
+func TestParseListID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   MailingList
+		wantOK bool
+	}{
+		{
+			name:   "quoted name",
+			header: `"Go Nuts" <golang-nuts.googlegroups.com>`,
+			want:   MailingList{ID: "golang-nuts.googlegroups.com", Name: "Go Nuts"},
+			wantOK: true,
+		},
+		{
+			name:   "unquoted name",
+			header: "Announcements <announce.example.com>",
+			want:   MailingList{ID: "announce.example.com", Name: "Announcements"},
+			wantOK: true,
+		},
+		{
+			name:   "id only",
+			header: "<dev.lists.example.org>",
+			want:   MailingList{ID: "dev.lists.example.org"},
+			wantOK: true,
+		},
+		{
+			name:   "bare id",
+			header: "dev.lists.example.org",
+			want:   MailingList{ID: "dev.lists.example.org"},
+			wantOK: true,
+		},
+		{
+			name:   "empty",
+			header: "",
+		},
+		{
+			name:   "empty brackets",
+			header: "Name <>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseListID(tt.header)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("ParseListID() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMailingList_DisplayName(t *testing.T) {
+	if got := (MailingList{ID: "a.example.com", Name: "A"}).DisplayName(); got != "A" {
+		t.Errorf("DisplayName() = %q, want %q", got, "A")
+	}
+	if got := (MailingList{ID: "a.example.com"}).DisplayName(); got != "a.example.com" {
+		t.Errorf("DisplayName() = %q, want %q", got, "a.example.com")
+	}
+}
+
+func TestParseListPost(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   string
+		wantOK bool
+	}{
+		{name: "mailto", header: "<mailto:golang-nuts@googlegroups.com>", want: "golang-nuts@googlegroups.com", wantOK: true},
+		{name: "mailto with query", header: "<mailto:dev@example.org?subject=post>", want: "dev@example.org", wantOK: true},
+		{name: "http then mailto", header: "<https://example.org/post>, <mailto:dev@example.org>", want: "dev@example.org", wantOK: true},
+		{name: "posting disallowed", header: "NO"},
+		{name: "empty", header: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseListPost(tt.header)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got.Email != tt.want {
+				t.Errorf("Email = %q, want %q", got.Email, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmail_ReplyRecipient(t *testing.T) {
+	from := Address{Name: "Alice", Email: "alice@example.com"}
+	tests := []struct {
+		name  string
+		email Email
+		want  string
+	}{
+		{
+			name: "list post preferred",
+			email: Email{
+				From:     from,
+				ListID:   "<golang-nuts.googlegroups.com>",
+				ListPost: "<mailto:golang-nuts@googlegroups.com>",
+			},
+			want: "golang-nuts@googlegroups.com",
+		},
+		{
+			name:  "not a list",
+			email: Email{From: from},
+			want:  "alice@example.com",
+		},
+		{
+			name: "list without posting",
+			email: Email{
+				From:     from,
+				ListID:   "<announce.example.com>",
+				ListPost: "NO",
+			},
+			want: "alice@example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.email.ReplyRecipient(); got.Email != tt.want {
+				t.Errorf("ReplyRecipient() = %q, want %q", got.Email, tt.want)
+			}
+		})
+	}
+}
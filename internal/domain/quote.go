@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QuoteMode selects how much of the original message a reply quotes.
+type QuoteMode string
+
+const (
+	QuoteFull QuoteMode = "full" // the whole body, including earlier quoted replies
+	QuoteLast QuoteMode = "last" // only the newest message, without the quoted history
+	QuoteNone QuoteMode = "none" // nothing
+)
+
+// QuotedTextMarker stands in for the quoted history dropped by QuoteLast.
+// It sits on its own line so it can be deleted in one go.
+const QuotedTextMarker = "[... quoted text ...]"
+
+// ParseQuoteMode parses a quote mode name; an empty name means QuoteFull.
+func ParseQuoteMode(s string) (QuoteMode, error) {
+	switch mode := QuoteMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return QuoteFull, nil
+	case QuoteFull, QuoteLast, QuoteNone:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid quote mode %q: use full, last or none", s)
+	}
+}
+
+// QuoteReply builds the attribution line and "> "-quoted body of e for a
+// reply. With QuoteLast the quoted history at the end of the body is left
+// out and QuotedTextMarker is put above the quote in its place.
+func QuoteReply(e *Email, mode QuoteMode) string {
+	if mode == QuoteNone {
+		return ""
+	}
+
+	body := e.Body
+	var b strings.Builder
+	if mode == QuoteLast {
+		if latest, trimmed := LatestMessage(e.Body); trimmed {
+			body = latest
+			b.WriteString(QuotedTextMarker + "\n")
+		}
+	}
+	fmt.Fprintf(&b, "On %s, %s wrote:\n", FormatDate(e.Date, "Mon, Jan 2, 2006 at 3:04 PM"), e.From.Display())
+	for _, line := range strings.Split(body, "\n") {
+		fmt.Fprintf(&b, "> %s\n", line)
+	}
+	return b.String()
+}
+
+// LatestMessage returns body without the quoted history mail clients append
+// below a reply: a trailing run of "> " lines with its "On ... wrote:"
+// attribution, or everything from an "-----Original Message-----"
+// separator. It reports false, returning body unchanged, when there is no
+// such history or nothing would be left without it.
+func LatestMessage(body string) (string, bool) {
+	lines := strings.Split(body, "\n")
+	cut := len(lines)
+
+	for i, line := range lines {
+		if strings.Contains(line, "-----Original Message-----") {
+			cut = i
+			break
+		}
+	}
+
+	if cut == len(lines) {
+		start, quoted := len(lines), false
+		for start > 0 {
+			line := strings.TrimSpace(lines[start-1])
+			if line != "" && !strings.HasPrefix(line, ">") {
+				break
+			}
+			quoted = quoted || line != ""
+			start--
+		}
+		if !quoted {
+			return body, false
+		}
+		cut = start
+
+		// Gmail wraps long attributions, so "wrote:" may end the line
+		// after the one starting "On".
+		if cut > 0 && strings.HasSuffix(strings.TrimSpace(lines[cut-1]), "wrote:") {
+			cut--
+			if !strings.HasPrefix(strings.TrimSpace(lines[cut]), "On ") &&
+				cut > 0 && strings.HasPrefix(strings.TrimSpace(lines[cut-1]), "On ") {
+				cut--
+			}
+		}
+	}
+
+	latest := strings.TrimRight(strings.Join(lines[:cut], "\n"), " \t\r\n")
+	if strings.TrimSpace(latest) == "" {
+		return body, false
+	}
+	return latest, true
+}
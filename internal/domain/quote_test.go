@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatestMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		trimmed bool
+	}{
+		{"no history", "Hi,\nsee you there.", "Hi,\nsee you there.", false},
+		{
+			"attribution and quotes",
+			"Sounds good.\n\nOn Mon, Jan 5, 2026 at 9:00 AM, Bob <bob@example.com> wrote:\n> Lunch?\n>\n> > Earlier\n",
+			"Sounds good.",
+			true,
+		},
+		{
+			"wrapped attribution",
+			"Done.\n\nOn Mon, Jan 5, 2026 at 9:00 AM Bob Smith <\nbob@example.com> wrote:\n\n> Can you?\n",
+			"Done.",
+			true,
+		},
+		{"outlook separator", "Yes.\n\n-----Original Message-----\nFrom: Bob\nQuestion?", "Yes.", true},
+		{"inline reply keeps quotes", "> Lunch?\nSure.\n> Where?\nCafe.", "> Lunch?\nSure.\n> Where?\nCafe.", false},
+		{"only quotes", "> forwarded words\n", "> forwarded words\n", false},
+	}
+	for _, tt := range tests {
+		got, trimmed := LatestMessage(tt.body)
+		if got != tt.want || trimmed != tt.trimmed {
+			t.Errorf("%s: LatestMessage() = %q, %v; want %q, %v", tt.name, got, trimmed, tt.want, tt.trimmed)
+		}
+	}
+}
+
+func TestQuoteReply(t *testing.T) {
+	e := &Email{
+		From: Address{Name: "Ann", Email: "ann@example.com"},
+		Date: time.Date(2026, 1, 5, 10, 30, 0, 0, time.UTC),
+		Body: "Sounds good.\n\nOn Mon, Jan 5, 2026 at 9:00 AM, Bob <bob@example.com> wrote:\n> Lunch?",
+	}
+	attribution := "On Mon, Jan 5, 2026 at 10:30 AM, Ann <ann@example.com> wrote:\n"
+
+	tests := []struct {
+		mode QuoteMode
+		want string
+	}{
+		{QuoteNone, ""},
+		{QuoteLast, QuotedTextMarker + "\n" + attribution + "> Sounds good.\n"},
+		{QuoteFull, attribution + "> Sounds good.\n> \n> On Mon, Jan 5, 2026 at 9:00 AM, Bob <bob@example.com> wrote:\n> > Lunch?\n"},
+	}
+	for _, tt := range tests {
+		if got := QuoteReply(e, tt.mode); got != tt.want {
+			t.Errorf("QuoteReply(%s) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+
+	// Without history to drop there is nothing for the marker to stand for.
+	single := &Email{From: e.From, Date: e.Date, Body: "Lunch?"}
+	if got, want := QuoteReply(single, QuoteLast), attribution+"> Lunch?\n"; got != want {
+		t.Errorf("QuoteReply(last) without history = %q, want %q", got, want)
+	}
+}
+
+func TestParseQuoteMode(t *testing.T) {
+	for in, want := range map[string]QuoteMode{"": QuoteFull, "full": QuoteFull, "LAST": QuoteLast, "none": QuoteNone} {
+		if got, err := ParseQuoteMode(in); err != nil || got != want {
+			t.Errorf("ParseQuoteMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseQuoteMode("some"); err == nil {
+		t.Error("ParseQuoteMode(\"some\") should fail")
+	}
+}
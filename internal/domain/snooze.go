@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseSnoozeUntil resolves a snooze target relative to now. It accepts an
+// RFC 3339 timestamp, a Go duration such as "90m" or "2h30m", or a whole
+// number of days such as "3d". The result must be in the future.
+func ParseSnoozeUntil(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("snooze time is empty")
+	}
+
+	var until time.Time
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		until = t
+	} else if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid snooze time %q: use a duration (2h), days (3d), or RFC 3339", s)
+		}
+		until = now.AddDate(0, 0, n)
+	} else {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid snooze time %q: use a duration (2h), days (3d), or RFC 3339", s)
+		}
+		until = now.Add(d)
+	}
+
+	if !until.After(now) {
+		return time.Time{}, fmt.Errorf("snooze time %q is not in the future", s)
+	}
+	return until, nil
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSnoozeUntil(t *testing.T) {
+	now := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2h", now.Add(2 * time.Hour)},
+		{"90m", now.Add(90 * time.Minute)},
+		{"3d", now.AddDate(0, 0, 3)},
+		{"2025-06-20T09:00:00Z", time.Date(2025, 6, 20, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseSnoozeUntil(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseSnoozeUntil(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseSnoozeUntil(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "soon", "xd", "-1h", "2025-06-01T00:00:00Z"} {
+		if _, err := ParseSnoozeUntil(bad, now); err == nil {
+			t.Errorf("ParseSnoozeUntil(%q) should fail", bad)
+		}
+	}
+}
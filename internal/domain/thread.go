@@ -14,6 +14,9 @@ type Thread struct {
 	FromAddress Address
 	TotalCount  int
 	HasUnread   bool
+	HasStarred  bool
+	// Flags is the union of local flags on the thread's messages.
+	Flags []string
 }
 
 func (t *Thread) MessageCount() int {
@@ -34,3 +37,36 @@ func (t *Thread) IsUnread() bool {
 	}
 	return false
 }
+
+// IsStarred reports whether any message in the thread is starred, matching
+// how Gmail displays a thread as starred.
+func (t *Thread) IsStarred() bool {
+	if len(t.Messages) == 0 {
+		return t.HasStarred
+	}
+	for i := range t.Messages {
+		if t.Messages[i].IsStarred {
+			return true
+		}
+	}
+	return false
+}
+
+// StarTargets returns the message IDs to change when starring or unstarring
+// the thread. Starring marks only the latest message, as Gmail does; unstarring
+// clears every starred message so the thread no longer shows as starred.
+func (t *Thread) StarTargets(star bool) []string {
+	if len(t.Messages) == 0 {
+		return nil
+	}
+	if star {
+		return []string{t.Messages[len(t.Messages)-1].ID}
+	}
+	var ids []string
+	for i := range t.Messages {
+		if t.Messages[i].IsStarred {
+			ids = append(ids, t.Messages[i].ID)
+		}
+	}
+	return ids
+}
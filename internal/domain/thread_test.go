@@ -45,3 +45,31 @@ func TestThread_IsUnread_Summary(t *testing.T) {
 		t.Error("expected IsUnread() = false from HasUnread summary field")
 	}
 }
+
+func TestThread_IsStarred(t *testing.T) {
+	thread := &Thread{Messages: []Email{{ID: "1", IsStarred: true}, {ID: "2"}}}
+	if !thread.IsStarred() {
+		t.Error("expected IsStarred() = true when any message is starred")
+	}
+
+	summary := &Thread{HasStarred: true}
+	if !summary.IsStarred() {
+		t.Error("expected IsStarred() = true from HasStarred summary field")
+	}
+}
+
+func TestThread_StarTargets(t *testing.T) {
+	thread := &Thread{Messages: []Email{
+		{ID: "1", IsStarred: true},
+		{ID: "2"},
+		{ID: "3", IsStarred: true},
+		{ID: "4"},
+	}}
+
+	if got := thread.StarTargets(true); len(got) != 1 || got[0] != "4" {
+		t.Errorf("StarTargets(true) = %v, want [4]", got)
+	}
+	if got := thread.StarTargets(false); len(got) != 2 || got[0] != "1" || got[1] != "3" {
+		t.Errorf("StarTargets(false) = %v, want [1 3]", got)
+	}
+}
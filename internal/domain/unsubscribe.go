@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Unsubscribe holds the targets advertised by a List-Unsubscribe header
+// (RFC 2369). Either field may be empty.
+type Unsubscribe struct {
+	Mailto string // full mailto: URI
+	URL    string // http(s) URL to open in a browser
+}
+
+// IsEmpty reports whether no usable unsubscribe target was found.
+func (u Unsubscribe) IsEmpty() bool {
+	return u.Mailto == "" && u.URL == ""
+}
+
+// ParseListUnsubscribe extracts the first mailto: and http(s): targets from a
+// List-Unsubscribe header value such as
+// "<mailto:leave@example.com?subject=unsubscribe>, <https://example.com/u>".
+func ParseListUnsubscribe(header string) Unsubscribe {
+	var u Unsubscribe
+	for _, target := range unsubscribeTargets(header) {
+		lower := strings.ToLower(target)
+		switch {
+		case strings.HasPrefix(lower, "mailto:") && u.Mailto == "":
+			u.Mailto = target
+		case (strings.HasPrefix(lower, "https:") || strings.HasPrefix(lower, "http:")) && u.URL == "":
+			u.URL = target
+		}
+	}
+	return u
+}
+
+// unsubscribeTargets returns the URIs enclosed in angle brackets, which may
+// themselves contain commas (RFC 2369). Whitespace inside the brackets is
+// folding and is dropped. A header with no brackets is taken as one bare URI.
+func unsubscribeTargets(header string) []string {
+	var targets []string
+	rest := header
+	for {
+		start := strings.IndexByte(rest, '<')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '>')
+		if end < 0 {
+			break
+		}
+		target := strings.Join(strings.Fields(rest[start+1:start+end]), "")
+		if target != "" {
+			targets = append(targets, target)
+		}
+		rest = rest[start+end+1:]
+	}
+	if targets == nil && !strings.Contains(header, "<") {
+		if bare := strings.TrimSpace(header); bare != "" {
+			targets = append(targets, bare)
+		}
+	}
+	return targets
+}
+
+// MailtoEmail builds the unsubscribe request described by the mailto target.
+// The subject defaults to "unsubscribe" when the URI does not specify one.
+func (u Unsubscribe) MailtoEmail() (*Email, error) {
+	if u.Mailto == "" {
+		return nil, fmt.Errorf("no mailto unsubscribe target")
+	}
+	parsed, err := url.Parse(u.Mailto)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mailto URI %q: %w", u.Mailto, err)
+	}
+	to, err := url.PathUnescape(parsed.Opaque)
+	if err != nil || to == "" {
+		return nil, fmt.Errorf("invalid mailto URI %q: missing address", u.Mailto)
+	}
+
+	query := parsed.Query()
+	subject := query.Get("subject")
+	if subject == "" {
+		subject = "unsubscribe"
+	}
+
+	return &Email{
+		To:      []Address{{Email: to}},
+		Subject: subject,
+		Body:    query.Get("body"),
+	}, nil
+}
@@ -0,0 +1,85 @@
+package domain
+
+import "testing"
+
+func TestParseListUnsubscribe(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		wantMailto string
+		wantURL    string
+	}{
+		{
+			name:       "mailto and https",
+			header:     "<mailto:leave@example.com?subject=unsubscribe>, <https://example.com/u/123>",
+			wantMailto: "mailto:leave@example.com?subject=unsubscribe",
+			wantURL:    "https://example.com/u/123",
+		},
+		{
+			name:    "https only",
+			header:  "<https://example.com/u/123>",
+			wantURL: "https://example.com/u/123",
+		},
+		{
+			name:       "mailto only, no brackets",
+			header:     "mailto:leave@example.com",
+			wantMailto: "mailto:leave@example.com",
+		},
+		{
+			name:       "comma inside URL",
+			header:     "<https://x.example/u?ids=1,2>, <mailto:u@x.example>",
+			wantMailto: "mailto:u@x.example",
+			wantURL:    "https://x.example/u?ids=1,2",
+		},
+		{
+			name:    "folded URL",
+			header:  "<https://example.com/u/\r\n 123>",
+			wantURL: "https://example.com/u/123",
+		},
+		{
+			name:   "empty",
+			header: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseListUnsubscribe(tt.header)
+			if got.Mailto != tt.wantMailto {
+				t.Errorf("Mailto = %q, want %q", got.Mailto, tt.wantMailto)
+			}
+			if got.URL != tt.wantURL {
+				t.Errorf("URL = %q, want %q", got.URL, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestUnsubscribe_MailtoEmail(t *testing.T) {
+	u := Unsubscribe{Mailto: "mailto:leave@example.com?subject=remove%20me&body=please"}
+	email, err := u.MailtoEmail()
+	if err != nil {
+		t.Fatalf("MailtoEmail() error: %v", err)
+	}
+	if len(email.To) != 1 || email.To[0].Email != "leave@example.com" {
+		t.Errorf("To = %v, want [leave@example.com]", email.To)
+	}
+	if email.Subject != "remove me" {
+		t.Errorf("Subject = %q, want %q", email.Subject, "remove me")
+	}
+	if email.Body != "please" {
+		t.Errorf("Body = %q, want %q", email.Body, "please")
+	}
+
+	u = Unsubscribe{Mailto: "mailto:leave@example.com"}
+	email, err = u.MailtoEmail()
+	if err != nil {
+		t.Fatalf("MailtoEmail() error: %v", err)
+	}
+	if email.Subject != "unsubscribe" {
+		t.Errorf("default Subject = %q, want %q", email.Subject, "unsubscribe")
+	}
+
+	if _, err := (Unsubscribe{URL: "https://example.com"}).MailtoEmail(); err == nil {
+		t.Error("expected error when no mailto target")
+	}
+}
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/rfc822"
+)
+
+// WriteMaildir writes emails into a maildir at dir, creating its cur, new
+// and tmp subdirectories if needed. Every message is delivered to cur with
+// an info suffix carrying its state (see MaildirFlags), so mail clients such
+// as mutt and notmuch see read and starred mail as such. Messages are
+// serialized with opts. It returns the number of messages written.
+func WriteMaildir(dir string, emails []domain.Email, opts rfc822.Options) (int, error) {
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return 0, fmt.Errorf("failed to create maildir: %w", err)
+		}
+	}
+
+	for i := range emails {
+		e := &emails[i]
+		base := maildirUniqueName(e)
+		// Deliver through tmp and rename, as the maildir spec requires, so
+		// readers never see a partially written message.
+		tmp := filepath.Join(dir, "tmp", base)
+		if err := os.WriteFile(tmp, []byte(rfc822.BuildWith(e, opts)), 0o600); err != nil {
+			return i, fmt.Errorf("failed to write %s: %w", tmp, err)
+		}
+		dst := filepath.Join(dir, "cur", base+":2,"+MaildirFlags(e))
+		if err := os.Rename(tmp, dst); err != nil {
+			return i, fmt.Errorf("failed to deliver %s: %w", dst, err)
+		}
+	}
+	return len(emails), nil
+}
+
+// MaildirFlags returns the maildir info flags for an email in the required
+// ASCII order: D (draft), F (flagged, i.e. starred), S (seen, i.e. read) and
+// T (trashed).
+func MaildirFlags(e *domain.Email) string {
+	var flags []byte
+	if e.HasLabel(domain.LabelDraft) {
+		flags = append(flags, 'D')
+	}
+	if e.IsStarred {
+		flags = append(flags, 'F')
+	}
+	if e.IsRead {
+		flags = append(flags, 'S')
+	}
+	if e.HasLabel(domain.LabelTrash) {
+		flags = append(flags, 'T')
+	}
+	return string(flags)
+}
+
+// maildirUniqueName builds a maildir base file name from the message date
+// and ID. The ID is unique per account, and ":" is replaced because it
+// separates the info suffix.
+func maildirUniqueName(e *domain.Email) string {
+	id := strings.ReplaceAll(safeFilename(e.ID), ":", "_")
+	return fmt.Sprintf("%d.%s.termail", e.Date.Unix(), id)
+}
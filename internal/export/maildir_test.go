@@ -0,0 +1,80 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/rfc822"
+)
+
+func TestMaildirFlags(t *testing.T) {
+	tests := []struct {
+		name  string
+		email domain.Email
+		want  string
+	}{
+		{"unread", domain.Email{}, ""},
+		{"read", domain.Email{IsRead: true}, "S"},
+		{"read and starred", domain.Email{IsRead: true, IsStarred: true}, "FS"},
+		{"trashed draft", domain.Email{Labels: []string{domain.LabelTrash, domain.LabelDraft}}, "DT"},
+	}
+	for _, tt := range tests {
+		if got := MaildirFlags(&tt.email); got != tt.want {
+			t.Errorf("%s: MaildirFlags() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWriteMaildir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "Mail")
+	emails := []domain.Email{
+		{
+			ID:        "msg:1",
+			From:      domain.Address{Email: "alice@example.com"},
+			Subject:   "Read and starred",
+			Body:      "Hello",
+			Date:      time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC),
+			IsRead:    true,
+			IsStarred: true,
+		},
+		{
+			ID:      "msg-2",
+			From:    domain.Address{Email: "bob@example.com"},
+			Subject: "Unread",
+			Date:    time.Date(2025, 3, 11, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	n, err := WriteMaildir(dir, emails, rfc822.Options{})
+	if err != nil {
+		t.Fatalf("WriteMaildir() error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("WriteMaildir() = %d, want 2", n)
+	}
+
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		if fi, err := os.Stat(filepath.Join(dir, sub)); err != nil || !fi.IsDir() {
+			t.Errorf("missing maildir subdirectory %s", sub)
+		}
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "tmp")); len(entries) != 0 {
+		t.Errorf("tmp has %d leftover files, want 0", len(entries))
+	}
+
+	starred := filepath.Join(dir, "cur", "1741608000.msg_1.termail:2,FS")
+	data, err := os.ReadFile(starred)
+	if err != nil {
+		t.Fatalf("read+starred message not found: %v", err)
+	}
+	if !strings.Contains(string(data), "Subject: Read and starred") {
+		t.Errorf("message content missing subject:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cur", "1741694400.msg-2.termail:2,")); err != nil {
+		t.Errorf("unread message not found with empty flags: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+// Package export writes locally stored emails to standard mailbox formats.
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/rfc822"
+)
+
+// mboxDateLayout is the asctime-style date used on mbox "From " separator lines.
+const mboxDateLayout = "Mon Jan _2 15:04:05 2006"
+
+// WriteMbox writes emails to w as an mboxrd mailbox. Each message is preceded
+// by a "From " separator line, and body lines that would be mistaken for a
+// separator are escaped with ">". Messages are serialized with opts, except
+// that mbox files always use LF line endings.
+func WriteMbox(w io.Writer, emails []domain.Email, opts rfc822.Options) error {
+	opts.LineEnding = rfc822.LF
+	bw := bufio.NewWriter(w)
+	for i := range emails {
+		if err := writeMboxMessage(bw, &emails[i], opts); err != nil {
+			return fmt.Errorf("failed to write message %s: %w", emails[i].ID, err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush mbox: %w", err)
+	}
+	return nil
+}
+
+func writeMboxMessage(w *bufio.Writer, email *domain.Email, opts rfc822.Options) error {
+	sender := email.From.Email
+	if sender == "" {
+		sender = "MAILER-DAEMON"
+	}
+	if _, err := fmt.Fprintf(w, "From %s %s\n", sender, email.Date.UTC().Format(mboxDateLayout)); err != nil {
+		return err
+	}
+
+	raw := rfc822.BuildWith(email, opts)
+	for _, line := range strings.Split(strings.TrimSuffix(raw, "\n"), "\n") {
+		if _, err := w.WriteString(escapeFromLine(line)); err != nil {
+			return err
+		}
+		if err := w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+
+	// Messages are separated by a blank line.
+	return w.WriteByte('\n')
+}
+
+// escapeFromLine quotes lines matching ^>*From  by prepending ">" (mboxrd).
+func escapeFromLine(line string) string {
+	if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+		return ">" + line
+	}
+	return line
+}
+
+// WriteEML writes each email as an individual <id>.eml file in dir, creating
+// the directory if needed, serialized with opts. It returns the number of
+// files written.
+func WriteEML(dir string, emails []domain.Email, opts rfc822.Options) (int, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return 0, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	for i := range emails {
+		name := filepath.Join(dir, safeFilename(emails[i].ID)+".eml")
+		if err := os.WriteFile(name, []byte(rfc822.BuildWith(&emails[i], opts)), 0o600); err != nil {
+			return i, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return len(emails), nil
+}
+
+// safeFilename strips path separators from a message ID so it can be used as a file name.
+func safeFilename(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, id)
+}
@@ -0,0 +1,171 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/rfc822"
+)
+
+func TestEscapeFromLine(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"From the desk of Alice", ">From the desk of Alice"},
+		{">From quoted", ">>From quoted"},
+		{">>From twice", ">>>From twice"},
+		{"From:header-like", "From:header-like"},
+		{"Nothing to escape", "Nothing to escape"},
+		{" From indented", " From indented"},
+	}
+	for _, tt := range tests {
+		if got := escapeFromLine(tt.input); got != tt.want {
+			t.Errorf("escapeFromLine(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestWriteMbox(t *testing.T) {
+	emails := []domain.Email{
+		{
+			ID:      "m1",
+			From:    domain.Address{Name: "Alice", Email: "alice@example.com"},
+			To:      []domain.Address{{Email: "bob@example.com"}},
+			Subject: "First",
+			Body:    "Hello\nFrom here on, things change.\n>From already quoted",
+			Date:    time.Date(2024, 1, 5, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			ID:      "m2",
+			From:    domain.Address{Email: "carol@example.com"},
+			Subject: "Second",
+			Body:    "Bye",
+			Date:    time.Date(2024, 1, 6, 10, 0, 0, 0, time.UTC),
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMbox(&buf, emails, rfc822.Options{}); err != nil {
+		t.Fatalf("WriteMbox() error: %v", err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "From alice@example.com Fri Jan  5 09:30:00 2024\n") {
+		t.Errorf("missing separator line for first message:\n%s", out)
+	}
+	if !strings.Contains(out, "\nFrom carol@example.com Sat Jan  6 10:00:00 2024\n") {
+		t.Errorf("missing separator line for second message:\n%s", out)
+	}
+	if !strings.Contains(out, "\n>From here on, things change.\n") {
+		t.Errorf("body line starting with 'From ' was not escaped:\n%s", out)
+	}
+	if !strings.Contains(out, "\n>>From already quoted\n") {
+		t.Errorf("quoted 'From ' line was not escaped:\n%s", out)
+	}
+	if strings.Contains(out, "\r\n") {
+		t.Error("mbox output should use LF line endings")
+	}
+
+	// Exactly two unescaped separators.
+	separators := 0
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "From ") {
+			separators++
+		}
+	}
+	if separators != 2 {
+		t.Errorf("found %d separator lines, want 2", separators)
+	}
+}
+
+func TestWriteEML(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	emails := []domain.Email{
+		{ID: "m1", Subject: "One", Body: "body one", From: domain.Address{Email: "a@example.com"}},
+		{ID: "m2", Subject: "Two", Body: "body two", From: domain.Address{Email: "b@example.com"}},
+	}
+
+	n, err := WriteEML(dir, emails, rfc822.Options{})
+	if err != nil {
+		t.Fatalf("WriteEML() error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("WriteEML() wrote %d files, want 2", n)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "m2.eml"))
+	if err != nil {
+		t.Fatalf("reading m2.eml: %v", err)
+	}
+	if !strings.Contains(string(data), "Subject: Two\r\n") {
+		t.Errorf("m2.eml missing subject header:\n%s", data)
+	}
+}
+
+func TestWriteEML_NonASCIIRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	body := "Grüße aus München!\nBis bald."
+	emails := []domain.Email{
+		{ID: "latin", Subject: "Grüße", Body: body, From: domain.Address{Name: "Jürgen", Email: "j@example.com"}},
+		{ID: "cjk", Subject: "こんにちは", Body: "こんにちは、世界。\nお元気ですか？", From: domain.Address{Email: "k@example.com"}},
+	}
+	if _, err := WriteEML(dir, emails, rfc822.Options{}); err != nil {
+		t.Fatalf("WriteEML() error: %v", err)
+	}
+
+	for _, e := range emails {
+		data, err := os.ReadFile(filepath.Join(dir, e.ID+".eml"))
+		if err != nil {
+			t.Fatalf("reading %s.eml: %v", e.ID, err)
+		}
+		for i, c := range data {
+			if c >= 0x80 {
+				t.Fatalf("%s.eml has a non-ASCII byte at %d:\n%s", e.ID, i, data)
+			}
+			if c == '\n' && (i == 0 || data[i-1] != '\r') {
+				t.Fatalf("%s.eml has a bare LF at %d", e.ID, i)
+			}
+		}
+
+		msg, err := mail.ReadMessage(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s.eml is not a valid message: %v", e.ID, err)
+		}
+		var dec mime.WordDecoder
+		if subject, err := dec.DecodeHeader(msg.Header.Get("Subject")); err != nil || subject != e.Subject {
+			t.Errorf("%s.eml Subject = %q (%v), want %q", e.ID, subject, err, e.Subject)
+		}
+		from, err := msg.Header.AddressList("From")
+		if err != nil || len(from) != 1 || from[0].Name != e.From.Name {
+			t.Errorf("%s.eml From = %v (%v), want name %q", e.ID, from, err, e.From.Name)
+		}
+
+		var r io.Reader
+		switch cte := msg.Header.Get("Content-Transfer-Encoding"); cte {
+		case "quoted-printable":
+			r = quotedprintable.NewReader(msg.Body)
+		case "base64":
+			r = base64.NewDecoder(base64.StdEncoding, msg.Body)
+		default:
+			t.Fatalf("%s.eml Content-Transfer-Encoding = %q, want quoted-printable or base64", e.ID, cte)
+		}
+		decoded, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("decoding %s.eml body: %v", e.ID, err)
+		}
+		if got := strings.ReplaceAll(string(decoded), "\r\n", "\n"); got != e.Body {
+			t.Errorf("%s.eml body = %q, want %q", e.ID, got, e.Body)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Package htmltext converts HTML email bodies into plain text for the
+// terminal reader, blocking remote images unless they are allowed.
+package htmltext
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// BlockedImage replaces a remote image that was not loaded.
+const BlockedImage = "[image blocked]"
+
+// Options controls how HTML is converted.
+type Options struct {
+	// RemoteImages shows remote images as "[image: alt <url>]" instead of
+	// the BlockedImage placeholder. Tracking pixels are dropped either way.
+	RemoteImages bool
+}
+
+// Result is the converted text along with what was held back.
+type Result struct {
+	Text string
+	// Blocked is the number of remote images replaced by BlockedImage.
+	Blocked int
+}
+
+// Convert renders src as plain text: block elements start new lines, links
+// keep their target, and script and style content is dropped.
+func Convert(src string, opts Options) Result {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		return Result{Text: src}
+	}
+	c := &converter{opts: opts}
+	c.walk(doc)
+	return Result{Text: c.text(), Blocked: c.blocked}
+}
+
+type converter struct {
+	opts    Options
+	b       strings.Builder
+	blocked int
+	// space records pending whitespace to emit before the next word.
+	space bool
+}
+
+func (c *converter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.writeText(n.Data)
+		return
+	case html.ElementNode:
+		switch n.DataAtom {
+		case atom.Script, atom.Style, atom.Head, atom.Title:
+			return
+		case atom.Br:
+			c.newline()
+			return
+		case atom.Img:
+			c.image(n)
+			return
+		case atom.Li:
+			c.newline()
+			c.writeWord("- ")
+			c.space = false
+		}
+	}
+
+	block := n.Type == html.ElementNode && isBlock(n.DataAtom)
+	if block {
+		c.newline()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.walk(child)
+	}
+	if n.Type == html.ElementNode && n.DataAtom == atom.A {
+		if href := attr(n, "href"); strings.HasPrefix(href, "http") {
+			c.space = true
+			c.writeWord("<" + href + ">")
+		}
+	}
+	if block {
+		c.newline()
+	}
+}
+
+// image writes the placeholder for an <img>, dropping tracking pixels.
+func (c *converter) image(n *html.Node) {
+	if IsTrackingPixel(n) {
+		return
+	}
+	src := attr(n, "src")
+	alt := strings.TrimSpace(attr(n, "alt"))
+	remote := strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") || strings.HasPrefix(src, "//")
+	switch {
+	case remote && !c.opts.RemoteImages:
+		c.blocked++
+		c.writeWord(BlockedImage)
+	case remote:
+		if alt != "" {
+			c.writeWord("[image: " + alt + " <" + src + ">]")
+		} else {
+			c.writeWord("[image: <" + src + ">]")
+		}
+	case alt != "":
+		c.writeWord("[image: " + alt + "]")
+	default:
+		c.writeWord("[image]")
+	}
+}
+
+// IsTrackingPixel reports whether an <img> is sized at most 1x1, the shape
+// of read-receipt beacons, via its attributes or inline style.
+func IsTrackingPixel(n *html.Node) bool {
+	w, h := attr(n, "width"), attr(n, "height")
+	style := strings.ReplaceAll(strings.ToLower(attr(n, "style")), " ", "")
+	for _, decl := range strings.Split(style, ";") {
+		prop, val, ok := strings.Cut(decl, ":")
+		if !ok {
+			continue
+		}
+		switch prop {
+		case "width":
+			w = val
+		case "height":
+			h = val
+		case "display":
+			if val == "none" {
+				return true
+			}
+		}
+	}
+	return tiny(w) && tiny(h)
+}
+
+// tiny reports whether a CSS or attribute length is at most one pixel.
+func tiny(v string) bool {
+	v = strings.TrimSuffix(strings.TrimSpace(v), "px")
+	n, err := strconv.ParseFloat(v, 64)
+	return err == nil && n <= 1
+}
+
+func (c *converter) writeText(s string) {
+	words := strings.Fields(s)
+	if len(words) == 0 || isSpace(s[0]) {
+		c.space = c.space || s != ""
+	}
+	for i, word := range words {
+		if i > 0 {
+			c.space = true
+		}
+		c.writeWord(word)
+	}
+	if len(words) > 0 && isSpace(s[len(s)-1]) {
+		c.space = true
+	}
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+func (c *converter) writeWord(w string) {
+	if c.space && c.b.Len() > 0 && !strings.HasSuffix(c.b.String(), "\n") {
+		c.b.WriteByte(' ')
+	}
+	c.space = false
+	c.b.WriteString(w)
+}
+
+func (c *converter) newline() {
+	c.space = false
+	if c.b.Len() > 0 && !strings.HasSuffix(c.b.String(), "\n") {
+		c.b.WriteByte('\n')
+	}
+}
+
+// text returns the output with trailing spaces trimmed from every line.
+func (c *converter) text() string {
+	lines := strings.Split(c.b.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func isBlock(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Table, atom.Tr, atom.Ul, atom.Ol, atom.Blockquote,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Hr, atom.Pre,
+		atom.Section, atom.Article, atom.Header, atom.Footer:
+		return true
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
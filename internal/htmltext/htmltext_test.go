@@ -0,0 +1,58 @@
+package htmltext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConvert_Text(t *testing.T) {
+	src := `<html><head><style>p { color: red }</style></head><body>
+<h1>Weekly   update</h1>
+<p>Hello <b>team</b>,<br>see the <a href="https://example.com/notes">notes</a>.</p>
+<ul><li>one</li><li>two</li></ul>
+<script>alert(1)</script>
+</body></html>`
+
+	got := Convert(src, Options{}).Text
+	want := "Weekly update\nHello team,\nsee the notes <https://example.com/notes>.\n- one\n- two"
+	if got != want {
+		t.Errorf("Convert() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestConvert_RemoteImages(t *testing.T) {
+	src := `<p>Logo: <img src="https://cdn.example.com/logo.png" alt="ACME"> <img src="cid:inline1" alt="chart"></p>`
+
+	blocked := Convert(src, Options{})
+	if blocked.Blocked != 1 {
+		t.Errorf("Blocked = %d, want 1", blocked.Blocked)
+	}
+	if !strings.Contains(blocked.Text, BlockedImage) || strings.Contains(blocked.Text, "cdn.example.com") {
+		t.Errorf("blocked text = %q, want placeholder without the URL", blocked.Text)
+	}
+	if !strings.Contains(blocked.Text, "[image: chart]") {
+		t.Errorf("inline images are not remote and should show: %q", blocked.Text)
+	}
+
+	loaded := Convert(src, Options{RemoteImages: true})
+	if loaded.Blocked != 0 || !strings.Contains(loaded.Text, "[image: ACME <https://cdn.example.com/logo.png>]") {
+		t.Errorf("loaded = %+v, want the remote image shown", loaded)
+	}
+}
+
+func TestConvert_TrackingPixelAlwaysDropped(t *testing.T) {
+	pixels := []string{
+		`<img src="https://t.example.com/open?id=42" width="1" height="1">`,
+		`<img src="https://t.example.com/open?id=42" style="width:1px; height:1px">`,
+		`<img src="https://t.example.com/open?id=42" width="0" height="0" alt="">`,
+		`<img src="https://t.example.com/open?id=42" style="display: none">`,
+	}
+	for _, pixel := range pixels {
+		for _, remote := range []bool{false, true} {
+			res := Convert("<p>Hi"+pixel+"</p>", Options{RemoteImages: remote})
+			if res.Text != "Hi" || res.Blocked != 0 {
+				t.Errorf("Convert(%s, remote=%v) = %+v, want the pixel dropped", pixel, remote, res)
+			}
+		}
+	}
+}
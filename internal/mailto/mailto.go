@@ -0,0 +1,85 @@
+// Package mailto parses RFC 6068 mailto: URLs into draft emails.
+package mailto
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+const scheme = "mailto:"
+
+// Parse converts a mailto URL such as
+// "mailto:a@b.com?cc=c@d.com&subject=Hi&body=Yo" into a draft email with
+// To, CC, BCC, Subject, and Body set. Unknown header fields are ignored.
+// As RFC 6068 requires, "+" is kept literally rather than decoded as a space.
+func Parse(raw string) (*domain.Email, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) < len(scheme) || !strings.EqualFold(raw[:len(scheme)], scheme) {
+		return nil, fmt.Errorf("not a mailto URL: %q", raw)
+	}
+	rest := raw[len(scheme):]
+
+	path, query, _ := strings.Cut(rest, "?")
+
+	email := &domain.Email{}
+	to, err := unescape(path)
+	if err != nil {
+		return nil, err
+	}
+	email.To = addresses(to)
+
+	if query == "" {
+		return email, nil
+	}
+	for _, field := range strings.Split(query, "&") {
+		if field == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(field, "=")
+		name, err := unescape(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err = unescape(value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch strings.ToLower(name) {
+		case "to":
+			email.To = append(email.To, addresses(value)...)
+		case "cc":
+			email.CC = append(email.CC, addresses(value)...)
+		case "bcc":
+			email.BCC = append(email.BCC, addresses(value)...)
+		case "subject":
+			email.Subject = value
+		case "body":
+			email.Body = strings.ReplaceAll(value, "\r\n", "\n")
+		}
+	}
+	return email, nil
+}
+
+// unescape percent-decodes s without treating "+" as a space.
+func unescape(s string) (string, error) {
+	out, err := url.PathUnescape(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode mailto field %q: %w", s, err)
+	}
+	return out, nil
+}
+
+// addresses splits a comma-separated address list.
+func addresses(s string) []domain.Address {
+	var addrs []domain.Address
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			addrs = append(addrs, domain.Address{Email: part})
+		}
+	}
+	return addrs
+}
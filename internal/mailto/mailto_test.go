@@ -0,0 +1,93 @@
+package mailto
+
+import (
+	"testing"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+func emails(addrs []domain.Address) []string {
+	out := make([]string, len(addrs))
+	for i, a := range addrs {
+		out[i] = a.Email
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParse_Simple(t *testing.T) {
+	email, err := Parse("mailto:a@b.com?subject=Hi&body=Yo")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if got := emails(email.To); !equal(got, []string{"a@b.com"}) {
+		t.Errorf("To = %v, want [a@b.com]", got)
+	}
+	if email.Subject != "Hi" {
+		t.Errorf("Subject = %q, want %q", email.Subject, "Hi")
+	}
+	if email.Body != "Yo" {
+		t.Errorf("Body = %q, want %q", email.Body, "Yo")
+	}
+}
+
+func TestParse_MultipleRecipients(t *testing.T) {
+	email, err := Parse("mailto:a@b.com,c@d.com?to=e@f.com&cc=g@h.com,i@j.com&bcc=k@l.com")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if got := emails(email.To); !equal(got, []string{"a@b.com", "c@d.com", "e@f.com"}) {
+		t.Errorf("To = %v", got)
+	}
+	if got := emails(email.CC); !equal(got, []string{"g@h.com", "i@j.com"}) {
+		t.Errorf("CC = %v", got)
+	}
+	if got := emails(email.BCC); !equal(got, []string{"k@l.com"}) {
+		t.Errorf("BCC = %v", got)
+	}
+}
+
+func TestParse_URLEncoding(t *testing.T) {
+	email, err := Parse("MAILTO:a%40b.com%2C%20c@d.com?subject=Hello%20there%3F&body=Line%201%0D%0ALine+2%26more")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if got := emails(email.To); !equal(got, []string{"a@b.com", "c@d.com"}) {
+		t.Errorf("To = %v, want [a@b.com c@d.com]", got)
+	}
+	if email.Subject != "Hello there?" {
+		t.Errorf("Subject = %q, want %q", email.Subject, "Hello there?")
+	}
+	if want := "Line 1\nLine+2&more"; email.Body != want {
+		t.Errorf("Body = %q, want %q", email.Body, want)
+	}
+}
+
+func TestParse_NoRecipient(t *testing.T) {
+	email, err := Parse("mailto:?to=a@b.com&x-unknown=1")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if got := emails(email.To); !equal(got, []string{"a@b.com"}) {
+		t.Errorf("To = %v, want [a@b.com]", got)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, raw := range []string{"https://example.com", "a@b.com", "mailto:a@b.com?subject=%zz"} {
+		if _, err := Parse(raw); err == nil {
+			t.Errorf("Parse(%q) should return an error", raw)
+		}
+	}
+}
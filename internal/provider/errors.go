@@ -0,0 +1,20 @@
+package provider
+
+import "errors"
+
+// Errors providers wrap their failures in so callers can tell kinds of
+// failure apart with errors.Is, whatever the provider's own error types.
+var (
+	// ErrNotFound means the requested message, thread, label or history
+	// record does not exist on the server.
+	ErrNotFound = errors.New("not found on server")
+	// ErrAuth means the account's credentials are missing, expired or
+	// revoked; re-adding the account fixes it.
+	ErrAuth = errors.New("authentication failed")
+	// ErrNetwork means the server could not be reached or failed to
+	// respond; retrying later may succeed.
+	ErrNetwork = errors.New("network error")
+	// ErrRateLimit means the server refused the request for exceeding a
+	// quota, even after retrying.
+	ErrRateLimit = errors.New("rate limit exceeded")
+)
@@ -0,0 +1,124 @@
+package gmail
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+// parseCalendar extracts the first VEVENT from an iCalendar document.
+// It returns nil if the document contains no event.
+func parseCalendar(ics string) *domain.CalendarEvent {
+	var (
+		event   *domain.CalendarEvent
+		method  string
+		inEvent bool
+	)
+
+	for _, line := range unfoldICSLines(ics) {
+		name, params, value := splitICSLine(line)
+		switch {
+		case name == "METHOD" && !inEvent:
+			method = value
+		case name == "BEGIN" && value == "VEVENT":
+			if event == nil {
+				inEvent = true
+				event = &domain.CalendarEvent{}
+			}
+		case name == "END" && value == "VEVENT":
+			inEvent = false
+		case !inEvent:
+			continue
+		case name == "SUMMARY":
+			event.Summary = unescapeICSText(value)
+		case name == "LOCATION":
+			event.Location = unescapeICSText(value)
+		case name == "ORGANIZER":
+			event.Organizer = domain.Address{
+				Name:  strings.Trim(params["CN"], `"`),
+				Email: strings.TrimPrefix(strings.TrimPrefix(value, "mailto:"), "MAILTO:"),
+			}
+		case name == "DTSTART":
+			event.Start, event.AllDay = parseICSTime(value, params)
+		case name == "DTEND":
+			event.End, _ = parseICSTime(value, params)
+		}
+	}
+
+	if event != nil {
+		event.Method = method
+	}
+	return event
+}
+
+// unfoldICSLines splits an iCalendar document into logical lines, joining
+// continuation lines that begin with a space or tab (RFC 5545 §3.1).
+func unfoldICSLines(ics string) []string {
+	raw := strings.Split(strings.ReplaceAll(ics, "\r\n", "\n"), "\n")
+	lines := make([]string, 0, len(raw))
+	for _, l := range raw {
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// splitICSLine splits "NAME;PARAM=VALUE:content" into its parts.
+func splitICSLine(line string) (name string, params map[string]string, value string) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", nil, ""
+	}
+	parts := strings.Split(head, ";")
+	name = strings.ToUpper(parts[0])
+	params = make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = v
+		}
+	}
+	return name, params, value
+}
+
+// parseICSTime parses DATE and DATE-TIME values, honoring a TZID parameter.
+func parseICSTime(value string, params map[string]string) (time.Time, bool) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.Parse("20060102", value)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t, true
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t, false
+	}
+
+	loc := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(strings.Trim(tzid, `"`)); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, false
+}
+
+// unescapeICSText reverses iCalendar TEXT escaping.
+func unescapeICSText(s string) string {
+	r := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return r.Replace(s)
+}
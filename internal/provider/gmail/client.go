@@ -3,33 +3,152 @@ package gmail
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/lu-zhengda/termail/internal/domain"
 	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/rfc822"
 	"github.com/lu-zhengda/termail/internal/store"
 	"golang.org/x/oauth2"
 	gmailapi "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
 const userID = "me"
 
+// DefaultMaxConcurrency is how many messages ListMessages fetches in
+// parallel unless SetMaxConcurrency says otherwise.
+const DefaultMaxConcurrency = 4
+
+// Retry tuning for transient Gmail API errors; variables so tests can
+// shorten the waits.
+var (
+	maxAttempts  = 5
+	retryBackoff = 500 * time.Millisecond
+	maxBackoff   = 16 * time.Second
+)
+
+// Messages larger than resumableSendThreshold bytes are sent through
+// Gmail's resumable upload endpoint in resumableChunkSize chunks, so a
+// transient failure retries the chunk in flight rather than the whole
+// message. Variables so tests can use small messages.
+var (
+	resumableSendThreshold = 5 << 20
+	resumableChunkSize     = 1 << 20
+)
+
 // Provider implements the provider.EmailProvider interface for Gmail.
 type Provider struct {
-	tokenStore *store.KeyringTokenStore
-	accountID  string
-	service    *gmailapi.Service
-	token      *oauth2.Token
+	tokenStore     store.TokenStore
+	accountID      string
+	service        *gmailapi.Service
+	token          *oauth2.Token
+	maxConcurrency int
 }
 
 // New creates a new Gmail provider for the given account.
-func New(accountID string, tokenStore *store.KeyringTokenStore) *Provider {
+func New(accountID string, tokenStore store.TokenStore) *Provider {
 	return &Provider{
-		accountID:  accountID,
-		tokenStore: tokenStore,
+		accountID:      accountID,
+		tokenStore:     tokenStore,
+		maxConcurrency: DefaultMaxConcurrency,
+	}
+}
+
+// SetMaxConcurrency limits how many messages are fetched in parallel.
+// Values below 1 fetch one at a time.
+func (p *Provider) SetMaxConcurrency(n int) {
+	p.maxConcurrency = max(n, 1)
+}
+
+// withRetry calls fn, typically a Gmail API call's Do method, retrying
+// rate-limit and server errors with exponential backoff and jitter until it
+// succeeds, fails permanently, maxAttempts is reached, or ctx is done.
+func withRetry[T any](ctx context.Context, fn func(...googleapi.CallOption) (T, error)) (T, error) {
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		v, err := fn()
+		if err == nil || attempt >= maxAttempts || !isRetryable(err) {
+			return v, classifyError(err)
+		}
+
+		// Full jitter: wait a random duration up to the current backoff.
+		wait := time.Duration(rand.Int64N(int64(backoff)) + 1)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return v, errors.Join(err, ctx.Err())
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// isRetryable reports whether err is a transient Gmail API error: a 429, a
+// 5xx, or a 403 carrying a rate-limit reason.
+func isRetryable(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch {
+	case apiErr.Code == http.StatusTooManyRequests:
+		return true
+	case apiErr.Code >= http.StatusInternalServerError:
+		return true
+	case apiErr.Code == http.StatusForbidden:
+		for _, e := range apiErr.Errors {
+			if e.Reason == "rateLimitExceeded" || e.Reason == "userRateLimitExceeded" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// classifyError wraps a failed Gmail API call's error in the provider
+// error for its kind, keeping the original error in the chain. Errors of
+// no known kind, including cancellation, are returned as is.
+func classifyError(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.Code == http.StatusUnauthorized:
+			return fmt.Errorf("%w: %w", provider.ErrAuth, err)
+		case apiErr.Code == http.StatusNotFound:
+			return fmt.Errorf("%w: %w", provider.ErrNotFound, err)
+		case isRetryable(err) && apiErr.Code < http.StatusInternalServerError:
+			return fmt.Errorf("%w: %w", provider.ErrRateLimit, err)
+		case apiErr.Code >= http.StatusInternalServerError:
+			return fmt.Errorf("%w: %w", provider.ErrNetwork, err)
+		}
+		return err
+	}
+
+	// A refused token refresh surfaces as an oauth2 error inside the
+	// transport's url.Error, so check it before the network errors.
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		return fmt.Errorf("%w: %w", provider.ErrAuth, err)
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &netErr) || errors.As(err, &urlErr) {
+		return fmt.Errorf("%w: %w", provider.ErrNetwork, err)
 	}
+	return err
 }
 
 // Authenticate runs the OAuth2 flow, saves the token, and initializes the Gmail service.
@@ -44,7 +163,7 @@ func (p *Provider) Authenticate(ctx context.Context) error {
 	}
 
 	p.token = token
-	srv, err := gmailapi.NewService(ctx, option.WithTokenSource(oauthConfig.TokenSource(ctx, token)))
+	srv, err := gmailapi.NewService(ctx, option.WithTokenSource(p.tokenSource(ctx, token)))
 	if err != nil {
 		return fmt.Errorf("failed to create gmail service: %w", err)
 	}
@@ -52,6 +171,12 @@ func (p *Provider) Authenticate(ctx context.Context) error {
 	return nil
 }
 
+// tokenSource returns the source the Gmail service authorizes requests
+// with. Tokens refreshed from it are saved back to the token store.
+func (p *Provider) tokenSource(ctx context.Context, token *oauth2.Token) oauth2.TokenSource {
+	return persistentTokenSource(token, oauthConfig.TokenSource(ctx, token), p.tokenStore, p.accountID)
+}
+
 // IsAuthenticated returns true if the Gmail service is initialized.
 func (p *Provider) IsAuthenticated() bool {
 	return p.service != nil
@@ -60,12 +185,15 @@ func (p *Provider) IsAuthenticated() bool {
 // initService loads the token from the keyring and creates the Gmail service.
 func (p *Provider) initService(ctx context.Context) error {
 	token, err := p.tokenStore.LoadToken(p.accountID)
+	if errors.Is(err, store.ErrTokenNotFound) {
+		return fmt.Errorf("failed to load gmail token: %w: %w", provider.ErrAuth, err)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to load gmail token: %w", err)
 	}
 
 	p.token = token
-	srv, err := gmailapi.NewService(ctx, option.WithTokenSource(oauthConfig.TokenSource(ctx, token)))
+	srv, err := gmailapi.NewService(ctx, option.WithTokenSource(p.tokenSource(ctx, token)))
 	if err != nil {
 		return fmt.Errorf("failed to create gmail service: %w", err)
 	}
@@ -101,22 +229,69 @@ func (p *Provider) ListMessages(ctx context.Context, opts provider.ListOptions)
 		call = call.Q(opts.Query)
 	}
 
-	resp, err := call.Context(ctx).Do()
+	resp, err := withRetry(ctx, call.Context(ctx).Do)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to list gmail messages: %w", err)
 	}
 
-	emails := make([]domain.Email, 0, len(resp.Messages))
+	ids := make([]string, 0, len(resp.Messages))
 	for _, m := range resp.Messages {
-		msg, err := p.service.Users.Messages.Get(userID, m.Id).
-			Format("full").Context(ctx).Do()
-		if err != nil {
-			return nil, "", fmt.Errorf("failed to get gmail message %s: %w", m.Id, err)
+		ids = append(ids, m.Id)
+	}
+	emails, err := p.fetchMessages(ctx, ids)
+	if err != nil {
+		return nil, "", err
+	}
+	return emails, resp.NextPageToken, nil
+}
+
+// fetchMessages gets full messages with up to maxConcurrency requests in
+// flight, returning them in the order of ids. The first failure cancels
+// the remaining fetches.
+func (p *Provider) fetchMessages(ctx context.Context, ids []string) ([]domain.Email, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	emails := make([]domain.Email, len(ids))
+	sem := make(chan struct{}, max(p.maxConcurrency, 1))
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i, id := range ids {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
 		}
-		emails = append(emails, *mapMessage(msg))
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			msg, err := withRetry(ctx, p.service.Users.Messages.Get(userID, id).
+				Format("full").Context(ctx).Do)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("failed to get gmail message %s: %w", id, err)
+					cancel()
+				})
+				return
+			}
+			emails[i] = *mapMessage(msg)
+		}()
 	}
+	wg.Wait()
 
-	return emails, resp.NextPageToken, nil
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return emails, nil
 }
 
 // GetMessage returns a single email by ID.
@@ -125,8 +300,8 @@ func (p *Provider) GetMessage(ctx context.Context, id string) (*domain.Email, er
 		return nil, fmt.Errorf("failed to ensure gmail service: %w", err)
 	}
 
-	msg, err := p.service.Users.Messages.Get(userID, id).
-		Format("full").Context(ctx).Do()
+	msg, err := withRetry(ctx, p.service.Users.Messages.Get(userID, id).
+		Format("full").Context(ctx).Do)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get gmail message %s: %w", id, err)
 	}
@@ -135,63 +310,67 @@ func (p *Provider) GetMessage(ctx context.Context, id string) (*domain.Email, er
 	return email, nil
 }
 
-// SendMessage composes and sends an email via the Gmail API.
-func (p *Provider) SendMessage(ctx context.Context, email *domain.Email) error {
+// GetMessages retrieves full messages by ID, fetching up to the provider's
+// concurrency limit at once. Results are returned in the order of ids.
+func (p *Provider) GetMessages(ctx context.Context, ids []string) ([]domain.Email, error) {
 	if err := p.ensureService(ctx); err != nil {
-		return fmt.Errorf("failed to ensure gmail service: %w", err)
-	}
-
-	raw := buildRawMessage(email)
-	encoded := base64.URLEncoding.EncodeToString([]byte(raw))
-
-	msg := &gmailapi.Message{Raw: encoded}
-	_, err := p.service.Users.Messages.Send(userID, msg).Context(ctx).Do()
-	if err != nil {
-		return fmt.Errorf("failed to send gmail message: %w", err)
+		return nil, fmt.Errorf("failed to ensure gmail service: %w", err)
 	}
-	return nil
+	return p.fetchMessages(ctx, ids)
 }
 
-// buildRawMessage constructs an RFC 2822 message from a domain Email.
-func buildRawMessage(email *domain.Email) string {
-	var b strings.Builder
-
-	b.WriteString("From: " + email.From.String() + "\r\n")
+// GetAttachment downloads the content of one attachment of a message.
+func (p *Provider) GetAttachment(ctx context.Context, msgID, attachmentID string) ([]byte, error) {
+	if err := p.ensureService(ctx); err != nil {
+		return nil, fmt.Errorf("failed to ensure gmail service: %w", err)
+	}
 
-	to := make([]string, 0, len(email.To))
-	for _, a := range email.To {
-		to = append(to, a.String())
+	body, err := withRetry(ctx, p.service.Users.Messages.Attachments.Get(userID, msgID, attachmentID).
+		Context(ctx).Do)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment of gmail message %s: %w", msgID, err)
 	}
-	b.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
 
-	if len(email.CC) > 0 {
-		cc := make([]string, 0, len(email.CC))
-		for _, a := range email.CC {
-			cc = append(cc, a.String())
-		}
-		b.WriteString("Cc: " + strings.Join(cc, ", ") + "\r\n")
+	// Gmail pads some attachment data and not others.
+	data, err := base64.URLEncoding.WithPadding(base64.NoPadding).DecodeString(strings.TrimRight(body.Data, "="))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode attachment of gmail message %s: %w", msgID, err)
 	}
+	return data, nil
+}
 
-	if len(email.BCC) > 0 {
-		bcc := make([]string, 0, len(email.BCC))
-		for _, a := range email.BCC {
-			bcc = append(bcc, a.String())
-		}
-		b.WriteString("Bcc: " + strings.Join(bcc, ", ") + "\r\n")
+// SendMessage composes and sends an email via the Gmail API, then sets
+// email's ID, ThreadID and Labels to those Gmail gave the sent message.
+func (p *Provider) SendMessage(ctx context.Context, email *domain.Email) error {
+	if err := p.ensureService(ctx); err != nil {
+		return fmt.Errorf("failed to ensure gmail service: %w", err)
 	}
 
-	b.WriteString("Subject: " + email.Subject + "\r\n")
+	raw := buildRawMessage(email)
 
-	if email.InReplyTo != "" {
-		b.WriteString("In-Reply-To: " + email.InReplyTo + "\r\n")
+	var call *gmailapi.UsersMessagesSendCall
+	if len(raw) > resumableSendThreshold {
+		call = p.service.Users.Messages.Send(userID, &gmailapi.Message{}).
+			Media(strings.NewReader(raw),
+				googleapi.ContentType("message/rfc822"),
+				googleapi.ChunkSize(resumableChunkSize))
+	} else {
+		encoded := base64.URLEncoding.EncodeToString([]byte(raw))
+		call = p.service.Users.Messages.Send(userID, &gmailapi.Message{Raw: encoded})
+	}
+	sent, err := call.Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to send gmail message: %w", classifyError(err))
 	}
+	email.ID = sent.Id
+	email.ThreadID = sent.ThreadId
+	email.Labels = sent.LabelIds
+	return nil
+}
 
-	b.WriteString("MIME-Version: 1.0\r\n")
-	b.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n")
-	b.WriteString("\r\n")
-	b.WriteString(email.Body)
-
-	return b.String()
+// buildRawMessage constructs an RFC 2822 message from a domain Email.
+func buildRawMessage(email *domain.Email) string {
+	return rfc822.Build(email)
 }
 
 // ListThreads returns a page of threads matching the given options.
@@ -214,7 +393,7 @@ func (p *Provider) ListThreads(ctx context.Context, opts provider.ListOptions) (
 		call = call.Q(opts.Query)
 	}
 
-	resp, err := call.Context(ctx).Do()
+	resp, err := withRetry(ctx, call.Context(ctx).Do)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to list gmail threads: %w", err)
 	}
@@ -236,8 +415,8 @@ func (p *Provider) GetThread(ctx context.Context, id string) (*domain.Thread, er
 		return nil, fmt.Errorf("failed to ensure gmail service: %w", err)
 	}
 
-	t, err := p.service.Users.Threads.Get(userID, id).
-		Format("full").Context(ctx).Do()
+	t, err := withRetry(ctx, p.service.Users.Threads.Get(userID, id).
+		Format("full").Context(ctx).Do)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get gmail thread %s: %w", id, err)
 	}
@@ -285,13 +464,37 @@ func (p *Provider) ModifyLabels(ctx context.Context, msgID string, add, remove [
 		AddLabelIds:    add,
 		RemoveLabelIds: remove,
 	}
-	_, err := p.service.Users.Messages.Modify(userID, msgID, req).Context(ctx).Do()
+	_, err := withRetry(ctx, p.service.Users.Messages.Modify(userID, msgID, req).Context(ctx).Do)
 	if err != nil {
 		return fmt.Errorf("failed to modify labels on message %s: %w", msgID, err)
 	}
 	return nil
 }
 
+// maxBatchModify is the Gmail API limit on message IDs per batchModify call.
+const maxBatchModify = 1000
+
+// BatchModifyLabels adds and removes labels on many messages at once,
+// splitting the request into chunks the Gmail API accepts.
+func (p *Provider) BatchModifyLabels(ctx context.Context, msgIDs []string, add, remove []string) error {
+	if err := p.ensureService(ctx); err != nil {
+		return fmt.Errorf("failed to ensure gmail service: %w", err)
+	}
+
+	for start := 0; start < len(msgIDs); start += maxBatchModify {
+		end := min(start+maxBatchModify, len(msgIDs))
+		req := &gmailapi.BatchModifyMessagesRequest{
+			Ids:            msgIDs[start:end],
+			AddLabelIds:    add,
+			RemoveLabelIds: remove,
+		}
+		if err := p.service.Users.Messages.BatchModify(userID, req).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to batch modify labels on %d messages: %w", end-start, classifyError(err))
+		}
+	}
+	return nil
+}
+
 // TrashMessage moves a message to trash.
 func (p *Provider) TrashMessage(ctx context.Context, msgID string) error {
 	if err := p.ensureService(ctx); err != nil {
@@ -300,7 +503,7 @@ func (p *Provider) TrashMessage(ctx context.Context, msgID string) error {
 
 	_, err := p.service.Users.Messages.Trash(userID, msgID).Context(ctx).Do()
 	if err != nil {
-		return fmt.Errorf("failed to trash gmail message %s: %w", msgID, err)
+		return fmt.Errorf("failed to trash gmail message %s: %w", msgID, classifyError(err))
 	}
 	return nil
 }
@@ -319,7 +522,7 @@ func (p *Provider) ListLabels(ctx context.Context) ([]domain.Label, error) {
 		return nil, fmt.Errorf("failed to ensure gmail service: %w", err)
 	}
 
-	resp, err := p.service.Users.Labels.List(userID).Context(ctx).Do()
+	resp, err := withRetry(ctx, p.service.Users.Labels.List(userID).Context(ctx).Do)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list gmail labels: %w", err)
 	}
@@ -347,6 +550,60 @@ func (p *Provider) ListLabels(ctx context.Context) ([]domain.Label, error) {
 	return labels, nil
 }
 
+// ErrSystemLabel is returned when deleting one of Gmail's built-in labels.
+var ErrSystemLabel = errors.New("system labels cannot be deleted")
+
+// CreateLabel creates a user label with the given name.
+func (p *Provider) CreateLabel(ctx context.Context, name string) (domain.Label, error) {
+	if err := p.ensureService(ctx); err != nil {
+		return domain.Label{}, fmt.Errorf("failed to ensure gmail service: %w", err)
+	}
+
+	req := &gmailapi.Label{
+		Name:                  name,
+		LabelListVisibility:   "labelShow",
+		MessageListVisibility: "show",
+	}
+	l, err := p.service.Users.Labels.Create(userID, req).Context(ctx).Do()
+	if err != nil {
+		return domain.Label{}, fmt.Errorf("failed to create gmail label %q: %w", name, classifyError(err))
+	}
+
+	return domain.Label{
+		ID:        l.Id,
+		AccountID: p.accountID,
+		Name:      l.Name,
+		Type:      domain.LabelTypeUser,
+	}, nil
+}
+
+// DeleteLabel permanently deletes a user label. Gmail rejects deleting
+// system labels such as INBOX; that case is reported as ErrSystemLabel.
+func (p *Provider) DeleteLabel(ctx context.Context, labelID string) error {
+	if err := p.ensureService(ctx); err != nil {
+		return fmt.Errorf("failed to ensure gmail service: %w", err)
+	}
+
+	if err := p.service.Users.Labels.Delete(userID, labelID).Context(ctx).Do(); err != nil {
+		if isSystemLabelError(err) {
+			return fmt.Errorf("failed to delete label %s: %w", labelID, ErrSystemLabel)
+		}
+		return fmt.Errorf("failed to delete gmail label %s: %w", labelID, classifyError(err))
+	}
+	return nil
+}
+
+// isSystemLabelError reports whether err is Gmail's "Invalid delete request"
+// response, which it returns for attempts to delete a system label.
+func isSystemLabelError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusBadRequest &&
+		strings.Contains(strings.ToLower(apiErr.Message), "invalid delete request")
+}
+
 // Search searches for messages matching the query.
 func (p *Provider) Search(ctx context.Context, query string, opts provider.ListOptions) ([]domain.Email, string, error) {
 	opts.Query = query
@@ -362,10 +619,19 @@ func (p *Provider) History(ctx context.Context, startHistoryID uint64) ([]provid
 	var events []provider.HistoryEvent
 	var latestHistoryID uint64
 
-	call := p.service.Users.History.List(userID).
-		StartHistoryId(startHistoryID)
-
-	err := call.Pages(ctx, func(resp *gmailapi.ListHistoryResponse) error {
+	var pageToken string
+	for {
+		call := p.service.Users.History.List(userID).
+			StartHistoryId(startHistoryID).
+			Context(ctx)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		// An expired start ID is a 404, classified as provider.ErrNotFound.
+		resp, err := withRetry(ctx, call.Do)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to list gmail history: %w", err)
+		}
 		latestHistoryID = resp.HistoryId
 
 		for _, h := range resp.History {
@@ -397,26 +663,30 @@ func (p *Provider) History(ctx context.Context, startHistoryID uint64) ([]provid
 				})
 			}
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list gmail history: %w", err)
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
 	}
 
 	return events, latestHistoryID, nil
 }
 
-// GetProfile returns the authenticated user's email address.
-func (p *Provider) GetProfile(ctx context.Context) (string, error) {
+// GetProfile returns the authenticated user's email address and mailbox size.
+func (p *Provider) GetProfile(ctx context.Context) (*domain.Profile, error) {
 	if err := p.ensureService(ctx); err != nil {
-		return "", fmt.Errorf("failed to ensure gmail service: %w", err)
+		return nil, fmt.Errorf("failed to ensure gmail service: %w", err)
 	}
 
-	profile, err := p.service.Users.GetProfile(userID).Context(ctx).Do()
+	profile, err := withRetry(ctx, p.service.Users.GetProfile(userID).Context(ctx).Do)
 	if err != nil {
-		return "", fmt.Errorf("failed to get gmail profile: %w", err)
+		return nil, fmt.Errorf("failed to get gmail profile: %w", err)
 	}
-	return profile.EmailAddress, nil
+	return &domain.Profile{
+		Email:         profile.EmailAddress,
+		MessagesTotal: profile.MessagesTotal,
+		ThreadsTotal:  profile.ThreadsTotal,
+	}, nil
 }
 
 // Compile-time interface compliance check.
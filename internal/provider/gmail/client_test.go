@@ -0,0 +1,515 @@
+package gmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/app"
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/store"
+	"github.com/lu-zhengda/termail/internal/store/sqlite"
+	"golang.org/x/oauth2"
+	gmailapi "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+func TestBuildRawMessage_ReplyTo(t *testing.T) {
+	email := &domain.Email{
+		From:    domain.Address{Email: "me@example.com"},
+		To:      []domain.Address{{Email: "bob@example.com"}},
+		ReplyTo: []domain.Address{{Name: "Support", Email: "support@example.com"}},
+		Subject: "Hello",
+		Body:    "Hi Bob",
+	}
+
+	raw := buildRawMessage(email)
+
+	if !strings.Contains(raw, "Reply-To: Support <support@example.com>\r\n") {
+		t.Errorf("raw message missing Reply-To header:\n%s", raw)
+	}
+}
+
+func TestBuildRawMessage_NoReplyTo(t *testing.T) {
+	email := &domain.Email{
+		To:      []domain.Address{{Email: "bob@example.com"}},
+		Subject: "Hello",
+	}
+
+	raw := buildRawMessage(email)
+
+	if strings.Contains(raw, "Reply-To:") {
+		t.Errorf("raw message should not contain Reply-To header:\n%s", raw)
+	}
+}
+
+func TestBuildRawMessage_References(t *testing.T) {
+	original := &domain.Email{
+		ID:         "gmail-id-2",
+		MessageID:  "<b@example.com>",
+		References: []string{"<a@example.com>"},
+	}
+	reply := &domain.Email{
+		To:         []domain.Address{{Email: "bob@example.com"}},
+		Subject:    "Re: Hello",
+		InReplyTo:  original.ReplyInReplyTo(),
+		References: original.ReplyReferences(),
+	}
+
+	raw := buildRawMessage(reply)
+
+	if !strings.Contains(raw, "In-Reply-To: <b@example.com>\r\n") {
+		t.Errorf("raw message missing In-Reply-To header:\n%s", raw)
+	}
+	if !strings.Contains(raw, "References: <a@example.com> <b@example.com>\r\n") {
+		t.Errorf("raw message missing References chain:\n%s", raw)
+	}
+}
+
+func TestBuildRawMessage_NoReferences(t *testing.T) {
+	raw := buildRawMessage(&domain.Email{Subject: "Hello"})
+
+	if strings.Contains(raw, "References:") {
+		t.Errorf("raw message should not contain References header:\n%s", raw)
+	}
+}
+
+func TestBuildRawMessage_EncodesNonASCII(t *testing.T) {
+	raw := buildRawMessage(&domain.Email{
+		To:      []domain.Address{{Name: "Zoë", Email: "zoe@example.com"}},
+		Subject: "Café",
+		Body:    "À bientôt, et merci pour tout.",
+	})
+
+	for _, want := range []string{
+		"To: =?utf-8?q?Zo=C3=AB?= <zoe@example.com>\r\n",
+		"Subject: =?utf-8?q?Caf=C3=A9?=\r\n",
+		"Content-Transfer-Encoding: quoted-printable\r\n",
+		"=C3=80 bient=C3=B4t",
+	} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("raw message missing %q:\n%s", want, raw)
+		}
+	}
+}
+
+func TestIsSystemLabelError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"invalid delete", &googleapi.Error{Code: http.StatusBadRequest, Message: "Invalid delete request"}, true},
+		{"wrapped", fmt.Errorf("call: %w", &googleapi.Error{Code: http.StatusBadRequest, Message: "Invalid delete request"}), true},
+		{"not found", &googleapi.Error{Code: http.StatusNotFound, Message: "Not Found"}, false},
+		{"other bad request", &googleapi.Error{Code: http.StatusBadRequest, Message: "Invalid label name"}, false},
+		{"plain error", fmt.Errorf("network down"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSystemLabelError(tt.err); got != tt.want {
+				t.Errorf("isSystemLabelError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// scriptedTransport returns canned responses in order, repeating the last.
+type scriptedTransport struct {
+	mu        sync.Mutex
+	responses []int
+	calls     int
+}
+
+func (s *scriptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.mu.Lock()
+	code := s.responses[min(s.calls, len(s.responses)-1)]
+	s.calls++
+	s.mu.Unlock()
+
+	body := `{"id":"m1","threadId":"t1","labelIds":["INBOX"]}`
+	if code != http.StatusOK {
+		body = fmt.Sprintf(`{"error":{"code":%d,"message":"rate limited"}}`, code)
+	}
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func newTestProvider(t *testing.T, rt http.RoundTripper) *Provider {
+	t.Helper()
+	srv, err := gmailapi.NewService(context.Background(),
+		option.WithHTTPClient(&http.Client{Transport: rt}),
+		option.WithEndpoint("http://gmail.test/"))
+	if err != nil {
+		t.Fatalf("NewService() error: %v", err)
+	}
+	p := New("acc-1", nil)
+	p.service = srv
+	return p
+}
+
+func shortenRetries(t *testing.T) {
+	t.Helper()
+	prev := retryBackoff
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = prev })
+}
+
+func TestGetMessage_RetriesRateLimit(t *testing.T) {
+	shortenRetries(t)
+	rt := &scriptedTransport{responses: []int{http.StatusTooManyRequests, http.StatusOK}}
+	p := newTestProvider(t, rt)
+
+	email, err := p.GetMessage(context.Background(), "m1")
+	if err != nil {
+		t.Fatalf("GetMessage() error: %v", err)
+	}
+	if email.ID != "m1" {
+		t.Errorf("ID = %q, want m1", email.ID)
+	}
+	if rt.calls != 2 {
+		t.Errorf("calls = %d, want 2 (one retry)", rt.calls)
+	}
+}
+
+func TestGetMessage_DoesNotRetryClientErrors(t *testing.T) {
+	shortenRetries(t)
+	rt := &scriptedTransport{responses: []int{http.StatusNotFound}}
+	p := newTestProvider(t, rt)
+
+	if _, err := p.GetMessage(context.Background(), "m1"); err == nil {
+		t.Fatal("GetMessage() should fail on 404")
+	}
+	if rt.calls != 1 {
+		t.Errorf("calls = %d, want 1", rt.calls)
+	}
+}
+
+func TestGetMessage_GivesUpAfterMaxAttempts(t *testing.T) {
+	shortenRetries(t)
+	rt := &scriptedTransport{responses: []int{http.StatusServiceUnavailable}}
+	p := newTestProvider(t, rt)
+
+	if _, err := p.GetMessage(context.Background(), "m1"); err == nil {
+		t.Fatal("GetMessage() should fail when every attempt is a 503")
+	}
+	if rt.calls != maxAttempts {
+		t.Errorf("calls = %d, want %d", rt.calls, maxAttempts)
+	}
+}
+
+func TestGetMessage_ClassifiesErrors(t *testing.T) {
+	shortenRetries(t)
+	tests := []struct {
+		code int
+		want error
+	}{
+		{http.StatusNotFound, provider.ErrNotFound},
+		{http.StatusUnauthorized, provider.ErrAuth},
+		{http.StatusTooManyRequests, provider.ErrRateLimit},
+		{http.StatusServiceUnavailable, provider.ErrNetwork},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.code), func(t *testing.T) {
+			p := newTestProvider(t, &scriptedTransport{responses: []int{tt.code}})
+
+			_, err := p.GetMessage(context.Background(), "m1")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("GetMessage() error = %v, want %v", err, tt.want)
+			}
+			// The API error stays reachable for callers that need details.
+			var apiErr *googleapi.Error
+			if !errors.As(err, &apiErr) || apiErr.Code != tt.code {
+				t.Errorf("GetMessage() error %v does not wrap a %d googleapi.Error", err, tt.code)
+			}
+		})
+	}
+}
+
+// failingTransport fails every request before it reaches the server.
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestClassifyError(t *testing.T) {
+	p := newTestProvider(t, failingTransport{})
+	_, err := p.GetMessage(context.Background(), "m1")
+	if !errors.Is(err, provider.ErrNetwork) {
+		t.Errorf("GetMessage() unreachable error = %v, want ErrNetwork", err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"bad request", &googleapi.Error{Code: http.StatusBadRequest}, nil},
+		{"rate-limited 403", &googleapi.Error{Code: http.StatusForbidden,
+			Errors: []googleapi.ErrorItem{{Reason: "userRateLimitExceeded"}}}, provider.ErrRateLimit},
+		{"refused refresh", &url.Error{Op: "Post", URL: "https://oauth2.googleapis.com/token",
+			Err: &oauth2.RetrieveError{ErrorCode: "invalid_grant"}}, provider.ErrAuth},
+		{"cancelled", &url.Error{Op: "Get", URL: "http://gmail.test/", Err: context.Canceled}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyError(tt.err)
+			for _, kind := range []error{provider.ErrNotFound, provider.ErrAuth, provider.ErrNetwork, provider.ErrRateLimit} {
+				if errors.Is(got, kind) != (kind == tt.want) {
+					t.Errorf("classifyError(%v) = %v, want kind %v", tt.err, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// mailboxTransport serves a message list and each message by ID.
+type mailboxTransport struct {
+	ids []string
+}
+
+func (m *mailboxTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body string
+	if id, ok := strings.CutPrefix(req.URL.Path, "/gmail/v1/users/me/messages/"); ok {
+		body = fmt.Sprintf(`{"id":%q,"threadId":"t"}`, id)
+	} else {
+		refs := make([]string, len(m.ids))
+		for i, id := range m.ids {
+			refs[i] = fmt.Sprintf(`{"id":%q}`, id)
+		}
+		body = `{"messages":[` + strings.Join(refs, ",") + `]}`
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestListMessages_ConcurrentFetchKeepsOrder(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	p := newTestProvider(t, &mailboxTransport{ids: ids})
+	p.SetMaxConcurrency(3)
+
+	emails, _, err := p.ListMessages(context.Background(), provider.ListOptions{})
+	if err != nil {
+		t.Fatalf("ListMessages() error: %v", err)
+	}
+	if len(emails) != len(ids) {
+		t.Fatalf("got %d emails, want %d", len(emails), len(ids))
+	}
+	for i, e := range emails {
+		if e.ID != ids[i] {
+			t.Errorf("emails[%d].ID = %q, want %q", i, e.ID, ids[i])
+		}
+	}
+}
+
+// expiredHistoryTransport answers history requests with Gmail's 404 for an
+// expired start ID and serves a one-message mailbox for the fallback sync.
+type expiredHistoryTransport struct {
+	historyCalls int
+}
+
+func (e *expiredHistoryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	code, body := http.StatusOK, ""
+	switch path := strings.TrimPrefix(req.URL.Path, "/gmail/v1/users/me/"); {
+	case path == "history":
+		e.historyCalls++
+		code = http.StatusNotFound
+		body = `{"error":{"code":404,"message":"Requested entity was not found."}}`
+	case path == "labels":
+		body = `{"labels":[]}`
+	case path == "messages":
+		body = `{"messages":[{"id":"m1"}]}`
+	case path == "messages/m1":
+		body = `{"id":"m1","threadId":"t1","historyId":"90","labelIds":["INBOX"]}`
+	case path == "profile":
+		body = `{"emailAddress":"me@example.com","historyId":"90","messagesTotal":1}`
+	default:
+		code, body = http.StatusNotFound, `{"error":{"code":404,"message":"no route"}}`
+	}
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestHistory_ExpiredStartIDFallsBackToInitialSync(t *testing.T) {
+	shortenRetries(t)
+	rt := &expiredHistoryTransport{}
+	p := newTestProvider(t, rt)
+	ctx := context.Background()
+
+	if _, _, err := p.History(ctx, 5); !errors.Is(err, provider.ErrNotFound) {
+		t.Fatalf("History() error = %v, want provider.ErrNotFound", err)
+	}
+	if rt.historyCalls != 1 {
+		t.Errorf("history calls = %d, want 1 (a 404 is not retried)", rt.historyCalls)
+	}
+
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New() error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.CreateAccount(ctx, &domain.Account{ID: "acc-1", Email: "me@example.com", Provider: "gmail"}); err != nil {
+		t.Fatalf("CreateAccount() error: %v", err)
+	}
+	if err := db.SetSyncState(ctx, &store.SyncState{AccountID: "acc-1", HistoryID: 5, LastSync: 1}); err != nil {
+		t.Fatalf("SetSyncState() error: %v", err)
+	}
+
+	if err := app.NewSyncService(db, p, "acc-1").IncrementalSync(ctx); err != nil {
+		t.Fatalf("IncrementalSync() error: %v", err)
+	}
+	if _, err := db.GetEmail(ctx, "m1", "acc-1"); err != nil {
+		t.Errorf("GetEmail(m1) after fallback error: %v", err)
+	}
+	state, err := db.GetSyncState(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("GetSyncState() error: %v", err)
+	}
+	if state.HistoryID == 5 {
+		t.Errorf("HistoryID = %d, want it replaced by the fallback sync", state.HistoryID)
+	}
+}
+
+// uploadTransport fakes Gmail's send endpoints, including the resumable
+// upload session protocol, and fails the chunk at failChunk once.
+type uploadTransport struct {
+	failChunk int
+
+	requests []string // "METHOD path?uploadType" for each request
+	chunks   []string // Content-Range of each chunk PUT
+	received int64
+}
+
+func (u *uploadTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u.requests = append(u.requests, req.Method+" "+req.URL.Path+"?"+req.URL.Query().Get("uploadType"))
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"id":"sent-1","threadId":"thread-1","labelIds":["SENT"]}`)),
+		Request:    req,
+	}
+
+	if req.URL.Path != "/upload/session" {
+		if req.URL.Query().Get("uploadType") == "resumable" {
+			resp.Header.Set("Location", "http://gmail.test/upload/session")
+			resp.Body = io.NopCloser(strings.NewReader(""))
+		}
+		return resp, nil
+	}
+
+	// A chunk of the resumable session: "bytes first-last/total".
+	contentRange := req.Header.Get("Content-Range")
+	u.chunks = append(u.chunks, contentRange)
+	body, _ := io.ReadAll(req.Body)
+	if len(u.chunks) == u.failChunk {
+		resp.StatusCode = http.StatusServiceUnavailable
+		resp.Body = io.NopCloser(strings.NewReader(`{"error":{"code":503,"message":"backend error"}}`))
+		return resp, nil
+	}
+	u.received += int64(len(body))
+	if !strings.HasSuffix(contentRange, "/*") {
+		return resp, nil
+	}
+	// With X-GUploader-No-308, "resume incomplete" is a 200 with an override.
+	resp.Header.Set("X-Http-Status-Code-Override", "308")
+	resp.Header.Set("Range", fmt.Sprintf("bytes=0-%d", u.received-1))
+	resp.Body = io.NopCloser(strings.NewReader(""))
+	return resp, nil
+}
+
+func TestSendMessage_ResumableForLargeMessages(t *testing.T) {
+	prevThreshold, prevChunk := resumableSendThreshold, resumableChunkSize
+	resumableSendThreshold, resumableChunkSize = 300<<10, googleapi.MinUploadChunkSize
+	t.Cleanup(func() { resumableSendThreshold, resumableChunkSize = prevThreshold, prevChunk })
+
+	email := &domain.Email{
+		From:    domain.Address{Email: "me@example.com"},
+		To:      []domain.Address{{Email: "you@example.com"}},
+		Subject: "Small",
+		Body:    "hello",
+	}
+
+	small := &uploadTransport{}
+	if err := newTestProvider(t, small).SendMessage(context.Background(), email); err != nil {
+		t.Fatalf("SendMessage(small) error: %v", err)
+	}
+	if want := []string{"POST /gmail/v1/users/me/messages/send?"}; !slices.Equal(small.requests, want) {
+		t.Errorf("small message requests = %q, want %q", small.requests, want)
+	}
+	if email.ID != "sent-1" || email.ThreadID != "thread-1" || !slices.Equal(email.Labels, []string{"SENT"}) {
+		t.Errorf("sent email ID/ThreadID/Labels = %q/%q/%v, want the server's", email.ID, email.ThreadID, email.Labels)
+	}
+
+	email.Subject = "Large"
+	email.Body = strings.Repeat("attachment data line\n", 30000) // ~600 KiB
+	large := &uploadTransport{failChunk: 2}
+	if err := newTestProvider(t, large).SendMessage(context.Background(), email); err != nil {
+		t.Fatalf("SendMessage(large) error: %v", err)
+	}
+	if got := large.requests[0]; got != "POST /upload/gmail/v1/users/me/messages/send?resumable" {
+		t.Errorf("first request = %q, want a resumable upload session", got)
+	}
+	// Three chunks, the second one sent again after its transient failure.
+	if len(large.chunks) != 4 || large.chunks[1] != large.chunks[2] {
+		t.Errorf("chunks = %q, want the failed chunk resent", large.chunks)
+	}
+	if raw := buildRawMessage(email); large.received != int64(len(raw)) {
+		t.Errorf("uploaded %d bytes, want the %d-byte message", large.received, len(raw))
+	}
+}
+
+// attachmentTransport serves one attachment body and records the path asked for.
+type attachmentTransport struct {
+	data string
+	path string
+}
+
+func (a *attachmentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	a.path = req.URL.Path
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(fmt.Sprintf(`{"attachmentId":"a1","size":5,"data":%q}`, a.data))),
+		Request:    req,
+	}, nil
+}
+
+func TestGetAttachment(t *testing.T) {
+	for _, data := range []string{"aGk_Pz8", "aGk_Pz8="} {
+		rt := &attachmentTransport{data: data}
+		got, err := newTestProvider(t, rt).GetAttachment(context.Background(), "m1", "a1")
+		if err != nil {
+			t.Fatalf("GetAttachment(%q) error: %v", data, err)
+		}
+		if string(got) != "hi???" {
+			t.Errorf("GetAttachment(%q) = %q, want %q", data, got, "hi???")
+		}
+		if want := "/gmail/v1/users/me/messages/m1/attachments/a1"; rt.path != want {
+			t.Errorf("request path = %q, want %q", rt.path, want)
+		}
+	}
+}
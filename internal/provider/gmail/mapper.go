@@ -2,11 +2,16 @@ package gmail
 
 import (
 	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
 	"net/mail"
 	"strings"
 	"time"
 
 	"github.com/lu-zhengda/termail/internal/domain"
+	"golang.org/x/text/encoding/htmlindex"
 	gmailapi "google.golang.org/api/gmail/v1"
 )
 
@@ -17,24 +22,38 @@ func mapMessage(msg *gmailapi.Message) *domain.Email {
 		headers = msg.Payload.Headers
 	}
 
-	text, html := extractBody(msg.Payload)
+	text, html, calendar := extractBody(msg.Payload)
 	attachments := extractAttachments(msg.Payload)
 
+	var event *domain.CalendarEvent
+	if calendar != "" {
+		event = parseCalendar(calendar)
+	}
+
 	return &domain.Email{
 		ID:          msg.Id,
 		ThreadID:    msg.ThreadId,
 		From:        parseAddress(findHeader(headers, "From")),
 		To:          parseAddressList(findHeader(headers, "To")),
 		CC:          parseAddressList(findHeader(headers, "Cc")),
-		Subject:     findHeader(headers, "Subject"),
+		BCC:         parseAddressList(findHeader(headers, "Bcc")),
+		Subject:     decodeHeader(findHeader(headers, "Subject")),
 		Body:        text,
 		BodyHTML:    html,
-		Date:        parseDate(findHeader(headers, "Date")),
+		Date:        messageDate(findHeader(headers, "Date"), msg.InternalDate),
+		ReceivedAt:  receivedAt(msg.InternalDate),
 		Labels:      msg.LabelIds,
 		IsRead:      !containsLabel(msg.LabelIds, "UNREAD"),
 		IsStarred:   containsLabel(msg.LabelIds, "STARRED"),
 		Attachments: attachments,
 		InReplyTo:   findHeader(headers, "In-Reply-To"),
+		MessageID:   findHeader(headers, "Message-ID"),
+		References:  strings.Fields(findHeader(headers, "References")),
+
+		ListUnsubscribe: findHeader(headers, "List-Unsubscribe"),
+		ListID:          findHeader(headers, "List-Id"),
+		ListPost:        findHeader(headers, "List-Post"),
+		Event:           event,
 	}
 }
 
@@ -49,6 +68,24 @@ func findHeader(headers []*gmailapi.MessagePartHeader, name string) string {
 	return ""
 }
 
+// wordDecoder decodes RFC 2047 encoded words ("=?UTF-8?B?...?=") in
+// headers, in any charset charsetReader knows.
+var wordDecoder = mime.WordDecoder{CharsetReader: charsetReader}
+
+// addressParser parses address headers, decoding encoded display names.
+var addressParser = mail.AddressParser{WordDecoder: &wordDecoder}
+
+// decodeHeader decodes the encoded words in a header value, in B or Q
+// encoding, joining adjacent ones. A value it cannot decode, such as one in
+// an unknown charset, is returned as is.
+func decodeHeader(s string) string {
+	decoded, err := wordDecoder.DecodeHeader(s)
+	if err != nil {
+		return s
+	}
+	return decoded
+}
+
 // parseAddress parses an RFC 5322 address string into a domain Address.
 // Falls back to treating the entire string as a bare email if parsing fails.
 func parseAddress(s string) domain.Address {
@@ -57,13 +94,13 @@ func parseAddress(s string) domain.Address {
 		return domain.Address{}
 	}
 
-	addr, err := mail.ParseAddress(s)
+	addr, err := addressParser.Parse(s)
 	if err != nil {
 		// Fallback: treat as bare email
 		return domain.Address{Email: s}
 	}
 	return domain.Address{
-		Name:  addr.Name,
+		Name:  decodeHeader(addr.Name),
 		Email: addr.Address,
 	}
 }
@@ -75,7 +112,7 @@ func parseAddressList(s string) []domain.Address {
 		return nil
 	}
 
-	parsed, err := mail.ParseAddressList(s)
+	parsed, err := addressParser.ParseList(s)
 	if err != nil {
 		// Fallback: split by comma and parse individually
 		parts := strings.Split(s, ",")
@@ -91,13 +128,32 @@ func parseAddressList(s string) []domain.Address {
 	addrs := make([]domain.Address, 0, len(parsed))
 	for _, a := range parsed {
 		addrs = append(addrs, domain.Address{
-			Name:  a.Name,
+			Name:  decodeHeader(a.Name),
 			Email: a.Address,
 		})
 	}
 	return addrs
 }
 
+// receivedAt converts Gmail's internalDate, in milliseconds since the epoch,
+// to a time. Zero stays unknown.
+func receivedAt(internalDate int64) time.Time {
+	if internalDate <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(internalDate)
+}
+
+// messageDate returns the time in a message's Date header, falling back to
+// when Gmail received it for a missing or unparseable header. It is zero
+// only when neither is known.
+func messageDate(header string, internalDate int64) time.Time {
+	if t := parseDate(header); !t.IsZero() {
+		return t
+	}
+	return receivedAt(internalDate)
+}
+
 // parseDate tries multiple date formats commonly used in email headers.
 func parseDate(s string) time.Time {
 	s = strings.TrimSpace(s)
@@ -135,39 +191,97 @@ func containsLabel(labels []string, label string) bool {
 	return false
 }
 
-// extractBody recursively extracts text/plain and text/html content from a message payload.
-func extractBody(payload *gmailapi.MessagePart) (text, html string) {
+// extractBody recursively extracts text/plain, text/html, and text/calendar
+// content from a message payload.
+func extractBody(payload *gmailapi.MessagePart) (text, html, calendar string) {
 	if payload == nil {
-		return "", ""
+		return "", "", ""
 	}
 
 	// If this part has sub-parts, recurse into them
 	if len(payload.Parts) > 0 {
 		for _, part := range payload.Parts {
-			t, h := extractBody(part)
+			t, h, c := extractBody(part)
 			if text == "" && t != "" {
 				text = t
 			}
 			if html == "" && h != "" {
 				html = h
 			}
+			if calendar == "" && c != "" {
+				calendar = c
+			}
 		}
-		return text, html
+		return text, html, calendar
 	}
 
 	// Leaf part: decode the body
 	data := ""
 	if payload.Body != nil {
-		data = decodeBase64URL(payload.Body.Data)
+		data = decodePart(payload.Headers, decodeBase64URL(payload.Body.Data))
 	}
 
 	switch payload.MimeType {
 	case "text/plain":
-		return data, ""
+		return data, "", ""
 	case "text/html":
-		return "", data
+		return "", data, ""
+	case "text/calendar":
+		return "", "", data
+	}
+	return "", "", ""
+}
+
+// decodePart turns the content of a leaf part into UTF-8 text: it undoes
+// the part's Content-Transfer-Encoding, which mail relayed from other
+// servers can still carry inside Gmail's own encoding, and converts it from
+// the part's charset. Content that fails to decode is returned as is.
+func decodePart(headers []*gmailapi.MessagePartHeader, data string) string {
+	switch strings.ToLower(strings.TrimSpace(findHeader(headers, "Content-Transfer-Encoding"))) {
+	case "quoted-printable":
+		if b, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(data))); err == nil {
+			data = string(b)
+		}
+	case "base64":
+		compact := strings.Join(strings.Fields(data), "")
+		if b, err := base64.StdEncoding.DecodeString(compact); err == nil {
+			data = string(b)
+		}
+	}
+
+	_, params, err := mime.ParseMediaType(findHeader(headers, "Content-Type"))
+	if err != nil {
+		return data
+	}
+	return toUTF8(data, params["charset"])
+}
+
+// toUTF8 converts s from the named charset to UTF-8. UTF-8, ASCII and
+// unknown charsets are left alone.
+func toUTF8(s, charset string) string {
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return s
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return s
+	}
+	decoded, err := enc.NewDecoder().String(s)
+	if err != nil {
+		return s
+	}
+	return decoded
+}
+
+// charsetReader converts encoded words in charsets other than UTF-8,
+// ISO-8859-1 and US-ASCII, which wordDecoder handles itself.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q: %w", charset, err)
 	}
-	return "", ""
+	return enc.NewDecoder().Reader(input), nil
 }
 
 // extractAttachments collects attachment metadata from message parts.
@@ -1,6 +1,7 @@
 package gmail
 
 import (
+	"encoding/base64"
 	"testing"
 	"time"
 
@@ -252,7 +253,7 @@ func TestExtractBody(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			text, html := extractBody(tt.payload)
+			text, html, _ := extractBody(tt.payload)
 			if text != tt.wantText {
 				t.Errorf("extractBody() text = %q, want %q", text, tt.wantText)
 			}
@@ -263,6 +264,75 @@ func TestExtractBody(t *testing.T) {
 	}
 }
 
+func TestExtractBody_TransferEncodingAndCharset(t *testing.T) {
+	headers := func(contentType, cte string) []*gmailapi.MessagePartHeader {
+		return []*gmailapi.MessagePartHeader{
+			{Name: "Content-Type", Value: contentType},
+			{Name: "Content-Transfer-Encoding", Value: cte},
+		}
+	}
+	tests := []struct {
+		name    string
+		part    *gmailapi.MessagePart
+		want    string
+		wantAlt string // html, when the part is text/html
+	}{
+		{
+			name: "quoted-printable utf-8",
+			part: &gmailapi.MessagePart{
+				MimeType: "text/plain",
+				Headers:  headers(`text/plain; charset="utf-8"`, "quoted-printable"),
+				Body:     &gmailapi.MessagePartBody{Data: "Q2FmPUMzPUE5IGF1IGxhaXQsIHRyPUMzPUE4cw0KYm9uID0zRCBzdXBlcj0NCiBsb25n"},
+			},
+			want: "Café au lait, très\r\nbon = super long",
+		},
+		{
+			name: "latin-1 charset",
+			part: &gmailapi.MessagePart{
+				MimeType: "text/plain",
+				Headers:  headers("text/plain; charset=ISO-8859-1", "8bit"),
+				Body:     &gmailapi.MessagePartBody{Data: "Q2Fm6SBjcuhtZQ"},
+			},
+			want: "Café crème",
+		},
+		{
+			name: "inner base64",
+			part: &gmailapi.MessagePart{
+				MimeType: "text/plain",
+				Headers:  headers("text/plain; charset=utf-8", "base64"),
+				Body:     &gmailapi.MessagePartBody{Data: "UjNMRA0Kdk1PZlpRPT0"},
+			},
+			want: "Grüße",
+		},
+		{
+			name: "koi8-r html",
+			part: &gmailapi.MessagePart{
+				MimeType: "text/html",
+				Headers:  headers("text/html; charset=koi8-r", "7bit"),
+				Body:     &gmailapi.MessagePartBody{Data: "8NLJ18XU"},
+			},
+			wantAlt: "Привет",
+		},
+		{
+			name: "unknown charset passes through",
+			part: &gmailapi.MessagePart{
+				MimeType: "text/plain",
+				Headers:  headers("text/plain; charset=x-made-up", "7bit"),
+				Body:     &gmailapi.MessagePartBody{Data: "SGVsbG8"},
+			},
+			want: "Hello",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, html, _ := extractBody(tt.part)
+			if text != tt.want || html != tt.wantAlt {
+				t.Errorf("extractBody() = %q, %q; want %q, %q", text, html, tt.want, tt.wantAlt)
+			}
+		})
+	}
+}
+
 func TestMapMessage(t *testing.T) {
 	msg := &gmailapi.Message{
 		Id:       "msg123",
@@ -317,6 +387,276 @@ func TestMapMessage(t *testing.T) {
 	}
 }
 
+func TestMapMessage_EncodedWords(t *testing.T) {
+	msg := &gmailapi.Message{
+		Id: "msg1",
+		Payload: &gmailapi.MessagePart{
+			Headers: []*gmailapi.MessagePartHeader{
+				// Two adjacent B-encoded words split mid-phrase.
+				{Name: "Subject", Value: "=?UTF-8?B?R3LDvMOfZSBhdXMg?= =?UTF-8?B?TcO8bmNoZW4=?="},
+				{Name: "From", Value: "=?UTF-8?Q?J=C3=BCrgen_M=C3=BCller?= <juergen@example.com>"},
+				{Name: "To", Value: "=?iso-8859-1?q?Andr=E9?= <andre@example.com>, Bob <bob@example.com>"},
+				{Name: "Cc", Value: `"=?UTF-8?Q?Zo=C3=AB?=" <zoe@example.com>`},
+			},
+		},
+	}
+
+	email := mapMessage(msg)
+	if want := "Grüße aus München"; email.Subject != want {
+		t.Errorf("Subject = %q, want %q", email.Subject, want)
+	}
+	if want := "Jürgen Müller"; email.From.Name != want || email.From.Email != "juergen@example.com" {
+		t.Errorf("From = %+v, want %q <juergen@example.com>", email.From, want)
+	}
+	if len(email.To) != 2 || email.To[0].Name != "André" || email.To[1].Name != "Bob" {
+		t.Errorf("To = %+v, want André and Bob", email.To)
+	}
+	if len(email.CC) != 1 || email.CC[0].Name != "Zoë" {
+		t.Errorf("CC = %+v, want Zoë", email.CC)
+	}
+}
+
+func TestDecodeHeader(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Plain subject", "Plain subject"},
+		{"=?UTF-8?B?8J+OiSBQYXJ0eQ==?=", "🎉 Party"},
+		{"Re: =?UTF-8?Q?caf=C3=A9?= plans", "Re: café plans"},
+		{"=?windows-1252?Q?=93Quoted=94?=", "“Quoted”"},
+		// An unknown charset is left encoded rather than dropped.
+		{"=?x-unknown?Q?abc?=", "=?x-unknown?Q?abc?="},
+	}
+	for _, tt := range tests {
+		if got := decodeHeader(tt.input); got != tt.want {
+			t.Errorf("decodeHeader(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMapMessage_ListUnsubscribe(t *testing.T) {
+	msg := &gmailapi.Message{
+		Id: "msg1",
+		Payload: &gmailapi.MessagePart{
+			MimeType: "text/plain",
+			Headers: []*gmailapi.MessagePartHeader{
+				{Name: "List-Unsubscribe", Value: "<mailto:leave@example.com>, <https://example.com/u>"},
+			},
+			Body: &gmailapi.MessagePartBody{},
+		},
+	}
+
+	email := mapMessage(msg)
+	want := "<mailto:leave@example.com>, <https://example.com/u>"
+	if email.ListUnsubscribe != want {
+		t.Errorf("ListUnsubscribe = %q, want %q", email.ListUnsubscribe, want)
+	}
+}
+
+func TestMapMessage_MailingList(t *testing.T) {
+	msg := &gmailapi.Message{
+		Id: "msg1",
+		Payload: &gmailapi.MessagePart{
+			MimeType: "text/plain",
+			Headers: []*gmailapi.MessagePartHeader{
+				{Name: "From", Value: "Alice <alice@example.com>"},
+				{Name: "List-Id", Value: `"Go Nuts" <golang-nuts.googlegroups.com>`},
+				{Name: "List-Post", Value: "<mailto:golang-nuts@googlegroups.com>"},
+			},
+			Body: &gmailapi.MessagePartBody{},
+		},
+	}
+
+	email := mapMessage(msg)
+	list, ok := email.MailingList()
+	if !ok {
+		t.Fatal("MailingList() ok = false, want true")
+	}
+	if list.ID != "golang-nuts.googlegroups.com" {
+		t.Errorf("list ID = %q, want %q", list.ID, "golang-nuts.googlegroups.com")
+	}
+	if list.Name != "Go Nuts" {
+		t.Errorf("list Name = %q, want %q", list.Name, "Go Nuts")
+	}
+	if got := email.ReplyRecipient().Email; got != "golang-nuts@googlegroups.com" {
+		t.Errorf("ReplyRecipient() = %q, want %q", got, "golang-nuts@googlegroups.com")
+	}
+}
+
+func TestMapMessage_ReceivedAt(t *testing.T) {
+	msg := &gmailapi.Message{
+		Id:           "msg1",
+		InternalDate: 1750000000000,
+		Payload: &gmailapi.MessagePart{
+			MimeType: "text/plain",
+			Headers: []*gmailapi.MessagePartHeader{
+				{Name: "Date", Value: "Mon, 1 Jan 2035 00:00:00 +0000"},
+			},
+			Body: &gmailapi.MessagePartBody{},
+		},
+	}
+
+	email := mapMessage(msg)
+	if want := time.UnixMilli(1750000000000); !email.ReceivedAt.Equal(want) {
+		t.Errorf("ReceivedAt = %v, want %v", email.ReceivedAt, want)
+	}
+	if email.Date.Year() != 2035 {
+		t.Errorf("Date = %v, want the header date", email.Date)
+	}
+
+	msg.InternalDate = 0
+	if got := mapMessage(msg).ReceivedAt; !got.IsZero() {
+		t.Errorf("ReceivedAt without internalDate = %v, want zero", got)
+	}
+}
+
+func TestMapMessage_NoDateFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string
+		internalDate int64
+		want         time.Time
+	}{
+		{"header wins", "Mon, 1 Jan 2035 00:00:00 +0000", 1750000000000, time.Date(2035, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"missing header uses internalDate", "", 1750000000000, time.UnixMilli(1750000000000)},
+		{"unparseable header uses internalDate", "sometime last week", 1750000000000, time.UnixMilli(1750000000000)},
+		{"nothing known", "", 0, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []*gmailapi.MessagePartHeader
+			if tt.header != "" {
+				headers = append(headers, &gmailapi.MessagePartHeader{Name: "Date", Value: tt.header})
+			}
+			msg := &gmailapi.Message{
+				Id:           "msg1",
+				InternalDate: tt.internalDate,
+				Payload: &gmailapi.MessagePart{
+					MimeType: "text/plain",
+					Headers:  headers,
+					Body:     &gmailapi.MessagePartBody{},
+				},
+			}
+			if got := mapMessage(msg).Date; !got.Equal(tt.want) {
+				t.Errorf("Date = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapMessage_References(t *testing.T) {
+	msg := &gmailapi.Message{
+		Id: "msg1",
+		Payload: &gmailapi.MessagePart{
+			MimeType: "text/plain",
+			Headers: []*gmailapi.MessagePartHeader{
+				{Name: "Message-Id", Value: "<c@example.com>"},
+				{Name: "References", Value: "<a@example.com>\r\n <b@example.com>"},
+			},
+			Body: &gmailapi.MessagePartBody{},
+		},
+	}
+
+	email := mapMessage(msg)
+	if email.MessageID != "<c@example.com>" {
+		t.Errorf("MessageID = %q, want %q", email.MessageID, "<c@example.com>")
+	}
+	if len(email.References) != 2 || email.References[0] != "<a@example.com>" || email.References[1] != "<b@example.com>" {
+		t.Errorf("References = %v, want [<a@example.com> <b@example.com>]", email.References)
+	}
+}
+
+const sampleICS = "BEGIN:VCALENDAR\r\n" +
+	"METHOD:REQUEST\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Quarterly planning\\, Q3\r\n" +
+	"DTSTART:20240115T150000Z\r\n" +
+	"DTEND:20240115T160000Z\r\n" +
+	"LOCATION:Room 4\r\n" +
+	"ORGANIZER;CN=Alice Smith:mailto:alice@example.com\r\n" +
+	"DESCRIPTION:A long description that is folded\r\n" +
+	"  across two lines\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestMapMessage_CalendarInvite(t *testing.T) {
+	msg := &gmailapi.Message{
+		Id: "msg1",
+		Payload: &gmailapi.MessagePart{
+			MimeType: "multipart/mixed",
+			Parts: []*gmailapi.MessagePart{
+				{
+					MimeType: "text/plain",
+					Body:     &gmailapi.MessagePartBody{Data: "SGVsbG8"},
+				},
+				{
+					MimeType: "text/calendar",
+					Body: &gmailapi.MessagePartBody{
+						Data: base64.URLEncoding.WithPadding(base64.NoPadding).EncodeToString([]byte(sampleICS)),
+					},
+				},
+			},
+		},
+	}
+
+	email := mapMessage(msg)
+	if email.Body != "Hello" {
+		t.Errorf("Body = %q, want %q", email.Body, "Hello")
+	}
+	ev := email.Event
+	if ev == nil {
+		t.Fatal("Event = nil, want parsed calendar event")
+	}
+	if ev.Method != "REQUEST" {
+		t.Errorf("Method = %q, want %q", ev.Method, "REQUEST")
+	}
+	if ev.Summary != "Quarterly planning, Q3" {
+		t.Errorf("Summary = %q, want %q", ev.Summary, "Quarterly planning, Q3")
+	}
+	if ev.Location != "Room 4" {
+		t.Errorf("Location = %q, want %q", ev.Location, "Room 4")
+	}
+	if ev.Organizer.Name != "Alice Smith" || ev.Organizer.Email != "alice@example.com" {
+		t.Errorf("Organizer = %+v, want Alice Smith <alice@example.com>", ev.Organizer)
+	}
+	wantStart := time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC)
+	if !ev.Start.Equal(wantStart) {
+		t.Errorf("Start = %v, want %v", ev.Start, wantStart)
+	}
+	if !ev.End.Equal(wantStart.Add(time.Hour)) {
+		t.Errorf("End = %v, want %v", ev.End, wantStart.Add(time.Hour))
+	}
+}
+
+func TestParseCalendar_AllDayAndTZID(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:Offsite\nDTSTART;VALUE=DATE:20240301\nEND:VEVENT\nEND:VCALENDAR\n"
+	ev := parseCalendar(ics)
+	if ev == nil {
+		t.Fatal("parseCalendar() = nil")
+	}
+	if !ev.AllDay {
+		t.Error("AllDay = false, want true")
+	}
+	if ev.Start.Year() != 2024 || ev.Start.Month() != time.March || ev.Start.Day() != 1 {
+		t.Errorf("Start = %v, want 2024-03-01", ev.Start)
+	}
+
+	ics = "BEGIN:VEVENT\nDTSTART;TZID=America/New_York:20240115T100000\nEND:VEVENT\n"
+	ev = parseCalendar(ics)
+	if ev == nil {
+		t.Fatal("parseCalendar() = nil")
+	}
+	want := time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC)
+	if !ev.Start.Equal(want) {
+		t.Errorf("Start = %v, want %v", ev.Start.UTC(), want)
+	}
+
+	if parseCalendar("BEGIN:VCALENDAR\nEND:VCALENDAR\n") != nil {
+		t.Error("expected nil for calendar without VEVENT")
+	}
+}
+
 func TestMapMessage_IsRead(t *testing.T) {
 	// UNREAD label present means IsRead = false
 	msg := &gmailapi.Message{
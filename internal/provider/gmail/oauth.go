@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 
+	"github.com/lu-zhengda/termail/internal/store"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	gmailapi "google.golang.org/api/gmail/v1"
@@ -25,6 +26,27 @@ var oauthConfig = &oauth2.Config{
 	Endpoint: google.Endpoint,
 }
 
+// scopeReasons explains why termail needs each OAuth scope it requests.
+var scopeReasons = map[string]string{
+	gmailapi.GmailReadonlyScope: "read your mail and labels to sync them into the local database",
+	gmailapi.GmailSendScope:     "send mail you compose, reply to or forward",
+	gmailapi.GmailModifyScope:   "archive, trash, label, star and mark your mail read",
+}
+
+// ScopeSummary returns a line for each OAuth scope Authenticate requests,
+// naming the scope and why termail needs it.
+func ScopeSummary() []string {
+	lines := make([]string, 0, len(oauthConfig.Scopes))
+	for _, scope := range oauthConfig.Scopes {
+		reason, ok := scopeReasons[scope]
+		if !ok {
+			reason = "requested by termail"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", scope, reason))
+	}
+	return lines
+}
+
 // SetCredentials sets the OAuth client ID and secret.
 func SetCredentials(clientID, clientSecret string) {
 	oauthConfig.ClientID = clientID
@@ -46,6 +68,36 @@ func EnsureCredentials() error {
 	return fmt.Errorf("gmail OAuth credentials not configured; set them in ~/.config/termail/config.toml under [gmail] or via GMAIL_CLIENT_ID / GMAIL_CLIENT_SECRET env vars")
 }
 
+// savingTokenSource saves every token minted by src to the token store, so
+// refreshed access tokens and rotated refresh tokens survive restarts.
+type savingTokenSource struct {
+	src        oauth2.TokenSource
+	tokenStore store.TokenStore
+	accountID  string
+}
+
+func (s *savingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tokenStore.SaveToken(s.accountID, token); err != nil {
+		return nil, fmt.Errorf("failed to save refreshed token: %w", err)
+	}
+	return token, nil
+}
+
+// persistentTokenSource returns a token source that reuses token while it
+// is valid and otherwise fetches a new one from src, saving it to
+// tokenStore under accountID.
+func persistentTokenSource(token *oauth2.Token, src oauth2.TokenSource, tokenStore store.TokenStore, accountID string) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(token, &savingTokenSource{
+		src:        src,
+		tokenStore: tokenStore,
+		accountID:  accountID,
+	})
+}
+
 func authenticate(ctx context.Context) (*oauth2.Token, error) {
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
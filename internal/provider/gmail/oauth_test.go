@@ -0,0 +1,94 @@
+package gmail
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/store"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/oauth2"
+)
+
+// rotatingTokenSource mints a new access token and refresh token per call.
+type rotatingTokenSource struct {
+	calls int
+}
+
+func (r *rotatingTokenSource) Token() (*oauth2.Token, error) {
+	r.calls++
+	return &oauth2.Token{
+		AccessToken:  "access-2",
+		RefreshToken: "refresh-2",
+		Expiry:       time.Now().Add(time.Hour),
+	}, nil
+}
+
+func TestPersistentTokenSource_SavesRefreshedToken(t *testing.T) {
+	keyring.MockInit()
+	ts := store.NewKeyringTokenStore()
+
+	stale := &oauth2.Token{
+		AccessToken:  "access-1",
+		RefreshToken: "refresh-1",
+		Expiry:       time.Now().Add(-time.Minute),
+	}
+	if err := ts.SaveToken("acc-1", stale); err != nil {
+		t.Fatalf("SaveToken() error: %v", err)
+	}
+
+	src := &rotatingTokenSource{}
+	source := persistentTokenSource(stale, src, ts, "acc-1")
+	for range 2 {
+		token, err := source.Token()
+		if err != nil {
+			t.Fatalf("Token() error: %v", err)
+		}
+		if token.AccessToken != "access-2" {
+			t.Errorf("AccessToken = %q, want access-2", token.AccessToken)
+		}
+	}
+	if src.calls != 1 {
+		t.Errorf("refreshes = %d, want 1 (the new token is reused)", src.calls)
+	}
+
+	saved, err := ts.LoadToken("acc-1")
+	if err != nil {
+		t.Fatalf("LoadToken() error: %v", err)
+	}
+	if saved.AccessToken != "access-2" || saved.RefreshToken != "refresh-2" {
+		t.Errorf("keyring token = %q/%q, want access-2/refresh-2", saved.AccessToken, saved.RefreshToken)
+	}
+}
+
+func TestPersistentTokenSource_ValidTokenNotSaved(t *testing.T) {
+	keyring.MockInit()
+	ts := store.NewKeyringTokenStore()
+
+	valid := &oauth2.Token{AccessToken: "access-1", Expiry: time.Now().Add(time.Hour)}
+	src := &rotatingTokenSource{}
+	if _, err := persistentTokenSource(valid, src, ts, "acc-1").Token(); err != nil {
+		t.Fatalf("Token() error: %v", err)
+	}
+	if src.calls != 0 {
+		t.Errorf("refreshes = %d, want 0", src.calls)
+	}
+	if _, err := ts.LoadToken("acc-1"); err == nil {
+		t.Error("an unrefreshed token should not be written to the keyring")
+	}
+}
+
+func TestScopeSummary_MatchesRequestedScopes(t *testing.T) {
+	summary := ScopeSummary()
+	if len(summary) != len(oauthConfig.Scopes) {
+		t.Fatalf("summary has %d lines, want one per scope: %q", len(summary), summary)
+	}
+	for i, scope := range oauthConfig.Scopes {
+		if !strings.HasPrefix(summary[i], scope+": ") {
+			t.Errorf("summary[%d] = %q, want it to name %s", i, summary[i], scope)
+		}
+		if _, ok := scopeReasons[scope]; !ok {
+			t.Errorf("scope %s has no reason to show the user", scope)
+		}
+	}
+}
@@ -19,19 +19,30 @@ type EmailProvider interface {
 
 	ListMessages(ctx context.Context, opts ListOptions) ([]domain.Email, string, error)
 	GetMessage(ctx context.Context, id string) (*domain.Email, error)
+	GetMessages(ctx context.Context, ids []string) ([]domain.Email, error)
+	// GetAttachment returns the content of the attachment attachmentID
+	// of message msgID.
+	GetAttachment(ctx context.Context, msgID, attachmentID string) ([]byte, error)
+	// SendMessage sends email. When the provider reports the sent
+	// message, email's ID, ThreadID and Labels are updated to match it.
 	SendMessage(ctx context.Context, email *domain.Email) error
 
 	ListThreads(ctx context.Context, opts ListOptions) ([]domain.Thread, string, error)
 	GetThread(ctx context.Context, id string) (*domain.Thread, error)
 
 	ModifyLabels(ctx context.Context, msgID string, add, remove []string) error
+	BatchModifyLabels(ctx context.Context, msgIDs []string, add, remove []string) error
 	TrashMessage(ctx context.Context, msgID string) error
 	MarkRead(ctx context.Context, msgID string, read bool) error
 
 	ListLabels(ctx context.Context) ([]domain.Label, error)
+	CreateLabel(ctx context.Context, name string) (domain.Label, error)
+	DeleteLabel(ctx context.Context, labelID string) error
 	Search(ctx context.Context, query string, opts ListOptions) ([]domain.Email, string, error)
 
 	History(ctx context.Context, startHistoryID uint64) ([]HistoryEvent, uint64, error)
+
+	GetProfile(ctx context.Context) (*domain.Profile, error)
 }
 
 type HistoryEventType int
@@ -0,0 +1,278 @@
+// Package rfc822 serializes domain emails into RFC 5322 messages.
+package rfc822
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+// Encoding is the Content-Transfer-Encoding used for the message body.
+type Encoding string
+
+const (
+	// EncodingAuto sends ASCII bodies as 7bit, mostly-ASCII ones (such as
+	// accented Latin text) as quoted-printable to keep them readable, and
+	// anything else as base64.
+	EncodingAuto            Encoding = "auto"
+	EncodingQuotedPrintable Encoding = "quoted-printable"
+	EncodingBase64          Encoding = "base64"
+)
+
+// LineEnding is the line terminator used throughout a message.
+type LineEnding string
+
+const (
+	CRLF LineEnding = "crlf" // as RFC 5322 requires; the default
+	LF   LineEnding = "lf"   // for mbox files and Unix tools
+)
+
+// Options controls how Build serializes a message. The zero value uses
+// EncodingAuto and CRLF.
+type Options struct {
+	Encoding   Encoding
+	LineEnding LineEnding
+}
+
+// maxLineLength is the longest line RFC 5322 allows, excluding the CRLF.
+const maxLineLength = 998
+
+// base64LineLength is the line length of base64 bodies (RFC 2045).
+const base64LineLength = 76
+
+// ParseEncoding parses an encoding name; an empty name means EncodingAuto.
+func ParseEncoding(s string) (Encoding, error) {
+	switch e := Encoding(strings.ToLower(strings.TrimSpace(s))); e {
+	case "":
+		return EncodingAuto, nil
+	case EncodingAuto, EncodingQuotedPrintable, EncodingBase64:
+		return e, nil
+	default:
+		return "", fmt.Errorf("invalid encoding %q: use auto, quoted-printable or base64", s)
+	}
+}
+
+// ParseLineEnding parses a line ending name; an empty name means CRLF.
+func ParseLineEnding(s string) (LineEnding, error) {
+	switch l := LineEnding(strings.ToLower(strings.TrimSpace(s))); l {
+	case "":
+		return CRLF, nil
+	case CRLF, LF:
+		return l, nil
+	default:
+		return "", fmt.Errorf("invalid line ending %q: use crlf or lf", s)
+	}
+}
+
+// Build constructs an RFC 5322 message from a domain Email with the default
+// Options: CRLF line endings and an encoding chosen from the body.
+func Build(email *domain.Email) string {
+	return BuildWith(email, Options{})
+}
+
+// BuildWith constructs an RFC 5322 message from a domain Email. Non-ASCII
+// header text is encoded as RFC 2047 encoded-words and the body with
+// opts.Encoding, so the message is 7-bit clean whatever its content.
+func BuildWith(email *domain.Email, opts Options) string {
+	eol := "\r\n"
+	if opts.LineEnding == LF {
+		eol = "\n"
+	}
+
+	var b strings.Builder
+	header := func(name, value string) {
+		b.WriteString(name + ": " + value + eol)
+	}
+
+	header("From", email.From.String())
+	header("To", joinAddresses(email.To))
+
+	if len(email.CC) > 0 {
+		header("Cc", joinAddresses(email.CC))
+	}
+	if len(email.BCC) > 0 {
+		header("Bcc", joinAddresses(email.BCC))
+	}
+	if len(email.ReplyTo) > 0 {
+		header("Reply-To", joinAddresses(email.ReplyTo))
+	}
+
+	header("Subject", mime.QEncoding.Encode("utf-8", email.Subject))
+
+	if !email.Date.IsZero() {
+		header("Date", email.Date.Format(time.RFC1123Z))
+	}
+	if email.MessageID != "" {
+		header("Message-ID", email.MessageID)
+	}
+	if email.InReplyTo != "" {
+		header("In-Reply-To", email.InReplyTo)
+	}
+	if len(email.References) > 0 {
+		header("References", strings.Join(email.References, " "))
+	}
+
+	cte, body := encodeBody(email.Body, opts.Encoding)
+	header("MIME-Version", "1.0")
+	attachments := outgoingAttachments(email.Attachments)
+	if len(attachments) == 0 {
+		header("Content-Type", "text/plain; charset=\"UTF-8\"")
+		header("Content-Transfer-Encoding", cte)
+		b.WriteString(eol)
+		b.WriteString(strings.ReplaceAll(body, "\r\n", eol))
+		return b.String()
+	}
+
+	// With attachments the body becomes the first part of a multipart/mixed
+	// message and each attachment a base64 part after it.
+	boundary := newBoundary()
+	header("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": boundary}))
+	b.WriteString(eol)
+	part := func(headers []string, content string) {
+		b.WriteString("--" + boundary + eol)
+		for _, h := range headers {
+			b.WriteString(h + eol)
+		}
+		b.WriteString(eol)
+		b.WriteString(strings.ReplaceAll(content, "\r\n", eol))
+		b.WriteString(eol)
+	}
+	part([]string{
+		"Content-Type: text/plain; charset=\"UTF-8\"",
+		"Content-Transfer-Encoding: " + cte,
+	}, body)
+	for _, a := range attachments {
+		part([]string{
+			"Content-Type: " + attachmentType(a),
+			"Content-Disposition: " + mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}),
+			"Content-Transfer-Encoding: base64",
+		}, base64Data(a.Data))
+	}
+	b.WriteString("--" + boundary + "--" + eol)
+
+	return b.String()
+}
+
+// outgoingAttachments returns the attachments that carry content to send.
+// Those of synced mail have none and are left out.
+func outgoingAttachments(attachments []domain.Attachment) []domain.Attachment {
+	var out []domain.Attachment
+	for _, a := range attachments {
+		if a.Data != nil {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// attachmentType returns the Content-Type of an attachment's part, naming
+// the file as older clients expect. An unknown type is sent as
+// application/octet-stream.
+func attachmentType(a domain.Attachment) string {
+	mediaType := a.MIMEType
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	if t := mime.FormatMediaType(mediaType, map[string]string{"name": a.Filename}); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// newBoundary returns a random multipart boundary, which cannot occur in
+// the base64 and quoted-printable parts it separates.
+func newBoundary() string {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	return "termail-" + hex.EncodeToString(buf[:])
+}
+
+// base64Data encodes data as base64 wrapped at base64LineLength columns.
+func base64Data(data []byte) string {
+	enc := base64.StdEncoding.EncodeToString(data)
+	var b strings.Builder
+	for len(enc) > base64LineLength {
+		b.WriteString(enc[:base64LineLength] + "\r\n")
+		enc = enc[base64LineLength:]
+	}
+	b.WriteString(enc)
+	return b.String()
+}
+
+// encodeBody returns the Content-Transfer-Encoding for body under enc and
+// body encoded with it, with CRLF line endings.
+func encodeBody(body string, enc Encoding) (string, string) {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	switch enc {
+	case EncodingQuotedPrintable:
+		return string(EncodingQuotedPrintable), quotedPrintable(body)
+	case EncodingBase64:
+		return string(EncodingBase64), base64Lines(body)
+	}
+
+	if is7Bit(body) {
+		return "7bit", strings.ReplaceAll(body, "\n", "\r\n")
+	}
+	// Quoted-printable triples each 8-bit byte, so past about a third of
+	// them base64 is shorter.
+	eightBit := 0
+	for i := 0; i < len(body); i++ {
+		if body[i] >= utf8.RuneSelf {
+			eightBit++
+		}
+	}
+	if eightBit*3 <= len(body) {
+		return string(EncodingQuotedPrintable), quotedPrintable(body)
+	}
+	return string(EncodingBase64), base64Lines(body)
+}
+
+// is7Bit reports whether body can be sent unencoded: ASCII only, without
+// NULs, bare CRs or lines too long for RFC 5322.
+func is7Bit(body string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		if len(line) > maxLineLength {
+			return false
+		}
+	}
+	for i := 0; i < len(body); i++ {
+		if c := body[i]; c >= utf8.RuneSelf || c == 0 || c == '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// quotedPrintable encodes body as quoted-printable text, whose line breaks
+// become CRLF.
+func quotedPrintable(body string) string {
+	var buf bytes.Buffer
+	w := quotedprintable.NewWriter(&buf)
+	// Writes to a bytes.Buffer cannot fail.
+	_, _ = w.Write([]byte(body))
+	_ = w.Close()
+	return buf.String()
+}
+
+// base64Lines encodes body, in canonical CRLF form, as base64 wrapped at
+// base64LineLength columns.
+func base64Lines(body string) string {
+	return base64Data([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+}
+
+func joinAddresses(addrs []domain.Address) string {
+	parts := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		parts = append(parts, a.String())
+	}
+	return strings.Join(parts, ", ")
+}
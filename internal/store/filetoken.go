@@ -0,0 +1,71 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// FileTokenStore persists OAuth2 tokens as JSON files in a directory readable
+// only by the current user. It is a fallback for systems without a usable
+// OS keyring, such as headless Linux machines.
+type FileTokenStore struct {
+	dir string
+}
+
+// NewFileTokenStore returns a FileTokenStore that keeps tokens in dir.
+func NewFileTokenStore(dir string) *FileTokenStore {
+	return &FileTokenStore{dir: dir}
+}
+
+// SaveToken writes the token for the account ID to disk.
+func (f *FileTokenStore) SaveToken(accountID string, token *oauth2.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token: %w", err)
+	}
+	if err := os.MkdirAll(f.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create token directory: %w", err)
+	}
+	if err := os.WriteFile(f.path(accountID), data, 0o600); err != nil {
+		return fmt.Errorf("failed to save token file: %w", err)
+	}
+	return nil
+}
+
+// LoadToken reads the token for the account ID from disk.
+func (f *FileTokenStore) LoadToken(accountID string) (*oauth2.Token, error) {
+	data, err := os.ReadFile(f.path(accountID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to load token file: %w", ErrTokenNotFound)
+		}
+		return nil, fmt.Errorf("failed to load token file: %w", err)
+	}
+	var token oauth2.Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token: %w", err)
+	}
+	return &token, nil
+}
+
+// DeleteToken removes the token file for the account ID.
+func (f *FileTokenStore) DeleteToken(accountID string) error {
+	if err := os.Remove(f.path(accountID)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete token file: %w", ErrTokenNotFound)
+		}
+		return fmt.Errorf("failed to delete token file: %w", err)
+	}
+	return nil
+}
+
+func (f *FileTokenStore) path(accountID string) string {
+	name := strings.NewReplacer("/", "_", `\`, "_").Replace(accountID)
+	return filepath.Join(f.dir, name+".json")
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/zalando/go-keyring"
@@ -10,6 +11,16 @@ import (
 
 const serviceName = "termail"
 
+// ErrKeyringUnavailable is returned when the OS keyring cannot be reached,
+// for example when the Secret Service is not running or the keychain is locked.
+var ErrKeyringUnavailable = errors.New("system keyring is unavailable")
+
+// ErrTokenNotFound is returned when no token is stored for an account.
+var ErrTokenNotFound = errors.New("no token stored for account")
+
+// keyringHint tells the user how to recover from an unavailable keyring.
+const keyringHint = `unlock your keyring, or store tokens in a file by setting token_store = "file" under [auth] in config.toml (or TERMAIL_TOKEN_STORE=file) and re-running 'termail account add'`
+
 // KeyringTokenStore persists OAuth2 tokens in the OS keyring
 // (macOS Keychain, Windows Credential Manager, or Linux Secret Service).
 type KeyringTokenStore struct{}
@@ -26,7 +37,7 @@ func (k *KeyringTokenStore) SaveToken(accountID string, token *oauth2.Token) err
 		return fmt.Errorf("failed to marshal token: %w", err)
 	}
 	if err := keyring.Set(serviceName, accountID, string(data)); err != nil {
-		return fmt.Errorf("failed to save token to keyring: %w", err)
+		return fmt.Errorf("failed to save token to keyring: %w", keyringError(err))
 	}
 	return nil
 }
@@ -35,7 +46,7 @@ func (k *KeyringTokenStore) SaveToken(accountID string, token *oauth2.Token) err
 func (k *KeyringTokenStore) LoadToken(accountID string) (*oauth2.Token, error) {
 	data, err := keyring.Get(serviceName, accountID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load token from keyring: %w", err)
+		return nil, fmt.Errorf("failed to load token from keyring: %w", keyringError(err))
 	}
 	var token oauth2.Token
 	if err := json.Unmarshal([]byte(data), &token); err != nil {
@@ -47,7 +58,16 @@ func (k *KeyringTokenStore) LoadToken(accountID string) (*oauth2.Token, error) {
 // DeleteToken removes the OAuth2 token for the given account ID from the OS keyring.
 func (k *KeyringTokenStore) DeleteToken(accountID string) error {
 	if err := keyring.Delete(serviceName, accountID); err != nil {
-		return fmt.Errorf("failed to delete token from keyring: %w", err)
+		return fmt.Errorf("failed to delete token from keyring: %w", keyringError(err))
 	}
 	return nil
 }
+
+// keyringError classifies a go-keyring error: a missing entry maps to
+// ErrTokenNotFound, anything else means the keyring itself is unusable.
+func keyringError(err error) error {
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrTokenNotFound
+	}
+	return fmt.Errorf("%w (%v); %s", ErrKeyringUnavailable, err, keyringHint)
+}
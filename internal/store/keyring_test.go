@@ -0,0 +1,69 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/oauth2"
+)
+
+func TestKeyringTokenStore_Unavailable(t *testing.T) {
+	keyring.MockInitWithError(errors.New("dbus: no Secret Service"))
+	t.Cleanup(keyring.MockInit)
+
+	_, err := NewKeyringTokenStore().LoadToken("user@example.com")
+	if err == nil {
+		t.Fatal("LoadToken() expected error, got nil")
+	}
+	if !errors.Is(err, ErrKeyringUnavailable) {
+		t.Errorf("LoadToken() error = %v, want ErrKeyringUnavailable", err)
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "no Secret Service") {
+		t.Errorf("error should include the underlying cause: %q", msg)
+	}
+	if !strings.Contains(msg, `token_store = "file"`) {
+		t.Errorf("error should suggest the file token store: %q", msg)
+	}
+}
+
+func TestKeyringTokenStore_NotFound(t *testing.T) {
+	keyring.MockInit()
+
+	_, err := NewKeyringTokenStore().LoadToken("missing@example.com")
+	if !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("LoadToken() error = %v, want ErrTokenNotFound", err)
+	}
+	if errors.Is(err, ErrKeyringUnavailable) {
+		t.Error("missing token should not be reported as an unavailable keyring")
+	}
+}
+
+func TestFileTokenStore_RoundTrip(t *testing.T) {
+	s := NewFileTokenStore(t.TempDir())
+
+	if _, err := s.LoadToken("user@example.com"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("LoadToken() on empty store error = %v, want ErrTokenNotFound", err)
+	}
+
+	want := &oauth2.Token{AccessToken: "access", RefreshToken: "refresh"}
+	if err := s.SaveToken("user@example.com", want); err != nil {
+		t.Fatalf("SaveToken() error: %v", err)
+	}
+	got, err := s.LoadToken("user@example.com")
+	if err != nil {
+		t.Fatalf("LoadToken() error: %v", err)
+	}
+	if got.AccessToken != want.AccessToken || got.RefreshToken != want.RefreshToken {
+		t.Errorf("LoadToken() = %+v, want %+v", got, want)
+	}
+
+	if err := s.DeleteToken("user@example.com"); err != nil {
+		t.Fatalf("DeleteToken() error: %v", err)
+	}
+	if _, err := s.LoadToken("user@example.com"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("LoadToken() after delete error = %v, want ErrTokenNotFound", err)
+	}
+}
@@ -2,12 +2,18 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
 )
 
 func (s *DB) CreateAccount(ctx context.Context, acct *domain.Account) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	_, err := s.db.ExecContext(ctx,
 		`INSERT INTO accounts (id, email, provider, display_name) VALUES (?, ?, ?, ?)`,
 		acct.ID, acct.Email, acct.Provider, acct.DisplayName,
@@ -21,8 +27,12 @@ func (s *DB) CreateAccount(ctx context.Context, acct *domain.Account) error {
 func (s *DB) GetAccount(ctx context.Context, id string) (*domain.Account, error) {
 	var a domain.Account
 	err := s.db.QueryRowContext(ctx,
-		`SELECT id, email, provider, display_name, created_at FROM accounts WHERE id = ?`, id,
-	).Scan(&a.ID, &a.Email, &a.Provider, &a.DisplayName, &a.CreatedAt)
+		`SELECT id, email, provider, display_name, created_at, COALESCE(messages_total, 0)
+		FROM accounts WHERE id = ?`, id,
+	).Scan(&a.ID, &a.Email, &a.Provider, &a.DisplayName, &a.CreatedAt, &a.MessagesTotal)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("account %s: %w", id, store.ErrNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get account %s: %w", id, err)
 	}
@@ -31,7 +41,8 @@ func (s *DB) GetAccount(ctx context.Context, id string) (*domain.Account, error)
 
 func (s *DB) ListAccounts(ctx context.Context) ([]domain.Account, error) {
 	rows, err := s.db.QueryContext(ctx,
-		`SELECT id, email, provider, display_name, created_at FROM accounts ORDER BY created_at`,
+		`SELECT id, email, provider, display_name, created_at, COALESCE(messages_total, 0)
+		FROM accounts ORDER BY created_at`,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list accounts: %w", err)
@@ -41,7 +52,7 @@ func (s *DB) ListAccounts(ctx context.Context) ([]domain.Account, error) {
 	var accounts []domain.Account
 	for rows.Next() {
 		var a domain.Account
-		if err := rows.Scan(&a.ID, &a.Email, &a.Provider, &a.DisplayName, &a.CreatedAt); err != nil {
+		if err := rows.Scan(&a.ID, &a.Email, &a.Provider, &a.DisplayName, &a.CreatedAt, &a.MessagesTotal); err != nil {
 			return nil, fmt.Errorf("failed to scan account: %w", err)
 		}
 		accounts = append(accounts, a)
@@ -49,7 +60,39 @@ func (s *DB) ListAccounts(ctx context.Context) ([]domain.Account, error) {
 	return accounts, rows.Err()
 }
 
+// SetAccountMessagesTotal records the mailbox size last reported by the
+// provider for the account.
+func (s *DB) SetAccountMessagesTotal(ctx context.Context, id string, total int64) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	_, err := s.db.ExecContext(ctx, `UPDATE accounts SET messages_total = ? WHERE id = ?`, total, id)
+	if err != nil {
+		return fmt.Errorf("failed to set messages total for account %s: %w", id, err)
+	}
+	return nil
+}
+
+// UnreadCount returns how many of the account's INBOX emails are unread.
+// An account that has never synced has none.
+func (s *DB) UnreadCount(ctx context.Context, accountID string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM emails e
+		JOIN email_labels el ON el.email_id = e.id AND el.label_id = ?
+		WHERE e.account_id = ? AND e.is_read = 0`,
+		domain.LabelInbox, accountID,
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread mail for account %s: %w", accountID, err)
+	}
+	return n, nil
+}
+
 func (s *DB) DeleteAccount(ctx context.Context, id string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	_, err := s.db.ExecContext(ctx, `DELETE FROM accounts WHERE id = ?`, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete account %s: %w", id, err)
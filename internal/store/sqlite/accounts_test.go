@@ -2,9 +2,12 @@ package sqlite
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
 )
 
 func newTestDB(t *testing.T) *DB {
@@ -43,6 +46,14 @@ func TestCreateAccount(t *testing.T) {
 	}
 }
 
+func TestGetAccount_NotFound(t *testing.T) {
+	db := newTestDB(t)
+
+	if _, err := db.GetAccount(context.Background(), "missing"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetAccount(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
 func TestListAccounts(t *testing.T) {
 	db := newTestDB(t)
 	ctx := context.Background()
@@ -76,3 +87,53 @@ func TestDeleteAccount(t *testing.T) {
 		t.Errorf("got %d accounts after delete, want 0", len(accounts))
 	}
 }
+
+func TestSetAccountMessagesTotal(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	db.CreateAccount(ctx, &domain.Account{ID: "a1", Email: "a@test.com", Provider: "gmail"})
+	got, err := db.GetAccount(ctx, "a1")
+	if err != nil {
+		t.Fatalf("GetAccount() error: %v", err)
+	}
+	if got.MessagesTotal != 0 {
+		t.Errorf("MessagesTotal before sync = %d, want 0", got.MessagesTotal)
+	}
+
+	if err := db.SetAccountMessagesTotal(ctx, "a1", 12345); err != nil {
+		t.Fatalf("SetAccountMessagesTotal() error: %v", err)
+	}
+	accounts, err := db.ListAccounts(ctx)
+	if err != nil {
+		t.Fatalf("ListAccounts() error: %v", err)
+	}
+	if len(accounts) != 1 || accounts[0].MessagesTotal != 12345 {
+		t.Errorf("accounts = %+v, want MessagesTotal 12345", accounts)
+	}
+}
+
+func TestUnreadCount(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	db.CreateAccount(ctx, &domain.Account{ID: "a1", Email: "a@test.com", Provider: "gmail"})
+	db.CreateAccount(ctx, &domain.Account{ID: "a2", Email: "b@test.com", Provider: "gmail"})
+	emails := []domain.Email{
+		{ID: "m1", ThreadID: "t1", Date: time.Now(), Labels: []string{domain.LabelInbox}},
+		{ID: "m2", ThreadID: "t2", Date: time.Now(), Labels: []string{domain.LabelInbox}},
+		{ID: "m3", ThreadID: "t3", Date: time.Now(), IsRead: true, Labels: []string{domain.LabelInbox}},
+		{ID: "m4", ThreadID: "t4", Date: time.Now(), Labels: []string{"Label_news"}},
+	}
+	if err := db.UpsertEmails(ctx, emails, "a1"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+
+	if n, err := db.UnreadCount(ctx, "a1"); err != nil || n != 2 {
+		t.Errorf("UnreadCount(a1) = %d, %v; want 2", n, err)
+	}
+	// Never synced.
+	if n, err := db.UnreadCount(ctx, "a2"); err != nil || n != 0 {
+		t.Errorf("UnreadCount(a2) = %d, %v; want 0", n, err)
+	}
+}
@@ -0,0 +1,136 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+// contactScanLimit caps how many of the account's most recent messages
+// FrequentContacts reads, so suggestions stay quick in large mailboxes.
+const contactScanLimit = 5000
+
+// likeEscaper escapes the LIKE wildcards of a literal pattern, for use with
+// ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// FrequentContacts returns addresses seen in the account's recent mail whose
+// name or email starts with prefix (case-insensitively), most frequent first
+// and then alphabetically by email. The account's own address is excluded.
+// A limit of zero or less returns all matches.
+func (s *DB) FrequentContacts(ctx context.Context, accountID, prefix string, limit int) ([]domain.Address, error) {
+	query := `
+		SELECT from_addr, from_name, to_addrs, cc_addrs, date
+		FROM emails WHERE account_id = ?`
+	args := []any{accountID}
+	if trimmed := strings.TrimSpace(prefix); trimmed != "" {
+		// Only rows that can hold a match are read and their recipients
+		// decoded; contactMatches then checks each address exactly. The
+		// recipient columns are JSON, so they are matched against the
+		// prefix as JSON would encode it.
+		encoded, _ := json.Marshal(trimmed)
+		p := likeEscaper.Replace(trimmed)
+		j := likeEscaper.Replace(strings.Trim(string(encoded), `"`))
+		query += ` AND (from_addr LIKE ? ESCAPE '\' OR from_name LIKE ? ESCAPE '\'
+			OR from_name LIKE ? ESCAPE '\' OR to_addrs LIKE ? ESCAPE '\' OR cc_addrs LIKE ? ESCAPE '\')`
+		args = append(args, p+"%", p+"%", "% "+p+"%", "%"+j+"%", "%"+j+"%")
+	}
+	// Read the window oldest first, so each address keeps its first spelling.
+	query = `SELECT from_addr, from_name, to_addrs, cc_addrs FROM (` + query + `
+		ORDER BY date DESC LIMIT ?) ORDER BY date`
+	args = append(args, contactScanLimit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contacts: %w", err)
+	}
+	defer rows.Close()
+
+	type contact struct {
+		addr  domain.Address
+		count int
+	}
+	seen := make(map[string]*contact)
+	add := func(a domain.Address) {
+		key := strings.ToLower(strings.TrimSpace(a.Email))
+		if key == "" || key == strings.ToLower(accountID) {
+			return
+		}
+		c, ok := seen[key]
+		if !ok {
+			c = &contact{addr: domain.Address{Email: strings.TrimSpace(a.Email)}}
+			seen[key] = c
+		}
+		c.count++
+		if c.addr.Name == "" && a.Name != "" {
+			c.addr.Name = a.Name
+		}
+	}
+
+	for rows.Next() {
+		var fromAddr, fromName, toJSON, ccJSON string
+		if err := rows.Scan(&fromAddr, &fromName, &toJSON, &ccJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan contact row: %w", err)
+		}
+		add(domain.Address{Name: fromName, Email: fromAddr})
+		for _, data := range []string{toJSON, ccJSON} {
+			if data == "" {
+				continue
+			}
+			var addrs []domain.Address
+			if err := json.Unmarshal([]byte(data), &addrs); err != nil {
+				continue
+			}
+			for _, a := range addrs {
+				add(a)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate contacts: %w", err)
+	}
+
+	matches := make([]*contact, 0, len(seen))
+	for _, c := range seen {
+		if contactMatches(c.addr, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].count != matches[j].count {
+			return matches[i].count > matches[j].count
+		}
+		return strings.ToLower(matches[i].addr.Email) < strings.ToLower(matches[j].addr.Email)
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	result := make([]domain.Address, len(matches))
+	for i, c := range matches {
+		result[i] = c.addr
+	}
+	return result, nil
+}
+
+// contactMatches reports whether prefix starts the address's email, its
+// display name, or any word of the display name, ignoring case.
+func contactMatches(a domain.Address, prefix string) bool {
+	p := strings.ToLower(strings.TrimSpace(prefix))
+	if p == "" {
+		return true
+	}
+	if strings.HasPrefix(strings.ToLower(a.Email), p) || strings.HasPrefix(strings.ToLower(a.Name), p) {
+		return true
+	}
+	for _, word := range strings.Fields(strings.ToLower(a.Name)) {
+		if strings.HasPrefix(word, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+func TestFrequentContacts_Ranking(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	carol := domain.Address{Name: "Carol King", Email: "carol@example.com"}
+	bob := domain.Address{Name: "Bob Stone", Email: "bob@example.com"}
+	alice := domain.Address{Name: "Alice Cooper", Email: "alice@example.com"}
+	dave := domain.Address{Email: "dave@example.com"}
+
+	emails := []domain.Email{
+		{From: carol, To: []domain.Address{bob}},
+		{From: carol, CC: []domain.Address{alice}},
+		{From: bob, To: []domain.Address{dave}},
+		{From: domain.Address{Email: "CAROL@example.com"}},
+	}
+	for i := range emails {
+		emails[i].ID = fmt.Sprintf("msg-%d", i)
+		emails[i].ThreadID = fmt.Sprintf("thread-%d", i)
+		emails[i].Date = time.Date(2025, 6, 15, 10, i, 0, 0, time.UTC)
+		if err := db.UpsertEmail(ctx, &emails[i], "acc-1"); err != nil {
+			t.Fatalf("UpsertEmail() error: %v", err)
+		}
+	}
+
+	got, err := db.FrequentContacts(ctx, "acc-1", "", 0)
+	if err != nil {
+		t.Fatalf("FrequentContacts() error: %v", err)
+	}
+	// carol: 3 (case-insensitive), bob: 2, then alice and dave tie at 1 and sort alphabetically.
+	want := []string{"carol@example.com", "bob@example.com", "alice@example.com", "dave@example.com"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d contacts, want %d: %v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Email != w {
+			t.Errorf("contact[%d] = %q, want %q", i, got[i].Email, w)
+		}
+	}
+	if got[0].Name != "Carol King" {
+		t.Errorf("contact[0].Name = %q, want %q", got[0].Name, "Carol King")
+	}
+}
+
+func TestFrequentContacts_PrefixAndLimit(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	email := &domain.Email{
+		ID:       "msg-1",
+		ThreadID: "thread-1",
+		From:     domain.Address{Name: "Alice Cooper", Email: "ac@example.com"},
+		To: []domain.Address{
+			{Name: "Bob Stone", Email: "bob@example.com"},
+			{Email: "coop@example.com"},
+		},
+		Date: time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC),
+	}
+	if err := db.UpsertEmail(ctx, email, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+
+	// "COOP" matches Alice's surname and coop@'s email, case-insensitively.
+	got, err := db.FrequentContacts(ctx, "acc-1", "COOP", 0)
+	if err != nil {
+		t.Fatalf("FrequentContacts() error: %v", err)
+	}
+	if len(got) != 2 || got[0].Email != "ac@example.com" || got[1].Email != "coop@example.com" {
+		t.Errorf("FrequentContacts(COOP) = %v, want ac@ and coop@", got)
+	}
+
+	got, err = db.FrequentContacts(ctx, "acc-1", "", 1)
+	if err != nil {
+		t.Fatalf("FrequentContacts() error: %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("FrequentContacts(limit 1) returned %d contacts", len(got))
+	}
+}
+
+func TestFrequentContacts_PrefixIsLiteral(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	email := &domain.Email{
+		ID:       "msg-1",
+		ThreadID: "thread-1",
+		From:     domain.Address{Email: "bob@example.com"},
+		CC:       []domain.Address{{Name: "Dev_Ops", Email: "ops@example.com"}, {Name: "AT&T Billing", Email: "billing@example.com"}},
+		Date:     time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC),
+	}
+	if err := db.UpsertEmail(ctx, email, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+
+	for _, prefix := range []string{"%", "_ob", "dev%"} {
+		got, err := db.FrequentContacts(ctx, "acc-1", prefix, 0)
+		if err != nil {
+			t.Fatalf("FrequentContacts(%q) error: %v", prefix, err)
+		}
+		if len(got) != 0 {
+			t.Errorf("FrequentContacts(%q) = %v, want no wildcard matches", prefix, got)
+		}
+	}
+
+	got, err := db.FrequentContacts(ctx, "acc-1", "dev_", 0)
+	if err != nil {
+		t.Fatalf("FrequentContacts() error: %v", err)
+	}
+	if len(got) != 1 || got[0].Email != "ops@example.com" {
+		t.Errorf("FrequentContacts(dev_) = %v, want the CC'd ops@", got)
+	}
+	got, err = db.FrequentContacts(ctx, "acc-1", "at&t", 0)
+	if err != nil {
+		t.Fatalf("FrequentContacts() error: %v", err)
+	}
+	if len(got) != 1 || got[0].Email != "billing@example.com" {
+		t.Errorf("FrequentContacts(at&t) = %v, want billing@", got)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/lu-zhengda/termail/internal/domain"
 	"github.com/lu-zhengda/termail/internal/store"
@@ -13,6 +16,52 @@ import (
 
 // UpsertEmail inserts or updates an email and its label associations.
 func (s *DB) UpsertEmail(ctx context.Context, email *domain.Email, accountID string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := upsertEmailTx(ctx, tx, email, accountID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit email upsert: %w", err)
+	}
+	return nil
+}
+
+// UpsertEmails inserts or updates a batch of emails in a single transaction.
+// Either every email is stored or none are.
+func (s *DB) UpsertEmails(ctx context.Context, emails []domain.Email, accountID string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := range emails {
+		if err := upsertEmailTx(ctx, tx, &emails[i], accountID); err != nil {
+			return fmt.Errorf("failed to upsert email %s: %w", emails[i].ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit email batch: %w", err)
+	}
+	return nil
+}
+
+// upsertEmailTx writes an email and replaces its label associations within
+// tx. An ID that belongs to another account is left untouched.
+func upsertEmailTx(ctx context.Context, tx *sql.Tx, email *domain.Email, accountID string) error {
 	toJSON, err := json.Marshal(email.To)
 	if err != nil {
 		return fmt.Errorf("failed to marshal To addresses: %w", err)
@@ -21,19 +70,26 @@ func (s *DB) UpsertEmail(ctx context.Context, email *domain.Email, accountID str
 	if err != nil {
 		return fmt.Errorf("failed to marshal CC addresses: %w", err)
 	}
-
-	tx, err := s.db.BeginTx(ctx, nil)
+	bccJSON, err := json.Marshal(email.BCC)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return fmt.Errorf("failed to marshal BCC addresses: %w", err)
+	}
+	var eventJSON sql.NullString
+	if email.Event != nil {
+		data, err := json.Marshal(email.Event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal calendar event: %w", err)
+		}
+		eventJSON = sql.NullString{String: string(data), Valid: true}
 	}
-	defer tx.Rollback()
 
-	_, err = tx.ExecContext(ctx, `
+	res, err := tx.ExecContext(ctx, `
 		INSERT INTO emails (id, account_id, thread_id, from_addr, from_name, to_addrs, cc_addrs,
-			subject, body_text, body_html, date, is_read, is_starred, in_reply_to)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			subject, body_text, body_html, date, is_read, is_starred, in_reply_to,
+			list_unsubscribe, calendar_event, message_id, refs, list_id, list_post, received_at, bcc_addrs,
+			snippet)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
-			account_id = excluded.account_id,
 			thread_id  = excluded.thread_id,
 			from_addr  = excluded.from_addr,
 			from_name  = excluded.from_name,
@@ -45,17 +101,38 @@ func (s *DB) UpsertEmail(ctx context.Context, email *domain.Email, accountID str
 			date       = excluded.date,
 			is_read    = excluded.is_read,
 			is_starred = excluded.is_starred,
-			in_reply_to = excluded.in_reply_to`,
+			in_reply_to = excluded.in_reply_to,
+			list_unsubscribe = excluded.list_unsubscribe,
+			calendar_event = excluded.calendar_event,
+			message_id = excluded.message_id,
+			refs = excluded.refs,
+			list_id = excluded.list_id,
+			list_post = excluded.list_post,
+			received_at = excluded.received_at,
+			bcc_addrs = excluded.bcc_addrs,
+			snippet = excluded.snippet
+		WHERE emails.account_id = excluded.account_id`,
 		email.ID, accountID, email.ThreadID,
 		email.From.Email, email.From.Name,
 		string(toJSON), string(ccJSON),
 		email.Subject, email.Body, email.BodyHTML,
 		email.Date.Format(time.RFC3339),
 		email.IsRead, email.IsStarred, email.InReplyTo,
+		email.ListUnsubscribe, eventJSON,
+		email.MessageID, strings.Join(email.References, " "),
+		email.ListID, email.ListPost, formatReceivedAt(email.ReceivedAt),
+		string(bccJSON), emailSnippet(email.Body),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to upsert email: %w", err)
 	}
+	// A message ID already stored under another account keeps that
+	// account's row; the conflict update is skipped and nothing changes.
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to upsert email: %w", err)
+	} else if n == 0 {
+		return nil
+	}
 
 	// Delete existing labels, then reinsert.
 	if _, err := tx.ExecContext(ctx, `DELETE FROM email_labels WHERE email_id = ?`, email.ID); err != nil {
@@ -68,34 +145,44 @@ func (s *DB) UpsertEmail(ctx context.Context, email *domain.Email, accountID str
 			return fmt.Errorf("failed to insert email label: %w", err)
 		}
 	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit email upsert: %w", err)
-	}
 	return nil
 }
 
-// GetEmail retrieves a single email by ID, including its labels.
-func (s *DB) GetEmail(ctx context.Context, id string) (*domain.Email, error) {
+// GetEmail retrieves a single email of an account by ID, including its
+// labels. Mail stored under another account is reported as not found.
+func (s *DB) GetEmail(ctx context.Context, id string, accountID string) (*domain.Email, error) {
 	var e domain.Email
 	var fromAddr, fromName string
-	var toJSON, ccJSON string
-	var dateStr string
+	var toJSON, ccJSON, bccJSON, eventJSON string
+	var refs, flags string
+	var dateStr, receivedStr string
 
 	err := s.db.QueryRowContext(ctx, `
 		SELECT id, thread_id, from_addr, from_name, to_addrs, cc_addrs,
-			subject, body_text, body_html, date, is_read, is_starred, in_reply_to
-		FROM emails WHERE id = ?`, id,
+			subject, body_text, body_html, date, is_read, is_starred, in_reply_to,
+			COALESCE(list_unsubscribe, ''), COALESCE(calendar_event, ''),
+			COALESCE(message_id, ''), COALESCE(refs, ''),
+			COALESCE(list_id, ''), COALESCE(list_post, ''), COALESCE(received_at, ''),
+			COALESCE(bcc_addrs, ''),
+			`+emailFlagsColumn+`
+		FROM emails e WHERE id = ? AND account_id = ?`, id, accountID,
 	).Scan(
 		&e.ID, &e.ThreadID, &fromAddr, &fromName, &toJSON, &ccJSON,
 		&e.Subject, &e.Body, &e.BodyHTML, &dateStr,
 		&e.IsRead, &e.IsStarred, &e.InReplyTo,
+		&e.ListUnsubscribe, &eventJSON,
+		&e.MessageID, &refs, &e.ListID, &e.ListPost, &receivedStr, &bccJSON, &flags,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("email %s: %w", id, store.ErrNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get email %s: %w", id, err)
 	}
 
 	e.From = domain.Address{Name: fromName, Email: fromAddr}
+	e.References = strings.Fields(refs)
+	e.Flags = splitFlags(flags)
 
 	if toJSON != "" {
 		if err := json.Unmarshal([]byte(toJSON), &e.To); err != nil {
@@ -107,12 +194,24 @@ func (s *DB) GetEmail(ctx context.Context, id string) (*domain.Email, error) {
 			return nil, fmt.Errorf("failed to unmarshal CC addresses: %w", err)
 		}
 	}
+	if bccJSON != "" {
+		if err := json.Unmarshal([]byte(bccJSON), &e.BCC); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal BCC addresses: %w", err)
+		}
+	}
+
+	if e.Event, err = unmarshalEvent(eventJSON); err != nil {
+		return nil, err
+	}
 
 	parsedDate, err := time.Parse(time.RFC3339, dateStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse email date: %w", err)
 	}
 	e.Date = parsedDate
+	if e.ReceivedAt, err = parseReceivedAt(receivedStr); err != nil {
+		return nil, err
+	}
 
 	// Fetch labels.
 	rows, err := s.db.QueryContext(ctx, `SELECT label_id FROM email_labels WHERE email_id = ?`, id)
@@ -135,30 +234,134 @@ func (s *DB) GetEmail(ctx context.Context, id string) (*domain.Email, error) {
 	return &e, nil
 }
 
+// listIDCond matches emails whose raw List-Id header is the given ID, either
+// bare or in angle brackets after a description.
+const listIDCond = `(e.list_id = ? OR instr(e.list_id, '<' || ? || '>') > 0)`
+
+// emailSortDate orders emails by when they were received, falling back to
+// the Date header for mail stored without a received time. The Date header
+// is set by the sender and may be wrong or forged.
+const emailSortDate = `COALESCE(e.received_at, e.date)`
+
+// appendDateRange adds predicates limiting emails to those received at or
+// after after and strictly before before; zero times are unbounded. Dates
+// are compared through datetime() so stored values with differing UTC
+// offsets order correctly.
+func appendDateRange(query string, args []any, after, before time.Time) (string, []any) {
+	if !after.IsZero() {
+		query += ` AND datetime(` + emailSortDate + `) >= datetime(?)`
+		args = append(args, after.UTC().Format(time.RFC3339))
+	}
+	if !before.IsZero() {
+		query += ` AND datetime(` + emailSortDate + `) < datetime(?)`
+		args = append(args, before.UTC().Format(time.RFC3339))
+	}
+	return query, args
+}
+
+// appendSyncedAfter adds a predicate limiting emails to those first stored
+// at or after t, unless t is zero.
+func appendSyncedAfter(query string, args []any, t time.Time) (string, []any) {
+	if t.IsZero() {
+		return query, args
+	}
+	return query + ` AND datetime(e.created_at) >= datetime(?)`, append(args, t.UTC().Format(time.RFC3339))
+}
+
+// formatReceivedAt returns the stored form of a received time: RFC 3339 in
+// UTC, or NULL when unknown.
+func formatReceivedAt(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(time.RFC3339), Valid: true}
+}
+
+// parseReceivedAt parses a stored received time; empty means unknown.
+func parseReceivedAt(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse email received time: %w", err)
+	}
+	return t, nil
+}
+
+// snippetLength is the most runes of an email's body kept as its snippet.
+const snippetLength = 100
+
+// emailSnippet returns the preview stored with an email: the start of its
+// plain-text body on one line, with runs of whitespace collapsed.
+func emailSnippet(body string) string {
+	snippet := strings.Join(strings.Fields(body), " ")
+	if utf8.RuneCountInString(snippet) > snippetLength {
+		snippet = strings.TrimRight(string([]rune(snippet)[:snippetLength]), " ")
+	}
+	return snippet
+}
+
+// emailSnippetColumn selects a short preview of an email, falling back to
+// the start of its plain-text body for emails stored before snippets were,
+// matching the thread snippet length.
+const emailSnippetColumn = `COALESCE(e.snippet, substr(e.body_text, 1, 100))`
+
+// labelJoin returns the join restricting emails (aliased e) to opts' label
+// filter, with its arguments. Several labels go through a DISTINCT subquery
+// so an email carrying more than one of them is listed once.
+func labelJoin(opts store.ListEmailOptions) (string, []any) {
+	if len(opts.IncludeLabelIDs) == 0 {
+		return "email_labels el ON el.email_id = e.id AND el.label_id = ?", []any{opts.LabelID}
+	}
+	ids := append([]string{opts.LabelID}, opts.IncludeLabelIDs...)
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	return "(SELECT DISTINCT email_id FROM email_labels WHERE label_id IN (" + placeholders + ")) el ON el.email_id = e.id", args
+}
+
 // ListEmails returns a summary list of emails, optionally filtered by label.
 func (s *DB) ListEmails(ctx context.Context, opts store.ListEmailOptions) ([]domain.Email, error) {
 	var query string
 	var args []any
 
 	if opts.LabelID != "" {
+		join, joinArgs := labelJoin(opts)
 		query = `
-			SELECT e.id, e.thread_id, e.from_addr, e.from_name, e.subject, e.snippet,
-				e.date, e.is_read, e.is_starred
+			SELECT e.id, e.thread_id, e.from_addr, e.from_name, e.subject, ` + emailSnippetColumn + `,
+				e.date, e.is_read, e.is_starred, ` + emailFlagsColumn + `, ` + emailLabelsColumn + `
 			FROM emails e
-			JOIN email_labels el ON el.email_id = e.id
-			WHERE e.account_id = ? AND el.label_id = ?
-			ORDER BY e.date DESC`
-		args = append(args, opts.AccountID, opts.LabelID)
+			JOIN ` + join + `
+			WHERE e.account_id = ?`
+		args = append(joinArgs, opts.AccountID)
 	} else {
 		query = `
-			SELECT e.id, e.thread_id, e.from_addr, e.from_name, e.subject, e.snippet,
-				e.date, e.is_read, e.is_starred
+			SELECT e.id, e.thread_id, e.from_addr, e.from_name, e.subject, ` + emailSnippetColumn + `,
+				e.date, e.is_read, e.is_starred, ` + emailFlagsColumn + `, ` + emailLabelsColumn + `
 			FROM emails e
-			WHERE e.account_id = ?
-			ORDER BY e.date DESC`
+			WHERE e.account_id = ?`
 		args = append(args, opts.AccountID)
 	}
 
+	if opts.LabelID == domain.LabelInbox {
+		query += ` AND ` + notSnoozedCond
+		args = append(args, time.Now().Unix())
+	}
+	if opts.Flag != "" {
+		query += ` AND EXISTS (SELECT 1 FROM email_flags f WHERE f.email_id = e.id AND f.flag = ?)`
+		args = append(args, opts.Flag)
+	}
+	if opts.ListID != "" {
+		query += ` AND ` + listIDCond
+		args = append(args, opts.ListID, opts.ListID)
+	}
+	query, args = appendDateRange(query, args, opts.After, opts.Before)
+	query, args = appendSyncedAfter(query, args, opts.SyncedAfter)
+	query += " ORDER BY " + emailSortDate + " DESC"
+
 	if opts.Limit > 0 {
 		query += " LIMIT ?"
 		args = append(args, opts.Limit)
@@ -173,22 +376,56 @@ func (s *DB) ListEmails(ctx context.Context, opts store.ListEmailOptions) ([]dom
 		return nil, fmt.Errorf("failed to list emails: %w", err)
 	}
 	defer rows.Close()
+	return scanEmailSummaries(rows)
+}
+
+// ListBySender returns summaries of the account's mail from addr, matched
+// case-insensitively, newest first, leaving out TRASH and SPAM. A positive
+// limit caps the number returned.
+func (s *DB) ListBySender(ctx context.Context, accountID, addr string, limit int) ([]domain.Email, error) {
+	query := `
+		SELECT e.id, e.thread_id, e.from_addr, e.from_name, e.subject, ` + emailSnippetColumn + `,
+			e.date, e.is_read, e.is_starred, ` + emailFlagsColumn + `, ` + emailLabelsColumn + `
+		FROM emails e
+		WHERE e.account_id = ? AND e.from_addr = ? COLLATE NOCASE
+			AND NOT EXISTS (SELECT 1 FROM email_labels el
+				WHERE el.email_id = e.id AND el.label_id IN (?, ?))
+		ORDER BY ` + emailSortDate + ` DESC`
+	args := []any{accountID, addr, domain.LabelTrash, domain.LabelSpam}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
 
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list emails from %s: %w", addr, err)
+	}
+	defer rows.Close()
+	return scanEmailSummaries(rows)
+}
+
+// scanEmailSummaries scans the rows of a ListEmails-style query: summaries
+// without bodies.
+func scanEmailSummaries(rows *sql.Rows) ([]domain.Email, error) {
 	var emails []domain.Email
 	for rows.Next() {
 		var e domain.Email
 		var fromAddr, fromName string
 		var snippet sql.NullString
-		var dateStr string
+		var dateStr, flags, labels string
 
 		if err := rows.Scan(
 			&e.ID, &e.ThreadID, &fromAddr, &fromName, &e.Subject, &snippet,
-			&dateStr, &e.IsRead, &e.IsStarred,
+			&dateStr, &e.IsRead, &e.IsStarred, &flags, &labels,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan email row: %w", err)
 		}
 
 		e.From = domain.Address{Name: fromName, Email: fromAddr}
+		e.Snippet = snippet.String
+		e.Flags = splitFlags(flags)
+		e.Labels = splitLabels(labels)
 
 		parsedDate, err := time.Parse(time.RFC3339, dateStr)
 		if err != nil {
@@ -204,17 +441,55 @@ func (s *DB) ListEmails(ctx context.Context, opts store.ListEmailOptions) ([]dom
 	return emails, nil
 }
 
-// SetEmailRead updates the is_read flag for a single email.
-func (s *DB) SetEmailRead(ctx context.Context, emailID string, read bool) error {
-	_, err := s.db.ExecContext(ctx, `UPDATE emails SET is_read = ? WHERE id = ?`, read, emailID)
+// SetEmailRead updates the is_read flag for a single email of an account.
+func (s *DB) SetEmailRead(ctx context.Context, emailID string, accountID string, read bool) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	_, err := s.db.ExecContext(ctx, `UPDATE emails SET is_read = ? WHERE id = ? AND account_id = ?`, read, emailID, accountID)
 	if err != nil {
 		return fmt.Errorf("failed to set email %s read=%v: %w", emailID, read, err)
 	}
 	return nil
 }
 
+// SetEmailStarred updates the is_starred flag and STARRED label for an
+// email of accountID.
+func (s *DB) SetEmailStarred(ctx context.Context, emailID string, accountID string, starred bool) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE emails SET is_starred = ? WHERE id = ? AND account_id = ?`, starred, emailID, accountID); err != nil {
+		return fmt.Errorf("failed to set email %s starred=%v: %w", emailID, starred, err)
+	}
+	if starred {
+		_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO email_labels (email_id, label_id)
+			SELECT id, ? FROM emails WHERE id = ? AND account_id = ?`, domain.LabelStarred, emailID, accountID)
+	} else {
+		_, err = tx.ExecContext(ctx, `DELETE FROM email_labels WHERE label_id = ? AND email_id IN (
+			SELECT id FROM emails WHERE id = ? AND account_id = ?)`, domain.LabelStarred, emailID, accountID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update STARRED label for email %s: %w", emailID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit star update: %w", err)
+	}
+	return nil
+}
+
 // SetThreadRead updates the is_read flag for all emails in a thread.
 func (s *DB) SetThreadRead(ctx context.Context, threadID string, read bool) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	_, err := s.db.ExecContext(ctx, `UPDATE emails SET is_read = ? WHERE thread_id = ?`, read, threadID)
 	if err != nil {
 		return fmt.Errorf("failed to set thread %s read=%v: %w", threadID, read, err)
@@ -222,30 +497,55 @@ func (s *DB) SetThreadRead(ctx context.Context, threadID string, read bool) erro
 	return nil
 }
 
-// DeleteEmail removes an email by ID.
-func (s *DB) DeleteEmail(ctx context.Context, id string) error {
-	_, err := s.db.ExecContext(ctx, `DELETE FROM emails WHERE id = ?`, id)
+// DeleteEmail removes an email of an account by ID.
+func (s *DB) DeleteEmail(ctx context.Context, id string, accountID string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	_, err := s.db.ExecContext(ctx, `DELETE FROM emails WHERE id = ? AND account_id = ?`, id, accountID)
 	if err != nil {
 		return fmt.Errorf("failed to delete email %s: %w", id, err)
 	}
 	return nil
 }
 
-// SetEmailLabels replaces the label set for an email.
-func (s *DB) SetEmailLabels(ctx context.Context, emailID string, labelIDs []string) error {
+// DeleteAccountEmails removes every email of an account, returning how many
+// were deleted.
+func (s *DB) DeleteAccountEmails(ctx context.Context, accountID string) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM emails WHERE account_id = ?`, accountID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete emails of %s: %w", accountID, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete emails of %s: %w", accountID, err)
+	}
+	return int(n), nil
+}
+
+// SetEmailLabels replaces the label set for an email of accountID.
+func (s *DB) SetEmailLabels(ctx context.Context, emailID string, accountID string, labelIDs []string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	if _, err := tx.ExecContext(ctx, `DELETE FROM email_labels WHERE email_id = ?`, emailID); err != nil {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM email_labels WHERE email_id IN (
+		SELECT id FROM emails WHERE id = ? AND account_id = ?)`, emailID, accountID); err != nil {
 		return fmt.Errorf("failed to delete email labels: %w", err)
 	}
 
 	for _, labelID := range labelIDs {
-		if _, err := tx.ExecContext(ctx, `INSERT INTO email_labels (email_id, label_id) VALUES (?, ?)`,
-			emailID, labelID); err != nil {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO email_labels (email_id, label_id)
+			SELECT id, ? FROM emails WHERE id = ? AND account_id = ?`,
+			labelID, emailID, accountID); err != nil {
 			return fmt.Errorf("failed to insert email label: %w", err)
 		}
 	}
@@ -255,3 +555,15 @@ func (s *DB) SetEmailLabels(ctx context.Context, emailID string, labelIDs []stri
 	}
 	return nil
 }
+
+// unmarshalEvent decodes a stored calendar event, returning nil for an empty value.
+func unmarshalEvent(data string) (*domain.CalendarEvent, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var event domain.CalendarEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal calendar event: %w", err)
+	}
+	return &event, nil
+}
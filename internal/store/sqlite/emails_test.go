@@ -2,9 +2,14 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/lu-zhengda/termail/internal/domain"
 	"github.com/lu-zhengda/termail/internal/store"
@@ -52,7 +57,7 @@ func TestUpsertAndGetEmail(t *testing.T) {
 		t.Fatalf("UpsertEmail() error: %v", err)
 	}
 
-	got, err := db.GetEmail(ctx, "msg-1")
+	got, err := db.GetEmail(ctx, "msg-1", "acc-1")
 	if err != nil {
 		t.Fatalf("GetEmail() error: %v", err)
 	}
@@ -158,7 +163,10 @@ func TestListEmails_ByLabel(t *testing.T) {
 		t.Fatalf("ListEmails(STARRED) error: %v", err)
 	}
 	if len(emails) != 1 {
-		t.Errorf("STARRED count = %d, want 1", len(emails))
+		t.Fatalf("STARRED count = %d, want 1", len(emails))
+	}
+	if !slices.Equal(emails[0].Labels, []string{"STARRED"}) {
+		t.Errorf("Labels = %v, want [STARRED]", emails[0].Labels)
 	}
 }
 
@@ -189,7 +197,7 @@ func TestUpsertEmail_UpdateExisting(t *testing.T) {
 		t.Fatalf("UpsertEmail() second call error: %v", err)
 	}
 
-	got, err := db.GetEmail(ctx, "msg-1")
+	got, err := db.GetEmail(ctx, "msg-1", "acc-1")
 	if err != nil {
 		t.Fatalf("GetEmail() error: %v", err)
 	}
@@ -221,16 +229,91 @@ func TestDeleteEmail(t *testing.T) {
 		t.Fatalf("UpsertEmail() error: %v", err)
 	}
 
-	if err := db.DeleteEmail(ctx, "msg-1"); err != nil {
+	if err := db.DeleteEmail(ctx, "msg-1", "acc-1"); err != nil {
 		t.Fatalf("DeleteEmail() error: %v", err)
 	}
 
-	_, err := db.GetEmail(ctx, "msg-1")
+	_, err := db.GetEmail(ctx, "msg-1", "acc-1")
 	if err == nil {
 		t.Fatal("GetEmail() after delete returned no error, want error")
 	}
 }
 
+func TestEmail_AccountIsolation(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+	if err := db.CreateAccount(ctx, &domain.Account{ID: "acc-2", Email: "other@gmail.com", Provider: "gmail"}); err != nil {
+		t.Fatalf("CreateAccount() error: %v", err)
+	}
+
+	mine := &domain.Email{
+		ID:       "msg-1",
+		ThreadID: "thread-1",
+		From:     domain.Address{Email: "alice@example.com"},
+		Subject:  "Private",
+		Date:     time.Now(),
+		Labels:   []string{domain.LabelInbox},
+	}
+	if err := db.UpsertEmail(ctx, mine, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail(acc-1) error: %v", err)
+	}
+	// The same ID arrives from a second account.
+	theirs := &domain.Email{
+		ID:       "msg-1",
+		ThreadID: "thread-9",
+		From:     domain.Address{Email: "mallory@example.com"},
+		Subject:  "Takeover",
+		Date:     time.Now(),
+		Labels:   []string{domain.LabelSpam},
+	}
+	if err := db.UpsertEmail(ctx, theirs, "acc-2"); err != nil {
+		t.Fatalf("UpsertEmail(acc-2) error: %v", err)
+	}
+
+	// acc-2 cannot see or change acc-1's row.
+	if _, err := db.GetEmail(ctx, "msg-1", "acc-2"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetEmail() from acc-2 error = %v, want ErrNotFound", err)
+	}
+	if err := db.SetEmailRead(ctx, "msg-1", "acc-2", true); err != nil {
+		t.Fatalf("SetEmailRead() error: %v", err)
+	}
+	if err := db.SetEmailStarred(ctx, "msg-1", "acc-2", true); err != nil {
+		t.Fatalf("SetEmailStarred() error: %v", err)
+	}
+	if err := db.SetEmailLabels(ctx, "msg-1", "acc-2", []string{domain.LabelTrash}); err != nil {
+		t.Fatalf("SetEmailLabels() error: %v", err)
+	}
+	if err := db.DeleteEmail(ctx, "msg-1", "acc-2"); err != nil {
+		t.Fatalf("DeleteEmail() error: %v", err)
+	}
+
+	got, err := db.GetEmail(ctx, "msg-1", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() from acc-1 error: %v", err)
+	}
+	if got.Subject != "Private" || got.From.Email != "alice@example.com" || got.ThreadID != "thread-1" {
+		t.Errorf("acc-1's email = %q from %s in %s, want it untouched by acc-2's upsert", got.Subject, got.From.Email, got.ThreadID)
+	}
+	if got.IsRead || got.IsStarred || !slices.Equal(got.Labels, []string{domain.LabelInbox}) {
+		t.Errorf("acc-1's email read=%v starred=%v labels=%v, want unchanged by acc-2", got.IsRead, got.IsStarred, got.Labels)
+	}
+
+	// acc-1 still manages its own row.
+	if err := db.SetEmailRead(ctx, "msg-1", "acc-1", true); err != nil {
+		t.Fatalf("SetEmailRead() error: %v", err)
+	}
+	if got, err := db.GetEmail(ctx, "msg-1", "acc-1"); err != nil || !got.IsRead {
+		t.Errorf("SetEmailRead() through acc-1 did not apply: %v", err)
+	}
+	if err := db.DeleteEmail(ctx, "msg-1", "acc-1"); err != nil {
+		t.Fatalf("DeleteEmail() error: %v", err)
+	}
+	if _, err := db.GetEmail(ctx, "msg-1", "acc-1"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("GetEmail() after acc-1's delete error = %v, want ErrNotFound", err)
+	}
+}
+
 func TestSetEmailLabels(t *testing.T) {
 	db := newTestDB(t)
 	seedAccount(t, db)
@@ -249,11 +332,11 @@ func TestSetEmailLabels(t *testing.T) {
 	}
 
 	// Set new labels
-	if err := db.SetEmailLabels(ctx, "msg-1", []string{"INBOX", "STARRED", "IMPORTANT"}); err != nil {
+	if err := db.SetEmailLabels(ctx, "msg-1", "acc-1", []string{"INBOX", "STARRED", "IMPORTANT"}); err != nil {
 		t.Fatalf("SetEmailLabels() error: %v", err)
 	}
 
-	got, err := db.GetEmail(ctx, "msg-1")
+	got, err := db.GetEmail(ctx, "msg-1", "acc-1")
 	if err != nil {
 		t.Fatalf("GetEmail() error: %v", err)
 	}
@@ -262,11 +345,11 @@ func TestSetEmailLabels(t *testing.T) {
 	}
 
 	// Change labels
-	if err := db.SetEmailLabels(ctx, "msg-1", []string{"TRASH"}); err != nil {
+	if err := db.SetEmailLabels(ctx, "msg-1", "acc-1", []string{"TRASH"}); err != nil {
 		t.Fatalf("SetEmailLabels() second call error: %v", err)
 	}
 
-	got, err = db.GetEmail(ctx, "msg-1")
+	got, err = db.GetEmail(ctx, "msg-1", "acc-1")
 	if err != nil {
 		t.Fatalf("GetEmail() after relabel error: %v", err)
 	}
@@ -277,3 +360,463 @@ func TestSetEmailLabels(t *testing.T) {
 		t.Errorf("Labels[0] = %q, want %q", got.Labels[0], "TRASH")
 	}
 }
+
+func TestUpsertEmail_ListUnsubscribe(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	email := &domain.Email{
+		ID:              "msg-1",
+		ThreadID:        "thread-1",
+		From:            domain.Address{Email: "news@example.com"},
+		Subject:         "Weekly digest",
+		Date:            time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC),
+		ListUnsubscribe: "<mailto:leave@example.com>",
+	}
+	if err := db.UpsertEmail(ctx, email, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+
+	got, err := db.GetEmail(ctx, "msg-1", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if got.ListUnsubscribe != "<mailto:leave@example.com>" {
+		t.Errorf("ListUnsubscribe = %q, want %q", got.ListUnsubscribe, "<mailto:leave@example.com>")
+	}
+}
+
+func TestUpsertEmail_References(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	email := &domain.Email{
+		ID:         "msg-1",
+		ThreadID:   "thread-1",
+		Subject:    "Re: Plans",
+		Date:       time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC),
+		MessageID:  "<c@example.com>",
+		References: []string{"<a@example.com>", "<b@example.com>"},
+	}
+	if err := db.UpsertEmail(ctx, email, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+
+	got, err := db.GetEmail(ctx, "msg-1", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if got.MessageID != "<c@example.com>" {
+		t.Errorf("MessageID = %q, want %q", got.MessageID, "<c@example.com>")
+	}
+	if len(got.References) != 2 || got.References[1] != "<b@example.com>" {
+		t.Errorf("References = %v, want %v", got.References, email.References)
+	}
+}
+
+func TestUpsertEmail_BCC(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	email := &domain.Email{
+		ID:       "msg-1",
+		ThreadID: "thread-1",
+		From:     domain.Address{Email: "me@example.com"},
+		To:       []domain.Address{{Email: "alice@example.com"}},
+		BCC:      []domain.Address{{Name: "Bob", Email: "bob@example.com"}},
+		Subject:  "Surprise party",
+		Date:     time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC),
+	}
+	if err := db.UpsertEmail(ctx, email, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+
+	got, err := db.GetEmail(ctx, "msg-1", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if len(got.BCC) != 1 || got.BCC[0] != email.BCC[0] {
+		t.Errorf("GetEmail() BCC = %v, want %v", got.BCC, email.BCC)
+	}
+
+	thread, err := db.GetThread(ctx, "thread-1", "acc-1")
+	if err != nil {
+		t.Fatalf("GetThread() error: %v", err)
+	}
+	if len(thread.Messages) != 1 || len(thread.Messages[0].BCC) != 1 {
+		t.Errorf("GetThread() BCC = %v, want %v", thread.Messages, email.BCC)
+	}
+}
+
+func TestUpsertEmails_Batch(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	date := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	batch := []domain.Email{
+		{ID: "msg-1", ThreadID: "t1", Subject: "One", Date: date, Labels: []string{domain.LabelInbox}},
+		{ID: "msg-2", ThreadID: "t2", Subject: "Two", Date: date, Labels: []string{domain.LabelInbox}},
+	}
+	if err := db.UpsertEmails(ctx, batch, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+	emails, err := db.ListEmails(ctx, store.ListEmailOptions{AccountID: "acc-1", LabelID: domain.LabelInbox})
+	if err != nil {
+		t.Fatalf("ListEmails() error: %v", err)
+	}
+	if len(emails) != 2 {
+		t.Fatalf("got %d emails, want 2", len(emails))
+	}
+
+	// A failure part way through rolls back the whole batch.
+	bad := []domain.Email{
+		{ID: "msg-3", ThreadID: "t3", Subject: "Three", Date: date},
+		{ID: "msg-4", ThreadID: "t4", Subject: "Four", Date: date, Labels: []string{domain.LabelInbox, domain.LabelInbox}},
+	}
+	if err := db.UpsertEmails(ctx, bad, "acc-1"); err == nil {
+		t.Fatal("UpsertEmails() with duplicate labels should fail")
+	}
+	if _, err := db.GetEmail(ctx, "msg-3", "acc-1"); err == nil {
+		t.Error("msg-3 should have been rolled back with the failed batch")
+	}
+}
+
+func TestUpsertEmail_StoresSnippet(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	body := "Hi team,\n\n  The   quarterly numbers are in.\r\n" + strings.Repeat("Revenue grew again. ", 20)
+	email := &domain.Email{
+		ID:       "msg-1",
+		ThreadID: "thread-1",
+		Subject:  "Numbers",
+		Body:     body,
+		Date:     time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC),
+		Labels:   []string{"INBOX"},
+	}
+	if err := db.UpsertEmail(ctx, email, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+
+	var stored sql.NullString
+	if err := db.db.QueryRowContext(ctx, `SELECT snippet FROM emails WHERE id = ?`, "msg-1").Scan(&stored); err != nil {
+		t.Fatalf("select snippet error: %v", err)
+	}
+	snippet := stored.String
+	if snippet == "" || utf8.RuneCountInString(snippet) > 100 {
+		t.Fatalf("snippet = %q (%d runes), want a non-empty preview of at most 100", snippet, utf8.RuneCountInString(snippet))
+	}
+	if !strings.HasPrefix(snippet, "Hi team, The quarterly numbers are in. Revenue") {
+		t.Errorf("snippet = %q, want the body on one line with whitespace collapsed", snippet)
+	}
+
+	emails, err := db.ListEmails(ctx, store.ListEmailOptions{AccountID: "acc-1", LabelID: "INBOX", Limit: 10})
+	if err != nil {
+		t.Fatalf("ListEmails() error: %v", err)
+	}
+	if len(emails) != 1 || emails[0].Snippet != snippet {
+		t.Errorf("ListEmails() = %+v, want the stored snippet", emails)
+	}
+}
+
+func TestEmailSnippet(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"", ""},
+		{"  short\n\tnote  ", "short note"},
+		{strings.Repeat("é", 150), strings.Repeat("é", 100)},
+		{strings.Repeat("a", 99) + " b", strings.Repeat("a", 99)},
+	}
+	for _, tt := range tests {
+		if got := emailSnippet(tt.body); got != tt.want {
+			t.Errorf("emailSnippet(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestListEmails_IncludeLabelIDs(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	date := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	emails := []domain.Email{
+		{ID: "parent", ThreadID: "t1", Date: date, Labels: []string{"Label_work"}},
+		{ID: "child", ThreadID: "t2", Date: date, Labels: []string{"Label_proj"}},
+		{ID: "both", ThreadID: "t2", Date: date, Labels: []string{"Label_work", "Label_proj"}},
+		{ID: "other", ThreadID: "t3", Date: date, Labels: []string{"Label_home"}},
+	}
+	if err := db.UpsertEmails(ctx, emails, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+
+	opts := store.ListEmailOptions{AccountID: "acc-1", LabelID: "Label_work"}
+	got, err := db.ListEmails(ctx, opts)
+	if err != nil {
+		t.Fatalf("ListEmails() error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("without children got %d emails, want 2", len(got))
+	}
+
+	opts.IncludeLabelIDs = []string{"Label_proj"}
+	got, err = db.ListEmails(ctx, opts)
+	if err != nil {
+		t.Fatalf("ListEmails() error: %v", err)
+	}
+	if len(got) != 3 {
+		t.Errorf("with children got %d emails, want 3 (each listed once)", len(got))
+	}
+
+	threads, err := db.ListThreads(ctx, opts)
+	if err != nil {
+		t.Fatalf("ListThreads() error: %v", err)
+	}
+	if len(threads) != 2 {
+		t.Fatalf("got %d threads, want 2", len(threads))
+	}
+	for _, th := range threads {
+		if th.ID == "t2" && th.TotalCount != 2 {
+			t.Errorf("thread t2 TotalCount = %d, want 2", th.TotalCount)
+		}
+	}
+}
+
+func TestListEmails_ListID(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	date := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	emails := []domain.Email{
+		{ID: "nuts", ThreadID: "t1", Date: date, ListID: `"Go Nuts" <golang-nuts.googlegroups.com>`, ListPost: "<mailto:golang-nuts@googlegroups.com>"},
+		{ID: "dev", ThreadID: "t2", Date: date, ListID: "<golang-dev.googlegroups.com>"},
+		{ID: "direct", ThreadID: "t3", Date: date},
+	}
+	if err := db.UpsertEmails(ctx, emails, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+
+	got, err := db.ListEmails(ctx, store.ListEmailOptions{AccountID: "acc-1", ListID: "golang-nuts.googlegroups.com"})
+	if err != nil {
+		t.Fatalf("ListEmails() error: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "nuts" {
+		t.Fatalf("got %v, want only nuts", got)
+	}
+
+	email, err := db.GetEmail(ctx, "nuts", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if email.ListPost != "<mailto:golang-nuts@googlegroups.com>" {
+		t.Errorf("ListPost = %q, want %q", email.ListPost, "<mailto:golang-nuts@googlegroups.com>")
+	}
+}
+
+func TestListEmails_OrdersByReceivedAt(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	received := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	emails := []domain.Email{
+		// A spammer's Date header claims the message is from the future.
+		{ID: "forged", ThreadID: "t1", Date: received.AddDate(1, 0, 0), ReceivedAt: received},
+		{ID: "honest", ThreadID: "t2", Date: received.Add(time.Hour), ReceivedAt: received.Add(time.Hour)},
+		// Mail stored before received times were captured sorts by Date.
+		{ID: "legacy", ThreadID: "t3", Date: received.Add(30 * time.Minute)},
+	}
+	if err := db.UpsertEmails(ctx, emails, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+
+	got, err := db.ListEmails(ctx, store.ListEmailOptions{AccountID: "acc-1"})
+	if err != nil {
+		t.Fatalf("ListEmails() error: %v", err)
+	}
+	var ids []string
+	for _, e := range got {
+		ids = append(ids, e.ID)
+	}
+	if want := []string{"honest", "legacy", "forged"}; !slices.Equal(ids, want) {
+		t.Errorf("ListEmails() order = %v, want %v", ids, want)
+	}
+
+	threads, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "acc-1"})
+	if err != nil {
+		t.Fatalf("ListThreads() error: %v", err)
+	}
+	ids = ids[:0]
+	for _, th := range threads {
+		ids = append(ids, th.ID)
+	}
+	if want := []string{"t2", "t3", "t1"}; !slices.Equal(ids, want) {
+		t.Errorf("ListThreads() order = %v, want %v", ids, want)
+	}
+
+	email, err := db.GetEmail(ctx, "forged", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if !email.ReceivedAt.Equal(received) || !email.Date.Equal(received.AddDate(1, 0, 0)) {
+		t.Errorf("GetEmail() Date/ReceivedAt = %v/%v, want both kept", email.Date, email.ReceivedAt)
+	}
+	legacy, err := db.GetEmail(ctx, "legacy", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if !legacy.ReceivedAt.IsZero() {
+		t.Errorf("legacy ReceivedAt = %v, want zero", legacy.ReceivedAt)
+	}
+}
+
+func TestListEmails_SyncedAfter(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	lastSync := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	stored := map[string]time.Time{
+		"old":     lastSync.Add(-time.Second),
+		"at-sync": lastSync,
+		"new":     lastSync.Add(time.Minute),
+	}
+	for id, at := range stored {
+		e := domain.Email{ID: id, ThreadID: "t-" + id, Subject: "report " + id, Date: lastSync.AddDate(0, 0, -1)}
+		if err := db.UpsertEmail(ctx, &e, "acc-1"); err != nil {
+			t.Fatalf("UpsertEmail() error: %v", err)
+		}
+		// created_at is stored as SQLite's CURRENT_TIMESTAMP would write it.
+		if _, err := db.db.ExecContext(ctx, `UPDATE emails SET created_at = ? WHERE id = ?`,
+			at.Format("2006-01-02 15:04:05"), id); err != nil {
+			t.Fatalf("setting created_at: %v", err)
+		}
+	}
+
+	got, err := db.ListEmails(ctx, store.ListEmailOptions{AccountID: "acc-1", SyncedAfter: lastSync})
+	if err != nil {
+		t.Fatalf("ListEmails() error: %v", err)
+	}
+	var ids []string
+	for _, e := range got {
+		ids = append(ids, e.ID)
+	}
+	slices.Sort(ids)
+	if want := []string{"at-sync", "new"}; !slices.Equal(ids, want) {
+		t.Errorf("ListEmails(SyncedAfter) = %v, want %v", ids, want)
+	}
+
+	threads, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "acc-1", SyncedAfter: lastSync})
+	if err != nil {
+		t.Fatalf("ListThreads() error: %v", err)
+	}
+	if len(threads) != 2 {
+		t.Errorf("ListThreads(SyncedAfter) returned %d threads, want 2", len(threads))
+	}
+
+	found, err := db.SearchEmails(ctx, "report", "acc-1", store.SearchOptions{SyncedAfter: lastSync.Add(time.Second)})
+	if err != nil {
+		t.Fatalf("SearchEmails() error: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "new" {
+		t.Errorf("SearchEmails(SyncedAfter) = %v, want only new", found)
+	}
+}
+
+func TestListEmails_DateRange(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)
+	plus2 := time.FixedZone("+02:00", 2*60*60)
+	emails := []domain.Email{
+		{ID: "before-start", ThreadID: "t1", Date: start.Add(-time.Second)},
+		{ID: "at-start", ThreadID: "t2", Date: start},
+		{ID: "inside", ThreadID: "t3", Date: start.AddDate(0, 0, 3)},
+		// 01:30 at +02:00 is still 23:30 UTC on the last day of the range.
+		{ID: "offset-inside", ThreadID: "t4", Date: time.Date(2025, 6, 8, 1, 30, 0, 0, plus2)},
+		{ID: "at-end", ThreadID: "t5", Date: end},
+		// The received time wins over a Date header that falls in range.
+		{ID: "received-after", ThreadID: "t6", Date: start.AddDate(0, 0, 1), ReceivedAt: end.AddDate(0, 0, 1)},
+	}
+	if err := db.UpsertEmails(ctx, emails, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		after, before time.Time
+		want          []string
+	}{
+		{"range", start, end, []string{"offset-inside", "inside", "at-start"}},
+		{"after only", end, time.Time{}, []string{"received-after", "at-end"}},
+		{"before only", time.Time{}, start, []string{"before-start"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.ListEmails(ctx, store.ListEmailOptions{AccountID: "acc-1", After: tt.after, Before: tt.before})
+			if err != nil {
+				t.Fatalf("ListEmails() error: %v", err)
+			}
+			var ids []string
+			for _, e := range got {
+				ids = append(ids, e.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("ListEmails() = %v, want %v", ids, tt.want)
+			}
+
+			threads, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "acc-1", After: tt.after, Before: tt.before})
+			if err != nil {
+				t.Fatalf("ListThreads() error: %v", err)
+			}
+			if len(threads) != len(tt.want) {
+				t.Errorf("ListThreads() returned %d threads, want %d", len(threads), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestListBySender(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	base := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	ann := domain.Address{Name: "Ann", Email: "ann@example.com"}
+	emails := []domain.Email{
+		{ID: "old", ThreadID: "t1", From: ann, Date: base, Labels: []string{domain.LabelInbox}},
+		{ID: "new", ThreadID: "t2", From: domain.Address{Email: "Ann@Example.com"}, Date: base.Add(time.Hour)},
+		{ID: "binned", ThreadID: "t3", From: ann, Date: base, Labels: []string{domain.LabelTrash}},
+		{ID: "other", ThreadID: "t1", From: domain.Address{Email: "bob@example.com"}, Date: base},
+	}
+	if err := db.UpsertEmails(ctx, emails, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+
+	got, err := db.ListBySender(ctx, "acc-1", "ann@example.com", 0)
+	if err != nil {
+		t.Fatalf("ListBySender() error: %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "new" || got[1].ID != "old" {
+		t.Fatalf("ListBySender() = %+v, want new then old", got)
+	}
+
+	got, err = db.ListBySender(ctx, "acc-1", "ann@example.com", 1)
+	if err != nil {
+		t.Fatalf("ListBySender(limit 1) error: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "new" {
+		t.Errorf("ListBySender(limit 1) = %+v, want only new", got)
+	}
+}
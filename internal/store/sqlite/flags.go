@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// emailFlagsColumn selects an email's local flags as a comma-separated list.
+const emailFlagsColumn = `COALESCE((SELECT GROUP_CONCAT(f.flag) FROM email_flags f WHERE f.email_id = e.id), '')`
+
+// threadFlagsColumn selects the union of local flags across a thread.
+const threadFlagsColumn = `COALESCE((SELECT GROUP_CONCAT(DISTINCT f.flag) FROM email_flags f
+	JOIN emails ef ON ef.id = f.email_id
+	WHERE ef.thread_id = e.thread_id AND ef.account_id = e.account_id), '')`
+
+// SetEmailFlag sets or clears a local flag on an email.
+func (s *DB) SetEmailFlag(ctx context.Context, emailID, flag string, set bool) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	var err error
+	if set {
+		_, err = s.db.ExecContext(ctx,
+			`INSERT OR IGNORE INTO email_flags (email_id, flag) VALUES (?, ?)`, emailID, flag)
+	} else {
+		_, err = s.db.ExecContext(ctx,
+			`DELETE FROM email_flags WHERE email_id = ? AND flag = ?`, emailID, flag)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set email %s flag %s=%v: %w", emailID, flag, set, err)
+	}
+	return nil
+}
+
+// splitFlags parses a GROUP_CONCAT flag list into a sorted slice.
+func splitFlags(s string) []string {
+	if s == "" {
+		return nil
+	}
+	flags := strings.Split(s, ",")
+	sort.Strings(flags)
+	return flags
+}
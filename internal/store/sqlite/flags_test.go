@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+func TestSetEmailFlag(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	email := domain.Email{ID: "msg-1", ThreadID: "thread-1", Date: time.Now()}
+	if err := db.UpsertEmail(ctx, &email, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+
+	for _, flag := range []string{domain.FlagTodo, domain.FlagFollowUp, domain.FlagTodo} {
+		if err := db.SetEmailFlag(ctx, "msg-1", flag, true); err != nil {
+			t.Fatalf("SetEmailFlag(%s) error: %v", flag, err)
+		}
+	}
+	got, err := db.GetEmail(ctx, "msg-1", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if want := []string{domain.FlagFollowUp, domain.FlagTodo}; !slices.Equal(got.Flags, want) {
+		t.Errorf("Flags = %v, want %v", got.Flags, want)
+	}
+
+	if err := db.SetEmailFlag(ctx, "msg-1", domain.FlagTodo, false); err != nil {
+		t.Fatalf("SetEmailFlag(clear) error: %v", err)
+	}
+	got, err = db.GetEmail(ctx, "msg-1", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if want := []string{domain.FlagFollowUp}; !slices.Equal(got.Flags, want) {
+		t.Errorf("Flags after clear = %v, want %v", got.Flags, want)
+	}
+
+	// Flags are local and must survive a re-sync of the message.
+	if err := db.UpsertEmail(ctx, &email, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+	got, err = db.GetEmail(ctx, "msg-1", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if len(got.Flags) != 1 {
+		t.Errorf("Flags after upsert = %v, want flags kept", got.Flags)
+	}
+}
+
+func TestListByFlag(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	baseDate := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	emails := []domain.Email{
+		{ID: "t1-m0", ThreadID: "thread-1", Date: baseDate, Labels: []string{"INBOX"}},
+		{ID: "t1-m1", ThreadID: "thread-1", Date: baseDate.Add(time.Hour), Labels: []string{"INBOX"}},
+		{ID: "t2-m0", ThreadID: "thread-2", Date: baseDate.Add(2 * time.Hour), Labels: []string{"INBOX"}},
+	}
+	for i := range emails {
+		if err := db.UpsertEmail(ctx, &emails[i], "acc-1"); err != nil {
+			t.Fatalf("UpsertEmail() error: %v", err)
+		}
+	}
+	if err := db.SetEmailFlag(ctx, "t1-m0", domain.FlagWaiting, true); err != nil {
+		t.Fatalf("SetEmailFlag() error: %v", err)
+	}
+
+	for _, labelID := range []string{"", "INBOX"} {
+		list, err := db.ListEmails(ctx, store.ListEmailOptions{AccountID: "acc-1", LabelID: labelID, Flag: domain.FlagWaiting})
+		if err != nil {
+			t.Fatalf("ListEmails() error: %v", err)
+		}
+		if len(list) != 1 || list[0].ID != "t1-m0" || !slices.Equal(list[0].Flags, []string{domain.FlagWaiting}) {
+			t.Errorf("ListEmails(label=%q, flag=waiting) = %+v, want only t1-m0 flagged", labelID, list)
+		}
+
+		threads, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "acc-1", LabelID: labelID, Flag: domain.FlagWaiting})
+		if err != nil {
+			t.Fatalf("ListThreads() error: %v", err)
+		}
+		if len(threads) != 1 || threads[0].ID != "thread-1" {
+			t.Fatalf("ListThreads(label=%q, flag=waiting) = %+v, want only thread-1", labelID, threads)
+		}
+		if threads[0].MessageCount() != 2 {
+			t.Errorf("thread-1 MessageCount() = %d, want 2 (filter must not drop unflagged messages)", threads[0].MessageCount())
+		}
+		if !slices.Equal(threads[0].Flags, []string{domain.FlagWaiting}) {
+			t.Errorf("thread-1 Flags = %v, want [waiting]", threads[0].Flags)
+		}
+	}
+
+	none, err := db.ListEmails(ctx, store.ListEmailOptions{AccountID: "acc-1", Flag: domain.FlagTodo})
+	if err != nil {
+		t.Fatalf("ListEmails() error: %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("ListEmails(flag=todo) = %d emails, want 0", len(none))
+	}
+}
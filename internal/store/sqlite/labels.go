@@ -3,12 +3,35 @@ package sqlite
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/lu-zhengda/termail/internal/domain"
 )
 
+// emailLabelsColumn selects an email's label IDs as a comma-separated list.
+const emailLabelsColumn = `COALESCE((SELECT GROUP_CONCAT(l.label_id) FROM email_labels l WHERE l.email_id = e.id), '')`
+
+// threadLabelsColumn selects the union of label IDs across a thread.
+const threadLabelsColumn = `COALESCE((SELECT GROUP_CONCAT(DISTINCT l.label_id) FROM email_labels l
+	JOIN emails el2 ON el2.id = l.email_id
+	WHERE el2.thread_id = e.thread_id AND el2.account_id = e.account_id), '')`
+
+// splitLabels parses a GROUP_CONCAT label list into a sorted slice.
+func splitLabels(s string) []string {
+	if s == "" {
+		return nil
+	}
+	labels := strings.Split(s, ",")
+	sort.Strings(labels)
+	return labels
+}
+
 // UpsertLabel inserts or updates a label.
 func (s *DB) UpsertLabel(ctx context.Context, label *domain.Label) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	_, err := s.db.ExecContext(ctx, `
 		INSERT INTO labels (id, account_id, name, type, color)
 		VALUES (?, ?, ?, ?, ?)
@@ -49,3 +72,26 @@ func (s *DB) ListLabels(ctx context.Context, accountID string) ([]domain.Label,
 
 	return labels, nil
 }
+
+// DeleteLabel removes a label and detaches it from every email.
+func (s *DB) DeleteLabel(ctx context.Context, labelID string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM email_labels WHERE label_id = ?`, labelID); err != nil {
+		return fmt.Errorf("failed to detach label %s: %w", labelID, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM labels WHERE id = ?`, labelID); err != nil {
+		return fmt.Errorf("failed to delete label %s: %w", labelID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit label delete: %w", err)
+	}
+	return nil
+}
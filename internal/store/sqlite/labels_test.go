@@ -78,3 +78,38 @@ func TestUpsertAndListLabels(t *testing.T) {
 		t.Error("updated label lbl-custom not found in list")
 	}
 }
+
+func TestDeleteLabel(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	label := domain.Label{ID: "Label_1", AccountID: "acc-1", Name: "Work", Type: domain.LabelTypeUser}
+	if err := db.UpsertLabel(ctx, &label); err != nil {
+		t.Fatalf("UpsertLabel() error: %v", err)
+	}
+	email := domain.Email{ID: "msg-1", ThreadID: "thread-1", Labels: []string{"INBOX", "Label_1"}}
+	if err := db.UpsertEmail(ctx, &email, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+
+	if err := db.DeleteLabel(ctx, "Label_1"); err != nil {
+		t.Fatalf("DeleteLabel() error: %v", err)
+	}
+
+	labels, err := db.ListLabels(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("ListLabels() error: %v", err)
+	}
+	if len(labels) != 0 {
+		t.Errorf("ListLabels() = %v, want none after delete", labels)
+	}
+
+	got, err := db.GetEmail(ctx, "msg-1", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if len(got.Labels) != 1 || got.Labels[0] != "INBOX" {
+		t.Errorf("email labels = %v, want [INBOX]", got.Labels)
+	}
+}
@@ -1,12 +1,53 @@
 package sqlite
 
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migration is one versioned schema change. Applied versions are recorded
+// in schema_migrations so each step runs exactly once per database.
+type migration struct {
+	version int
+	up      string
+	// upgrade, if set, runs after up in the same transaction.
+	upgrade func(tx *sql.Tx) error
+}
+
+// migrations lists the schema changes in the order they are applied.
+// Append new steps with the next version; never edit or reorder a step
+// that has shipped. Version 1 is the schema from before versioning, which
+// uses IF NOT EXISTS so databases created back then take it cleanly, and
+// adds the columns such databases may lack.
+var migrations = []migration{
+	{1, schema + ftsSchema, addLegacyColumns},
+	{version: 2, up: `
+CREATE TABLE recent_views (
+    account_id  TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    thread_id   TEXT NOT NULL,
+    email_id    TEXT NOT NULL,
+    viewed_at   INTEGER NOT NULL,
+    PRIMARY KEY (account_id, thread_id)
+);
+`},
+	{version: 3, up: threadSummariesSchema},
+}
+
+const migrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version     INTEGER PRIMARY KEY,
+    applied_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+`
+
 const schema = `
 CREATE TABLE IF NOT EXISTS accounts (
     id          TEXT PRIMARY KEY,
     email       TEXT NOT NULL UNIQUE,
     provider    TEXT NOT NULL DEFAULT 'gmail',
     display_name TEXT,
-    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    messages_total INTEGER
 );
 
 CREATE TABLE IF NOT EXISTS emails (
@@ -27,7 +68,16 @@ CREATE TABLE IF NOT EXISTS emails (
     in_reply_to TEXT,
     history_id  INTEGER,
     raw_size    INTEGER,
-    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+    created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+    list_unsubscribe TEXT,
+    calendar_event   TEXT,
+    message_id       TEXT,
+    refs             TEXT,
+    snoozed_until    INTEGER,
+    list_id          TEXT,
+    list_post        TEXT,
+    received_at      DATETIME,
+    bcc_addrs        TEXT
 );
 
 CREATE TABLE IF NOT EXISTS email_labels (
@@ -44,6 +94,12 @@ CREATE TABLE IF NOT EXISTS labels (
     color       TEXT
 );
 
+CREATE TABLE IF NOT EXISTS email_flags (
+    email_id    TEXT NOT NULL REFERENCES emails(id) ON DELETE CASCADE,
+    flag        TEXT NOT NULL,
+    PRIMARY KEY (email_id, flag)
+);
+
 CREATE TABLE IF NOT EXISTS attachments (
     id          TEXT PRIMARY KEY,
     email_id    TEXT NOT NULL REFERENCES emails(id) ON DELETE CASCADE,
@@ -52,6 +108,14 @@ CREATE TABLE IF NOT EXISTS attachments (
     size        INTEGER
 );
 
+CREATE TABLE IF NOT EXISTS outbox (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    account_id  TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    message     TEXT NOT NULL,
+    send_at     INTEGER NOT NULL,
+    created_at  INTEGER NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS sync_state (
     account_id  TEXT PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
     history_id  INTEGER,
@@ -62,8 +126,73 @@ CREATE INDEX IF NOT EXISTS idx_emails_account ON emails(account_id);
 CREATE INDEX IF NOT EXISTS idx_emails_thread ON emails(thread_id);
 CREATE INDEX IF NOT EXISTS idx_emails_date ON emails(date DESC);
 CREATE INDEX IF NOT EXISTS idx_email_labels_label ON email_labels(label_id);
+CREATE INDEX IF NOT EXISTS idx_email_flags_flag ON email_flags(flag);
 `
 
+// legacyColumns lists the columns that were added to the schema with ALTER
+// TABLE before migrations were versioned. A database created back then may
+// lack any of them; newer ones get them from schema. Later columns belong in
+// a numbered migration.
+var legacyColumns = []struct {
+	table  string
+	column string
+	decl   string
+}{
+	{"emails", "list_unsubscribe", "TEXT"},
+	{"emails", "calendar_event", "TEXT"},
+	{"emails", "message_id", "TEXT"},
+	{"emails", "refs", "TEXT"},
+	{"emails", "snoozed_until", "INTEGER"},
+	{"emails", "list_id", "TEXT"},
+	{"emails", "list_post", "TEXT"},
+	{"emails", "received_at", "DATETIME"},
+	{"accounts", "messages_total", "INTEGER"},
+	{"emails", "bcc_addrs", "TEXT"},
+}
+
+// addLegacyColumns adds the legacyColumns a database created before
+// versioning is missing.
+func addLegacyColumns(tx *sql.Tx) error {
+	for _, c := range legacyColumns {
+		if err := addColumnIfMissing(tx, c.table, c.column, c.decl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumnIfMissing adds a column to an existing table unless it is already present.
+func addColumnIfMissing(tx *sql.Tx, table, column, decl string) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, colType    string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return fmt.Errorf("failed to scan column info for %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate column info for %s: %w", table, err)
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, decl)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
 const ftsSchema = `
 CREATE VIRTUAL TABLE IF NOT EXISTS emails_fts USING fts5(
     subject, body_text, from_addr, from_name,
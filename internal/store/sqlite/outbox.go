@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// EnqueueOutbox stores an outgoing email to be sent at sendAt and returns
+// its outbox ID.
+func (s *DB) EnqueueOutbox(ctx context.Context, accountID string, email *domain.Email, sendAt time.Time) (int64, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	data, err := json.Marshal(email)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal outbox message: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO outbox (account_id, message, send_at, created_at) VALUES (?, ?, ?, ?)`,
+		accountID, string(data), sendAt.UnixMilli(), time.Now().UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue outbox message: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read outbox ID: %w", err)
+	}
+	return id, nil
+}
+
+// ListOutbox returns an account's queued emails, earliest due first.
+func (s *DB) ListOutbox(ctx context.Context, accountID string) ([]store.OutboxItem, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, account_id, message, send_at, created_at FROM outbox
+		WHERE account_id = ? ORDER BY send_at, id`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var items []store.OutboxItem
+	for rows.Next() {
+		var item store.OutboxItem
+		var data string
+		var sendAt, createdAt int64
+		if err := rows.Scan(&item.ID, &item.AccountID, &data, &sendAt, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox row: %w", err)
+		}
+		if err := json.Unmarshal([]byte(data), &item.Email); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal outbox message %d: %w", item.ID, err)
+		}
+		item.SendAt = time.UnixMilli(sendAt)
+		item.CreatedAt = time.UnixMilli(createdAt)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate outbox: %w", err)
+	}
+	return items, nil
+}
+
+// DeleteOutbox removes a queued email. It returns store.ErrNotFound if the
+// email was already sent or cancelled.
+func (s *DB) DeleteOutbox(ctx context.Context, id int64) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM outbox WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete outbox message %d: %w", id, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete outbox message %d: %w", id, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("outbox message %d: %w", id, store.ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+func TestOutbox(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	now := time.Now()
+	late := &domain.Email{Subject: "Later", To: []domain.Address{{Email: "bob@test.com"}}}
+	soon := &domain.Email{Subject: "Sooner", Body: "hi"}
+
+	lateID, err := db.EnqueueOutbox(ctx, "acc-1", late, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("EnqueueOutbox() error: %v", err)
+	}
+	if _, err := db.EnqueueOutbox(ctx, "acc-1", soon, now.Add(time.Second)); err != nil {
+		t.Fatalf("EnqueueOutbox() error: %v", err)
+	}
+
+	items, err := db.ListOutbox(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("ListOutbox() error: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+	if items[0].Email.Subject != "Sooner" || items[1].Email.Subject != "Later" {
+		t.Errorf("items not ordered by send time: %q, %q", items[0].Email.Subject, items[1].Email.Subject)
+	}
+	if got := items[1].Email.To; len(got) != 1 || got[0].Email != "bob@test.com" {
+		t.Errorf("To = %v, want bob@test.com", got)
+	}
+
+	if err := db.DeleteOutbox(ctx, lateID); err != nil {
+		t.Fatalf("DeleteOutbox() error: %v", err)
+	}
+	if err := db.DeleteOutbox(ctx, lateID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("second DeleteOutbox() error = %v, want ErrNotFound", err)
+	}
+
+	items, err = db.ListOutbox(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("ListOutbox() error: %v", err)
+	}
+	if len(items) != 1 {
+		t.Errorf("got %d items after delete, want 1", len(items))
+	}
+}
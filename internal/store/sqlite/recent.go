@@ -0,0 +1,89 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// recentViewsCap is how many recently viewed threads are kept per account.
+const recentViewsCap = 50
+
+// RecordView notes that emailID in threadID was opened at at. A thread is
+// listed once, under its latest view; only the newest recentViewsCap
+// threads of the account are kept.
+func (s *DB) RecordView(ctx context.Context, accountID, threadID, emailID string, at time.Time) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO recent_views (account_id, thread_id, email_id, viewed_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(account_id, thread_id) DO UPDATE SET
+			email_id  = excluded.email_id,
+			viewed_at = excluded.viewed_at`,
+		accountID, threadID, emailID, at.UnixMilli()); err != nil {
+		return fmt.Errorf("failed to record view of %s: %w", emailID, err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM recent_views WHERE account_id = ? AND thread_id NOT IN (
+			SELECT thread_id FROM recent_views WHERE account_id = ?
+			ORDER BY viewed_at DESC LIMIT ?)`,
+		accountID, accountID, recentViewsCap); err != nil {
+		return fmt.Errorf("failed to trim recent views: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit view: %w", err)
+	}
+	return nil
+}
+
+// ListRecent returns up to limit of an account's recently viewed threads,
+// most recent first. Views of messages since removed from the store are
+// skipped. A limit of zero or less lists all that are kept.
+func (s *DB) ListRecent(ctx context.Context, accountID string, limit int) ([]store.RecentView, error) {
+	if limit <= 0 {
+		limit = recentViewsCap
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, e.thread_id, e.from_addr, e.from_name, e.subject, e.date, e.is_read,
+			r.viewed_at
+		FROM recent_views r
+		JOIN emails e ON e.id = r.email_id AND e.account_id = r.account_id
+		WHERE r.account_id = ?
+		ORDER BY r.viewed_at DESC
+		LIMIT ?`, accountID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recent views: %w", err)
+	}
+	defer rows.Close()
+
+	var views []store.RecentView
+	for rows.Next() {
+		var v store.RecentView
+		var fromName, dateStr string
+		var viewedAt int64
+		if err := rows.Scan(&v.Email.ID, &v.Email.ThreadID, &v.Email.From.Email, &fromName,
+			&v.Email.Subject, &dateStr, &v.Email.IsRead, &viewedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan recent view: %w", err)
+		}
+		v.Email.From.Name = fromName
+		if v.Email.Date, err = time.Parse(time.RFC3339, dateStr); err != nil {
+			return nil, fmt.Errorf("failed to parse email date: %w", err)
+		}
+		v.ViewedAt = time.UnixMilli(viewedAt)
+		views = append(views, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate recent views: %w", err)
+	}
+	return views, nil
+}
@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+func TestRecordView_OrderAndCap(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	total := recentViewsCap + 5
+	emails := make([]domain.Email, total)
+	for i := range emails {
+		emails[i] = domain.Email{
+			ID:       fmt.Sprintf("msg-%d", i),
+			ThreadID: fmt.Sprintf("thread-%d", i),
+			Subject:  fmt.Sprintf("Subject %d", i),
+			Date:     time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC),
+		}
+	}
+	if err := db.UpsertEmails(ctx, emails, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+
+	base := time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC)
+	for i, e := range emails {
+		if err := db.RecordView(ctx, "acc-1", e.ThreadID, e.ID, base.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("RecordView(%s) error: %v", e.ID, err)
+		}
+	}
+	// Reopening an old thread moves it to the top instead of adding a row.
+	if err := db.RecordView(ctx, "acc-1", "thread-10", "msg-10", base.Add(time.Hour*24)); err != nil {
+		t.Fatalf("RecordView() error: %v", err)
+	}
+
+	all, err := db.ListRecent(ctx, "acc-1", 0)
+	if err != nil {
+		t.Fatalf("ListRecent() error: %v", err)
+	}
+	if len(all) != recentViewsCap {
+		t.Fatalf("ListRecent() returned %d views, want the cap of %d", len(all), recentViewsCap)
+	}
+	if all[0].Email.ID != "msg-10" {
+		t.Errorf("most recent = %s, want msg-10", all[0].Email.ID)
+	}
+	if want := fmt.Sprintf("msg-%d", total-1); all[1].Email.ID != want {
+		t.Errorf("second most recent = %s, want %s", all[1].Email.ID, want)
+	}
+	for _, v := range all {
+		if v.Email.ID == "msg-0" {
+			t.Error("oldest view msg-0 should have been dropped by the cap")
+		}
+	}
+
+	top, err := db.ListRecent(ctx, "acc-1", 3)
+	if err != nil {
+		t.Fatalf("ListRecent(3) error: %v", err)
+	}
+	if len(top) != 3 || top[0].Email.Subject != "Subject 10" {
+		t.Errorf("ListRecent(3) = %+v, want 3 views starting with Subject 10", top)
+	}
+}
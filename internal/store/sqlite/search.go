@@ -7,17 +7,39 @@ import (
 	"time"
 
 	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
 )
 
-// SearchEmails performs a full-text search across emails using FTS5.
-func (s *DB) SearchEmails(ctx context.Context, query string, accountID string) ([]domain.Email, error) {
-	rows, err := s.db.QueryContext(ctx, `
+// SearchEmails performs a full-text search across emails using FTS5, best
+// match first. Each result's Snippet is an excerpt of the best matching
+// column with the matched terms marked. By default messages in TRASH or
+// SPAM are excluded; see store.SearchOptions.
+func (s *DB) SearchEmails(ctx context.Context, query string, accountID string, opts store.SearchOptions) ([]domain.Email, error) {
+	sqlQuery := `
 		SELECT e.id, e.thread_id, e.from_addr, e.from_name, e.to_addrs, e.cc_addrs,
-			e.subject, e.body_text, e.body_html, e.date, e.is_read, e.is_starred, e.in_reply_to
+			e.subject, e.body_text, e.body_html, e.date, e.is_read, e.is_starred, e.in_reply_to,
+			COALESCE(e.bcc_addrs, ''),
+			snippet(emails_fts, -1, ?, ?, '…', 16)
 		FROM emails e
 		JOIN emails_fts fts ON fts.rowid = e.rowid
-		WHERE emails_fts MATCH ? AND e.account_id = ?
-		ORDER BY rank`, query, accountID)
+		WHERE emails_fts MATCH ? AND e.account_id = ?`
+	args := []any{domain.SnippetMatchStart, domain.SnippetMatchEnd, query, accountID}
+
+	if !opts.IncludeTrash {
+		sqlQuery += ` AND NOT EXISTS (SELECT 1 FROM email_labels el
+			WHERE el.email_id = e.id AND el.label_id IN (?, ?))`
+		args = append(args, domain.LabelTrash, domain.LabelSpam)
+	}
+	if opts.InboxOnly {
+		sqlQuery += ` AND EXISTS (SELECT 1 FROM email_labels el
+			WHERE el.email_id = e.id AND el.label_id = ?)`
+		args = append(args, domain.LabelInbox)
+	}
+	sqlQuery, args = appendDateRange(sqlQuery, args, opts.After, opts.Before)
+	sqlQuery, args = appendSyncedAfter(sqlQuery, args, opts.SyncedAfter)
+	sqlQuery += " ORDER BY rank"
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search emails: %w", err)
 	}
@@ -27,13 +49,13 @@ func (s *DB) SearchEmails(ctx context.Context, query string, accountID string) (
 	for rows.Next() {
 		var e domain.Email
 		var fromAddr, fromName string
-		var toJSON, ccJSON string
+		var toJSON, ccJSON, bccJSON string
 		var dateStr string
 
 		if err := rows.Scan(
 			&e.ID, &e.ThreadID, &fromAddr, &fromName, &toJSON, &ccJSON,
 			&e.Subject, &e.Body, &e.BodyHTML, &dateStr,
-			&e.IsRead, &e.IsStarred, &e.InReplyTo,
+			&e.IsRead, &e.IsStarred, &e.InReplyTo, &bccJSON, &e.Snippet,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan search result: %w", err)
 		}
@@ -50,6 +72,11 @@ func (s *DB) SearchEmails(ctx context.Context, query string, accountID string) (
 				return nil, fmt.Errorf("failed to unmarshal CC addresses: %w", err)
 			}
 		}
+		if bccJSON != "" {
+			if err := json.Unmarshal([]byte(bccJSON), &e.BCC); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal BCC addresses: %w", err)
+			}
+		}
 
 		parsedDate, err := time.Parse(time.RFC3339, dateStr)
 		if err != nil {
@@ -2,7 +2,10 @@ package sqlite
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -27,7 +30,7 @@ func TestSearchEmails(t *testing.T) {
 		}
 	}
 
-	results, err := db.SearchEmails(ctx, "project", "acc-1")
+	results, err := db.SearchEmails(ctx, "project", "acc-1", store.SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchEmails() error: %v", err)
 	}
@@ -39,6 +42,39 @@ func TestSearchEmails(t *testing.T) {
 	}
 }
 
+func TestSearchEmails_Snippet(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	body := "Hi all, attached is the draft plan for next year. The revised budget " +
+		"covers two new hires and the conference trip. Comments welcome by Thursday."
+	email := domain.Email{ID: "m1", ThreadID: "t1", From: domain.Address{Email: "alice@test.com"},
+		Subject: "Planning", Body: body, Date: time.Now()}
+	if err := db.UpsertEmail(ctx, &email, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+
+	results, err := db.SearchEmails(ctx, "budget", "acc-1", store.SearchOptions{})
+	if err != nil {
+		t.Fatalf("SearchEmails() error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	snippet := results[0].Snippet
+	if !strings.Contains(snippet, domain.SnippetMatchStart+"budget"+domain.SnippetMatchEnd) {
+		t.Errorf("snippet %q should mark the matched term", snippet)
+	}
+	plain := results[0].PlainSnippet()
+	if !strings.Contains(plain, "The revised budget covers") || strings.ContainsAny(plain, "\x02\x03") {
+		t.Errorf("PlainSnippet() = %q, want the excerpt without markers", plain)
+	}
+	if len(plain) >= len(body) {
+		t.Errorf("snippet should be an excerpt, got the whole body: %q", plain)
+	}
+}
+
 func TestSearchEmails_NoResults(t *testing.T) {
 	db := newTestDB(t)
 	seedAccount(t, db)
@@ -56,7 +92,7 @@ func TestSearchEmails_NoResults(t *testing.T) {
 		t.Fatalf("UpsertEmail() error: %v", err)
 	}
 
-	results, err := db.SearchEmails(ctx, "nonexistent", "acc-1")
+	results, err := db.SearchEmails(ctx, "nonexistent", "acc-1", store.SearchOptions{})
 	if err != nil {
 		t.Fatalf("SearchEmails() error: %v", err)
 	}
@@ -65,6 +101,50 @@ func TestSearchEmails_NoResults(t *testing.T) {
 	}
 }
 
+func TestSearchEmails_TrashFilter(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	emails := []domain.Email{
+		{ID: "inbox", ThreadID: "t1", Subject: "Invoice", Date: time.Now(), Labels: []string{domain.LabelInbox}},
+		{ID: "archived", ThreadID: "t2", Subject: "Invoice", Date: time.Now()},
+		{ID: "trashed", ThreadID: "t3", Subject: "Invoice", Date: time.Now(), Labels: []string{domain.LabelTrash}},
+		{ID: "spam", ThreadID: "t4", Subject: "Invoice", Date: time.Now(), Labels: []string{domain.LabelSpam}},
+	}
+	for i := range emails {
+		if err := db.UpsertEmail(ctx, &emails[i], "acc-1"); err != nil {
+			t.Fatalf("UpsertEmail(%d) error: %v", i, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts store.SearchOptions
+		want []string
+	}{
+		{"default", store.SearchOptions{}, []string{"archived", "inbox"}},
+		{"include trash", store.SearchOptions{IncludeTrash: true}, []string{"archived", "inbox", "spam", "trashed"}},
+		{"inbox only", store.SearchOptions{InboxOnly: true}, []string{"inbox"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := db.SearchEmails(ctx, "invoice", "acc-1", tt.opts)
+			if err != nil {
+				t.Fatalf("SearchEmails() error: %v", err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, r.ID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetThread(t *testing.T) {
 	db := newTestDB(t)
 	seedAccount(t, db)
@@ -135,8 +215,8 @@ func TestGetThread_NotFound(t *testing.T) {
 	ctx := context.Background()
 
 	_, err := db.GetThread(ctx, "nonexistent", "acc-1")
-	if err == nil {
-		t.Fatal("GetThread() expected error for nonexistent thread, got nil")
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("GetThread() error = %v, want ErrNotFound", err)
 	}
 }
 
@@ -354,3 +434,162 @@ func TestSyncState(t *testing.T) {
 		t.Errorf("LastSync = %d, want %d", state.LastSync, now+100)
 	}
 }
+
+func TestListThreads_HasStarred(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	baseDate := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	emails := []domain.Email{
+		{ID: "t1-m0", ThreadID: "thread-1", Subject: "Starred early", Date: baseDate},
+		{ID: "t1-m1", ThreadID: "thread-1", Subject: "Re: Starred early", Date: baseDate.Add(time.Hour)},
+		{ID: "t2-m0", ThreadID: "thread-2", Subject: "Plain", Date: baseDate.Add(2 * time.Hour)},
+	}
+	for i := range emails {
+		if err := db.UpsertEmail(ctx, &emails[i], "acc-1"); err != nil {
+			t.Fatalf("UpsertEmail() error: %v", err)
+		}
+	}
+	if err := db.SetEmailStarred(ctx, "t1-m0", "acc-1", true); err != nil {
+		t.Fatalf("SetEmailStarred() error: %v", err)
+	}
+
+	threads, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "acc-1"})
+	if err != nil {
+		t.Fatalf("ListThreads() error: %v", err)
+	}
+	starred := map[string]bool{}
+	for _, th := range threads {
+		starred[th.ID] = th.HasStarred
+	}
+	if !starred["thread-1"] {
+		t.Error("thread-1 should be starred when any message is starred")
+	}
+	if starred["thread-2"] {
+		t.Error("thread-2 should not be starred")
+	}
+
+	got, err := db.GetEmail(ctx, "t1-m0", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if !got.IsStarred || !got.HasLabel(domain.LabelStarred) {
+		t.Errorf("GetEmail() IsStarred = %v, labels = %v; want starred with STARRED label", got.IsStarred, got.Labels)
+	}
+}
+
+func TestListThreads_SortPriority(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	baseDate := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	emails := []domain.Email{
+		{ID: "old-m0", ThreadID: "old", Subject: "Unread and starred", Date: baseDate, IsStarred: true, Labels: []string{"INBOX"}},
+		{ID: "imp-m0", ThreadID: "important", Subject: "Important", Date: baseDate.Add(time.Hour), IsRead: true, Labels: []string{"INBOX", "IMPORTANT"}},
+		{ID: "new-m0", ThreadID: "new", Subject: "Read", Date: baseDate.Add(2 * time.Hour), IsRead: true, Labels: []string{"INBOX"}},
+	}
+	for i := range emails {
+		if err := db.UpsertEmail(ctx, &emails[i], "acc-1"); err != nil {
+			t.Fatalf("UpsertEmail() error: %v", err)
+		}
+	}
+
+	for _, labelID := range []string{"", "INBOX"} {
+		threads, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "acc-1", LabelID: labelID, Sort: store.SortPriority})
+		if err != nil {
+			t.Fatalf("ListThreads(%q) error: %v", labelID, err)
+		}
+		var got []string
+		for _, th := range threads {
+			got = append(got, th.ID)
+		}
+		want := []string{"old", "important", "new"}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("ListThreads(label=%q, priority) order = %v, want %v", labelID, got, want)
+		}
+	}
+
+	threads, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "acc-1", Sort: store.SortDate})
+	if err != nil {
+		t.Fatalf("ListThreads() error: %v", err)
+	}
+	if len(threads) != 3 || threads[0].ID != "new" {
+		t.Errorf("ListThreads(date) first = %v, want newest thread first", threads)
+	}
+}
+
+func TestReconstructThreads(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	base := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	emails := []domain.Email{
+		{ID: "m1", Subject: "Plan", Date: base, MessageID: "<a@x>"},
+		{ID: "m2", Subject: "Re: Plan", Date: base.Add(time.Hour), MessageID: "<b@x>", InReplyTo: "<a@x>"},
+		{ID: "m3", Subject: "Re: Plan", Date: base.Add(2 * time.Hour), MessageID: "<c@x>",
+			InReplyTo: "<b@x>", References: []string{"<a@x>", "<b@x>"}},
+		{ID: "other", Subject: "Unrelated", Date: base, MessageID: "<z@x>"},
+	}
+	for i := range emails {
+		if err := db.UpsertEmail(ctx, &emails[i], "acc-1"); err != nil {
+			t.Fatalf("UpsertEmail(%d) error: %v", i, err)
+		}
+	}
+
+	if n, err := db.ReconstructThreads(ctx, "acc-1"); err != nil || n != 4 {
+		t.Fatalf("ReconstructThreads() = %d, %v; want 4, nil", n, err)
+	}
+	threads, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "acc-1"})
+	if err != nil {
+		t.Fatalf("ListThreads() error: %v", err)
+	}
+	if len(threads) != 2 {
+		t.Fatalf("got %d threads, want 2", len(threads))
+	}
+
+	counts := make(map[string]int)
+	for _, th := range threads {
+		if th.ID == "" {
+			t.Error("thread has empty ID after reconstruction")
+		}
+		counts[th.Subject] = th.MessageCount()
+	}
+	if counts["Plan"] != 3 || counts["Unrelated"] != 1 {
+		t.Errorf("thread sizes = %v, want Plan:3 Unrelated:1", counts)
+	}
+
+	// Reconstruction is stable and idempotent.
+	if n, err := db.ReconstructThreads(ctx, "acc-1"); err != nil || n != 0 {
+		t.Errorf("second ReconstructThreads() = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestReconstructThreads_JoinsExistingThread(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	emails := []domain.Email{
+		{ID: "m1", ThreadID: "gmail-thread", Date: time.Now(), MessageID: "<a@x>"},
+		{ID: "m2", Date: time.Now(), MessageID: "<b@x>", InReplyTo: "<a@x>"},
+	}
+	for i := range emails {
+		if err := db.UpsertEmail(ctx, &emails[i], "acc-1"); err != nil {
+			t.Fatalf("UpsertEmail(%d) error: %v", i, err)
+		}
+	}
+
+	if n, err := db.ReconstructThreads(ctx, "acc-1"); err != nil || n != 1 {
+		t.Fatalf("ReconstructThreads() = %d, %v; want 1, nil", n, err)
+	}
+	got, err := db.GetEmail(ctx, "m2", "acc-1")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	if got.ThreadID != "gmail-thread" {
+		t.Errorf("ThreadID = %q, want gmail-thread", got.ThreadID)
+	}
+}
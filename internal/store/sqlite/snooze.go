@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// notSnoozedCond excludes emails snoozed past the bound Unix time.
+const notSnoozedCond = `COALESCE(e.snoozed_until, 0) <= ?`
+
+// SnoozeEmail hides an email from the inbox until the given time. A zero
+// time clears the snooze.
+func (s *DB) SnoozeEmail(ctx context.Context, id string, until time.Time) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	var value any
+	if !until.IsZero() {
+		value = until.Unix()
+	}
+	res, err := s.db.ExecContext(ctx, `UPDATE emails SET snoozed_until = ? WHERE id = ?`, value, id)
+	if err != nil {
+		return fmt.Errorf("failed to snooze email %s: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("email %s not found", id)
+	}
+	return nil
+}
+
+// WakeSnoozed clears snoozes that expired at or before now and returns how
+// many emails resurfaced.
+func (s *DB) WakeSnoozed(ctx context.Context, accountID string, now time.Time) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE emails SET snoozed_until = NULL
+		WHERE account_id = ? AND snoozed_until IS NOT NULL AND snoozed_until <= ?`,
+		accountID, now.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to wake snoozed emails: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count woken emails: %w", err)
+	}
+	return int(n), nil
+}
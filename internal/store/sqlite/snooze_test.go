@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+func TestSnoozeEmail(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	for _, id := range []string{"msg-1", "msg-2"} {
+		email := domain.Email{ID: id, ThreadID: "thread-" + id, Date: time.Now(), Labels: []string{domain.LabelInbox}}
+		if err := db.UpsertEmail(ctx, &email, "acc-1"); err != nil {
+			t.Fatalf("UpsertEmail() error: %v", err)
+		}
+	}
+
+	if err := db.SnoozeEmail(ctx, "msg-1", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("SnoozeEmail() error: %v", err)
+	}
+	if err := db.SnoozeEmail(ctx, "missing", time.Now().Add(time.Hour)); err == nil {
+		t.Error("SnoozeEmail(missing) should fail")
+	}
+
+	inbox := store.ListEmailOptions{AccountID: "acc-1", LabelID: domain.LabelInbox}
+	emails, err := db.ListEmails(ctx, inbox)
+	if err != nil {
+		t.Fatalf("ListEmails() error: %v", err)
+	}
+	if len(emails) != 1 || emails[0].ID != "msg-2" {
+		t.Errorf("inbox emails = %v, want only msg-2", emails)
+	}
+	threads, err := db.ListThreads(ctx, inbox)
+	if err != nil {
+		t.Fatalf("ListThreads() error: %v", err)
+	}
+	if len(threads) != 1 || threads[0].ID != "thread-msg-2" {
+		t.Errorf("inbox threads = %v, want only thread-msg-2", threads)
+	}
+
+	// Snoozed mail stays visible outside the inbox.
+	all, err := db.ListEmails(ctx, store.ListEmailOptions{AccountID: "acc-1"})
+	if err != nil {
+		t.Fatalf("ListEmails(all) error: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("all emails = %d, want 2", len(all))
+	}
+
+	// Waking before the snooze time is a no-op.
+	if n, err := db.WakeSnoozed(ctx, "acc-1", time.Now()); err != nil || n != 0 {
+		t.Errorf("WakeSnoozed(now) = %d, %v; want 0, nil", n, err)
+	}
+	if n, err := db.WakeSnoozed(ctx, "acc-1", time.Now().Add(2*time.Hour)); err != nil || n != 1 {
+		t.Errorf("WakeSnoozed(later) = %d, %v; want 1, nil", n, err)
+	}
+	emails, err = db.ListEmails(ctx, inbox)
+	if err != nil {
+		t.Fatalf("ListEmails() error: %v", err)
+	}
+	if len(emails) != 2 {
+		t.Errorf("inbox emails after wake = %d, want 2", len(emails))
+	}
+}
@@ -3,13 +3,37 @@ package sqlite
 import (
 	"database/sql"
 	"fmt"
+	"sync"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// busyTimeout is how long, in milliseconds, a connection waits for another
+// one's lock on the database, such as a sync in another process, before
+// failing with "database is locked".
+const busyTimeout = 5000
+
+// maxOpenConns caps the connection pool. In WAL mode readers run alongside
+// the single writer, so a few connections let TUI reads proceed during a
+// background sync without piling up waiters on the file lock.
+const maxOpenConns = 4
+
 // DB wraps a sql.DB connection to a SQLite database.
 type DB struct {
 	db *sql.DB
+
+	// memory is set for an in-memory database, which lives on a single
+	// connection: each new connection to ":memory:" is a separate, empty
+	// database.
+	memory bool
+
+	// writeMu serializes the methods that write, since SQLite allows one
+	// writer at a time and the busy timeout alone gives no fairness
+	// between this process's own connections.
+	writeMu sync.Mutex
+
+	versionMu   sync.Mutex
+	versionConn *sql.Conn
 }
 
 // New opens a SQLite database at the given DSN and runs migrations.
@@ -17,21 +41,30 @@ type DB struct {
 func New(dsn string) (*DB, error) {
 	connStr := dsn
 	if dsn != ":memory:" {
-		connStr = dsn + "?_journal_mode=WAL&_foreign_keys=on"
+		// Write transactions take the lock when they begin rather than on
+		// their first write, so they wait out the busy timeout instead of
+		// failing when a read would have to be upgraded.
+		connStr = fmt.Sprintf("%s?_journal_mode=WAL&_foreign_keys=on&_busy_timeout=%d&_txlock=immediate", dsn, busyTimeout)
 	} else {
-		connStr = ":memory:?_foreign_keys=on"
+		connStr = fmt.Sprintf(":memory:?_foreign_keys=on&_busy_timeout=%d", busyTimeout)
 	}
 
 	db, err := sql.Open("sqlite3", connStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	memory := dsn == ":memory:"
+	if memory {
+		db.SetMaxOpenConns(1)
+	} else {
+		db.SetMaxOpenConns(maxOpenConns)
+	}
 
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	s := &DB{db: db}
+	s := &DB{db: db, memory: memory}
 	if err := s.migrate(); err != nil {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
@@ -40,16 +73,78 @@ func New(dsn string) (*DB, error) {
 }
 
 func (s *DB) migrate() error {
-	if _, err := s.db.Exec(schema); err != nil {
-		return fmt.Errorf("failed to apply schema: %w", err)
+	if _, err := s.db.Exec(migrationsTable); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
 	}
-	if _, err := s.db.Exec(ftsSchema); err != nil {
-		return fmt.Errorf("failed to apply FTS schema: %w", err)
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if !applied[m.version] {
+			if err := s.applyMigration(m); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// appliedMigrations returns the versions recorded in schema_migrations.
+func (s *DB) appliedMigrations() (map[int]bool, error) {
+	rows, err := s.db.Query("SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan migration version: %w", err)
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+// applyMigration runs m and records its version in one transaction, so a
+// failed step leaves neither a partial change nor a record behind.
+func (s *DB) applyMigration(m migration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d: %w", m.version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(m.up); err != nil {
+		return fmt.Errorf("failed to apply migration %d: %w", m.version, err)
+	}
+	if m.upgrade != nil {
+		if err := m.upgrade(tx); err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", m.version, err)
+		}
+	}
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", m.version); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", m.version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.version, err)
 	}
 	return nil
 }
 
 // Close closes the underlying database connection.
 func (s *DB) Close() error {
+	s.versionMu.Lock()
+	if s.versionConn != nil {
+		s.versionConn.Close()
+		s.versionConn = nil
+	}
+	s.versionMu.Unlock()
 	return s.db.Close()
 }
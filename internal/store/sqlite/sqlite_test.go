@@ -2,7 +2,15 @@ package sqlite
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
 )
 
 func TestNew_CreatesTables(t *testing.T) {
@@ -43,3 +51,175 @@ func TestNew_CreatesTables(t *testing.T) {
 		}
 	}
 }
+
+func TestMigrate_Idempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if err := db.migrate(); err != nil {
+		t.Fatalf("second migrate() error: %v", err)
+	}
+	db.Close()
+
+	// Reopening runs the migrations again against the existing file.
+	db, err = New(path)
+	if err != nil {
+		t.Fatalf("reopen New() error: %v", err)
+	}
+	defer db.Close()
+
+	var count, latest int
+	if err := db.db.QueryRow("SELECT COUNT(*), MAX(version) FROM schema_migrations").Scan(&count, &latest); err != nil {
+		t.Fatalf("query schema_migrations error: %v", err)
+	}
+	want := migrations[len(migrations)-1].version
+	if count != len(migrations) || latest != want {
+		t.Errorf("schema_migrations has %d rows up to version %d, want %d up to %d",
+			count, latest, len(migrations), want)
+	}
+}
+
+func TestMigrate_UpgradesUnversionedDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	legacy, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("sql.Open() error: %v", err)
+	}
+	// The tables as created before any column was added or any migration
+	// was recorded.
+	if _, err := legacy.Exec(`
+		CREATE TABLE accounts (
+			id TEXT PRIMARY KEY, email TEXT NOT NULL UNIQUE, provider TEXT NOT NULL DEFAULT 'gmail',
+			display_name TEXT, created_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+		CREATE TABLE emails (
+			id TEXT PRIMARY KEY, account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+			thread_id TEXT NOT NULL, from_addr TEXT NOT NULL, from_name TEXT, to_addrs TEXT, cc_addrs TEXT,
+			subject TEXT, body_text TEXT, body_html TEXT, snippet TEXT, date DATETIME NOT NULL,
+			is_read BOOLEAN DEFAULT FALSE, is_starred BOOLEAN DEFAULT FALSE, in_reply_to TEXT,
+			history_id INTEGER, raw_size INTEGER, created_at DATETIME DEFAULT CURRENT_TIMESTAMP);`); err != nil {
+		t.Fatalf("create legacy tables error: %v", err)
+	}
+	legacy.Close()
+
+	db, err := New(path)
+	if err != nil {
+		t.Fatalf("New() on a legacy database error: %v", err)
+	}
+	defer db.Close()
+
+	for _, c := range legacyColumns {
+		var n int
+		if err := db.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, c.table, c.column).Scan(&n); err != nil {
+			t.Fatalf("inspect %s.%s error: %v", c.table, c.column, err)
+		}
+		if n != 1 {
+			t.Errorf("column %s.%s missing after upgrade", c.table, c.column)
+		}
+	}
+	var count int
+	if err := db.db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count); err != nil {
+		t.Fatalf("query schema_migrations error: %v", err)
+	}
+	if count != len(migrations) {
+		t.Errorf("schema_migrations has %d rows, want %d", count, len(migrations))
+	}
+}
+
+func TestConcurrentUpsertAndListThreads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer db.Close()
+	seedAccount(t, db)
+
+	// A second handle on the file stands in for a sync in another process.
+	other, err := New(path)
+	if err != nil {
+		t.Fatalf("New() second handle error: %v", err)
+	}
+	defer other.Close()
+
+	const workers, perWorker = 4, 50
+	ctx := context.Background()
+	errs := make(chan error, 3*workers*perWorker)
+	var wg sync.WaitGroup
+	for w := range workers {
+		for _, handle := range []*DB{db, other} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range perWorker {
+					id := fmt.Sprintf("m-%p-%d-%d", handle, w, i)
+					email := &domain.Email{
+						ID:       id,
+						ThreadID: fmt.Sprintf("t-%d", i%10),
+						Subject:  "Load " + id,
+						Date:     time.Unix(int64(i), 0),
+						Labels:   []string{domain.LabelInbox},
+					}
+					if err := handle.UpsertEmail(ctx, email, "acc-1"); err != nil {
+						errs <- fmt.Errorf("UpsertEmail(%s): %w", id, err)
+					}
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perWorker {
+				if _, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "acc-1", LabelID: domain.LabelInbox, Limit: 20}); err != nil {
+					errs <- fmt.Errorf("ListThreads(): %w", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	emails, err := db.ListEmails(ctx, store.ListEmailOptions{AccountID: "acc-1"})
+	if err != nil {
+		t.Fatalf("ListEmails() error: %v", err)
+	}
+	if want := 2 * workers * perWorker; len(emails) != want {
+		t.Errorf("ListEmails() = %d emails, want %d", len(emails), want)
+	}
+}
+
+func TestNew_MemorySharesOneDatabase(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	// A pinned version connection must not starve the single in-memory one.
+	if _, err := db.DataVersion(ctx); err != nil {
+		t.Fatalf("DataVersion() error: %v", err)
+	}
+
+	const readers = 8
+	errs := make(chan error, readers)
+	var wg sync.WaitGroup
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			accounts, err := db.ListAccounts(ctx)
+			if err != nil {
+				errs <- fmt.Errorf("ListAccounts(): %w", err)
+			} else if len(accounts) != 1 {
+				errs <- fmt.Errorf("ListAccounts() = %d accounts, want 1", len(accounts))
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// MailboxStats counts the account's emails, and of them the unread and
+// starred ones.
+func (s *DB) MailboxStats(ctx context.Context, accountID string) (store.MailboxStats, error) {
+	var st store.MailboxStats
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(is_read = 0), 0), COALESCE(SUM(is_starred), 0)
+		FROM emails WHERE account_id = ?`, accountID,
+	).Scan(&st.Total, &st.Unread, &st.Starred)
+	if err != nil {
+		return store.MailboxStats{}, fmt.Errorf("failed to count mail for account %s: %w", accountID, err)
+	}
+	return st, nil
+}
+
+// LabelCounts counts the account's emails per label, most used first.
+// Labels no email carries are left out.
+func (s *DB) LabelCounts(ctx context.Context, accountID string) ([]store.LabelCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT el.label_id, COALESCE(l.name, el.label_id), COUNT(*), SUM(e.is_read = 0)
+		FROM emails e
+		JOIN email_labels el ON el.email_id = e.id
+		LEFT JOIN labels l ON l.id = el.label_id AND l.account_id = e.account_id
+		WHERE e.account_id = ?
+		GROUP BY el.label_id
+		ORDER BY COUNT(*) DESC, el.label_id`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count labels: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []store.LabelCount
+	for rows.Next() {
+		var c store.LabelCount
+		if err := rows.Scan(&c.LabelID, &c.Name, &c.Total, &c.Unread); err != nil {
+			return nil, fmt.Errorf("failed to scan label count: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate label counts: %w", err)
+	}
+	return counts, nil
+}
+
+// TopSenders returns the addresses that sent the account the most emails,
+// up to limit (all of them when limit is 0), most frequent first. Addresses
+// are compared case-insensitively.
+func (s *DB) TopSenders(ctx context.Context, accountID string, limit int) ([]store.SenderCount, error) {
+	// With MAX, SQLite takes the bare from_name and from_addr columns from
+	// the sender's latest email.
+	query, args := appendPage(`
+		SELECT from_name, from_addr, COUNT(*), MAX(`+emailSortDate+`)
+		FROM emails e
+		WHERE e.account_id = ? AND e.from_addr != ''
+		GROUP BY lower(e.from_addr)
+		ORDER BY COUNT(*) DESC, lower(e.from_addr)`, []any{accountID}, limit, 0)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count senders: %w", err)
+	}
+	defer rows.Close()
+
+	var senders []store.SenderCount
+	for rows.Next() {
+		var c store.SenderCount
+		var latest any
+		if err := rows.Scan(&c.Address.Name, &c.Address.Email, &c.Count, &latest); err != nil {
+			return nil, fmt.Errorf("failed to scan sender count: %w", err)
+		}
+		senders = append(senders, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sender counts: %w", err)
+	}
+	return senders, nil
+}
+
+// MessagesPerMonth counts the account's emails by the UTC month of their
+// date, oldest month first. Months without mail are left out.
+func (s *DB) MessagesPerMonth(ctx context.Context, accountID string) ([]store.MonthCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT strftime('%Y-%m', e.date) AS month, COUNT(*)
+		FROM emails e
+		WHERE e.account_id = ? AND month IS NOT NULL
+		GROUP BY month
+		ORDER BY month`, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count mail per month: %w", err)
+	}
+	defer rows.Close()
+
+	var months []store.MonthCount
+	for rows.Next() {
+		var c store.MonthCount
+		if err := rows.Scan(&c.Month, &c.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan month count: %w", err)
+		}
+		months = append(months, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate month counts: %w", err)
+	}
+	return months, nil
+}
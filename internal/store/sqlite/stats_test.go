@@ -0,0 +1,123 @@
+package sqlite
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// seedStats stores five emails over two months from three senders.
+func seedStats(t *testing.T, db *DB) {
+	t.Helper()
+	ctx := context.Background()
+	may := time.Date(2025, 5, 20, 9, 0, 0, 0, time.UTC)
+	june := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	ann := domain.Address{Name: "Ann", Email: "ann@example.com"}
+	emails := []domain.Email{
+		{ID: "m1", ThreadID: "t1", From: domain.Address{Name: "A. Old", Email: "Ann@Example.com"}, Date: may, IsRead: true,
+			Labels: []string{domain.LabelInbox}},
+		{ID: "m2", ThreadID: "t2", From: ann, Date: june, Labels: []string{domain.LabelInbox, "Label_work"}},
+		{ID: "m3", ThreadID: "t3", From: ann, Date: june.Add(time.Hour), IsStarred: true, IsRead: true,
+			Labels: []string{"Label_work"}},
+		{ID: "m4", ThreadID: "t4", From: domain.Address{Email: "bob@example.com"}, Date: may.Add(time.Hour),
+			Labels: []string{domain.LabelInbox}},
+		{ID: "m5", ThreadID: "t5", From: domain.Address{Email: "cy@example.com"}, Date: june, IsRead: true,
+			Labels: []string{domain.LabelSent}},
+	}
+	if err := db.UpsertEmails(ctx, emails, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+	if err := db.UpsertLabel(ctx, &domain.Label{ID: "Label_work", AccountID: "acc-1", Name: "Work"}); err != nil {
+		t.Fatalf("UpsertLabel() error: %v", err)
+	}
+}
+
+func TestMailboxStats(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	empty, err := db.MailboxStats(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("MailboxStats(empty) error: %v", err)
+	}
+	if empty != (store.MailboxStats{}) {
+		t.Errorf("MailboxStats(empty) = %+v, want zeros", empty)
+	}
+
+	seedStats(t, db)
+	got, err := db.MailboxStats(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("MailboxStats() error: %v", err)
+	}
+	if want := (store.MailboxStats{Total: 5, Unread: 2, Starred: 1}); got != want {
+		t.Errorf("MailboxStats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestLabelCounts(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	seedStats(t, db)
+
+	got, err := db.LabelCounts(context.Background(), "acc-1")
+	if err != nil {
+		t.Fatalf("LabelCounts() error: %v", err)
+	}
+	want := []store.LabelCount{
+		{LabelID: domain.LabelInbox, Name: domain.LabelInbox, Total: 3, Unread: 2},
+		{LabelID: "Label_work", Name: "Work", Total: 2, Unread: 1},
+		{LabelID: domain.LabelSent, Name: domain.LabelSent, Total: 1, Unread: 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LabelCounts() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestTopSenders(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	seedStats(t, db)
+	ctx := context.Background()
+
+	got, err := db.TopSenders(ctx, "acc-1", 2)
+	if err != nil {
+		t.Fatalf("TopSenders() error: %v", err)
+	}
+	// Ann's three emails count together whatever the address's case, under
+	// the name from the latest one.
+	want := []store.SenderCount{
+		{Address: domain.Address{Name: "Ann", Email: "ann@example.com"}, Count: 3},
+		{Address: domain.Address{Email: "bob@example.com"}, Count: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TopSenders(2) =\n%+v\nwant\n%+v", got, want)
+	}
+
+	all, err := db.TopSenders(ctx, "acc-1", 0)
+	if err != nil {
+		t.Fatalf("TopSenders(0) error: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("TopSenders(0) = %d senders, want 3", len(all))
+	}
+}
+
+func TestMessagesPerMonth(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	seedStats(t, db)
+
+	got, err := db.MessagesPerMonth(context.Background(), "acc-1")
+	if err != nil {
+		t.Fatalf("MessagesPerMonth() error: %v", err)
+	}
+	want := []store.MonthCount{{Month: "2025-05", Count: 2}, {Month: "2025-06", Count: 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MessagesPerMonth() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,258 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// threadSummariesSchema adds thread_summaries, a precomputed ListThreads
+// row per thread: one with an empty label_id covering all of the thread's
+// mail and one per label its messages carry, counting only those messages.
+// The subject, sender and snippet are always the whole thread's. Writing mail
+// only marks its thread in thread_summary_dirty, which keeps syncing cheap;
+// the summaries of marked threads are recomputed before the next listing.
+var threadSummariesSchema = `
+CREATE TABLE thread_summaries (
+    account_id    TEXT NOT NULL,
+    label_id      TEXT NOT NULL,
+    thread_id     TEXT NOT NULL,
+    subject       TEXT,
+    from_name     TEXT,
+    from_addr     TEXT,
+    snippet       TEXT,
+    last_date     DATETIME NOT NULL,
+    last_received DATETIME NOT NULL,
+    msg_count     INTEGER NOT NULL,
+    has_unread    INTEGER NOT NULL,
+    has_starred   INTEGER NOT NULL,
+    labels        TEXT NOT NULL,
+    PRIMARY KEY (account_id, label_id, thread_id)
+);
+CREATE INDEX idx_thread_summaries_recent ON thread_summaries(account_id, label_id, last_received DESC);
+CREATE INDEX idx_thread_summaries_thread ON thread_summaries(account_id, thread_id);
+CREATE INDEX idx_emails_account_thread ON emails(account_id, thread_id);
+CREATE INDEX idx_emails_snoozed ON emails(account_id) WHERE snoozed_until IS NOT NULL;
+
+CREATE TABLE thread_summary_dirty (
+    account_id TEXT NOT NULL,
+    thread_id  TEXT NOT NULL,
+    PRIMARY KEY (account_id, thread_id)
+);
+
+` + strings.Join(threadSummaryInserts("1"), ";\n") + `;
+
+CREATE TRIGGER thread_summaries_email_insert AFTER INSERT ON emails BEGIN
+    INSERT INTO thread_summary_dirty VALUES (new.account_id, new.thread_id) ON CONFLICT DO NOTHING;
+END;
+
+CREATE TRIGGER thread_summaries_email_update
+AFTER UPDATE OF account_id, thread_id, subject, from_name, from_addr, body_text, date, received_at, is_read, is_starred ON emails
+BEGIN
+    INSERT INTO thread_summary_dirty VALUES (old.account_id, old.thread_id) ON CONFLICT DO NOTHING;
+    INSERT INTO thread_summary_dirty VALUES (new.account_id, new.thread_id) ON CONFLICT DO NOTHING;
+END;
+
+CREATE TRIGGER thread_summaries_email_delete AFTER DELETE ON emails BEGIN
+    INSERT INTO thread_summary_dirty VALUES (old.account_id, old.thread_id) ON CONFLICT DO NOTHING;
+END;
+
+CREATE TRIGGER thread_summaries_label_insert AFTER INSERT ON email_labels BEGIN
+    INSERT INTO thread_summary_dirty
+    SELECT account_id, thread_id FROM emails WHERE id = new.email_id ON CONFLICT DO NOTHING;
+END;
+
+CREATE TRIGGER thread_summaries_label_delete AFTER DELETE ON email_labels BEGIN
+    INSERT INTO thread_summary_dirty
+    SELECT account_id, thread_id FROM emails WHERE id = old.email_id ON CONFLICT DO NOTHING;
+END;
+`
+
+// threadSummaryInserts returns the INSERT statements that summarize the
+// threads of the emails (aliased e) matching cond: first the all-mail rows,
+// then the per-label rows.
+func threadSummaryInserts(cond string) []string {
+	const insert = `INSERT INTO thread_summaries (account_id, label_id, thread_id, subject, from_name, from_addr,
+	snippet, last_date, last_received, msg_count, has_unread, has_starred, labels)`
+	first := func(column string) string {
+		return `(SELECT f.` + column + ` FROM emails f WHERE f.account_id = e.account_id AND f.thread_id = e.thread_id
+		ORDER BY COALESCE(f.received_at, f.date) ASC LIMIT 1)`
+	}
+	columns := first("subject") + `,
+	` + first("from_name") + `,
+	` + first("from_addr") + `,
+	(SELECT substr(l.body_text, 1, 100) FROM emails l WHERE l.account_id = e.account_id AND l.thread_id = e.thread_id
+		ORDER BY COALESCE(l.received_at, l.date) DESC LIMIT 1),
+	MAX(e.date), ` + threadLastReceived + `, COUNT(*), MIN(e.is_read) = 0, MAX(e.is_starred),
+	` + threadLabelsColumn
+
+	return []string{
+		insert + `
+SELECT e.account_id, '', e.thread_id,
+	` + columns + `
+FROM emails e
+WHERE ` + cond + `
+GROUP BY e.account_id, e.thread_id`,
+		insert + `
+SELECT e.account_id, el.label_id, e.thread_id,
+	` + columns + `
+FROM emails e
+JOIN email_labels el ON el.email_id = e.id
+WHERE ` + cond + `
+GROUP BY e.account_id, e.thread_id, el.label_id`,
+	}
+}
+
+// RebuildThreadSummaries recomputes the thread summaries of an account from
+// its mail and returns how many threads it has. Listing keeps summaries
+// current on its own; this repairs them should they ever drift.
+func (s *DB) RebuildThreadSummaries(ctx context.Context, accountID string) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM thread_summaries WHERE account_id = ?`, accountID); err != nil {
+		return 0, fmt.Errorf("failed to clear thread summaries: %w", err)
+	}
+	var threads int64
+	for i, insert := range threadSummaryInserts("e.account_id = ?") {
+		res, err := tx.ExecContext(ctx, insert, accountID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to rebuild thread summaries: %w", err)
+		}
+		if i == 0 {
+			threads, _ = res.RowsAffected()
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM thread_summary_dirty WHERE account_id = ?`, accountID); err != nil {
+		return 0, fmt.Errorf("failed to clear dirty threads: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit thread summaries: %w", err)
+	}
+	return int(threads), nil
+}
+
+// refreshThreadSummaries recomputes the summaries of the account's threads
+// whose mail changed since they were last summarized.
+func (s *DB) refreshThreadSummaries(ctx context.Context, accountID string) error {
+	// Most listings find nothing to refresh; check before taking the write
+	// lock so they do not queue behind a sync, then again once it is held.
+	if dirty, err := s.hasDirtyThreads(ctx, accountID); err != nil || !dirty {
+		return err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if dirty, err := s.hasDirtyThreads(ctx, accountID); err != nil || !dirty {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	const dirtyThreads = `(SELECT thread_id FROM thread_summary_dirty WHERE account_id = ?)`
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM thread_summaries WHERE account_id = ? AND thread_id IN `+dirtyThreads,
+		accountID, accountID); err != nil {
+		return fmt.Errorf("failed to clear thread summaries: %w", err)
+	}
+	for _, insert := range threadSummaryInserts("e.account_id = ? AND e.thread_id IN " + dirtyThreads) {
+		if _, err := tx.ExecContext(ctx, insert, accountID, accountID); err != nil {
+			return fmt.Errorf("failed to refresh thread summaries: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM thread_summary_dirty WHERE account_id = ?`, accountID); err != nil {
+		return fmt.Errorf("failed to clear dirty threads: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit thread summaries: %w", err)
+	}
+	return nil
+}
+
+// hasDirtyThreads reports whether any of the account's threads are marked
+// for a summary refresh.
+func (s *DB) hasDirtyThreads(ctx context.Context, accountID string) (bool, error) {
+	var dirty bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM thread_summary_dirty WHERE account_id = ?)`, accountID).Scan(&dirty)
+	if err != nil {
+		return false, fmt.Errorf("failed to check dirty threads: %w", err)
+	}
+	return dirty, nil
+}
+
+// summaryListable reports whether ListThreads can read opts' threads
+// straight from thread_summaries: a listing of all mail or of one label
+// with no other filter. The inbox hides snoozed mail as of the time of the
+// query, which summaries cannot track, so it only qualifies while the
+// account has nothing snoozed.
+func (s *DB) summaryListable(ctx context.Context, opts store.ListEmailOptions) (bool, error) {
+	if len(opts.IncludeLabelIDs) > 0 || opts.Flag != "" || opts.ListID != "" ||
+		!opts.After.IsZero() || !opts.Before.IsZero() || !opts.SyncedAfter.IsZero() {
+		return false, nil
+	}
+	if opts.LabelID != domain.LabelInbox {
+		return true, nil
+	}
+	var snoozed bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM emails WHERE account_id = ? AND snoozed_until IS NOT NULL)`,
+		opts.AccountID).Scan(&snoozed)
+	if err != nil {
+		return false, fmt.Errorf("failed to check snoozed mail: %w", err)
+	}
+	return !snoozed, nil
+}
+
+// summaryPriorityScore is threadPriorityScore for a thread_summaries row.
+const summaryPriorityScore = `
+	(CASE WHEN e.has_unread THEN 2 ELSE 0 END)
+	+ (CASE WHEN e.has_starred THEN 2 ELSE 0 END)
+	+ (CASE WHEN instr(',' || e.labels || ',', ',` + domain.LabelImportant + `,') > 0 THEN 1 ELSE 0 END)`
+
+// summaryThreadsQuery returns the ListThreads query over thread_summaries
+// (aliased e) and its arguments. Its columns match the grouped query's.
+func summaryThreadsQuery(opts store.ListEmailOptions) (string, []any) {
+	query := `
+		SELECT e.thread_id, e.subject, e.from_name, e.from_addr, e.last_date, e.snippet,
+			e.msg_count, NOT e.has_unread, e.has_starred,
+			` + threadFlagsColumn + ` AS flags,
+			e.labels
+		FROM thread_summaries e
+		WHERE e.account_id = ? AND e.label_id = ?
+		ORDER BY `
+	if opts.Sort == store.SortPriority {
+		query += summaryPriorityScore + " DESC, "
+	}
+	query += "e.last_received DESC"
+	args := []any{opts.AccountID, opts.LabelID}
+	return appendPage(query, args, opts.Limit, opts.Offset)
+}
+
+// appendPage adds LIMIT and OFFSET clauses for a page of results.
+func appendPage(query string, args []any, limit, offset int) (string, []any) {
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+	if offset > 0 {
+		if limit <= 0 {
+			query += " LIMIT -1"
+		}
+		query += " OFFSET ?"
+		args = append(args, offset)
+	}
+	return query, args
+}
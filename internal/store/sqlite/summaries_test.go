@@ -0,0 +1,250 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// assertSummariesMatch checks that every summary listing of the account
+// returns exactly what grouping its emails does.
+func assertSummariesMatch(t *testing.T, db *DB, step string) {
+	t.Helper()
+	ctx := context.Background()
+	for _, label := range []string{"", domain.LabelInbox, domain.LabelSent, "Label_work"} {
+		for _, sort := range []string{store.SortDate, store.SortPriority} {
+			opts := store.ListEmailOptions{AccountID: "acc-1", LabelID: label, Sort: sort}
+			if err := db.refreshThreadSummaries(ctx, opts.AccountID); err != nil {
+				t.Fatalf("%s: refreshThreadSummaries() error: %v", step, err)
+			}
+			query, args := summaryThreadsQuery(opts)
+			got, err := db.queryThreads(ctx, query, args)
+			if err != nil {
+				t.Fatalf("%s: summary ListThreads(%q, %s) error: %v", step, label, sort, err)
+			}
+			query, args = groupedThreadsQuery(opts)
+			want, err := db.queryThreads(ctx, query, args)
+			if err != nil {
+				t.Fatalf("%s: grouped ListThreads(%q, %s) error: %v", step, label, sort, err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: summaries for %q (%s) =\n%+v\nwant\n%+v", step, label, sort, got, want)
+			}
+		}
+	}
+}
+
+func TestThreadSummaries_StayCurrent(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+	base := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+
+	emails := []domain.Email{
+		{ID: "a1", ThreadID: "t-a", Subject: "Plan", Body: "first", From: domain.Address{Name: "Ann", Email: "ann@example.com"},
+			Date: base, ReceivedAt: base, IsRead: true, Labels: []string{domain.LabelInbox, "Label_work"}},
+		{ID: "a2", ThreadID: "t-a", Subject: "Re: Plan", Body: "reply", From: domain.Address{Email: "me@example.com"},
+			Date: base.Add(time.Hour), ReceivedAt: base.Add(time.Hour), IsRead: true, Labels: []string{domain.LabelSent}},
+		{ID: "b1", ThreadID: "t-b", Subject: "Lunch", Body: "noon", From: domain.Address{Email: "bob@example.com"},
+			Date: base.Add(2 * time.Hour), Labels: []string{domain.LabelInbox, domain.LabelImportant}},
+		{ID: "c1", ThreadID: "t-c", Subject: "Old", Body: "archived", Date: base.Add(-time.Hour), IsRead: true, IsStarred: true},
+	}
+	if err := db.UpsertEmails(ctx, emails, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+	assertSummariesMatch(t, db, "after insert")
+
+	// A newer reply joins t-a in the inbox.
+	reply := domain.Email{ID: "a3", ThreadID: "t-a", Subject: "Re: Plan", Body: "latest", From: domain.Address{Email: "cy@example.com"},
+		Date: base.Add(3 * time.Hour), ReceivedAt: base.Add(3 * time.Hour), Labels: []string{domain.LabelInbox}}
+	if err := db.UpsertEmail(ctx, &reply, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+	assertSummariesMatch(t, db, "after reply")
+
+	if err := db.SetEmailRead(ctx, "a3", "acc-1", true); err != nil {
+		t.Fatalf("SetEmailRead() error: %v", err)
+	}
+	if err := db.SetEmailStarred(ctx, "b1", "acc-1", true); err != nil {
+		t.Fatalf("SetEmailStarred() error: %v", err)
+	}
+	assertSummariesMatch(t, db, "after read and star")
+
+	// Archiving b1 takes t-b out of the inbox; labelling c1 files t-c.
+	if err := db.SetEmailLabels(ctx, "b1", "acc-1", nil); err != nil {
+		t.Fatalf("SetEmailLabels(b1) error: %v", err)
+	}
+	if err := db.SetEmailLabels(ctx, "c1", "acc-1", []string{"Label_work"}); err != nil {
+		t.Fatalf("SetEmailLabels(c1) error: %v", err)
+	}
+	assertSummariesMatch(t, db, "after relabel")
+
+	// Moving a message to another thread updates both.
+	if _, err := db.db.ExecContext(ctx, `UPDATE emails SET thread_id = 't-c' WHERE id = 'a1'`); err != nil {
+		t.Fatalf("move a1 error: %v", err)
+	}
+	assertSummariesMatch(t, db, "after thread change")
+
+	if err := db.DeleteEmail(ctx, "a3", "acc-1"); err != nil {
+		t.Fatalf("DeleteEmail(a3) error: %v", err)
+	}
+	if err := db.DeleteEmail(ctx, "b1", "acc-1"); err != nil {
+		t.Fatalf("DeleteEmail(b1) error: %v", err)
+	}
+	assertSummariesMatch(t, db, "after delete")
+
+	var n int
+	if err := db.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM thread_summaries WHERE thread_id = 't-b'`).Scan(&n); err != nil {
+		t.Fatalf("count t-b summaries error: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("deleted thread t-b still has %d summary rows", n)
+	}
+}
+
+func TestListThreads_SnoozedInboxBypassesSummaries(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Second)
+	emails := []domain.Email{
+		{ID: "m1", ThreadID: "t1", Subject: "awake", Date: now, Labels: []string{domain.LabelInbox}},
+		{ID: "m2", ThreadID: "t2", Subject: "asleep", Date: now, Labels: []string{domain.LabelInbox}},
+	}
+	if err := db.UpsertEmails(ctx, emails, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+	if err := db.SnoozeEmail(ctx, "m2", now.Add(time.Hour)); err != nil {
+		t.Fatalf("SnoozeEmail() error: %v", err)
+	}
+
+	inbox := store.ListEmailOptions{AccountID: "acc-1", LabelID: domain.LabelInbox}
+	if ok, err := db.summaryListable(ctx, inbox); err != nil || ok {
+		t.Errorf("summaryListable(inbox with snoozed mail) = %v, %v; want false", ok, err)
+	}
+	threads, err := db.ListThreads(ctx, inbox)
+	if err != nil {
+		t.Fatalf("ListThreads() error: %v", err)
+	}
+	if len(threads) != 1 || threads[0].ID != "t1" {
+		t.Errorf("ListThreads(inbox) = %+v, want only t1", threads)
+	}
+
+	if err := db.SnoozeEmail(ctx, "m2", time.Time{}); err != nil {
+		t.Fatalf("SnoozeEmail(clear) error: %v", err)
+	}
+	if ok, err := db.summaryListable(ctx, inbox); err != nil || !ok {
+		t.Errorf("summaryListable(inbox) = %v, %v; want true", ok, err)
+	}
+	if ok, _ := db.summaryListable(ctx, store.ListEmailOptions{AccountID: "acc-1", Flag: domain.FlagWaiting}); ok {
+		t.Error("summaryListable(flag filter) = true, want false")
+	}
+}
+
+func TestRebuildThreadSummaries(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	base := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	emails := []domain.Email{
+		{ID: "m1", ThreadID: "t1", Subject: "one", Date: base, Labels: []string{domain.LabelInbox}},
+		{ID: "m2", ThreadID: "t1", Subject: "Re: one", Date: base.Add(time.Hour), Labels: []string{domain.LabelSent}},
+		{ID: "m3", ThreadID: "t2", Subject: "two", Date: base, Labels: []string{domain.LabelInbox}},
+	}
+	if err := db.UpsertEmails(ctx, emails, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+	// Simulate drift: summaries lost behind the store's back.
+	if _, err := db.db.ExecContext(ctx, `DELETE FROM thread_summaries`); err != nil {
+		t.Fatalf("clear summaries error: %v", err)
+	}
+
+	n, err := db.RebuildThreadSummaries(ctx, "acc-1")
+	if err != nil {
+		t.Fatalf("RebuildThreadSummaries() error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("RebuildThreadSummaries() = %d, want 2 threads", n)
+	}
+	assertSummariesMatch(t, db, "after rebuild")
+}
+
+func TestMigrate_SummarizesExistingMail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	seedAccount(t, db)
+	ctx := context.Background()
+	email := domain.Email{ID: "m1", ThreadID: "t1", Subject: "before", Date: time.Now(), Labels: []string{domain.LabelInbox}}
+	if err := db.UpsertEmail(ctx, &email, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+	// Roll the database back to before thread summaries existed.
+	if _, err := db.db.ExecContext(ctx, `
+		DROP TABLE thread_summaries;
+		DROP TABLE thread_summary_dirty;
+		DROP INDEX idx_emails_account_thread;
+		DROP INDEX idx_emails_snoozed;
+		DROP TRIGGER thread_summaries_email_insert;
+		DROP TRIGGER thread_summaries_email_update;
+		DROP TRIGGER thread_summaries_email_delete;
+		DROP TRIGGER thread_summaries_label_insert;
+		DROP TRIGGER thread_summaries_label_delete;
+		DELETE FROM schema_migrations WHERE version = 3;`); err != nil {
+		t.Fatalf("roll back error: %v", err)
+	}
+	db.Close()
+
+	db, err = New(path)
+	if err != nil {
+		t.Fatalf("reopen New() error: %v", err)
+	}
+	defer db.Close()
+	threads, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "acc-1", LabelID: domain.LabelInbox})
+	if err != nil {
+		t.Fatalf("ListThreads() error: %v", err)
+	}
+	if len(threads) != 1 || threads[0].Subject != "before" {
+		t.Errorf("ListThreads() after migrating = %+v, want the existing thread", threads)
+	}
+}
+
+func TestListThreads_CleanSummariesSkipWriteLock(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+	email := &domain.Email{ID: "m1", ThreadID: "t1", Subject: "Hi", Date: time.Now(), Labels: []string{domain.LabelInbox}}
+	if err := db.UpsertEmail(ctx, email, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+	if err := db.refreshThreadSummaries(ctx, "acc-1"); err != nil {
+		t.Fatalf("refreshThreadSummaries() error: %v", err)
+	}
+
+	// A sync holding the write lock must not stall a listing with nothing
+	// to refresh.
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+	done := make(chan error, 1)
+	go func() {
+		_, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "acc-1"})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("ListThreads() error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ListThreads() waited for the write lock with no dirty threads")
+	}
+}
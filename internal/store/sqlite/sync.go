@@ -33,6 +33,9 @@ func (s *DB) GetSyncState(ctx context.Context, accountID string) (*store.SyncSta
 
 // SetSyncState inserts or updates the sync state for an account.
 func (s *DB) SetSyncState(ctx context.Context, state *store.SyncState) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
 	_, err := s.db.ExecContext(ctx, `
 		INSERT INTO sync_state (account_id, history_id, last_sync)
 		VALUES (?, ?, ?)
@@ -2,23 +2,33 @@ package sqlite
 
 import (
 	"context"
+	"crypto/sha1"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/lu-zhengda/termail/internal/domain"
 	"github.com/lu-zhengda/termail/internal/store"
 )
 
-// GetThread retrieves a thread by ID, including all its messages ordered by date ascending.
+// GetThread retrieves a thread by ID, including all its messages in the order
+// they were received.
 func (s *DB) GetThread(ctx context.Context, threadID string, accountID string) (*domain.Thread, error) {
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT id, thread_id, from_addr, from_name, to_addrs, cc_addrs,
-			subject, body_text, body_html, date, is_read, is_starred, in_reply_to
-		FROM emails
+			subject, body_text, body_html, date, is_read, is_starred, in_reply_to,
+			COALESCE(list_unsubscribe, ''), COALESCE(calendar_event, ''),
+			COALESCE(message_id, ''), COALESCE(refs, ''),
+			COALESCE(list_id, ''), COALESCE(list_post, ''), COALESCE(received_at, ''),
+			COALESCE(bcc_addrs, ''),
+			`+emailFlagsColumn+`
+		FROM emails e
 		WHERE thread_id = ? AND account_id = ?
-		ORDER BY date ASC`, threadID, accountID)
+		ORDER BY `+emailSortDate+` ASC`, threadID, accountID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query thread %s: %w", threadID, err)
 	}
@@ -28,18 +38,23 @@ func (s *DB) GetThread(ctx context.Context, threadID string, accountID string) (
 	for rows.Next() {
 		var e domain.Email
 		var fromAddr, fromName string
-		var toJSON, ccJSON string
-		var dateStr string
+		var toJSON, ccJSON, bccJSON, eventJSON string
+		var refs, flags string
+		var dateStr, receivedStr string
 
 		if err := rows.Scan(
 			&e.ID, &e.ThreadID, &fromAddr, &fromName, &toJSON, &ccJSON,
 			&e.Subject, &e.Body, &e.BodyHTML, &dateStr,
 			&e.IsRead, &e.IsStarred, &e.InReplyTo,
+			&e.ListUnsubscribe, &eventJSON,
+			&e.MessageID, &refs, &e.ListID, &e.ListPost, &receivedStr, &bccJSON, &flags,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan thread message: %w", err)
 		}
 
 		e.From = domain.Address{Name: fromName, Email: fromAddr}
+		e.References = strings.Fields(refs)
+		e.Flags = splitFlags(flags)
 
 		if toJSON != "" {
 			if err := json.Unmarshal([]byte(toJSON), &e.To); err != nil {
@@ -51,12 +66,24 @@ func (s *DB) GetThread(ctx context.Context, threadID string, accountID string) (
 				return nil, fmt.Errorf("failed to unmarshal CC addresses: %w", err)
 			}
 		}
+		if bccJSON != "" {
+			if err := json.Unmarshal([]byte(bccJSON), &e.BCC); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal BCC addresses: %w", err)
+			}
+		}
+
+		if e.Event, err = unmarshalEvent(eventJSON); err != nil {
+			return nil, err
+		}
 
 		parsedDate, err := time.Parse(time.RFC3339, dateStr)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse email date: %w", err)
 		}
 		e.Date = parsedDate
+		if e.ReceivedAt, err = parseReceivedAt(receivedStr); err != nil {
+			return nil, err
+		}
 
 		messages = append(messages, e)
 	}
@@ -65,7 +92,7 @@ func (s *DB) GetThread(ctx context.Context, threadID string, accountID string) (
 	}
 
 	if len(messages) == 0 {
-		return nil, fmt.Errorf("thread %s not found: %w", threadID, sql.ErrNoRows)
+		return nil, fmt.Errorf("thread %s: %w", threadID, store.ErrNotFound)
 	}
 
 	// Build Thread struct from messages.
@@ -86,53 +113,26 @@ func (s *DB) GetThread(ctx context.Context, threadID string, accountID string) (
 	}, nil
 }
 
-// ListThreads returns threads grouped by thread_id, optionally filtered by label.
+// ListThreads returns threads grouped by thread_id, optionally filtered by
+// label. Listings of all mail or of a single label read precomputed rows
+// from thread_summaries; other filters group the matching emails.
 func (s *DB) ListThreads(ctx context.Context, opts store.ListEmailOptions) ([]domain.Thread, error) {
-	var query string
-	var args []any
-
-	if opts.LabelID != "" {
-		query = `
-			SELECT e.thread_id,
-				(SELECT e2.subject FROM emails e2 WHERE e2.thread_id = e.thread_id ORDER BY e2.date ASC LIMIT 1) AS first_subject,
-				(SELECT e2.from_name FROM emails e2 WHERE e2.thread_id = e.thread_id ORDER BY e2.date ASC LIMIT 1) AS first_from_name,
-				(SELECT e2.from_addr FROM emails e2 WHERE e2.thread_id = e.thread_id ORDER BY e2.date ASC LIMIT 1) AS first_from_addr,
-				MAX(e.date) AS last_date,
-				(SELECT e3.body_text FROM emails e3 WHERE e3.thread_id = e.thread_id ORDER BY e3.date DESC LIMIT 1) AS last_body,
-				COUNT(*) AS msg_count,
-				MIN(e.is_read) AS all_read
-			FROM emails e
-			JOIN email_labels el ON el.email_id = e.id
-			WHERE e.account_id = ? AND el.label_id = ?
-			GROUP BY e.thread_id
-			ORDER BY last_date DESC`
-		args = append(args, opts.AccountID, opts.LabelID)
-	} else {
-		query = `
-			SELECT e.thread_id,
-				(SELECT e2.subject FROM emails e2 WHERE e2.thread_id = e.thread_id ORDER BY e2.date ASC LIMIT 1) AS first_subject,
-				(SELECT e2.from_name FROM emails e2 WHERE e2.thread_id = e.thread_id ORDER BY e2.date ASC LIMIT 1) AS first_from_name,
-				(SELECT e2.from_addr FROM emails e2 WHERE e2.thread_id = e.thread_id ORDER BY e2.date ASC LIMIT 1) AS first_from_addr,
-				MAX(e.date) AS last_date,
-				(SELECT e3.body_text FROM emails e3 WHERE e3.thread_id = e.thread_id ORDER BY e3.date DESC LIMIT 1) AS last_body,
-				COUNT(*) AS msg_count,
-				MIN(e.is_read) AS all_read
-			FROM emails e
-			WHERE e.account_id = ?
-			GROUP BY e.thread_id
-			ORDER BY last_date DESC`
-		args = append(args, opts.AccountID)
-	}
-
-	if opts.Limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, opts.Limit)
-	}
-	if opts.Offset > 0 {
-		query += " OFFSET ?"
-		args = append(args, opts.Offset)
+	listable, err := s.summaryListable(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	query, args := groupedThreadsQuery(opts)
+	if listable {
+		if err := s.refreshThreadSummaries(ctx, opts.AccountID); err != nil {
+			return nil, err
+		}
+		query, args = summaryThreadsQuery(opts)
 	}
+	return s.queryThreads(ctx, query, args)
+}
 
+// queryThreads runs a ListThreads query and scans its thread rows.
+func (s *DB) queryThreads(ctx context.Context, query string, args []any) ([]domain.Thread, error) {
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list threads: %w", err)
@@ -146,9 +146,10 @@ func (s *DB) ListThreads(ctx context.Context, opts store.ListEmailOptions) ([]do
 		var lastDateStr string
 		var lastBody sql.NullString
 		var msgCount int
-		var allRead bool
+		var allRead, anyStarred bool
+		var flags, labels string
 
-		if err := rows.Scan(&t.ID, &t.Subject, &fromName, &fromAddr, &lastDateStr, &lastBody, &msgCount, &allRead); err != nil {
+		if err := rows.Scan(&t.ID, &t.Subject, &fromName, &fromAddr, &lastDateStr, &lastBody, &msgCount, &allRead, &anyStarred, &flags, &labels); err != nil {
 			return nil, fmt.Errorf("failed to scan thread row: %w", err)
 		}
 
@@ -171,6 +172,9 @@ func (s *DB) ListThreads(ctx context.Context, opts store.ListEmailOptions) ([]do
 		}
 		t.TotalCount = msgCount
 		t.HasUnread = !allRead
+		t.HasStarred = anyStarred
+		t.Flags = splitFlags(flags)
+		t.Labels = splitLabels(labels)
 
 		threads = append(threads, t)
 	}
@@ -180,3 +184,261 @@ func (s *DB) ListThreads(ctx context.Context, opts store.ListEmailOptions) ([]do
 
 	return threads, nil
 }
+
+// groupedThreadsQuery returns the ListThreads query that groups opts'
+// emails into threads, and its arguments. The page of threads is grouped,
+// sorted and limited first; only its rows then look up their first and
+// latest messages and their flags and labels, rather than every thread of
+// the account. The page is aliased e so the thread columns apply to it.
+func groupedThreadsQuery(opts store.ListEmailOptions) (string, []any) {
+	page := `
+		SELECT e.thread_id, e.account_id,
+			MAX(e.date) AS last_date,
+			COUNT(*) AS msg_count,
+			MIN(e.is_read) AS all_read,
+			MAX(e.is_starred) AS any_starred,
+			` + threadSortColumns(opts.Sort) + `
+		FROM emails e`
+	var args []any
+	if opts.LabelID != "" {
+		join, joinArgs := labelJoin(opts)
+		page += `
+		JOIN ` + join
+		args = append(args, joinArgs...)
+	}
+	page += `
+		WHERE e.account_id = ?`
+	args = append(args, opts.AccountID)
+
+	if opts.LabelID == domain.LabelInbox {
+		page += ` AND ` + notSnoozedCond
+		args = append(args, time.Now().Unix())
+	}
+	if opts.Flag != "" {
+		page += ` AND e.thread_id IN (
+			SELECT ef.thread_id FROM emails ef
+			JOIN email_flags f ON f.email_id = ef.id
+			WHERE ef.account_id = e.account_id AND f.flag = ?)`
+		args = append(args, opts.Flag)
+	}
+	if opts.ListID != "" {
+		page += ` AND ` + listIDCond
+		args = append(args, opts.ListID, opts.ListID)
+	}
+	page, args = appendDateRange(page, args, opts.After, opts.Before)
+	page, args = appendSyncedAfter(page, args, opts.SyncedAfter)
+	page += " GROUP BY e.thread_id ORDER BY " + threadPageOrder
+
+	page, args = appendPage(page, args, opts.Limit, opts.Offset)
+
+	return `
+		SELECT e.thread_id,
+			first.subject,
+			first.from_name,
+			first.from_addr,
+			e.last_date,
+			last.body_text,
+			e.msg_count,
+			e.all_read,
+			e.any_starred,
+			` + threadFlagsColumn + ` AS flags,
+			` + threadLabelsColumn + ` AS labels
+		FROM (` + page + `) e
+		JOIN emails first ON first.rowid = (` + threadEndRowid + ` ASC LIMIT 1)
+		JOIN emails last ON last.rowid = (` + threadEndRowid + ` DESC LIMIT 1)
+		ORDER BY ` + threadPageOrder, args
+}
+
+// threadEndRowid selects the rowids of thread e's messages ordered by when
+// they were received; ListThreads completes it with a direction and limit
+// to find the first and latest message.
+const threadEndRowid = `SELECT e2.rowid FROM emails e2 WHERE e2.thread_id = e.thread_id
+	ORDER BY COALESCE(e2.received_at, e2.date)`
+
+// threadPriorityScore ranks a thread group for store.SortPriority: unread and
+// starred threads are boosted most, Gmail's IMPORTANT label less so.
+const threadPriorityScore = `
+	(CASE WHEN MIN(e.is_read) = 0 THEN 2 ELSE 0 END)
+	+ (CASE WHEN MAX(e.is_starred) = 1 THEN 2 ELSE 0 END)
+	+ (CASE WHEN EXISTS (
+		SELECT 1 FROM emails ei
+		JOIN email_labels eli ON eli.email_id = ei.id
+		WHERE ei.thread_id = e.thread_id AND ei.account_id = e.account_id
+			AND eli.label_id = '` + domain.LabelImportant + `'
+	) THEN 1 ELSE 0 END)`
+
+// threadLastReceived is when a thread's latest message was received; see
+// emailSortDate.
+const threadLastReceived = `MAX(` + emailSortDate + `)`
+
+// threadSortColumns returns the sort keys of a ListThreads page, priority
+// and last_received, for a sort mode: store.SortPriority ranks by
+// threadPriorityScore first, any other mode by recency alone.
+func threadSortColumns(sort string) string {
+	priority := "0"
+	if sort == store.SortPriority {
+		priority = threadPriorityScore
+	}
+	return priority + " AS priority, " + threadLastReceived + " AS last_received"
+}
+
+// threadPageOrder orders threads by the columns of threadSortColumns.
+const threadPageOrder = "priority DESC, last_received DESC"
+
+// reconstructBatchSize is how many Message-IDs ReconstructThreads looks up
+// per query, below SQLite's limit on bound parameters.
+const reconstructBatchSize = 500
+
+// ReconstructThreads assigns thread IDs to an account's emails that have
+// none, such as mail from providers without native threading. Emails are
+// linked through their Message-ID, In-Reply-To and References headers; a
+// chain that reaches a message with a thread ID joins that thread, and any
+// other chain gets a synthetic ID derived from its root. It returns the
+// number of emails updated.
+func (s *DB) ReconstructThreads(ctx context.Context, accountID string) (int, error) {
+	// Most syncs bring no unthreaded mail; check before taking the write lock.
+	var pending bool
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM emails WHERE account_id = ? AND thread_id = '')`,
+		accountID).Scan(&pending); err != nil {
+		return 0, fmt.Errorf("failed to check for unthreaded emails: %w", err)
+	}
+	if !pending {
+		return 0, nil
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, COALESCE(message_id, ''), COALESCE(in_reply_to, ''), COALESCE(refs, '')
+		FROM emails WHERE account_id = ? AND thread_id = ''`, accountID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load emails for threading: %w", err)
+	}
+
+	type node struct {
+		id, messageID string
+		links         []string
+	}
+	var nodes []node
+	for rows.Next() {
+		var n node
+		var inReplyTo, refs string
+		if err := rows.Scan(&n.id, &n.messageID, &inReplyTo, &refs); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan email for threading: %w", err)
+		}
+		n.links = strings.Fields(refs)
+		if inReplyTo != "" {
+			n.links = append(n.links, inReplyTo)
+		}
+		nodes = append(nodes, n)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to iterate emails for threading: %w", err)
+	}
+	if len(nodes) == 0 {
+		return 0, nil
+	}
+
+	// Union-find over Message-IDs; an email without one is keyed by its ID.
+	parent := make(map[string]string)
+	var find func(string) string
+	find = func(k string) string {
+		p, ok := parent[k]
+		if !ok || p == k {
+			parent[k] = k
+			return k
+		}
+		root := find(p)
+		parent[k] = root
+		return root
+	}
+	union := func(a, b string) {
+		ra, rb := find(a), find(b)
+		if ra == rb {
+			return
+		}
+		// Keep the smaller key as root so synthetic IDs are stable.
+		if rb < ra {
+			ra, rb = rb, ra
+		}
+		parent[rb] = ra
+	}
+	key := func(n node) string {
+		if n.messageID != "" {
+			return n.messageID
+		}
+		return "id:" + n.id
+	}
+
+	for _, n := range nodes {
+		for _, l := range n.links {
+			union(key(n), l)
+		}
+	}
+
+	// Groups that reach an already-threaded message adopt its thread.
+	linked := make([]string, 0, len(parent))
+	for k := range parent {
+		if !strings.HasPrefix(k, "id:") {
+			linked = append(linked, k)
+		}
+	}
+	slices.Sort(linked)
+	known := make(map[string]string)
+	for batch := range slices.Chunk(linked, reconstructBatchSize) {
+		args := []any{accountID}
+		for _, k := range batch {
+			args = append(args, k)
+		}
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT message_id, thread_id FROM emails
+			WHERE account_id = ? AND thread_id != ''
+				AND message_id IN (?`+strings.Repeat(", ?", len(batch)-1)+`)
+			ORDER BY rowid`, args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load threaded emails: %w", err)
+		}
+		for rows.Next() {
+			var messageID, threadID string
+			if err := rows.Scan(&messageID, &threadID); err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("failed to scan threaded email: %w", err)
+			}
+			known[find(messageID)] = threadID
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, fmt.Errorf("failed to iterate threaded emails: %w", err)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	updated := 0
+	for _, n := range nodes {
+		root := find(key(n))
+		threadID, ok := known[root]
+		if !ok {
+			sum := sha1.Sum([]byte(root))
+			threadID = "ref-" + hex.EncodeToString(sum[:8])
+		}
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE emails SET thread_id = ? WHERE id = ?`, threadID, n.id); err != nil {
+			return 0, fmt.Errorf("failed to set thread for email %s: %w", n.id, err)
+		}
+		updated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit threads: %w", err)
+	}
+	return updated, nil
+}
@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+func TestListThreads_Summaries(t *testing.T) {
+	db := newTestDB(t)
+	seedAccount(t, db)
+	ctx := context.Background()
+
+	base := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	emails := []domain.Email{
+		{
+			ID: "a1", ThreadID: "t-a", Subject: "Kickoff", Body: "first",
+			From: domain.Address{Name: "Ann", Email: "ann@example.com"},
+			Date: base, ReceivedAt: base, IsRead: true, Labels: []string{"INBOX"},
+		},
+		{
+			ID: "a2", ThreadID: "t-a", Subject: "Re: Kickoff", Body: strings.Repeat("x", 150),
+			From: domain.Address{Name: "Bob", Email: "bob@example.com"},
+			Date: base.Add(2 * time.Hour), ReceivedAt: base.Add(2 * time.Hour), Labels: []string{"INBOX"},
+		},
+		// Archived, but still the thread's latest message.
+		{
+			ID: "a3", ThreadID: "t-a", Subject: "Re: Kickoff", Body: "latest",
+			From: domain.Address{Name: "Cy", Email: "cy@example.com"},
+			Date: base.Add(3 * time.Hour), ReceivedAt: base.Add(3 * time.Hour), IsRead: true, IsStarred: true,
+		},
+		{
+			ID: "b1", ThreadID: "t-b", Subject: "Lunch?", Body: "noon",
+			From: domain.Address{Email: "dee@example.com"},
+			Date: base.Add(time.Hour), IsRead: true, Labels: []string{"INBOX"},
+		},
+	}
+	if err := db.UpsertEmails(ctx, emails, "acc-1"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+
+	for _, label := range []string{"", "INBOX"} {
+		threads, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "acc-1", LabelID: label})
+		if err != nil {
+			t.Fatalf("ListThreads(%q) error: %v", label, err)
+		}
+		if len(threads) != 2 || threads[0].ID != "t-a" || threads[1].ID != "t-b" {
+			t.Fatalf("ListThreads(%q) = %+v, want t-a then t-b", label, threads)
+		}
+
+		a := threads[0]
+		if a.Subject != "Kickoff" || a.FromAddress.Name != "Ann" || a.FromAddress.Email != "ann@example.com" {
+			t.Errorf("ListThreads(%q) t-a subject/from = %q/%+v, want the first message's", label, a.Subject, a.FromAddress)
+		}
+		// The snippet comes from the thread's latest message even when the
+		// label filter excludes it.
+		if a.Snippet != "latest" {
+			t.Errorf("ListThreads(%q) t-a snippet = %q, want %q", label, a.Snippet, "latest")
+		}
+		wantCount := 3
+		if label != "" {
+			wantCount = 2
+		}
+		if a.TotalCount != wantCount || !a.HasUnread {
+			t.Errorf("ListThreads(%q) t-a count/unread = %d/%v, want %d/true", label, a.TotalCount, a.HasUnread, wantCount)
+		}
+
+		b := threads[1]
+		if b.Subject != "Lunch?" || b.FromAddress.Email != "dee@example.com" || b.Snippet != "noon" || b.TotalCount != 1 || b.HasUnread {
+			t.Errorf("ListThreads(%q) t-b = %+v", label, b)
+		}
+	}
+
+	threads, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "acc-1", LabelID: "INBOX", Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("ListThreads(page 2) error: %v", err)
+	}
+	if len(threads) != 1 || threads[0].ID != "t-b" {
+		t.Errorf("ListThreads(page 2) = %+v, want t-b", threads)
+	}
+}
+
+// BenchmarkListThreads lists a mailbox of 5000 threads of up to five
+// messages each, all in the inbox: the newest page, and every thread.
+func BenchmarkListThreads(b *testing.B) {
+	db, err := New(":memory:")
+	if err != nil {
+		b.Fatalf("New() error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.CreateAccount(ctx, &domain.Account{ID: "acc-1", Email: "me@example.com", Provider: "gmail"}); err != nil {
+		b.Fatalf("CreateAccount() error: %v", err)
+	}
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var emails []domain.Email
+	for i := range 5000 {
+		for j := range i%5 + 1 {
+			date := base.Add(time.Duration(i)*time.Hour + time.Duration(j)*time.Minute)
+			emails = append(emails, domain.Email{
+				ID:         fmt.Sprintf("m-%d-%d", i, j),
+				ThreadID:   fmt.Sprintf("t-%d", i),
+				Subject:    fmt.Sprintf("Subject %d", i),
+				From:       domain.Address{Name: "Sender", Email: fmt.Sprintf("s%d@example.com", j)},
+				Body:       strings.Repeat("body ", 40),
+				Date:       date,
+				ReceivedAt: date,
+				Labels:     []string{"INBOX"},
+			})
+		}
+	}
+	if err := db.UpsertEmails(ctx, emails, "acc-1"); err != nil {
+		b.Fatalf("UpsertEmails() error: %v", err)
+	}
+
+	for _, bench := range []struct {
+		name string
+		opts store.ListEmailOptions
+	}{
+		{"page", store.ListEmailOptions{AccountID: "acc-1", Limit: 50}},
+		{"inbox-page", store.ListEmailOptions{AccountID: "acc-1", LabelID: "INBOX", Limit: 50}},
+		{"all", store.ListEmailOptions{AccountID: "acc-1"}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for b.Loop() {
+				if _, err := db.ListThreads(ctx, bench.opts); err != nil {
+					b.Fatalf("ListThreads() error: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+)
+
+// DataVersion returns SQLite's data_version counter, which changes whenever
+// another connection (including another process) commits to the database.
+// It uses a dedicated connection because the value is per-connection, except
+// for an in-memory database, whose only connection cannot be held.
+func (s *DB) DataVersion(ctx context.Context) (int64, error) {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+
+	if s.memory {
+		var v int64
+		if err := s.db.QueryRowContext(ctx, `PRAGMA data_version`).Scan(&v); err != nil {
+			return 0, fmt.Errorf("failed to read data version: %w", err)
+		}
+		return v, nil
+	}
+	if s.versionConn == nil {
+		conn, err := s.db.Conn(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open version connection: %w", err)
+		}
+		s.versionConn = conn
+	}
+
+	var v int64
+	if err := s.versionConn.QueryRowContext(ctx, `PRAGMA data_version`).Scan(&v); err != nil {
+		return 0, fmt.Errorf("failed to read data version: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestDataVersion_ChangesOnExternalWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "termail.db")
+	ctx := context.Background()
+
+	reader, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	t.Cleanup(func() { reader.Close() })
+
+	writer, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	t.Cleanup(func() { writer.Close() })
+
+	before, err := reader.DataVersion(ctx)
+	if err != nil {
+		t.Fatalf("DataVersion() error: %v", err)
+	}
+
+	again, err := reader.DataVersion(ctx)
+	if err != nil {
+		t.Fatalf("DataVersion() error: %v", err)
+	}
+	if again != before {
+		t.Errorf("DataVersion() changed without writes: %d -> %d", before, again)
+	}
+
+	seedAccount(t, writer)
+
+	after, err := reader.DataVersion(ctx)
+	if err != nil {
+		t.Fatalf("DataVersion() error: %v", err)
+	}
+	if after == before {
+		t.Errorf("DataVersion() = %d after external write, want a change", after)
+	}
+}
@@ -2,8 +2,11 @@ package store
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/lu-zhengda/termail/internal/domain"
+	"golang.org/x/oauth2"
 )
 
 // Store defines the persistence interface for the application.
@@ -13,26 +16,55 @@ type Store interface {
 	GetAccount(ctx context.Context, id string) (*domain.Account, error)
 	ListAccounts(ctx context.Context) ([]domain.Account, error)
 	DeleteAccount(ctx context.Context, id string) error
+	SetAccountMessagesTotal(ctx context.Context, id string, total int64) error
+	UnreadCount(ctx context.Context, accountID string) (int, error)
 
 	// Emails
 	UpsertEmail(ctx context.Context, email *domain.Email, accountID string) error
-	GetEmail(ctx context.Context, id string) (*domain.Email, error)
+	UpsertEmails(ctx context.Context, emails []domain.Email, accountID string) error
+	GetEmail(ctx context.Context, id string, accountID string) (*domain.Email, error)
 	ListEmails(ctx context.Context, opts ListEmailOptions) ([]domain.Email, error)
-	DeleteEmail(ctx context.Context, id string) error
-	SetEmailRead(ctx context.Context, emailID string, read bool) error
+	ListBySender(ctx context.Context, accountID, addr string, limit int) ([]domain.Email, error)
+	DeleteEmail(ctx context.Context, id string, accountID string) error
+	DeleteAccountEmails(ctx context.Context, accountID string) (int, error)
+	SetEmailRead(ctx context.Context, emailID string, accountID string, read bool) error
 	SetThreadRead(ctx context.Context, threadID string, read bool) error
+	SetEmailStarred(ctx context.Context, emailID string, accountID string, starred bool) error
+	SetEmailFlag(ctx context.Context, emailID, flag string, set bool) error
+	SnoozeEmail(ctx context.Context, id string, until time.Time) error
+	WakeSnoozed(ctx context.Context, accountID string, now time.Time) (int, error)
 
 	// Labels
 	UpsertLabel(ctx context.Context, label *domain.Label) error
 	ListLabels(ctx context.Context, accountID string) ([]domain.Label, error)
-	SetEmailLabels(ctx context.Context, emailID string, labelIDs []string) error
+	DeleteLabel(ctx context.Context, labelID string) error
+	SetEmailLabels(ctx context.Context, emailID string, accountID string, labelIDs []string) error
 
 	// Threads
 	GetThread(ctx context.Context, threadID string, accountID string) (*domain.Thread, error)
 	ListThreads(ctx context.Context, opts ListEmailOptions) ([]domain.Thread, error)
+	ReconstructThreads(ctx context.Context, accountID string) (int, error)
 
 	// Search
-	SearchEmails(ctx context.Context, query string, accountID string) ([]domain.Email, error)
+	SearchEmails(ctx context.Context, query string, accountID string, opts SearchOptions) ([]domain.Email, error)
+
+	// Contacts
+	FrequentContacts(ctx context.Context, accountID, prefix string, limit int) ([]domain.Address, error)
+
+	// Outbox
+	EnqueueOutbox(ctx context.Context, accountID string, email *domain.Email, sendAt time.Time) (int64, error)
+	ListOutbox(ctx context.Context, accountID string) ([]OutboxItem, error)
+	DeleteOutbox(ctx context.Context, id int64) error
+
+	// Stats
+	MailboxStats(ctx context.Context, accountID string) (MailboxStats, error)
+	LabelCounts(ctx context.Context, accountID string) ([]LabelCount, error)
+	TopSenders(ctx context.Context, accountID string, limit int) ([]SenderCount, error)
+	MessagesPerMonth(ctx context.Context, accountID string) ([]MonthCount, error)
+
+	// Recently viewed
+	RecordView(ctx context.Context, accountID, threadID, emailID string, at time.Time) error
+	ListRecent(ctx context.Context, accountID string, limit int) ([]RecentView, error)
 
 	// Sync state
 	GetSyncState(ctx context.Context, accountID string) (*SyncState, error)
@@ -46,13 +78,121 @@ type Store interface {
 type ListEmailOptions struct {
 	AccountID string
 	LabelID   string
-	Limit     int
-	Offset    int
+	// IncludeLabelIDs widens the LabelID filter to emails carrying any of
+	// these labels too, such as the children of a nested label.
+	IncludeLabelIDs []string
+	Limit           int
+	Offset          int
+	// Sort selects the thread ordering; empty means SortDate.
+	Sort string
+	// Flag, if set, limits results to emails (or threads containing an
+	// email) with this local flag.
+	Flag string
+	// ListID, if set, limits results to mail sent through the mailing
+	// list with this List-Id (e.g. "golang-nuts.googlegroups.com").
+	ListID string
+	// After and Before, if set, limit results to mail received at or after
+	// After and strictly before Before.
+	After  time.Time
+	Before time.Time
+	// SyncedAfter, if set, limits results to mail first stored locally at
+	// or after it, such as SyncState.LastSync for what the last sync added.
+	SyncedAfter time.Time
+}
+
+// SearchOptions narrows full-text search results. The zero value searches
+// active mail: everything except TRASH and SPAM.
+type SearchOptions struct {
+	// IncludeTrash also returns messages in TRASH or SPAM.
+	IncludeTrash bool
+	// InboxOnly limits results to messages in INBOX.
+	InboxOnly bool
+	// After and Before limit results to a received-date range, and
+	// SyncedAfter to recently stored mail, as in ListEmailOptions.
+	After       time.Time
+	Before      time.Time
+	SyncedAfter time.Time
+}
+
+// Thread sort modes for ListEmailOptions.Sort.
+const (
+	// SortDate orders threads newest first.
+	SortDate = "date"
+	// SortPriority orders unread, starred and important threads first,
+	// then newest first.
+	SortPriority = "priority"
+)
+
+// ErrNotFound is returned when a requested record does not exist.
+var ErrNotFound = errors.New("not found")
+
+// OutboxItem is an outgoing message waiting to be handed to the provider.
+type OutboxItem struct {
+	ID        int64
+	AccountID string
+	Email     domain.Email
+	// SendAt is when the message becomes due; until then it can be
+	// cancelled.
+	SendAt    time.Time
+	CreatedAt time.Time
+}
+
+// MailboxStats counts an account's stored mail.
+type MailboxStats struct {
+	Total   int
+	Unread  int
+	Starred int
+}
+
+// LabelCount is how many of an account's emails carry a label.
+type LabelCount struct {
+	LabelID string
+	Name    string
+	Total   int
+	Unread  int
+}
+
+// SenderCount is how many emails an address sent, with the display name
+// it most recently used.
+type SenderCount struct {
+	Address domain.Address
+	Count   int
+}
+
+// MonthCount is how many emails are dated in a month, given as "2006-01"
+// in UTC.
+type MonthCount struct {
+	Month string
+	Count int
+}
+
+// RecentView is a recently opened thread, newest first in ListRecent.
+type RecentView struct {
+	// Email is a summary (no body) of the message that was opened, or for
+	// a whole thread, its latest message at the time.
+	Email    domain.Email
+	ViewedAt time.Time
 }
 
 // SyncState tracks the synchronization progress for an account.
 type SyncState struct {
 	AccountID string
 	HistoryID uint64
-	LastSync  int64 // Unix timestamp
+	// LastSync is when the last completed sync started, as a Unix
+	// timestamp, so mail it stored has a created_at at or after it.
+	LastSync int64
+}
+
+// ChangeDetector is implemented by stores that can report when their data
+// was modified by another process, such as a `termail sync` run from cron.
+type ChangeDetector interface {
+	// DataVersion returns a counter that changes after external writes.
+	DataVersion(ctx context.Context) (int64, error)
+}
+
+// TokenStore persists OAuth2 tokens per account.
+type TokenStore interface {
+	SaveToken(accountID string, token *oauth2.Token) error
+	LoadToken(accountID string) (*oauth2.Token, error)
+	DeleteToken(accountID string) error
 }
@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+type closeAccountPickerMsg struct{}
+
+// accountPickerModel is an overlay listing every account, with the active
+// one marked and its unread count, that switches to the chosen account.
+type accountPickerModel struct {
+	accounts []domain.Account
+	active   string
+	unread   map[string]int
+	cursor   int
+	visible  bool
+	width    int
+	height   int
+
+	styles styles
+}
+
+func newAccountPicker() accountPickerModel {
+	return accountPickerModel{styles: defaultStyles()}
+}
+
+// Open shows the picker over accounts with the cursor on the active one.
+func (p *accountPickerModel) Open(accounts []domain.Account, active string) {
+	p.accounts = accounts
+	p.active = active
+	p.cursor = 0
+	for i, a := range accounts {
+		if a.ID == active {
+			p.cursor = i
+		}
+	}
+	p.visible = true
+}
+
+// Close hides the picker.
+func (p *accountPickerModel) Close() {
+	p.visible = false
+}
+
+// SetSize updates the available dimensions for the overlay.
+func (p *accountPickerModel) SetSize(w, h int) {
+	p.width = w
+	p.height = h
+}
+
+// IsVisible reports whether the picker is shown.
+func (p accountPickerModel) IsVisible() bool {
+	return p.visible
+}
+
+func (p accountPickerModel) Update(msg tea.Msg) (accountPickerModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !p.visible || !ok {
+		return p, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, keys.Back), key.Matches(keyMsg, keys.SwitchAccount):
+		return p, func() tea.Msg { return closeAccountPickerMsg{} }
+
+	case key.Matches(keyMsg, keys.Enter):
+		if p.cursor >= len(p.accounts) {
+			return p, nil
+		}
+		id := p.accounts[p.cursor].ID
+		if id == p.active {
+			return p, func() tea.Msg { return closeAccountPickerMsg{} }
+		}
+		return p, func() tea.Msg { return accountSwitchedMsg{accountID: id} }
+
+	case key.Matches(keyMsg, keys.Up):
+		if p.cursor > 0 {
+			p.cursor--
+		}
+
+	case key.Matches(keyMsg, keys.Down):
+		if p.cursor < len(p.accounts)-1 {
+			p.cursor++
+		}
+	}
+	return p, nil
+}
+
+func (p accountPickerModel) View() string {
+	if !p.visible {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(p.styles.title.Render(" Switch account "))
+	b.WriteString("\n\n")
+
+	// Keep the cursor row within the rows available below the title.
+	rows := max(p.height-4, 1)
+	start := max(p.cursor-rows+1, 0)
+	end := min(start+rows, len(p.accounts))
+	for i := start; i < end; i++ {
+		id := p.accounts[i].ID
+		marker := "  "
+		if id == p.active {
+			marker = "● "
+		}
+		badge := p.styles.mutedText.Render(fmt.Sprintf("  %d unread", p.unread[id]))
+		line := lipgloss.NewStyle().Width(max(p.width-2, 10)).Render(marker + id + badge)
+		if i == p.cursor {
+			line = p.styles.selected.Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
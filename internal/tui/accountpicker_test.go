@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lu-zhengda/termail/internal/config"
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store/sqlite"
+)
+
+func TestAccountPicker_EnterSwitchesToChosenAccount(t *testing.T) {
+	accounts := []domain.Account{{ID: "a@example.com"}, {ID: "b@example.com"}, {ID: "c@example.com"}}
+	p := newAccountPicker()
+	p.SetSize(60, 20)
+	p.Open(accounts, "b@example.com")
+
+	if p.cursor != 1 {
+		t.Fatalf("cursor on open = %d, want the active account (1)", p.cursor)
+	}
+	if view := p.View(); !strings.Contains(view, "● b@example.com") || strings.Contains(view, "● a@example.com") {
+		t.Errorf("View() does not mark only the active account:\n%s", view)
+	}
+
+	p, _ = p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+	_, cmd := p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("Enter returned no command")
+	}
+	if msg, ok := cmd().(accountSwitchedMsg); !ok || msg.accountID != "c@example.com" {
+		t.Errorf("Enter emitted %#v, want accountSwitchedMsg for c@example.com", cmd())
+	}
+
+	// Choosing the active account just closes the picker.
+	p.Open(accounts, "b@example.com")
+	_, cmd = p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, ok := cmd().(closeAccountPickerMsg); !ok {
+		t.Errorf("Enter on the active account emitted %#v, want closeAccountPickerMsg", cmd())
+	}
+}
+
+func TestAccountSwitch_KeysOpenPickerOrCycle(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+	accounts := []domain.Account{{ID: "a@example.com"}, {ID: "b@example.com"}, {ID: "c@example.com"}}
+	m := NewModel(cfg, nil, nil, "a@example.com", accounts, nil)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'@'}})
+	m = updated.(model)
+	if !m.accountPicker.IsVisible() {
+		t.Fatal("@ did not open the account picker")
+	}
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(model)
+	if cmd == nil {
+		t.Fatal("esc returned no command")
+	}
+	updated, _ = m.Update(cmd())
+	m = updated.(model)
+	if m.accountPicker.IsVisible() {
+		t.Error("esc did not close the account picker")
+	}
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	if cmd == nil {
+		t.Fatal("ctrl+n returned no command")
+	}
+	if msg, ok := cmd().(accountSwitchedMsg); !ok || msg.accountID != "b@example.com" {
+		t.Errorf("ctrl+n emitted %#v, want accountSwitchedMsg for b@example.com", cmd())
+	}
+}
+
+func TestAccountPicker_ShowsUnreadCounts(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New() error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	accounts := []domain.Account{{ID: "a@example.com"}, {ID: "b@example.com"}}
+	for _, a := range accounts {
+		if err := db.CreateAccount(ctx, &domain.Account{ID: a.ID, Email: a.ID, Provider: "gmail"}); err != nil {
+			t.Fatalf("CreateAccount() error: %v", err)
+		}
+	}
+	unread := domain.Email{ID: "e1", ThreadID: "t1", Date: time.Now(), Labels: []string{domain.LabelInbox}}
+	if err := db.UpsertEmail(ctx, &unread, "a@example.com"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+
+	m := NewModel(cfg, db, nil, "a@example.com", accounts, nil)
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m = updated.(model)
+	updated, _ = m.Update(m.loadUnreadCountsCmd()())
+	m = updated.(model)
+	if m.unread["a@example.com"] != 1 || m.unread["b@example.com"] != 0 {
+		t.Fatalf("unread = %v, want a: 1 and b (never synced): 0", m.unread)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'@'}})
+	m = updated.(model)
+	view := m.accountPicker.View()
+	if !strings.Contains(view, "a@example.com  1 unread") || !strings.Contains(view, "b@example.com  0 unread") {
+		t.Errorf("account picker does not show unread counts:\n%s", view)
+	}
+}
@@ -2,11 +2,16 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/lu-zhengda/termail/internal/app"
+	"github.com/lu-zhengda/termail/internal/config"
 	"github.com/lu-zhengda/termail/internal/domain"
 	"github.com/lu-zhengda/termail/internal/provider"
 	"github.com/lu-zhengda/termail/internal/store"
@@ -33,6 +38,14 @@ type labelsLoadedMsg struct {
 	labels []domain.Label
 }
 
+// startupLabelMsg carries the label the TUI opens on, resolved against the
+// account's labels. warning is set when the configured label was not found.
+type startupLabelMsg struct {
+	labelID string
+	labels  []domain.Label
+	warning string
+}
+
 type emailsLoadedMsg struct {
 	emails []domain.Email
 }
@@ -43,26 +56,94 @@ type threadsLoadedMsg struct {
 
 type emailLoadedMsg struct {
 	email *domain.Email
+	query string
 }
 
 type threadLoadedMsg struct {
 	thread *domain.Thread
 }
 
+// threadIndexLoadedMsg carries a thread whose messages are listed inline in
+// the inbox.
+type threadIndexLoadedMsg struct {
+	thread *domain.Thread
+}
+
 type searchResultsMsg struct {
 	results []domain.Email
 }
 
 type emailSentMsg struct{}
 
+// emailSavedMsg reports a send that failed and was kept in the outbox.
+type emailSavedMsg struct {
+	err error
+}
+
+// outboxRetryMsg triggers a retry of mail waiting in the outbox.
+type outboxRetryMsg struct{}
+
+// emailQueuedMsg reports an email held in the outbox for the send delay.
+type emailQueuedMsg struct {
+	id        int64
+	accountID string
+	email     *domain.Email
+}
+
+// outboxDueMsg fires when queued mail for an account becomes due.
+type outboxDueMsg struct {
+	accountID string
+}
+
+type outboxSentMsg struct {
+	count int
+}
+
 type actionDoneMsg struct {
 	action string
+	// count is the number of emails an emailActionMsg was applied to.
+	count int
+	// undo is set for actions that can be reversed within undoWindow.
+	undo *undoEntry
+}
+
+// threadSyncedMsg carries the reader's content reloaded after a thread
+// sync; both email and thread are nil once nothing open is left locally.
+type threadSyncedMsg struct {
+	email   *domain.Email
+	thread  *domain.Thread
+	fetched int
+	removed int
+}
+
+// readerReloadedMsg carries a fresh copy of what the reader has open.
+type readerReloadedMsg struct {
+	email  *domain.Email
+	thread *domain.Thread
+}
+
+type flagDoneMsg struct {
+	flag string
+}
+
+type undoDoneMsg struct {
+	entry undoEntry
 }
 
 type accountSwitchedMsg struct {
 	accountID string
 }
 
+// unreadCountsMsg carries the unread INBOX count of every account.
+type unreadCountsMsg struct {
+	counts map[string]int
+}
+
+// senderFilterMsg filters the list to all mail from addr.
+type senderFilterMsg struct {
+	addr string
+}
+
 type errMsg struct {
 	err error
 }
@@ -73,61 +154,196 @@ type ProviderFactory func(accountID string) provider.EmailProvider
 // --- root model ---
 
 type model struct {
+	cfg             *config.Config
 	store           store.Store
 	provider        provider.EmailProvider
 	providerFactory ProviderFactory
 	accountID       string
 	accounts        []domain.Account
 
-	sidebar  sidebarModel
-	inbox    inboxModel
-	reader   readerModel
-	composer composerModel
-	search   searchModel
+	sidebar       sidebarModel
+	inbox         inboxModel
+	reader        readerModel
+	composer      composerModel
+	search        searchModel
+	help          helpModel
+	snooze        snoozePromptModel
+	picker        labelPickerModel
+	accountPicker accountPickerModel
 
 	activePane pane
+	focusRing  focusRing
 	viewMode   viewMode
 	statusBar  statusBar
 
 	width  int
 	height int
+
+	// reloadInterval is how often to poll the store for external changes;
+	// zero disables auto-reload.
+	reloadInterval time.Duration
+	watcher        changeWatcher
+
+	// sendDelay holds sent mail in the outbox so it can be undone; zero
+	// sends immediately.
+	sendDelay time.Duration
+
+	// outboxRetry is how often mail left in the outbox by a failed send is
+	// retried (sync.interval); zero disables retries.
+	outboxRetry time.Duration
+
+	// undo holds recent archive/delete actions that can still be reversed.
+	undo undoStack
+
+	// positions remembers each account's label and list position so
+	// switching back to an account restores where the user left off.
+	positions map[string]accountPosition
+
+	// unread caches each account's unread INBOX count for the account
+	// picker; it is refreshed at startup and after syncs.
+	unread map[string]int
+
+	// senderFilter, while set, replaces the list with all mail from this
+	// address, shown flat; esc in the list clears it.
+	senderFilter string
+
+	// authRequired is set once the provider reports that no usable OAuth
+	// token is available; remote calls are skipped while it is set.
+	authRequired bool
+
+	styles styles
 }
 
 // NewModel creates a new root TUI model.
-func NewModel(s store.Store, p provider.EmailProvider, accountID string, accounts []domain.Account, factory ProviderFactory) model {
+func NewModel(cfg *config.Config, s store.Store, p provider.EmailProvider, accountID string, accounts []domain.Account, factory ProviderFactory) model {
+	view := parseViewMode(cfg.UI.DefaultView)
 	inbox := newInbox()
 	inbox.focused = true
+	inbox.viewMode = view
+	inbox.enterExpands = cfg.UI.ThreadEnter == "expand"
+	inbox.comfortable = cfg.UI.Density == "comfortable"
+	if cfg.UI.List.FromWidth > 0 {
+		inbox.fromWidth = cfg.UI.List.FromWidth
+	}
+	inbox.absoluteDates = cfg.UI.List.DateFormat == "absolute"
+	inbox.showLabels = cfg.UI.List.ShowLabels
+	inbox.groupByDate = cfg.UI.List.GroupByDate
 
 	sidebar := newSidebar()
 	sidebar.accountEmail = accountID
+	sidebar.mailboxSize = mailboxSize(accounts, accountID)
 
 	sb := newStatusBar()
 	sb.multiAccount = len(accounts) > 1
 
+	composer := newComposer()
+	composer.defaultReplyTo = cfg.Compose.ReplyTo
+	composer.confirmRecipients = cfg.Compose.ConfirmRecipients
+	composer.groups = cfg.Groups
+	composer.defaultCC = parseAddresses(cfg.Compose.DefaultCC, cfg.Groups)
+	composer.defaultBCC = parseAddresses(cfg.Compose.DefaultBCC, cfg.Groups)
+	if cfg.Compose.DefaultCC != "" {
+		composer.ccInput.Placeholder = "defaults to " + cfg.Compose.DefaultCC
+	}
+
+	reader := newReader()
+	reader.contextLines = cfg.UI.SearchContextLines
+	reader.loadRemote = cfg.UI.LoadRemoteContent
+	reader.compact = cfg.UI.CompactHeaders
+	reader.preferHTML = cfg.UI.PreferHTML
+	reader.inlineReply = cfg.UI.InlineReply
+	reader.defaultReplyTo = parseAddresses(cfg.Compose.ReplyTo, nil)
+	reader.defaultCC = composer.defaultCC
+	reader.defaultBCC = composer.defaultBCC
+
+	var reloadInterval time.Duration
+	if cfg.UI.AutoReload != "" {
+		// An invalid interval leaves auto-reload disabled.
+		if d, err := time.ParseDuration(cfg.UI.AutoReload); err == nil && d > 0 {
+			reloadInterval = d
+		}
+	}
+
+	// An invalid send delay sends immediately.
+	sendDelay, _ := cfg.SendDelay("gmail")
+
+	// An invalid sync interval leaves failed sends in the outbox until the
+	// next start or `termail outbox flush`.
+	outboxRetry, _ := time.ParseDuration(cfg.Sync.Interval)
+
+	// Run rejects unknown themes; here they fall back to the default.
+	theme, err := LookupTheme(cfg.UI.Theme)
+	if err != nil {
+		theme = themes[DefaultTheme]
+	}
+	st := newStyles(theme)
+	inbox.styles = st
+	sidebar.styles = st
+	sb.styles = st
+	composer.styles = st
+	reader.styles = st
+	search := newSearch()
+	search.styles = st
+	help := newHelp()
+	help.styles = st
+	snooze := newSnoozePrompt()
+	snooze.styles = st
+	picker := newLabelPicker()
+	picker.styles = st
+	accountPicker := newAccountPicker()
+	accountPicker.styles = st
+
 	return model{
+		cfg:             cfg,
 		store:           s,
 		provider:        p,
 		providerFactory: factory,
 		accountID:       accountID,
 		accounts:        accounts,
 		activePane:      paneList,
-		viewMode:        viewThread,
+		focusRing:       parseFocusRing(cfg.UI.FocusOrder),
+		viewMode:        view,
 		sidebar:         sidebar,
 		inbox:           inbox,
-		reader:          newReader(),
-		composer:        newComposer(),
-		search:          newSearch(),
+		reader:          reader,
+		composer:        composer,
+		search:          search,
+		help:            help,
+		snooze:          snooze,
+		picker:          picker,
+		accountPicker:   accountPicker,
 		statusBar:       sb,
+		reloadInterval:  reloadInterval,
+		sendDelay:       sendDelay,
+		outboxRetry:     outboxRetry,
+		positions:       make(map[string]accountPosition),
+		styles:          st,
 	}
 }
 
 func (m model) Init() tea.Cmd {
 	return tea.Batch(
 		m.loadLabelsCmd(),
-		m.loadMailCmd(domain.LabelInbox),
+		m.startupLabelCmd(),
+		m.pollStoreCmd(),
+		m.wakeSnoozedCmd(),
+		m.snoozeTickCmd(),
+		m.sendDueCmd(m.accountID),
+		m.outboxRetryCmd(),
+		m.initialSyncCmd(),
+		m.loadUnreadCountsCmd(),
 	)
 }
 
+// pollStoreCmd schedules the next external-change check, if enabled.
+func (m model) pollStoreCmd() tea.Cmd {
+	d, ok := m.store.(store.ChangeDetector)
+	if !ok || m.reloadInterval <= 0 {
+		return nil
+	}
+	return pollStoreCmd(d, m.reloadInterval)
+}
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
@@ -138,28 +354,58 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		m.statusBar.width = msg.Width
+		m.snooze.SetSize(msg.Width)
 		m.resizeSubModels()
 		return m, nil
 
 	// --- async result messages ---
 	case labelsLoadedMsg:
 		m.sidebar.SetLabels(msg.labels)
+		m.inbox.SetLabels(msg.labels)
 		m.statusBar.setMessage(fmt.Sprintf("Loaded %d labels", len(msg.labels)))
 		return m, nil
 
+	case startupLabelMsg:
+		if msg.labels != nil {
+			m.sidebar.SetLabels(msg.labels)
+			m.inbox.SetLabels(msg.labels)
+		}
+		m.sidebar.SetActiveLabel(msg.labelID)
+		if msg.warning != "" {
+			m.statusBar.setError(msg.warning)
+		}
+		return m, m.loadMailCmd(msg.labelID)
+
 	case emailsLoadedMsg:
 		m.inbox.SetEmails(msg.emails)
+		if m.senderFilter != "" {
+			m.statusBar.setMessage(fmt.Sprintf("%d emails from %s (esc to clear)", len(msg.emails), m.senderFilter))
+			return m, nil
+		}
 		m.statusBar.setMessage(fmt.Sprintf("Loaded %d emails", len(msg.emails)))
 		return m, nil
 
 	case threadsLoadedMsg:
+		// A reload started before the sender filter was set.
+		if m.senderFilter != "" {
+			return m, nil
+		}
 		m.inbox.SetThreads(msg.threads)
 		m.statusBar.setMessage(fmt.Sprintf("Loaded %d threads", len(msg.threads)))
 		return m, nil
 
+	case senderFilterMsg:
+		m.senderFilter = msg.addr
+		m.inbox.SetViewMode(viewFlat)
+		m.reader.Close()
+		m.statusBar.readerVisible = false
+		m.setFocus(paneList)
+		m.statusBar.setMessage(fmt.Sprintf("Loading mail from %s...", msg.addr))
+		return m, m.loadMailCmd(m.sidebar.activeLabel)
+
 	case emailLoadedMsg:
 		if msg.email != nil {
-			m.reader.ShowEmail(msg.email)
+			m.reader.ShowEmail(msg.email, msg.query)
 			m.setFocus(paneReader)
 			m.statusBar.readerVisible = true
 			m.resizeSubModels()
@@ -181,47 +427,133 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case emailSentMsg:
-		m.composer.Close()
+		m.closeCompose()
 		m.statusBar.setMessage("Email sent")
-		m.setFocus(paneList)
+		return m, nil
+
+	case emailSavedMsg:
+		m.closeCompose()
+		m.statusBar.setError(msg.err.Error())
 		return m, nil
 
 	case actionDoneMsg:
 		m.statusBar.setMessage(fmt.Sprintf("Action: %s done", msg.action))
+		if msg.count > 0 {
+			m.inbox.ClearSelection()
+		}
+		if msg.count > 1 {
+			m.statusBar.setMessage(fmt.Sprintf("Action: %s done on %d messages", msg.action, msg.count))
+		}
 		// Close reader and go back to list after destructive actions.
-		if msg.action == "archive" || msg.action == "delete" {
+		if undoable(msg.action) {
 			m.reader.Close()
 			m.statusBar.readerVisible = false
 			m.setFocus(paneList)
 		}
 		// Reload current label to reflect changes.
+		reload := m.loadMailCmd(m.sidebar.activeLabel)
+		if (msg.action == "star" || msg.action == "unstar") && m.reader.IsVisible() {
+			reload = tea.Batch(reload, m.reloadReaderCmd())
+		}
+		if msg.undo != nil {
+			m.undo.push(*msg.undo)
+			what := undoPastTense(msg.action)
+			if msg.count > 1 {
+				what = fmt.Sprintf("%s %d messages", what, msg.count)
+			}
+			m.statusBar.setMessage(fmt.Sprintf("%s (%s to undo)", what, keys.Undo.Help().Key))
+			expire := tea.Tick(undoWindow, func(time.Time) tea.Msg {
+				return undoExpiredMsg{}
+			})
+			return m, tea.Batch(reload, expire)
+		}
+		return m, reload
+
+	case storeVersionMsg:
+		next := m.pollStoreCmd()
+		if msg.version < 0 || !m.watcher.observe(msg.version) {
+			return m, next
+		}
+		return m, tea.Batch(m.loadMailCmd(m.sidebar.activeLabel), m.loadLabelsCmd(), m.loadUnreadCountsCmd(), next)
+
+	case unreadCountsMsg:
+		m.unread = msg.counts
+		m.accountPicker.unread = msg.counts
+		return m, nil
+
+	case undoExpiredMsg:
+		// Only clear the hint if no newer undoable action is still pending
+		// and nothing else has replaced it in the status bar.
+		m.undo.prune(time.Now())
+		if m.undo.len() == 0 && strings.HasSuffix(m.statusBar.message, "to undo)") {
+			m.statusBar.setMessage("Ready")
+		}
+		return m, nil
+
+	case undoDoneMsg:
+		if msg.entry.action == "send" {
+			// Reopen the cancelled message so it can be edited.
+			m.composer.ComposeDraft(msg.entry.email)
+			m.resizeComposer()
+			m.statusBar.setMessage("Send cancelled")
+			return m, nil
+		}
+		m.statusBar.setMessage(fmt.Sprintf("Undid %s", msg.entry.action))
 		return m, m.loadMailCmd(m.sidebar.activeLabel)
 
+	case closeAccountPickerMsg:
+		m.accountPicker.Close()
+		return m, nil
+
 	case accountSwitchedMsg:
+		m.accountPicker.Close()
+		m.clearSenderFilter()
+		m.authRequired = false
+		m.undo.clear()
+		m.positions[m.accountID] = m.currentPosition()
 		m.accountID = msg.accountID
 		if m.providerFactory != nil {
 			m.provider = m.providerFactory(msg.accountID)
 		}
 		m.sidebar.accountEmail = msg.accountID
-		m.sidebar.activeLabel = domain.LabelInbox
-		m.sidebar.cursor = 0
-		m.inbox.cursor = 0
-		m.inbox.offset = 0
+		m.sidebar.mailboxSize = mailboxSize(m.accounts, msg.accountID)
+		m.restorePosition(m.positions[msg.accountID])
 		m.reader.Close()
 		m.statusBar.readerVisible = false
 		m.setFocus(paneList)
 		m.statusBar.setMessage(fmt.Sprintf("Switched to %s", msg.accountID))
 		return m, tea.Batch(
 			m.loadLabelsCmd(),
-			m.loadMailCmd(domain.LabelInbox),
+			m.loadMailCmd(m.sidebar.activeLabel),
 		)
 
+	case syncProgressMsg:
+		m.statusBar.setProgress(msg.fetched, msg.total)
+		return m, waitSyncCmd(msg.updates)
+
+	case initialSyncDoneMsg:
+		m.statusBar.clearProgress()
+		if msg.err != nil {
+			return m.Update(errMsg{err: fmt.Errorf("initial sync failed: %w", msg.err)})
+		}
+		m.statusBar.setMessage(fmt.Sprintf("Synced %d messages", msg.fetched))
+		return m, tea.Batch(m.loadLabelsCmd(), m.loadMailCmd(m.sidebar.activeLabel), m.loadUnreadCountsCmd())
+
 	case errMsg:
+		if isAuthError(msg.err) {
+			// Keep the UI usable offline; remote actions are skipped until
+			// the user fixes their credentials and restarts.
+			m.authRequired = true
+			m.statusBar.setError(authRequiredMessage(msg.err))
+			return m, nil
+		}
 		m.statusBar.setError(fmt.Sprintf("Error: %v", msg.err))
 		return m, nil
 
 	// --- sub-model emitted messages ---
 	case labelSelectedMsg:
+		m.clearSenderFilter()
+		m.undo.clear()
 		m.reader.Close()
 		m.statusBar.readerVisible = false
 		m.inbox.cursor = 0
@@ -233,7 +565,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case emailSelectedMsg:
 		m.statusBar.setMessage("Loading email...")
 		return m, tea.Batch(
-			m.loadEmailCmd(msg.emailID),
+			m.loadEmailCmd(msg.emailID, ""),
 			m.markReadCmd(msg.emailID),
 		)
 
@@ -244,9 +576,121 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.markThreadReadCmd(msg.threadID),
 		)
 
+	case threadExpandMsg:
+		return m, m.loadThreadIndexCmd(msg.threadID)
+
+	case threadIndexLoadedMsg:
+		m.inbox.Expand(msg.thread)
+		m.statusBar.setMessage("Select a message to open (esc to collapse)")
+		return m, nil
+
+	case threadStarMsg:
+		m.statusBar.setMessage("Updating star...")
+		return m, m.starThreadCmd(msg.threadID, msg.star)
+
+	case flagMsg:
+		return m, m.cycleFlagCmd(msg)
+
+	case flagDoneMsg:
+		if msg.flag == "" {
+			m.statusBar.setMessage("Flag cleared")
+		} else {
+			m.statusBar.setMessage(fmt.Sprintf("Flagged %s", msg.flag))
+		}
+		return m, m.loadMailCmd(m.sidebar.activeLabel)
+
+	case snoozeMsg:
+		m.snooze.Open(msg)
+		return m, nil
+
+	case labelPickerMsg:
+		targets := moveTargets(m.sidebar.labels)
+		if len(targets) == 0 {
+			m.statusBar.setMessage("No labels to move to")
+			return m, nil
+		}
+		m.picker.Open(msg.emailIDs, targets)
+		m.resizePicker()
+		return m, nil
+
+	case closeLabelPickerMsg:
+		m.picker.Close()
+		return m, nil
+
+	case moveToLabelMsg:
+		m.picker.Close()
+		m.statusBar.setMessage(fmt.Sprintf("Moving to %s...", displayName(msg.label)))
+		return m, m.moveToLabelCmd(msg)
+
+	case movedToLabelMsg:
+		m.inbox.ClearSelection()
+		if msg.label.ID != m.sidebar.activeLabel {
+			m.reader.Close()
+			m.statusBar.readerVisible = false
+			m.setFocus(paneList)
+		}
+		if msg.count > 1 {
+			m.statusBar.setMessage(fmt.Sprintf("Moved %d messages to %s", msg.count, displayName(msg.label)))
+		} else {
+			m.statusBar.setMessage(fmt.Sprintf("Moved to %s", displayName(msg.label)))
+		}
+		return m, m.loadMailCmd(m.sidebar.activeLabel)
+
+	case snoozeRequestMsg:
+		m.snooze.Close()
+		m.statusBar.setMessage("Snoozing...")
+		return m, m.snoozeCmd(msg)
+
+	case closeSnoozeMsg:
+		m.snooze.Close()
+		return m, nil
+
+	case snoozeDoneMsg:
+		if m.reader.IsVisible() {
+			m.reader.Close()
+			m.statusBar.readerVisible = false
+			m.setFocus(paneList)
+		}
+		m.statusBar.setMessage(fmt.Sprintf("Snoozed until %s", msg.until.Local().Format("Mon Jan 2 15:04")))
+		return m, m.loadMailCmd(m.sidebar.activeLabel)
+
+	case snoozeTickMsg:
+		return m, tea.Batch(m.wakeSnoozedCmd(), m.snoozeTickCmd())
+
+	case snoozeWokenMsg:
+		if msg.count == 0 {
+			return m, nil
+		}
+		m.statusBar.setMessage(fmt.Sprintf("%d snoozed message(s) returned to the inbox", msg.count))
+		return m, m.loadMailCmd(m.sidebar.activeLabel)
+
 	case emailActionMsg:
-		m.statusBar.setMessage(fmt.Sprintf("Performing %s...", msg.action))
-		return m, m.performActionCmd(msg.emailID, msg.action)
+		action := msg.action
+		if action == "delete" {
+			switch setting := m.cfg.UI.DeleteAction; {
+			case setting == "archive":
+				action = "archive"
+			case strings.HasPrefix(setting, "label:"):
+				want := strings.TrimPrefix(setting, "label:")
+				label, ok := m.findLabel(want)
+				if !ok {
+					m.statusBar.setError(fmt.Sprintf("delete_action label %q not found", want))
+					return m, nil
+				}
+				m.statusBar.setMessage(fmt.Sprintf("Moving to %s...", displayName(label)))
+				return m, m.moveToLabelCmd(moveToLabelMsg{emailIDs: msg.emailIDs, label: label})
+			}
+		}
+		// The spam key reports mail elsewhere and rescues it in Spam, and
+		// the trash key likewise restores mail in Trash.
+		if action == "spam" && m.sidebar.activeLabel == domain.LabelSpam {
+			action = "notspam"
+		}
+		if action == "trash" && m.sidebar.activeLabel == domain.LabelTrash {
+			action = "untrash"
+		}
+		m.statusBar.setMessage(fmt.Sprintf("Performing %s...", action))
+		return m, m.performActionCmd(msg.emailIDs, action)
 
 	case replyMsg:
 		m.composer.Reply(msg.email, msg.replyAll)
@@ -258,6 +702,32 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.resizeComposer()
 		return m, nil
 
+	case refreshThreadMsg:
+		m.statusBar.setMessage("Refreshing thread...")
+		return m, m.syncThreadCmd(msg)
+
+	case fullBodyMsg:
+		m.statusBar.setMessage("Fetching full message...")
+		return m, m.fetchFullBodyCmd(msg.emailID)
+
+	case threadSyncedMsg:
+		if msg.email == nil && msg.thread == nil {
+			m.reader.Close()
+			m.statusBar.readerVisible = false
+			m.setFocus(paneList)
+			m.statusBar.setMessage("Message no longer on the server")
+		} else {
+			m.reader.Reload(msg.email, msg.thread)
+			m.statusBar.setMessage(fmt.Sprintf("Thread refreshed: %d messages, %d removed", msg.fetched, msg.removed))
+		}
+		return m, m.loadMailCmd(m.sidebar.activeLabel)
+
+	case readerReloadedMsg:
+		if m.reader.IsVisible() {
+			m.reader.Reload(msg.email, msg.thread)
+		}
+		return m, nil
+
 	case closeReaderMsg:
 		m.reader.Close()
 		m.statusBar.readerVisible = false
@@ -265,9 +735,62 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case sendMsg:
+		if msg.forwardOf != "" {
+			m.statusBar.setMessage("Fetching attachments...")
+			return m, m.forwardAttachmentsCmd(msg)
+		}
+		if m.sendDelay > 0 {
+			return m, m.queueSendCmd(msg.email)
+		}
 		m.statusBar.setMessage("Sending email...")
 		return m, m.sendEmailCmd(msg.email)
 
+	case emailQueuedMsg:
+		m.closeCompose()
+		m.undo.push(undoEntry{
+			action:   "send",
+			outboxID: msg.id,
+			email:    msg.email,
+			expires:  time.Now().Add(m.sendDelay),
+		})
+		m.statusBar.setMessage(fmt.Sprintf("Sending in %s (%s to undo)", m.sendDelay, keys.Undo.Help().Key))
+		return m, tea.Batch(
+			tea.Tick(m.sendDelay, func(time.Time) tea.Msg { return outboxDueMsg{accountID: msg.accountID} }),
+			tea.Tick(m.sendDelay, func(time.Time) tea.Msg { return undoExpiredMsg{} }),
+		)
+
+	case outboxDueMsg:
+		return m, m.sendDueCmd(msg.accountID)
+
+	case outboxRetryMsg:
+		return m, tea.Batch(m.retryOutboxCmd(), m.outboxRetryCmd())
+
+	case outboxSentMsg:
+		if msg.count > 0 {
+			m.statusBar.setMessage("Email sent")
+		}
+		return m, nil
+
+	case unsubscribeMsg:
+		unsub := domain.ParseListUnsubscribe(msg.email.ListUnsubscribe)
+		if unsub.Mailto == "" {
+			if unsub.URL == "" {
+				m.statusBar.setError("No usable List-Unsubscribe target")
+				return m, nil
+			}
+			m.statusBar.setMessage(fmt.Sprintf("Unsubscribe at: %s", unsub.URL))
+			return m, nil
+		}
+		m.statusBar.setMessage("Sending unsubscribe request...")
+		return m, m.unsubscribeCmd(unsub)
+
+	case contactQueryMsg:
+		return m, m.contactsCmd(msg.prefix)
+
+	case contactSuggestionsMsg:
+		m.composer.SetSuggestions(msg.prefix, msg.contacts)
+		return m, nil
+
 	case cancelComposeMsg:
 		m.composer.Close()
 		m.setFocus(paneList)
@@ -281,7 +804,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.search.Close()
 		m.statusBar.setMessage("Loading email...")
 		return m, tea.Batch(
-			m.loadEmailCmd(msg.emailID),
+			m.loadEmailCmd(msg.emailID, msg.query),
 			m.markReadCmd(msg.emailID),
 		)
 
@@ -306,6 +829,41 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 
+		// Snooze prompt gets all key events when visible.
+		if m.snooze.IsVisible() {
+			var cmd tea.Cmd
+			m.snooze, cmd = m.snooze.Update(msg)
+			return m, cmd
+		}
+
+		// Label picker gets all key events when visible.
+		if m.picker.IsVisible() {
+			var cmd tea.Cmd
+			m.picker, cmd = m.picker.Update(msg)
+			return m, cmd
+		}
+
+		// Account picker gets all key events when visible.
+		if m.accountPicker.IsVisible() {
+			var cmd tea.Cmd
+			m.accountPicker, cmd = m.accountPicker.Update(msg)
+			return m, cmd
+		}
+
+		// Help overlay gets all key events when visible.
+		if m.help.IsVisible() {
+			var cmd tea.Cmd
+			m.help, cmd = m.help.Update(msg)
+			return m, cmd
+		}
+
+		// The inline reply box gets all key events while open.
+		if m.reader.IsReplying() {
+			var cmd tea.Cmd
+			m.reader, cmd = m.reader.Update(msg)
+			return m, cmd
+		}
+
 		// Global keys (when no overlay).
 		switch {
 		case key.Matches(msg, keys.Quit):
@@ -321,25 +879,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.resizeSearch()
 			return m, nil
 
-		case key.Matches(msg, keys.Tab):
-			if m.reader.IsVisible() {
-				// Toggle between list and reader when reader is open.
-				if m.activePane == paneList {
-					m.setFocus(paneReader)
-				} else {
-					m.setFocus(paneList)
-				}
-			} else {
-				// Toggle between sidebar and list.
-				if m.activePane == paneSidebar {
-					m.setFocus(paneList)
-				} else {
-					m.setFocus(paneSidebar)
-				}
-			}
+		case key.Matches(msg, keys.Help):
+			m.help.Open()
+			m.resizeHelp()
+			return m, nil
+
+		case key.Matches(msg, keys.Tab), key.Matches(msg, keys.BackTab):
+			m.setFocus(m.focusRing.next(m.activePane, m.paneVisible, key.Matches(msg, keys.BackTab)))
 			return m, nil
 
 		case key.Matches(msg, keys.Toggle):
+			m.clearSenderFilter()
 			if m.viewMode == viewThread {
 				m.viewMode = viewFlat
 				m.inbox.SetViewMode(viewFlat)
@@ -351,7 +901,25 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, m.loadMailCmd(m.sidebar.activeLabel)
 
+		case key.Matches(msg, keys.Undo):
+			entry, ok := m.undo.pop(time.Now())
+			if !ok {
+				m.statusBar.setMessage("Nothing to undo")
+				return m, nil
+			}
+			m.statusBar.setMessage(fmt.Sprintf("Undoing %s...", entry.action))
+			return m, m.undoCmd(entry)
+
 		case key.Matches(msg, keys.SwitchAccount):
+			if len(m.accounts) < 2 {
+				m.statusBar.setMessage("Only one account configured")
+				return m, nil
+			}
+			m.accountPicker.Open(m.accounts, m.accountID)
+			m.resizeAccountPicker()
+			return m, nil
+
+		case key.Matches(msg, keys.NextAccount):
 			if len(m.accounts) < 2 {
 				m.statusBar.setMessage("Only one account configured")
 				return m, nil
@@ -359,6 +927,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.switchAccountCmd()
 		}
 
+		if m.senderFilter != "" && m.activePane == paneList && key.Matches(msg, keys.Back) && len(m.inbox.selected) == 0 {
+			m.clearSenderFilter()
+			m.statusBar.setMessage("Sender filter cleared")
+			return m, m.loadMailCmd(m.sidebar.activeLabel)
+		}
+
 		// Delegate to focused sub-model.
 		switch m.activePane {
 		case paneSidebar:
@@ -398,7 +972,7 @@ func (m model) View() string {
 	contentHeight := m.height - 3 // reserve space for status bar
 
 	// --- Sidebar ---
-	sidebarView := sidebarStyle.
+	sidebarView := m.styles.sidebar.
 		Width(sidebarWidth).
 		Height(contentHeight).
 		Render(m.sidebar.View())
@@ -421,17 +995,35 @@ func (m model) View() string {
 			Height(contentHeight).
 			Render(m.search.View())
 
+	case m.picker.IsVisible():
+		contentView = lipgloss.NewStyle().
+			Width(contentWidth).
+			Height(contentHeight).
+			Render(m.picker.View())
+
+	case m.accountPicker.IsVisible():
+		contentView = lipgloss.NewStyle().
+			Width(contentWidth).
+			Height(contentHeight).
+			Render(m.accountPicker.View())
+
+	case m.help.IsVisible():
+		contentView = lipgloss.NewStyle().
+			Width(contentWidth).
+			Height(contentHeight).
+			Render(m.help.View())
+
 	case m.reader.IsVisible():
 		// Split view: list (top half) + reader (bottom half).
 		listHeight := contentHeight / 2
 		readerHeight := contentHeight - listHeight
 
-		listView := listStyle.
+		listView := m.styles.list.
 			Width(contentWidth).
 			Height(listHeight).
 			Render(m.inbox.View())
 
-		readerView := readerStyle.
+		readerView := m.styles.reader.
 			Width(contentWidth).
 			Height(readerHeight).
 			Render(m.reader.View())
@@ -440,7 +1032,7 @@ func (m model) View() string {
 
 	default:
 		// List takes full content area.
-		contentView = listStyle.
+		contentView = m.styles.list.
 			Width(contentWidth).
 			Height(contentHeight).
 			Render(m.inbox.View())
@@ -448,10 +1040,46 @@ func (m model) View() string {
 
 	main := lipgloss.JoinHorizontal(lipgloss.Top, sidebarView, contentView)
 	sb := m.statusBar.View()
+	if m.snooze.IsVisible() {
+		sb = m.snooze.View()
+	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, main, sb)
 }
 
+// --- per-account position ---
+
+// accountPosition is the list state remembered for an account while another
+// account is active.
+type accountPosition struct {
+	label         string
+	sidebarCursor int
+	cursor        int
+	offset        int
+}
+
+// currentPosition captures the active account's label and list position.
+func (m model) currentPosition() accountPosition {
+	return accountPosition{
+		label:         m.sidebar.activeLabel,
+		sidebarCursor: m.sidebar.cursor,
+		cursor:        m.inbox.cursor,
+		offset:        m.inbox.offset,
+	}
+}
+
+// restorePosition applies a remembered position; the zero value starts at
+// the top of the inbox. The list cursor is clamped once the mail loads.
+func (m *model) restorePosition(p accountPosition) {
+	if p.label == "" {
+		p.label = domain.LabelInbox
+	}
+	m.sidebar.activeLabel = p.label
+	m.sidebar.cursor = p.sidebarCursor
+	m.inbox.cursor = p.cursor
+	m.inbox.offset = p.offset
+}
+
 // --- focus management ---
 
 func (m *model) setFocus(p pane) {
@@ -461,6 +1089,26 @@ func (m *model) setFocus(p pane) {
 	m.reader.focused = (p == paneReader)
 }
 
+// closeCompose closes the composer, or the reader's inline reply box when
+// the mail came from there, once it is sent or saved for a retry. The inline
+// reply leaves the reader open and focused.
+func (m *model) closeCompose() {
+	if m.reader.IsReplying() {
+		m.reader.CloseReply()
+		return
+	}
+	m.composer.Close()
+	m.setFocus(paneList)
+}
+
+// paneVisible reports whether p is on screen and can take focus.
+func (m model) paneVisible(p pane) bool {
+	if p == paneReader {
+		return m.reader.IsVisible()
+	}
+	return true
+}
+
 // --- layout helpers ---
 
 func (m model) layoutWidths() (sidebarWidth, contentWidth int) {
@@ -477,15 +1125,15 @@ func (m *model) resizeSubModels() {
 	contentHeight := m.height - 3
 
 	// Pass content area dimensions (subtract border + padding from each style).
-	// sidebarStyle: Border(2h + 2v) + Padding(2h + 2v) = 4h, 4v
+	// m.styles.sidebar: Border(2h + 2v) + Padding(2h + 2v) = 4h, 4v
 	m.sidebar.SetSize(sidebarWidth-4, contentHeight-4)
 
-	// listStyle: Border(2h + 2v) + Padding(2h + 0v) = 4h, 2v
+	// m.styles.list: Border(2h + 2v) + Padding(2h + 0v) = 4h, 2v
 	if m.reader.IsVisible() {
 		listHeight := contentHeight / 2
 		readerHeight := contentHeight - listHeight
 		m.inbox.SetSize(contentWidth-4, listHeight-2)
-		// readerStyle: Border(2h + 2v) + Padding(4h + 2v) = 6h, 4v
+		// m.styles.reader: Border(2h + 2v) + Padding(4h + 2v) = 6h, 4v
 		m.reader.SetSize(contentWidth-6, readerHeight-4)
 	} else {
 		m.inbox.SetSize(contentWidth-4, contentHeight-2)
@@ -493,6 +1141,9 @@ func (m *model) resizeSubModels() {
 
 	m.resizeComposer()
 	m.resizeSearch()
+	m.resizeHelp()
+	m.resizePicker()
+	m.resizeAccountPicker()
 }
 
 func (m *model) resizeComposer() {
@@ -507,6 +1158,24 @@ func (m *model) resizeSearch() {
 	m.search.SetSize(contentWidth, contentHeight)
 }
 
+func (m *model) resizeHelp() {
+	_, contentWidth := m.layoutWidths()
+	contentHeight := m.height - 3
+	m.help.SetSize(contentWidth, contentHeight)
+}
+
+func (m *model) resizePicker() {
+	_, contentWidth := m.layoutWidths()
+	contentHeight := m.height - 3
+	m.picker.SetSize(contentWidth, contentHeight)
+}
+
+func (m *model) resizeAccountPicker() {
+	_, contentWidth := m.layoutWidths()
+	contentHeight := m.height - 3
+	m.accountPicker.SetSize(contentWidth, contentHeight)
+}
+
 // --- async commands ---
 
 func (m model) loadLabelsCmd() tea.Cmd {
@@ -519,10 +1188,100 @@ func (m model) loadLabelsCmd() tea.Cmd {
 	}
 }
 
+// startupLabelCmd resolves the configured startup label, falling back to
+// INBOX with a warning when the account has no such label.
+func (m model) startupLabelCmd() tea.Cmd {
+	want := m.cfg.UI.StartupLabel
+	if want == "" || want == domain.LabelInbox {
+		return m.loadMailCmd(domain.LabelInbox)
+	}
+	return func() tea.Msg {
+		labels, err := m.store.ListLabels(context.Background(), m.accountID)
+		if err != nil {
+			return errMsg{err: fmt.Errorf("failed to load labels: %w", err)}
+		}
+		if id, ok := resolveLabel(labels, want); ok {
+			return startupLabelMsg{labelID: id, labels: labels}
+		}
+		return startupLabelMsg{
+			labelID: domain.LabelInbox,
+			labels:  labels,
+			warning: fmt.Sprintf("startup_label %q not found; showing Inbox", want),
+		}
+	}
+}
+
+// resolveLabel finds a label by ID, or by name ignoring case. System labels
+// also match by their sidebar names, e.g. "Drafts".
+func resolveLabel(labels []domain.Label, want string) (string, bool) {
+	for _, l := range labels {
+		if l.ID == want {
+			return l.ID, true
+		}
+	}
+	for _, id := range systemLabelOrder {
+		if id == want || strings.EqualFold(systemLabelNames[id], want) {
+			return id, true
+		}
+	}
+	for _, l := range labels {
+		if strings.EqualFold(l.Name, want) {
+			return l.ID, true
+		}
+	}
+	return "", false
+}
+
+// findLabel resolves want against the sidebar's labels like resolveLabel.
+func (m model) findLabel(want string) (domain.Label, bool) {
+	id, ok := resolveLabel(m.sidebar.labels, want)
+	if !ok {
+		return domain.Label{}, false
+	}
+	for _, l := range m.sidebar.labels {
+		if l.ID == id {
+			return l, true
+		}
+	}
+	return domain.Label{ID: id, Name: id, Type: domain.LabelTypeSystem}, true
+}
+
+// loadUnreadCountsCmd counts the unread INBOX mail of every account.
+func (m model) loadUnreadCountsCmd() tea.Cmd {
+	accounts := m.accounts
+	return func() tea.Msg {
+		counts := make(map[string]int, len(accounts))
+		for _, a := range accounts {
+			n, err := m.store.UnreadCount(context.Background(), a.ID)
+			if err != nil {
+				return errMsg{err: err}
+			}
+			counts[a.ID] = n
+		}
+		return unreadCountsMsg{counts: counts}
+	}
+}
+
 func (m model) loadMailCmd(labelID string) tea.Cmd {
+	if addr := m.senderFilter; addr != "" {
+		limit := m.cfg.UI.ListLimit
+		return func() tea.Msg {
+			emails, err := m.store.ListBySender(context.Background(), m.accountID, addr, limit)
+			if err != nil {
+				return errMsg{err: fmt.Errorf("failed to load mail from %s: %w", addr, err)}
+			}
+			return emailsLoadedMsg{emails: emails}
+		}
+	}
+
 	opts := store.ListEmailOptions{
 		AccountID: m.accountID,
 		LabelID:   labelID,
+		Limit:     m.cfg.ListLimit(labelID, m.sidebar.labelName(labelID)),
+		Sort:      m.cfg.UI.Sort,
+	}
+	if m.cfg.UI.IncludeChildLabels {
+		opts.IncludeLabelIDs = m.sidebar.descendantLabelIDs(labelID)
 	}
 
 	if m.viewMode == viewThread {
@@ -544,36 +1303,144 @@ func (m model) loadMailCmd(labelID string) tea.Cmd {
 	}
 }
 
-func (m model) loadEmailCmd(emailID string) tea.Cmd {
+// loadEmailCmd loads an email for the reader and records it as recently
+// viewed. A non-empty query scrolls the reader to its first match.
+func (m model) loadEmailCmd(emailID, query string) tea.Cmd {
 	return func() tea.Msg {
-		email, err := m.store.GetEmail(context.Background(), emailID)
+		ctx := context.Background()
+		email, err := m.store.GetEmail(ctx, emailID, m.accountID)
 		if err != nil {
 			return errMsg{err: fmt.Errorf("failed to load email: %w", err)}
 		}
-		return emailLoadedMsg{email: email}
+		// The history is a convenience; failing to record it is not worth
+		// interrupting the reader for.
+		_ = m.store.RecordView(ctx, m.accountID, email.ThreadID, email.ID, time.Now())
+		return emailLoadedMsg{email: email, query: query}
 	}
 }
 
+// loadThreadCmd loads a thread for the reader and records it as recently
+// viewed under its latest message.
 func (m model) loadThreadCmd(threadID string) tea.Cmd {
 	return func() tea.Msg {
-		thread, err := m.store.GetThread(context.Background(), threadID, m.accountID)
+		ctx := context.Background()
+		thread, err := m.store.GetThread(ctx, threadID, m.accountID)
 		if err != nil {
 			return errMsg{err: fmt.Errorf("failed to load thread: %w", err)}
 		}
+		if n := len(thread.Messages); n > 0 {
+			_ = m.store.RecordView(ctx, m.accountID, thread.ID, thread.Messages[n-1].ID, time.Now())
+		}
 		return threadLoadedMsg{thread: thread}
 	}
 }
 
+// reloadReaderCmd reloads the reader's open email or thread from the store,
+// e.g. to show a star that was just changed.
+func (m model) reloadReaderCmd() tea.Cmd {
+	email, thread := m.reader.email, m.reader.thread
+	if email == nil && thread == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		ctx := context.Background()
+		if thread != nil {
+			t, err := m.store.GetThread(ctx, thread.ID, m.accountID)
+			if err != nil {
+				return errMsg{err: fmt.Errorf("failed to load thread: %w", err)}
+			}
+			return readerReloadedMsg{thread: t}
+		}
+		e, err := m.store.GetEmail(ctx, email.ID, m.accountID)
+		if err != nil {
+			return errMsg{err: fmt.Errorf("failed to load email: %w", err)}
+		}
+		return readerReloadedMsg{email: e}
+	}
+}
+
+// fetchFullBodyCmd fetches an email the sync truncated from the provider,
+// stores it whole and reloads the reader.
+func (m model) fetchFullBodyCmd(emailID string) tea.Cmd {
+	reload := m.reloadReaderCmd()
+	return func() tea.Msg {
+		ctx := context.Background()
+		email, err := m.provider.GetMessage(ctx, emailID)
+		if err != nil {
+			return errMsg{err: fmt.Errorf("failed to fetch message: %w", err)}
+		}
+		if err := m.store.UpsertEmail(ctx, email, m.accountID); err != nil {
+			return errMsg{err: fmt.Errorf("failed to store message: %w", err)}
+		}
+		if reload == nil {
+			return nil
+		}
+		return reload()
+	}
+}
+
+// syncThreadCmd syncs a thread from the provider and reloads what the
+// reader has open: the thread, or the single message msg.emailID.
+func (m model) syncThreadCmd(msg refreshThreadMsg) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		svc := app.NewSyncService(m.store, m.provider, m.accountID)
+		svc.SetMaxBodyBytes(m.cfg.Sync.MaxBodyBytes)
+		svc.SetThreadByReferences(m.cfg.Sync.ThreadByReferences)
+		fetched, removed, err := svc.SyncThread(ctx, msg.threadID)
+		if err != nil {
+			return errMsg{err: fmt.Errorf("failed to refresh thread: %w", err)}
+		}
+
+		synced := threadSyncedMsg{fetched: fetched, removed: removed}
+		if msg.emailID != "" {
+			email, err := m.store.GetEmail(ctx, msg.emailID, m.accountID)
+			if errors.Is(err, store.ErrNotFound) {
+				return synced
+			}
+			if err != nil {
+				return errMsg{err: fmt.Errorf("failed to load email: %w", err)}
+			}
+			synced.email = email
+			return synced
+		}
+		thread, err := m.store.GetThread(ctx, msg.threadID, m.accountID)
+		if errors.Is(err, store.ErrNotFound) {
+			return synced
+		}
+		if err != nil {
+			return errMsg{err: fmt.Errorf("failed to load thread: %w", err)}
+		}
+		synced.thread = thread
+		return synced
+	}
+}
+
+// loadThreadIndexCmd loads a thread's messages for listing inline in the
+// inbox.
+func (m model) loadThreadIndexCmd(threadID string) tea.Cmd {
+	return func() tea.Msg {
+		thread, err := m.store.GetThread(context.Background(), threadID, m.accountID)
+		if err != nil {
+			return errMsg{err: fmt.Errorf("failed to load thread: %w", err)}
+		}
+		return threadIndexLoadedMsg{thread: thread}
+	}
+}
+
 func (m model) markReadCmd(emailID string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
 
 		// Update local DB first for immediate UI feedback.
-		if err := m.store.SetEmailRead(ctx, emailID, true); err != nil {
+		if err := m.store.SetEmailRead(ctx, emailID, m.accountID, true); err != nil {
 			return errMsg{err: fmt.Errorf("failed to mark as read locally: %w", err)}
 		}
 
 		// Sync to remote provider.
+		if m.authRequired {
+			return nil
+		}
 		if err := m.provider.MarkRead(ctx, emailID, true); err != nil {
 			return errMsg{err: fmt.Errorf("failed to mark as read remotely: %w", err)}
 		}
@@ -591,6 +1458,10 @@ func (m model) markThreadReadCmd(threadID string) tea.Cmd {
 			return errMsg{err: fmt.Errorf("failed to mark thread as read locally: %w", err)}
 		}
 
+		if m.authRequired {
+			return nil
+		}
+
 		// Get message IDs in the thread to sync to remote.
 		thread, err := m.store.GetThread(ctx, threadID, m.accountID)
 		if err != nil {
@@ -609,9 +1480,15 @@ func (m model) markThreadReadCmd(threadID string) tea.Cmd {
 	}
 }
 
+// sendEmailCmd sends email right away, keeping it in the outbox for a
+// later retry if the send fails.
 func (m model) sendEmailCmd(email *domain.Email) tea.Cmd {
+	accountID := m.accountID
 	return func() tea.Msg {
-		err := m.provider.SendMessage(context.Background(), email)
+		err := app.NewOutboxService(m.store, m.provider, accountID).SendNow(context.Background(), email)
+		if errors.Is(err, app.ErrQueued) {
+			return emailSavedMsg{err: err}
+		}
 		if err != nil {
 			return errMsg{err: fmt.Errorf("failed to send email: %w", err)}
 		}
@@ -619,9 +1496,101 @@ func (m model) sendEmailCmd(email *domain.Email) tea.Cmd {
 	}
 }
 
+// forwardAttachmentsCmd adds the attachments of the forwarded message to
+// the forward in msg, then hands it back to be sent.
+func (m model) forwardAttachmentsCmd(msg sendMsg) tea.Cmd {
+	p := m.provider
+	return func() tea.Msg {
+		attachments, err := app.ForwardAttachments(context.Background(), p, msg.forwardOf)
+		if err != nil {
+			return errMsg{err: fmt.Errorf("failed to get attachments to forward: %w", err)}
+		}
+		msg.email.Attachments = attachments
+		return sendMsg{email: msg.email}
+	}
+}
+
+// queueSendCmd holds email in the outbox for the send delay.
+func (m model) queueSendCmd(email *domain.Email) tea.Cmd {
+	accountID := m.accountID
+	return func() tea.Msg {
+		outbox := app.NewOutboxService(m.store, m.provider, accountID)
+		id, err := outbox.Queue(context.Background(), email, m.sendDelay)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return emailQueuedMsg{id: id, accountID: accountID, email: email}
+	}
+}
+
+// sendDueCmd sends an account's queued mail whose send delay has elapsed,
+// including any left over from a previous session.
+func (m model) sendDueCmd(accountID string) tea.Cmd {
+	p := m.provider
+	if accountID != m.accountID && m.providerFactory != nil {
+		p = m.providerFactory(accountID)
+	}
+	if p == nil || m.authRequired {
+		return nil
+	}
+	return func() tea.Msg {
+		n, err := app.NewOutboxService(m.store, p, accountID).SendDue(context.Background(), time.Now())
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return outboxSentMsg{count: n}
+	}
+}
+
+// outboxRetryCmd schedules the next retry of failed sends, if enabled.
+func (m model) outboxRetryCmd() tea.Cmd {
+	if m.outboxRetry <= 0 {
+		return nil
+	}
+	return tea.Tick(m.outboxRetry, func(time.Time) tea.Msg { return outboxRetryMsg{} })
+}
+
+// retryOutboxCmd sends the current account's due outbox mail. Failures are
+// not reported: the mail stays queued and the next tick tries again.
+func (m model) retryOutboxCmd() tea.Cmd {
+	if m.provider == nil || m.authRequired {
+		return nil
+	}
+	accountID := m.accountID
+	return func() tea.Msg {
+		n, _ := app.NewOutboxService(m.store, m.provider, accountID).SendDue(context.Background(), time.Now())
+		return outboxSentMsg{count: n}
+	}
+}
+
+func (m model) unsubscribeCmd(unsub domain.Unsubscribe) tea.Cmd {
+	return func() tea.Msg {
+		request, err := unsub.MailtoEmail()
+		if err != nil {
+			return errMsg{err: err}
+		}
+		request.Date = time.Now()
+		if err := m.provider.SendMessage(context.Background(), request); err != nil {
+			return errMsg{err: fmt.Errorf("failed to send unsubscribe request: %w", err)}
+		}
+		return actionDoneMsg{action: "unsubscribe"}
+	}
+}
+
+func (m model) contactsCmd(prefix string) tea.Cmd {
+	return func() tea.Msg {
+		contacts, err := m.store.FrequentContacts(context.Background(), m.accountID, prefix, maxSuggestions)
+		if err != nil {
+			// Suggestions are best-effort; don't interrupt composing.
+			return nil
+		}
+		return contactSuggestionsMsg{prefix: prefix, contacts: contacts}
+	}
+}
+
 func (m model) searchCmd(query string) tea.Cmd {
 	return func() tea.Msg {
-		results, err := m.store.SearchEmails(context.Background(), query, m.accountID)
+		results, err := m.store.SearchEmails(context.Background(), query, m.accountID, store.SearchOptions{})
 		if err != nil {
 			return errMsg{err: fmt.Errorf("failed to search: %w", err)}
 		}
@@ -629,36 +1598,195 @@ func (m model) searchCmd(query string) tea.Cmd {
 	}
 }
 
-func (m model) performActionCmd(emailID, action string) tea.Cmd {
+// performActionCmd applies action to each email in turn. Undoable actions
+// on several emails are undone together as one entry.
+func (m model) performActionCmd(emailIDs []string, action string) tea.Cmd {
 	return func() tea.Msg {
 		ctx := context.Background()
-		var err error
-
-		switch action {
-		case "archive":
-			err = m.provider.ModifyLabels(ctx, emailID, nil, []string{domain.LabelInbox})
-		case "delete":
-			err = m.provider.TrashMessage(ctx, emailID)
-		case "star":
-			err = m.provider.ModifyLabels(ctx, emailID, []string{domain.LabelStarred}, nil)
-		case "unread":
-			if localErr := m.store.SetEmailRead(ctx, emailID, false); localErr != nil {
-				return errMsg{err: fmt.Errorf("failed to mark unread locally: %w", localErr)}
+		expires := time.Now().Add(undoWindow)
+
+		var undos []undoEntry
+		for _, emailID := range emailIDs {
+			// Remember the labels before a destructive action so it can be undone.
+			if undoable(action) {
+				if email, getErr := m.store.GetEmail(ctx, emailID, m.accountID); getErr == nil {
+					undos = append(undos, undoEntry{
+						emailID:    emailID,
+						action:     action,
+						prevLabels: email.Labels,
+						expires:    expires,
+					})
+				}
 			}
-			err = m.provider.MarkRead(ctx, emailID, false)
+
+			if err := app.ApplyAction(ctx, m.provider, m.store, m.accountID, emailID, action); err != nil {
+				return errMsg{err: fmt.Errorf("failed to %s: %w", action, err)}
+			}
+		}
+
+		done := actionDoneMsg{action: action, count: len(emailIDs)}
+		switch len(undos) {
+		case 0:
+		case 1:
+			done.undo = &undos[0]
 		default:
-			return errMsg{err: fmt.Errorf("unknown action: %s", action)}
+			done.undo = &undoEntry{action: action, expires: expires, batch: undos}
+		}
+		return done
+	}
+}
+
+// cycleFlagCmd advances the local flag of an email or thread to the next one
+// in domain.Flags. For a thread the new flag goes on its latest message and
+// existing flags are cleared from every message.
+func (m model) cycleFlagCmd(msg flagMsg) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+
+		var emails []domain.Email
+		if msg.threadID != "" {
+			thread, err := m.store.GetThread(ctx, msg.threadID, m.accountID)
+			if err != nil {
+				return errMsg{err: fmt.Errorf("failed to load thread: %w", err)}
+			}
+			emails = thread.Messages
+		} else {
+			email, err := m.store.GetEmail(ctx, msg.emailID, m.accountID)
+			if err != nil {
+				return errMsg{err: fmt.Errorf("failed to load email: %w", err)}
+			}
+			emails = []domain.Email{*email}
+		}
+
+		var current []string
+		for _, e := range emails {
+			current = append(current, e.Flags...)
+		}
+		next := domain.NextFlag(current)
+
+		for _, e := range emails {
+			for _, f := range e.Flags {
+				if err := m.store.SetEmailFlag(ctx, e.ID, f, false); err != nil {
+					return errMsg{err: fmt.Errorf("failed to clear flag: %w", err)}
+				}
+			}
+		}
+		if next != "" {
+			latest := emails[len(emails)-1].ID
+			if err := m.store.SetEmailFlag(ctx, latest, next, true); err != nil {
+				return errMsg{err: fmt.Errorf("failed to set flag: %w", err)}
+			}
 		}
+		return flagDoneMsg{flag: next}
+	}
+}
 
+// starThreadCmd stars or unstars a thread using the semantics of
+// domain.Thread.StarTargets, then mirrors the change in the local store.
+func (m model) starThreadCmd(threadID string, star bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+
+		thread, err := m.store.GetThread(ctx, threadID, m.accountID)
 		if err != nil {
-			return errMsg{err: fmt.Errorf("failed to %s: %w", action, err)}
+			return errMsg{err: fmt.Errorf("failed to load thread: %w", err)}
 		}
-		return actionDoneMsg{action: action}
+
+		ids := thread.StarTargets(star)
+		if len(ids) > 0 {
+			var add, remove []string
+			if star {
+				add = []string{domain.LabelStarred}
+			} else {
+				remove = []string{domain.LabelStarred}
+			}
+			if err := m.provider.BatchModifyLabels(ctx, ids, add, remove); err != nil {
+				return errMsg{err: fmt.Errorf("failed to update thread star: %w", err)}
+			}
+			for _, id := range ids {
+				if err := m.store.SetEmailStarred(ctx, id, m.accountID, star); err != nil {
+					return errMsg{err: fmt.Errorf("failed to update star locally: %w", err)}
+				}
+			}
+		}
+
+		if star {
+			return actionDoneMsg{action: "star"}
+		}
+		return actionDoneMsg{action: "unstar"}
 	}
 }
 
+// undoCmd reverses an archive or delete on the remote and restores the
+// message's previous labels locally.
+func (m model) undoCmd(entry undoEntry) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+
+		if entry.action == "send" {
+			err := app.NewOutboxService(m.store, m.provider, m.accountID).Cancel(ctx, entry.outboxID)
+			if errors.Is(err, app.ErrAlreadySent) {
+				return errMsg{err: fmt.Errorf("too late to undo: message already sent")}
+			}
+			if err != nil {
+				return errMsg{err: err}
+			}
+			return undoDoneMsg{entry: entry}
+		}
+
+		entries := entry.batch
+		if len(entries) == 0 {
+			entries = []undoEntry{entry}
+		}
+		for _, e := range entries {
+			add, remove := undoLabelChanges(e)
+			if err := m.provider.ModifyLabels(ctx, e.emailID, add, remove); err != nil {
+				return errMsg{err: fmt.Errorf("failed to undo %s: %w", e.action, err)}
+			}
+			if err := m.store.SetEmailLabels(ctx, e.emailID, m.accountID, e.prevLabels); err != nil {
+				return errMsg{err: fmt.Errorf("failed to restore labels locally: %w", err)}
+			}
+		}
+		return undoDoneMsg{entry: entry}
+	}
+}
+
+// isAuthError reports whether err means the provider has no usable token.
+func isAuthError(err error) bool {
+	return errors.Is(err, store.ErrKeyringUnavailable) || errors.Is(err, store.ErrTokenNotFound)
+}
+
+// authRequiredMessage returns a short, actionable status line for an auth error.
+func authRequiredMessage(err error) string {
+	if errors.Is(err, store.ErrKeyringUnavailable) {
+		return `Auth required: keyring unavailable. Unlock it or set token_store = "file" under [auth], then run 'termail account add'`
+	}
+	return "Auth required: no token for this account. Run 'termail account add'"
+}
+
+// mailboxSize returns the message count cached for the account at its last
+// sync, or zero if unknown.
+func mailboxSize(accounts []domain.Account, accountID string) int64 {
+	for _, a := range accounts {
+		if a.ID == accountID {
+			return a.MessagesTotal
+		}
+	}
+	return 0
+}
+
+// clearSenderFilter returns the list to the active label in the chosen
+// view.
+func (m *model) clearSenderFilter() {
+	if m.senderFilter == "" {
+		return
+	}
+	m.senderFilter = ""
+	m.inbox.SetViewMode(m.viewMode)
+}
+
+// switchAccountCmd cycles to the next account.
 func (m model) switchAccountCmd() tea.Cmd {
-	// Cycle to the next account.
 	current := m.accountID
 	var nextID string
 	for i, acc := range m.accounts {
@@ -676,12 +1804,32 @@ func (m model) switchAccountCmd() tea.Cmd {
 	}
 }
 
+// parseViewMode returns the list view named by ui.default_view: "flat"
+// lists single messages, anything else groups them into threads.
+func parseViewMode(name string) viewMode {
+	if strings.EqualFold(strings.TrimSpace(name), "flat") {
+		return viewFlat
+	}
+	return viewThread
+}
+
 // Run starts the Bubble Tea TUI application.
-func Run(s store.Store, p provider.EmailProvider, accountID string, accounts []domain.Account, factory ProviderFactory) error {
-	prog := tea.NewProgram(
-		NewModel(s, p, accountID, accounts, factory),
-		tea.WithAltScreen(),
-	)
+func Run(cfg *config.Config, s store.Store, p provider.EmailProvider, accountID string, accounts []domain.Account, factory ProviderFactory) error {
+	return run(NewModel(cfg, s, p, accountID, accounts, factory))
+}
+
+// RunCompose starts the TUI with the composer open and pre-filled from draft.
+func RunCompose(cfg *config.Config, s store.Store, p provider.EmailProvider, accountID string, accounts []domain.Account, factory ProviderFactory, draft *domain.Email) error {
+	m := NewModel(cfg, s, p, accountID, accounts, factory)
+	m.composer.ComposeDraft(draft)
+	return run(m)
+}
+
+func run(m model) error {
+	if _, err := LookupTheme(m.cfg.UI.Theme); err != nil {
+		return fmt.Errorf("invalid ui.theme: %w", err)
+	}
+	prog := tea.NewProgram(m, tea.WithAltScreen())
 	_, err := prog.Run()
 	return err
 }
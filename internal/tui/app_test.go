@@ -0,0 +1,463 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lu-zhengda/termail/internal/config"
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/provider"
+	"github.com/lu-zhengda/termail/internal/store"
+	"github.com/lu-zhengda/termail/internal/store/sqlite"
+)
+
+func TestAccountSwitch_RestoresPosition(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+	accounts := []domain.Account{{ID: "a@example.com"}, {ID: "b@example.com"}}
+	m := NewModel(cfg, nil, nil, "a@example.com", accounts, nil)
+
+	m.sidebar.activeLabel = domain.LabelSent
+	m.sidebar.cursor = 2
+	m.inbox.cursor = 7
+	m.inbox.offset = 3
+
+	updated, _ := m.Update(accountSwitchedMsg{accountID: "b@example.com"})
+	m = updated.(model)
+	if m.sidebar.activeLabel != domain.LabelInbox || m.inbox.cursor != 0 || m.inbox.offset != 0 {
+		t.Errorf("new account position = %q/%d/%d, want INBOX/0/0",
+			m.sidebar.activeLabel, m.inbox.cursor, m.inbox.offset)
+	}
+
+	m.inbox.cursor = 1
+
+	updated, _ = m.Update(accountSwitchedMsg{accountID: "a@example.com"})
+	m = updated.(model)
+	if m.sidebar.activeLabel != domain.LabelSent {
+		t.Errorf("activeLabel = %q, want %q", m.sidebar.activeLabel, domain.LabelSent)
+	}
+	if m.sidebar.cursor != 2 || m.inbox.cursor != 7 || m.inbox.offset != 3 {
+		t.Errorf("restored sidebar/list cursor/offset = %d/%d/%d, want 2/7/3",
+			m.sidebar.cursor, m.inbox.cursor, m.inbox.offset)
+	}
+
+	updated, _ = m.Update(accountSwitchedMsg{accountID: "b@example.com"})
+	m = updated.(model)
+	if m.inbox.cursor != 1 {
+		t.Errorf("second account cursor = %d, want 1", m.inbox.cursor)
+	}
+}
+
+// labelProvider applies label changes to an in-memory label set.
+type labelProvider struct {
+	provider.EmailProvider
+	labels map[string][]string
+}
+
+func (p *labelProvider) ModifyLabels(_ context.Context, id string, add, remove []string) error {
+	var out []string
+	for _, l := range p.labels[id] {
+		if !slices.Contains(remove, l) {
+			out = append(out, l)
+		}
+	}
+	p.labels[id] = append(out, add...)
+	return nil
+}
+
+func TestPerformAction_SelectedEmailsUndoTogether(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New() error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.CreateAccount(ctx, &domain.Account{ID: "a@example.com", Email: "a@example.com", Provider: "gmail"}); err != nil {
+		t.Fatalf("CreateAccount() error: %v", err)
+	}
+	p := &labelProvider{labels: map[string][]string{}}
+	for _, id := range []string{"e1", "e2"} {
+		if err := db.UpsertEmail(ctx, &domain.Email{ID: id, ThreadID: id, Labels: []string{domain.LabelInbox}}, "a@example.com"); err != nil {
+			t.Fatalf("UpsertEmail() error: %v", err)
+		}
+		p.labels[id] = []string{domain.LabelInbox}
+	}
+
+	m := NewModel(cfg, db, p, "a@example.com", []domain.Account{{ID: "a@example.com"}}, nil)
+	m.inbox.selected = map[string]bool{"e1": true, "e2": true}
+
+	done, ok := m.performActionCmd([]string{"e1", "e2"}, "archive")().(actionDoneMsg)
+	if !ok || done.count != 2 || done.undo == nil || len(done.undo.batch) != 2 {
+		t.Fatalf("performActionCmd() = %+v, want one grouped undo for 2 emails", done)
+	}
+	for _, id := range []string{"e1", "e2"} {
+		if slices.Contains(p.labels[id], domain.LabelInbox) {
+			t.Errorf("%s still in INBOX after archive", id)
+		}
+	}
+
+	updated, _ := m.Update(done)
+	m = updated.(model)
+	if len(m.inbox.selected) != 0 {
+		t.Error("selection should be cleared once the action completes")
+	}
+
+	if _, ok := m.undoCmd(*done.undo)().(undoDoneMsg); !ok {
+		t.Fatal("undoCmd() should succeed")
+	}
+	for _, id := range []string{"e1", "e2"} {
+		if !slices.Contains(p.labels[id], domain.LabelInbox) {
+			t.Errorf("%s not restored to INBOX by undo", id)
+		}
+	}
+}
+
+func TestDeleteKey_ConfiguredAction(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New() error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.CreateAccount(ctx, &domain.Account{ID: "a@example.com", Email: "a@example.com", Provider: "gmail"}); err != nil {
+		t.Fatalf("CreateAccount() error: %v", err)
+	}
+	old := domain.Label{ID: "Label_old", AccountID: "a@example.com", Name: "Old Mail", Type: domain.LabelTypeUser}
+	if err := db.UpsertLabel(ctx, &old); err != nil {
+		t.Fatalf("UpsertLabel() error: %v", err)
+	}
+	p := &labelProvider{labels: map[string][]string{}}
+	for _, id := range []string{"e1", "e2"} {
+		if err := db.UpsertEmail(ctx, &domain.Email{ID: id, ThreadID: id, Labels: []string{domain.LabelInbox}}, "a@example.com"); err != nil {
+			t.Fatalf("UpsertEmail() error: %v", err)
+		}
+		p.labels[id] = []string{domain.LabelInbox}
+	}
+
+	cfg.UI.DeleteAction = "archive"
+	m := NewModel(cfg, db, p, "a@example.com", []domain.Account{{ID: "a@example.com"}}, nil)
+	_, cmd := m.Update(emailActionMsg{emailIDs: []string{"e1"}, action: "delete"})
+	done, ok := cmd().(actionDoneMsg)
+	if !ok || done.action != "archive" {
+		t.Fatalf("delete with delete_action=archive = %+v, want an archive", done)
+	}
+	if len(p.labels["e1"]) != 0 {
+		t.Errorf("e1 labels = %v, want none after archive", p.labels["e1"])
+	}
+
+	cfg.UI.DeleteAction = "label:old mail"
+	m = NewModel(cfg, db, p, "a@example.com", []domain.Account{{ID: "a@example.com"}}, nil)
+	m.sidebar.SetLabels([]domain.Label{old})
+	_, cmd = m.Update(emailActionMsg{emailIDs: []string{"e2"}, action: "delete"})
+	moved, ok := cmd().(movedToLabelMsg)
+	if !ok || moved.label.ID != "Label_old" {
+		t.Fatalf("delete with delete_action=label:old mail = %+v, want a move to Label_old", moved)
+	}
+	if !slices.Equal(p.labels["e2"], []string{"Label_old"}) {
+		t.Errorf("e2 labels = %v, want [Label_old]", p.labels["e2"])
+	}
+
+	// The trash key ignores the mapping.
+	updated, _ := m.Update(emailActionMsg{emailIDs: []string{"e2"}, action: "trash"})
+	if got := updated.(model).statusBar.message; got != "Performing trash..." {
+		t.Errorf("trash key status = %q, want %q", got, "Performing trash...")
+	}
+}
+
+func TestStartupLabel_DrivesInitialLoad(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New() error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.CreateAccount(ctx, &domain.Account{ID: "a@example.com", Email: "a@example.com", Provider: "gmail"}); err != nil {
+		t.Fatalf("CreateAccount() error: %v", err)
+	}
+	work := &domain.Label{ID: "Label_work", AccountID: "a@example.com", Name: "Work", Type: domain.LabelTypeUser}
+	if err := db.UpsertLabel(ctx, work); err != nil {
+		t.Fatalf("UpsertLabel() error: %v", err)
+	}
+	emails := []domain.Email{
+		{ID: "e1", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+		{ID: "e2", ThreadID: "t2", Labels: []string{"Label_work"}},
+	}
+	if err := db.UpsertEmails(ctx, emails, "a@example.com"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+
+	cfg.UI.StartupLabel = "work"
+	m := NewModel(cfg, db, nil, "a@example.com", []domain.Account{{ID: "a@example.com"}}, nil)
+	msg, ok := m.startupLabelCmd()().(startupLabelMsg)
+	if !ok || msg.labelID != "Label_work" || msg.warning != "" {
+		t.Fatalf("startupLabelCmd() = %+v, want Label_work without warning", msg)
+	}
+	updated, cmd := m.Update(msg)
+	m = updated.(model)
+	if m.sidebar.activeLabel != "Label_work" {
+		t.Errorf("activeLabel = %q, want Label_work", m.sidebar.activeLabel)
+	}
+	loaded, ok := cmd().(threadsLoadedMsg)
+	if !ok || len(loaded.threads) != 1 || loaded.threads[0].ID != "t2" {
+		t.Errorf("initial load = %+v, want only thread t2", loaded)
+	}
+
+	cfg.UI.StartupLabel = "Missing"
+	m = NewModel(cfg, db, nil, "a@example.com", []domain.Account{{ID: "a@example.com"}}, nil)
+	msg = m.startupLabelCmd()().(startupLabelMsg)
+	if msg.labelID != domain.LabelInbox || msg.warning == "" {
+		t.Errorf("unknown startup label = %+v, want INBOX with a warning", msg)
+	}
+}
+
+// flakyProvider fails every send while offline is set.
+type flakyProvider struct {
+	provider.EmailProvider
+	offline bool
+	sent    int
+}
+
+func (p *flakyProvider) SendMessage(context.Context, *domain.Email) error {
+	if p.offline {
+		return errors.New("network unreachable")
+	}
+	p.sent++
+	return nil
+}
+
+func TestDefaultView_FlatConfigLoadsEmails(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New() error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.CreateAccount(ctx, &domain.Account{ID: "a@example.com", Email: "a@example.com", Provider: "gmail"}); err != nil {
+		t.Fatalf("CreateAccount() error: %v", err)
+	}
+	emails := []domain.Email{
+		{ID: "e1", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+		{ID: "e2", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+	}
+	if err := db.UpsertEmails(ctx, emails, "a@example.com"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+
+	m := NewModel(cfg, db, nil, "a@example.com", []domain.Account{{ID: "a@example.com"}}, nil)
+	if m.viewMode != viewThread || m.inbox.viewMode != viewThread {
+		t.Errorf("default view = %v/%v, want thread view", m.viewMode, m.inbox.viewMode)
+	}
+
+	cfg.UI.DefaultView = "flat"
+	m = NewModel(cfg, db, nil, "a@example.com", []domain.Account{{ID: "a@example.com"}}, nil)
+	if m.viewMode != viewFlat || m.inbox.viewMode != viewFlat {
+		t.Errorf("flat default_view = %v/%v, want flat view", m.viewMode, m.inbox.viewMode)
+	}
+	loaded, ok := m.startupLabelCmd()().(emailsLoadedMsg)
+	if !ok || len(loaded.emails) != 2 {
+		t.Errorf("initial load = %+v, want both emails listed flat", loaded)
+	}
+}
+
+func TestSendEmail_FailedSendKeptForRetry(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New() error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.CreateAccount(ctx, &domain.Account{ID: "a@example.com", Email: "a@example.com", Provider: "gmail"}); err != nil {
+		t.Fatalf("CreateAccount() error: %v", err)
+	}
+	p := &flakyProvider{offline: true}
+	m := NewModel(cfg, db, p, "a@example.com", []domain.Account{{ID: "a@example.com"}}, nil)
+	m.composer.Compose()
+
+	msg := m.sendEmailCmd(&domain.Email{Subject: "offline"})()
+	if _, ok := msg.(emailSavedMsg); !ok {
+		t.Fatalf("sendEmailCmd() = %#v, want emailSavedMsg", msg)
+	}
+	updated, _ := m.Update(msg)
+	if m = updated.(model); m.composer.IsVisible() {
+		t.Error("the composer should close once the message is saved")
+	}
+	if items, _ := db.ListOutbox(ctx, "a@example.com"); len(items) != 1 {
+		t.Fatalf("outbox has %d messages, want 1", len(items))
+	}
+
+	p.offline = false
+	if msg := m.retryOutboxCmd()(); msg != (outboxSentMsg{count: 1}) {
+		t.Errorf("retryOutboxCmd() = %#v, want 1 sent", msg)
+	}
+	if items, _ := db.ListOutbox(ctx, "a@example.com"); len(items) != 0 || p.sent != 1 {
+		t.Errorf("after retry: outbox %d, sent %d; want 0, 1", len(items), p.sent)
+	}
+}
+
+// syncProvider serves a fixed mailbox to a full sync one message per page.
+type syncProvider struct {
+	provider.EmailProvider
+	remote []domain.Email
+}
+
+func (p *syncProvider) ListLabels(context.Context) ([]domain.Label, error) {
+	return nil, nil
+}
+
+func (p *syncProvider) ListMessages(_ context.Context, opts provider.ListOptions) ([]domain.Email, string, error) {
+	i := 0
+	if opts.PageToken != "" {
+		i, _ = strconv.Atoi(opts.PageToken)
+	}
+	if i+1 < len(p.remote) {
+		return p.remote[i : i+1], strconv.Itoa(i + 1), nil
+	}
+	return p.remote[i:], "", nil
+}
+
+func (p *syncProvider) GetProfile(context.Context) (*domain.Profile, error) {
+	return &domain.Profile{MessagesTotal: int64(len(p.remote))}, nil
+}
+
+func TestInitialSync_ReportsProgress(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+	cfg.Sync.InitialCount = 4
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New() error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.CreateAccount(ctx, &domain.Account{ID: "a@example.com", Email: "a@example.com", Provider: "gmail"}); err != nil {
+		t.Fatalf("CreateAccount() error: %v", err)
+	}
+	p := &syncProvider{remote: []domain.Email{
+		{ID: "e1", ThreadID: "t1", Labels: []string{domain.LabelInbox}},
+		{ID: "e2", ThreadID: "t2", Labels: []string{domain.LabelInbox}},
+	}}
+	m := NewModel(cfg, db, p, "a@example.com", []domain.Account{{ID: "a@example.com"}}, nil)
+
+	var fetched []int
+	msg := m.initialSyncCmd()()
+	for {
+		progress, ok := msg.(syncProgressMsg)
+		if !ok {
+			break
+		}
+		updated, cmd := m.Update(progress)
+		m = updated.(model)
+		if m.statusBar.syncTotal != 4 {
+			t.Errorf("status bar total = %d, want 4", m.statusBar.syncTotal)
+		}
+		fetched = append(fetched, progress.fetched)
+		msg = cmd()
+	}
+	if !slices.Equal(fetched, []int{1, 2}) {
+		t.Errorf("progress = %v, want [1 2]", fetched)
+	}
+	done, ok := msg.(initialSyncDoneMsg)
+	if !ok || done.err != nil || done.fetched != 2 {
+		t.Fatalf("final message = %#v, want 2 fetched", msg)
+	}
+	updated, _ := m.Update(done)
+	if m = updated.(model); m.statusBar.syncTotal != 0 || m.statusBar.message != "Synced 2 messages" {
+		t.Errorf("status bar = %d/%q, want no progress and the synced count",
+			m.statusBar.syncTotal, m.statusBar.message)
+	}
+
+	if msg := m.initialSyncCmd()(); msg != nil {
+		t.Errorf("initialSyncCmd() after a sync = %#v, want nil", msg)
+	}
+}
+
+func TestSenderFilter_ListsSenderMailUntilEsc(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New() error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.CreateAccount(ctx, &domain.Account{ID: "a@example.com", Email: "a@example.com", Provider: "gmail"}); err != nil {
+		t.Fatalf("CreateAccount() error: %v", err)
+	}
+	emails := []domain.Email{
+		{ID: "e1", ThreadID: "t1", From: domain.Address{Email: "ann@example.com"}, Labels: []string{domain.LabelInbox}},
+		{ID: "e2", ThreadID: "t2", From: domain.Address{Email: "bob@example.com"}, Labels: []string{domain.LabelInbox}},
+		{ID: "e3", ThreadID: "t3", From: domain.Address{Email: "ann@example.com"}},
+	}
+	if err := db.UpsertEmails(ctx, emails, "a@example.com"); err != nil {
+		t.Fatalf("UpsertEmails() error: %v", err)
+	}
+
+	m := NewModel(cfg, db, nil, "a@example.com", []domain.Account{{ID: "a@example.com"}}, nil)
+	threads, err := db.ListThreads(ctx, store.ListEmailOptions{AccountID: "a@example.com", LabelID: domain.LabelInbox})
+	if err != nil {
+		t.Fatalf("ListThreads() error: %v", err)
+	}
+	updated, _ := m.Update(threadsLoadedMsg{threads: threads})
+	m = updated.(model)
+	for m.inbox.SelectedThreadID() != "t1" {
+		updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'j'}})
+		m = updated.(model)
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'e'}})
+	m = updated.(model)
+	updated, cmd = m.Update(cmd())
+	m = updated.(model)
+	updated, _ = m.Update(cmd())
+	m = updated.(model)
+	if m.senderFilter != "ann@example.com" || m.inbox.viewMode != viewFlat {
+		t.Fatalf("senderFilter/view = %q/%v, want ann@example.com in flat view", m.senderFilter, m.inbox.viewMode)
+	}
+	var ids []string
+	for _, e := range m.inbox.emails {
+		ids = append(ids, e.ID)
+	}
+	if !slices.Equal(ids, []string{"e1", "e3"}) && !slices.Equal(ids, []string{"e3", "e1"}) {
+		t.Errorf("filtered list = %v, want Ann's e1 and e3 from every label", ids)
+	}
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(model)
+	if m.senderFilter != "" || m.inbox.viewMode != viewThread {
+		t.Errorf("after esc senderFilter/view = %q/%v, want cleared in thread view", m.senderFilter, m.inbox.viewMode)
+	}
+	if _, ok := cmd().(threadsLoadedMsg); !ok {
+		t.Error("esc should reload the label's threads")
+	}
+}
@@ -0,0 +1,294 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// reflowMinLine is the shortest line taken to have been hard-wrapped by the
+// sender. Shorter lines, such as greetings and sign-offs, end a paragraph.
+const reflowMinLine = 60
+
+// quoteCollapseLines is the longest quoted passage shown in full by default;
+// longer ones are folded into a single placeholder line.
+const quoteCollapseLines = 4
+
+// blockKind classifies a run of non-blank body lines for layout.
+type blockKind int
+
+const (
+	// blockProse is ordinary text, reflowed and word-wrapped to the reader
+	// width.
+	blockProse blockKind = iota
+	// blockPreformatted is a table, code or ASCII art whose alignment
+	// would be destroyed by wrapping; its lines are clipped instead.
+	blockPreformatted
+)
+
+// layoutBody fits a plain-text body to width: prose paragraphs are reflowed
+// and word-wrapped while preformatted blocks keep their lines intact,
+// clipped at the edge.
+func layoutBody(body string, width int) string {
+	if width < 20 {
+		width = 20
+	}
+	lines := strings.Split(body, "\n")
+	var out []string
+	for start := 0; start < len(lines); {
+		if strings.TrimSpace(lines[start]) == "" {
+			out = append(out, "")
+			start++
+			continue
+		}
+		end := start
+		for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+			end++
+		}
+		block := lines[start:end]
+		if classifyBlock(block) == blockPreformatted {
+			for _, l := range block {
+				out = append(out, truncate(expandTabs(l), width))
+			}
+		} else {
+			for _, l := range reflowBlock(block) {
+				out = append(out, wrapLine(l, width)...)
+			}
+		}
+		start = end
+	}
+	return strings.Join(out, "\n")
+}
+
+// classifyBlock reports whether lines look preformatted: they contain
+// box-drawing or ASCII table borders, are all indented like code or all
+// start with "|" like a Markdown table, or share a column that several lines
+// align on after a gap of two or more spaces.
+func classifyBlock(lines []string) blockKind {
+	indented, piped := true, true
+	for _, l := range lines {
+		if hasTableBorder(l) {
+			return blockPreformatted
+		}
+		if !strings.HasPrefix(l, "    ") && !strings.HasPrefix(l, "\t") {
+			indented = false
+		}
+		if !strings.HasPrefix(strings.TrimSpace(l), "|") {
+			piped = false
+		}
+	}
+	if indented || piped {
+		return blockPreformatted
+	}
+	if len(lines) >= 2 && sharesColumn(lines) {
+		return blockPreformatted
+	}
+	return blockProse
+}
+
+// hasTableBorder reports whether line draws a box or an ASCII table rule
+// such as "+----+----+" or "|----|".
+func hasTableBorder(line string) bool {
+	for _, r := range line {
+		if r >= '─' && r <= '╿' {
+			return true
+		}
+	}
+	t := strings.TrimSpace(line)
+	if len(t) < 3 || (t[0] != '+' && t[0] != '|') {
+		return false
+	}
+	return strings.Trim(t, "+|-=: ") == ""
+}
+
+// sharesColumn reports whether every line has a column starting at the same
+// position after a run of at least two spaces.
+func sharesColumn(lines []string) bool {
+	common := columnStarts(expandTabs(lines[0]))
+	for _, l := range lines[1:] {
+		starts := columnStarts(expandTabs(l))
+		for col := range common {
+			if !starts[col] {
+				delete(common, col)
+			}
+		}
+		if len(common) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// columnStarts returns the rune positions where text resumes after an
+// internal gap of two or more spaces.
+func columnStarts(line string) map[int]bool {
+	starts := make(map[int]bool)
+	runes := []rune(line)
+	seenText := false
+	gap := 0
+	for i, r := range runes {
+		if r == ' ' {
+			gap++
+			continue
+		}
+		if seenText && gap >= 2 {
+			starts[i] = true
+		}
+		seenText = true
+		gap = 0
+	}
+	return starts
+}
+
+// reflowBlock joins the hard-wrapped lines of a prose block into paragraphs
+// so they can be wrapped to the reader width instead of the sender's. A line
+// is only joined onto a long line of the same quote depth; lines that look
+// like code or a table row (leading whitespace or "|" after the quote
+// markers) and list items keep their own lines.
+func reflowBlock(lines []string) []string {
+	var out []string
+	prevLen := 0
+	for i, l := range lines {
+		prefix, text := splitQuote(l)
+		if i > 0 && prevLen >= reflowMinLine && !fixedLine(text) && !listItem(text) {
+			prevPrefix, prevText := splitQuote(lines[i-1])
+			if !fixedLine(prevText) && strings.Count(prevPrefix, ">") == strings.Count(prefix, ">") {
+				out[len(out)-1] += " " + strings.TrimSpace(text)
+				prevLen = utf8.RuneCountInString(text)
+				continue
+			}
+		}
+		out = append(out, l)
+		prevLen = utf8.RuneCountInString(text)
+	}
+	return out
+}
+
+// splitQuote splits line into its quote markers, such as "> > ", and the
+// text after them.
+func splitQuote(line string) (prefix, text string) {
+	i := 0
+	for i < len(line) && line[i] == '>' {
+		i++
+		if i < len(line) && line[i] == ' ' {
+			i++
+		}
+	}
+	return line[:i], line[i:]
+}
+
+// fixedLine reports whether text, a line without its quote markers, must
+// not be joined with its neighbours: it is blank, indented or a table row.
+func fixedLine(text string) bool {
+	if strings.TrimSpace(text) == "" {
+		return true
+	}
+	return text[0] == ' ' || text[0] == '\t' || text[0] == '|'
+}
+
+// listItem reports whether text starts a bulleted or numbered list item.
+func listItem(text string) bool {
+	for _, bullet := range []string{"- ", "* ", "+ ", "• "} {
+		if strings.HasPrefix(text, bullet) {
+			return true
+		}
+	}
+	digits := len(text) - len(strings.TrimLeft(text, "0123456789"))
+	rest := text[digits:]
+	return digits > 0 && (strings.HasPrefix(rest, ". ") || strings.HasPrefix(rest, ") "))
+}
+
+// WrapText word-wraps each line of text to width runes, as the reader does,
+// repeating quote markers and indentation on the continued lines. Unlike the
+// reader it neither reflows paragraphs nor clips preformatted lines, so no
+// text is lost. A width below one leaves text unchanged.
+func WrapText(text string, width int) string {
+	if width < 1 {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	for _, l := range lines {
+		out = append(out, wrapLine(strings.TrimRight(l, " \r"), width)...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// wrapLine breaks line at spaces so no piece is wider than width runes,
+// keeping its indentation (such as "> " quote markers) on each piece.
+func wrapLine(line string, width int) []string {
+	if utf8.RuneCountInString(line) <= width {
+		return []string{line}
+	}
+	trimmed := strings.TrimLeft(line, " >")
+	indent := line[:len(line)-len(trimmed)]
+	if utf8.RuneCountInString(indent) > width/2 {
+		indent = ""
+	}
+
+	var out []string
+	cur := indent
+	for _, word := range strings.Fields(trimmed) {
+		switch {
+		case cur == indent:
+			cur += word
+		case utf8.RuneCountInString(cur)+1+utf8.RuneCountInString(word) <= width:
+			cur += " " + word
+		default:
+			out = append(out, cur)
+			cur = indent + word
+		}
+		for utf8.RuneCountInString(cur) > width {
+			runes := []rune(cur)
+			out = append(out, string(runes[:width]))
+			cur = indent + string(runes[width:])
+		}
+	}
+	return append(out, cur)
+}
+
+// collapseQuotes replaces each run of more than quoteCollapseLines quoted
+// lines (starting with ">") with a placeholder naming the key that shows them.
+func collapseQuotes(st styles, body string) string {
+	lines := strings.Split(body, "\n")
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); {
+		end := i
+		for end < len(lines) && strings.HasPrefix(strings.TrimLeft(lines[end], " "), ">") {
+			end++
+		}
+		switch {
+		case end == i:
+			out = append(out, lines[i])
+			i++
+		case end-i > quoteCollapseLines:
+			out = append(out, st.mutedText.Render(fmt.Sprintf("[%d quoted lines hidden, press %s to show]",
+				end-i, keys.Quotes.Help().Key)))
+			i = end
+		default:
+			out = append(out, lines[i:end]...)
+			i = end
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// expandTabs replaces tabs with spaces up to the next multiple of eight.
+func expandTabs(s string) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	var b strings.Builder
+	col := 0
+	for _, r := range s {
+		if r == '\t' {
+			n := 8 - col%8
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		b.WriteRune(r)
+		col++
+	}
+	return b.String()
+}
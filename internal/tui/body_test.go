@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestClassifyBlock(t *testing.T) {
+	tests := []struct {
+		name  string
+		block string
+		want  blockKind
+	}{
+		{
+			name: "aligned table",
+			block: "Host      CPU   Memory\n" +
+				"web-1     42%   3.1 GB\n" +
+				"db-1      87%   12.4 GB",
+			want: blockPreformatted,
+		},
+		{
+			name: "ascii table",
+			block: "+------+-------+\n" +
+				"| id   | state |\n" +
+				"+------+-------+",
+			want: blockPreformatted,
+		},
+		{
+			name:  "box drawing",
+			block: "┌───┐\n│ x │\n└───┘",
+			want:  blockPreformatted,
+		},
+		{
+			name: "code block",
+			block: "    func main() {\n" +
+				"        fmt.Println(\"hi\")\n" +
+				"    }",
+			want: blockPreformatted,
+		},
+		{
+			name: "prose",
+			block: "Hi team, the deploy went out this morning and everything looks fine.\n" +
+				"Let me know if you see anything odd.  Thanks for the quick reviews.",
+			want: blockProse,
+		},
+		{
+			name:  "quoted prose",
+			block: "> On Monday Alice wrote:\n> Can we move the meeting?",
+			want:  blockProse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyBlock(strings.Split(tt.block, "\n")); got != tt.want {
+				t.Errorf("classifyBlock() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutBody(t *testing.T) {
+	table := "Name        Status     Owner\n" +
+		"migration   running    alice@example.com\n" +
+		"backfill    queued     bob@example.com"
+	prose := "This paragraph is long enough that it has to be wrapped onto several lines in a narrow reader."
+	body := prose + "\n\n" + table
+
+	got := strings.Split(layoutBody(body, 30), "\n")
+	for _, l := range got {
+		if n := utf8.RuneCountInString(l); n > 30 {
+			t.Errorf("line %q is %d wide, want <= 30", l, n)
+		}
+	}
+
+	// The prose is wrapped at word boundaries.
+	if got[0] != "This paragraph is long enough" {
+		t.Errorf("first line = %q, want a word-wrapped line", got[0])
+	}
+	// Each table row stays on one line, clipped rather than wrapped.
+	var rows int
+	for _, l := range got {
+		if strings.HasPrefix(l, "migration   running") || strings.HasPrefix(l, "backfill    queued") {
+			rows++
+		}
+	}
+	if rows != 2 {
+		t.Errorf("table rows kept intact = %d, want 2:\n%s", rows, strings.Join(got, "\n"))
+	}
+}
+
+func TestWrapLine_KeepsQuotePrefix(t *testing.T) {
+	got := wrapLine("> one two three four five six seven eight nine ten", 20)
+	for _, l := range got {
+		if !strings.HasPrefix(l, "> ") {
+			t.Errorf("wrapped line %q lost its quote prefix", l)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	body := "Short line.\n" +
+		"The release is scheduled for Thursday afternoon.\n" +
+		"> > quoted text that runs past the edge\n" +
+		"\n" +
+		"Thanks"
+
+	got := WrapText(body, 20)
+	want := "Short line.\n" +
+		"The release is\n" +
+		"scheduled for\n" +
+		"Thursday afternoon.\n" +
+		"> > quoted text that\n" +
+		"> > runs past the\n" +
+		"> > edge\n" +
+		"\n" +
+		"Thanks"
+	if got != want {
+		t.Errorf("WrapText(20) =\n%s\nwant\n%s", got, want)
+	}
+	if got := WrapText(body, 0); got != body {
+		t.Errorf("WrapText(0) = %q, want the body unchanged", got)
+	}
+}
+
+func TestLayoutBody_ReflowsLongParagraph(t *testing.T) {
+	body := "The release is scheduled for Thursday once the last migration has\n" +
+		"finished on every replica, and the dashboards have been checked by\n" +
+		"whoever is on call that week.\n" +
+		"\n" +
+		"Thanks,\n" +
+		"Alice"
+
+	got := strings.Split(layoutBody(body, 100), "\n")
+	want := []string{
+		"The release is scheduled for Thursday once the last migration has finished on every replica, and the",
+		"dashboards have been checked by whoever is on call that week.",
+		"",
+		"Thanks,",
+		"Alice",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("layoutBody() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLayoutBody_ReflowsQuotedBlock(t *testing.T) {
+	body := "> The release is scheduled for Thursday once the last migration has\n" +
+		"> finished on every replica.\n" +
+		"Sounds good to me, let's keep the Thursday date and see how the replicas go."
+
+	got := strings.Split(layoutBody(body, 40), "\n")
+	var quoted []string
+	for _, l := range got {
+		if n := utf8.RuneCountInString(l); n > 40 {
+			t.Errorf("line %q is %d wide, want <= 40", l, n)
+		}
+		if strings.HasPrefix(l, "> ") {
+			quoted = append(quoted, strings.TrimPrefix(l, "> "))
+		}
+	}
+	want := "The release is scheduled for Thursday once the last migration has finished on every replica."
+	if strings.Join(quoted, " ") != want {
+		t.Errorf("quoted text = %q, want %q", strings.Join(quoted, " "), want)
+	}
+	if last := got[len(got)-1]; strings.HasPrefix(last, ">") {
+		t.Errorf("reply line %q was joined into the quote", last)
+	}
+}
+
+func TestLayoutBody_KeepsPreformattedLines(t *testing.T) {
+	body := "Here are the numbers from this morning's run, as promised in standup:\n" +
+		"    total   1204\n" +
+		"| region | latency |\n" +
+		"- check the eu numbers once the cache has warmed up again"
+
+	if got := layoutBody(body, 100); got != body {
+		t.Errorf("layoutBody() =\n%s\nwant the lines unchanged:\n%s", got, body)
+	}
+
+	table := "| region | latency |\n| eu | 120ms |"
+	if kind := classifyBlock(strings.Split(table, "\n")); kind != blockPreformatted {
+		t.Errorf("classifyBlock(markdown table) = %v, want preformatted", kind)
+	}
+}
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"net/mail"
 	"strings"
 	"time"
 
@@ -26,23 +27,42 @@ const (
 
 type sendMsg struct {
 	email *domain.Email
+	// forwardOf is the ID of the forwarded message whose attachments still
+	// have to be fetched and added to email before it is sent.
+	forwardOf string
 }
 
 type cancelComposeMsg struct{}
 
+// contactQueryMsg asks for address suggestions matching prefix.
+type contactQueryMsg struct {
+	prefix string
+}
+
+// contactSuggestionsMsg delivers address suggestions for prefix.
+type contactSuggestionsMsg struct {
+	prefix   string
+	contacts []domain.Address
+}
+
+// maxSuggestions is the number of address suggestions shown under To/CC.
+const maxSuggestions = 3
+
 // Field indices within the composer form.
 const (
 	fieldTo      = 0
 	fieldCC      = 1
-	fieldSubject = 2
-	fieldBody    = 3
-	fieldCount   = 4
+	fieldReplyTo = 2
+	fieldSubject = 3
+	fieldBody    = 4
+	fieldCount   = 5
 )
 
 // composerModel is a Bubble Tea sub-model for composing, replying, and forwarding emails.
 type composerModel struct {
 	toInput      textinput.Model
 	ccInput      textinput.Model
+	replyToInput textinput.Model
 	subjectInput textinput.Model
 	bodyInput    textarea.Model
 
@@ -50,9 +70,37 @@ type composerModel struct {
 	mode        composerMode
 	replyTo     *domain.Email
 
+	// defaultReplyTo pre-fills the Reply-To field (from compose.reply_to).
+	defaultReplyTo string
+
+	// defaultCC and defaultBCC are added on send when CC or BCC is empty
+	// (from compose.default_cc and compose.default_bcc).
+	defaultCC  []domain.Address
+	defaultBCC []domain.Address
+
+	// groups are the address groups from config; a group name typed as a
+	// recipient expands to its members on send.
+	groups map[string]string
+
+	// confirmRecipients is the recipient count above which sending needs a
+	// second Ctrl+S (from compose.confirm_recipients); zero never asks.
+	// confirming is set while that second press is awaited.
+	confirmRecipients int
+	confirming        bool
+
+	// bcc carries BCC recipients from a pre-filled draft (e.g. a mailto URL).
+	// There is no editable field for it; it is shown read-only.
+	bcc []domain.Address
+
+	// suggestions are contacts matching the address being typed in To/CC.
+	suggestions   []domain.Address
+	suggestPrefix string
+
 	width   int
 	height  int
 	visible bool
+
+	styles styles
 }
 
 // newComposer creates a new composerModel with text inputs and textarea configured.
@@ -67,6 +115,11 @@ func newComposer() composerModel {
 	cc.CharLimit = 500
 	cc.Prompt = ""
 
+	replyTo := textinput.New()
+	replyTo.Placeholder = "reply-to@example.com (optional)"
+	replyTo.CharLimit = 500
+	replyTo.Prompt = ""
+
 	subject := textinput.New()
 	subject.Placeholder = "Subject"
 	subject.CharLimit = 200
@@ -81,8 +134,10 @@ func newComposer() composerModel {
 	return composerModel{
 		toInput:      to,
 		ccInput:      cc,
+		replyToInput: replyTo,
 		subjectInput: subject,
 		bodyInput:    body,
+		styles:       defaultStyles(),
 	}
 }
 
@@ -94,9 +149,18 @@ func (c composerModel) Update(msg tea.Msg) (composerModel, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		confirming := c.confirming
+		c.confirming = false
 		switch msg.String() {
-		case "tab":
+		case "tab", "enter":
+			if c.acceptSuggestion() {
+				return c, nil
+			}
+			if msg.String() == "enter" {
+				break
+			}
 			c.activeField = (c.activeField + 1) % fieldCount
+			c.clearSuggestions()
 			c.updateFocus()
 			return c, nil
 
@@ -105,7 +169,22 @@ func (c composerModel) Update(msg tea.Msg) (composerModel, tea.Cmd) {
 
 		case "ctrl+s":
 			email := c.BuildEmail()
-			return c, func() tea.Msg { return sendMsg{email: email} }
+			for _, a := range email.ReplyTo {
+				if _, err := mail.ParseAddress(a.Email); err != nil {
+					return c, func() tea.Msg {
+						return errMsg{err: fmt.Errorf("invalid Reply-To address %q", a.Email)}
+					}
+				}
+			}
+			if n := len(email.Recipients()); c.confirmRecipients > 0 && n > c.confirmRecipients && !confirming {
+				c.confirming = true
+				return c, nil
+			}
+			send := sendMsg{email: email}
+			if c.mode == modeForward && c.replyTo != nil {
+				send.forwardOf = c.replyTo.ID
+			}
+			return c, func() tea.Msg { return send }
 		}
 	}
 
@@ -114,8 +193,12 @@ func (c composerModel) Update(msg tea.Msg) (composerModel, tea.Cmd) {
 	switch c.activeField {
 	case fieldTo:
 		c.toInput, cmd = c.toInput.Update(msg)
+		cmd = tea.Batch(cmd, c.queryContacts(c.toInput.Value()))
 	case fieldCC:
 		c.ccInput, cmd = c.ccInput.Update(msg)
+		cmd = tea.Batch(cmd, c.queryContacts(c.ccInput.Value()))
+	case fieldReplyTo:
+		c.replyToInput, cmd = c.replyToInput.Update(msg)
 	case fieldSubject:
 		c.subjectInput, cmd = c.subjectInput.Update(msg)
 	case fieldBody:
@@ -138,7 +221,7 @@ func (c composerModel) View() string {
 
 	title := c.modeTitle()
 
-	labelWidth := 10 // "Subject: " + spacing
+	labelWidth := 11 // "Reply-To: " + spacing
 	inputWidth := innerWidth - labelWidth
 	if inputWidth < 10 {
 		inputWidth = 10
@@ -147,27 +230,49 @@ func (c composerModel) View() string {
 	// Resize inputs to fit.
 	c.toInput.Width = inputWidth
 	c.ccInput.Width = inputWidth
+	c.replyToInput.Width = inputWidth
 	c.subjectInput.Width = inputWidth
 	c.bodyInput.SetWidth(innerWidth)
 
-	// Calculate body height: total height minus border(2) padding(2) fields(3) separator(1) help(1) spacing(1).
-	bodyHeight := c.height - 10
+	suggestionRows := c.suggestionRows()
+
+	// Calculate body height: total height minus border(2) padding(2) fields(4) separator(1) help(1) spacing(1).
+	bodyHeight := c.height - 11 - len(suggestionRows)
+	if len(c.bcc) > 0 {
+		bodyHeight--
+	}
 	if bodyHeight < 3 {
 		bodyHeight = 3
 	}
 	c.bodyInput.SetHeight(bodyHeight)
 
-	toLabel := mutedTextStyle.Render(fmt.Sprintf("%-9s", "To:"))
-	ccLabel := mutedTextStyle.Render(fmt.Sprintf("%-9s", "CC:"))
-	subjectLabel := mutedTextStyle.Render(fmt.Sprintf("%-9s", "Subject:"))
+	toLabel := c.styles.mutedText.Render(fmt.Sprintf("%-10s", "To:"))
+	ccLabel := c.styles.mutedText.Render(fmt.Sprintf("%-10s", "CC:"))
+	replyToLabel := c.styles.mutedText.Render(fmt.Sprintf("%-10s", "Reply-To:"))
+	subjectLabel := c.styles.mutedText.Render(fmt.Sprintf("%-10s", "Subject:"))
 
-	separator := mutedTextStyle.Render(strings.Repeat("─", innerWidth))
+	separator := c.styles.mutedText.Render(strings.Repeat("─", innerWidth))
 
-	helpText := mutedTextStyle.Render("Tab:fields  Ctrl+S:send  Esc:cancel")
+	helpText := c.styles.mutedText.Render("Tab:fields/complete  Ctrl+S:send  Esc:cancel")
+	if c.confirming {
+		n := len(c.BuildEmail().Recipients())
+		helpText = c.styles.errorText.Render(fmt.Sprintf("Send to %d recipients? Ctrl+S:send  any other key:keep editing", n))
+	}
 
 	var rows []string
 	rows = append(rows, toLabel+c.toInput.View())
+	if c.activeField == fieldTo {
+		rows = append(rows, suggestionRows...)
+	}
 	rows = append(rows, ccLabel+c.ccInput.View())
+	if c.activeField == fieldCC {
+		rows = append(rows, suggestionRows...)
+	}
+	if len(c.bcc) > 0 {
+		bccLabel := c.styles.mutedText.Render(fmt.Sprintf("%-10s", "BCC:"))
+		rows = append(rows, bccLabel+formatAddresses(c.bcc))
+	}
+	rows = append(rows, replyToLabel+c.replyToInput.View())
 	rows = append(rows, subjectLabel+c.subjectInput.View())
 	rows = append(rows, separator)
 	rows = append(rows, c.bodyInput.View())
@@ -178,13 +283,13 @@ func (c composerModel) View() string {
 
 	boxStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(primaryColor).
+		BorderForeground(c.styles.theme.Primary).
 		Padding(0, 1).
 		Width(c.width - 2)
 
-	titleRow := titleStyle.Render(" " + title + " ")
+	titleRow := c.styles.title.Render(" " + title + " ")
 	header := lipgloss.NewStyle().
-		BorderForeground(primaryColor).
+		BorderForeground(c.styles.theme.Primary).
 		Render(titleRow)
 
 	return header + "\n" + boxStyle.Render(content)
@@ -200,8 +305,28 @@ func (c *composerModel) Compose() {
 	c.updateFocus()
 }
 
-// Reply opens the composer pre-filled for replying to the given email.
-// If replyAll is true, CC is populated with the original To and CC recipients.
+// ComposeDraft opens the composer for a new email pre-filled from draft,
+// such as one parsed from a mailto URL.
+func (c *composerModel) ComposeDraft(draft *domain.Email) {
+	c.Compose()
+	c.toInput.SetValue(formatAddresses(draft.To))
+	c.ccInput.SetValue(formatAddresses(draft.CC))
+	c.subjectInput.SetValue(draft.Subject)
+	c.bodyInput.SetValue(draft.Body)
+	c.bcc = draft.BCC
+
+	if len(draft.To) > 0 {
+		c.activeField = fieldBody
+		if draft.Subject == "" {
+			c.activeField = fieldSubject
+		}
+	}
+	c.updateFocus()
+}
+
+// Reply opens the composer pre-filled for replying to the given email, or
+// to its mailing list when it has a List-Post address. If replyAll is true,
+// CC is populated with the original sender, To and CC recipients.
 func (c *composerModel) Reply(email *domain.Email, replyAll bool) {
 	c.replyTo = email
 	c.clearFields()
@@ -213,27 +338,23 @@ func (c *composerModel) Reply(email *domain.Email, replyAll bool) {
 		c.mode = modeReply
 	}
 
-	// Pre-fill To with the original sender.
-	c.toInput.SetValue(email.From.String())
+	// Pre-fill To with the original sender, or the list for list mail.
+	to := email.ReplyRecipient()
+	c.toInput.SetValue(to.Display())
 
 	// For reply-all, populate CC with original To and CC (excluding the sender already in To).
 	if replyAll {
 		var ccAddrs []string
-		for _, addr := range email.To {
-			ccAddrs = append(ccAddrs, addr.String())
-		}
-		for _, addr := range email.CC {
-			ccAddrs = append(ccAddrs, addr.String())
+		for _, addr := range append(append([]domain.Address{email.From}, email.To...), email.CC...) {
+			// The sender is already in To unless replying to a list.
+			if !strings.EqualFold(addr.Email, to.Email) {
+				ccAddrs = append(ccAddrs, addr.Display())
+			}
 		}
 		c.ccInput.SetValue(strings.Join(ccAddrs, ", "))
 	}
 
-	// Pre-fill Subject with "Re: " prefix.
-	subject := email.Subject
-	if !strings.HasPrefix(strings.ToLower(subject), "re: ") {
-		subject = "Re: " + subject
-	}
-	c.subjectInput.SetValue(subject)
+	c.subjectInput.SetValue(replySubject(email.Subject))
 
 	// Quote the original body.
 	quoted := formatReplyQuote(email)
@@ -271,6 +392,18 @@ func (c *composerModel) Close() {
 	c.clearFields()
 }
 
+// SetSuggestions shows contacts for prefix if it still matches the address
+// being typed; stale results from earlier keystrokes are dropped.
+func (c *composerModel) SetSuggestions(prefix string, contacts []domain.Address) {
+	if prefix != c.suggestPrefix {
+		return
+	}
+	if len(contacts) > maxSuggestions {
+		contacts = contacts[:maxSuggestions]
+	}
+	c.suggestions = contacts
+}
+
 // SetSize updates the available dimensions for the composer.
 func (c *composerModel) SetSize(w, h int) {
 	c.width = w
@@ -285,15 +418,19 @@ func (c composerModel) IsVisible() bool {
 // BuildEmail constructs a domain.Email from the current field values.
 func (c composerModel) BuildEmail() *domain.Email {
 	email := &domain.Email{
-		To:      parseAddresses(c.toInput.Value()),
-		CC:      parseAddresses(c.ccInput.Value()),
+		To:      parseAddresses(c.toInput.Value(), c.groups),
+		CC:      parseAddresses(c.ccInput.Value(), c.groups),
+		BCC:     c.bcc,
+		ReplyTo: parseAddresses(c.replyToInput.Value(), nil),
 		Subject: c.subjectInput.Value(),
 		Body:    c.bodyInput.Value(),
 		Date:    time.Now(),
 	}
+	email.ApplyDefaultRecipients(c.defaultCC, c.defaultBCC)
 
 	if c.replyTo != nil {
-		email.InReplyTo = c.replyTo.ID
+		email.InReplyTo = c.replyTo.ReplyInReplyTo()
+		email.References = c.replyTo.ReplyReferences()
 		email.ThreadID = c.replyTo.ThreadID
 	}
 
@@ -302,18 +439,102 @@ func (c composerModel) BuildEmail() *domain.Email {
 
 // --- internal helpers ---
 
-// clearFields resets all input fields to empty.
+// clearFields resets all input fields to empty, restoring the configured
+// default Reply-To.
 func (c *composerModel) clearFields() {
 	c.toInput.SetValue("")
 	c.ccInput.SetValue("")
+	c.replyToInput.SetValue(c.defaultReplyTo)
 	c.subjectInput.SetValue("")
 	c.bodyInput.SetValue("")
+	c.bcc = nil
+	c.confirming = false
+	c.clearSuggestions()
+}
+
+// queryContacts returns a command requesting suggestions for the address
+// currently being typed in value, or nil if it has not changed.
+func (c *composerModel) queryContacts(value string) tea.Cmd {
+	prefix := currentAddressToken(value)
+	if prefix == c.suggestPrefix {
+		return nil
+	}
+	c.suggestPrefix = prefix
+	c.suggestions = nil
+	if prefix == "" {
+		return nil
+	}
+	return func() tea.Msg { return contactQueryMsg{prefix: prefix} }
+}
+
+// acceptSuggestion replaces the address being typed in the active To/CC
+// field with the top suggestion. It reports whether one was accepted.
+func (c *composerModel) acceptSuggestion() bool {
+	if len(c.suggestions) == 0 {
+		return false
+	}
+	top := c.suggestions[0]
+	switch c.activeField {
+	case fieldTo:
+		c.toInput.SetValue(completeAddress(c.toInput.Value(), top))
+		c.toInput.CursorEnd()
+	case fieldCC:
+		c.ccInput.SetValue(completeAddress(c.ccInput.Value(), top))
+		c.ccInput.CursorEnd()
+	default:
+		return false
+	}
+	c.clearSuggestions()
+	return true
+}
+
+func (c *composerModel) clearSuggestions() {
+	c.suggestions = nil
+	c.suggestPrefix = ""
+}
+
+// suggestionRows renders the suggestion list, highlighting the top match
+// that Tab/Enter would accept.
+func (c composerModel) suggestionRows() []string {
+	if len(c.suggestions) == 0 || (c.activeField != fieldTo && c.activeField != fieldCC) {
+		return nil
+	}
+	indent := strings.Repeat(" ", 10)
+	rows := make([]string, len(c.suggestions))
+	for i, a := range c.suggestions {
+		if i == 0 {
+			rows[i] = indent + c.styles.selected.Render(a.Display())
+		} else {
+			rows[i] = indent + c.styles.mutedText.Render(a.Display())
+		}
+	}
+	return rows
+}
+
+// currentAddressToken returns the trailing, partially typed address in a
+// comma-separated recipient list.
+func currentAddressToken(value string) string {
+	if i := strings.LastIndex(value, ","); i >= 0 {
+		value = value[i+1:]
+	}
+	return strings.TrimSpace(value)
+}
+
+// completeAddress replaces the trailing partial address in value with addr
+// and appends a separator ready for the next recipient.
+func completeAddress(value string, addr domain.Address) string {
+	head := ""
+	if i := strings.LastIndex(value, ","); i >= 0 {
+		head = value[:i+1] + " "
+	}
+	return head + addr.Display() + ", "
 }
 
 // updateFocus sets the correct focus state on all input components.
 func (c *composerModel) updateFocus() {
 	c.toInput.Blur()
 	c.ccInput.Blur()
+	c.replyToInput.Blur()
 	c.subjectInput.Blur()
 	c.bodyInput.Blur()
 
@@ -322,6 +543,8 @@ func (c *composerModel) updateFocus() {
 		c.toInput.Focus()
 	case fieldCC:
 		c.ccInput.Focus()
+	case fieldReplyTo:
+		c.replyToInput.Focus()
 	case fieldSubject:
 		c.subjectInput.Focus()
 	case fieldBody:
@@ -344,23 +567,19 @@ func (c composerModel) modeTitle() string {
 }
 
 // parseAddresses splits a comma-separated string into Address structs.
-// Each entry is trimmed. If the entry contains "<email>", name and email are
-// extracted; otherwise, the whole string is treated as an email address.
-func parseAddresses(s string) []domain.Address {
+// Each entry is trimmed and names of address groups are expanded. If the
+// entry contains "<email>", name and email are extracted; otherwise, the
+// whole string is treated as an email address.
+func parseAddresses(s string, groups map[string]string) []domain.Address {
 	s = strings.TrimSpace(s)
 	if s == "" {
 		return nil
 	}
 
-	parts := strings.Split(s, ",")
+	parts := domain.ExpandGroups(splitAddresses(s), groups)
 	addrs := make([]domain.Address, 0, len(parts))
 
 	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		if part == "" {
-			continue
-		}
-
 		addr := parseOneAddress(part)
 		addrs = append(addrs, addr)
 	}
@@ -368,13 +587,38 @@ func parseAddresses(s string) []domain.Address {
 	return addrs
 }
 
+// splitAddresses splits a recipient list at the commas that are not inside
+// a quoted display name such as "Doe, John".
+func splitAddresses(s string) []string {
+	var parts []string
+	start, quoted, escaped := 0, false, false
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quoted:
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
 // parseOneAddress parses a single address string.
-// Supports "Name <email>" and bare "email" formats.
+// Supports "Name <email>", with the name optionally quoted, and bare "email"
+// formats.
 func parseOneAddress(s string) domain.Address {
 	if idx := strings.LastIndex(s, "<"); idx >= 0 {
 		end := strings.Index(s[idx:], ">")
 		if end > 0 {
 			name := strings.TrimSpace(s[:idx])
+			if len(name) >= 2 && strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) {
+				name = strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(name[1 : len(name)-1])
+			}
 			email := s[idx+1 : idx+end]
 			return domain.Address{Name: name, Email: email}
 		}
@@ -382,28 +626,26 @@ func parseOneAddress(s string) domain.Address {
 	return domain.Address{Email: s}
 }
 
-// formatReplyQuote builds the quoted text for a reply.
-func formatReplyQuote(email *domain.Email) string {
-	date := email.Date.Format("Jan 2, 2006")
-	header := fmt.Sprintf("\nOn %s, %s wrote:", date, email.From.String())
-
-	lines := strings.Split(email.Body, "\n")
-	var quoted strings.Builder
-	for _, line := range lines {
-		quoted.WriteString("> ")
-		quoted.WriteString(line)
-		quoted.WriteString("\n")
+// replySubject returns subject with a "Re: " prefix, unless it has one.
+func replySubject(subject string) string {
+	if strings.HasPrefix(strings.ToLower(subject), "re: ") {
+		return subject
 	}
+	return "Re: " + subject
+}
 
-	return header + "\n" + quoted.String()
+// formatReplyQuote builds the quoted text for a reply: only the newest
+// message of the conversation, below a marker standing in for the rest.
+func formatReplyQuote(email *domain.Email) string {
+	return "\n" + domain.QuoteReply(email, domain.QuoteLast)
 }
 
 // formatForwardBody builds the forwarded message body.
 func formatForwardBody(email *domain.Email) string {
-	date := email.Date.Format("Jan 2, 2006")
+	date := domain.FormatDate(email.Date, "Jan 2, 2006")
 	var b strings.Builder
 	b.WriteString("\n---------- Forwarded message ----------\n")
-	b.WriteString(fmt.Sprintf("From: %s\n", email.From.String()))
+	b.WriteString(fmt.Sprintf("From: %s\n", email.From.Display()))
 	b.WriteString(fmt.Sprintf("Date: %s\n", date))
 	b.WriteString(fmt.Sprintf("Subject: %s\n", email.Subject))
 	b.WriteString("\n")
@@ -0,0 +1,219 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+func TestCurrentAddressToken(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"al", "al"},
+		{"alice@example.com, bo", "bo"},
+		{"alice@example.com, ", ""},
+	}
+	for _, tt := range tests {
+		if got := currentAddressToken(tt.value); got != tt.want {
+			t.Errorf("currentAddressToken(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCompleteAddress(t *testing.T) {
+	bob := domain.Address{Name: "Bob", Email: "bob@example.com"}
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"bo", "Bob <bob@example.com>, "},
+		{"alice@example.com, bo", "alice@example.com, Bob <bob@example.com>, "},
+	}
+	for _, tt := range tests {
+		if got := completeAddress(tt.value, bob); got != tt.want {
+			t.Errorf("completeAddress(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestComposer_SetSuggestionsIgnoresStalePrefix(t *testing.T) {
+	c := newComposer()
+	c.suggestPrefix = "bob"
+
+	c.SetSuggestions("bo", []domain.Address{{Email: "bob@example.com"}})
+	if len(c.suggestions) != 0 {
+		t.Error("SetSuggestions() applied results for a stale prefix")
+	}
+
+	c.SetSuggestions("bob", []domain.Address{{Email: "a@x"}, {Email: "b@x"}, {Email: "c@x"}, {Email: "d@x"}})
+	if len(c.suggestions) != maxSuggestions {
+		t.Errorf("got %d suggestions, want %d", len(c.suggestions), maxSuggestions)
+	}
+}
+
+func TestComposer_ComposeDraft(t *testing.T) {
+	c := newComposer()
+	c.ComposeDraft(&domain.Email{
+		To:      []domain.Address{{Email: "a@b.com"}, {Email: "c@d.com"}},
+		BCC:     []domain.Address{{Email: "e@f.com"}},
+		Subject: "Hi",
+		Body:    "Yo",
+	})
+
+	if !c.IsVisible() {
+		t.Fatal("composer should be visible")
+	}
+	if c.activeField != fieldBody {
+		t.Errorf("activeField = %d, want body when To and Subject are set", c.activeField)
+	}
+
+	email := c.BuildEmail()
+	if len(email.To) != 2 || email.To[1].Email != "c@d.com" {
+		t.Errorf("To = %v, want two recipients", email.To)
+	}
+	if len(email.BCC) != 1 || email.BCC[0].Email != "e@f.com" {
+		t.Errorf("BCC = %v, want [e@f.com]", email.BCC)
+	}
+	if email.Subject != "Hi" || email.Body != "Yo" {
+		t.Errorf("Subject/Body = %q/%q, want Hi/Yo", email.Subject, email.Body)
+	}
+
+	c.Close()
+	if c.BuildEmail().BCC != nil {
+		t.Error("Close() should clear BCC")
+	}
+}
+
+func TestComposerReply_QuotesLatestMessageOnly(t *testing.T) {
+	c := newComposer()
+	c.Reply(&domain.Email{
+		From:    domain.Address{Email: "ann@example.com"},
+		Subject: "Lunch",
+		Body:    "Sounds good.\n\nOn Mon, Jan 5, 2026 at 9:00 AM, Bob <bob@example.com> wrote:\n> Lunch?\n> > Older\n",
+	}, false)
+
+	body := c.bodyInput.Value()
+	if !strings.Contains(body, domain.QuotedTextMarker+"\n") || !strings.Contains(body, "> Sounds good.") {
+		t.Errorf("reply body = %q, want the marker and the newest message quoted", body)
+	}
+	if strings.Contains(body, "Lunch?") || strings.Contains(body, "Older") {
+		t.Errorf("reply body = %q, should not quote the earlier thread", body)
+	}
+}
+
+func TestComposerForward_SendAsksForAttachments(t *testing.T) {
+	c := newComposer()
+	c.Forward(&domain.Email{ID: "m1", ThreadID: "t1", Subject: "Report"})
+	c.toInput.SetValue("you@example.com")
+	_, cmd := c.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if cmd == nil {
+		t.Fatal("Ctrl+S should send the forward")
+	}
+	if msg, ok := cmd().(sendMsg); !ok || msg.forwardOf != "m1" {
+		t.Fatalf("got %#v, want a sendMsg forwarding m1's attachments", cmd())
+	}
+
+	c.Compose()
+	c.toInput.SetValue("you@example.com")
+	_, cmd = c.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if msg, ok := cmd().(sendMsg); !ok || msg.forwardOf != "" {
+		t.Fatalf("got %#v, want a plain sendMsg for new mail", cmd())
+	}
+}
+
+func TestComposerSend_ConfirmsLongRecipientList(t *testing.T) {
+	ctrlS := tea.KeyMsg{Type: tea.KeyCtrlS}
+
+	c := newComposer()
+	c.confirmRecipients = 2
+	c.Compose()
+	c.toInput.SetValue("a@example.com, b@example.com")
+	c.ccInput.SetValue("B@example.com")
+	c, cmd := c.Update(ctrlS)
+	if cmd == nil {
+		t.Fatal("two distinct recipients should send without confirming")
+	}
+	if _, ok := cmd().(sendMsg); !ok {
+		t.Fatalf("got %T, want sendMsg", cmd())
+	}
+
+	c.ccInput.SetValue("c@example.com")
+	c, cmd = c.Update(ctrlS)
+	if cmd != nil || !c.confirming {
+		t.Fatal("three recipients should ask for confirmation first")
+	}
+	if !strings.Contains(c.View(), "Send to 3 recipients?") {
+		t.Errorf("view should show the confirmation:\n%s", c.View())
+	}
+	_, cmd = c.Update(ctrlS)
+	if cmd == nil {
+		t.Fatal("a second Ctrl+S should send")
+	}
+	if _, ok := cmd().(sendMsg); !ok {
+		t.Fatalf("got %T, want sendMsg", cmd())
+	}
+
+	// Any other key cancels the pending confirmation.
+	c, _ = c.Update(ctrlS)
+	c, _ = c.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if c.confirming {
+		t.Error("typing should dismiss the confirmation")
+	}
+	if _, cmd = c.Update(ctrlS); cmd != nil {
+		t.Error("after dismissing, Ctrl+S should ask again")
+	}
+}
+
+func TestComposerBuildEmail_ExpandsGroups(t *testing.T) {
+	c := newComposer()
+	c.groups = map[string]string{"team": "ann@example.com, Bob <bob@example.com>"}
+	c.Compose()
+	c.toInput.SetValue("Team, carol@example.com")
+	c.ccInput.SetValue("bob@example.com")
+
+	email := c.BuildEmail()
+	var to []string
+	for _, a := range email.To {
+		to = append(to, a.String())
+	}
+	if want := "ann@example.com, Bob <bob@example.com>, carol@example.com"; strings.Join(to, ", ") != want {
+		t.Errorf("To = %q, want %q", strings.Join(to, ", "), want)
+	}
+	if len(email.CC) != 1 || email.CC[0].Email != "bob@example.com" {
+		t.Errorf("CC = %v, want bob@example.com", email.CC)
+	}
+}
+
+func TestParseAddresses_QuotedNames(t *testing.T) {
+	doe := domain.Address{Name: "Doe, John", Email: "john@example.com"}
+	jose := domain.Address{Name: "José", Email: "jose@example.com"}
+	quoted := domain.Address{Name: `Bob "B" Smith`, Email: "bob@example.com"}
+
+	value := strings.Join([]string{doe.Display(), jose.Display(), quoted.Display(), "ann@example.com"}, ", ")
+	got := parseAddresses(value, nil)
+	want := []domain.Address{doe, jose, quoted, {Email: "ann@example.com"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseAddresses(%q) = %+v, want %+v", value, got, want)
+	}
+}
+
+func TestComposerBuildEmail_DefaultRecipients(t *testing.T) {
+	c := newComposer()
+	c.defaultCC = []domain.Address{{Email: "shared@example.com"}}
+	c.defaultBCC = []domain.Address{{Email: "me@example.com"}}
+	c.Reply(&domain.Email{From: domain.Address{Email: "shared@example.com"}, Subject: "Hi"}, false)
+
+	email := c.BuildEmail()
+	if len(email.CC) != 0 {
+		t.Errorf("CC = %v, want none: the reply already goes to the default CC", email.CC)
+	}
+	if len(email.BCC) != 1 || email.BCC[0].Email != "me@example.com" {
+		t.Errorf("BCC = %v, want [me@example.com]", email.BCC)
+	}
+}
@@ -0,0 +1,68 @@
+package tui
+
+import "strings"
+
+// paneNames maps the pane names accepted by ui.focus_order to panes.
+var paneNames = map[string]pane{
+	"sidebar": paneSidebar,
+	"list":    paneList,
+	"reader":  paneReader,
+}
+
+// defaultFocusRing cycles through every pane, left to right and top down.
+var defaultFocusRing = focusRing{paneSidebar, paneList, paneReader}
+
+// focusRing is the order Tab moves focus through the panes. Panes that are
+// not shown, such as the reader before a message is opened, are skipped.
+type focusRing []pane
+
+// parseFocusRing builds a ring from pane names, ignoring unknown names and
+// repeats. It falls back to defaultFocusRing when no valid name is given.
+func parseFocusRing(names []string) focusRing {
+	var ring focusRing
+	seen := make(map[pane]bool)
+	for _, name := range names {
+		p, ok := paneNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok || seen[p] {
+			continue
+		}
+		seen[p] = true
+		ring = append(ring, p)
+	}
+	if len(ring) == 0 {
+		return defaultFocusRing
+	}
+	return ring
+}
+
+// next returns the visible pane after current in the ring, or before it
+// when reverse is set. A current pane outside the ring moves to the first
+// visible one; with nothing else visible, current is kept.
+func (r focusRing) next(current pane, visible func(pane) bool, reverse bool) pane {
+	start := -1
+	for i, p := range r {
+		if p == current {
+			start = i
+			break
+		}
+	}
+
+	step := 1
+	if reverse {
+		step = len(r) - 1
+	}
+	i := start
+	if start < 0 {
+		i = len(r) - 1
+		if reverse {
+			i = 0
+		}
+	}
+	for range r {
+		i = (i + step) % len(r)
+		if r[i] != current && visible(r[i]) {
+			return r[i]
+		}
+	}
+	return current
+}
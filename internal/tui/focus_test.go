@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lu-zhengda/termail/internal/config"
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+// cycle presses Tab (or Shift+Tab) n times and returns the panes focused.
+func cycle(m model, n int, reverse bool) []pane {
+	msg := tea.KeyMsg{Type: tea.KeyTab}
+	if reverse {
+		msg = tea.KeyMsg{Type: tea.KeyShiftTab}
+	}
+	var got []pane
+	for range n {
+		updated, _ := m.Update(msg)
+		m = updated.(model)
+		got = append(got, m.activePane)
+	}
+	return got
+}
+
+func TestFocusCycle(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+	m := NewModel(cfg, nil, nil, "a@example.com", nil, nil)
+
+	// Without the reader, Tab alternates between the sidebar and the list.
+	if got, want := cycle(m, 3, false), []pane{paneSidebar, paneList, paneSidebar}; !slices.Equal(got, want) {
+		t.Errorf("Tab without reader = %v, want %v", got, want)
+	}
+
+	m.reader.ShowEmail(&domain.Email{ID: "e1", Subject: "Hello"}, "")
+	if got, want := cycle(m, 3, false), []pane{paneReader, paneSidebar, paneList}; !slices.Equal(got, want) {
+		t.Errorf("Tab with reader = %v, want %v", got, want)
+	}
+	if got, want := cycle(m, 3, true), []pane{paneSidebar, paneReader, paneList}; !slices.Equal(got, want) {
+		t.Errorf("Shift+Tab with reader = %v, want %v", got, want)
+	}
+
+	cfg.UI.FocusOrder = []string{"list", "reader"}
+	m = NewModel(cfg, nil, nil, "a@example.com", nil, nil)
+	m.reader.ShowEmail(&domain.Email{ID: "e1", Subject: "Hello"}, "")
+	if got, want := cycle(m, 3, false), []pane{paneReader, paneList, paneReader}; !slices.Equal(got, want) {
+		t.Errorf("Tab with focus_order [list reader] = %v, want %v", got, want)
+	}
+}
+
+func TestParseFocusRing(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  focusRing
+	}{
+		{nil, defaultFocusRing},
+		{[]string{"Reader", " list "}, focusRing{paneReader, paneList}},
+		{[]string{"list", "bogus", "list"}, focusRing{paneList}},
+		{[]string{"bogus"}, defaultFocusRing},
+	}
+	for _, tt := range tests {
+		if got := parseFocusRing(tt.names); !slices.Equal(got, tt.want) {
+			t.Errorf("parseFocusRing(%q) = %v, want %v", tt.names, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// composerHelpKeys describes the composer's fixed keys for the help overlay.
+var composerHelpKeys = []key.Binding{
+	key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "next field/complete")),
+	key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "send")),
+	key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "cancel")),
+}
+
+// helpGroup is a titled set of keybindings shown together in the overlay.
+type helpGroup struct {
+	title    string
+	bindings []key.Binding
+}
+
+// helpGroups returns the keybindings of km grouped by the context they
+// apply in.
+func helpGroups(km keyMap) []helpGroup {
+	return []helpGroup{
+		{"Global", []key.Binding{km.Compose, km.Search, km.Tab, km.BackTab, km.Toggle, km.Undo, km.SwitchAccount, km.NextAccount, km.Help, km.Quit}},
+		{"Sidebar", []key.Binding{km.Up, km.Down, km.Enter, km.Expand, km.Collapse, km.Open}},
+		{"List", []key.Binding{km.Up, km.Down, km.Top, km.Bottom, km.HalfPageDown, km.HalfPageUp, km.Enter, km.Select, km.Archive, km.Delete, km.Trash, km.Star, km.Unread, km.Spam, km.Flag, km.Snooze, km.Label, km.FromSender, km.ExpandAll, km.CollapseAll}},
+		{"Reader", []key.Binding{km.Up, km.Down, km.HalfPageDown, km.HalfPageUp, km.NextMessage, km.PrevMessage, km.Back, km.Reply, km.ReplyAll, km.Forward, km.Archive, km.Delete, km.Trash, km.Star, km.Unread, km.Spam, km.Flag, km.Snooze, km.Label, km.FromSender, km.Unsubscribe, km.Quotes, km.Headers, km.RemoteContent, km.BodyView, km.RefreshThread, km.FullBody}},
+		{"Composer", composerHelpKeys},
+	}
+}
+
+// helpModel is a Bubble Tea sub-model for the keybinding help overlay.
+type helpModel struct {
+	width   int
+	height  int
+	visible bool
+
+	styles styles
+}
+
+func newHelp() helpModel {
+	return helpModel{styles: defaultStyles()}
+}
+
+// Update closes the overlay on esc or the help key; other keys are ignored.
+func (h helpModel) Update(msg tea.Msg) (helpModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if key.Matches(msg, keys.Back) || key.Matches(msg, keys.Help) {
+			h.Close()
+		}
+	}
+	return h, nil
+}
+
+func (h helpModel) View() string {
+	if !h.visible {
+		return ""
+	}
+
+	groups := helpGroups(keys)
+	half := (len(groups) + 1) / 2
+	colWidth := (h.width - 6) / 2
+	if colWidth < 20 {
+		colWidth = 20
+	}
+
+	left := lipgloss.NewStyle().Width(colWidth).Render(renderHelpGroups(h.styles, groups[:half]))
+	right := lipgloss.NewStyle().Width(colWidth).Render(renderHelpGroups(h.styles, groups[half:]))
+	content := lipgloss.JoinHorizontal(lipgloss.Top, left, "  ", right)
+	content += "\n\n" + h.styles.mutedText.Render(fmt.Sprintf("%s/%s: close", keys.Back.Help().Key, keys.Help.Help().Key))
+
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(h.styles.theme.Primary).
+		Padding(0, 1).
+		Width(h.width - 2)
+
+	header := h.styles.title.Render(" Keybindings ")
+	return header + "\n" + boxStyle.Render(content)
+}
+
+// renderHelpGroups renders each group's title followed by one line per
+// enabled binding.
+func renderHelpGroups(st styles, groups []helpGroup) string {
+	var b strings.Builder
+	for i, g := range groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(st.title.Render(g.title) + "\n")
+		for _, binding := range g.bindings {
+			if !binding.Enabled() {
+				continue
+			}
+			h := binding.Help()
+			b.WriteString(fmt.Sprintf("  %s %s\n", st.star.Render(fmt.Sprintf("%-8s", h.Key)), h.Desc))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Open shows the overlay.
+func (h *helpModel) Open() {
+	h.visible = true
+}
+
+// Close hides the overlay.
+func (h *helpModel) Close() {
+	h.visible = false
+}
+
+// SetSize updates the available dimensions for the overlay.
+func (h *helpModel) SetSize(w, height int) {
+	h.width = w
+	h.height = height
+}
+
+// IsVisible reports whether the overlay is currently displayed.
+func (h helpModel) IsVisible() bool {
+	return h.visible
+}
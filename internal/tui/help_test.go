@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestHelp_ViewListsGroupsAndBindings(t *testing.T) {
+	h := newHelp()
+	h.SetSize(100, 40)
+	h.Open()
+
+	view := h.View()
+	for _, want := range []string{"Global", "List", "Reader", "Composer", "compose", "reply all", "ctrl+s"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("help view missing %q", want)
+		}
+	}
+}
+
+func TestHelpGroups_SkipsDisabledBindings(t *testing.T) {
+	km := keys
+	km.Unsubscribe = key.NewBinding(key.WithKeys("U"), key.WithHelp("U", "unsubscribe"), key.WithDisabled())
+
+	out := renderHelpGroups(defaultStyles(), helpGroups(km))
+	if strings.Contains(out, "unsubscribe") {
+		t.Error("disabled binding should not be listed")
+	}
+	if !strings.Contains(out, "reply") {
+		t.Error("enabled bindings should be listed")
+	}
+}
+
+func TestHelp_DismissWithEsc(t *testing.T) {
+	h := newHelp()
+	h.Open()
+
+	h, _ = h.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	if !h.IsVisible() {
+		t.Fatal("other keys should not close the help overlay")
+	}
+
+	h, _ = h.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if h.IsVisible() {
+		t.Error("esc should close the help overlay")
+	}
+}
@@ -21,9 +21,29 @@ type threadSelectedMsg struct {
 	threadID string
 }
 
+// threadExpandMsg requests loading a thread's messages so they can be
+// listed inline under its row.
+type threadExpandMsg struct {
+	threadID string
+}
+
+// threadStarMsg requests starring or unstarring a whole thread.
+type threadStarMsg struct {
+	threadID string
+	star     bool
+}
+
+// flagMsg requests cycling the local flag on an email, or on a whole thread
+// when threadID is set.
+type flagMsg struct {
+	emailID  string
+	threadID string
+}
+
+// emailActionMsg requests an action on one or more emails.
 type emailActionMsg struct {
-	emailID string
-	action  string
+	emailIDs []string
+	action   string
 }
 
 // inboxModel is a Bubble Tea sub-model that displays the email or thread list.
@@ -37,11 +57,67 @@ type inboxModel struct {
 	width       int
 	height      int
 	focused     bool
+
+	// enterExpands makes Enter on a multi-message thread list its messages
+	// inline instead of opening the thread. expanded is that thread while
+	// it is open, and subCursor indexes its Messages.
+	enterExpands bool
+	expanded     *domain.Thread
+	subCursor    int
+
+	// selected holds the IDs (thread IDs in thread view, email IDs in flat
+	// view) of rows marked for a bulk action.
+	selected map[string]bool
+
+	// comfortable shows a snippet line under every row. It is a global
+	// preference that survives label, view and reload changes.
+	comfortable bool
+
+	// fromWidth is the preferred sender column width, absoluteDates shows
+	// dates instead of ages, and showLabels adds a chip per user label
+	// after the subject, looked up in labels by ID.
+	fromWidth     int
+	absoluteDates bool
+	showLabels    bool
+	labels        map[string]domain.Label
+
+	// groupByDate puts a date section header (see dateSection) above the
+	// first row of each section. Headers are not rows: the cursor only
+	// ever rests on mail.
+	groupByDate bool
+
+	// pendingG is set by a first "g" so a second one jumps to the top.
+	// Any other key clears it.
+	pendingG bool
+
+	styles styles
 }
 
+const (
+	// defaultFromWidth is the sender column width when none is configured.
+	defaultFromWidth = 18
+	// minFromWidth and minSubjectWidth bound how far the sender column
+	// shrinks on narrow terminals before the subject gives way.
+	minFromWidth    = 6
+	minSubjectWidth = 10
+	// minInlineSnippetWidth is the least room worth filling with a flat
+	// row's snippet after its subject.
+	minInlineSnippetWidth = 10
+)
+
 func newInbox() inboxModel {
 	return inboxModel{
-		viewMode: viewThread,
+		viewMode:  viewThread,
+		fromWidth: defaultFromWidth,
+		styles:    defaultStyles(),
+	}
+}
+
+// SetLabels records the account's labels for rendering label chips.
+func (m *inboxModel) SetLabels(labels []domain.Label) {
+	m.labels = make(map[string]domain.Label, len(labels))
+	for _, l := range labels {
+		m.labels[l.ID] = l
 	}
 }
 
@@ -52,6 +128,52 @@ func (m inboxModel) Update(msg tea.Msg) (inboxModel, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		pendingG := m.pendingG
+		m.pendingG = false
+		if key.Matches(msg, keys.Top) && !pendingG {
+			m.pendingG = true
+			return m, nil
+		}
+
+		if m.expanded != nil {
+			switch {
+			case key.Matches(msg, keys.Top):
+				m.subCursor = 0
+				m.adjustScroll()
+				return m, nil
+			case key.Matches(msg, keys.Bottom):
+				m.subCursor = len(m.expanded.Messages) - 1
+				m.adjustScroll()
+				return m, nil
+			case key.Matches(msg, keys.HalfPageDown):
+				m.subCursor = min(m.subCursor+m.halfPage(), len(m.expanded.Messages)-1)
+				m.adjustScroll()
+				return m, nil
+			case key.Matches(msg, keys.HalfPageUp):
+				m.subCursor = max(m.subCursor-m.halfPage(), 0)
+				m.adjustScroll()
+				return m, nil
+			case key.Matches(msg, keys.Up):
+				if m.subCursor > 0 {
+					m.subCursor--
+				}
+				m.adjustScroll()
+				return m, nil
+			case key.Matches(msg, keys.Down):
+				if m.subCursor < len(m.expanded.Messages)-1 {
+					m.subCursor++
+				}
+				m.adjustScroll()
+				return m, nil
+			case key.Matches(msg, keys.Enter):
+				id := m.expanded.Messages[m.subCursor].ID
+				return m, func() tea.Msg { return emailSelectedMsg{emailID: id} }
+			case key.Matches(msg, keys.Back):
+				m.Collapse()
+				return m, nil
+			}
+		}
+
 		switch {
 		case key.Matches(msg, keys.Up):
 			if m.cursor > 0 {
@@ -65,20 +187,65 @@ func (m inboxModel) Update(msg tea.Msg) (inboxModel, tea.Cmd) {
 				m.adjustScroll()
 			}
 
+		case key.Matches(msg, keys.Top):
+			m.moveCursor(0)
+
+		case key.Matches(msg, keys.Bottom):
+			m.moveCursor(m.itemCount() - 1)
+
+		case key.Matches(msg, keys.HalfPageDown):
+			m.moveCursor(m.cursor + m.halfPage())
+
+		case key.Matches(msg, keys.HalfPageUp):
+			m.moveCursor(m.cursor - m.halfPage())
+
 		case key.Matches(msg, keys.Enter):
 			return m, m.selectItem()
 
+		case key.Matches(msg, keys.Select):
+			m.toggleSelected()
+
+		case key.Matches(msg, keys.Back):
+			m.ClearSelection()
+
 		case key.Matches(msg, keys.Archive):
 			return m, m.actionCmd("archive")
 
 		case key.Matches(msg, keys.Delete):
 			return m, m.actionCmd("delete")
 
+		case key.Matches(msg, keys.Trash):
+			return m, m.actionCmd("trash")
+
 		case key.Matches(msg, keys.Star):
-			return m, m.actionCmd("star")
+			if m.viewMode == viewThread {
+				return m, m.threadStarCmd()
+			}
+			return m, m.starCmd()
 
 		case key.Matches(msg, keys.Unread):
 			return m, m.actionCmd("unread")
+
+		case key.Matches(msg, keys.Spam):
+			return m, m.actionCmd("spam")
+
+		case key.Matches(msg, keys.Flag):
+			return m, m.flagCmd()
+
+		case key.Matches(msg, keys.Snooze):
+			return m, m.snoozeCmd()
+
+		case key.Matches(msg, keys.Label):
+			return m, m.labelPickerCmd()
+
+		case key.Matches(msg, keys.FromSender):
+			return m, m.senderFilterCmd()
+
+		case key.Matches(msg, keys.ExpandAll):
+			m.SetComfortable(true)
+
+		case key.Matches(msg, keys.CollapseAll):
+			m.SetComfortable(false)
 		}
 	}
 
@@ -90,30 +257,43 @@ func (m inboxModel) View() string {
 		return ""
 	}
 
-	visible := m.visibleRows()
 	count := m.itemCount()
 	if count == 0 {
-		return mutedTextStyle.Render("No messages")
-	}
-
-	var b strings.Builder
-	end := m.offset + visible
-	if end > count {
-		end = count
+		return m.styles.mutedText.Render("No messages")
 	}
 
-	for i := m.offset; i < end; i++ {
-		if i > m.offset {
-			b.WriteByte('\n')
+	now := time.Now()
+	var lines []string
+	for i := m.offset; i < count && len(lines) < m.height; i++ {
+		if section, ok := m.sectionHeader(i, now); ok {
+			lines = append(lines, m.styles.title.Render(section))
 		}
-		line := m.renderRow(i)
-		if i == m.cursor && m.focused {
-			line = selectedStyle.Width(m.width).Render(line)
+		row := []string{m.renderRow(i)}
+		if m.comfortable {
+			row = append(row, m.renderSnippetRow(i))
 		}
-		b.WriteString(line)
+		for _, line := range row {
+			if i == m.cursor && m.focused && m.expanded == nil {
+				line = m.styles.selected.Width(m.width).Render(line)
+			}
+			lines = append(lines, line)
+		}
+
+		if i == m.cursor && m.expanded != nil {
+			for j := range m.expanded.Messages {
+				line := m.renderExpandedRow(j)
+				if j == m.subCursor && m.focused {
+					line = m.styles.selected.Width(m.width).Render(line)
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	if len(lines) > m.height {
+		lines = lines[:m.height]
 	}
 
-	return b.String()
+	return strings.Join(lines, "\n")
 }
 
 // SetEmails updates the email list for flat view.
@@ -122,10 +302,37 @@ func (m *inboxModel) SetEmails(emails []domain.Email) {
 	m.clampCursor()
 }
 
-// SetThreads updates the thread list for thread view.
+// SetThreads updates the thread list for thread view. An expanded thread
+// stays open only while it remains under the cursor.
 func (m *inboxModel) SetThreads(threads []domain.Thread) {
 	m.threads = threads
 	m.clampCursor()
+	if m.expanded != nil && m.SelectedThreadID() != m.expanded.ID {
+		m.Collapse()
+	}
+}
+
+// Expand lists thread's messages inline under its row, provided it is still
+// the thread under the cursor.
+func (m *inboxModel) Expand(thread *domain.Thread) {
+	if thread == nil || len(thread.Messages) == 0 || m.SelectedThreadID() != thread.ID {
+		return
+	}
+	m.expanded = thread
+	m.subCursor = 0
+	m.adjustScroll()
+}
+
+// Collapse closes the inline message list of an expanded thread.
+func (m *inboxModel) Collapse() {
+	m.expanded = nil
+	m.subCursor = 0
+}
+
+// SetComfortable shows or hides the snippet line under every row.
+func (m *inboxModel) SetComfortable(on bool) {
+	m.comfortable = on
+	m.adjustScroll()
 }
 
 // SetSize updates the dimensions available for rendering.
@@ -140,6 +347,51 @@ func (m *inboxModel) SetViewMode(vm viewMode) {
 	m.viewMode = vm
 	m.cursor = 0
 	m.offset = 0
+	m.Collapse()
+	m.ClearSelection()
+}
+
+// ClearSelection unmarks every selected row.
+func (m *inboxModel) ClearSelection() {
+	m.selected = nil
+}
+
+// toggleSelected marks or unmarks the row under the cursor and moves down.
+func (m *inboxModel) toggleSelected() {
+	id := m.itemID(m.cursor)
+	if id == "" {
+		return
+	}
+	if m.selected == nil {
+		m.selected = make(map[string]bool)
+	}
+	if m.selected[id] {
+		delete(m.selected, id)
+	} else {
+		m.selected[id] = true
+	}
+	if m.cursor < m.itemCount()-1 {
+		m.cursor++
+		m.adjustScroll()
+	}
+}
+
+// targets returns the indexes of the rows an action applies to: every
+// selected row in list order, or the cursor row when nothing is selected.
+func (m inboxModel) targets() []int {
+	if len(m.selected) == 0 {
+		if m.cursor < m.itemCount() {
+			return []int{m.cursor}
+		}
+		return nil
+	}
+	var idx []int
+	for i := range m.itemCount() {
+		if m.selected[m.itemID(i)] {
+			idx = append(idx, i)
+		}
+	}
+	return idx
 }
 
 // SelectedEmailID returns the ID of the currently highlighted email (flat view).
@@ -160,6 +412,20 @@ func (m inboxModel) SelectedThreadID() string {
 
 // --- internal helpers ---
 
+// itemID returns the thread or email ID of row idx.
+func (m inboxModel) itemID(idx int) string {
+	if m.viewMode == viewThread {
+		if idx < len(m.threads) {
+			return m.threads[idx].ID
+		}
+		return ""
+	}
+	if idx < len(m.emails) {
+		return m.emails[idx].ID
+	}
+	return ""
+}
+
 func (m inboxModel) itemCount() int {
 	if m.viewMode == viewThread {
 		return len(m.threads)
@@ -167,21 +433,96 @@ func (m inboxModel) itemCount() int {
 	return len(m.emails)
 }
 
+// rowHeight returns the number of lines each row occupies.
+func (m inboxModel) rowHeight() int {
+	if m.comfortable {
+		return 2
+	}
+	return 1
+}
+
+// visibleRows returns how many rows fit in the list's height.
 func (m inboxModel) visibleRows() int {
-	if m.height < 1 {
-		return 1
+	if rows := m.height / m.rowHeight(); rows > 0 {
+		return rows
 	}
-	return m.height
+	return 1
+}
+
+// halfPage returns how many rows ctrl+d and ctrl+u move the cursor.
+func (m inboxModel) halfPage() int {
+	return max(m.visibleRows()/2, 1)
+}
+
+// moveCursor puts the cursor on row idx, clamped to the list, and scrolls
+// it into view.
+func (m *inboxModel) moveCursor(idx int) {
+	m.cursor = max(min(idx, m.itemCount()-1), 0)
+	m.adjustScroll()
 }
 
 func (m *inboxModel) adjustScroll() {
 	visible := m.visibleRows()
+	// The highlighted line sits below the cursor row when a thread is
+	// expanded; its one-line messages are counted in whole rows.
+	below := 0
+	if m.expanded != nil {
+		h := m.rowHeight()
+		below = (m.subCursor + h) / h
+	}
 	if m.cursor < m.offset {
 		m.offset = m.cursor
 	}
-	if m.cursor >= m.offset+visible {
-		m.offset = m.cursor - visible + 1
+	if m.cursor+below >= m.offset+visible {
+		m.offset = min(m.cursor, m.cursor+below-visible+1)
+	}
+	// Section headers take lines of their own; scroll further until the
+	// highlighted line fits under them.
+	if m.groupByDate {
+		now := time.Now()
+		for m.offset < m.cursor && m.linesThrough(m.cursor, now) > m.height {
+			m.offset++
+		}
+	}
+}
+
+// linesThrough returns how many lines the list shows from the first visible
+// row through row idx, counting section headers and, when idx is the
+// cursor row of an expanded thread, its messages up to the sub-cursor.
+func (m inboxModel) linesThrough(idx int, now time.Time) int {
+	n := 0
+	for i := m.offset; i <= idx; i++ {
+		if _, ok := m.sectionHeader(i, now); ok {
+			n++
+		}
+		n += m.rowHeight()
+	}
+	if m.expanded != nil && idx == m.cursor {
+		n += m.subCursor + 1
+	}
+	return n
+}
+
+// sectionHeader returns the date section of row idx and whether a header
+// for it goes above the row: at the top of the list view and wherever the
+// section changes.
+func (m inboxModel) sectionHeader(idx int, now time.Time) (string, bool) {
+	if !m.groupByDate {
+		return "", false
+	}
+	section := dateSection(m.itemDate(idx), now)
+	if idx == m.offset {
+		return section, true
+	}
+	return section, section != dateSection(m.itemDate(idx-1), now)
+}
+
+// itemDate returns the date row idx is sorted by.
+func (m inboxModel) itemDate(idx int) time.Time {
+	if m.viewMode == viewThread {
+		return m.threads[idx].LastDate
 	}
+	return m.emails[idx].Date
 }
 
 func (m *inboxModel) clampCursor() {
@@ -203,6 +544,11 @@ func (m inboxModel) selectItem() tea.Cmd {
 		if id == "" {
 			return nil
 		}
+		if m.enterExpands && m.threads[m.cursor].MessageCount() > 1 {
+			return func() tea.Msg {
+				return threadExpandMsg{threadID: id}
+			}
+		}
 		return func() tea.Msg {
 			return threadSelectedMsg{threadID: id}
 		}
@@ -216,31 +562,140 @@ func (m inboxModel) selectItem() tea.Cmd {
 	}
 }
 
+// actionCmd applies action to the selected rows, or the cursor row when
+// nothing is selected.
 func (m inboxModel) actionCmd(action string) tea.Cmd {
-	var emailID string
-	if m.viewMode == viewThread {
-		if len(m.threads) > 0 && m.cursor < len(m.threads) {
-			msgs := m.threads[m.cursor].Messages
+	ids := m.targetEmailIDs()
+	if len(ids) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		return emailActionMsg{emailIDs: ids, action: action}
+	}
+}
+
+// labelPickerCmd opens the move-to-label picker for the selected rows, or
+// the cursor row when nothing is selected.
+func (m inboxModel) labelPickerCmd() tea.Cmd {
+	ids := m.targetEmailIDs()
+	if len(ids) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		return labelPickerMsg{emailIDs: ids}
+	}
+}
+
+// targetEmailIDs returns the emails an action applies to. In thread view a
+// thread is acted on through its latest message.
+func (m inboxModel) targetEmailIDs() []string {
+	var ids []string
+	for _, i := range m.targets() {
+		if m.viewMode == viewThread {
+			msgs := m.threads[i].Messages
 			if len(msgs) > 0 {
-				emailID = msgs[len(msgs)-1].ID
+				ids = append(ids, msgs[len(msgs)-1].ID)
 			}
+		} else {
+			ids = append(ids, m.emails[i].ID)
 		}
+	}
+	return ids
+}
+
+// senderFilterCmd filters the list to all mail from the sender of the
+// message under the cursor: in thread view, the thread's first sender.
+func (m inboxModel) senderFilterCmd() tea.Cmd {
+	var addr string
+	switch {
+	case m.expanded != nil:
+		addr = m.expanded.Messages[m.subCursor].From.Email
+	case m.viewMode == viewThread && m.cursor < len(m.threads):
+		addr = m.threads[m.cursor].FromAddress.Email
+	case m.viewMode == viewFlat && m.cursor < len(m.emails):
+		addr = m.emails[m.cursor].From.Email
+	}
+	if addr == "" {
+		return nil
+	}
+	return func() tea.Msg { return senderFilterMsg{addr: addr} }
+}
+
+// flagCmd cycles the local flag on the selected email or thread.
+func (m inboxModel) flagCmd() tea.Cmd {
+	var msg flagMsg
+	if m.viewMode == viewThread {
+		msg.threadID = m.SelectedThreadID()
 	} else {
-		emailID = m.SelectedEmailID()
+		msg.emailID = m.SelectedEmailID()
 	}
-	if emailID == "" {
+	if msg.emailID == "" && msg.threadID == "" {
 		return nil
 	}
-	return func() tea.Msg {
-		return emailActionMsg{emailID: emailID, action: action}
+	return func() tea.Msg { return msg }
+}
+
+// snoozeCmd opens the snooze prompt for the selected email or thread.
+func (m inboxModel) snoozeCmd() tea.Cmd {
+	var msg snoozeMsg
+	if m.viewMode == viewThread {
+		msg.threadID = m.SelectedThreadID()
+	} else {
+		msg.emailID = m.SelectedEmailID()
+	}
+	if msg.emailID == "" && msg.threadID == "" {
+		return nil
+	}
+	return func() tea.Msg { return msg }
+}
+
+// starCmd toggles the star on the target emails. Several selected emails
+// are all starred unless every one already is.
+func (m inboxModel) starCmd() tea.Cmd {
+	action := "unstar"
+	for _, i := range m.targets() {
+		if !m.emails[i].IsStarred {
+			action = "star"
+		}
 	}
+	return m.actionCmd(action)
+}
+
+// threadStarCmd toggles the star on the target threads as a whole. Several
+// selected threads are all starred unless every one already is.
+func (m inboxModel) threadStarCmd() tea.Cmd {
+	targets := m.targets()
+	if len(targets) == 0 {
+		return nil
+	}
+	star := false
+	for _, i := range targets {
+		if !m.threads[i].IsStarred() {
+			star = true
+		}
+	}
+	cmds := make([]tea.Cmd, 0, len(targets))
+	for _, i := range targets {
+		msg := threadStarMsg{threadID: m.threads[i].ID, star: star}
+		cmds = append(cmds, func() tea.Msg { return msg })
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m inboxModel) renderRow(idx int) string {
+	marker := ""
+	if len(m.selected) > 0 {
+		// Reserve a column for the selection marker.
+		m.width -= 2
+		marker = "  "
+		if m.selected[m.itemID(idx)] {
+			marker = m.styles.flag.Render("● ")
+		}
+	}
 	if m.viewMode == viewThread {
-		return m.renderThreadRow(idx)
+		return marker + m.renderThreadRow(idx)
 	}
-	return m.renderEmailRow(idx)
+	return marker + m.renderEmailRow(idx)
 }
 
 func (m inboxModel) renderEmailRow(idx int) string {
@@ -251,30 +706,31 @@ func (m inboxModel) renderEmailRow(idx int) string {
 
 	star := "  "
 	if e.IsStarred {
-		star = starStyle.Render("★ ")
+		star = m.styles.star.Render("★ ")
 	}
+	flags, flagsWidth := flagTags(m.styles, e.Flags)
 
 	from := addressDisplayName(e.From)
-	date := relativeDate(e.Date)
+	date := m.formatDate(e.Date)
 
-	fromWidth := 18
 	dateWidth := len(date)
-	subjectWidth := m.width - fromWidth - dateWidth - 6 // star(2) + two "  " gaps(4)
-	if subjectWidth < 10 {
-		subjectWidth = 10
-	}
+	fromWidth, subjectWidth := m.columnWidths(dateWidth + flagsWidth + 6) // star(2) + two "  " gaps(4)
 
 	from = truncate(from, fromWidth)
-	subject := truncate(e.Subject, subjectWidth)
 
 	fromCol := lipgloss.NewStyle().Width(fromWidth).Render(from)
-	subjectCol := lipgloss.NewStyle().Width(subjectWidth).Render(subject)
-	dateCol := mutedTextStyle.Width(dateWidth).Render(date)
+	// The comfortable density shows the snippet on its own line instead.
+	snippet := ""
+	if !m.comfortable {
+		snippet = e.PlainSnippet()
+	}
+	subjectCol := m.renderSubject(e.Subject, snippet, e.Labels, subjectWidth)
+	dateCol := m.styles.mutedText.Width(dateWidth).Render(date)
 
-	line := star + fromCol + "  " + subjectCol + "  " + dateCol
+	line := star + fromCol + "  " + flags + subjectCol + "  " + dateCol
 
 	if !e.IsRead {
-		line = unreadStyle.Render(line)
+		line = m.styles.unread.Render(line)
 	}
 
 	return line
@@ -286,50 +742,157 @@ func (m inboxModel) renderThreadRow(idx int) string {
 	}
 	t := m.threads[idx]
 
-	starred := false
-	for i := range t.Messages {
-		if t.Messages[i].IsStarred {
-			starred = true
-			break
-		}
-	}
-
 	star := "  "
-	if starred {
-		star = starStyle.Render("★ ")
+	if t.IsStarred() {
+		star = m.styles.star.Render("★ ")
 	}
+	flags, flagsWidth := flagTags(m.styles, t.Flags)
 
 	from := threadFromName(t)
 	count := fmt.Sprintf("(%d)", t.MessageCount())
-	date := relativeDate(t.LastDate)
+	date := m.formatDate(t.LastDate)
 
-	fromWidth := 18
 	countWidth := len(count) + 1 // +1 for leading space
 	dateWidth := len(date)
-	subjectWidth := m.width - fromWidth - countWidth - dateWidth - 6 // star(2) + two "  " gaps(4)
-	if subjectWidth < 10 {
-		subjectWidth = 10
-	}
+	fromWidth, subjectWidth := m.columnWidths(countWidth + dateWidth + flagsWidth + 6) // star(2) + two "  " gaps(4)
 
 	from = truncate(from, fromWidth)
-	subject := truncate(t.Subject, subjectWidth)
 
 	fromCol := lipgloss.NewStyle().Width(fromWidth).Render(from)
-	countCol := mutedTextStyle.Render(" " + count)
-	subjectCol := lipgloss.NewStyle().Width(subjectWidth).Render(subject)
-	dateCol := mutedTextStyle.Width(dateWidth).Render(date)
+	countCol := m.styles.mutedText.Render(" " + count)
+	subjectCol := m.renderSubject(t.Subject, "", t.Labels, subjectWidth)
+	dateCol := m.styles.mutedText.Width(dateWidth).Render(date)
 
-	line := star + fromCol + countCol + "  " + subjectCol + "  " + dateCol
+	line := star + fromCol + countCol + "  " + flags + subjectCol + "  " + dateCol
 
 	if t.IsUnread() {
-		line = unreadStyle.Render(line)
+		line = m.styles.unread.Render(line)
 	}
 
 	return line
 }
 
+// columnWidths splits what is left of the row after fixed columns of total
+// width fixed between the sender and subject columns. On narrow terminals
+// the sender column shrinks towards minFromWidth to keep minSubjectWidth
+// for the subject; neither width goes negative.
+func (m inboxModel) columnWidths(fixed int) (fromWidth, subjectWidth int) {
+	fromWidth = m.fromWidth
+	if fromWidth <= 0 {
+		fromWidth = defaultFromWidth
+	}
+	avail := max(m.width-fixed, 0)
+	if avail-fromWidth >= minSubjectWidth {
+		return fromWidth, avail - fromWidth
+	}
+	fromWidth = min(max(avail-minSubjectWidth, minFromWidth), fromWidth, avail)
+	return fromWidth, avail - fromWidth
+}
+
+// formatDate renders a row's date in the configured format.
+func (m inboxModel) formatDate(t time.Time) string {
+	if m.absoluteDates {
+		return absoluteDate(t)
+	}
+	return relativeDate(t)
+}
+
+// renderSubject renders the subject column at width, followed by chips for
+// labelIDs when showLabels is set and they fit after the subject. The
+// snippet, if any, fills what room is left between the subject and chips.
+func (m inboxModel) renderSubject(subject, snippet string, labelIDs []string, width int) string {
+	subject = truncate(subject, width)
+	chips := ""
+	if m.showLabels {
+		chips = m.labelChips(labelIDs, width-lipgloss.Width(subject))
+	}
+	snippet = strings.Join(strings.Fields(snippet), " ")
+	if room := width - lipgloss.Width(subject) - lipgloss.Width(chips) - 3; snippet != "" && room >= minInlineSnippetWidth {
+		subject += m.styles.mutedText.Render(" – " + truncate(snippet, room))
+	}
+	return lipgloss.NewStyle().Width(width).Render(subject + chips)
+}
+
+// labelChips renders the user labels among ids as colored chips, each
+// preceded by a space, stopping at the first that does not fit in room.
+func (m inboxModel) labelChips(ids []string, room int) string {
+	var b strings.Builder
+	for _, id := range ids {
+		l, ok := m.labels[id]
+		if !ok || l.Type != domain.LabelTypeUser {
+			continue
+		}
+		text := " " + l.Name + " "
+		w := lipgloss.Width(text) + 1
+		if w > room {
+			break
+		}
+		room -= w
+		st := m.styles.labelChip
+		if l.Color != "" {
+			st = st.Background(lipgloss.Color(l.Color))
+		}
+		b.WriteString(" " + st.Render(text))
+	}
+	return b.String()
+}
+
+// renderSnippetRow renders the preview line shown under row idx in the
+// comfortable density.
+func (m inboxModel) renderSnippetRow(idx int) string {
+	var snippet string
+	if m.viewMode == viewThread {
+		if idx < len(m.threads) {
+			snippet = m.threads[idx].Snippet
+		}
+	} else if idx < len(m.emails) {
+		snippet = m.emails[idx].Snippet
+	}
+	snippet = strings.Join(strings.Fields(snippet), " ")
+	return "    " + m.styles.mutedText.Render(truncate(snippet, m.width-4))
+}
+
+// renderExpandedRow renders message idx of the expanded thread as an
+// indented row beneath the thread.
+func (m inboxModel) renderExpandedRow(idx int) string {
+	e := m.expanded.Messages[idx]
+
+	branch := "├ "
+	if idx == len(m.expanded.Messages)-1 {
+		branch = "└ "
+	}
+	date := relativeDate(e.Date)
+
+	fromWidth := 16
+	dateWidth := len(date)
+	subjectWidth := m.width - fromWidth - dateWidth - 8 // indent(2) + branch(2) + two "  " gaps(4)
+	if subjectWidth < 10 {
+		subjectWidth = 10
+	}
+
+	fromCol := lipgloss.NewStyle().Width(fromWidth).Render(truncate(addressDisplayName(e.From), fromWidth))
+	subjectCol := lipgloss.NewStyle().Width(subjectWidth).Render(truncate(e.Subject, subjectWidth))
+	dateCol := m.styles.mutedText.Width(dateWidth).Render(date)
+
+	line := "  " + m.styles.mutedText.Render(branch) + fromCol + "  " + subjectCol + "  " + dateCol
+	if !e.IsRead {
+		line = m.styles.unread.Render(line)
+	}
+	return line
+}
+
 // --- utility functions ---
 
+// flagTags renders local flags as "[todo] " markers before the subject and
+// returns the rendered text with its display width.
+func flagTags(st styles, flags []string) (string, int) {
+	if len(flags) == 0 {
+		return "", 0
+	}
+	tag := "[" + strings.Join(flags, ",") + "] "
+	return st.flag.Render(tag), len(tag)
+}
+
 func addressDisplayName(addr domain.Address) string {
 	if addr.Name != "" {
 		return addr.Name
@@ -361,7 +924,46 @@ func truncate(s string, maxLen int) string {
 	return string(runes[:maxLen-1]) + "…"
 }
 
+// Date sections of a list grouped by date.
+const (
+	sectionToday     = "Today"
+	sectionYesterday = "Yesterday"
+	sectionLastWeek  = "Last 7 days"
+	sectionOlder     = "Older"
+)
+
+// dateSection returns the section t falls in relative to now, by local
+// calendar day. Dates in the future count as today, unknown ones as older.
+func dateSection(t, now time.Time) string {
+	if t.IsZero() {
+		return sectionOlder
+	}
+	now = now.Local()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch t = t.Local(); {
+	case !t.Before(today):
+		return sectionToday
+	case !t.Before(today.AddDate(0, 0, -1)):
+		return sectionYesterday
+	case !t.Before(today.AddDate(0, 0, -7)):
+		return sectionLastWeek
+	default:
+		return sectionOlder
+	}
+}
+
+// absoluteDate renders t as a local date and time.
+func absoluteDate(t time.Time) string {
+	if t.IsZero() {
+		return domain.UnknownDate
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
 func relativeDate(t time.Time) string {
+	if t.IsZero() {
+		return domain.UnknownDate
+	}
 	d := time.Since(t)
 	switch {
 	case d < time.Minute:
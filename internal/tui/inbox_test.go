@@ -0,0 +1,516 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+func TestRenderThreadRow_StarAggregation(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		thread domain.Thread
+		want   bool
+	}{
+		{
+			name:   "summary starred",
+			thread: domain.Thread{ID: "t1", Subject: "Hi", LastDate: now, TotalCount: 3, HasStarred: true},
+			want:   true,
+		},
+		{
+			name:   "summary not starred",
+			thread: domain.Thread{ID: "t2", Subject: "Hi", LastDate: now, TotalCount: 3},
+			want:   false,
+		},
+		{
+			name: "earlier message starred",
+			thread: domain.Thread{ID: "t3", Subject: "Hi", LastDate: now, Messages: []domain.Email{
+				{ID: "m1", IsStarred: true},
+				{ID: "m2"},
+			}},
+			want: true,
+		},
+		{
+			name: "no message starred",
+			thread: domain.Thread{ID: "t4", Subject: "Hi", LastDate: now, Messages: []domain.Email{
+				{ID: "m1"},
+				{ID: "m2"},
+			}},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newInbox()
+			m.width = 80
+			m.SetThreads([]domain.Thread{tt.thread})
+
+			row := m.renderThreadRow(0)
+			if got := strings.Contains(row, "★"); got != tt.want {
+				t.Errorf("row shows star = %v, want %v: %q", got, tt.want, row)
+			}
+		})
+	}
+}
+
+func TestInbox_ThreadStarTogglesWholeThread(t *testing.T) {
+	m := newInbox()
+	m.SetThreads([]domain.Thread{{ID: "t1", HasStarred: true}})
+
+	cmd := m.threadStarCmd()
+	if cmd == nil {
+		t.Fatal("threadStarCmd() returned nil")
+	}
+	msg, ok := cmd().(threadStarMsg)
+	if !ok {
+		t.Fatalf("threadStarCmd() produced %T, want threadStarMsg", cmd())
+	}
+	if msg.threadID != "t1" || msg.star {
+		t.Errorf("threadStarMsg = %+v, want unstar of t1", msg)
+	}
+}
+
+func TestInbox_StarToggles(t *testing.T) {
+	m := newInbox()
+	m.focused = true
+	m.SetViewMode(viewFlat)
+	m.SetSize(80, 10)
+	m.SetEmails([]domain.Email{{ID: "e1", IsStarred: true}, {ID: "e2"}})
+
+	star := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")}
+	_, cmd := m.Update(star)
+	if msg := cmd().(emailActionMsg); msg.action != "unstar" {
+		t.Errorf("star key on a starred email = %q, want unstar", msg.action)
+	}
+
+	// A mixed selection is starred as a whole.
+	m.selected = map[string]bool{"e1": true, "e2": true}
+	_, cmd = m.Update(star)
+	if msg := cmd().(emailActionMsg); msg.action != "star" || len(msg.emailIDs) != 2 {
+		t.Errorf("star key on a mixed selection = %+v, want star of both", msg)
+	}
+}
+
+func TestRenderEmailRow_FlagMarker(t *testing.T) {
+	m := newInbox()
+	m.SetViewMode(viewFlat)
+	m.SetSize(100, 10)
+	m.SetEmails([]domain.Email{
+		{ID: "m1", Subject: "Invoice", Date: time.Now(), Flags: []string{domain.FlagTodo}},
+		{ID: "m2", Subject: "Hello", Date: time.Now()},
+	})
+
+	if row := m.renderEmailRow(0); !strings.Contains(row, "[todo]") {
+		t.Errorf("flagged row = %q, want [todo] marker", row)
+	}
+	if row := m.renderEmailRow(1); strings.Contains(row, "[") {
+		t.Errorf("unflagged row = %q, want no marker", row)
+	}
+}
+
+func TestInbox_ColumnWidthsNarrowTerminal(t *testing.T) {
+	tests := []struct {
+		width, fixed int
+		wantFrom     int
+	}{
+		{width: 100, fixed: 20, wantFrom: 18},
+		{width: 40, fixed: 20, wantFrom: 10},
+		{width: 30, fixed: 20, wantFrom: 6},
+		{width: 22, fixed: 20, wantFrom: 2},
+		{width: 10, fixed: 20, wantFrom: 0},
+		{width: 0, fixed: 20, wantFrom: 0},
+	}
+	for _, tt := range tests {
+		m := newInbox()
+		m.width = tt.width
+		from, subject := m.columnWidths(tt.fixed)
+		if from != tt.wantFrom {
+			t.Errorf("width %d: from width = %d, want %d", tt.width, from, tt.wantFrom)
+		}
+		if from < 0 || subject < 0 {
+			t.Errorf("width %d: widths = %d, %d, want non-negative", tt.width, from, subject)
+		}
+		if avail := max(tt.width-tt.fixed, 0); from+subject != avail {
+			t.Errorf("width %d: from+subject = %d, want %d", tt.width, from+subject, avail)
+		}
+	}
+}
+
+func TestInbox_RowsRenderAtSmallWidths(t *testing.T) {
+	for _, width := range []int{0, 1, 5, 10, 20} {
+		m := newInbox()
+		m.showLabels = true
+		m.SetLabels([]domain.Label{{ID: "L1", Name: "Work", Type: domain.LabelTypeUser, Color: "#fb4c2f"}})
+		m.SetEmails([]domain.Email{{ID: "m1", From: domain.Address{Name: "Alice Example"}, Subject: "Quarterly report", Date: time.Now(), Labels: []string{"L1"}}})
+		m.SetThreads([]domain.Thread{{ID: "t1", Subject: "Quarterly report", LastDate: time.Now(), TotalCount: 2, Labels: []string{"L1"}}})
+		m.width = width
+
+		_ = m.renderEmailRow(0)
+		_ = m.renderThreadRow(0)
+	}
+}
+
+func TestRenderEmailRow_LabelChips(t *testing.T) {
+	m := newInbox()
+	m.SetViewMode(viewFlat)
+	m.SetSize(100, 10)
+	m.showLabels = true
+	m.SetLabels([]domain.Label{
+		{ID: "L1", Name: "Work", Type: domain.LabelTypeUser, Color: "#fb4c2f"},
+		{ID: domain.LabelInbox, Name: "INBOX", Type: domain.LabelTypeSystem},
+	})
+	m.SetEmails([]domain.Email{{ID: "m1", Subject: "Quarterly report", Date: time.Now(), Labels: []string{domain.LabelInbox, "L1"}}})
+
+	row := m.renderEmailRow(0)
+	if !strings.Contains(row, " Work ") {
+		t.Errorf("row = %q, want a Work chip", row)
+	}
+	if strings.Contains(row, " INBOX ") {
+		t.Errorf("row = %q, want no chip for system labels", row)
+	}
+
+	m.width = 40
+	if row := m.renderEmailRow(0); strings.Contains(row, "Work") {
+		t.Errorf("narrow row = %q, want the chip dropped", row)
+	}
+
+	m.width = 100
+	m.showLabels = false
+	if row := m.renderEmailRow(0); strings.Contains(row, "Work") {
+		t.Errorf("row = %q, want no chips when show_labels is off", row)
+	}
+}
+
+func TestRenderEmailRow_Snippet(t *testing.T) {
+	m := newInbox()
+	m.SetViewMode(viewFlat)
+	m.SetSize(100, 10)
+	m.SetEmails([]domain.Email{{ID: "m1", Subject: "Lunch", Snippet: "Are you free\non Friday?", Date: time.Now()}})
+
+	if row := m.renderEmailRow(0); !strings.Contains(row, "Lunch – Are you free on Friday?") {
+		t.Errorf("row = %q, want the snippet after the subject", row)
+	}
+
+	m.width = 40
+	if row := m.renderEmailRow(0); strings.Contains(row, "Are you") {
+		t.Errorf("narrow row = %q, want the snippet dropped", row)
+	}
+
+	m.width = 100
+	m.SetComfortable(true)
+	if row := m.renderEmailRow(0); strings.Contains(row, "Are you") {
+		t.Errorf("comfortable row = %q, want the snippet left to its own line", row)
+	}
+}
+
+func TestRenderEmailRow_AbsoluteDate(t *testing.T) {
+	date := time.Date(2024, 3, 5, 14, 7, 0, 0, time.Local)
+	m := newInbox()
+	m.SetViewMode(viewFlat)
+	m.SetSize(100, 10)
+	m.absoluteDates = true
+	m.SetEmails([]domain.Email{{ID: "m1", Subject: "Hi", Date: date}})
+
+	if row := m.renderEmailRow(0); !strings.Contains(row, "2024-03-05 14:07") {
+		t.Errorf("row = %q, want the absolute date", row)
+	}
+}
+
+func TestInbox_EnterExpandsThenOpensMessage(t *testing.T) {
+	now := time.Now()
+	m := newInbox()
+	m.focused = true
+	m.enterExpands = true
+	m.SetSize(80, 10)
+	m.SetThreads([]domain.Thread{
+		{ID: "single", Subject: "Solo", LastDate: now, TotalCount: 1},
+		{ID: "big", Subject: "Long thread", LastDate: now, TotalCount: 3},
+	})
+
+	// A single-message thread still opens directly.
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if _, ok := cmd().(threadSelectedMsg); !ok {
+		t.Fatal("enter on a single-message thread should open it")
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	msg, ok := cmd().(threadExpandMsg)
+	if !ok || msg.threadID != "big" {
+		t.Fatalf("enter on a long thread = %#v, want threadExpandMsg{big}", cmd())
+	}
+
+	m.Expand(&domain.Thread{ID: "big", Messages: []domain.Email{
+		{ID: "m1", Subject: "Long thread", From: domain.Address{Email: "a@example.com"}, Date: now},
+		{ID: "m2", Subject: "Re: Long thread", From: domain.Address{Email: "b@example.com"}, Date: now},
+		{ID: "m3", Subject: "Re: Long thread", From: domain.Address{Email: "c@example.com"}, Date: now},
+	}})
+	if view := m.View(); !strings.Contains(view, "b@example.com") || !strings.Contains(view, "└") {
+		t.Errorf("expanded view should list the thread's messages:\n%s", view)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown}) // clamped at the last message
+	if m.cursor != 1 {
+		t.Errorf("thread cursor moved to %d while expanded, want 1", m.cursor)
+	}
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if sel, ok := cmd().(emailSelectedMsg); !ok || sel.emailID != "m3" {
+		t.Errorf("enter on inline message = %#v, want emailSelectedMsg{m3}", cmd())
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.expanded != nil {
+		t.Error("esc should collapse the expanded thread")
+	}
+}
+
+func TestInbox_ExpandIgnoredWhenCursorMoved(t *testing.T) {
+	m := newInbox()
+	m.SetThreads([]domain.Thread{{ID: "a", TotalCount: 2}, {ID: "b", TotalCount: 2}})
+	m.Expand(&domain.Thread{ID: "b", Messages: []domain.Email{{ID: "m1"}, {ID: "m2"}}})
+	if m.expanded != nil {
+		t.Error("a thread no longer under the cursor should not expand")
+	}
+}
+
+func TestInbox_MultiSelectAction(t *testing.T) {
+	m := newInbox()
+	m.focused = true
+	m.SetViewMode(viewFlat)
+	m.SetSize(80, 10)
+	m.SetEmails([]domain.Email{{ID: "e1"}, {ID: "e2"}, {ID: "e3"}})
+
+	// Without a selection the action falls back to the cursor row.
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	if msg := cmd().(emailActionMsg); !slices.Equal(msg.emailIDs, []string{"e1"}) {
+		t.Errorf("unselected archive ids = %v, want [e1]", msg.emailIDs)
+	}
+
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+	m, _ = m.Update(space)                         // select e1, cursor -> e2
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown}) // cursor -> e3
+	m, _ = m.Update(space)                         // select e3
+	if !m.selected["e1"] || m.selected["e2"] || !m.selected["e3"] {
+		t.Fatalf("selected = %v, want e1 and e3", m.selected)
+	}
+	if !strings.Contains(m.renderRow(0), "●") || strings.Contains(m.renderRow(1), "●") {
+		t.Error("only selected rows should show the selection marker")
+	}
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	msg := cmd().(emailActionMsg)
+	if msg.action != "delete" || !slices.Equal(msg.emailIDs, []string{"e1", "e3"}) {
+		t.Errorf("selected delete = %+v, want delete of [e1 e3]", msg)
+	}
+
+	// Space again unselects; esc clears the rest.
+	m.cursor = 0
+	m, _ = m.Update(space)
+	if m.selected["e1"] {
+		t.Error("space on a selected row should unselect it")
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if len(m.selected) != 0 {
+		t.Errorf("esc should clear the selection, got %v", m.selected)
+	}
+}
+
+func TestInbox_VisibleRowsByDensity(t *testing.T) {
+	now := time.Now()
+	var threads []domain.Thread
+	for i := range 20 {
+		threads = append(threads, domain.Thread{
+			ID: string(rune('a' + i)), Subject: "Hi", LastDate: now, TotalCount: 1,
+			Snippet: "Lunch\non friday?",
+		})
+	}
+
+	tests := []struct {
+		name        string
+		height      int
+		comfortable bool
+		wantRows    int
+	}{
+		{name: "compact", height: 10, wantRows: 10},
+		{name: "comfortable", height: 10, comfortable: true, wantRows: 5},
+		{name: "comfortable odd height", height: 9, comfortable: true, wantRows: 4},
+		{name: "comfortable one line", height: 1, comfortable: true, wantRows: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newInbox()
+			m.focused = true
+			m.SetSize(80, tt.height)
+			m.SetThreads(threads)
+			m.SetComfortable(tt.comfortable)
+
+			if got := m.visibleRows(); got != tt.wantRows {
+				t.Errorf("visibleRows() = %d, want %d", got, tt.wantRows)
+			}
+			if lines := strings.Count(m.View(), "\n") + 1; lines > tt.height {
+				t.Errorf("view has %d lines, want at most %d", lines, tt.height)
+			}
+		})
+	}
+}
+
+func TestInbox_ExpandAllSnippetsKeepsCursorVisible(t *testing.T) {
+	now := time.Now()
+	var threads []domain.Thread
+	for i := range 20 {
+		threads = append(threads, domain.Thread{ID: string(rune('a' + i)), LastDate: now, TotalCount: 1})
+	}
+	m := newInbox()
+	m.focused = true
+	m.SetSize(80, 10)
+	m.SetThreads(threads)
+	for range 7 {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	if m.offset != 0 {
+		t.Fatalf("compact offset = %d, want 0", m.offset)
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")})
+	if !m.comfortable {
+		t.Fatal("+ should show snippet lines")
+	}
+	if m.cursor < m.offset || m.cursor >= m.offset+m.visibleRows() {
+		t.Errorf("cursor %d outside visible rows [%d, %d)", m.cursor, m.offset, m.offset+m.visibleRows())
+	}
+
+	// The preference survives reloading the list.
+	m.SetThreads(threads)
+	if !m.comfortable {
+		t.Error("density should survive a reload")
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("-")})
+	if m.comfortable {
+		t.Error("- should hide snippet lines")
+	}
+}
+
+func TestDateSection(t *testing.T) {
+	now := time.Date(2024, 3, 10, 0, 30, 0, 0, time.Local)
+	tests := []struct {
+		date time.Time
+		want string
+	}{
+		{time.Date(2024, 3, 10, 0, 0, 0, 0, time.Local), sectionToday},
+		{time.Date(2024, 3, 10, 9, 0, 0, 0, time.Local), sectionToday},
+		{time.Date(2024, 3, 9, 23, 59, 0, 0, time.Local), sectionYesterday},
+		{time.Date(2024, 3, 9, 0, 0, 0, 0, time.Local), sectionYesterday},
+		{time.Date(2024, 3, 8, 23, 59, 0, 0, time.Local), sectionLastWeek},
+		{time.Date(2024, 3, 3, 0, 0, 0, 0, time.Local), sectionLastWeek},
+		{time.Date(2024, 3, 2, 23, 59, 0, 0, time.Local), sectionOlder},
+		{time.Time{}, sectionOlder},
+	}
+	for _, tt := range tests {
+		if got := dateSection(tt.date, now); got != tt.want {
+			t.Errorf("dateSection(%v) = %q, want %q", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestInbox_GroupByDateCursorSkipsHeaders(t *testing.T) {
+	now := time.Now()
+	old := now.AddDate(0, -1, 0)
+	threads := []domain.Thread{
+		{ID: "t1", Subject: "new one", LastDate: now, TotalCount: 1},
+		{ID: "t2", Subject: "new two", LastDate: now, TotalCount: 1},
+		{ID: "t3", Subject: "old one", LastDate: old, TotalCount: 1},
+		{ID: "t4", Subject: "old two", LastDate: old, TotalCount: 1},
+	}
+	m := newInbox()
+	m.focused = true
+	m.groupByDate = true
+	m.SetSize(80, 4)
+	m.SetThreads(threads)
+
+	view := m.View()
+	if !strings.HasPrefix(view, sectionToday) || !strings.Contains(view, sectionOlder) {
+		t.Errorf("view should start with a Today header and reach Older:\n%s", view)
+	}
+
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	for i, want := range []string{"t2", "t3", "t4"} {
+		m, _ = m.Update(down)
+		if got := m.SelectedThreadID(); got != want {
+			t.Fatalf("after %d downs selected %q, want %q", i+1, got, want)
+		}
+		view := m.View()
+		if lines := strings.Count(view, "\n") + 1; lines > 4 {
+			t.Errorf("view has %d lines, want at most 4", lines)
+		}
+		if !strings.Contains(view, threads[m.cursor].Subject) {
+			t.Errorf("cursor row %q scrolled out of view:\n%s", threads[m.cursor].Subject, view)
+		}
+	}
+	if m.offset != 2 {
+		t.Errorf("offset = %d, want 2 so the Older header and both rows fit", m.offset)
+	}
+
+	up := tea.KeyMsg{Type: tea.KeyUp}
+	for range 3 {
+		m, _ = m.Update(up)
+	}
+	if m.SelectedThreadID() != "t1" || m.offset != 0 {
+		t.Errorf("back at the top: selected %q offset %d, want t1 at offset 0", m.SelectedThreadID(), m.offset)
+	}
+}
+
+func TestInbox_JumpKeys(t *testing.T) {
+	var threads []domain.Thread
+	for i := range 20 {
+		threads = append(threads, domain.Thread{ID: fmt.Sprintf("t%d", i), Subject: fmt.Sprintf("subject %d", i), TotalCount: 1})
+	}
+	m := newInbox()
+	m.focused = true
+	m.SetSize(80, 6)
+	m.SetThreads(threads)
+
+	press := func(k tea.KeyMsg) {
+		m, _ = m.Update(k)
+	}
+	g := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")})
+	if m.cursor != 19 || m.offset != 14 {
+		t.Errorf("G: cursor/offset = %d/%d, want 19/14", m.cursor, m.offset)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyCtrlU})
+	if m.cursor != 16 || m.offset != 14 {
+		t.Errorf("ctrl+u: cursor/offset = %d/%d, want 16/14", m.cursor, m.offset)
+	}
+
+	press(g)
+	if m.cursor != 16 || !m.pendingG {
+		t.Errorf("a single g should only arm the jump, cursor = %d", m.cursor)
+	}
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	if m.cursor != 17 || m.pendingG {
+		t.Errorf("j after g: cursor = %d pendingG = %v, want 17 and cleared", m.cursor, m.pendingG)
+	}
+	press(g)
+	press(g)
+	if m.cursor != 0 || m.offset != 0 || m.pendingG {
+		t.Errorf("gg: cursor/offset = %d/%d pendingG = %v, want 0/0 and cleared", m.cursor, m.offset, m.pendingG)
+	}
+
+	for range 10 {
+		press(tea.KeyMsg{Type: tea.KeyCtrlD})
+	}
+	if m.cursor != 19 || m.offset != 14 {
+		t.Errorf("ctrl+d past the end: cursor/offset = %d/%d, want 19/14", m.cursor, m.offset)
+	}
+}
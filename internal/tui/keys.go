@@ -5,6 +5,10 @@ import "github.com/charmbracelet/bubbles/key"
 type keyMap struct {
 	Up            key.Binding
 	Down          key.Binding
+	Top           key.Binding
+	Bottom        key.Binding
+	HalfPageDown  key.Binding
+	HalfPageUp    key.Binding
 	Enter         key.Binding
 	Back          key.Binding
 	Compose       key.Binding
@@ -13,13 +17,36 @@ type keyMap struct {
 	Forward       key.Binding
 	Archive       key.Binding
 	Delete        key.Binding
+	Trash         key.Binding
 	Star          key.Binding
 	Unread        key.Binding
+	Spam          key.Binding
+	Flag          key.Binding
+	Snooze        key.Binding
 	Label         key.Binding
+	FromSender    key.Binding
+	Unsubscribe   key.Binding
+	RemoteContent key.Binding
+	BodyView      key.Binding
+	NextMessage   key.Binding
+	PrevMessage   key.Binding
+	Quotes        key.Binding
+	Headers       key.Binding
+	RefreshThread key.Binding
+	FullBody      key.Binding
+	Undo          key.Binding
 	Search        key.Binding
 	Tab           key.Binding
+	BackTab       key.Binding
 	Toggle        key.Binding
+	Select        key.Binding
+	Expand        key.Binding
+	Collapse      key.Binding
+	Open          key.Binding
+	ExpandAll     key.Binding
+	CollapseAll   key.Binding
 	SwitchAccount key.Binding
+	NextAccount   key.Binding
 	Help          key.Binding
 	Quit          key.Binding
 }
@@ -27,6 +54,10 @@ type keyMap struct {
 var keys = keyMap{
 	Up:            key.NewBinding(key.WithKeys("k", "up"), key.WithHelp("k/\u2191", "up")),
 	Down:          key.NewBinding(key.WithKeys("j", "down"), key.WithHelp("j/\u2193", "down")),
+	Top:           key.NewBinding(key.WithKeys("g"), key.WithHelp("gg", "top")),
+	Bottom:        key.NewBinding(key.WithKeys("G"), key.WithHelp("G", "bottom")),
+	HalfPageDown:  key.NewBinding(key.WithKeys("ctrl+d"), key.WithHelp("ctrl+d", "half page down")),
+	HalfPageUp:    key.NewBinding(key.WithKeys("ctrl+u"), key.WithHelp("ctrl+u", "half page up")),
 	Enter:         key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "open")),
 	Back:          key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
 	Compose:       key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "compose")),
@@ -34,14 +65,37 @@ var keys = keyMap{
 	ReplyAll:      key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "reply all")),
 	Forward:       key.NewBinding(key.WithKeys("f"), key.WithHelp("f", "forward")),
 	Archive:       key.NewBinding(key.WithKeys("a"), key.WithHelp("a", "archive")),
-	Delete:        key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "trash")),
+	Delete:        key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete")),
+	Trash:         key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "trash")),
 	Star:          key.NewBinding(key.WithKeys("s"), key.WithHelp("s", "star")),
 	Unread:        key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "unread")),
-	Label:         key.NewBinding(key.WithKeys("l"), key.WithHelp("l", "label")),
+	Spam:          key.NewBinding(key.WithKeys("!"), key.WithHelp("!", "spam/not spam")),
+	Flag:          key.NewBinding(key.WithKeys("F"), key.WithHelp("F", "cycle flag")),
+	Snooze:        key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "snooze")),
+	Label:         key.NewBinding(key.WithKeys("l"), key.WithHelp("l", "move to label")),
+	FromSender:    key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "all mail from sender")),
+	Unsubscribe:   key.NewBinding(key.WithKeys("U"), key.WithHelp("U", "unsubscribe")),
+	RemoteContent: key.NewBinding(key.WithKeys("I"), key.WithHelp("I", "toggle remote images")),
+	BodyView:      key.NewBinding(key.WithKeys("v"), key.WithHelp("v", "text/HTML part")),
+	NextMessage:   key.NewBinding(key.WithKeys("n"), key.WithHelp("n", "next message")),
+	PrevMessage:   key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "previous message")),
+	Quotes:        key.NewBinding(key.WithKeys("x"), key.WithHelp("x", "show/hide quotes")),
+	Headers:       key.NewBinding(key.WithKeys("H"), key.WithHelp("H", "full/compact headers")),
+	RefreshThread: key.NewBinding(key.WithKeys("g"), key.WithHelp("g", "refresh thread")),
+	FullBody:      key.NewBinding(key.WithKeys("B"), key.WithHelp("B", "fetch truncated body")),
+	Undo:          key.NewBinding(key.WithKeys("z"), key.WithHelp("z", "undo")),
 	Search:        key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search")),
-	Tab:           key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "switch pane")),
+	Tab:           key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "next pane")),
+	BackTab:       key.NewBinding(key.WithKeys("shift+tab"), key.WithHelp("shift+tab", "previous pane")),
 	Toggle:        key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "thread/flat")),
-	SwitchAccount: key.NewBinding(key.WithKeys("@"), key.WithHelp("@", "account")),
+	Select:        key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "select")),
+	Expand:        key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "expand/collapse")),
+	Collapse:      key.NewBinding(key.WithKeys("h", "left"), key.WithHelp("h/\u2190", "collapse/parent")),
+	Open:          key.NewBinding(key.WithKeys("right"), key.WithHelp("\u2192", "expand/child")),
+	ExpandAll:     key.NewBinding(key.WithKeys("+", "="), key.WithHelp("+", "show snippets")),
+	CollapseAll:   key.NewBinding(key.WithKeys("-"), key.WithHelp("-", "hide snippets")),
+	SwitchAccount: key.NewBinding(key.WithKeys("@"), key.WithHelp("@", "switch account")),
+	NextAccount:   key.NewBinding(key.WithKeys("ctrl+n"), key.WithHelp("ctrl+n", "next account")),
 	Help:          key.NewBinding(key.WithKeys("?"), key.WithHelp("?", "help")),
 	Quit:          key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
 }
@@ -0,0 +1,273 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lu-zhengda/termail/internal/app"
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+// labelPickerMsg requests the move-to-label picker for the given emails.
+type labelPickerMsg struct {
+	emailIDs []string
+}
+
+// moveToLabelMsg is emitted when a label is chosen in the picker.
+type moveToLabelMsg struct {
+	emailIDs []string
+	label    domain.Label
+}
+
+type closeLabelPickerMsg struct{}
+
+type movedToLabelMsg struct {
+	label domain.Label
+	count int
+}
+
+// The picker's text input takes letters, so only arrows and ctrl+p/ctrl+n
+// move through the matches.
+var (
+	pickerUp   = key.NewBinding(key.WithKeys("up", "ctrl+p"))
+	pickerDown = key.NewBinding(key.WithKeys("down", "ctrl+n"))
+)
+
+// labelPickerModel is an overlay that filters labels as the user types and
+// moves the target emails into the chosen one.
+type labelPickerModel struct {
+	input    textinput.Model
+	labels   []domain.Label
+	matches  []domain.Label
+	cursor   int
+	emailIDs []string
+	visible  bool
+	width    int
+	height   int
+
+	styles styles
+}
+
+func newLabelPicker() labelPickerModel {
+	ti := textinput.New()
+	ti.Placeholder = "type to filter labels"
+	ti.Prompt = "Move to: "
+	ti.CharLimit = 64
+	return labelPickerModel{input: ti, styles: defaultStyles()}
+}
+
+// Open shows the picker for emailIDs with labels as the choices.
+func (p *labelPickerModel) Open(emailIDs []string, labels []domain.Label) {
+	p.emailIDs = emailIDs
+	p.labels = labels
+	p.visible = true
+	p.input.SetValue("")
+	p.input.Focus()
+	p.filter()
+}
+
+// Close hides the picker.
+func (p *labelPickerModel) Close() {
+	p.visible = false
+	p.input.Blur()
+}
+
+// SetSize updates the available dimensions for the overlay.
+func (p *labelPickerModel) SetSize(w, h int) {
+	p.width = w
+	p.height = h
+}
+
+// IsVisible reports whether the picker is shown.
+func (p labelPickerModel) IsVisible() bool {
+	return p.visible
+}
+
+func (p labelPickerModel) Update(msg tea.Msg) (labelPickerModel, tea.Cmd) {
+	if !p.visible {
+		return p, nil
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, keys.Back):
+			return p, func() tea.Msg { return closeLabelPickerMsg{} }
+
+		case key.Matches(msg, keys.Enter):
+			if p.cursor >= len(p.matches) {
+				return p, nil
+			}
+			move := moveToLabelMsg{emailIDs: p.emailIDs, label: p.matches[p.cursor]}
+			return p, func() tea.Msg { return move }
+
+		case key.Matches(msg, pickerUp):
+			if p.cursor > 0 {
+				p.cursor--
+			}
+			return p, nil
+
+		case key.Matches(msg, pickerDown):
+			if p.cursor < len(p.matches)-1 {
+				p.cursor++
+			}
+			return p, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	prev := p.input.Value()
+	p.input, cmd = p.input.Update(msg)
+	if p.input.Value() != prev {
+		p.filter()
+	}
+	return p, cmd
+}
+
+// filter recomputes the matches for the current query and resets the
+// cursor to the best one.
+func (p *labelPickerModel) filter() {
+	p.matches = fuzzyFilter(p.labels, p.input.Value())
+	p.cursor = 0
+}
+
+func (p labelPickerModel) View() string {
+	if !p.visible {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(p.styles.title.Render(" Move to label "))
+	b.WriteString("\n\n")
+	b.WriteString(p.input.View())
+	b.WriteString("\n\n")
+
+	if len(p.matches) == 0 {
+		b.WriteString(p.styles.mutedText.Render("No matching labels"))
+		return b.String()
+	}
+
+	// Keep the cursor row within the rows available below the input.
+	rows := max(p.height-6, 1)
+	start := max(p.cursor-rows+1, 0)
+	end := min(start+rows, len(p.matches))
+	for i := start; i < end; i++ {
+		line := lipgloss.NewStyle().Width(max(p.width-2, 10)).Render("  " + displayName(p.matches[i]))
+		if i == p.cursor {
+			line = p.styles.selected.Render(line)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// moveTargets returns the labels mail can be moved into from the picker:
+// the inbox followed by the user labels.
+func moveTargets(labels []domain.Label) []domain.Label {
+	var targets []domain.Label
+	for _, l := range labels {
+		if l.ID == domain.LabelInbox {
+			targets = append(targets, l)
+		}
+	}
+	for _, l := range labels {
+		if l.Type == domain.LabelTypeUser {
+			targets = append(targets, l)
+		}
+	}
+	return targets
+}
+
+// fuzzyFilter returns the labels whose display name fuzzily matches query,
+// best match first. An empty query keeps every label in its original order.
+func fuzzyFilter(labels []domain.Label, query string) []domain.Label {
+	if strings.TrimSpace(query) == "" {
+		return slices.Clone(labels)
+	}
+
+	type scored struct {
+		label domain.Label
+		score int
+	}
+	var matches []scored
+	for _, l := range labels {
+		if score, ok := fuzzyScore(displayName(l), query); ok {
+			matches = append(matches, scored{l, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int { return a.score - b.score })
+
+	out := make([]domain.Label, len(matches))
+	for i, m := range matches {
+		out[i] = m.label
+	}
+	return out
+}
+
+// fuzzyScore reports whether the runes of query appear in name in order,
+// ignoring case and spaces in query, and scores the best such match: lower
+// is better. Each skipped rune costs a point, and a match at the start of
+// the name or of a word or "/" segment earns one back.
+func fuzzyScore(name, query string) (int, bool) {
+	runes := []rune(strings.ToLower(name))
+	var q []rune
+	for _, r := range strings.ToLower(query) {
+		if !unicode.IsSpace(r) {
+			q = append(q, r)
+		}
+	}
+	if len(q) == 0 {
+		return 0, true
+	}
+
+	best, found := 0, false
+	for start, r := range runes {
+		if r != q[0] {
+			continue
+		}
+		if score, ok := fuzzyScoreFrom(runes, q, start); ok && (!found || score < best) {
+			best, found = score, true
+		}
+	}
+	return best, found
+}
+
+// fuzzyScoreFrom greedily matches q against runes with its first rune at
+// start, returning the score described by fuzzyScore.
+func fuzzyScoreFrom(runes, q []rune, start int) (int, bool) {
+	score, pos := start, start
+	for _, r := range q {
+		i := slices.Index(runes[pos:], r)
+		if i < 0 {
+			return 0, false
+		}
+		at := pos + i
+		score += i
+		if at == 0 || strings.ContainsRune("/ -_.", runes[at-1]) {
+			score--
+		}
+		pos = at + 1
+	}
+	return score, true
+}
+
+// moveToLabelCmd moves each email into the chosen label, removing it from
+// the inbox (and Spam or Trash) in the same change.
+func (m model) moveToLabelCmd(msg moveToLabelMsg) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		for _, id := range msg.emailIDs {
+			if err := app.MoveToLabel(ctx, m.provider, m.store, m.accountID, id, msg.label.ID); err != nil {
+				return errMsg{err: fmt.Errorf("failed to move to %s: %w", displayName(msg.label), err)}
+			}
+		}
+		return movedToLabelMsg{label: msg.label, count: len(msg.emailIDs)}
+	}
+}
@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lu-zhengda/termail/internal/config"
+	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/store/sqlite"
+)
+
+var pickerLabels = []domain.Label{
+	{ID: domain.LabelInbox, Name: "INBOX", Type: domain.LabelTypeSystem},
+	{ID: "Label_1", Name: "Receipts", Type: domain.LabelTypeUser},
+	{ID: "Label_2", Name: "Projects/Recruiting", Type: domain.LabelTypeUser},
+	{ID: "Label_3", Name: "Travel", Type: domain.LabelTypeUser},
+}
+
+func labelIDs(labels []domain.Label) []string {
+	ids := make([]string, len(labels))
+	for i, l := range labels {
+		ids[i] = l.ID
+	}
+	return ids
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{domain.LabelInbox, "Label_1", "Label_2", "Label_3"}},
+		{"rcp", []string{"Label_1"}},
+		{"rec", []string{"Label_1", "Label_2"}},
+		{"prec", []string{"Label_2"}},
+		{"TRV", []string{"Label_3"}},
+		{"inb", []string{domain.LabelInbox}},
+		{"xyz", []string{}},
+	}
+	for _, tt := range tests {
+		got := labelIDs(fuzzyFilter(pickerLabels, tt.query))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("fuzzyFilter(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestLabelPicker_EnterEmitsMove(t *testing.T) {
+	p := newLabelPicker()
+	p.Open([]string{"e1"}, moveTargets(pickerLabels))
+
+	for _, r := range "trv" {
+		p, _ = p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if got := labelIDs(p.matches); !slices.Equal(got, []string{"Label_3"}) {
+		t.Fatalf("matches after typing = %v, want [Label_3]", got)
+	}
+
+	_, cmd := p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("enter should emit a command")
+	}
+	move, ok := cmd().(moveToLabelMsg)
+	if !ok {
+		t.Fatalf("got %T, want moveToLabelMsg", cmd())
+	}
+	if move.label.ID != "Label_3" || !slices.Equal(move.emailIDs, []string{"e1"}) {
+		t.Errorf("move = %+v, want e1 to Label_3", move)
+	}
+
+	_, cmd = p.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if _, ok := cmd().(closeLabelPickerMsg); !ok {
+		t.Error("esc should close the picker")
+	}
+}
+
+func TestMoveToLabel_RemovesInboxAndAddsLabel(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+	db, err := sqlite.New(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sqlite.New() error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	if err := db.CreateAccount(ctx, &domain.Account{ID: "a@example.com", Email: "a@example.com", Provider: "gmail"}); err != nil {
+		t.Fatalf("CreateAccount() error: %v", err)
+	}
+	labels := []string{domain.LabelInbox, domain.LabelUnread}
+	if err := db.UpsertEmail(ctx, &domain.Email{ID: "e1", ThreadID: "e1", Labels: labels}, "a@example.com"); err != nil {
+		t.Fatalf("UpsertEmail() error: %v", err)
+	}
+	p := &labelProvider{labels: map[string][]string{"e1": labels}}
+
+	m := NewModel(cfg, db, p, "a@example.com", []domain.Account{{ID: "a@example.com"}}, nil)
+	msg := m.moveToLabelCmd(moveToLabelMsg{emailIDs: []string{"e1"}, label: pickerLabels[1]})()
+	if moved, ok := msg.(movedToLabelMsg); !ok || moved.count != 1 {
+		t.Fatalf("moveToLabelCmd() = %#v, want movedToLabelMsg for 1 email", msg)
+	}
+
+	want := []string{domain.LabelUnread, "Label_1"}
+	if !slices.Equal(p.labels["e1"], want) {
+		t.Errorf("provider labels = %v, want %v", p.labels["e1"], want)
+	}
+	email, err := db.GetEmail(ctx, "e1", "a@example.com")
+	if err != nil {
+		t.Fatalf("GetEmail() error: %v", err)
+	}
+	slices.Sort(email.Labels)
+	slices.Sort(want)
+	if !slices.Equal(email.Labels, want) {
+		t.Errorf("local labels = %v, want %v", email.Labels, want)
+	}
+}
@@ -3,10 +3,14 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/lu-zhengda/termail/internal/domain"
+	"github.com/lu-zhengda/termail/internal/htmltext"
 )
 
 // Messages emitted by readerModel.
@@ -22,6 +26,23 @@ type forwardMsg struct {
 
 type closeReaderMsg struct{}
 
+// refreshThreadMsg asks for the open thread to be synced from the provider.
+// emailID is set when a single message is open rather than the thread.
+type refreshThreadMsg struct {
+	threadID string
+	emailID  string
+}
+
+// fullBodyMsg asks for a message whose body the sync truncated to be
+// fetched again in full.
+type fullBodyMsg struct {
+	emailID string
+}
+
+type unsubscribeMsg struct {
+	email *domain.Email
+}
+
 // readerModel is a Bubble Tea sub-model for displaying email content
 // in a scrollable viewport.
 type readerModel struct {
@@ -34,16 +55,74 @@ type readerModel struct {
 	height       int
 	focused      bool
 	visible      bool
+
+	// matchTerms are the search terms highlighted in the body, if the email
+	// was opened from search.
+	matchTerms []string
+	// bodyStart is the first content line of the open email's body, below
+	// its headers.
+	bodyStart int
+	// contextLines is how many lines to keep above a match when scrolling to it.
+	contextLines int
+
+	// loadRemote is the configured default for showing remote images in
+	// HTML mail; remote is the setting for the open message, which the
+	// RemoteContent key toggles.
+	loadRemote bool
+	remote     bool
+
+	// messageStarts holds the first content line of each message of an
+	// open thread, and message the index of the one in view.
+	messageStarts []int
+	message       int
+	// showQuotes expands quoted passages that are collapsed by default.
+	showQuotes bool
+	// compact shows one-line headers; the Headers key toggles it for the
+	// rest of the session.
+	compact bool
+
+	// preferHTML is the configured default for rendering mail that has
+	// both parts from its HTML part (from ui.prefer_html); html is the
+	// setting for the open message, which the BodyView key toggles.
+	preferHTML bool
+	html       bool
+
+	// inlineReply makes the Reply key open a reply box at the bottom of
+	// the reader instead of the composer (from ui.inline_reply). replying
+	// is set while the box is open.
+	inlineReply bool
+	replying    bool
+	replyInput  textarea.Model
+
+	// defaultReplyTo, defaultCC and defaultBCC are the compose defaults
+	// applied to inline replies, as the composer applies them.
+	defaultReplyTo []domain.Address
+	defaultCC      []domain.Address
+	defaultBCC     []domain.Address
+
+	styles styles
 }
 
+// replyBoxHeight is the number of lines the inline reply box takes: a
+// hint line above the text area.
+const replyBoxHeight = 4
+
 func newReader() readerModel {
-	return readerModel{}
+	input := textarea.New()
+	input.Placeholder = "Write your reply..."
+	input.SetHeight(replyBoxHeight - 1)
+	input.CharLimit = 0
+	input.ShowLineNumbers = false
+	return readerModel{replyInput: input, styles: defaultStyles()}
 }
 
 func (r readerModel) Update(msg tea.Msg) (readerModel, tea.Cmd) {
 	if !r.focused || !r.visible {
 		return r, nil
 	}
+	if r.replying {
+		return r.updateReply(msg)
+	}
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -51,11 +130,51 @@ func (r readerModel) Update(msg tea.Msg) (readerModel, tea.Cmd) {
 		case key.Matches(msg, keys.Up):
 			if r.scrollOffset > 0 {
 				r.scrollOffset--
+				r.syncMessage()
 			}
 
 		case key.Matches(msg, keys.Down):
 			if r.scrollOffset < r.maxScroll {
 				r.scrollOffset++
+				r.syncMessage()
+			}
+
+		case key.Matches(msg, keys.HalfPageDown):
+			r.scrollBy(r.halfPage())
+
+		case key.Matches(msg, keys.HalfPageUp):
+			r.scrollBy(-r.halfPage())
+
+		case key.Matches(msg, keys.NextMessage):
+			r.jumpToMessage(r.message + 1)
+
+		case key.Matches(msg, keys.PrevMessage):
+			r.jumpToMessage(r.message - 1)
+
+		case key.Matches(msg, keys.Quotes):
+			r.showQuotes = !r.showQuotes
+			r.render()
+			r.jumpToMessage(r.message)
+
+		case key.Matches(msg, keys.Headers):
+			r.compact = !r.compact
+			r.render()
+			r.jumpToMessage(r.message)
+
+		case key.Matches(msg, keys.FromSender):
+			if email := r.messageInView(); email != nil && email.From.Email != "" {
+				addr := email.From.Email
+				return r, func() tea.Msg { return senderFilterMsg{addr: addr} }
+			}
+
+		case key.Matches(msg, keys.RefreshThread):
+			if t := r.thread; t != nil {
+				return r, func() tea.Msg { return refreshThreadMsg{threadID: t.ID} }
+			}
+			if email := r.currentEmail(); email != nil && email.ThreadID != "" {
+				return r, func() tea.Msg {
+					return refreshThreadMsg{threadID: email.ThreadID, emailID: email.ID}
+				}
 			}
 
 		case key.Matches(msg, keys.Back):
@@ -65,6 +184,9 @@ func (r readerModel) Update(msg tea.Msg) (readerModel, tea.Cmd) {
 
 		case key.Matches(msg, keys.Reply):
 			email := r.currentEmail()
+			if email != nil && r.inlineReply {
+				return r, r.OpenReply()
+			}
 			if email != nil {
 				return r, func() tea.Msg {
 					return replyMsg{email: email, replyAll: false}
@@ -91,7 +213,7 @@ func (r readerModel) Update(msg tea.Msg) (readerModel, tea.Cmd) {
 			email := r.currentEmail()
 			if email != nil {
 				return r, func() tea.Msg {
-					return emailActionMsg{emailID: email.ID, action: "archive"}
+					return emailActionMsg{emailIDs: []string{email.ID}, action: "archive"}
 				}
 			}
 
@@ -99,15 +221,32 @@ func (r readerModel) Update(msg tea.Msg) (readerModel, tea.Cmd) {
 			email := r.currentEmail()
 			if email != nil {
 				return r, func() tea.Msg {
-					return emailActionMsg{emailID: email.ID, action: "delete"}
+					return emailActionMsg{emailIDs: []string{email.ID}, action: "delete"}
+				}
+			}
+
+		case key.Matches(msg, keys.Trash):
+			email := r.currentEmail()
+			if email != nil {
+				return r, func() tea.Msg {
+					return emailActionMsg{emailIDs: []string{email.ID}, action: "trash"}
 				}
 			}
 
 		case key.Matches(msg, keys.Star):
+			if t := r.thread; t != nil {
+				return r, func() tea.Msg {
+					return threadStarMsg{threadID: t.ID, star: !t.IsStarred()}
+				}
+			}
 			email := r.currentEmail()
 			if email != nil {
+				action := "star"
+				if email.IsStarred {
+					action = "unstar"
+				}
 				return r, func() tea.Msg {
-					return emailActionMsg{emailID: email.ID, action: "star"}
+					return emailActionMsg{emailIDs: []string{email.ID}, action: action}
 				}
 			}
 
@@ -115,9 +254,61 @@ func (r readerModel) Update(msg tea.Msg) (readerModel, tea.Cmd) {
 			email := r.currentEmail()
 			if email != nil {
 				return r, func() tea.Msg {
-					return emailActionMsg{emailID: email.ID, action: "unread"}
+					return emailActionMsg{emailIDs: []string{email.ID}, action: "unread"}
+				}
+			}
+
+		case key.Matches(msg, keys.Spam):
+			email := r.currentEmail()
+			if email != nil {
+				return r, func() tea.Msg {
+					return emailActionMsg{emailIDs: []string{email.ID}, action: "spam"}
+				}
+			}
+
+		case key.Matches(msg, keys.Flag):
+			if t := r.thread; t != nil {
+				return r, func() tea.Msg { return flagMsg{threadID: t.ID} }
+			}
+			if email := r.currentEmail(); email != nil {
+				return r, func() tea.Msg { return flagMsg{emailID: email.ID} }
+			}
+
+		case key.Matches(msg, keys.Snooze):
+			if t := r.thread; t != nil {
+				return r, func() tea.Msg { return snoozeMsg{threadID: t.ID} }
+			}
+			if email := r.currentEmail(); email != nil {
+				return r, func() tea.Msg { return snoozeMsg{emailID: email.ID} }
+			}
+
+		case key.Matches(msg, keys.Label):
+			if email := r.currentEmail(); email != nil {
+				return r, func() tea.Msg { return labelPickerMsg{emailIDs: []string{email.ID}} }
+			}
+
+		case key.Matches(msg, keys.Unsubscribe):
+			email := r.currentEmail()
+			if email != nil && email.ListUnsubscribe != "" {
+				return r, func() tea.Msg {
+					return unsubscribeMsg{email: email}
 				}
 			}
+
+		case key.Matches(msg, keys.RemoteContent):
+			r.remote = !r.remote
+			r.render()
+
+		case key.Matches(msg, keys.BodyView):
+			r.html = !r.html
+			r.render()
+			r.jumpToMessage(r.message)
+
+		case key.Matches(msg, keys.FullBody):
+			if email := r.messageInView(); email != nil && email.BodyTruncated() {
+				id := email.ID
+				return r, func() tea.Msg { return fullBodyMsg{emailID: id} }
+			}
 		}
 	}
 
@@ -130,15 +321,11 @@ func (r readerModel) View() string {
 	}
 
 	if r.content == "" {
-		return mutedTextStyle.Render("No email selected")
+		return r.styles.mutedText.Render("No email selected")
 	}
 
 	lines := strings.Split(r.content, "\n")
-
-	visibleHeight := r.height
-	if visibleHeight < 1 {
-		visibleHeight = 1
-	}
+	visibleHeight := r.contentHeight()
 
 	end := r.scrollOffset + visibleHeight
 	if end > len(lines) {
@@ -151,17 +338,122 @@ func (r readerModel) View() string {
 	}
 
 	visible := strings.Join(lines[start:end], "\n")
+	if len(r.messageStarts) > 1 {
+		header := r.styles.mutedText.Render(fmt.Sprintf("Message %d/%d  (%s/%s: next/previous)",
+			r.message+1, len(r.messageStarts), keys.NextMessage.Help().Key, keys.PrevMessage.Help().Key))
+		visible = header + "\n" + visible
+	}
+	if r.replying {
+		// Pad short messages so the box sits at the bottom of the pane.
+		if n := end - start; n < visibleHeight {
+			visible += strings.Repeat("\n", visibleHeight-n)
+		}
+		to := ""
+		if email := r.currentEmail(); email != nil {
+			to = email.ReplyRecipient().Display()
+		}
+		hint := r.styles.mutedText.Render(fmt.Sprintf("Reply to %s  (ctrl+s: send, esc: cancel)", to))
+		visible += "\n" + hint + "\n" + r.replyInput.View()
+	}
 	return visible
 }
 
-// ShowEmail displays a single email in the reader pane.
-func (r *readerModel) ShowEmail(email *domain.Email) {
+// OpenReply opens the inline reply box for the email that Reply would
+// answer and returns the command starting its cursor blink.
+func (r *readerModel) OpenReply() tea.Cmd {
+	r.replying = true
+	r.replyInput.Reset()
+	r.replyInput.SetWidth(max(r.width, 1))
+	r.recalcMaxScroll()
+	return r.replyInput.Focus()
+}
+
+// CloseReply closes the inline reply box, discarding its text.
+func (r *readerModel) CloseReply() {
+	if !r.replying {
+		return
+	}
+	r.replying = false
+	r.replyInput.Reset()
+	r.replyInput.Blur()
+	r.recalcMaxScroll()
+}
+
+// IsReplying reports whether the inline reply box is open.
+func (r readerModel) IsReplying() bool {
+	return r.replying
+}
+
+// updateReply handles keys while the inline reply box is open: Ctrl+S
+// sends the reply, Esc discards it and anything else edits the text.
+func (r readerModel) updateReply(msg tea.Msg) (readerModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			r.CloseReply()
+			return r, nil
+
+		case "ctrl+s":
+			email := r.BuildReply()
+			if email == nil {
+				return r, nil
+			}
+			return r, func() tea.Msg { return sendMsg{email: email} }
+		}
+	}
+
+	var cmd tea.Cmd
+	r.replyInput, cmd = r.replyInput.Update(msg)
+	return r, cmd
+}
+
+// BuildReply builds the inline reply to the current email: sent to its
+// reply recipient, as the composer's reply would be, with a "Re:" subject
+// and the newest message quoted below the text. It returns nil while the
+// text is blank.
+func (r readerModel) BuildReply() *domain.Email {
+	original := r.currentEmail()
+	text := strings.TrimSpace(r.replyInput.Value())
+	if original == nil || text == "" {
+		return nil
+	}
+
+	email := &domain.Email{
+		To:         []domain.Address{original.ReplyRecipient()},
+		ReplyTo:    r.defaultReplyTo,
+		Subject:    replySubject(original.Subject),
+		Body:       text + "\n" + formatReplyQuote(original),
+		Date:       time.Now(),
+		InReplyTo:  original.ReplyInReplyTo(),
+		References: original.ReplyReferences(),
+		ThreadID:   original.ThreadID,
+	}
+	email.ApplyDefaultRecipients(r.defaultCC, r.defaultBCC)
+	return email
+}
+
+// ShowEmail displays a single email in the reader pane. If query is non-empty,
+// its terms are highlighted and the reader scrolls to the first match.
+func (r *readerModel) ShowEmail(email *domain.Email, query string) {
 	r.email = email
 	r.thread = nil
 	r.visible = true
 	r.scrollOffset = 0
-	r.content = renderEmail(email, r.width)
-	r.recalcMaxScroll()
+	r.matchTerms = searchTerms(query)
+	r.remote = r.loadRemote
+	r.html = r.preferHTML
+	// Show quotes when searching so a match inside one is not hidden.
+	r.showQuotes = len(r.matchTerms) > 0
+	r.message = 0
+	r.CloseReply()
+	r.render()
+
+	// Search the body only: terms often match the subject or sender too.
+	lines := strings.Split(r.content, "\n")
+	body := strings.Join(lines[min(r.bodyStart, len(lines)):], "\n")
+	if line := matchLineOffset(body, r.matchTerms); line >= 0 {
+		r.scrollOffset = scrollOffsetForMatch(r.bodyStart+line, r.contextLines, r.maxScroll)
+	}
 }
 
 // ShowThread displays a thread (all messages) in the reader pane.
@@ -170,8 +462,22 @@ func (r *readerModel) ShowThread(thread *domain.Thread) {
 	r.email = nil
 	r.visible = true
 	r.scrollOffset = 0
-	r.content = renderThread(thread, r.width)
-	r.recalcMaxScroll()
+	r.matchTerms = nil
+	r.remote = r.loadRemote
+	r.html = r.preferHTML
+	r.showQuotes = false
+	r.message = 0
+	r.CloseReply()
+	r.render()
+}
+
+// Reload replaces the open email or thread with a refreshed copy, keeping
+// the scroll position and display toggles.
+func (r *readerModel) Reload(email *domain.Email, thread *domain.Thread) {
+	r.email = email
+	r.thread = thread
+	r.render()
+	r.message = min(r.message, max(len(r.messageStarts)-1, 0))
 }
 
 // Close hides the reader and clears its content.
@@ -182,21 +488,78 @@ func (r *readerModel) Close() {
 	r.content = ""
 	r.scrollOffset = 0
 	r.maxScroll = 0
+	r.matchTerms = nil
+	r.messageStarts = nil
+	r.message = 0
+	r.CloseReply()
 }
 
 // SetSize updates the reader dimensions and recalculates scroll bounds.
 func (r *readerModel) SetSize(w, h int) {
 	r.width = w
 	r.height = h
+	r.replyInput.SetWidth(max(w, 1))
 	// Re-render content if we have something to display, since width may affect layout.
+	r.render()
+}
+
+// render re-renders the open email or thread and recalculates scroll bounds.
+func (r *readerModel) render() {
+	opts := renderOptions{remote: r.remote, quotes: r.showQuotes, compact: r.compact, html: r.html}
+	r.messageStarts = nil
+	r.bodyStart = 0
 	if r.email != nil {
-		r.content = renderEmail(r.email, r.width)
+		opts.terms = r.matchTerms
+		r.content, r.bodyStart = renderEmail(r.styles, r.email, r.width, opts)
 	} else if r.thread != nil {
-		r.content = renderThread(r.thread, r.width)
+		r.content, r.messageStarts = renderThread(r.styles, r.thread, r.width, opts)
 	}
 	r.recalcMaxScroll()
 }
 
+// contentHeight returns the number of content lines shown, leaving room for
+// the message index header on threads with more than one message and for
+// the inline reply box.
+func (r readerModel) contentHeight() int {
+	h := r.height
+	if len(r.messageStarts) > 1 {
+		h--
+	}
+	if r.replying {
+		h -= replyBoxHeight
+	}
+	return max(h, 1)
+}
+
+// jumpToMessage scrolls the viewport to the start of thread message i,
+// clamped to the messages of the open thread.
+func (r *readerModel) jumpToMessage(i int) {
+	if len(r.messageStarts) == 0 {
+		return
+	}
+	r.message = max(min(i, len(r.messageStarts)-1), 0)
+	r.scrollOffset = min(r.messageStarts[r.message], r.maxScroll)
+}
+
+// syncMessage updates the message index after scrolling: the message in
+// view is the last one starting at or above the top line, or the last
+// message once the viewport reaches the bottom.
+func (r *readerModel) syncMessage() {
+	if len(r.messageStarts) == 0 {
+		return
+	}
+	if r.scrollOffset == r.maxScroll && r.maxScroll > 0 {
+		r.message = len(r.messageStarts) - 1
+		return
+	}
+	r.message = 0
+	for i, start := range r.messageStarts {
+		if start <= r.scrollOffset {
+			r.message = i
+		}
+	}
+}
+
 // IsVisible returns whether the reader pane is currently shown.
 func (r readerModel) IsVisible() bool {
 	return r.visible
@@ -217,6 +580,30 @@ func (r readerModel) currentEmail() *domain.Email {
 	return nil
 }
 
+// messageInView returns the open email, or the message of the open thread
+// scrolled into view.
+func (r readerModel) messageInView() *domain.Email {
+	if r.thread != nil && r.message < len(r.thread.Messages) {
+		return &r.thread.Messages[r.message]
+	}
+	return r.currentEmail()
+}
+
+// halfPage returns how many lines ctrl+d and ctrl+u scroll the body.
+func (r readerModel) halfPage() int {
+	return max(r.contentHeight()/2, 1)
+}
+
+// scrollBy moves the viewport n lines down (up when negative), within the
+// rendered content.
+func (r *readerModel) scrollBy(n int) {
+	offset := max(min(r.scrollOffset+n, r.maxScroll), 0)
+	if offset != r.scrollOffset {
+		r.scrollOffset = offset
+		r.syncMessage()
+	}
+}
+
 func (r *readerModel) recalcMaxScroll() {
 	if r.content == "" {
 		r.maxScroll = 0
@@ -225,12 +612,7 @@ func (r *readerModel) recalcMaxScroll() {
 	}
 
 	lines := strings.Split(r.content, "\n")
-	visibleHeight := r.height
-	if visibleHeight < 1 {
-		visibleHeight = 1
-	}
-
-	r.maxScroll = len(lines) - visibleHeight
+	r.maxScroll = len(lines) - r.contentHeight()
 	if r.maxScroll < 0 {
 		r.maxScroll = 0
 	}
@@ -241,73 +623,338 @@ func (r *readerModel) recalcMaxScroll() {
 	}
 }
 
-// renderEmail formats a single email as a plain-text string with headers and body.
-func renderEmail(email *domain.Email, width int) string {
+// renderOptions are the reader's display toggles applied when rendering a
+// message.
+type renderOptions struct {
+	// terms are search terms to highlight in the body.
+	terms []string
+	// remote shows remote images in HTML-only mail.
+	remote bool
+	// quotes expands long quoted passages instead of collapsing them.
+	quotes bool
+	// compact shows a one-line header instead of the full header block.
+	compact bool
+	// html renders mail that has both parts from its HTML part.
+	html bool
+}
+
+// renderEmail formats a single email as a plain-text string with headers and
+// body, applying opts. bodyStart is the line the body starts on.
+func renderEmail(st styles, email *domain.Email, width int, opts renderOptions) (content string, bodyStart int) {
 	var b strings.Builder
 
-	// Headers
-	b.WriteString(mutedTextStyle.Render("From:    "))
-	b.WriteString(email.From.String())
+	if opts.compact {
+		b.WriteString(renderCompactHeader(email, width))
+	} else {
+		b.WriteString(renderFullHeader(st, email))
+	}
+	if email.Body != "" && email.BodyHTML != "" {
+		shown, other := "text", "HTML"
+		if opts.html {
+			shown, other = other, shown
+		}
+		b.WriteString(st.mutedText.Render(fmt.Sprintf("Showing the %s part (%s: %s)",
+			shown, keys.BodyView.Help().Key, other)))
+		b.WriteByte('\n')
+	}
+
+	// Separator
+	sepWidth := width
+	if sepWidth < 20 {
+		sepWidth = 20
+	}
+	b.WriteString(st.mutedText.Render(strings.Repeat("\u2500", sepWidth)))
 	b.WriteByte('\n')
 
-	b.WriteString(mutedTextStyle.Render("To:      "))
+	if email.BodyTruncated() {
+		b.WriteString(st.mutedText.Render(fmt.Sprintf("Body truncated by sync.max_body_bytes (press %s to fetch it in full)",
+			keys.FullBody.Help().Key)))
+		b.WriteByte('\n')
+	}
+
+	if email.Event != nil {
+		b.WriteByte('\n')
+		b.WriteString(renderEventCard(st, email.Event, width))
+		b.WriteByte('\n')
+	}
+
+	// Body
+	body := email.Body
+	if showHTML(email, opts.html) {
+		res := htmltext.Convert(email.BodyHTML, htmltext.Options{RemoteImages: opts.remote})
+		body = res.Text
+		if res.Blocked > 0 {
+			b.WriteString(st.mutedText.Render(fmt.Sprintf("%d remote images blocked (press %s to load)",
+				res.Blocked, keys.RemoteContent.Help().Key)))
+			b.WriteByte('\n')
+		}
+	}
+	bodyStart = strings.Count(b.String(), "\n")
+	if body != "" {
+		b.WriteByte('\n')
+		bodyStart++
+		body = layoutBody(body, width)
+		if !opts.quotes {
+			body = collapseQuotes(st, body)
+		}
+		b.WriteString(highlightTerms(st, body, opts.terms))
+	}
+
+	return b.String(), bodyStart
+}
+
+// showHTML reports whether email's body is rendered from its HTML part:
+// when html asks for it, or when there is no text part to show instead.
+func showHTML(email *domain.Email, html bool) bool {
+	if email.BodyHTML == "" {
+		return false
+	}
+	return html || email.Body == ""
+}
+
+// renderFullHeader formats the From, To, CC, Date, Subject and List header
+// lines, one per line.
+func renderFullHeader(st styles, email *domain.Email) string {
+	var b strings.Builder
+
+	b.WriteString(st.mutedText.Render("From:    "))
+	b.WriteString(email.From.Display())
+	b.WriteByte('\n')
+
+	b.WriteString(st.mutedText.Render("To:      "))
 	b.WriteString(formatAddresses(email.To))
 	b.WriteByte('\n')
 
 	if len(email.CC) > 0 {
-		b.WriteString(mutedTextStyle.Render("CC:      "))
+		b.WriteString(st.mutedText.Render("CC:      "))
 		b.WriteString(formatAddresses(email.CC))
 		b.WriteByte('\n')
 	}
 
-	b.WriteString(mutedTextStyle.Render("Date:    "))
-	b.WriteString(email.Date.Format("Jan 2, 2006 3:04 PM"))
-	b.WriteByte('\n')
-
-	b.WriteString(mutedTextStyle.Render("Subject: "))
-	b.WriteString(email.Subject)
+	b.WriteString(st.mutedText.Render("Date:    "))
+	b.WriteString(domain.FormatDate(email.Date, "Jan 2, 2006 3:04 PM"))
 	b.WriteByte('\n')
 
-	// Separator
-	sepWidth := width
-	if sepWidth < 20 {
-		sepWidth = 20
+	if receivedDiffers(email) {
+		b.WriteString(st.mutedText.Render("Received:"))
+		b.WriteString(" " + email.ReceivedAt.Local().Format("Jan 2, 2006 3:04 PM"))
+		b.WriteByte('\n')
 	}
-	b.WriteString(mutedTextStyle.Render(strings.Repeat("\u2500", sepWidth)))
+
+	b.WriteString(st.mutedText.Render("Subject: "))
+	b.WriteString(email.Subject)
 	b.WriteByte('\n')
 
-	// Body
-	body := email.Body
-	if body == "" && email.BodyHTML != "" {
-		body = "[HTML content - plain text not available]"
-	}
-	if body != "" {
+	list, isList := email.MailingList()
+	switch {
+	case isList && email.ListUnsubscribe != "":
+		b.WriteString(st.mutedText.Render("List:    "))
+		b.WriteString(list.DisplayName())
+		b.WriteString(st.mutedText.Render(" (press U to unsubscribe)"))
+		b.WriteByte('\n')
+	case isList:
+		b.WriteString(st.mutedText.Render("List:    "))
+		b.WriteString(list.DisplayName())
+		b.WriteByte('\n')
+	case email.ListUnsubscribe != "":
+		b.WriteString(st.mutedText.Render("List:    press U to unsubscribe"))
 		b.WriteByte('\n')
-		b.WriteString(body)
 	}
 
 	return b.String()
 }
 
+// renderCompactHeader formats the header as a single line, such as
+// "Alice → Bob +2 • Lunch? • 2h ago", clipped to width.
+func renderCompactHeader(email *domain.Email, width int) string {
+	parts := []string{addressDisplayName(email.From)}
+	if len(email.To) > 0 {
+		to := addressDisplayName(email.To[0])
+		if more := len(email.To) + len(email.CC) - 1; more > 0 {
+			to += fmt.Sprintf(" +%d", more)
+		}
+		parts[0] += " \u2192 " + to
+	}
+	parts = append(parts, email.Subject, relativeDate(email.Date))
+	return truncate(strings.Join(parts, " \u2022 "), max(width, 20)) + "\n"
+}
+
+// receivedDrift is how far the Date header may be from the received time
+// before the reader shows both; ordinary delivery delays stay hidden.
+const receivedDrift = 10 * time.Minute
+
+// receivedDiffers reports whether email's Date header is far enough from
+// when it was received to be worth showing both, as with forged dates.
+func receivedDiffers(email *domain.Email) bool {
+	if email.ReceivedAt.IsZero() {
+		return false
+	}
+	d := email.Date.Sub(email.ReceivedAt)
+	return d > receivedDrift || d < -receivedDrift
+}
+
 // renderThread formats all messages in a thread, separated by blank lines
-// and separator lines, with the most recent message at the bottom.
-func renderThread(thread *domain.Thread, width int) string {
+// and separator lines, with the most recent message at the bottom. starts
+// holds the line each message begins on.
+func renderThread(st styles, thread *domain.Thread, width int, opts renderOptions) (content string, starts []int) {
 	if len(thread.Messages) == 0 {
-		return mutedTextStyle.Render("Empty thread")
+		return st.mutedText.Render("Empty thread"), nil
 	}
 
 	var parts []string
+	line := 0
 	for i := range thread.Messages {
-		parts = append(parts, renderEmail(&thread.Messages[i], width))
+		part, _ := renderEmail(st, &thread.Messages[i], width, opts)
+		parts = append(parts, part)
+		starts = append(starts, line)
+		// Each part is followed by a newline, the separator and a newline.
+		line += strings.Count(part, "\n") + 2
 	}
 
 	sepWidth := width
 	if sepWidth < 20 {
 		sepWidth = 20
 	}
-	separator := "\n" + mutedTextStyle.Render(strings.Repeat("\u2500", sepWidth)) + "\n"
+	separator := "\n" + st.mutedText.Render(strings.Repeat("\u2500", sepWidth)) + "\n"
+
+	return strings.Join(parts, separator), starts
+}
+
+// renderEventCard formats a calendar invitation as a compact bordered card.
+func renderEventCard(st styles, ev *domain.CalendarEvent, width int) string {
+	var lines []string
+
+	title := "Event"
+	if ev.Method == "CANCEL" {
+		title = "Event cancelled"
+	}
+	lines = append(lines, st.title.Render(title))
+
+	if ev.Summary != "" {
+		lines = append(lines, ev.Summary)
+	}
+	if when := formatEventTime(ev); when != "" {
+		lines = append(lines, st.mutedText.Render("When:      ")+when)
+	}
+	if ev.Location != "" {
+		lines = append(lines, st.mutedText.Render("Where:     ")+ev.Location)
+	}
+	if ev.Organizer.Email != "" {
+		lines = append(lines, st.mutedText.Render("Organizer: ")+ev.Organizer.String())
+	}
+	if ev.Method == "REQUEST" {
+		lines = append(lines, st.mutedText.Render("Accept or decline from your calendar, or reply to the organizer."))
+	}
+
+	cardWidth := width - 2
+	if cardWidth > 70 {
+		cardWidth = 70
+	}
+	if cardWidth < 20 {
+		cardWidth = 20
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(st.theme.Primary).
+		Padding(0, 1).
+		Width(cardWidth).
+		Render(strings.Join(lines, "\n"))
+}
+
+// formatEventTime renders an event's start and end in the local time zone.
+func formatEventTime(ev *domain.CalendarEvent) string {
+	if ev.Start.IsZero() {
+		return ""
+	}
+	if ev.AllDay {
+		return ev.Start.Format("Mon, Jan 2 2006") + " (all day)"
+	}
+	start := ev.Start.Local()
+	when := start.Format("Mon, Jan 2 2006 3:04 PM")
+	if !ev.End.IsZero() {
+		end := ev.End.Local()
+		if end.YearDay() == start.YearDay() && end.Year() == start.Year() {
+			when += " – " + end.Format("3:04 PM")
+		} else {
+			when += " – " + end.Format("Mon, Jan 2 2006 3:04 PM")
+		}
+	}
+	return when
+}
+
+// searchTerms extracts the plain words from a full-text search query,
+// dropping FTS operators, column filters, quotes, and prefix wildcards.
+func searchTerms(query string) []string {
+	var terms []string
+	for _, f := range strings.Fields(query) {
+		switch f {
+		case "AND", "OR", "NOT", "NEAR":
+			continue
+		}
+		if _, after, ok := strings.Cut(f, ":"); ok {
+			f = after
+		}
+		f = strings.Trim(f, `"()*^+-`)
+		if len(f) >= 2 {
+			terms = append(terms, f)
+		}
+	}
+	return terms
+}
+
+// matchLineOffset returns the index of the first line in content containing
+// any of terms (case-insensitively), or -1 if none match.
+func matchLineOffset(content string, terms []string) int {
+	if len(terms) == 0 {
+		return -1
+	}
+	for i, line := range strings.Split(content, "\n") {
+		lower := strings.ToLower(line)
+		for _, t := range terms {
+			if strings.Contains(lower, strings.ToLower(t)) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// scrollOffsetForMatch returns the scroll offset that places the match line
+// contextLines below the top of the viewport, clamped to [0, maxScroll].
+func scrollOffsetForMatch(line, contextLines, maxScroll int) int {
+	offset := line - contextLines
+	if offset > maxScroll {
+		offset = maxScroll
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset
+}
 
-	return strings.Join(parts, separator)
+// highlightTerms wraps case-insensitive occurrences of terms in s with st.match.
+func highlightTerms(st styles, s string, terms []string) string {
+	if len(terms) == 0 {
+		return s
+	}
+	var b strings.Builder
+	i := 0
+	for i < len(s) {
+		n := 0
+		for _, t := range terms {
+			if len(t) > n && len(s)-i >= len(t) && strings.EqualFold(s[i:i+len(t)], t) {
+				n = len(t)
+			}
+		}
+		if n == 0 {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		b.WriteString(st.match.Render(s[i : i+n]))
+		i += n
+	}
+	return b.String()
 }
 
 // formatAddresses joins a slice of addresses into a comma-separated string.
@@ -318,7 +965,7 @@ func formatAddresses(addrs []domain.Address) string {
 
 	parts := make([]string, len(addrs))
 	for i, a := range addrs {
-		parts[i] = a.String()
+		parts[i] = a.Display()
 	}
 	return fmt.Sprintf("%s", strings.Join(parts, ", "))
 }
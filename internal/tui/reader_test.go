@@ -0,0 +1,455 @@
+package tui
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"invoice", []string{"invoice"}},
+		{"quarterly AND report", []string{"quarterly", "report"}},
+		{`"exact phrase"`, []string{"exact", "phrase"}},
+		{"subject:budget plan*", []string{"budget", "plan"}},
+		{"a", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := searchTerms(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("searchTerms(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMatchLineOffset(t *testing.T) {
+	content := strings.Join([]string{
+		"From: alice@example.com",
+		"Subject: Hello",
+		"",
+		"Some intro text.",
+		"The Invoice is attached.",
+		"Another invoice line.",
+	}, "\n")
+
+	tests := []struct {
+		name  string
+		terms []string
+		want  int
+	}{
+		{"case insensitive", []string{"invoice"}, 4},
+		{"first of several terms", []string{"another", "intro"}, 3},
+		{"header match", []string{"alice"}, 0},
+		{"no match", []string{"missing"}, -1},
+		{"no terms", nil, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchLineOffset(content, tt.terms); got != tt.want {
+				t.Errorf("matchLineOffset() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReader_ShowEmailScrollsToBodyMatch(t *testing.T) {
+	var body []string
+	for i := range 40 {
+		body = append(body, fmt.Sprintf("Filler line %d.", i))
+	}
+	body = append(body, "The invoice total is due Friday.")
+	for i := range 40 {
+		body = append(body, fmt.Sprintf("Trailing line %d.", i))
+	}
+	r := newReader()
+	r.SetSize(80, 10)
+	r.contextLines = 2
+	r.ShowEmail(&domain.Email{
+		ID:      "m1",
+		From:    domain.Address{Name: "Invoice Bot", Email: "invoice@example.com"},
+		Subject: "Invoice 42",
+		Body:    strings.Join(body, "\n"),
+	}, "invoice")
+
+	want := -1
+	for i, line := range strings.Split(r.content, "\n") {
+		if strings.Contains(line, "total is due") {
+			want = i
+		}
+	}
+	if want < 0 {
+		t.Fatalf("body match not rendered:\n%s", r.content)
+	}
+	if got := r.scrollOffset; got != want-2 {
+		t.Errorf("scrollOffset = %d, want %d (two lines above the body match, not the subject)", got, want-2)
+	}
+}
+
+func TestScrollOffsetForMatch(t *testing.T) {
+	tests := []struct {
+		line, context, maxScroll int
+		want                     int
+	}{
+		{line: 20, context: 3, maxScroll: 50, want: 17},
+		{line: 2, context: 3, maxScroll: 50, want: 0},
+		{line: 40, context: 3, maxScroll: 30, want: 30},
+		{line: 10, context: 0, maxScroll: 30, want: 10},
+	}
+	for _, tt := range tests {
+		if got := scrollOffsetForMatch(tt.line, tt.context, tt.maxScroll); got != tt.want {
+			t.Errorf("scrollOffsetForMatch(%d, %d, %d) = %d, want %d",
+				tt.line, tt.context, tt.maxScroll, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightTerms_PreservesText(t *testing.T) {
+	in := "Pay the INVOICE today"
+	got := highlightTerms(defaultStyles(), in, []string{"invoice"})
+	if !strings.Contains(got, "INVOICE") {
+		t.Errorf("highlightTerms() lost original casing: %q", got)
+	}
+	if highlightTerms(defaultStyles(), in, nil) != in {
+		t.Error("highlightTerms() with no terms should return input unchanged")
+	}
+}
+
+func TestReader_ToggleRemoteContentPerMessage(t *testing.T) {
+	html := `<p>Sale!</p><img src="https://cdn.example.com/banner.png" alt="Banner">` +
+		`<img src="https://t.example.com/o.gif" width="1" height="1">`
+	r := newReader()
+	r.focused = true
+	r.SetSize(80, 20)
+	r.ShowEmail(&domain.Email{ID: "m1", BodyHTML: html}, "")
+	if !strings.Contains(r.content, "[image blocked]") || strings.Contains(r.content, "banner.png") {
+		t.Fatalf("remote image should be blocked by default:\n%s", r.content)
+	}
+
+	r, _ = r.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")})
+	if !strings.Contains(r.content, "banner.png") {
+		t.Errorf("I should load remote images:\n%s", r.content)
+	}
+	if strings.Contains(r.content, "o.gif") {
+		t.Error("tracking pixel should stay dropped")
+	}
+
+	// The toggle applies to the open message only.
+	r.ShowEmail(&domain.Email{ID: "m2", BodyHTML: html}, "")
+	if strings.Contains(r.content, "banner.png") {
+		t.Error("the next message should start with remote images blocked")
+	}
+}
+
+func TestReader_TruncatedBodyOffersFullFetch(t *testing.T) {
+	email := &domain.Email{ID: "m1", Body: strings.Repeat("news ", 100)}
+	email.TruncateBody(50)
+	r := newReader()
+	r.focused = true
+	r.SetSize(80, 20)
+	r.ShowEmail(email, "")
+	if !strings.Contains(r.content, "Body truncated") || !strings.Contains(r.content, domain.BodyTruncatedMarker) {
+		t.Fatalf("reader should say the body was truncated:\n%s", r.content)
+	}
+
+	_, cmd := r.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")})
+	if cmd == nil {
+		t.Fatal("B should ask for the full body")
+	}
+	if msg, ok := cmd().(fullBodyMsg); !ok || msg.emailID != "m1" {
+		t.Errorf("B emitted %#v, want fullBodyMsg for m1", cmd())
+	}
+
+	r.ShowEmail(&domain.Email{ID: "m2", Body: "short"}, "")
+	if strings.Contains(r.content, "Body truncated") {
+		t.Error("a whole body should not be marked truncated")
+	}
+	if _, cmd := r.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")}); cmd != nil {
+		t.Error("B should do nothing for a whole body")
+	}
+}
+
+func TestReader_ThreadMessageNavigation(t *testing.T) {
+	var msgs []domain.Email
+	for i := range 3 {
+		body := strings.Repeat(fmt.Sprintf("line of message %d\n", i+1), 15)
+		msgs = append(msgs, domain.Email{ID: fmt.Sprintf("m%d", i+1), Subject: fmt.Sprintf("Subject %d", i+1), Body: body})
+	}
+	r := newReader()
+	r.focused = true
+	r.SetSize(80, 10)
+	r.ShowThread(&domain.Thread{ID: "t1", Messages: msgs})
+
+	if len(r.messageStarts) != 3 || r.messageStarts[0] != 0 {
+		t.Fatalf("messageStarts = %v, want 3 starts from 0", r.messageStarts)
+	}
+	if !strings.Contains(r.View(), "Message 1/3") {
+		t.Errorf("header should show the first message:\n%s", r.View())
+	}
+
+	next := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")}
+	r, _ = r.Update(next)
+	if r.scrollOffset != r.messageStarts[1] {
+		t.Errorf("after n scrollOffset = %d, want %d", r.scrollOffset, r.messageStarts[1])
+	}
+	lines := strings.Split(r.content, "\n")
+	if !strings.Contains(lines[r.scrollOffset], "From:") {
+		t.Errorf("n should land on a message header, got %q", lines[r.scrollOffset])
+	}
+	if !strings.Contains(r.View(), "Message 2/3") {
+		t.Errorf("header should show the second message:\n%s", r.View())
+	}
+
+	r, _ = r.Update(next)
+	r, _ = r.Update(next)
+	if r.message != 2 {
+		t.Errorf("n past the last message = %d, want 2", r.message)
+	}
+
+	r, _ = r.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	if r.message != 1 || r.scrollOffset != r.messageStarts[1] {
+		t.Errorf("after p message/offset = %d/%d, want 1/%d", r.message, r.scrollOffset, r.messageStarts[1])
+	}
+}
+
+func TestReader_RefreshThreadKeepsPosition(t *testing.T) {
+	var msgs []domain.Email
+	for i := range 3 {
+		msgs = append(msgs, domain.Email{ID: fmt.Sprintf("m%d", i+1), ThreadID: "t1", Body: strings.Repeat("line\n", 15)})
+	}
+	r := newReader()
+	r.focused = true
+	r.SetSize(80, 10)
+	r.ShowThread(&domain.Thread{ID: "t1", Messages: msgs})
+	r.jumpToMessage(2)
+
+	_, cmd := r.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")})
+	if cmd == nil {
+		t.Fatal("g should request a thread refresh")
+	}
+	if got, ok := cmd().(refreshThreadMsg); !ok || got.threadID != "t1" || got.emailID != "" {
+		t.Fatalf("g emitted %#v, want refreshThreadMsg for t1", cmd())
+	}
+
+	// The last message was deleted remotely.
+	r.Reload(nil, &domain.Thread{ID: "t1", Messages: msgs[:2]})
+	if r.message != 1 || len(r.messageStarts) != 2 {
+		t.Errorf("after reload message = %d of %d, want 1 of 2", r.message, len(r.messageStarts))
+	}
+}
+
+func TestReader_LongQuotesCollapsedByDefault(t *testing.T) {
+	body := "Sounds good.\n\n" + strings.Repeat("> earlier reply text\n", 8) + "\n> short quote"
+	r := newReader()
+	r.focused = true
+	r.SetSize(80, 40)
+	r.ShowEmail(&domain.Email{ID: "m1", Body: body}, "")
+
+	if strings.Count(r.content, "earlier reply text") != 0 {
+		t.Errorf("long quote should be collapsed:\n%s", r.content)
+	}
+	if !strings.Contains(r.content, "8 quoted lines hidden") || !strings.Contains(r.content, "> short quote") {
+		t.Errorf("want a placeholder for the long quote and the short one kept:\n%s", r.content)
+	}
+
+	r, _ = r.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if n := strings.Count(r.content, "earlier reply text"); n != 8 {
+		t.Errorf("x should show all 8 quoted lines, got %d", n)
+	}
+}
+
+func TestRenderEmail_ShowsDivergentReceivedDate(t *testing.T) {
+	received := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	email := &domain.Email{ID: "m1", Date: received.Add(2 * time.Minute), ReceivedAt: received}
+	if got, _ := renderEmail(defaultStyles(), email, 80, renderOptions{}); strings.Contains(got, "Received:") {
+		t.Errorf("a normal delivery delay should not show the received date:\n%s", got)
+	}
+
+	email.Date = received.AddDate(5, 0, 0)
+	got, _ := renderEmail(defaultStyles(), email, 80, renderOptions{})
+	if !strings.Contains(got, "Received: "+received.Local().Format("Jan 2, 2006 3:04 PM")) {
+		t.Errorf("a forged Date should show the received date too:\n%s", got)
+	}
+}
+
+func TestRenderEmail_CompactHeader(t *testing.T) {
+	email := &domain.Email{
+		ID:      "m1",
+		From:    domain.Address{Name: "Alice", Email: "alice@example.com"},
+		To:      []domain.Address{{Name: "Bob", Email: "bob@example.com"}, {Email: "carol@example.com"}},
+		CC:      []domain.Address{{Email: "dave@example.com"}},
+		Subject: "Lunch?",
+		Date:    time.Now().Add(-2 * time.Hour),
+		Body:    "Noon works.",
+	}
+
+	full, _ := renderEmail(defaultStyles(), email, 80, renderOptions{})
+	for _, want := range []string{"From:    Alice <alice@example.com>", "CC:      dave@example.com", "Subject: Lunch?"} {
+		if !strings.Contains(full, want) {
+			t.Errorf("full header missing %q:\n%s", want, full)
+		}
+	}
+
+	compact, _ := renderEmail(defaultStyles(), email, 80, renderOptions{compact: true})
+	lines := strings.Split(compact, "\n")
+	if want := "Alice \u2192 Bob +2 \u2022 Lunch? \u2022 " + relativeDate(email.Date); lines[0] != want {
+		t.Errorf("compact header = %q, want %q", lines[0], want)
+	}
+	if strings.Contains(compact, "From:") || !strings.Contains(compact, "Noon works.") {
+		t.Errorf("compact output should keep the body but drop the header block:\n%s", compact)
+	}
+	if len(lines) >= len(strings.Split(full, "\n")) {
+		t.Error("compact output should be shorter than the full output")
+	}
+
+	r := newReader()
+	r.focused = true
+	r.SetSize(80, 20)
+	r.ShowEmail(email, "")
+	r, _ = r.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("H")})
+	if strings.Contains(r.content, "From:") {
+		t.Errorf("H should switch to the compact header:\n%s", r.content)
+	}
+}
+
+func TestReader_InlineReply(t *testing.T) {
+	r := newReader()
+	r.focused = true
+	r.inlineReply = true
+	r.defaultBCC = []domain.Address{{Email: "me@example.com"}}
+	r.SetSize(80, 20)
+	r.ShowThread(&domain.Thread{ID: "t1", Messages: []domain.Email{
+		{ID: "m1", ThreadID: "t1", MessageID: "<m1@example.com>", From: domain.Address{Email: "ann@example.com"}, Subject: "Lunch", Body: "Noon?"},
+		{ID: "m2", ThreadID: "t1", MessageID: "<m2@example.com>", From: domain.Address{Name: "Bob", Email: "bob@example.com"}, Subject: "Re: Lunch", Body: "Sure."},
+	}})
+
+	r, _ = r.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if !r.IsReplying() {
+		t.Fatal("r should open the inline reply box")
+	}
+	if _, cmd := r.Update(tea.KeyMsg{Type: tea.KeyCtrlS}); cmd != nil {
+		t.Error("Ctrl+S with no text should not send")
+	}
+
+	r, _ = r.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("See you there")})
+	_, cmd := r.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if cmd == nil {
+		t.Fatal("Ctrl+S should send the reply")
+	}
+	msg, ok := cmd().(sendMsg)
+	if !ok {
+		t.Fatalf("Ctrl+S emitted %#v, want sendMsg", cmd())
+	}
+	email := msg.email
+	if len(email.To) != 1 || email.To[0].Email != "bob@example.com" {
+		t.Errorf("To = %v, want the latest sender", email.To)
+	}
+	if email.Subject != "Re: Lunch" {
+		t.Errorf("Subject = %q, want %q", email.Subject, "Re: Lunch")
+	}
+	if email.ThreadID != "t1" || email.InReplyTo != "<m2@example.com>" {
+		t.Errorf("ThreadID/InReplyTo = %q/%q, want t1/<m2@example.com>", email.ThreadID, email.InReplyTo)
+	}
+	if !strings.HasPrefix(email.Body, "See you there\n") || !strings.Contains(email.Body, "> Sure.") {
+		t.Errorf("Body = %q, want the text above the quoted message", email.Body)
+	}
+	if len(email.BCC) != 1 || email.BCC[0].Email != "me@example.com" {
+		t.Errorf("BCC = %v, want the compose default", email.BCC)
+	}
+
+	r, _ = r.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if r.IsReplying() || !r.IsVisible() {
+		t.Error("Esc should close the reply box and keep the reader open")
+	}
+}
+
+func TestReader_ToggleBodyView(t *testing.T) {
+	r := newReader()
+	r.focused = true
+	r.SetSize(80, 20)
+	r.ShowEmail(&domain.Email{ID: "m1", Body: "plain part", BodyHTML: "<p>html part</p>"}, "")
+	if !strings.Contains(r.content, "plain part") || strings.Contains(r.content, "html part") {
+		t.Fatalf("the text part should be shown by default:\n%s", r.content)
+	}
+	if !strings.Contains(r.content, "Showing the text part") {
+		t.Errorf("header should name the part shown:\n%s", r.content)
+	}
+
+	v := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")}
+	r, _ = r.Update(v)
+	if !strings.Contains(r.content, "html part") || strings.Contains(r.content, "plain part") {
+		t.Errorf("v should switch to the HTML part:\n%s", r.content)
+	}
+	if !strings.Contains(r.content, "Showing the HTML part") {
+		t.Errorf("header should name the HTML part:\n%s", r.content)
+	}
+
+	// A message missing one part shows the other whatever the toggle says.
+	r.ShowEmail(&domain.Email{ID: "m2", Body: "text only"}, "")
+	r, _ = r.Update(v)
+	if !strings.Contains(r.content, "text only") || strings.Contains(r.content, "Showing the") {
+		t.Errorf("text-only mail should show its text without a part indicator:\n%s", r.content)
+	}
+	r.ShowEmail(&domain.Email{ID: "m3", BodyHTML: "<p>html only</p>"}, "")
+	if !strings.Contains(r.content, "html only") {
+		t.Errorf("HTML-only mail should fall back to its HTML part:\n%s", r.content)
+	}
+
+	// The configured preference applies to each newly opened message.
+	r.preferHTML = true
+	r.ShowEmail(&domain.Email{ID: "m4", Body: "plain part", BodyHTML: "<p>html part</p>"}, "")
+	if !strings.Contains(r.content, "html part") {
+		t.Errorf("prefer_html should open on the HTML part:\n%s", r.content)
+	}
+}
+
+func TestReader_SetSizeRewrapsBody(t *testing.T) {
+	r := newReader()
+	r.SetSize(100, 20)
+	r.ShowEmail(&domain.Email{ID: "m1", Body: strings.Repeat("word ", 60)}, "")
+	wide := strings.Count(r.content, "\n")
+
+	r.SetSize(40, 20)
+	if narrow := strings.Count(r.content, "\n"); narrow <= wide {
+		t.Errorf("lines at width 40 = %d, want more than the %d at width 100", narrow, wide)
+	}
+	for _, l := range strings.Split(r.content, "\n") {
+		if w := lipgloss.Width(l); w > 40 {
+			t.Errorf("line %q is %d wide after resizing to 40", l, w)
+		}
+	}
+}
+
+func TestReader_HalfPageScroll(t *testing.T) {
+	r := newReader()
+	r.focused = true
+	r.SetSize(80, 10)
+	r.ShowEmail(&domain.Email{ID: "e1", Subject: "Long", Body: strings.Repeat("line\n", 40)}, "")
+	if r.maxScroll < 10 {
+		t.Fatalf("maxScroll = %d, want a body longer than a page", r.maxScroll)
+	}
+
+	down := tea.KeyMsg{Type: tea.KeyCtrlD}
+	r, _ = r.Update(down)
+	if r.scrollOffset != 5 {
+		t.Errorf("ctrl+d: scrollOffset = %d, want 5", r.scrollOffset)
+	}
+	for range 20 {
+		r, _ = r.Update(down)
+	}
+	if r.scrollOffset != r.maxScroll {
+		t.Errorf("ctrl+d past the end: scrollOffset = %d, want %d", r.scrollOffset, r.maxScroll)
+	}
+	r, _ = r.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	if r.scrollOffset != r.maxScroll-5 {
+		t.Errorf("ctrl+u: scrollOffset = %d, want %d", r.scrollOffset, r.maxScroll-5)
+	}
+}
@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lu-zhengda/termail/internal/store"
+)
+
+// storeVersionMsg carries the store's data version from a periodic poll.
+type storeVersionMsg struct {
+	version int64
+}
+
+// changeWatcher decides when external store changes warrant reloading the
+// view. A reload fires only once the version has changed since the last
+// reload and then held steady for one poll, so a sync that commits many
+// batches triggers a single reload at the end rather than one per batch.
+type changeWatcher struct {
+	primed bool
+	loaded int64
+	last   int64
+}
+
+// observe records a polled version and reports whether to reload.
+func (w *changeWatcher) observe(version int64) bool {
+	if !w.primed {
+		w.primed = true
+		w.loaded, w.last = version, version
+		return false
+	}
+	stable := version == w.last
+	w.last = version
+	if stable && version != w.loaded {
+		w.loaded = version
+		return true
+	}
+	return false
+}
+
+// pollStoreCmd waits interval and then reads the store's data version.
+// Poll errors are ignored; the next tick simply tries again.
+func pollStoreCmd(d store.ChangeDetector, interval time.Duration) tea.Cmd {
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		v, err := d.DataVersion(context.Background())
+		if err != nil {
+			return storeVersionMsg{version: -1}
+		}
+		return storeVersionMsg{version: v}
+	})
+}
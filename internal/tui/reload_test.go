@@ -0,0 +1,26 @@
+package tui
+
+import "testing"
+
+func TestChangeWatcher_Observe(t *testing.T) {
+	var w changeWatcher
+
+	steps := []struct {
+		version int64
+		want    bool
+	}{
+		{1, false}, // first poll primes the baseline
+		{1, false}, // unchanged
+		{2, false}, // changed, but a sync may still be writing
+		{3, false}, // still changing
+		{3, true},  // settled: reload once
+		{3, false}, // already reloaded at this version
+		{4, false},
+		{4, true},
+	}
+	for i, s := range steps {
+		if got := w.observe(s.version); got != s.want {
+			t.Errorf("step %d: observe(%d) = %v, want %v", i, s.version, got, s.want)
+		}
+	}
+}
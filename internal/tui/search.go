@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -19,6 +20,7 @@ type searchQueryMsg struct {
 
 type searchResultSelectedMsg struct {
 	emailID string
+	query   string
 }
 
 type closeSearchMsg struct{}
@@ -28,11 +30,18 @@ type searchModel struct {
 	input     textinput.Model
 	results   []domain.Email
 	cursor    int
+	offset    int
 	searching bool
 	inputMode bool
 	width     int
 	height    int
 	focused   bool
+
+	// pendingG is set by a first "g" in results mode so a second one jumps
+	// to the top. Any other key clears it.
+	pendingG bool
+
+	styles styles
 }
 
 func newSearch() searchModel {
@@ -43,6 +52,7 @@ func newSearch() searchModel {
 	return searchModel{
 		input:     ti,
 		inputMode: true,
+		styles:    defaultStyles(),
 	}
 }
 
@@ -53,6 +63,29 @@ func (s searchModel) Update(msg tea.Msg) (searchModel, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		pendingG := s.pendingG
+		s.pendingG = false
+		if !s.inputMode {
+			switch {
+			case key.Matches(msg, keys.Top):
+				if !pendingG {
+					s.pendingG = true
+				} else {
+					s.moveCursor(0)
+				}
+				return s, nil
+			case key.Matches(msg, keys.Bottom):
+				s.moveCursor(len(s.results) - 1)
+				return s, nil
+			case key.Matches(msg, keys.HalfPageDown):
+				s.moveCursor(s.cursor + s.halfPage())
+				return s, nil
+			case key.Matches(msg, keys.HalfPageUp):
+				s.moveCursor(s.cursor - s.halfPage())
+				return s, nil
+			}
+		}
+
 		switch {
 		case key.Matches(msg, keys.Back):
 			return s, func() tea.Msg { return closeSearchMsg{} }
@@ -67,6 +100,7 @@ func (s searchModel) Update(msg tea.Msg) (searchModel, tea.Cmd) {
 				s.inputMode = false
 				s.input.Blur()
 				s.cursor = 0
+				s.offset = 0
 				return s, func() tea.Msg { return searchQueryMsg{query: q} }
 			}
 			// In results mode: select highlighted result.
@@ -74,17 +108,18 @@ func (s searchModel) Update(msg tea.Msg) (searchModel, tea.Cmd) {
 			if id == "" {
 				return s, nil
 			}
-			return s, func() tea.Msg { return searchResultSelectedMsg{emailID: id} }
+			q := s.input.Value()
+			return s, func() tea.Msg { return searchResultSelectedMsg{emailID: id, query: q} }
 
 		case key.Matches(msg, keys.Up):
-			if !s.inputMode && s.cursor > 0 {
-				s.cursor--
+			if !s.inputMode {
+				s.moveCursor(s.cursor - 1)
 			}
 			return s, nil
 
 		case key.Matches(msg, keys.Down):
-			if !s.inputMode && s.cursor < len(s.results)-1 {
-				s.cursor++
+			if !s.inputMode {
+				s.moveCursor(s.cursor + 1)
 			}
 			return s, nil
 		}
@@ -115,32 +150,23 @@ func (s searchModel) View() string {
 	if len(s.results) == 0 {
 		if !s.inputMode {
 			b.WriteByte('\n')
-			b.WriteString(mutedTextStyle.Render("No results"))
+			b.WriteString(s.styles.mutedText.Render("No results"))
 		}
 		return b.String()
 	}
 
 	b.WriteByte('\n')
-	b.WriteString(titleStyle.Render(fmt.Sprintf("Results (%d):", len(s.results))))
+	b.WriteString(s.styles.title.Render(fmt.Sprintf("Results (%d):", len(s.results))))
 	b.WriteByte('\n')
 
-	// Determine how many results we can show.
-	maxRows := s.height - 4 // input(1) + blank(1) + header(1) + padding(1)
-	if maxRows < 1 {
-		maxRows = 1
-	}
-	end := len(s.results)
-	if end > maxRows {
-		end = maxRows
-	}
-
-	for i := 0; i < end; i++ {
-		if i > 0 {
+	end := min(s.offset+s.visibleRows(), len(s.results))
+	for i := s.offset; i < end; i++ {
+		if i > s.offset {
 			b.WriteByte('\n')
 		}
 		line := s.renderResultRow(i)
 		if !s.inputMode && i == s.cursor && s.focused {
-			line = selectedStyle.Width(s.width).Render(line)
+			line = s.styles.selected.Width(s.width).Render(line)
 		}
 		b.WriteString(line)
 	}
@@ -148,6 +174,33 @@ func (s searchModel) View() string {
 	return b.String()
 }
 
+// visibleRows returns how many results fit under the input and header;
+// each takes a snippet line too.
+func (s searchModel) visibleRows() int {
+	return max((s.height-4)/2, 1) // input(1) + blank(1) + header(1) + padding(1)
+}
+
+// halfPage returns how many results ctrl+d and ctrl+u move the cursor.
+func (s searchModel) halfPage() int {
+	return max(s.visibleRows()/2, 1)
+}
+
+// moveCursor puts the cursor on result idx, clamped to the results, and
+// scrolls it into view.
+func (s *searchModel) moveCursor(idx int) {
+	s.cursor = max(min(idx, len(s.results)-1), 0)
+	s.adjustScroll()
+}
+
+func (s *searchModel) adjustScroll() {
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	}
+	if visible := s.visibleRows(); s.cursor >= s.offset+visible {
+		s.offset = s.cursor - visible + 1
+	}
+}
+
 // Open activates search mode and focuses the text input.
 func (s *searchModel) Open() {
 	s.searching = true
@@ -165,12 +218,14 @@ func (s *searchModel) Close() {
 	s.input.Blur()
 	s.results = nil
 	s.cursor = 0
+	s.offset = 0
 }
 
 // SetResults updates the results list after a search query completes.
 func (s *searchModel) SetResults(results []domain.Email) {
 	s.results = results
 	s.cursor = 0
+	s.offset = 0
 }
 
 // SetSize updates the dimensions available for rendering.
@@ -178,6 +233,7 @@ func (s *searchModel) SetSize(w, h int) {
 	s.width = w
 	s.height = h
 	s.input.Width = w - 4 // account for prompt and padding
+	s.adjustScroll()
 }
 
 // IsActive reports whether the search overlay is currently shown.
@@ -208,7 +264,7 @@ func (s searchModel) renderResultRow(idx int) string {
 
 	star := "  "
 	if e.IsStarred {
-		star = starStyle.Render("★ ")
+		star = s.styles.star.Render("★ ")
 	}
 
 	from := addressDisplayName(e.From)
@@ -226,13 +282,47 @@ func (s searchModel) renderResultRow(idx int) string {
 
 	fromCol := lipgloss.NewStyle().Width(fromWidth).Render(from)
 	subjectCol := lipgloss.NewStyle().Width(subjectWidth).Render(subject)
-	dateCol := mutedTextStyle.Width(dateWidth).Render(date)
+	dateCol := s.styles.mutedText.Width(dateWidth).Render(date)
 
 	line := star + fromCol + "  " + subjectCol + "  " + dateCol
 
 	if !e.IsRead {
-		line = unreadStyle.Render(line)
+		line = s.styles.unread.Render(line)
 	}
 
-	return line
+	return line + "\n    " + renderSnippet(s.styles, e.Snippet, max(s.width-4, 10))
+}
+
+// renderSnippet renders a search snippet on one line of at most width
+// runes, styling the terms between the match markers and dimming the rest.
+func renderSnippet(st styles, snippet string, width int) string {
+	snippet = strings.Join(strings.Fields(snippet), " ")
+
+	var b strings.Builder
+	used := 0
+	match := false
+	for len(snippet) > 0 && used < width {
+		// Split off the text up to the next marker.
+		end := strings.IndexAny(snippet, domain.SnippetMatchStart+domain.SnippetMatchEnd)
+		if end < 0 {
+			end = len(snippet)
+		}
+		text := snippet[:end]
+		if n := utf8.RuneCountInString(text); used+n > width {
+			text = truncate(text, width-used)
+		}
+		used += utf8.RuneCountInString(text)
+		if match {
+			b.WriteString(st.match.Render(text))
+		} else {
+			b.WriteString(st.mutedText.Render(text))
+		}
+
+		if end == len(snippet) {
+			break
+		}
+		match = snippet[end:end+1] == domain.SnippetMatchStart
+		snippet = snippet[end+1:]
+	}
+	return b.String()
 }
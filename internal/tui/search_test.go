@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+func TestRenderSnippet(t *testing.T) {
+	st := defaultStyles()
+	snippet := "…the revised " + domain.SnippetMatchStart + "budget" + domain.SnippetMatchEnd +
+		"\ncovers two new hires…"
+
+	got := renderSnippet(st, snippet, 80)
+	if strings.ContainsAny(got, domain.SnippetMatchStart+domain.SnippetMatchEnd) {
+		t.Errorf("renderSnippet() = %q, markers should not be shown", got)
+	}
+	if !strings.Contains(got, "the revised budget covers two new hires") {
+		t.Errorf("renderSnippet() = %q, want the excerpt on one line", got)
+	}
+
+	short := renderSnippet(st, snippet, 15)
+	if n := utf8.RuneCountInString(short); n > 15 {
+		t.Errorf("renderSnippet(width 15) = %q (%d runes), want at most 15", short, n)
+	}
+}
+
+func TestSearch_JumpKeysScrollResults(t *testing.T) {
+	s := newSearch()
+	s.Open()
+	s.SetSize(80, 10) // three results fit
+	s.input.SetValue("budget")
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	var results []domain.Email
+	for i := range 8 {
+		results = append(results, domain.Email{ID: fmt.Sprintf("e%d", i), Subject: fmt.Sprintf("result %d", i)})
+	}
+	s.SetResults(results)
+
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")})
+	if s.SelectedEmailID() != "e7" || s.offset != 5 {
+		t.Errorf("G: selected %q offset %d, want e7 at offset 5", s.SelectedEmailID(), s.offset)
+	}
+	if view := s.View(); !strings.Contains(view, "result 7") || strings.Contains(view, "result 4") {
+		t.Errorf("view should scroll to the last results:\n%s", view)
+	}
+
+	g := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")}
+	s, _ = s.Update(g)
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	if s.SelectedEmailID() != "e6" || s.pendingG {
+		t.Errorf("g then ctrl+u: selected %q pendingG %v, want e6 and cleared", s.SelectedEmailID(), s.pendingG)
+	}
+	s, _ = s.Update(g)
+	s, _ = s.Update(g)
+	if s.SelectedEmailID() != "e0" || s.offset != 0 {
+		t.Errorf("gg: selected %q offset %d, want e0 at offset 0", s.SelectedEmailID(), s.offset)
+	}
+	if s.Query() != "budget" {
+		t.Errorf("jump keys must not edit the query, got %q", s.Query())
+	}
+}
@@ -35,27 +35,62 @@ var systemLabelNames = map[string]string{
 	domain.LabelSpam:    "Spam",
 }
 
+// sidebarItem is a single navigable row in the sidebar. User labels are
+// nested by their "/" hierarchy; rows for parents that exist only implicitly
+// have an empty labelID and can be expanded but not selected.
+type sidebarItem struct {
+	labelID     string
+	name        string
+	path        string
+	depth       int
+	user        bool
+	hasChildren bool
+}
+
 // sidebarModel displays a navigable list of email labels.
 type sidebarModel struct {
 	labels       []domain.Label
+	collapsed    map[string]bool
 	cursor       int
 	activeLabel  string
 	accountEmail string
 	width        int
 	height       int
 	focused      bool
+
+	// mailboxSize is the account's message count from the last sync; zero
+	// hides it.
+	mailboxSize int64
+
+	styles styles
 }
 
 // newSidebar creates a new sidebar with INBOX as the default active label.
 func newSidebar() sidebarModel {
 	return sidebarModel{
 		activeLabel: domain.LabelInbox,
+		collapsed:   make(map[string]bool),
+		styles:      defaultStyles(),
 	}
 }
 
-// SetLabels updates the label list displayed in the sidebar.
+// SetLabels updates the label list displayed in the sidebar, keeping the
+// cursor within the new list.
 func (s *sidebarModel) SetLabels(labels []domain.Label) {
 	s.labels = labels
+	s.clampCursor(len(s.items()))
+}
+
+// SetActiveLabel marks labelID as the label being shown and moves the
+// cursor to its row when visible.
+func (s *sidebarModel) SetActiveLabel(labelID string) {
+	s.activeLabel = labelID
+	for i, item := range s.items() {
+		if item.labelID == labelID {
+			s.cursor = i
+			return
+		}
+	}
 }
 
 // SetSize updates the sidebar dimensions.
@@ -70,7 +105,8 @@ func (s sidebarModel) Update(msg tea.Msg) (sidebarModel, tea.Cmd) {
 		return s, nil
 	}
 
-	total := s.totalItems()
+	items := s.items()
+	total := len(items)
 	if total == 0 {
 		return s, nil
 	}
@@ -88,12 +124,24 @@ func (s sidebarModel) Update(msg tea.Msg) (sidebarModel, tea.Cmd) {
 			if s.cursor >= total {
 				s.cursor = 0
 			}
+		case key.Matches(msg, keys.Expand):
+			s.toggleAt(items)
+		case key.Matches(msg, keys.Collapse):
+			s.collapseOrParent(items)
+		case key.Matches(msg, keys.Open):
+			s.expandOrChild(items)
 		case key.Matches(msg, keys.Enter):
-			if labelID, ok := s.labelIDAtCursor(); ok {
-				s.activeLabel = labelID
-				return s, func() tea.Msg {
-					return labelSelectedMsg{labelID: labelID}
-				}
+			if s.cursor < 0 || s.cursor >= total {
+				return s, nil
+			}
+			item := items[s.cursor]
+			if item.labelID == "" {
+				s.toggleAt(items)
+				return s, nil
+			}
+			s.activeLabel = item.labelID
+			return s, func() tea.Msg {
+				return labelSelectedMsg{labelID: item.labelID}
 			}
 		}
 	}
@@ -106,64 +154,65 @@ func (s sidebarModel) View() string {
 	var b strings.Builder
 
 	// Title and account
-	b.WriteString(titleStyle.Render("termail"))
+	b.WriteString(s.styles.title.Render("termail"))
 	b.WriteString("\n")
 	if s.accountEmail != "" {
-		b.WriteString(mutedTextStyle.Render(truncateEmail(s.accountEmail, max(s.width, 10))))
+		b.WriteString(s.styles.mutedText.Render(truncateEmail(s.accountEmail, max(s.width, 10))))
 	}
 	b.WriteString("\n")
+	if s.mailboxSize > 0 {
+		b.WriteString(s.styles.mutedText.Render(fmt.Sprintf("%d messages", s.mailboxSize)))
+		b.WriteString("\n")
+	}
 
 	if len(s.labels) == 0 {
-		b.WriteString(mutedTextStyle.Render("Loading labels..."))
+		b.WriteString(s.styles.mutedText.Render("Loading labels..."))
 		return b.String()
 	}
 
-	systemLabels, userLabels := s.partitionLabels()
-	itemIdx := 0
-
-	// System labels
-	for _, label := range systemLabels {
-		name := displayName(label)
-		line := s.renderLine(name, label.ID, itemIdx)
-		b.WriteString(line)
-		b.WriteString("\n")
-		itemIdx++
-	}
-
-	// Separator and user labels section
-	if len(userLabels) > 0 {
-		b.WriteString("\n")
-		b.WriteString(mutedTextStyle.Render(strings.Repeat("─", max(s.width, 10))))
-		b.WriteString("\n")
-		b.WriteString(mutedTextStyle.Render("Labels:"))
-		b.WriteString("\n")
-
-		for _, label := range userLabels {
-			name := displayName(label)
-			line := s.renderLine(name, label.ID, itemIdx)
-			b.WriteString(line)
+	inUserSection := false
+	for idx, item := range s.items() {
+		// Separator before the first user label
+		if item.user && !inUserSection {
+			inUserSection = true
+			b.WriteString("\n")
+			b.WriteString(s.styles.mutedText.Render(strings.Repeat("─", max(s.width, 10))))
+			b.WriteString("\n")
+			b.WriteString(s.styles.mutedText.Render("Labels:"))
 			b.WriteString("\n")
-			itemIdx++
 		}
+		b.WriteString(s.renderLine(item, idx))
+		b.WriteString("\n")
 	}
 
 	return b.String()
 }
 
-// renderLine renders a single label line with cursor highlighting and active marker.
-func (s sidebarModel) renderLine(name, labelID string, idx int) string {
+// renderLine renders a single label line with cursor highlighting, active
+// marker, nesting indent and expand/collapse marker.
+func (s sidebarModel) renderLine(item sidebarItem, idx int) string {
 	prefix := "  "
-	if labelID == s.activeLabel {
+	if item.labelID != "" && item.labelID == s.activeLabel {
 		prefix = "▶ "
 	}
 
-	line := fmt.Sprintf("%s%s", prefix, name)
+	marker := ""
+	if item.hasChildren {
+		marker = "▾ "
+		if s.collapsed[item.path] {
+			marker = "▸ "
+		}
+	} else if item.user {
+		marker = "  "
+	}
+
+	line := fmt.Sprintf("%s%s%s%s", prefix, strings.Repeat("  ", item.depth), marker, item.name)
 
 	// Pad to width so highlight covers the full line.
 	padded := lipgloss.NewStyle().Width(max(s.width, 10)).Render(line)
 
 	if s.focused && idx == s.cursor {
-		return selectedStyle.Render(padded)
+		return s.styles.selected.Render(padded)
 	}
 
 	return padded
@@ -192,20 +241,120 @@ func (s sidebarModel) partitionLabels() (system, user []domain.Label) {
 	return system, user
 }
 
-// totalItems returns the total number of navigable items.
-func (s sidebarModel) totalItems() int {
-	sys, usr := s.partitionLabels()
-	return len(sys) + len(usr)
+// items returns the navigable sidebar rows: system labels in canonical order
+// followed by the user label tree, omitting children of collapsed nodes.
+func (s sidebarModel) items() []sidebarItem {
+	system, user := s.partitionLabels()
+
+	items := make([]sidebarItem, 0, len(system)+len(user))
+	for _, l := range system {
+		items = append(items, sidebarItem{labelID: l.ID, name: displayName(l), path: l.ID})
+	}
+
+	domain.WalkLabelTree(domain.BuildLabelTree(user), func(n *domain.LabelNode, depth int) bool {
+		item := sidebarItem{
+			name:        n.Name,
+			path:        n.Path,
+			depth:       depth,
+			user:        true,
+			hasChildren: len(n.Children) > 0,
+		}
+		if n.Label != nil {
+			item.labelID = n.Label.ID
+		}
+		items = append(items, item)
+		return !s.collapsed[n.Path]
+	})
+	return items
+}
+
+// clampCursor keeps the cursor within a list of total items.
+func (s *sidebarModel) clampCursor(total int) {
+	if s.cursor >= total {
+		s.cursor = total - 1
+	}
+	if s.cursor < 0 {
+		s.cursor = 0
+	}
+}
+
+// collapseOrParent collapses the expanded node under the cursor, or moves
+// the cursor to its parent when it is already collapsed or a leaf.
+func (s *sidebarModel) collapseOrParent(items []sidebarItem) {
+	if s.cursor < 0 || s.cursor >= len(items) {
+		return
+	}
+	item := items[s.cursor]
+	if item.hasChildren && !s.collapsed[item.path] {
+		s.toggleAt(items)
+		return
+	}
+	for i := s.cursor - 1; i >= 0 && item.depth > 0; i-- {
+		if items[i].depth == item.depth-1 {
+			s.cursor = i
+			return
+		}
+	}
+}
+
+// expandOrChild expands the collapsed node under the cursor, or moves the
+// cursor to its first child when it is already expanded.
+func (s *sidebarModel) expandOrChild(items []sidebarItem) {
+	if s.cursor < 0 || s.cursor >= len(items) || !items[s.cursor].hasChildren {
+		return
+	}
+	if s.collapsed[items[s.cursor].path] {
+		s.toggleAt(items)
+		return
+	}
+	if s.cursor+1 < len(items) {
+		s.cursor++
+	}
 }
 
-// labelIDAtCursor returns the label ID at the current cursor position.
-func (s sidebarModel) labelIDAtCursor() (string, bool) {
-	sys, usr := s.partitionLabels()
-	all := append(sys, usr...)
-	if s.cursor < 0 || s.cursor >= len(all) {
-		return "", false
+// labelName returns the name of the label with the given ID, or "" when it
+// is not loaded.
+func (s sidebarModel) labelName(labelID string) string {
+	for _, l := range s.labels {
+		if l.ID == labelID {
+			return l.Name
+		}
+	}
+	return ""
+}
+
+// descendantLabelIDs returns the IDs of the user labels nested under the
+// label with the given ID, at any depth.
+func (s sidebarModel) descendantLabelIDs(labelID string) []string {
+	var parent string
+	for _, l := range s.labels {
+		if l.ID == labelID && l.Type == domain.LabelTypeUser {
+			parent = l.Name
+		}
+	}
+	if parent == "" {
+		return nil
+	}
+
+	var ids []string
+	for _, l := range s.labels {
+		if l.Type == domain.LabelTypeUser && strings.HasPrefix(l.Name, parent+domain.LabelSeparator) {
+			ids = append(ids, l.ID)
+		}
+	}
+	return ids
+}
+
+// toggleAt expands or collapses the tree node under the cursor.
+func (s *sidebarModel) toggleAt(items []sidebarItem) {
+	if s.cursor < 0 || s.cursor >= len(items) || !items[s.cursor].hasChildren {
+		return
+	}
+	if s.collapsed == nil {
+		s.collapsed = make(map[string]bool)
 	}
-	return all[s.cursor].ID, true
+	path := items[s.cursor].path
+	s.collapsed[path] = !s.collapsed[path]
 }
 
 // displayName returns the human-friendly name for a label.
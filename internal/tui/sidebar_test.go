@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+func TestSidebar_NestedLabelsCollapse(t *testing.T) {
+	s := newSidebar()
+	s.focused = true
+	s.SetLabels([]domain.Label{
+		{ID: domain.LabelInbox, Name: "INBOX", Type: domain.LabelTypeSystem},
+		{ID: "L1", Name: "Work", Type: domain.LabelTypeUser},
+		{ID: "L2", Name: "Work/ProjectA", Type: domain.LabelTypeUser},
+		{ID: "L3", Name: "Travel/2024", Type: domain.LabelTypeUser},
+	})
+
+	names := func() []string {
+		var out []string
+		for _, it := range s.items() {
+			out = append(out, it.name)
+		}
+		return out
+	}
+	if got := names(); len(got) != 5 {
+		t.Fatalf("items = %v, want 5 rows", got)
+	}
+
+	// Enter on the implicit "Travel" parent collapses it rather than selecting.
+	s.cursor = 1
+	if s.items()[1].labelID != "" {
+		t.Fatalf("row 1 = %+v, want implicit Travel parent", s.items()[1])
+	}
+	var cmd tea.Cmd
+	s, cmd = s.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd != nil {
+		t.Error("enter on an implicit parent should not select a label")
+	}
+	if got := names(); len(got) != 4 {
+		t.Errorf("after collapsing Travel items = %v, want 4 rows", got)
+	}
+
+	// Space collapses a real label; Enter on it still selects.
+	s.cursor = 2
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	if got := names(); len(got) != 3 {
+		t.Errorf("after collapsing Work items = %v, want 3 rows", got)
+	}
+	s, cmd = s.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("enter on a real label should select it")
+	}
+	if msg, ok := cmd().(labelSelectedMsg); !ok || msg.labelID != "L1" {
+		t.Errorf("selected = %+v, want L1", msg)
+	}
+}
+
+func treeSidebar() sidebarModel {
+	s := newSidebar()
+	s.focused = true
+	s.SetLabels([]domain.Label{
+		{ID: domain.LabelInbox, Name: "INBOX", Type: domain.LabelTypeSystem},
+		{ID: "L1", Name: "Work", Type: domain.LabelTypeUser},
+		{ID: "L2", Name: "Work/ProjectA", Type: domain.LabelTypeUser},
+		{ID: "L3", Name: "Work/ProjectA/Specs", Type: domain.LabelTypeUser},
+		{ID: "L4", Name: "Work/ProjectB", Type: domain.LabelTypeUser},
+		{ID: "L5", Name: "Zeta", Type: domain.LabelTypeUser},
+	})
+	return s
+}
+
+func press(s sidebarModel, msgs ...tea.KeyMsg) sidebarModel {
+	for _, m := range msgs {
+		s, _ = s.Update(m)
+	}
+	return s
+}
+
+var (
+	keyDown  = tea.KeyMsg{Type: tea.KeyDown}
+	keyUp    = tea.KeyMsg{Type: tea.KeyUp}
+	keyLeft  = tea.KeyMsg{Type: tea.KeyLeft}
+	keyRight = tea.KeyMsg{Type: tea.KeyRight}
+)
+
+func TestSidebar_NavigateExpandedTree(t *testing.T) {
+	s := treeSidebar()
+	// INBOX, Work, ProjectA, Specs, ProjectB, Zeta
+	want := []string{domain.LabelInbox, "L1", "L2", "L3", "L4", "L5"}
+	for i, id := range want {
+		if got := s.items()[s.cursor].labelID; got != id {
+			t.Fatalf("step %d: cursor on %q, want %q", i, got, id)
+		}
+		s = press(s, keyDown)
+	}
+	if s.cursor != 0 {
+		t.Errorf("cursor = %d, want wrap to 0", s.cursor)
+	}
+	s = press(s, keyUp)
+	if got := s.items()[s.cursor].labelID; got != "L5" {
+		t.Errorf("up from top: cursor on %q, want L5", got)
+	}
+}
+
+func TestSidebar_NavigateCollapsedTree(t *testing.T) {
+	s := treeSidebar()
+
+	// Collapse Work: its subtree disappears from navigation.
+	s.cursor = 1
+	s = press(s, keyLeft)
+	if n := len(s.items()); n != 3 {
+		t.Fatalf("items after collapse = %d, want 3", n)
+	}
+	s = press(s, keyDown)
+	if got := s.items()[s.cursor].labelID; got != "L5" {
+		t.Errorf("down over collapsed Work: cursor on %q, want L5", got)
+	}
+
+	// Right expands, right again steps into the first child.
+	s = press(s, keyUp, keyRight)
+	if n := len(s.items()); n != 6 {
+		t.Fatalf("items after expand = %d, want 6", n)
+	}
+	s = press(s, keyRight)
+	if got := s.items()[s.cursor].labelID; got != "L2" {
+		t.Errorf("right on expanded Work: cursor on %q, want L2", got)
+	}
+
+	// Left from a leaf jumps to its parent.
+	s = press(s, keyDown, keyLeft)
+	if got := s.items()[s.cursor].labelID; got != "L2" {
+		t.Errorf("left from Specs: cursor on %q, want L2", got)
+	}
+}
+
+func TestSidebar_SetLabelsClampsCursor(t *testing.T) {
+	s := treeSidebar()
+	s.cursor = 5
+	s.SetLabels([]domain.Label{{ID: domain.LabelInbox, Name: "INBOX", Type: domain.LabelTypeSystem}})
+	if s.cursor != 0 {
+		t.Errorf("cursor = %d, want 0", s.cursor)
+	}
+}
+
+func TestSidebar_DescendantLabelIDs(t *testing.T) {
+	s := treeSidebar()
+	if got := s.descendantLabelIDs("L1"); len(got) != 3 {
+		t.Errorf("descendants of Work = %v, want L2, L3, L4", got)
+	}
+	if got := s.descendantLabelIDs("L5"); len(got) != 0 {
+		t.Errorf("descendants of Zeta = %v, want none", got)
+	}
+	if got := s.descendantLabelIDs(domain.LabelInbox); got != nil {
+		t.Errorf("descendants of INBOX = %v, want nil", got)
+	}
+}
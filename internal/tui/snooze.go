@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+// defaultSnoozeCheck is how often snoozed mail is checked for expiry when
+// auto-reload is disabled.
+const defaultSnoozeCheck = time.Minute
+
+// snoozeMsg requests the snooze prompt for an email, or for a whole thread
+// when threadID is set.
+type snoozeMsg struct {
+	emailID  string
+	threadID string
+}
+
+// snoozeRequestMsg is emitted when the prompt is confirmed.
+type snoozeRequestMsg struct {
+	target snoozeMsg
+	until  time.Time
+}
+
+type closeSnoozeMsg struct{}
+
+type snoozeDoneMsg struct {
+	until time.Time
+}
+
+// snoozeTickMsg triggers a check for snoozes that have expired.
+type snoozeTickMsg struct{}
+
+// snoozeWokenMsg reports how many snoozed emails resurfaced.
+type snoozeWokenMsg struct {
+	count int
+}
+
+// snoozePromptModel is a one-line prompt for a snooze duration.
+type snoozePromptModel struct {
+	input   textinput.Model
+	target  snoozeMsg
+	visible bool
+	err     string
+	width   int
+
+	styles styles
+}
+
+func newSnoozePrompt() snoozePromptModel {
+	ti := textinput.New()
+	ti.Placeholder = "2h, 3d, or 2006-01-02T15:04:05Z07:00"
+	ti.Prompt = "Snooze for: "
+	ti.CharLimit = 64
+	return snoozePromptModel{input: ti, styles: defaultStyles()}
+}
+
+// Open shows the prompt for the given target.
+func (s *snoozePromptModel) Open(target snoozeMsg) {
+	s.target = target
+	s.visible = true
+	s.err = ""
+	s.input.SetValue("")
+	s.input.Focus()
+}
+
+// Close hides the prompt.
+func (s *snoozePromptModel) Close() {
+	s.visible = false
+	s.input.Blur()
+}
+
+// SetSize updates the width available for rendering.
+func (s *snoozePromptModel) SetSize(w int) {
+	s.width = w
+}
+
+// IsVisible reports whether the prompt is shown.
+func (s snoozePromptModel) IsVisible() bool {
+	return s.visible
+}
+
+func (s snoozePromptModel) Update(msg tea.Msg) (snoozePromptModel, tea.Cmd) {
+	if !s.visible {
+		return s, nil
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, keys.Back):
+			return s, func() tea.Msg { return closeSnoozeMsg{} }
+
+		case key.Matches(msg, keys.Enter):
+			until, err := domain.ParseSnoozeUntil(s.input.Value(), time.Now())
+			if err != nil {
+				s.err = err.Error()
+				return s, nil
+			}
+			req := snoozeRequestMsg{target: s.target, until: until}
+			return s, func() tea.Msg { return req }
+		}
+	}
+
+	var cmd tea.Cmd
+	s.input, cmd = s.input.Update(msg)
+	return s, cmd
+}
+
+func (s snoozePromptModel) View() string {
+	if !s.visible {
+		return ""
+	}
+	line := s.input.View()
+	if s.err != "" {
+		line += "  " + s.styles.errorText.Render(s.err)
+	}
+	return s.styles.statusBar.Width(s.width).Render(line)
+}
+
+// snoozeTickCmd schedules the next expired-snooze check.
+func (m model) snoozeTickCmd() tea.Cmd {
+	interval := m.reloadInterval
+	if interval <= 0 {
+		interval = defaultSnoozeCheck
+	}
+	return tea.Tick(interval, func(time.Time) tea.Msg { return snoozeTickMsg{} })
+}
+
+// wakeSnoozedCmd clears expired snoozes so the mail returns to the inbox.
+// Errors are ignored; the next tick simply tries again.
+func (m model) wakeSnoozedCmd() tea.Cmd {
+	return func() tea.Msg {
+		n, err := m.store.WakeSnoozed(context.Background(), m.accountID, time.Now())
+		if err != nil {
+			return snoozeWokenMsg{}
+		}
+		return snoozeWokenMsg{count: n}
+	}
+}
+
+// snoozeCmd snoozes an email, or every message of a thread.
+func (m model) snoozeCmd(req snoozeRequestMsg) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+
+		ids := []string{req.target.emailID}
+		if req.target.threadID != "" {
+			thread, err := m.store.GetThread(ctx, req.target.threadID, m.accountID)
+			if err != nil {
+				return errMsg{err: fmt.Errorf("failed to load thread: %w", err)}
+			}
+			ids = ids[:0]
+			for _, e := range thread.Messages {
+				ids = append(ids, e.ID)
+			}
+		}
+		for _, id := range ids {
+			if err := m.store.SnoozeEmail(ctx, id, req.until); err != nil {
+				return errMsg{err: err}
+			}
+		}
+		return snoozeDoneMsg{until: req.until}
+	}
+}
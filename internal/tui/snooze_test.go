@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSnoozePrompt(t *testing.T) {
+	s := newSnoozePrompt()
+	s.Open(snoozeMsg{threadID: "thread-1"})
+
+	s.input.SetValue("soon")
+	s, cmd := s.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd != nil || s.err == "" {
+		t.Fatalf("invalid input should set an error, got err=%q cmd=%v", s.err, cmd)
+	}
+
+	s.input.SetValue("2h")
+	s, cmd = s.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("valid input should emit a command")
+	}
+	req, ok := cmd().(snoozeRequestMsg)
+	if !ok {
+		t.Fatalf("got %T, want snoozeRequestMsg", cmd())
+	}
+	if req.target.threadID != "thread-1" {
+		t.Errorf("target = %+v, want thread-1", req.target)
+	}
+	if d := time.Until(req.until); d < time.Hour || d > 2*time.Hour {
+		t.Errorf("until is %v from now, want about 2h", d)
+	}
+
+	_, cmd = s.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if _, ok := cmd().(closeSnoozeMsg); !ok {
+		t.Error("esc should close the prompt")
+	}
+}
@@ -8,10 +8,17 @@ type statusBar struct {
 	isError       bool
 	multiAccount  bool
 	readerVisible bool
+
+	// syncFetched and syncTotal track a running initial sync; a zero
+	// syncTotal hides the progress bar.
+	syncFetched int
+	syncTotal   int
+
+	styles styles
 }
 
 func newStatusBar() statusBar {
-	return statusBar{message: "Ready"}
+	return statusBar{message: "Ready", styles: defaultStyles()}
 }
 
 func (s *statusBar) setMessage(msg string) {
@@ -24,13 +31,26 @@ func (s *statusBar) setError(msg string) {
 	s.isError = true
 }
 
+// setProgress shows the initial sync progress ahead of the message.
+func (s *statusBar) setProgress(fetched, total int) {
+	s.syncFetched, s.syncTotal = fetched, total
+}
+
+// clearProgress hides the progress bar.
+func (s *statusBar) clearProgress() {
+	s.syncFetched, s.syncTotal = 0, 0
+}
+
 func (s statusBar) View() string {
-	msgStyle := statusBarStyle
+	msgStyle := s.styles.statusBar
 	if s.isError {
-		msgStyle = msgStyle.Foreground(errorColor)
+		msgStyle = msgStyle.Foreground(s.styles.theme.Error)
 	}
 
 	left := s.message
+	if s.syncTotal > 0 {
+		left = renderProgress(s.syncFetched, s.syncTotal) + "  " + left
+	}
 	shortcuts := s.shortcuts()
 
 	gap := s.width - lipgloss.Width(left) - lipgloss.Width(shortcuts) - 2
@@ -38,7 +58,7 @@ func (s statusBar) View() string {
 		gap = 0
 	}
 
-	content := left + lipgloss.NewStyle().Width(gap).Render("") + mutedTextStyle.Render(shortcuts)
+	content := left + lipgloss.NewStyle().Width(gap).Render("") + s.styles.mutedText.Render(shortcuts)
 	return msgStyle.Width(s.width).Render(content)
 }
 
@@ -50,7 +70,7 @@ func (s statusBar) shortcuts() string {
 		}
 		return base
 	}
-	base := "j/k:nav  enter:open  c:compose  /:search"
+	base := "j/k:nav  enter:open  c:compose  /:search  ?:help"
 	if s.multiAccount {
 		return base + "  @:account"
 	}
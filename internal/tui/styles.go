@@ -2,48 +2,86 @@ package tui
 
 import "github.com/charmbracelet/lipgloss"
 
-var (
-	primaryColor   = lipgloss.Color("#7C3AED")
-	secondaryColor = lipgloss.Color("#6366F1")
-	mutedColor     = lipgloss.Color("#6B7280")
-	accentColor    = lipgloss.Color("#F59E0B")
-	errorColor     = lipgloss.Color("#EF4444")
-	successColor   = lipgloss.Color("#10B981")
-
-	sidebarStyle = lipgloss.NewStyle().
+// styles holds the lipgloss styles shared by the sub-models, all derived
+// from a Theme.
+type styles struct {
+	theme Theme
+
+	sidebar   lipgloss.Style
+	list      lipgloss.Style
+	reader    lipgloss.Style
+	statusBar lipgloss.Style
+	title     lipgloss.Style
+	selected  lipgloss.Style
+	unread    lipgloss.Style
+	star      lipgloss.Style
+	mutedText lipgloss.Style
+	flag      lipgloss.Style
+	labelChip lipgloss.Style
+	errorText lipgloss.Style
+	match     lipgloss.Style
+}
+
+// newStyles builds the TUI styles from theme t.
+func newStyles(t Theme) styles {
+	return styles{
+		theme: t,
+
+		sidebar: lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(mutedColor).
-			Padding(1, 1)
+			BorderForeground(t.Muted).
+			Padding(1, 1),
 
-	listStyle = lipgloss.NewStyle().
+		list: lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(mutedColor).
-			Padding(0, 1)
+			BorderForeground(t.Muted).
+			Padding(0, 1),
 
-	readerStyle = lipgloss.NewStyle().
+		reader: lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(mutedColor).
-			Padding(1, 2)
+			BorderForeground(t.Muted).
+			Padding(1, 2),
+
+		statusBar: lipgloss.NewStyle().
+			Background(t.StatusBarBg).
+			Foreground(t.StatusBarFg).
+			Padding(0, 1),
+
+		title: lipgloss.NewStyle().
+			Foreground(t.Primary).
+			Bold(true),
+
+		selected: lipgloss.NewStyle().
+			Background(t.Primary).
+			Foreground(t.SelectedFg),
+
+		unread: lipgloss.NewStyle().
+			Bold(true),
+
+		star: lipgloss.NewStyle().
+			Foreground(t.Accent),
 
-	statusBarStyle = lipgloss.NewStyle().
-			Background(lipgloss.Color("#1F2937")).
-			Foreground(lipgloss.Color("#D1D5DB")).
-			Padding(0, 1)
+		mutedText: lipgloss.NewStyle().
+			Foreground(t.Muted),
 
-	titleStyle = lipgloss.NewStyle().
-			Foreground(primaryColor).
-			Bold(true)
+		flag: lipgloss.NewStyle().
+			Foreground(t.Secondary),
 
-	selectedStyle = lipgloss.NewStyle().
-			Background(primaryColor).
-			Foreground(lipgloss.Color("#FFFFFF"))
+		labelChip: lipgloss.NewStyle().
+			Background(t.Muted).
+			Foreground(t.SelectedFg),
 
-	unreadStyle = lipgloss.NewStyle().
-			Bold(true)
+		errorText: lipgloss.NewStyle().
+			Foreground(t.Error),
 
-	starStyle = lipgloss.NewStyle().
-			Foreground(accentColor)
+		match: lipgloss.NewStyle().
+			Background(t.Accent).
+			Foreground(t.MatchFg),
+	}
+}
 
-	mutedTextStyle = lipgloss.NewStyle().
-			Foreground(mutedColor)
-)
+// defaultStyles returns the styles of DefaultTheme, used by sub-models
+// until the root model applies the configured theme.
+func defaultStyles() styles {
+	return newStyles(themes[DefaultTheme])
+}
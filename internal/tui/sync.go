@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lu-zhengda/termail/internal/app"
+)
+
+// syncProgressMsg reports how far the initial sync has got. updates
+// delivers the next progress or initialSyncDoneMsg.
+type syncProgressMsg struct {
+	fetched int
+	total   int
+	updates <-chan tea.Msg
+}
+
+// initialSyncDoneMsg is sent once the initial sync finishes or fails.
+type initialSyncDoneMsg struct {
+	fetched int
+	err     error
+}
+
+// progressBarWidth is the number of cells in the status bar progress bar.
+const progressBarWidth = 20
+
+// initialSyncCmd fills an account that has never been synced, such as on
+// the first run, with its newest sync.initial_count messages. The sync runs
+// in the background and reports its progress as syncProgressMsg, so the
+// TUI stays responsive, including to quit.
+func (m model) initialSyncCmd() tea.Cmd {
+	if m.provider == nil || m.authRequired {
+		return nil
+	}
+	s, p, accountID, count := m.store, m.provider, m.accountID, m.cfg.Sync.InitialCount
+	maxBody, threadByRefs := m.cfg.Sync.MaxBodyBytes, m.cfg.Sync.ThreadByReferences
+	return func() tea.Msg {
+		ctx := context.Background()
+		state, err := s.GetSyncState(ctx, accountID)
+		if err != nil || state.LastSync != 0 {
+			return nil
+		}
+
+		updates := make(chan tea.Msg, 1)
+		go func() {
+			defer close(updates)
+			svc := app.NewSyncService(s, p, accountID)
+			svc.SetMaxBodyBytes(maxBody)
+			svc.SetThreadByReferences(threadByRefs)
+			svc.OnProgress(func(fetched, total int) {
+				updates <- syncProgressMsg{fetched: fetched, total: total, updates: updates}
+			})
+			res, err := svc.FullSync(ctx, count, nil)
+			if err != nil {
+				updates <- initialSyncDoneMsg{err: err}
+				return
+			}
+			updates <- initialSyncDoneMsg{fetched: res.Fetched}
+		}()
+		return <-updates
+	}
+}
+
+// waitSyncCmd waits for the next message from a running initial sync.
+func waitSyncCmd(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+// renderProgress draws "[████░░░░] fetched/total" for the status bar.
+func renderProgress(fetched, total int) string {
+	filled := 0
+	if total > 0 {
+		filled = min(progressBarWidth, fetched*progressBarWidth/total)
+	}
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+	return fmt.Sprintf("Syncing [%s] %d/%d", bar, fetched, total)
+}
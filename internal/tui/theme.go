@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DefaultTheme is the theme used when config.UI.Theme is empty.
+const DefaultTheme = "default"
+
+// Theme is a named color palette from which all TUI styles are derived.
+type Theme struct {
+	Primary   lipgloss.Color
+	Secondary lipgloss.Color
+	Muted     lipgloss.Color
+	Accent    lipgloss.Color
+	Error     lipgloss.Color
+	Success   lipgloss.Color
+
+	StatusBarBg lipgloss.Color
+	StatusBarFg lipgloss.Color
+	// SelectedFg is the text color on the Primary selection highlight.
+	SelectedFg lipgloss.Color
+	// MatchFg is the text color on the Accent search-match highlight.
+	MatchFg lipgloss.Color
+}
+
+// themes holds the built-in themes by name.
+var themes = map[string]Theme{
+	"default": {
+		Primary:     "#7C3AED",
+		Secondary:   "#6366F1",
+		Muted:       "#6B7280",
+		Accent:      "#F59E0B",
+		Error:       "#EF4444",
+		Success:     "#10B981",
+		StatusBarBg: "#1F2937",
+		StatusBarFg: "#D1D5DB",
+		SelectedFg:  "#FFFFFF",
+		MatchFg:     "#000000",
+	},
+	"solarized": {
+		Primary:     "#268BD2",
+		Secondary:   "#6C71C4",
+		Muted:       "#586E75",
+		Accent:      "#B58900",
+		Error:       "#DC322F",
+		Success:     "#859900",
+		StatusBarBg: "#073642",
+		StatusBarFg: "#93A1A1",
+		SelectedFg:  "#FDF6E3",
+		MatchFg:     "#002B36",
+	},
+	"gruvbox": {
+		Primary:     "#FE8019",
+		Secondary:   "#83A598",
+		Muted:       "#928374",
+		Accent:      "#FABD2F",
+		Error:       "#FB4934",
+		Success:     "#B8BB26",
+		StatusBarBg: "#3C3836",
+		StatusBarFg: "#EBDBB2",
+		SelectedFg:  "#282828",
+		MatchFg:     "#282828",
+	},
+	"nord": {
+		Primary:     "#5E81AC",
+		Secondary:   "#B48EAD",
+		Muted:       "#4C566A",
+		Accent:      "#EBCB8B",
+		Error:       "#BF616A",
+		Success:     "#A3BE8C",
+		StatusBarBg: "#3B4252",
+		StatusBarFg: "#D8DEE9",
+		SelectedFg:  "#ECEFF4",
+		MatchFg:     "#2E3440",
+	},
+}
+
+// ThemeNames returns the names of the built-in themes, sorted.
+func ThemeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// LookupTheme returns the built-in theme with the given name. An empty name
+// selects DefaultTheme.
+func LookupTheme(name string) (Theme, error) {
+	if name == "" {
+		name = DefaultTheme
+	}
+	t, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(ThemeNames(), ", "))
+	}
+	return t, nil
+}
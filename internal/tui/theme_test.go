@@ -0,0 +1,56 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lu-zhengda/termail/internal/config"
+)
+
+func TestThemes_DefineEveryColor(t *testing.T) {
+	for _, name := range []string{"default", "solarized", "gruvbox", "nord"} {
+		theme, err := LookupTheme(name)
+		if err != nil {
+			t.Fatalf("LookupTheme(%q) error: %v", name, err)
+		}
+		v := reflect.ValueOf(theme)
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			if c, ok := v.Field(i).Interface().(lipgloss.Color); ok && c == "" {
+				t.Errorf("theme %q does not define %s", name, field.Name)
+			}
+		}
+	}
+}
+
+func TestLookupTheme(t *testing.T) {
+	if _, err := LookupTheme(""); err != nil {
+		t.Errorf("LookupTheme(\"\") error: %v, want the default theme", err)
+	}
+	_, err := LookupTheme("neon")
+	if err == nil || !strings.Contains(err.Error(), "neon") || !strings.Contains(err.Error(), "gruvbox") {
+		t.Errorf("LookupTheme(neon) error = %v, want it to name the theme and list the available ones", err)
+	}
+}
+
+func TestNewModel_AppliesTheme(t *testing.T) {
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("config.Load() error: %v", err)
+	}
+	cfg.UI.Theme = "nord"
+	m := NewModel(cfg, nil, nil, "a@example.com", nil, nil)
+
+	nord := themes["nord"]
+	for name, st := range map[string]styles{
+		"root": m.styles, "inbox": m.inbox.styles, "sidebar": m.sidebar.styles,
+		"reader": m.reader.styles, "composer": m.composer.styles, "search": m.search.styles,
+		"help": m.help.styles, "snooze": m.snooze.styles, "statusBar": m.statusBar.styles,
+	} {
+		if st.theme != nord {
+			t.Errorf("%s styles use %+v, want nord", name, st.theme)
+		}
+	}
+}
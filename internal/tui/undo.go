@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/lu-zhengda/termail/internal/app"
+	"github.com/lu-zhengda/termail/internal/domain"
+)
+
+// undoWindow is how long an archive, delete or spam report can be undone. Sends use the
+// configured send delay instead.
+const undoWindow = 5 * time.Second
+
+// maxUndoEntries bounds the undo stack.
+const maxUndoEntries = 10
+
+// undoEntry records a destructive action or a pending send so it can be
+// reversed.
+type undoEntry struct {
+	emailID    string
+	action     string
+	prevLabels []string
+	expires    time.Time
+
+	// outboxID and email identify a send held in the outbox.
+	outboxID int64
+	email    *domain.Email
+
+	// batch holds the per-email entries of an action applied to several
+	// selected emails, which are undone together.
+	batch []undoEntry
+}
+
+// undoExpiredMsg is sent when the undo window for an entry closes.
+type undoExpiredMsg struct{}
+
+// undoStack is a small LIFO of recent undoable actions.
+type undoStack struct {
+	entries []undoEntry
+}
+
+// push adds an entry, dropping the oldest when the stack is full.
+func (s *undoStack) push(e undoEntry) {
+	s.entries = append(s.entries, e)
+	if len(s.entries) > maxUndoEntries {
+		s.entries = s.entries[len(s.entries)-maxUndoEntries:]
+	}
+}
+
+// pop removes and returns the most recent entry that is still within its
+// undo window at now. Expired entries are discarded.
+func (s *undoStack) pop(now time.Time) (undoEntry, bool) {
+	s.prune(now)
+	if len(s.entries) == 0 {
+		return undoEntry{}, false
+	}
+	e := s.entries[len(s.entries)-1]
+	s.entries = s.entries[:len(s.entries)-1]
+	return e, true
+}
+
+// prune discards entries whose undo window has closed.
+func (s *undoStack) prune(now time.Time) {
+	live := s.entries[:0]
+	for _, e := range s.entries {
+		if now.Before(e.expires) {
+			live = append(live, e)
+		}
+	}
+	s.entries = live
+}
+
+// clear empties the stack.
+func (s *undoStack) clear() {
+	s.entries = nil
+}
+
+// len reports the number of entries, including any not yet pruned.
+func (s *undoStack) len() int {
+	return len(s.entries)
+}
+
+// undoable reports whether action moves mail out of the current view and
+// can be reversed with undo.
+func undoable(action string) bool {
+	switch action {
+	case "archive", "delete", "trash", "untrash", "spam", "notspam":
+		return true
+	}
+	return false
+}
+
+// undoLabelChanges returns the label changes that reverse entry's action.
+func undoLabelChanges(e undoEntry) (add, remove []string) {
+	switch e.action {
+	case "archive":
+		return []string{domain.LabelInbox}, nil
+	case "spam", "notspam":
+		remove, add = app.SpamLabels(e.action == "spam")
+		return add, remove
+	case "delete", "trash":
+		for _, l := range e.prevLabels {
+			if l != domain.LabelTrash {
+				add = append(add, l)
+			}
+		}
+		return add, []string{domain.LabelTrash}
+	case "untrash":
+		return app.TrashLabels(true)
+	}
+	return nil, nil
+}
+
+// undoPastTense returns the status-bar verb for an undoable action.
+func undoPastTense(action string) string {
+	switch action {
+	case "delete", "trash":
+		return "Trashed"
+	case "spam":
+		return "Reported as spam"
+	case "notspam":
+		return "Marked not spam"
+	case "untrash":
+		return "Restored from trash"
+	}
+	return "Archived"
+}
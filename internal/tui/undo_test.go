@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUndoStack_PopReturnsMostRecent(t *testing.T) {
+	now := time.Now()
+	var s undoStack
+	s.push(undoEntry{emailID: "m1", action: "archive", expires: now.Add(undoWindow)})
+	s.push(undoEntry{emailID: "m2", action: "delete", expires: now.Add(undoWindow)})
+
+	e, ok := s.pop(now)
+	if !ok || e.emailID != "m2" {
+		t.Fatalf("pop() = %+v, %v; want m2", e, ok)
+	}
+	e, ok = s.pop(now)
+	if !ok || e.emailID != "m1" {
+		t.Fatalf("pop() = %+v, %v; want m1", e, ok)
+	}
+	if _, ok := s.pop(now); ok {
+		t.Error("pop() on empty stack should report false")
+	}
+}
+
+func TestUndoStack_ExpiredEntriesDiscarded(t *testing.T) {
+	now := time.Now()
+	var s undoStack
+	s.push(undoEntry{emailID: "m1", action: "archive", expires: now.Add(time.Second)})
+
+	if _, ok := s.pop(now.Add(2 * time.Second)); ok {
+		t.Error("pop() should not return an entry past its undo window")
+	}
+	if s.len() != 0 {
+		t.Errorf("len() = %d after pruning, want 0", s.len())
+	}
+}
+
+func TestUndoStack_BoundedAndClear(t *testing.T) {
+	now := time.Now()
+	var s undoStack
+	for i := 0; i < maxUndoEntries+3; i++ {
+		s.push(undoEntry{emailID: "m", expires: now.Add(undoWindow)})
+	}
+	if s.len() != maxUndoEntries {
+		t.Errorf("len() = %d, want %d", s.len(), maxUndoEntries)
+	}
+	s.clear()
+	if s.len() != 0 {
+		t.Errorf("len() = %d after clear, want 0", s.len())
+	}
+}
+
+func TestUndoLabelChanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		entry       undoEntry
+		add, remove []string
+	}{
+		{
+			name:  "archive re-adds inbox",
+			entry: undoEntry{action: "archive", prevLabels: []string{"INBOX", "UNREAD"}},
+			add:   []string{"INBOX"},
+		},
+		{
+			name:   "delete untrashes and restores labels",
+			entry:  undoEntry{action: "delete", prevLabels: []string{"INBOX", "Work"}},
+			add:    []string{"INBOX", "Work"},
+			remove: []string{"TRASH"},
+		},
+		{
+			name:   "spam moves back to inbox",
+			entry:  undoEntry{action: "spam", prevLabels: []string{"INBOX"}},
+			add:    []string{"INBOX"},
+			remove: []string{"SPAM"},
+		},
+		{
+			name:   "not spam moves back to spam",
+			entry:  undoEntry{action: "notspam", prevLabels: []string{"SPAM"}},
+			add:    []string{"SPAM"},
+			remove: []string{"INBOX"},
+		},
+		{
+			name:   "untrash moves back to trash",
+			entry:  undoEntry{action: "untrash", prevLabels: []string{"TRASH"}},
+			add:    []string{"TRASH"},
+			remove: []string{"INBOX"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			add, remove := undoLabelChanges(tt.entry)
+			if !reflect.DeepEqual(add, tt.add) || !reflect.DeepEqual(remove, tt.remove) {
+				t.Errorf("undoLabelChanges() = %v, %v; want %v, %v", add, remove, tt.add, tt.remove)
+			}
+		})
+	}
+}